@@ -5,9 +5,13 @@ import (
 	"awesome-sharing/internal/config"
 	"awesome-sharing/internal/database"
 	"awesome-sharing/internal/initialization"
+	"awesome-sharing/internal/middleware"
 	"awesome-sharing/internal/services"
+	"context"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -23,8 +27,15 @@ func main() {
 	log.Printf("Config directory: %s", cfg.ConfigDir)
 	log.Printf("Upload directory: %s", cfg.UploadDir)
 	log.Printf("Database path: %s", cfg.DBPath)
+	log.Printf("Temp directory: %s", cfg.TempDir)
 	log.Println("")
 
+	// Route every temp file through the configured directory: os.CreateTemp("", ...)
+	// (multipart upload spooling, HEIC conversion) reads TMPDIR
+	if err := os.Setenv("TMPDIR", cfg.TempDir); err != nil {
+		log.Printf("Warning: could not set TMPDIR: %v", err)
+	}
+
 	// Initialize database
 	db, err := database.Initialize(cfg.DBPath)
 	if err != nil {
@@ -35,16 +46,43 @@ func main() {
 
 	// Initialize all services first (before any data operations)
 	log.Println("\nInitializing services...")
+	eventDispatcher := services.NewEventDispatcher(db.DB)
+	eventDispatcher.Start()
+	jobQueue := services.NewJobQueue(db.DB, eventDispatcher)
 	authService := services.NewAuthService(db.DB)
+	authService.SetIdleTimeout(cfg.IdleTimeout)
 	settingsService := services.NewSettingsService(db.DB)
+	authService.SetRegistrationDefaults(settingsService)
 	folderService := services.NewFolderService(db.DB)
 	permissionGroupService := services.NewPermissionGroupService(db.DB)
 	albumService := services.NewAlbumService(db.DB)
-	shareService := services.NewShareService(db.DB)
+	shareService := services.NewShareService(db.DB, eventDispatcher)
 	domainConfigService := services.NewDomainConfigService(db)
-	scanner := services.NewFileScanner(db, folderService, cfg.ThumbsDir)
+	scanner := services.NewFileScanner(db, folderService, cfg.ThumbsDir, eventDispatcher)
+	scanner.SetPerceptualHashing(cfg.PerceptualHash)
+	scanner.SetMaxDecodePixels(cfg.MaxThumbnailSourcePixels)
 	thumbService := services.NewThumbnailService(cfg.ThumbsDir)
-	validatorService := services.NewFileValidatorService(db.DB, folderService)
+	for size, dir := range cfg.ThumbSizeDirs {
+		thumbService.SetSizeDir(size, dir)
+	}
+	thumbService.SetMaxSourcePixels(cfg.MaxThumbnailSourcePixels)
+	thumbService.SetPlaceholderDir(cfg.ThumbnailPlaceholderDir)
+	thumbService.SetMaxConcurrentGenerations(cfg.ThumbnailConcurrency)
+	thumbService.SetRegenerateOnChange(cfg.ThumbnailRegenerateOnChange)
+	thumbService.SetHEICConverter(cfg.HEICConvertCommand)
+	if err := thumbService.SetBackground(cfg.ThumbnailBackground); err != nil {
+		log.Printf("Warning: THUMBNAIL_BACKGROUND %q: %v, using white", cfg.ThumbnailBackground, err)
+	}
+	thumbService.SetContentHashes(services.NewContentHashService(db.DB))
+	scanner.SetThumbnailPregeneration(thumbService, settingsService)
+	validatorService := services.NewFileValidatorService(db.DB, folderService, settingsService, eventDispatcher)
+	fileStatsService := services.NewFileStatsService(db.DB)
+	photoMetadataService := services.NewPhotoMetadataService(db.DB)
+	preferenceService := services.NewUserPreferenceService(db.DB)
+	metadataStripper := services.NewMetadataStripper(filepath.Join(cfg.ThumbsDir, "stripped"))
+	metadataStripper.SetMaxSourcePixels(cfg.MaxThumbnailSourcePixels)
+	jobQueue.SetFileDir(cfg.TempDir)
+	jobQueue.Start(2)
 	log.Println("✓ All services initialized")
 
 	// Initialize default data (admin user, migrate mount points)
@@ -56,37 +94,49 @@ func main() {
 	// Initialize default mount points (legacy support)
 	initializeMountPoints(db, cfg)
 
+	// Guest grants follow the guest permission group setting, which requests no longer
+	// check; sync them in case the database was changed while the server was down
+	if groupID, err := settingsService.GetGuestPermissionGroupID(); err != nil {
+		log.Printf("Warning: Failed to read the guest permission group: %v", err)
+	} else if err := permissionGroupService.SyncAllGuestAccess(groupID); err != nil {
+		log.Printf("Warning: Failed to sync guest access: %v", err)
+	}
+
+	// Move thumbnails from the old flat layout into sharded subdirectories
+	if moved, err := thumbService.MigrateFlatThumbnails(); err != nil {
+		log.Printf("Warning: Failed to migrate thumbnails: %v", err)
+	} else if moved > 0 {
+		log.Printf("✓ Migrated %d thumbnails to sharded layout", moved)
+	}
+
 	// Wait a moment to ensure all initialization is complete
 	time.Sleep(500 * time.Millisecond)
 
 	// Start periodic scanning in the background (delay first scan)
-	go func() {
-		// Wait 5 seconds before first scan to avoid conflicts
-		time.Sleep(5 * time.Second)
-		log.Println("Starting initial folder scan...")
-		scanner.ScanAllFolders()
-		log.Println("✓ Initial scan complete")
-
-		// Now start periodic scanning
-		ticker := time.NewTicker(30 * time.Minute)
-		defer ticker.Stop()
-		for range ticker.C {
-			scanner.ScanAllFolders()
-		}
-	}()
-	log.Println("✓ Background file scanner scheduled (first scan in 5 seconds)")
+	// The startup scan can be skipped with SCAN_ON_STARTUP=false on large libraries
+	go scanner.RunScheduledScans(context.Background(), cfg.ScanOnStartup, cfg.InitialScanDelay, 30*time.Minute)
+	if cfg.ScanOnStartup {
+		log.Printf("✓ Background file scanner scheduled (first scan in %s)", cfg.InitialScanDelay)
+	} else {
+		log.Println("⚠ Startup scan disabled by SCAN_ON_STARTUP env var (periodic scan in 30 minutes)")
+	}
+
+	// Validation limits also bound cleanups requested through the API
+	validatorService.SetValidationLimits(cfg.ValidationConcurrency, cfg.ValidationTimeout)
 
 	// Start periodic file validation and cleanup in background
 	// Can be disabled with DISABLE_FILE_VALIDATION=true
 	// Run AFTER the initial scan to avoid database lock conflicts
 	if os.Getenv("DISABLE_FILE_VALIDATION") != "true" {
 		go func() {
-			// Wait 30 seconds to let initial scan complete
-			time.Sleep(30 * time.Second)
+			// Wait to let initial scan complete
+			time.Sleep(cfg.InitialValidationDelay)
 			log.Println("Running initial file validation and cleanup...")
-			if count, err := validatorService.CleanupAllInvalidFiles(); err == nil {
-				if count > 0 {
-					log.Printf("✓ Initial cleanup: removed %d missing files", count)
+			if result, err := validatorService.CleanupAllInvalidFiles(context.Background()); err == nil {
+				if result.Partial {
+					log.Printf("✓ Initial cleanup: removed %d missing files, time limit reached (continues next run)", result.Deleted)
+				} else if result.Deleted > 0 {
+					log.Printf("✓ Initial cleanup: removed %d missing files", result.Deleted)
 				} else {
 					log.Println("✓ Initial cleanup: no invalid files found")
 				}
@@ -98,12 +148,12 @@ func main() {
 			ticker := time.NewTicker(6 * time.Hour)
 			defer ticker.Stop()
 			for range ticker.C {
-				if count, err := validatorService.CleanupAllInvalidFiles(); err == nil && count > 0 {
-					log.Printf("✓ Periodic cleanup: removed %d missing files", count)
+				if result, err := validatorService.CleanupAllInvalidFiles(context.Background()); err == nil && result.Deleted > 0 {
+					log.Printf("✓ Periodic cleanup: removed %d missing files", result.Deleted)
 				}
 			}
 		}()
-		log.Println("✓ Background file validator scheduled (first cleanup in 30 seconds, after initial scan)")
+		log.Printf("✓ Background file validator scheduled (first cleanup in %s, after initial scan)", cfg.InitialValidationDelay)
 	} else {
 		log.Println("⚠ File validation disabled by DISABLE_FILE_VALIDATION env var")
 	}
@@ -118,9 +168,58 @@ func main() {
 	}()
 	log.Println("✓ Session cleanup task started (1-hour interval)")
 
+	// Start periodic temp directory cleanup, including leftovers of a previous run
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for {
+			if removed, err := services.CleanupTempDir(cfg.TempDir, cfg.TempMaxAge); err != nil {
+				log.Printf("✗ Temp cleanup failed: %v", err)
+			} else if removed > 0 {
+				log.Printf("✓ Temp cleanup: removed %d stale files", removed)
+			}
+			<-ticker.C
+		}
+	}()
+	log.Printf("✓ Temp cleanup task started (1-hour interval, max age %s)", cfg.TempMaxAge)
+
+	// Start share activity digests when outgoing mail is configured
+	if cfg.SMTPHost != "" {
+		mailer := services.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+		digestService := services.NewShareDigestService(db.DB, mailer)
+		go func() {
+			ticker := time.NewTicker(1 * time.Hour)
+			defer ticker.Stop()
+			for range ticker.C {
+				if sent, err := digestService.SendDueDigests(time.Now()); err != nil {
+					log.Printf("✗ Share digest run failed: %v", err)
+				} else if sent > 0 {
+					log.Printf("✓ Sent %d share digests", sent)
+				}
+			}
+		}()
+		log.Println("✓ Share digest task started (1-hour interval)")
+	}
+
+	// Behind a reverse proxy, c.IP() honors ProxyHeader only for requests from TRUSTED_PROXIES
+	var proxyHeader string
+	if len(cfg.TrustedProxies) > 0 {
+		proxyHeader = cfg.ProxyHeader
+		log.Printf("✓ Trusting %s from proxies: %s", proxyHeader, strings.Join(cfg.TrustedProxies, ", "))
+	}
+
 	// Initialize Fiber app
+	// Bodies over BodyLimit are streamed rather than buffered: BodyLimitMiddleware bounds every
+	// route but uploads, which stream multipart files to disk up to MAX_BODY_SIZE_MB
 	app := fiber.New(fiber.Config{
-		AppName: "AwesomeSharing v2.0",
+		AppName:                      "AwesomeSharing v2.0",
+		BodyLimit:                    cfg.MaxRequestSize,
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+		ProxyHeader:                  proxyHeader,
+		EnableTrustedProxyCheck:      len(cfg.TrustedProxies) > 0,
+		TrustedProxies:               cfg.TrustedProxies,
+		EnableIPValidation:           true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -132,17 +231,37 @@ func main() {
 		},
 	})
 
+	// Subpath deployments: generated URLs carry the prefix, and routes answer with or without it
+	services.SetBasePath(cfg.BasePath)
+	if cfg.BasePath != "" {
+		app.Use(middleware.BasePathMiddleware(cfg.BasePath))
+		log.Printf("✓ Serving under base path %s", cfg.BasePath)
+	}
+
+	app.Use(middleware.BodyLimitMiddleware(cfg.MaxRequestSize, "/api/upload"))
+
 	// Setup all handlers
-	handler := api.NewHandler(db, scanner, thumbService, validatorService, folderService, permissionGroupService)
-	authHandler := api.NewAuthHandler(authService, settingsService)
+	api.SetMaxUploadSize(cfg.MaxBodySize)
+	api.SetQueryTimeout(cfg.QueryTimeout)
+	api.SetDownloadChecksums(cfg.DownloadChecksums)
+	middleware.SetSessionNames(cfg.SessionCookieName, cfg.SessionHeaderName)
+	middleware.SetAllowedOrigins(cfg.AllowedOrigins)
+	middleware.SetSecureCookies(cfg.CookieSecure)
+	handler := api.NewHandler(db, scanner, thumbService, validatorService, folderService, permissionGroupService, fileStatsService, photoMetadataService, settingsService, shareService, albumService, jobQueue)
+	authHandler := api.NewAuthHandler(authService, settingsService, preferenceService)
 	userHandler := api.NewUserHandler(authService)
-	folderHandler := api.NewFolderHandler(folderService, scanner)
+	folderHandler := api.NewFolderHandler(folderService, scanner, jobQueue)
 	permissionGroupHandler := api.NewPermissionGroupHandler(permissionGroupService)
-	albumHandler := api.NewAlbumHandler(albumService)
-	shareHandler := api.NewShareHandler(shareService, settingsService, domainConfigService, db, validatorService)
-	settingsHandler := api.NewSettingsHandler(settingsService)
+	albumHandler := api.NewAlbumHandler(albumService, settingsService)
+	shareHandler := api.NewShareHandler(shareService, settingsService, domainConfigService, db, validatorService, fileStatsService, metadataStripper, albumService)
+	settingsHandler := api.NewSettingsHandler(settingsService, permissionGroupService)
 	domainConfigHandler := api.NewDomainConfigHandlers(domainConfigService)
-	uploadHandler := api.NewUploadHandler(folderService, scanner)
+	uploadHandler := api.NewUploadHandler(folderService, scanner, settingsService)
+	adminHandler := api.NewAdminHandler(authService, shareService, folderService)
+	eventSubscriptionHandler := api.NewEventSubscriptionHandler(eventDispatcher)
+	jobHandler := api.NewJobHandler(jobQueue)
+	eventStreamHandler := api.NewEventStreamHandler(eventDispatcher, permissionGroupService)
+	fileCommentHandler := api.NewFileCommentHandler(services.NewFileCommentService(db.DB), permissionGroupService)
 
 	// Setup routes (v2 with authentication)
 	api.SetupRoutesV2(
@@ -158,6 +277,11 @@ func main() {
 		settingsHandler,
 		domainConfigHandler,
 		uploadHandler,
+		adminHandler,
+		eventSubscriptionHandler,
+		jobHandler,
+		eventStreamHandler,
+		fileCommentHandler,
 		authService,
 		cfg.AllowedOrigin,
 	)
@@ -176,6 +300,10 @@ func main() {
 	log.Println("   Albums:          /api/albums-v2")
 	log.Println("   Shares:          /api/shares")
 	log.Println("   Settings:        /api/settings (admin)")
+	log.Println("   Admin:           /api/admin (server owner)")
+	log.Println("   Webhooks:        /api/event-subscriptions (admin)")
+	log.Println("   Jobs:            /api/jobs/:id")
+	log.Println("   Live events:     /api/ws/events (websocket)")
 	log.Println("   Public:          /api/s/:id (share access)")
 	log.Println("")
 	log.Println("✅ SERVER IS NOW ACCEPTING CONNECTIONS")
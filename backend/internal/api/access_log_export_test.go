@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestExportShareAccessLog(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+	shareService := services.NewShareService(db.DB, nil)
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	admin := seedUser(t, db.DB, "admin", "admin")
+	stranger := seedUser(t, db.DB, "stranger", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+
+	share, err := shareService.CreateShare("file", file, owner.ID, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := shareService.LogAccess(share.ID, nil, fmt.Sprintf("192.0.2.%d", i), "test-agent"); err != nil {
+			t.Fatalf("log access: %v", err)
+		}
+	}
+	if err := shareService.LogAccess(share.ID, &stranger.ID, "192.0.2.9", "test-agent"); err != nil {
+		t.Fatalf("log access: %v", err)
+	}
+
+	appAs := func(u *models.User) *fiber.App {
+		app := fiber.New()
+		app.Get("/api/shares/:id/access-log/export", asUser(u), h.ExportShareAccessLog)
+		return app
+	}
+	path := "/api/shares/" + share.ID + "/access-log/export"
+
+	for _, u := range []*models.User{owner, admin} {
+		resp := sendRequest(t, appAs(u), http.MethodGet, path, "", nil)
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("%s: status %d", u.Username, resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "text/csv" {
+			t.Errorf("%s: content type %q", u.Username, ct)
+		}
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: parse csv: %v", u.Username, err)
+		}
+		// Header plus one row per logged access
+		if len(records) != 5 {
+			t.Fatalf("%s: got %d records, want 5", u.Username, len(records))
+		}
+		if records[4][1] != "192.0.2.9" || records[4][3] != "stranger" {
+			t.Errorf("%s: last row %v", u.Username, records[4])
+		}
+	}
+
+	cases := []struct {
+		name string
+		user *models.User
+		path string
+		want int
+	}{
+		{"stranger", stranger, path, fiber.StatusForbidden},
+		{"anonymous", nil, path, fiber.StatusUnauthorized},
+		{"unknown share", owner, "/api/shares/missing/access-log/export", fiber.StatusNotFound},
+	}
+	for _, tc := range cases {
+		if status, _ := doRequest(t, appAs(tc.user), http.MethodGet, tc.path, "", nil); status != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, status, tc.want)
+		}
+	}
+}
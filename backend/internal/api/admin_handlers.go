@@ -0,0 +1,359 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/services"
+)
+
+type AdminHandler struct {
+	authService   *services.AuthService
+	shareService  *services.ShareService
+	folderService *services.FolderService
+}
+
+func NewAdminHandler(authService *services.AuthService, shareService *services.ShareService, folderService *services.FolderService) *AdminHandler {
+	return &AdminHandler{
+		authService:   authService,
+		shareService:  shareService,
+		folderService: folderService,
+	}
+}
+
+// RotateSecurity regenerates the share token secret and optionally drops all sessions (server owner only)
+// POST /api/admin/security/rotate
+func (h *AdminHandler) RotateSecurity(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	var req struct {
+		DeleteSessions bool `json:"delete_sessions"`
+	}
+
+	// Body is optional; an empty body only rotates the token secret
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	if err := h.shareService.RotateTokenSecret(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to rotate share token secret",
+		})
+	}
+
+	var sessionsDeleted int64
+	if req.DeleteSessions {
+		count, err := h.authService.DeleteAllSessions()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Share token secret rotated, but failed to delete sessions",
+			})
+		}
+		sessionsDeleted = count
+	}
+
+	log.Printf("Security rotation by %s (user ID: %d): share token secret regenerated, %d sessions deleted",
+		user.Username, user.ID, sessionsDeleted)
+	h.authService.LogUserActivity(user.ID, user.ID, "security_rotated",
+		fmt.Sprintf(`{"delete_sessions":%t,"sessions_deleted":%d}`, req.DeleteSessions, sessionsDeleted), c.IP())
+
+	return c.JSON(fiber.Map{
+		"message":          "Security credentials rotated successfully",
+		"sessions_deleted": sessionsDeleted,
+	})
+}
+
+// ListFolderOverlaps reports folders whose paths overlap (one contains the other)
+// GET /api/admin/folders/overlaps
+func (h *AdminHandler) ListFolderOverlaps(c *fiber.Ctx) error {
+	overlaps, err := h.folderService.FindOverlappingFolders()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to detect folder overlaps",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"overlaps": overlaps,
+		"total":    len(overlaps),
+	})
+}
+
+// GetStorageByFolder reports file count and total bytes per folder, largest first
+// GET /api/admin/storage/by-folder
+func (h *AdminHandler) GetStorageByFolder(c *fiber.Ctx) error {
+	report, err := h.folderService.StorageByFolder()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to compute storage report",
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// MergeFolder merges a redundant folder into a folder whose path contains it
+// POST /api/admin/folders/:id/merge
+func (h *AdminHandler) MergeFolder(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid folder ID",
+		})
+	}
+
+	var req struct {
+		TargetFolderID int64 `json:"target_folder_id"`
+		DropGroupLinks bool  `json:"drop_group_links"` // Confirms removing the merged folder's permission group links
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.TargetFolderID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Target folder ID is required",
+		})
+	}
+
+	result, err := h.folderService.MergeFolder(id, req.TargetFolderID, req.DropGroupLinks)
+	if err != nil {
+		switch err {
+		case services.ErrFolderHasGroupLinks:
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":                      "Folder is linked to permission groups; set drop_group_links to merge anyway",
+				"permission_groups_affected": result.PermissionGroupsAffected,
+			})
+		case services.ErrFolderNotFound:
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Folder not found",
+			})
+		case services.ErrFolderNotContained:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to merge folders",
+		})
+	}
+
+	log.Printf("Folder %d merged into folder %d by %s: %d mappings moved, %d duplicates removed",
+		id, req.TargetFolderID, user.Username, result.MappingsMoved, result.DuplicatesRemoved)
+
+	return c.JSON(fiber.Map{
+		"message": "Folders merged successfully",
+		"result":  result,
+	})
+}
+
+// maxBulkMoveFiles limits the number of files moved in one bulk request
+const maxBulkMoveFiles = 1000
+
+// BulkMoveFiles moves files on disk into another folder and updates their mappings
+// POST /api/admin/files/bulk-move
+func (h *AdminHandler) BulkMoveFiles(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	var req struct {
+		FileIDs        []int64 `json:"file_ids"`
+		TargetFolderID int64   `json:"target_folder_id"`
+		Flatten        bool    `json:"flatten"`     // Drop the source relative directories
+		OnConflict     string  `json:"on_conflict"` // skip (default), rename or fail
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.FileIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "file_ids is required",
+		})
+	}
+	if len(req.FileIDs) > maxBulkMoveFiles {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Too many files, maximum is %d", maxBulkMoveFiles),
+		})
+	}
+	if req.TargetFolderID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Target folder ID is required",
+		})
+	}
+
+	result, err := h.folderService.MoveFiles(req.FileIDs, req.TargetFolderID, req.Flatten, req.OnConflict)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrFolderNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Folder not found",
+			})
+		case errors.Is(err, services.ErrInvalidMoveConflict):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case errors.Is(err, services.ErrMoveConflict):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to move files",
+		})
+	}
+
+	log.Printf("Bulk move to folder %d by %s: %d moved, %d skipped",
+		req.TargetFolderID, user.Username, len(result.Moved), len(result.Skipped))
+
+	return c.JSON(fiber.Map{
+		"message": "Files moved",
+		"result":  result,
+	})
+}
+
+// maxImpersonationMinutes bounds the lifetime of an impersonation session
+const maxImpersonationMinutes = 240
+
+// ImpersonateUser issues a short-lived session acting as another user (server owner only).
+// The session token is returned in the body (not set as a cookie) so the owner's own session
+// stays intact; use it as a Bearer token and end it with POST /api/auth/impersonation/end.
+// POST /api/admin/impersonate/:id
+func (h *AdminHandler) ImpersonateUser(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	if session := middleware.GetSession(c); session != nil && session.ImpersonatedBy != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Cannot impersonate from an impersonation session",
+		})
+	}
+
+	targetID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	if targetID == user.ID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cannot impersonate yourself",
+		})
+	}
+
+	req := struct {
+		DurationMinutes int   `json:"duration_minutes"`
+		ReadOnly        *bool `json:"read_only"` // Defaults to true
+	}{DurationMinutes: 60}
+
+	// Body is optional
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	if req.DurationMinutes <= 0 || req.DurationMinutes > maxImpersonationMinutes {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("duration_minutes must be between 1 and %d", maxImpersonationMinutes),
+		})
+	}
+	readOnly := req.ReadOnly == nil || *req.ReadOnly
+
+	target, err := h.authService.GetUserByID(targetID)
+	if err != nil {
+		if err == services.ErrUserNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch user",
+		})
+	}
+
+	if !target.Enabled {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "User is disabled",
+		})
+	}
+
+	session, err := h.authService.CreateImpersonationSession(target.ID, user.ID,
+		time.Duration(req.DurationMinutes)*time.Minute, readOnly)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create impersonation session",
+		})
+	}
+
+	log.Printf("Impersonation started by %s (user ID: %d) as %s (user ID: %d), read_only=%t",
+		user.Username, user.ID, target.Username, target.ID, readOnly)
+	h.authService.LogUserActivity(target.ID, user.ID, "impersonation_started",
+		fmt.Sprintf(`{"duration_minutes":%d,"read_only":%t}`, req.DurationMinutes, readOnly), c.IP())
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"session_id": session.ID,
+		"expires_at": session.ExpiresAt,
+		"read_only":  readOnly,
+		"user":       target,
+	})
+}
+
+// ExportInventory streams every indexed file with its folder, path, size, type,
+// capture time and tags as CSV. An export that fails part way ends with a row
+// starting with services.InventoryExportErrorMarker.
+// GET /api/admin/inventory.csv
+func (h *AdminHandler) ExportInventory(c *fiber.Ctx) error {
+	c.Set("Content-Type", "text/csv")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=inventory_%s.csv", time.Now().Format("20060102")))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := h.folderService.ExportInventory(w); err != nil {
+			log.Printf("Failed to export inventory: %v", err)
+			if err := services.WriteInventoryExportError(w, err); err != nil {
+				log.Printf("Failed to report the inventory export error: %v", err)
+			}
+		}
+		w.Flush()
+	})
+	return nil
+}
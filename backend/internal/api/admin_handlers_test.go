@@ -0,0 +1,69 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestRotateSecurity(t *testing.T) {
+	db := newTestDB(t)
+	authService := services.NewAuthService(db.DB)
+	shareService := services.NewShareService(db.DB, nil)
+	h := NewAdminHandler(authService, shareService, services.NewFolderService(db.DB))
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	regular := seedUser(t, db.DB, "alice", "user")
+	for _, userID := range []int64{owner.ID, regular.ID} {
+		if _, err := authService.CreateSession(userID, time.Hour); err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+	}
+
+	newApp := func(user *models.User) *fiber.App {
+		app := fiber.New()
+		app.Post("/api/admin/security/rotate", asUser(user), middleware.ServerOwnerOnlyMiddleware(), h.RotateSecurity)
+		return app
+	}
+
+	// Only the server owner may rotate
+	if status, _ := doRequest(t, newApp(regular), "POST", "/api/admin/security/rotate", "", nil); status != fiber.StatusForbidden {
+		t.Errorf("regular user: status %d, want 403", status)
+	}
+
+	app := newApp(owner)
+	if status, _ := doRequest(t, app, "POST", "/api/admin/security/rotate", "{", nil); status != fiber.StatusBadRequest {
+		t.Errorf("invalid body: status %d, want 400", status)
+	}
+
+	// An empty body only rotates the token secret
+	status, body := doRequest(t, app, "POST", "/api/admin/security/rotate", "", nil)
+	if status != fiber.StatusOK || body["sessions_deleted"] != float64(0) {
+		t.Errorf("empty body: status %d body %v", status, body)
+	}
+
+	status, body = doRequest(t, app, "POST", "/api/admin/security/rotate", `{"delete_sessions":true}`, nil)
+	if status != fiber.StatusOK || body["sessions_deleted"] != float64(2) {
+		t.Errorf("delete sessions: status %d body %v, want 2 sessions deleted", status, body)
+	}
+	var remaining int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&remaining); err != nil {
+		t.Fatalf("count sessions: %v", err)
+	}
+	if remaining != 0 {
+		t.Errorf("%d sessions left after rotation", remaining)
+	}
+
+	var logged int
+	if err := db.QueryRow("SELECT COUNT(*) FROM user_activity_logs WHERE action = 'security_rotated'").Scan(&logged); err != nil {
+		t.Fatalf("count activity: %v", err)
+	}
+	if logged != 2 {
+		t.Errorf("%d security_rotated activity entries, want 2", logged)
+	}
+}
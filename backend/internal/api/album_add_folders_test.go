@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestAlbumFolderErrors(t *testing.T) {
+	db := newTestDB(t)
+	albumService := services.NewAlbumService(db.DB)
+	h := NewAlbumHandler(albumService, services.NewSettingsService(db.DB))
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	other := seedUser(t, db.DB, "other", "user")
+	mine := seedFolder(t, db.DB, "/photos/mine", owner.ID)
+	foreign := seedFolder(t, db.DB, "/photos/foreign", other.ID)
+	grantFolder(t, db.DB, owner.ID, mine, "read")
+
+	app := fiber.New()
+	app.Post("/api/albums", asUser(owner), h.CreateAlbum)
+	app.Post("/api/albums/:id/folders", asUser(owner), h.AddAlbumFolders)
+
+	albumCount := func() int {
+		var count int
+		db.QueryRow("SELECT COUNT(*) FROM albums_v2").Scan(&count)
+		return count
+	}
+
+	// A rejected folder discards the album created in the same request
+	body := fmt.Sprintf(`{"name":"Trip","folders":[{"folder_id":%d},{"folder_id":%d}]}`, mine, foreign)
+	if status, resp := doRequest(t, app, http.MethodPost, "/api/albums", body, nil); status != fiber.StatusForbidden {
+		t.Fatalf("create with foreign folder: status %d (%v)", status, resp)
+	}
+	if n := albumCount(); n != 0 {
+		t.Fatalf("rejected album was kept (%d albums)", n)
+	}
+
+	album, err := albumService.CreateAlbum("Album", "", owner.ID)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	path := "/api/albums/" + strconv.FormatInt(album.ID, 10) + "/folders"
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"missing folder", `{"folders":[{"folder_id":9999}]}`, fiber.StatusBadRequest},
+		{"foreign folder", fmt.Sprintf(`{"folders":[{"folder_id":%d}]}`, foreign), fiber.StatusForbidden},
+		{"empty list", `{"folders":[]}`, fiber.StatusBadRequest},
+		{"own folder", fmt.Sprintf(`{"folders":[{"folder_id":%d}]}`, mine), fiber.StatusCreated},
+	}
+	for _, tc := range cases {
+		if status, resp := doRequest(t, app, http.MethodPost, path, tc.body, nil); status != tc.want {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.want, resp)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/services"
+)
+
+// ListAllAlbums lists every user's albums with owner and file count (admin only).
+// Supports ?page=, ?limit=, ?sort=name|owner|file_count|created_at [asc|desc] and ?owner_id=
+// GET /api/admin/albums
+func (h *AlbumHandler) ListAllAlbums(c *fiber.Ctx) error {
+	var ownerID *int64
+	if ownerIDStr := c.Query("owner_id"); ownerIDStr != "" {
+		parsed, err := strconv.ParseInt(ownerIDStr, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid owner ID",
+			})
+		}
+		ownerID = &parsed
+	}
+
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	albums, total, err := h.albumService.ListAllAlbums(ctx, ownerID, c.Query("sort"), page, limit)
+	if err != nil {
+		if err == services.ErrInvalidAlbumListSort {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if isQueryTimeout(err) {
+			return queryTimeoutError(c)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch albums",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"albums": albums,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// RecountAlbums recomputes the file count of every album in one pass (admin only).
+// Album counts are not cached, so this reports the current counts without changing anything.
+// POST /api/admin/albums/recount
+func (h *AlbumHandler) RecountAlbums(c *fiber.Ctx) error {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	counts, err := h.albumService.AlbumFileCounts(ctx, nil)
+	if err != nil {
+		if isQueryTimeout(err) {
+			return queryTimeoutError(c)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to count album files",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"counts": counts,
+		"albums": len(counts),
+	})
+}
+
+// GetAlbumCounts returns the file count of each of the user's albums, keyed by album ID,
+// so album lists don't need a request per album
+// GET /api/albums-v2/counts
+func (h *AlbumHandler) GetAlbumCounts(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	counts, err := h.albumService.AlbumFileCounts(ctx, &user.ID)
+	if err != nil {
+		if isQueryTimeout(err) {
+			return queryTimeoutError(c)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to count album files",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"counts": counts,
+	})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestListAllAlbums(t *testing.T) {
+	db := newTestDB(t)
+	albumService := services.NewAlbumService(db.DB)
+	h := NewAlbumHandler(albumService, services.NewSettingsService(db.DB))
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	alice := seedUser(t, db.DB, "alice", "user")
+	bob := seedUser(t, db.DB, "bob", "user")
+	folder := seedFolder(t, db.DB, "/photos", alice.ID)
+	seedFile(t, db.DB, folder, "a.jpg", "image")
+	seedFile(t, db.DB, folder, "b.jpg", "image")
+	trip, _ := albumService.CreateAlbum("Trip", "", alice.ID)
+	albumService.CreateAlbum("Empty", "", bob.ID)
+	mustExec(t, db.DB, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '')", trip.ID, folder)
+
+	app := fiber.New()
+	app.Get("/api/admin/albums", asUser(admin), h.ListAllAlbums)
+
+	status, resp := doRequest(t, app, http.MethodGet, "/api/admin/albums?sort=name", "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("list: status %d (%v)", status, resp)
+	}
+	albums := resp["albums"].([]interface{})
+	if resp["total"] != float64(2) || len(albums) != 2 {
+		t.Fatalf("unexpected listing %v", resp)
+	}
+	empty, tripRow := albums[0].(map[string]interface{}), albums[1].(map[string]interface{})
+	if empty["owner_username"] != "bob" || empty["file_count"] != float64(0) ||
+		tripRow["owner_username"] != "alice" || tripRow["file_count"] != float64(2) {
+		t.Errorf("unexpected albums %v", albums)
+	}
+
+	cases := []struct {
+		name   string
+		query  string
+		status int
+		total  float64
+	}{
+		{"owner filter", fmt.Sprintf("?owner_id=%d", alice.ID), fiber.StatusOK, 1},
+		{"invalid owner", "?owner_id=x", fiber.StatusBadRequest, 0},
+		{"invalid sort", "?sort=size", fiber.StatusBadRequest, 0},
+	}
+	for _, tc := range cases {
+		status, resp := doRequest(t, app, http.MethodGet, "/api/admin/albums"+tc.query, "", nil)
+		if status != tc.status {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.status, resp)
+			continue
+		}
+		if status == fiber.StatusOK && resp["total"] != tc.total {
+			t.Errorf("%s: total %v, want %v", tc.name, resp["total"], tc.total)
+		}
+	}
+}
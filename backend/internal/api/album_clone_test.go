@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestCloneAlbumHandler(t *testing.T) {
+	db := newTestDB(t)
+	albumService := services.NewAlbumService(db.DB)
+	h := NewAlbumHandler(albumService, services.NewSettingsService(db.DB))
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	admin := seedUser(t, db.DB, "admin", "admin")
+	stranger := seedUser(t, db.DB, "stranger", "user")
+	photos := seedFolder(t, db.DB, "/photos", owner.ID)
+	seedFile(t, db.DB, photos, "a.jpg", "image")
+	seedFile(t, db.DB, photos, "b.jpg", "image")
+
+	album, err := albumService.CreateAlbum("Album", "", owner.ID)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	if err := albumService.AddFolders(album.ID, []services.FolderConfig{{FolderID: photos}}, owner.ID, true); err != nil {
+		t.Fatalf("add folders: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		user *models.User
+		id   string
+		want int
+	}{
+		{"owner", owner, strconv.FormatInt(album.ID, 10), fiber.StatusCreated},
+		{"admin", admin, strconv.FormatInt(album.ID, 10), fiber.StatusCreated},
+		{"stranger", stranger, strconv.FormatInt(album.ID, 10), fiber.StatusForbidden},
+		{"missing album", owner, "9999", fiber.StatusNotFound},
+		{"bad id", owner, "abc", fiber.StatusBadRequest},
+		{"anonymous", nil, strconv.FormatInt(album.ID, 10), fiber.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		app := fiber.New()
+		app.Post("/api/albums/:id/clone", asUser(tc.user), h.CloneAlbum)
+
+		status, resp := doRequest(t, app, http.MethodPost, "/api/albums/"+tc.id+"/clone", "", nil)
+		if status != tc.want {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.want, resp)
+			continue
+		}
+		if status != fiber.StatusCreated {
+			continue
+		}
+		clone := resp["album"].(map[string]interface{})
+		if clone["owner_id"] != float64(tc.user.ID) || clone["name"] != "Album (copy)" {
+			t.Errorf("%s: unexpected clone %v", tc.name, clone)
+		}
+		if resp["file_count"] != float64(2) {
+			t.Errorf("%s: clone file count %v, want 2", tc.name, resp["file_count"])
+		}
+	}
+}
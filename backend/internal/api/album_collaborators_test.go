@@ -0,0 +1,118 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestAlbumCollaboratorHandlers(t *testing.T) {
+	db := newTestDB(t)
+	albumService := services.NewAlbumService(db.DB)
+	h := NewAlbumHandler(albumService, services.NewSettingsService(db.DB))
+
+	alice := seedUser(t, db.DB, "alice", "user")
+	bob := seedUser(t, db.DB, "bob", "user")
+	carol := seedUser(t, db.DB, "carol", "user")
+	shared := seedFolder(t, db.DB, "/shared", alice.ID)
+	private := seedFolder(t, db.DB, "/private", alice.ID)
+	seedFile(t, db.DB, shared, "shared.jpg", "image")
+	seedFile(t, db.DB, private, "private.jpg", "image")
+	grantFolder(t, db.DB, bob.ID, shared, "read")
+
+	album, err := albumService.CreateAlbum("Trip", "", alice.ID)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	mustExec(t, db.DB, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, ''), (?, ?, '')",
+		album.ID, shared, album.ID, private)
+	albumPath := fmt.Sprintf("/api/albums/%d", album.ID)
+
+	appFor := func(user *models.User) *fiber.App {
+		app := fiber.New()
+		app.Use(asUser(user))
+		app.Get("/api/albums", h.ListAlbums)
+		app.Get("/api/albums/:id", h.GetAlbum)
+		app.Put("/api/albums/:id", h.UpdateAlbum)
+		app.Get("/api/albums/:id/items", h.ListAlbumItems)
+		app.Get("/api/albums/:id/collaborators", h.ListAlbumCollaborators)
+		app.Post("/api/albums/:id/collaborators", h.SetAlbumCollaborator)
+		app.Delete("/api/albums/:id/collaborators/:userId", h.RemoveAlbumCollaborator)
+		return app
+	}
+	owner, reader := appFor(alice), appFor(bob)
+
+	if status, _ := doRequest(t, reader, http.MethodGet, albumPath, "", nil); status != fiber.StatusForbidden {
+		t.Errorf("before sharing: status %d, want 403", status)
+	}
+
+	status, body := doRequest(t, owner, http.MethodPost, albumPath+"/collaborators", fmt.Sprintf(`{"user_id":%d,"permission":"read"}`, bob.ID), nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("add collaborator: status %d, body %v", status, body)
+	}
+
+	status, body = doRequest(t, reader, http.MethodGet, "/api/albums", "", nil)
+	albums := body["albums"].([]interface{})
+	if status != fiber.StatusOK || len(albums) != 1 {
+		t.Fatalf("collaborator's albums: status %d, body %v", status, body)
+	}
+	if listed := albums[0].(map[string]interface{}); listed["id"] != float64(album.ID) || listed["access"] != "read" {
+		t.Errorf("listed album = %v, want access read", listed)
+	}
+
+	if status, _ := doRequest(t, reader, http.MethodGet, albumPath, "", nil); status != fiber.StatusOK {
+		t.Errorf("read collaborator GET: status %d", status)
+	}
+	if status, _ := doRequest(t, reader, http.MethodPut, albumPath, `{"name":"Mine now"}`, nil); status != fiber.StatusForbidden {
+		t.Errorf("read collaborator update: status %d, want 403", status)
+	}
+	if status, _ := doRequest(t, reader, http.MethodPost, albumPath+"/collaborators", fmt.Sprintf(`{"user_id":%d,"permission":"read"}`, carol.ID), nil); status != fiber.StatusForbidden {
+		t.Errorf("collaborator adding others: status %d, want 403", status)
+	}
+
+	// Collaborators only see album files they can access themselves
+	_, body = doRequest(t, reader, http.MethodGet, albumPath+"/items", "", nil)
+	if files := body["files"].([]interface{}); len(files) != 1 || files[0].(map[string]interface{})["filename"] != "shared.jpg" {
+		t.Errorf("collaborator items = %v, want only shared.jpg", files)
+	}
+	_, body = doRequest(t, owner, http.MethodGet, albumPath+"/items", "", nil)
+	if files := body["files"].([]interface{}); len(files) != 2 {
+		t.Errorf("owner sees %d items, want 2", len(files))
+	}
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"invalid permission", fmt.Sprintf(`{"user_id":%d,"permission":"owner"}`, carol.ID), fiber.StatusBadRequest},
+		{"owner as collaborator", fmt.Sprintf(`{"user_id":%d,"permission":"read"}`, alice.ID), fiber.StatusBadRequest},
+		{"unknown user", `{"user_id":9999,"permission":"read"}`, fiber.StatusNotFound},
+	}
+	for _, tc := range cases {
+		if status, body := doRequest(t, owner, http.MethodPost, albumPath+"/collaborators", tc.body, nil); status != tc.want {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.want, body)
+		}
+	}
+
+	_, body = doRequest(t, reader, http.MethodGet, albumPath+"/collaborators", "", nil)
+	if body["total"] != float64(1) {
+		t.Errorf("collaborators = %v", body)
+	}
+
+	// A collaborator can leave an album, but not remove others
+	if status, _ := doRequest(t, appFor(carol), http.MethodDelete, fmt.Sprintf("%s/collaborators/%d", albumPath, bob.ID), "", nil); status != fiber.StatusForbidden {
+		t.Errorf("removing someone else: status %d, want 403", status)
+	}
+	if status, _ := doRequest(t, reader, http.MethodDelete, fmt.Sprintf("%s/collaborators/%d", albumPath, bob.ID), "", nil); status != fiber.StatusOK {
+		t.Errorf("leaving: status %d", status)
+	}
+	if status, _ := doRequest(t, reader, http.MethodGet, albumPath, "", nil); status != fiber.StatusForbidden {
+		t.Errorf("after leaving: status %d, want 403", status)
+	}
+}
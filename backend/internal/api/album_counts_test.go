@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestAlbumCountEndpoints(t *testing.T) {
+	db := newTestDB(t)
+	albumService := services.NewAlbumService(db.DB)
+	h := NewAlbumHandler(albumService, services.NewSettingsService(db.DB))
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	alice := seedUser(t, db.DB, "alice", "user")
+	bob := seedUser(t, db.DB, "bob", "user")
+	folder := seedFolder(t, db.DB, "/photos", alice.ID)
+	seedFile(t, db.DB, folder, "a.jpg", "image")
+	seedFile(t, db.DB, folder, "b.jpg", "image")
+	trip, _ := albumService.CreateAlbum("Trip", "", alice.ID)
+	other, _ := albumService.CreateAlbum("Other", "", bob.ID)
+	mustExec(t, db.DB, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '')", trip.ID, folder)
+
+	tripKey, otherKey := strconv.FormatInt(trip.ID, 10), strconv.FormatInt(other.ID, 10)
+
+	app := fiber.New()
+	app.Get("/api/albums-v2/counts", asUser(alice), h.GetAlbumCounts)
+	app.Post("/api/admin/albums/recount", asUser(admin), h.RecountAlbums)
+
+	status, resp := doRequest(t, app, http.MethodGet, "/api/albums-v2/counts", "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("counts: status %d (%v)", status, resp)
+	}
+	counts := resp["counts"].(map[string]interface{})
+	if _, hasOther := counts[otherKey]; counts[tripKey] != float64(2) || hasOther {
+		t.Errorf("alice's counts %v, want only %s with 2 files", counts, tripKey)
+	}
+
+	status, resp = doRequest(t, app, http.MethodPost, "/api/admin/albums/recount", "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("recount: status %d (%v)", status, resp)
+	}
+	counts = resp["counts"].(map[string]interface{})
+	if resp["albums"] != float64(2) || counts[tripKey] != float64(2) || counts[otherKey] != float64(0) {
+		t.Errorf("unexpected recount %v", resp)
+	}
+}
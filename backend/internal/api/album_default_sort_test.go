@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestAlbumDefaultSortHandlers(t *testing.T) {
+	db := newTestDB(t)
+	albumService := services.NewAlbumService(db.DB)
+	h := NewAlbumHandler(albumService, services.NewSettingsService(db.DB))
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	for name, takenAt := range map[string]string{
+		"b.jpg": "2024-03-01 00:00:00",
+		"c.jpg": "2024-01-01 00:00:00",
+		"a.jpg": "2024-02-01 00:00:00",
+	} {
+		id := seedFile(t, db.DB, folder, name, "image")
+		mustExec(t, db.DB, "INSERT INTO photo_metadata (file_id, taken_at) VALUES (?, ?)", id, takenAt)
+	}
+	album, err := albumService.CreateAlbum("Slideshow", "", owner.ID)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	mustExec(t, db.DB, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '')", album.ID, folder)
+
+	app := fiber.New()
+	app.Use(asUser(owner))
+	app.Put("/api/albums/:id", h.UpdateAlbum)
+	app.Get("/api/albums/:id/items", h.ListAlbumItems)
+	albumPath := fmt.Sprintf("/api/albums/%d", album.ID)
+
+	itemNames := func(query string) []string {
+		t.Helper()
+		status, body := doRequest(t, app, http.MethodGet, albumPath+"/items"+query, "", nil)
+		if status != fiber.StatusOK {
+			t.Fatalf("items%s: status %d, body %v", query, status, body)
+		}
+		var names []string
+		for _, f := range body["files"].([]interface{}) {
+			names = append(names, f.(map[string]interface{})["filename"].(string))
+		}
+		return names
+	}
+
+	if got := itemNames(""); fmt.Sprint(got) != "[b.jpg a.jpg c.jpg]" {
+		t.Errorf("before default: %v, want taken_at DESC", got)
+	}
+
+	status, body := doRequest(t, app, http.MethodPut, albumPath, `{"name":"Slideshow","default_sort":"filename asc"}`, nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("update: status %d, body %v", status, body)
+	}
+	if got := body["album"].(map[string]interface{})["default_sort"]; got != "filename ASC" {
+		t.Errorf("response default_sort = %v", got)
+	}
+
+	if got := itemNames(""); fmt.Sprint(got) != "[a.jpg b.jpg c.jpg]" {
+		t.Errorf("with default: %v, want filename order", got)
+	}
+	// An explicit sort still wins
+	if got := itemNames("?sort=" + url.QueryEscape("taken_at asc")); fmt.Sprint(got) != "[c.jpg a.jpg b.jpg]" {
+		t.Errorf("explicit sort: %v, want taken_at ASC", got)
+	}
+
+	// Omitting default_sort keeps it
+	doRequest(t, app, http.MethodPut, albumPath, `{"name":"Renamed"}`, nil)
+	if got := itemNames(""); fmt.Sprint(got) != "[a.jpg b.jpg c.jpg]" {
+		t.Errorf("after rename: %v, want filename order", got)
+	}
+
+	if status, _ := doRequest(t, app, http.MethodPut, albumPath, `{"name":"Slideshow","default_sort":"random()"}`, nil); status != fiber.StatusBadRequest {
+		t.Errorf("invalid default_sort: status %d, want 400", status)
+	}
+	if status, _ := doRequest(t, app, http.MethodGet, albumPath+"/items?sort="+url.QueryEscape("filename; --"), "", nil); status != fiber.StatusBadRequest {
+		t.Errorf("invalid sort: status %d, want 400", status)
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+// ExportAlbumHTML starts a background job building a ZIP with the album's images and an
+// index.html gallery with a lightbox that works offline. ?images=medium bundles medium
+// thumbnails instead of originals. Once the job is done the ZIP is downloaded from
+// GET /api/jobs/:id/download.
+// GET /api/albums-v2/:id/export-html
+func (h *Handler) ExportAlbumHTML(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid album ID"})
+	}
+
+	variant := c.Query("images", "original")
+	if variant != "original" && variant != "medium" {
+		return c.Status(400).JSON(fiber.Map{"error": "Images must be 'original' or 'medium'"})
+	}
+
+	album, err := h.albumService.GetAlbum(id)
+	if err != nil {
+		if err == services.ErrAlbumNotFound {
+			return c.Status(404).JSON(fiber.Map{"error": "Album not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch album"})
+	}
+	if !canAccessAlbum(h.albumService, user, album, false) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied"})
+	}
+
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	files, err := h.albumService.ListItemsWithFiles(ctx, id, album.DefaultSort, "image", albumViewerID(user, album))
+	if err != nil {
+		if isQueryTimeout(err) {
+			return queryTimeoutError(c)
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch album items"})
+	}
+
+	// Files are resolved (and thumbnails generated) while the job writes the ZIP
+	images := make([]services.GalleryImage, 0, len(files))
+	for i, f := range files {
+		fileID := f.ID
+		image := services.GalleryImage{
+			Name:    services.GalleryImageName(i, f.Filename, ""),
+			Caption: f.Filename,
+			Source: func() (string, error) {
+				return h.folderService.ResolveAbsolutePath(fileID)
+			},
+		}
+		if variant == "medium" {
+			image.Name = services.GalleryImageName(i, f.Filename, ".jpg")
+			image.Source = func() (string, error) {
+				path, err := h.folderService.ResolveAbsolutePath(fileID)
+				if err != nil {
+					return "", err
+				}
+				return h.thumbService.GetThumbnail(path, fileID, "medium", services.ThumbnailModeFit)
+			}
+		}
+		images = append(images, image)
+	}
+
+	job, err := h.jobs.Enqueue(services.JobTypeAlbumExport, user.ID,
+		func(ctx context.Context, progress func(percent int)) (interface{}, error) {
+			return h.writeAlbumGallery(album, images, progress)
+		})
+	if err != nil {
+		return jobQueueError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Album export started",
+		"job_id":  job.ID,
+	})
+}
+
+// writeAlbumGallery writes the gallery ZIP of an album to a job file, reporting progress per image
+func (h *Handler) writeAlbumGallery(album *models.Album, images []services.GalleryImage, progress func(percent int)) (*services.JobFileResult, error) {
+	for i := range images {
+		source, done := images[i].Source, i+1
+		images[i].Source = func() (string, error) {
+			progress(done * 100 / len(images))
+			return source()
+		}
+	}
+
+	out, err := h.jobs.CreateFile(".zip")
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(out)
+	err = services.WriteHTMLGallery(w, album.Name, album.Description, images)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return nil, fmt.Errorf("failed to export album %d as HTML: %w", album.ID, err)
+	}
+
+	return &services.JobFileResult{
+		File:     filepath.Base(out.Name()),
+		Filename: fmt.Sprintf("album_%d_gallery.zip", album.ID),
+	}, nil
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+)
+
+func TestExportAlbumHTML(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	h.jobs.SetFileDir(t.TempDir())
+	h.jobs.Start(1)
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	stranger := seedUser(t, db.DB, "stranger", "user")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	grantFolder(t, db.DB, owner.ID, folder, "read")
+	writeTestImage(t, filepath.Join(root, "a.png"), 64, 48)
+	writeTestImage(t, filepath.Join(root, "b.png"), 64, 48)
+	os.WriteFile(filepath.Join(root, "clip.mp4"), []byte("video"), 0644)
+	seedFile(t, db.DB, folder, "a.png", "image")
+	seedFile(t, db.DB, folder, "b.png", "image")
+	seedFile(t, db.DB, folder, "clip.mp4", "video")
+
+	album, err := h.albumService.CreateAlbum("Trip", "", owner.ID)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	mustExec(t, db.DB, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '')", album.ID, folder)
+	exportPath := fmt.Sprintf("/api/albums-v2/%d/export-html", album.ID)
+
+	export := func(user *models.User, query string) *http.Response {
+		app := fiber.New()
+		app.Get("/api/albums-v2/:id/export-html", asUser(user), h.ExportAlbumHTML)
+		return sendRequest(t, app, http.MethodGet, exportPath+query, "", nil)
+	}
+
+	cases := []struct {
+		query  string
+		images []string
+	}{
+		{"", []string{"0001-a.png", "0002-b.png"}},
+		{"?images=medium", []string{"0001-a.jpg", "0002-b.jpg"}},
+	}
+	for _, tc := range cases {
+		resp := export(owner, tc.query)
+		var started map[string]interface{}
+		err := json.NewDecoder(resp.Body).Decode(&started)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != fiber.StatusAccepted {
+			t.Fatalf("%q: status %d (%v, %v)", tc.query, resp.StatusCode, started, err)
+		}
+		job := waitForJob(t, h.jobs, int64(started["job_id"].(float64)))
+		path, result, err := h.jobs.FilePath(job)
+		if err != nil {
+			t.Fatalf("%q: job file: %v (%+v)", tc.query, err, job)
+		}
+		if want := fmt.Sprintf("album_%d_gallery.zip", album.ID); result.Filename != want {
+			t.Errorf("%q: download name %q, want %q", tc.query, result.Filename, want)
+		}
+		body, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%q: read bundle: %v", tc.query, err)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		if err != nil {
+			t.Fatalf("%q: open zip: %v", tc.query, err)
+		}
+		names := map[string]*zip.File{}
+		for _, f := range zr.File {
+			names[f.Name] = f
+		}
+		if len(names) != len(tc.images)+3 {
+			t.Errorf("%q: bundle has %d entries, want %d images plus index, CSS and JS", tc.query, len(names), len(tc.images))
+		}
+		index, ok := names["index.html"]
+		if !ok {
+			t.Fatalf("%q: bundle has no index.html", tc.query)
+		}
+		rc, err := index.Open()
+		if err != nil {
+			t.Fatalf("%q: open index.html: %v", tc.query, err)
+		}
+		html, _ := io.ReadAll(rc)
+		rc.Close()
+		for _, image := range tc.images {
+			if names["images/"+image] == nil {
+				t.Errorf("%q: bundle is missing images/%s", tc.query, image)
+			}
+			if !strings.Contains(string(html), `src="images/`+image+`"`) {
+				t.Errorf("%q: index.html does not reference %s", tc.query, image)
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		user   *models.User
+		query  string
+		status int
+	}{
+		{"stranger", stranger, "", fiber.StatusForbidden},
+		{"unknown variant", owner, "?images=large", fiber.StatusBadRequest},
+	} {
+		resp := export(tc.user, tc.query)
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s: status %d, want %d", tc.name, resp.StatusCode, tc.status)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestListAlbumItemsByType(t *testing.T) {
+	db := newTestDB(t)
+	albumService := services.NewAlbumService(db.DB)
+	h := NewAlbumHandler(albumService, services.NewSettingsService(db.DB))
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	seedFile(t, db.DB, folder, "a.jpg", "image")
+	seedFile(t, db.DB, folder, "b.mp4", "video")
+	seedFile(t, db.DB, folder, "c.jpg", "image")
+	album, err := albumService.CreateAlbum("Mixed", "", owner.ID)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	mustExec(t, db.DB, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '')", album.ID, folder)
+
+	app := fiber.New()
+	app.Get("/api/albums/:id/items", asUser(owner), h.ListAlbumItems)
+	itemsPath := fmt.Sprintf("/api/albums/%d/items?sort=filename+asc", album.ID)
+
+	cases := []struct {
+		query      string
+		wantStatus int
+		wantNames  string
+	}{
+		{"", fiber.StatusOK, "[a.jpg b.mp4 c.jpg]"},
+		{"&type=video", fiber.StatusOK, "[b.mp4]"},
+		{"&type=image", fiber.StatusOK, "[a.jpg c.jpg]"},
+		{"&type=audio", fiber.StatusBadRequest, ""},
+	}
+	for _, tc := range cases {
+		status, body := doRequest(t, app, http.MethodGet, itemsPath+tc.query, "", nil)
+		if status != tc.wantStatus {
+			t.Errorf("%q: status %d, want %d (body %v)", tc.query, status, tc.wantStatus, body)
+			continue
+		}
+		if status != fiber.StatusOK {
+			continue
+		}
+		var names []string
+		for _, f := range body["files"].([]interface{}) {
+			names = append(names, f.(map[string]interface{})["filename"].(string))
+		}
+		if got := fmt.Sprint(names); got != tc.wantNames {
+			t.Errorf("%q: %s, want %s", tc.query, got, tc.wantNames)
+		}
+	}
+}
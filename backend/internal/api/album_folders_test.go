@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestListAlbumFoldersDetails(t *testing.T) {
+	db := newTestDB(t)
+	albumService := services.NewAlbumService(db.DB)
+	h := NewAlbumHandler(albumService, services.NewSettingsService(db.DB))
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	other := seedUser(t, db.DB, "other", "user")
+	photos := seedFolder(t, db.DB, "/photos", owner.ID)
+	videos := seedFolder(t, db.DB, "/videos", owner.ID)
+	seedFile(t, db.DB, photos, "2023/a.jpg", "image")
+	seedFile(t, db.DB, photos, "2024/b.jpg", "image")
+	seedFile(t, db.DB, videos, "clip.mp4", "video")
+
+	album, err := albumService.CreateAlbum("Album", "", owner.ID)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	mustExec(t, db.DB, "INSERT INTO album_folders (album_id, folder_id, path_prefix, added_at) VALUES (?, ?, '2023/', '2024-01-01 00:00:00')", album.ID, photos)
+	mustExec(t, db.DB, "INSERT INTO album_folders (album_id, folder_id, path_prefix, added_at) VALUES (?, ?, '', '2024-01-02 00:00:00')", album.ID, videos)
+
+	app := fiber.New()
+	app.Get("/api/albums/:id/folders", asUser(owner), h.ListAlbumFolders)
+	app.Get("/other/albums/:id/folders", asUser(other), h.ListAlbumFolders)
+	base := fmt.Sprintf("/api/albums/%d/folders", album.ID)
+
+	status, body := doRequest(t, app, http.MethodGet, base, "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, body %v", status, body)
+	}
+	folders := body["folders"].([]interface{})
+	if body["total"] != float64(2) || len(folders) != 2 {
+		t.Fatalf("total %v, %d folders, want 2", body["total"], len(folders))
+	}
+	for i, want := range []struct {
+		name  string
+		path  string
+		count float64
+	}{
+		{"videos", "/videos", 1},
+		{"photos", "/photos", 1},
+	} {
+		f := folders[i].(map[string]interface{})
+		if f["folder_name"] != want.name || f["absolute_path"] != want.path || f["file_count"] != want.count || f["enabled"] != true {
+			t.Errorf("folder %d = %v, want %s %s with %v files", i, f, want.name, want.path, want.count)
+		}
+	}
+
+	_, body = doRequest(t, app, http.MethodGet, base+"?page=2&limit=1", "", nil)
+	if folders := body["folders"].([]interface{}); len(folders) != 1 || body["total"] != float64(2) || body["page"] != float64(2) || body["limit"] != float64(1) {
+		t.Errorf("page 2: %v", body)
+	} else if folders[0].(map[string]interface{})["folder_name"] != "photos" {
+		t.Errorf("page 2 folder = %v", folders[0])
+	}
+
+	_, body = doRequest(t, app, http.MethodGet, fmt.Sprintf("%s?folder_id=%d", base, videos), "", nil)
+	if folders := body["folders"].([]interface{}); len(folders) != 1 || folders[0].(map[string]interface{})["folder_name"] != "videos" {
+		t.Errorf("folder filter: %v", body)
+	}
+
+	if status, _ := doRequest(t, app, http.MethodGet, base+"?folder_id=abc", "", nil); status != fiber.StatusBadRequest {
+		t.Errorf("invalid folder_id: status %d, want 400", status)
+	}
+	if status, _ := doRequest(t, app, http.MethodGet, fmt.Sprintf("/other/albums/%d/folders", album.ID), "", nil); status != fiber.StatusForbidden {
+		t.Errorf("other user: status %d, want 403", status)
+	}
+}
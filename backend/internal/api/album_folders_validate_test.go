@@ -0,0 +1,55 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestAlbumFolderValidationAccess(t *testing.T) {
+	db := newTestDB(t)
+	albumService := services.NewAlbumService(db.DB)
+	h := NewAlbumHandler(albumService, services.NewSettingsService(db.DB))
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	reader := seedUser(t, db.DB, "reader", "user")
+	stranger := seedUser(t, db.DB, "stranger", "user")
+	album, err := albumService.CreateAlbum("Album", "", owner.ID)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	if err := albumService.SetCollaborator(album.ID, reader.ID, "read", owner.ID); err != nil {
+		t.Fatalf("add collaborator: %v", err)
+	}
+
+	request := func(user *models.User, method, action string) int {
+		app := fiber.New()
+		app.Get("/api/albums/:id/folders/validate", asUser(user), h.ValidateAlbumFolders)
+		app.Post("/api/albums/:id/folders/prune", asUser(user), h.PruneAlbumFolders)
+		status, _ := doRequest(t, app, method, "/api/albums/"+strconv.FormatInt(album.ID, 10)+"/folders/"+action, "", nil)
+		return status
+	}
+
+	cases := []struct {
+		name           string
+		user           *models.User
+		method, action string
+		want           int
+	}{
+		{"owner validates", owner, "GET", "validate", fiber.StatusOK},
+		{"owner prunes", owner, "POST", "prune", fiber.StatusOK},
+		{"reader validates", reader, "GET", "validate", fiber.StatusOK},
+		{"reader prunes", reader, "POST", "prune", fiber.StatusForbidden},
+		{"stranger validates", stranger, "GET", "validate", fiber.StatusForbidden},
+		{"stranger prunes", stranger, "POST", "prune", fiber.StatusForbidden},
+	}
+	for _, tc := range cases {
+		if status := request(tc.user, tc.method, tc.action); status != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, status, tc.want)
+		}
+	}
+}
@@ -1,25 +1,60 @@
 package api
 
 import (
+	"errors"
+	"log"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 
 	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/models"
 	"awesome-sharing/internal/services"
 )
 
 type AlbumHandler struct {
-	albumService *services.AlbumService
+	albumService    *services.AlbumService
+	settingsService *services.SettingsService
 }
 
-func NewAlbumHandler(albumService *services.AlbumService) *AlbumHandler {
+func NewAlbumHandler(albumService *services.AlbumService, settingsService *services.SettingsService) *AlbumHandler {
 	return &AlbumHandler{
-		albumService: albumService,
+		albumService:    albumService,
+		settingsService: settingsService,
 	}
 }
 
-// ListAlbums returns all albums for the current user
+// canAccessAlbum reports whether a user may read (or, with write set, modify) an album:
+// owners and admins always can, collaborators according to their permission
+func (h *AlbumHandler) canAccessAlbum(user *models.User, album *models.Album, write bool) bool {
+	return canAccessAlbum(h.albumService, user, album, write)
+}
+
+// canAccessAlbum implements AlbumHandler.canAccessAlbum for handlers outside AlbumHandler
+func canAccessAlbum(albumService *services.AlbumService, user *models.User, album *models.Album, write bool) bool {
+	if album.OwnerID == user.ID || user.Role == "admin" {
+		return true
+	}
+
+	permission, err := albumService.GetCollaboratorPermission(album.ID, user.ID)
+	if err != nil {
+		log.Printf("Failed to check album collaborator %d on album %d: %v", user.ID, album.ID, err)
+		return false
+	}
+	return permission == "write" || (permission == "read" && !write)
+}
+
+// albumViewerID returns the user ID album file lists must be filtered by, or 0 when the user
+// owns the album or is the server owner. Everyone else, collaborators and admins included,
+// only sees the files they can access through permission groups.
+func albumViewerID(user *models.User, album *models.Album) int64 {
+	if album.OwnerID == user.ID || user.Role == "server_owner" {
+		return 0
+	}
+	return user.ID
+}
+
+// ListAlbums returns the albums the current user owns or collaborates on
 // GET /api/albums
 func (h *AlbumHandler) ListAlbums(c *fiber.Ctx) error {
 	user := middleware.GetUser(c)
@@ -71,8 +106,8 @@ func (h *AlbumHandler) GetAlbum(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check ownership
-	if album.OwnerID != user.ID && user.Role != "admin" {
+	// Check access (owner, admin or collaborator)
+	if !h.canAccessAlbum(user, album, false) {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "Access denied",
 		})
@@ -111,6 +146,10 @@ func (h *AlbumHandler) CreateAlbum(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := h.checkAlbumLimit(user); err != nil {
+		return albumLimitError(c, err)
+	}
+
 	album, err := h.albumService.CreateAlbum(req.Name, req.Description, user.ID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -118,12 +157,14 @@ func (h *AlbumHandler) CreateAlbum(c *fiber.Ctx) error {
 		})
 	}
 
-	// Add folder configurations if provided
+	// Add folder configurations if provided (the album is discarded if they are rejected)
 	if len(req.Folders) > 0 {
-		if err := h.albumService.AddFolders(album.ID, req.Folders); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to add folders to album",
-			})
+		isAdmin := user.Role == "admin" || user.Role == "server_owner"
+		if err := h.albumService.AddFolders(album.ID, req.Folders, user.ID, isAdmin); err != nil {
+			if delErr := h.albumService.DeleteAlbum(album.ID); delErr != nil {
+				log.Printf("Failed to discard album %d after folder error: %v", album.ID, delErr)
+			}
+			return albumFolderError(c, err)
 		}
 	}
 
@@ -162,16 +203,17 @@ func (h *AlbumHandler) UpdateAlbum(c *fiber.Ctx) error {
 		})
 	}
 
-	if album.OwnerID != user.ID && user.Role != "admin" {
+	if !h.canAccessAlbum(user, album, true) {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "Access denied",
 		})
 	}
 
 	var req struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
-		CoverFileID *int64 `json:"cover_file_id"`
+		Name        string  `json:"name"`
+		Description string  `json:"description"`
+		CoverFileID *int64  `json:"cover_file_id"`
+		DefaultSort *string `json:"default_sort"` // Omitted = unchanged, "" = back to taken_at DESC
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -186,6 +228,14 @@ func (h *AlbumHandler) UpdateAlbum(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.DefaultSort != nil && *req.DefaultSort != "" {
+		if _, err := services.NormalizeAlbumSort(*req.DefaultSort); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid default_sort, expected '<column> [asc|desc]' with column one of taken_at, filename, size, width, height, created_at, updated_at",
+			})
+		}
+	}
+
 	err = h.albumService.UpdateAlbum(id, req.Name, req.Description, req.CoverFileID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -193,6 +243,14 @@ func (h *AlbumHandler) UpdateAlbum(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.DefaultSort != nil {
+		if err := h.albumService.SetDefaultSort(id, *req.DefaultSort); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update album",
+			})
+		}
+	}
+
 	updatedAlbum, err := h.albumService.GetAlbum(id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -254,7 +312,7 @@ func (h *AlbumHandler) DeleteAlbum(c *fiber.Ctx) error {
 }
 
 // ListAlbumItems returns all items in an album with file details
-// GET /api/albums/:id/items
+// GET /api/albums/:id/items?sort=taken_at+DESC&type=video
 func (h *AlbumHandler) ListAlbumItems(c *fiber.Ctx) error {
 	user := middleware.GetUser(c)
 	if user == nil {
@@ -283,17 +341,36 @@ func (h *AlbumHandler) ListAlbumItems(c *fiber.Ctx) error {
 		})
 	}
 
-	if album.OwnerID != user.ID && user.Role != "admin" {
+	if !h.canAccessAlbum(user, album, false) {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "Access denied",
 		})
 	}
 
-	// Get sort order from query parameter (default: taken_at DESC)
-	sortOrder := c.Query("sort", "taken_at DESC")
+	// Sort order from query parameter, falling back to the album's default (then taken_at DESC)
+	sortOrder := c.Query("sort", album.DefaultSort)
+
+	// Optional filter by file type
+	fileType := c.Query("type")
+	if fileType != "" && fileType != "image" && fileType != "video" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Type must be 'image' or 'video'",
+		})
+	}
+
+	ctx, cancel := queryContext(c)
+	defer cancel()
 
-	files, err := h.albumService.ListItemsWithFiles(id, sortOrder)
+	files, err := h.albumService.ListItemsWithFiles(ctx, id, sortOrder, fileType, albumViewerID(user, album))
 	if err != nil {
+		if err == services.ErrInvalidAlbumSort {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid sort order",
+			})
+		}
+		if isQueryTimeout(err) {
+			return queryTimeoutError(c)
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch album items",
 		})
@@ -335,7 +412,7 @@ func (h *AlbumHandler) AddAlbumFolders(c *fiber.Ctx) error {
 		})
 	}
 
-	if album.OwnerID != user.ID && user.Role != "admin" {
+	if !h.canAccessAlbum(user, album, true) {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "Access denied",
 		})
@@ -357,11 +434,9 @@ func (h *AlbumHandler) AddAlbumFolders(c *fiber.Ctx) error {
 		})
 	}
 
-	err = h.albumService.AddFolders(id, req.Folders)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to add folders to album",
-		})
+	isAdmin := user.Role == "admin" || user.Role == "server_owner"
+	if err := h.albumService.AddFolders(id, req.Folders, user.ID, isAdmin); err != nil {
+		return albumFolderError(c, err)
 	}
 
 	// Get file count for the updated album
@@ -403,13 +478,34 @@ func (h *AlbumHandler) ListAlbumFolders(c *fiber.Ctx) error {
 		})
 	}
 
-	if album.OwnerID != user.ID && user.Role != "admin" {
+	if !h.canAccessAlbum(user, album, false) {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "Access denied",
 		})
 	}
 
-	folders, err := h.albumService.ListAlbumFolders(id)
+	// Optional filter by folder
+	var folderID *int64
+	if folderIDStr := c.Query("folder_id"); folderIDStr != "" {
+		parsed, err := strconv.ParseInt(folderIDStr, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid folder ID",
+			})
+		}
+		folderID = &parsed
+	}
+
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 100)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	folders, total, err := h.albumService.ListAlbumFolderDetails(id, folderID, page, limit)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch album folders",
@@ -418,7 +514,9 @@ func (h *AlbumHandler) ListAlbumFolders(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{
 		"folders": folders,
-		"total":   len(folders),
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
 	})
 }
 
@@ -459,7 +557,7 @@ func (h *AlbumHandler) RemoveAlbumFolder(c *fiber.Ctx) error {
 		})
 	}
 
-	if album.OwnerID != user.ID && user.Role != "admin" {
+	if !h.canAccessAlbum(user, album, true) {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "Access denied",
 		})
@@ -479,3 +577,429 @@ func (h *AlbumHandler) RemoveAlbumFolder(c *fiber.Ctx) error {
 		"message": "Folder removed successfully",
 	})
 }
+
+// ValidateAlbumFolders reports the status of each folder configuration of an album
+// GET /api/albums-v2/:id/folders/validate
+func (h *AlbumHandler) ValidateAlbumFolders(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid album ID",
+		})
+	}
+
+	// Check ownership
+	album, err := h.albumService.GetAlbum(id)
+	if err != nil {
+		if err == services.ErrAlbumNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Album not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch album",
+		})
+	}
+
+	if !h.canAccessAlbum(user, album, false) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	statuses, err := h.albumService.ValidateAlbumFolders(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to validate album folders",
+		})
+	}
+
+	healthy := 0
+	for _, st := range statuses {
+		if st.Status == "ok" {
+			healthy++
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"folders": statuses,
+		"total":   len(statuses),
+		"healthy": healthy,
+	})
+}
+
+// PruneAlbumFolders removes folder configurations whose folder no longer exists
+// POST /api/albums-v2/:id/folders/prune
+func (h *AlbumHandler) PruneAlbumFolders(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid album ID",
+		})
+	}
+
+	// Check ownership
+	album, err := h.albumService.GetAlbum(id)
+	if err != nil {
+		if err == services.ErrAlbumNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Album not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch album",
+		})
+	}
+
+	if !h.canAccessAlbum(user, album, true) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	removed, err := h.albumService.PruneAlbumFolders(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to prune album folders",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Album folders pruned",
+		"removed": removed,
+	})
+}
+
+// CloneAlbum creates a copy of an album, including its folder configurations, owned by the caller
+// POST /api/albums/:id/clone
+func (h *AlbumHandler) CloneAlbum(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid album ID",
+		})
+	}
+
+	// Check ownership of the source album
+	album, err := h.albumService.GetAlbum(id)
+	if err != nil {
+		if err == services.ErrAlbumNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Album not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch album",
+		})
+	}
+
+	if album.OwnerID != user.ID && user.Role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	if err := h.checkAlbumLimit(user); err != nil {
+		return albumLimitError(c, err)
+	}
+
+	clone, err := h.albumService.CloneAlbum(id, user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to clone album",
+		})
+	}
+
+	count, _ := h.albumService.GetAlbumFileCount(clone.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"album":      clone,
+		"file_count": count,
+	})
+}
+
+// CreateAutoAlbums creates one album per top-level subdirectory of a folder, owned by the caller
+// POST /api/folders/:id/auto-albums
+func (h *AlbumHandler) CreateAutoAlbums(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	folderID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid folder ID",
+		})
+	}
+
+	if err := h.checkAlbumLimit(user); err != nil {
+		return albumLimitError(c, err)
+	}
+
+	// One album per subdirectory, so the whole batch must fit in the user's limit
+	isAdmin := user.Role == "admin" || user.Role == "server_owner"
+	maxAlbums := 0
+	if !isAdmin {
+		usage, err := h.settingsService.GetAlbumUsage(user.ID)
+		if err != nil {
+			return albumLimitError(c, err)
+		}
+		maxAlbums = usage.Limit
+	}
+
+	albums, err := h.albumService.CreateAutoAlbums(folderID, user.ID, isAdmin, maxAlbums)
+	if err != nil {
+		if errors.Is(err, services.ErrAlbumLimitReached) {
+			return albumLimitError(c, err)
+		}
+		switch err {
+		case services.ErrFolderNotFound:
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Folder not found",
+			})
+		case services.ErrFolderAccessDenied:
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Access denied",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create albums",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"albums":  albums,
+		"created": len(albums),
+	})
+}
+
+// checkAlbumLimit returns services.ErrAlbumLimitReached if a non-admin user
+// already owns the maximum number of albums
+func (h *AlbumHandler) checkAlbumLimit(user *models.User) error {
+	if user.Role == "admin" || user.Role == "server_owner" {
+		return nil
+	}
+	return h.settingsService.CheckAlbumLimit(user.ID)
+}
+
+// albumLimitError maps a checkAlbumLimit error to an HTTP response
+func albumLimitError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrAlbumLimitReached) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Album limit reached",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to check album limit",
+	})
+}
+
+// albumFolderError maps an AddFolders error to an HTTP response
+func albumFolderError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrFolderNotFound), errors.Is(err, services.ErrInvalidPathPrefix):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrFolderAccessDenied):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to add folders to album",
+	})
+}
+
+// ListAlbumCollaborators lists the users an album is shared with
+// GET /api/albums/:id/collaborators
+func (h *AlbumHandler) ListAlbumCollaborators(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid album ID",
+		})
+	}
+
+	album, err := h.albumService.GetAlbum(id)
+	if err != nil {
+		if err == services.ErrAlbumNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Album not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch album",
+		})
+	}
+
+	if !h.canAccessAlbum(user, album, false) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	collaborators, err := h.albumService.ListCollaborators(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch collaborators",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"collaborators": collaborators,
+		"total":         len(collaborators),
+	})
+}
+
+// SetAlbumCollaborator shares an album with a user or changes their permission (owner only)
+// POST /api/albums/:id/collaborators
+func (h *AlbumHandler) SetAlbumCollaborator(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid album ID",
+		})
+	}
+
+	album, err := h.albumService.GetAlbum(id)
+	if err != nil {
+		if err == services.ErrAlbumNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Album not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch album",
+		})
+	}
+
+	if album.OwnerID != user.ID && user.Role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	var req struct {
+		UserID     int64  `json:"user_id"`
+		Permission string `json:"permission"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := h.albumService.SetCollaborator(id, req.UserID, req.Permission, user.ID); err != nil {
+		switch err {
+		case services.ErrInvalidCollaboratorPermission, services.ErrCollaboratorIsOwner:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case services.ErrUserNotFound:
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to add collaborator",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Collaborator saved",
+	})
+}
+
+// RemoveAlbumCollaborator stops sharing an album with a user.
+// Owners can remove anyone; collaborators can remove themselves.
+// DELETE /api/albums/:id/collaborators/:userId
+func (h *AlbumHandler) RemoveAlbumCollaborator(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid album ID",
+		})
+	}
+
+	collaboratorID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	album, err := h.albumService.GetAlbum(id)
+	if err != nil {
+		if err == services.ErrAlbumNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Album not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch album",
+		})
+	}
+
+	if album.OwnerID != user.ID && user.Role != "admin" && collaboratorID != user.ID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	if err := h.albumService.RemoveCollaborator(id, collaboratorID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to remove collaborator",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Collaborator removed",
+	})
+}
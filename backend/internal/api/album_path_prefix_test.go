@@ -0,0 +1,44 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestAddAlbumFoldersPathPrefix(t *testing.T) {
+	db := newTestDB(t)
+	albumService := services.NewAlbumService(db.DB)
+	h := NewAlbumHandler(albumService, services.NewSettingsService(db.DB))
+
+	owner := seedUser(t, db.DB, "owner", "admin")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	seedFile(t, db.DB, folder, "100%/a.jpg", "image")
+	seedFile(t, db.DB, folder, "1000/b.jpg", "image")
+	album, err := albumService.CreateAlbum("Prefixed", "", owner.ID)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+
+	app := fiber.New()
+	app.Post("/api/albums/:id/folders", asUser(owner), h.AddAlbumFolders)
+	path := fmt.Sprintf("/api/albums/%d/folders", album.ID)
+
+	status, body := doRequest(t, app, http.MethodPost, path, fmt.Sprintf(`{"folders":[{"folder_id":%d,"path_prefix":"../other"}]}`, folder), nil)
+	if status != fiber.StatusBadRequest {
+		t.Errorf("../ prefix: status %d, want 400 (%v)", status, body)
+	}
+
+	status, body = doRequest(t, app, http.MethodPost, path, fmt.Sprintf(`{"folders":[{"folder_id":%d,"path_prefix":"/100%%"}]}`, folder), nil)
+	if status != fiber.StatusCreated || body["count"] != float64(1) {
+		t.Fatalf("%% prefix: status %d, body %v, want 201 with 1 file", status, body)
+	}
+	folders, _ := albumService.ListAlbumFolders(album.ID)
+	if len(folders) != 1 || folders[0].PathPrefix != "100%/" {
+		t.Errorf("stored folders = %+v, want prefix 100%%/", folders)
+	}
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"errors"
+	"log"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,12 +14,14 @@ import (
 type AuthHandler struct {
 	authService *services.AuthService
 	settingsService *services.SettingsService
+	preferenceService *services.UserPreferenceService
 }
 
-func NewAuthHandler(authService *services.AuthService, settingsService *services.SettingsService) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, settingsService *services.SettingsService, preferenceService *services.UserPreferenceService) *AuthHandler {
 	return &AuthHandler{
 		authService: authService,
 		settingsService: settingsService,
+		preferenceService: preferenceService,
 	}
 }
 
@@ -73,16 +77,23 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	// However, SameSite=None requires Secure=true (HTTPS)
 	// For HTTP development, we use Lax which should work for localhost
 	c.Cookie(&fiber.Cookie{
-		Name:     "session_id",
+		Name:     middleware.SessionCookieName(),
 		Value:    session.ID,
 		Path:     "/",
 		Domain:   "", // Empty domain to work with localhost
 		Expires:  session.ExpiresAt,
 		HTTPOnly: true,
-		Secure:   false, // Set to true in production with HTTPS
+		Secure:   middleware.SecureCookie(c),
 		SameSite: "Lax",
 	})
 
+	// Fresh CSRF token for the new session
+	if _, err := middleware.SetCSRFCookie(c); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Login failed",
+		})
+	}
+
 	return c.JSON(fiber.Map{
 		"user":    user,
 		"session": session,
@@ -92,7 +103,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 // Logout destroys the user session
 // POST /api/auth/logout
 func (h *AuthHandler) Logout(c *fiber.Ctx) error {
-	sessionID := c.Cookies("session_id")
+	sessionID := c.Cookies(middleware.SessionCookieName())
 	if sessionID == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "No active session",
@@ -107,11 +118,12 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 
 	// Clear cookie
 	c.Cookie(&fiber.Cookie{
-		Name:     "session_id",
+		Name:     middleware.SessionCookieName(),
 		Value:    "",
 		Path:     "/",
 		Expires:  time.Now().Add(-time.Hour),
 		HTTPOnly: true,
+		Secure:   middleware.SecureCookie(c),
 		SameSite: "Lax",
 	})
 
@@ -153,8 +165,8 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		})
 	}
 
-	// Only admins can set role, default to 'user'
-	role := "user"
+	// Only admins can set role; without one CreateUser applies the registration defaults
+	role := ""
 	if req.Role != "" && user != nil && user.Role == "admin" {
 		role = req.Role
 	}
@@ -172,6 +184,13 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		})
 	}
 
+	// Self-registered addresses stay unverified; ones entered by an administrator are trusted
+	if req.Email != "" && user != nil && user.Role == "admin" {
+		if err := h.authService.UpdateUser(newUser.ID, map[string]interface{}{"email_verified": true}); err != nil {
+			log.Printf("Failed to mark the email of user %d verified: %v", newUser.ID, err)
+		}
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"user": newUser,
 	})
@@ -187,8 +206,88 @@ func (h *AuthHandler) Me(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.JSON(fiber.Map{
+	response := fiber.Map{
 		"user": user,
+	}
+	if session := middleware.GetSession(c); session != nil && session.ImpersonatedBy != nil {
+		response["impersonated_by"] = *session.ImpersonatedBy
+		response["impersonation_read_only"] = session.ImpersonationReadOnly
+	}
+
+	return c.JSON(response)
+}
+
+// GetLimits returns the current user's album and share counts against the configured
+// per-user limits (a limit of 0 means unlimited; admins are exempt)
+// GET /api/auth/limits
+func (h *AuthHandler) GetLimits(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Not authenticated",
+		})
+	}
+
+	limits, err := h.settingsService.GetUserLimits(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch limits",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"limits": limits,
+		"exempt": user.Role == "admin" || user.Role == "server_owner",
+	})
+}
+
+// GetCSRFToken returns the CSRF token for cookie-authenticated requests, issuing one if needed.
+// The same value must be sent in the X-CSRF-Token header on POST/PUT/DELETE requests.
+// GET /api/auth/csrf
+func (h *AuthHandler) GetCSRFToken(c *fiber.Ctx) error {
+	token := c.Cookies(middleware.CSRFCookieName)
+	if token == "" {
+		var err error
+		if token, err = middleware.SetCSRFCookie(c); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to issue CSRF token",
+			})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"csrf_token":  token,
+		"header_name": middleware.CSRFHeaderName,
+	})
+}
+
+// EndImpersonation deletes the current impersonation session
+// POST /api/auth/impersonation/end
+func (h *AuthHandler) EndImpersonation(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	session := middleware.GetSession(c)
+	if user == nil || session == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Not authenticated",
+		})
+	}
+
+	if session.ImpersonatedBy == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Not an impersonation session",
+		})
+	}
+
+	if err := h.authService.DeleteSession(session.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to end impersonation",
+		})
+	}
+
+	h.authService.LogUserActivity(user.ID, *session.ImpersonatedBy, "impersonation_ended", "{}", c.IP())
+
+	return c.JSON(fiber.Map{
+		"message": "Impersonation ended",
 	})
 }
 
@@ -219,8 +318,18 @@ func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
 		})
 	}
 
-	// Update password directly
-	// TODO: In production, should verify old password first
+	// Verify old password first
+	if err := h.authService.VerifyUserPassword(user.ID, req.OldPassword); err != nil {
+		if err == services.ErrInvalidCredentials {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Old password is incorrect",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to verify password",
+		})
+	}
+
 	err := h.authService.UpdateUser(user.ID, map[string]interface{}{
 		"password": req.NewPassword,
 	})
@@ -230,7 +339,78 @@ func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
 		})
 	}
 
+	// Cut off all other sessions, keep the current one alive
+	sessionsDeleted, err := h.authService.DeleteSessionsForUser(user.ID, middleware.GetSessionID(c))
+	if err != nil {
+		log.Printf("Failed to invalidate sessions for user %d: %v", user.ID, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message":          "Password changed successfully",
+		"sessions_deleted": sessionsDeleted,
+	})
+}
+
+// GetPreferences returns the current user's UI preferences
+// GET /api/auth/preferences
+func (h *AuthHandler) GetPreferences(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Not authenticated",
+		})
+	}
+
+	prefs, err := h.preferenceService.GetPreferences(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch preferences",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"preferences": prefs,
+	})
+}
+
+// UpdatePreferences merges the given keys into the current user's UI preferences.
+// A null value removes the key.
+// PUT /api/auth/preferences
+func (h *AuthHandler) UpdatePreferences(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Not authenticated",
+		})
+	}
+
+	var req map[string]*string
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := h.preferenceService.UpdatePreferences(user.ID, req); err != nil {
+		if errors.Is(err, services.ErrUnknownPreference) || errors.Is(err, services.ErrPreferenceTooLarge) ||
+			errors.Is(err, services.ErrInvalidPreferenceValue) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update preferences",
+		})
+	}
+
+	prefs, err := h.preferenceService.GetPreferences(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch preferences",
+		})
+	}
+
 	return c.JSON(fiber.Map{
-		"message": "Password changed successfully",
+		"preferences": prefs,
 	})
 }
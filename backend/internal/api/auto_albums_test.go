@@ -0,0 +1,81 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCreateAutoAlbumsHandler(t *testing.T) {
+	db := newTestDB(t)
+	h := NewAlbumHandler(services.NewAlbumService(db.DB), services.NewSettingsService(db.DB))
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	other := seedUser(t, db.DB, "other", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	grantFolder(t, db.DB, owner.ID, folder, "read")
+	seedFile(t, db.DB, folder, "2023/a.jpg", "image")
+	seedFile(t, db.DB, folder, "2024/b.jpg", "image")
+
+	path := fmt.Sprintf("/api/folders/%d/auto-albums", folder)
+	cases := []struct {
+		name        string
+		user        *models.User
+		path        string
+		wantStatus  int
+		wantCreated int
+	}{
+		{"anonymous", nil, path, fiber.StatusUnauthorized, 0},
+		{"invalid id", owner, "/api/folders/abc/auto-albums", fiber.StatusBadRequest, 0},
+		{"missing folder", owner, fmt.Sprintf("/api/folders/%d/auto-albums", folder+100), fiber.StatusNotFound, 0},
+		{"no access", other, path, fiber.StatusForbidden, 0},
+		{"owner", owner, path, fiber.StatusCreated, 2},
+		{"owner again", owner, path, fiber.StatusCreated, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Post("/api/folders/:id/auto-albums", asUser(tc.user), h.CreateAutoAlbums)
+
+			status, body := doRequest(t, app, http.MethodPost, tc.path, "", nil)
+			if status != tc.wantStatus {
+				t.Fatalf("status %d, body %v, want %d", status, body, tc.wantStatus)
+			}
+			if status != fiber.StatusCreated {
+				return
+			}
+			if got := int(body["created"].(float64)); got != tc.wantCreated {
+				t.Errorf("created = %d, want %d", got, tc.wantCreated)
+			}
+			if albums := body["albums"].([]interface{}); len(albums) != tc.wantCreated {
+				t.Errorf("returned %d albums, want %d", len(albums), tc.wantCreated)
+			}
+		})
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT a.name, af.folder_id, af.path_prefix FROM albums_v2 a
+		INNER JOIN album_folders af ON af.album_id = a.id
+		WHERE a.owner_id = ? ORDER BY a.name
+	`, owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var name, prefix string
+		var folderID int64
+		rows.Scan(&name, &folderID, &prefix)
+		got = append(got, fmt.Sprintf("%s:%d:%s", name, folderID, prefix))
+	}
+	want := []string{fmt.Sprintf("2023:%d:2023/", folder), fmt.Sprintf("2024:%d:2024/", folder)}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("album configs = %v, want %v", got, want)
+	}
+}
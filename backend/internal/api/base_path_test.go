@@ -0,0 +1,82 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/services"
+)
+
+func TestBasePathDeployment(t *testing.T) {
+	services.SetBasePath("/photos")
+	t.Cleanup(func() { services.SetBasePath("") })
+
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	shares := newTestShareHandler(t, db)
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	writeTestImage(t, filepath.Join(root, "a.png"), 64, 48)
+	file := seedFile(t, db.DB, folder, "a.png", "image")
+
+	app := fiber.New()
+	app.Use(middleware.BasePathMiddleware("/photos"))
+	app.Get("/api/files/:id", asUser(owner), h.GetFileByID)
+	app.Post("/api/files/:id/thumbnail-url", asUser(owner), h.CreateSignedThumbnailURL)
+	app.Get("/api/public/thumbnails/:id", h.GetSignedThumbnail)
+	app.Post("/api/shares", asUser(owner), shares.CreateShare)
+
+	status, resp := doRequest(t, app, http.MethodGet, fmt.Sprintf("/photos/api/files/%d", file), "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("prefixed request: status %d (%v)", status, resp)
+	}
+	if want := fmt.Sprintf("/photos/api/files/%d/thumbnail", file); resp["thumbnail_url"] != want {
+		t.Errorf("thumbnail_url = %v, want %s", resp["thumbnail_url"], want)
+	}
+	if large := resp["thumbnails"].(map[string]interface{})["large"]; large != fmt.Sprintf("/photos/api/files/%d/thumbnail?size=large", file) {
+		t.Errorf("large thumbnail = %v", large)
+	}
+
+	status, resp = doRequest(t, app, http.MethodPost, fmt.Sprintf("/photos/api/files/%d/thumbnail-url", file), "", nil)
+	signed, _ := resp["url"].(string)
+	if status != fiber.StatusOK || !strings.HasPrefix(signed, "/photos/api/public/thumbnails/") {
+		t.Fatalf("signed URL: status %d, url %q", status, signed)
+	}
+	thumb := sendRequest(t, app, http.MethodGet, signed, "", nil)
+	thumb.Body.Close()
+	if thumb.StatusCode != fiber.StatusOK {
+		t.Errorf("fetching the signed URL: status %d", thumb.StatusCode)
+	}
+
+	status, resp = doRequest(t, app, http.MethodPost, "/photos/api/shares", fmt.Sprintf(`{"share_type":"file","resource_id":%d}`, file), nil)
+	if status != fiber.StatusCreated {
+		t.Fatalf("create share: status %d (%v)", status, resp)
+	}
+	shareID := resp["share"].(map[string]interface{})["id"].(string)
+	if resp["url"] != "https://photos.example.com/photos/s/"+shareID {
+		t.Errorf("share url = %v", resp["url"])
+	}
+
+	cases := []struct {
+		path   string
+		status int
+	}{
+		{fmt.Sprintf("/api/files/%d", file), fiber.StatusOK},
+		{fmt.Sprintf("/photosx/api/files/%d", file), fiber.StatusNotFound},
+		{fmt.Sprintf("/photos/photos/api/files/%d", file), fiber.StatusNotFound},
+	}
+	for _, tc := range cases {
+		resp := sendRequest(t, app, http.MethodGet, tc.path, "", nil)
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s: status %d, want %d", tc.path, resp.StatusCode, tc.status)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestBulkMoveFiles(t *testing.T) {
+	db := newTestDB(t)
+	h := NewAdminHandler(services.NewAuthService(db.DB), services.NewShareService(db.DB, nil), services.NewFolderService(db.DB))
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	source, target := t.TempDir(), t.TempDir()
+	sourceID := seedFolder(t, db.DB, source, admin.ID)
+	targetID := seedFolder(t, db.DB, target, admin.ID)
+	os.MkdirAll(filepath.Join(source, "2024"), 0755)
+	os.WriteFile(filepath.Join(source, "2024", "a.jpg"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(source, "b.jpg"), []byte("b"), 0644)
+	os.WriteFile(filepath.Join(target, "taken.jpg"), []byte("existing"), 0644)
+	os.WriteFile(filepath.Join(source, "taken.jpg"), []byte("c"), 0644)
+	a := seedFile(t, db.DB, sourceID, "2024/a.jpg", "image")
+	b := seedFile(t, db.DB, sourceID, "b.jpg", "image")
+	taken := seedFile(t, db.DB, sourceID, "taken.jpg", "image")
+
+	app := fiber.New()
+	app.Post("/api/admin/files/bulk-move", asUser(admin), h.BulkMoveFiles)
+	move := func(body string) (int, map[string]interface{}) {
+		return doRequest(t, app, http.MethodPost, "/api/admin/files/bulk-move", body, nil)
+	}
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"invalid body", `{`, fiber.StatusBadRequest},
+		{"no files", fmt.Sprintf(`{"file_ids":[],"target_folder_id":%d}`, targetID), fiber.StatusBadRequest},
+		{"no target", fmt.Sprintf(`{"file_ids":[%d]}`, a), fiber.StatusBadRequest},
+		{"unknown target", fmt.Sprintf(`{"file_ids":[%d],"target_folder_id":9999}`, a), fiber.StatusNotFound},
+		{"invalid conflict policy", fmt.Sprintf(`{"file_ids":[%d],"target_folder_id":%d,"on_conflict":"overwrite"}`, a, targetID), fiber.StatusBadRequest},
+		{"conflict", fmt.Sprintf(`{"file_ids":[%d],"target_folder_id":%d,"on_conflict":"fail"}`, taken, targetID), fiber.StatusConflict},
+	}
+	for _, tc := range cases {
+		if status, body := move(tc.body); status != tc.want {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.want, body)
+		}
+	}
+
+	status, body := move(fmt.Sprintf(`{"file_ids":[%d,%d],"target_folder_id":%d}`, a, b, targetID))
+	if status != fiber.StatusOK {
+		t.Fatalf("move: status %d, body %v", status, body)
+	}
+	result := body["result"].(map[string]interface{})
+	if len(result["moved"].([]interface{})) != 2 || len(result["skipped"].([]interface{})) != 0 {
+		t.Errorf("result = %v, want 2 moved", result)
+	}
+
+	for id, rel := range map[int64]string{a: filepath.Join("2024", "a.jpg"), b: "b.jpg"} {
+		if _, err := os.Stat(filepath.Join(target, rel)); err != nil {
+			t.Errorf("%s not moved: %v", rel, err)
+		}
+		var folderID int64
+		var relativePath string
+		if err := db.QueryRow("SELECT folder_id, relative_path FROM file_folder_mappings WHERE file_id = ?", id).Scan(&folderID, &relativePath); err != nil {
+			t.Fatalf("read mapping of %s: %v", rel, err)
+		}
+		if folderID != targetID || relativePath != rel {
+			t.Errorf("%s mapped to folder %d at %s, want folder %d", rel, folderID, relativePath, targetID)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setCacheValidators sets ETag and Last-Modified for a file served from disk, based on the
+// file ID, modification time and size. Returns true if the client's cached copy is still
+// valid, in which case the caller should respond with 304 Not Modified.
+func setCacheValidators(c *fiber.Ctx, fileID int64, path string) bool {
+	return setVariantCacheValidators(c, fileID, path, "")
+}
+
+// setVariantCacheValidators is setCacheValidators for a derived copy of the file (e.g. a
+// metadata-free copy); the variant becomes part of the ETag so caches never mix the two
+func setVariantCacheValidators(c *fiber.Ctx, fileID int64, path, variant string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	modTime := info.ModTime().UTC().Truncate(time.Second)
+	etag := fmt.Sprintf(`"%d-%x-%x"`, fileID, info.ModTime().UnixNano(), info.Size())
+	if variant != "" {
+		etag = fmt.Sprintf(`"%d-%x-%x-%s"`, fileID, info.ModTime().UnixNano(), info.Size(), variant)
+	}
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, modTime.Format(http.TimeFormat))
+
+	// If-None-Match takes precedence over If-Modified-Since
+	if inm := c.Get(fiber.HeaderIfNoneMatch); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		// The static file sender checks If-Modified-Since on its own; drop it so a
+		// mismatched ETag always gets the full response
+		c.Request().Header.Del(fiber.HeaderIfModifiedSince)
+		return false
+	}
+
+	if ims := c.Get(fiber.HeaderIfModifiedSince); ims != "" {
+		if t, err := http.ParseTime(ims); err == nil && !modTime.After(t) {
+			return true
+		}
+	}
+
+	return false
+}
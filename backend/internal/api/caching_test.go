@@ -0,0 +1,82 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestConditionalFileRequests(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	shareHandler := newTestShareHandler(t, db)
+	shareService := services.NewShareService(db.DB, nil)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	writeTestImage(t, filepath.Join(root, "a.png"), 32, 32)
+	file := seedFile(t, db.DB, folder, "a.png", "image")
+
+	share, err := shareService.CreateShare("file", file, owner.ID, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	token, err := shareService.GenerateAccessToken(share.ID)
+	if err != nil {
+		t.Fatalf("access token: %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/api/public/files/:id/download", shareHandler.DownloadPublicFile)
+	app.Use(asUser(owner))
+	app.Get("/api/files/:id/thumbnail", h.GetFileThumbnail)
+	app.Get("/api/files/:id/download", h.DownloadFile)
+	app.Get("/api/files/:id/raw", h.GetFileRaw)
+
+	paths := []string{
+		fmt.Sprintf("/api/files/%d/thumbnail", file),
+		fmt.Sprintf("/api/files/%d/download", file),
+		fmt.Sprintf("/api/files/%d/raw", file),
+		fmt.Sprintf("/api/public/files/%d/download?token=%s", file, token),
+	}
+	for _, path := range paths {
+		resp := sendRequest(t, app, http.MethodGet, path, "", nil)
+		resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("%s: status %d", path, resp.StatusCode)
+		}
+		etag := resp.Header.Get("ETag")
+		lastModified := resp.Header.Get("Last-Modified")
+		if etag == "" || lastModified == "" {
+			t.Fatalf("%s: missing validators (ETag %q, Last-Modified %q)", path, etag, lastModified)
+		}
+
+		stale := time.Now().Add(-24 * time.Hour).UTC().Format(http.TimeFormat)
+		cases := []struct {
+			name    string
+			headers map[string]string
+			want    int
+		}{
+			{"matching etag", map[string]string{"If-None-Match": etag}, fiber.StatusNotModified},
+			{"weak etag in list", map[string]string{"If-None-Match": `"other", W/` + etag}, fiber.StatusNotModified},
+			{"other etag", map[string]string{"If-None-Match": `"other"`}, fiber.StatusOK},
+			{"etag wins over date", map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": lastModified}, fiber.StatusOK},
+			{"not modified since", map[string]string{"If-Modified-Since": lastModified}, fiber.StatusNotModified},
+			{"modified since", map[string]string{"If-Modified-Since": stale}, fiber.StatusOK},
+		}
+		for _, tc := range cases {
+			resp := sendRequest(t, app, http.MethodGet, path, "", tc.headers)
+			resp.Body.Close()
+			if resp.StatusCode != tc.want {
+				t.Errorf("%s %s: status %d, want %d", path, tc.name, resp.StatusCode, tc.want)
+			}
+		}
+	}
+}
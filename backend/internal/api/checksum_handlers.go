@@ -0,0 +1,99 @@
+package api
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/services"
+)
+
+// HeaderContentSHA256 carries the hex SHA-256 of a downloaded file's bytes
+const HeaderContentSHA256 = "X-Content-SHA256"
+
+// downloadChecksums adds HeaderContentSHA256 to file downloads
+var downloadChecksums = false
+
+// SetDownloadChecksums enables the SHA-256 header on file downloads. The first download
+// of a file hashes it; later downloads reuse the stored hash until the file changes.
+func SetDownloadChecksums(enabled bool) {
+	downloadChecksums = enabled
+}
+
+// setChecksumHeader sets HeaderContentSHA256 for a download of path when enabled.
+// path is hashed directly, without storing, when it isn't the original (e.g. a stripped copy).
+func setChecksumHeader(c *fiber.Ctx, hashes *services.ContentHashService, fileID int64, path string, original bool) {
+	if !downloadChecksums {
+		return
+	}
+
+	var hash string
+	var err error
+	if original {
+		hash, err = currentContentHash(hashes, fileID, path)
+	} else {
+		hash, err = services.ComputeContentHash(path)
+	}
+	if err != nil {
+		log.Printf("Failed to hash file %d for download: %v", fileID, err)
+		return
+	}
+	c.Set(HeaderContentSHA256, hash)
+}
+
+// currentContentHash returns the stored hash of a file, rehashing it if it changed on disk
+func currentContentHash(hashes *services.ContentHashService, fileID int64, path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	return hashes.GetOrComputeCurrent(fileID, path, info.ModTime())
+}
+
+// GetFileChecksum returns the SHA-256 of a file's content, hashing it if none is stored
+// GET /api/files/:id/checksum
+func (h *Handler) GetFileChecksum(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid file ID"})
+	}
+
+	isServerOwner := user.Role == "server_owner"
+	if !isServerOwner {
+		hasAccess, err := h.permService.CheckFileAccess(user.ID, id, isServerOwner)
+		if err != nil || !hasAccess {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Access denied",
+			})
+		}
+	}
+
+	filePath, err := h.folderService.ResolveAbsolutePath(id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "File not found"})
+	}
+
+	hash, err := currentContentHash(h.contentHashes, id, filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c.Status(404).JSON(fiber.Map{"error": "File not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to compute checksum"})
+	}
+
+	return c.JSON(fiber.Map{
+		"file_id":   id,
+		"algorithm": "sha256",
+		"checksum":  hash,
+	})
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sha256Hex returns the hex SHA-256 of a file on disk
+func sha256Hex(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownloadChecksums(t *testing.T) {
+	SetDownloadChecksums(true)
+	t.Cleanup(func() { SetDownloadChecksums(false) })
+
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	shares := newTestShareHandler(t, db)
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	stranger := seedUser(t, db.DB, "stranger", "user")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	path := filepath.Join(root, "a.png")
+	writeTestImage(t, path, 40, 30)
+	id := seedFile(t, db.DB, folder, "a.png", "image")
+
+	share, err := shares.shareService.CreateShare("file", id, owner.ID, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	token, err := shares.shareService.GenerateAccessToken(share.ID)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/api/files/:id/download", asUser(owner), h.DownloadFile)
+	app.Get("/api/files/:id/checksum", asUser(owner), h.GetFileChecksum)
+	app.Get("/api/public/files/:id/download", shares.DownloadPublicFile)
+	downloadPath := fmt.Sprintf("/api/files/%d/download", id)
+	publicPath := fmt.Sprintf("/api/public/files/%d/download?token=%s", id, url.QueryEscape(token))
+
+	want := sha256Hex(t, path)
+	for _, p := range []string{downloadPath, publicPath} {
+		resp := sendRequest(t, app, http.MethodGet, p, "", nil)
+		resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("%s: status %d", p, resp.StatusCode)
+		}
+		if got := resp.Header.Get(HeaderContentSHA256); got != want {
+			t.Errorf("%s: checksum %q, want %q", p, got, want)
+		}
+	}
+	checksum := func() (int, map[string]interface{}) {
+		return doRequest(t, app, http.MethodGet, fmt.Sprintf("/api/files/%d/checksum", id), "", nil)
+	}
+	if status, resp := checksum(); status != fiber.StatusOK || resp["checksum"] != want || resp["algorithm"] != "sha256" {
+		t.Errorf("checksum endpoint: status %d (%v), want %s", status, resp, want)
+	}
+
+	// The header is opt-in; the checksum endpoint is always available
+	SetDownloadChecksums(false)
+	resp := sendRequest(t, app, http.MethodGet, downloadPath, "", nil)
+	resp.Body.Close()
+	if got := resp.Header.Get(HeaderContentSHA256); got != "" {
+		t.Errorf("checksums disabled: header %q", got)
+	}
+
+	// An edit in place must not keep reporting the stored hash
+	writeTestImage(t, path, 30, 40)
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(path, later, later)
+	if status, resp := checksum(); status != fiber.StatusOK || resp["checksum"] != sha256Hex(t, path) {
+		t.Errorf("after edit: status %d (%v), want %s", status, resp, sha256Hex(t, path))
+	}
+
+	denied := fiber.New()
+	denied.Get("/api/files/:id/checksum", asUser(stranger), h.GetFileChecksum)
+	if status, _ := doRequest(t, denied, http.MethodGet, fmt.Sprintf("/api/files/%d/checksum", id), "", nil); status != fiber.StatusForbidden {
+		t.Errorf("stranger: status %d, want 403", status)
+	}
+}
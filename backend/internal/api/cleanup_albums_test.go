@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestCleanupReportsEmptiedAlbums(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	admin := seedUser(t, db.DB, "admin", "admin")
+	folder := seedFolder(t, db.DB, t.TempDir(), admin.ID)
+	seedFile(t, db.DB, folder, "deleted.jpg", "image")
+
+	albumService := services.NewAlbumService(db.DB)
+	album, _ := albumService.CreateAlbum("Gone", "", admin.ID)
+	albumService.AddFolders(album.ID, []services.FolderConfig{{FolderID: folder}}, admin.ID, true)
+	if err := services.NewSettingsService(db.DB).SetSetting("cleanup_empty_albums", services.EmptyAlbumsRemove); err != nil {
+		t.Fatalf("set policy: %v", err)
+	}
+
+	app := fiber.New()
+	app.Post("/api/files/cleanup", asUser(admin), h.CleanupDeletedFiles)
+	status, body := doRequest(t, app, http.MethodPost, "/api/files/cleanup", "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, body %v", status, body)
+	}
+	if body["deleted"] != float64(1) {
+		t.Errorf("deleted = %v, want 1", body["deleted"])
+	}
+	emptied := body["empty_albums"].([]interface{})
+	if len(emptied) != 1 {
+		t.Fatalf("empty_albums = %v, want the removed album", emptied)
+	}
+	entry := emptied[0].(map[string]interface{})
+	if entry["id"] != float64(album.ID) || entry["name"] != "Gone" || entry["action"] != "removed" {
+		t.Errorf("empty album entry = %v", entry)
+	}
+	if _, err := albumService.GetAlbum(album.ID); err != services.ErrAlbumNotFound {
+		t.Errorf("album still exists (%v)", err)
+	}
+}
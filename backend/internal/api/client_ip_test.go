@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Requests sent through app.Test come from 0.0.0.0
+const testPeerIP = "0.0.0.0"
+
+// newProxyApp returns an app with the trusted proxy settings main applies
+func newProxyApp(proxies []string, header string) *fiber.App {
+	if len(proxies) == 0 {
+		header = ""
+	}
+	return fiber.New(fiber.Config{
+		ProxyHeader:             header,
+		EnableTrustedProxyCheck: len(proxies) > 0,
+		TrustedProxies:          proxies,
+		EnableIPValidation:      true,
+	})
+}
+
+func TestClientIPFromTrustedProxy(t *testing.T) {
+	cases := []struct {
+		name    string
+		proxies []string
+		header  string
+		request map[string]string
+		want    string
+	}{
+		{"no proxies configured", nil, fiber.HeaderXForwardedFor, map[string]string{"X-Forwarded-For": "203.0.113.9"}, testPeerIP},
+		{"untrusted peer", []string{"10.0.0.0/8"}, fiber.HeaderXForwardedFor, map[string]string{"X-Forwarded-For": "203.0.113.9"}, testPeerIP},
+		{"trusted peer", []string{testPeerIP}, fiber.HeaderXForwardedFor, map[string]string{"X-Forwarded-For": "203.0.113.9"}, "203.0.113.9"},
+		{"trusted peer without header", []string{testPeerIP}, fiber.HeaderXForwardedFor, nil, testPeerIP},
+		{"malformed header", []string{testPeerIP}, fiber.HeaderXForwardedFor, map[string]string{"X-Forwarded-For": "garbage"}, testPeerIP},
+		{"custom header", []string{testPeerIP}, "X-Real-IP", map[string]string{"X-Real-IP": "198.51.100.7", "X-Forwarded-For": "203.0.113.9"}, "198.51.100.7"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newProxyApp(tc.proxies, tc.header)
+			app.Get("/ip", func(c *fiber.Ctx) error {
+				return c.JSON(fiber.Map{"ip": c.IP()})
+			})
+			_, body := doRequest(t, app, http.MethodGet, "/ip", "", tc.request)
+			if body["ip"] != tc.want {
+				t.Errorf("client IP = %v, want %s", body["ip"], tc.want)
+			}
+		})
+	}
+}
+
+func TestShareAccessLogsForwardedIP(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+	share, err := h.shareService.CreateShare("file", file, owner.ID, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	forwarded := map[string]string{"X-Forwarded-For": "203.0.113.9"}
+
+	lastLoggedIP := func() string {
+		t.Helper()
+		var ip string
+		if err := db.QueryRow("SELECT ip_address FROM share_access_log WHERE share_id = ? ORDER BY id DESC LIMIT 1", share.ID).Scan(&ip); err != nil {
+			t.Fatalf("read access log: %v", err)
+		}
+		return ip
+	}
+
+	// Not behind a trusted proxy: the header is client-controlled and ignored
+	app := newProxyApp(nil, fiber.HeaderXForwardedFor)
+	app.Get("/s/:id", h.AccessShare)
+	if status, _ := doRequest(t, app, http.MethodGet, "/s/"+share.ID, "", forwarded); status != fiber.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	if ip := lastLoggedIP(); ip != testPeerIP {
+		t.Errorf("untrusted: logged %q, want %q", ip, testPeerIP)
+	}
+
+	app = newProxyApp([]string{testPeerIP}, fiber.HeaderXForwardedFor)
+	app.Get("/s/:id", h.AccessShare)
+	if status, _ := doRequest(t, app, http.MethodGet, "/s/"+share.ID, "", forwarded); status != fiber.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	if ip := lastLoggedIP(); ip != "203.0.113.9" {
+		t.Errorf("trusted: logged %q, want 203.0.113.9", ip)
+	}
+}
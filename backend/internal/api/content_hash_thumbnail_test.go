@@ -0,0 +1,59 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestThumbnailsOfIdenticalFilesShareContentHash(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	h.thumbService.SetContentHashes(services.NewContentHashService(db.DB))
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	writeTestImage(t, filepath.Join(root, "a.png"), 40, 30)
+	data, _ := os.ReadFile(filepath.Join(root, "a.png"))
+	os.WriteFile(filepath.Join(root, "b.png"), data, 0644)
+	first := seedFile(t, db.DB, folder, "a.png", "image")
+	second := seedFile(t, db.DB, folder, "b.png", "image")
+
+	app := fiber.New()
+	app.Get("/api/files/:id/thumbnail", asUser(owner), h.GetFileThumbnail)
+
+	var bodies [][]byte
+	for _, id := range []int64{first, second} {
+		resp := sendRequest(t, app, http.MethodGet, fmt.Sprintf("/api/files/%d/thumbnail", id), "", nil)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK || resp.Header.Get("X-Thumbnail-Placeholder") != "" {
+			t.Fatalf("file %d: status %d, placeholder %q", id, resp.StatusCode, resp.Header.Get("X-Thumbnail-Placeholder"))
+		}
+		bodies = append(bodies, body)
+	}
+	if !bytes.Equal(bodies[0], bodies[1]) {
+		t.Error("identical files served different thumbnails")
+	}
+
+	var hashes []string
+	for _, id := range []int64{first, second} {
+		var hash string
+		if err := db.QueryRow("SELECT content_hash FROM files WHERE id = ?", id).Scan(&hash); err != nil {
+			t.Fatalf("file %d has no content hash: %v", id, err)
+		}
+		hashes = append(hashes, hash)
+	}
+	if hashes[0] == "" || hashes[0] != hashes[1] {
+		t.Errorf("content hashes = %v, want two equal hashes", hashes)
+	}
+}
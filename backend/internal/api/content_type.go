@@ -0,0 +1,34 @@
+package api
+
+import (
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sendFileAs sends a file with an explicit Content-Type. SendFile alone guesses the
+// type from the extension, which is wrong for extension-less or mislabeled files.
+func sendFileAs(c *fiber.Ctx, path, contentType string) error {
+	if err := c.SendFile(path); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	return nil
+}
+
+// sendThumbnail streams a cached thumbnail as JPEG. Thumbnails are regenerated in
+// place when their source changes, and SendFile keeps opened files cached for a few
+// seconds, so it would keep serving the old image after a regeneration.
+func sendThumbnail(c *fiber.Ctx, thumbPath string) error {
+	f, err := os.Open(thumbPath)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	c.Set(fiber.HeaderContentType, "image/jpeg")
+	return c.SendStream(f, int(info.Size()))
+}
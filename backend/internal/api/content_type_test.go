@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestFileContentTypes(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	writeTestImage(t, filepath.Join(root, "photo.jpg"), 16, 16)
+	os.WriteFile(filepath.Join(root, "clip.mp4"), []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), 0644)
+	// A JPEG without an extension is identified by its content
+	jpeg, _ := os.ReadFile(filepath.Join(root, "photo.jpg"))
+	os.WriteFile(filepath.Join(root, "IMG_0001"), jpeg, 0644)
+
+	files := map[string]int64{
+		"photo.jpg": seedFile(t, db.DB, folder, "photo.jpg", "image"),
+		"clip.mp4":  seedFile(t, db.DB, folder, "clip.mp4", "video"),
+		"IMG_0001":  seedFile(t, db.DB, folder, "IMG_0001", "image"),
+	}
+
+	app := fiber.New()
+	app.Use(asUser(owner))
+	app.Get("/api/files/:id/download", h.DownloadFile)
+	app.Get("/api/files/:id/raw", h.GetFileRaw)
+	app.Get("/api/files/:id/thumbnail", h.GetFileThumbnail)
+
+	cases := []struct {
+		file, endpoint, want string
+	}{
+		{"photo.jpg", "download", "image/jpeg"},
+		{"photo.jpg", "download?disposition=inline", "image/jpeg"},
+		{"photo.jpg", "raw", "image/jpeg"},
+		{"clip.mp4", "download", "video/mp4"},
+		{"clip.mp4", "raw", "video/mp4"},
+		{"IMG_0001", "download", "image/jpeg"},
+		{"IMG_0001", "raw", "image/jpeg"},
+		{"photo.jpg", "thumbnail", "image/jpeg"},
+	}
+	for _, tc := range cases {
+		resp := sendRequest(t, app, http.MethodGet, fmt.Sprintf("/api/files/%d/%s", files[tc.file], tc.endpoint), "", nil)
+		resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("%s %s: status %d", tc.file, tc.endpoint, resp.StatusCode)
+			continue
+		}
+		if got := resp.Header.Get(fiber.HeaderContentType); got != tc.want {
+			t.Errorf("%s %s: Content-Type %q, want %q", tc.file, tc.endpoint, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+)
+
+func newCSRFTestApp() *fiber.App {
+	app := fiber.New()
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) }
+	app.Get("/api/files", middleware.CSRFMiddleware(), ok)
+	app.Post("/api/files", middleware.CSRFMiddleware(), ok)
+	app.Delete("/api/files", middleware.CSRFMiddleware(), ok)
+	return app
+}
+
+func TestCSRFMiddleware(t *testing.T) {
+	app := newCSRFTestApp()
+	const token = "0123456789abcdef"
+
+	cases := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		want    int
+	}{
+		{"safe method", "GET", map[string]string{"Cookie": "session_id=s"}, fiber.StatusNoContent},
+		{"no session", "POST", nil, fiber.StatusNoContent},
+		{"bearer only", "POST", map[string]string{"Authorization": "Bearer s"}, fiber.StatusNoContent},
+		{"cookie without token", "POST", map[string]string{"Cookie": "session_id=s"}, fiber.StatusForbidden},
+		{"cookie without header", "POST", map[string]string{"Cookie": "session_id=s; csrf_token=" + token}, fiber.StatusForbidden},
+		{"header without cookie", "POST", map[string]string{"Cookie": "session_id=s", "X-CSRF-Token": token}, fiber.StatusForbidden},
+		{"mismatched token", "POST", map[string]string{"Cookie": "session_id=s; csrf_token=" + token, "X-CSRF-Token": "other"}, fiber.StatusForbidden},
+		{"matching token", "POST", map[string]string{"Cookie": "session_id=s; csrf_token=" + token, "X-CSRF-Token": token}, fiber.StatusNoContent},
+		{"matching token on delete", "DELETE", map[string]string{"Cookie": "session_id=s; csrf_token=" + token, "X-CSRF-Token": token}, fiber.StatusNoContent},
+		// The cookie authenticates the request, so adding a Bearer header must not skip the check
+		{"cookie and bearer", "POST", map[string]string{"Cookie": "session_id=s", "Authorization": "Bearer s"}, fiber.StatusForbidden},
+		{"cookie and other bearer", "POST", map[string]string{"Cookie": "session_id=s", "Authorization": "Bearer other"}, fiber.StatusForbidden},
+	}
+	for _, tc := range cases {
+		if status, _ := doRequest(t, app, tc.method, "/api/files", "", tc.headers); status != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, status, tc.want)
+		}
+	}
+}
+
+func TestCSRFTokenIssuedOnce(t *testing.T) {
+	app := newCSRFTestApp()
+
+	csrfCookie := func(headers map[string]string) string {
+		resp := sendRequest(t, app, "GET", "/api/files", "", headers)
+		resp.Body.Close()
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == middleware.CSRFCookieName {
+				if cookie.HttpOnly {
+					t.Errorf("CSRF cookie must be readable by scripts")
+				}
+				return cookie.Value
+			}
+		}
+		return ""
+	}
+
+	if token := csrfCookie(map[string]string{"Cookie": "session_id=s"}); len(token) != 64 {
+		t.Errorf("session without token: issued %q, want a 64 character token", token)
+	}
+	if token := csrfCookie(map[string]string{"Cookie": "session_id=s; csrf_token=existing"}); token != "" {
+		t.Errorf("session with token: reissued %q", token)
+	}
+	if token := csrfCookie(nil); token != "" {
+		t.Errorf("no session: issued %q", token)
+	}
+}
+
+func TestCSRFOriginCheck(t *testing.T) {
+	middleware.SetAllowedOrigins([]string{"https://photos.example.com/"})
+	t.Cleanup(func() { middleware.SetAllowedOrigins(nil) })
+	app := newCSRFTestApp()
+	const token = "0123456789abcdef"
+	session := "session_id=s; csrf_token=" + token
+
+	cases := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"no origin", map[string]string{"Cookie": session, "X-CSRF-Token": token}, fiber.StatusNoContent},
+		{"same origin", map[string]string{"Cookie": session, "X-CSRF-Token": token, "Origin": "http://example.com"}, fiber.StatusNoContent},
+		{"allowed origin", map[string]string{"Cookie": session, "X-CSRF-Token": token, "Origin": "https://photos.example.com"}, fiber.StatusNoContent},
+		{"other origin", map[string]string{"Cookie": session, "X-CSRF-Token": token, "Origin": "https://evil.example.com"}, fiber.StatusForbidden},
+		{"bearer from other origin", map[string]string{"Authorization": "Bearer s", "Origin": "https://evil.example.com"}, fiber.StatusNoContent},
+	}
+	for _, tc := range cases {
+		if status, _ := doRequest(t, app, "POST", "/api/files", "", tc.headers); status != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, status, tc.want)
+		}
+	}
+}
+
+func TestCSRFCookieSecure(t *testing.T) {
+	app := newCSRFTestApp()
+	secure := func(headers map[string]string) bool {
+		headers["Cookie"] = "session_id=s"
+		resp := sendRequest(t, app, "GET", "/api/files", "", headers)
+		resp.Body.Close()
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == middleware.CSRFCookieName {
+				return cookie.Secure
+			}
+		}
+		t.Fatal("no CSRF cookie issued")
+		return false
+	}
+
+	if secure(map[string]string{}) {
+		t.Errorf("cookie marked Secure on a plain HTTP request")
+	}
+	if !secure(map[string]string{"X-Forwarded-Proto": "https"}) {
+		t.Errorf("cookie not marked Secure on a request forwarded over HTTPS")
+	}
+
+	middleware.SetSecureCookies(true)
+	t.Cleanup(func() { middleware.SetSecureCookies(false) })
+	if !secure(map[string]string{}) {
+		t.Errorf("cookie not marked Secure with COOKIE_SECURE")
+	}
+}
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"context"
+	"time"
+
 	"awesome-sharing/internal/middleware"
 	"awesome-sharing/internal/services"
 	"github.com/gofiber/fiber/v2"
@@ -75,6 +78,45 @@ func (h *DomainConfigHandlers) SaveDomainConfig(c *fiber.Ctx) error {
 	return c.JSON(config)
 }
 
+// domainCheckTimeout bounds the optional reachability check
+const domainCheckTimeout = 5 * time.Second
+
+// TestDomainConfig godoc
+// @Summary Test domain configuration
+// @Description Return the base URL and a sample share URL built from the current configuration,
+// @Description optionally checking that the server answers there (Admin only)
+// @Tags domain-config
+// @Produce json
+// @Param check query bool false "Send a HEAD request to the configured host"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/domain-config/test [get]
+func (h *DomainConfigHandlers) TestDomainConfig(c *fiber.Ctx) error {
+	config, err := h.service.GetConfig()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve domain configuration",
+		})
+	}
+
+	baseURL := services.BuildBaseURL(config)
+	response := fiber.Map{
+		"config":           config,
+		"base_url":         baseURL,
+		"sample_share_url": baseURL + "/s/example",
+	}
+
+	if c.QueryBool("check") {
+		ctx, cancel := context.WithTimeout(c.UserContext(), domainCheckTimeout)
+		defer cancel()
+		response["reachability"] = h.service.CheckReachability(ctx, baseURL)
+	}
+
+	return c.JSON(response)
+}
+
 type SaveDomainConfigRequest struct {
 	Protocol string `json:"protocol"` // http or https
 	Domain   string `json:"domain"`   // example.com or IP address
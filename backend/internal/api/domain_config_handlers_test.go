@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"awesome-sharing/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestTestDomainConfig(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	srvURL, _ := url.Parse(srv.URL)
+
+	cases := []struct {
+		name, protocol, domain, port string
+		query                        string
+		wantBase                     string
+		wantReachable                interface{}
+	}{
+		{"default port", "https", "photos.example.com", "443", "", "https://photos.example.com", nil},
+		{"custom port", "http", "photos.example.com", "8080", "", "http://photos.example.com:8080", nil},
+		{"reachable", "http", srvURL.Hostname(), srvURL.Port(), "?check=true", srv.URL, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestDB(t)
+			admin := seedUser(t, db.DB, "admin", "admin")
+			service := services.NewDomainConfigService(db)
+			if _, err := service.SaveConfig(tc.protocol, tc.domain, tc.port, admin.ID); err != nil {
+				t.Fatalf("save config: %v", err)
+			}
+			h := NewDomainConfigHandlers(service)
+
+			app := fiber.New()
+			app.Get("/api/domain-config/test", asUser(admin), h.TestDomainConfig)
+			status, body := doRequest(t, app, http.MethodGet, "/api/domain-config/test"+tc.query, "", nil)
+			if status != fiber.StatusOK {
+				t.Fatalf("status %d, body %v", status, body)
+			}
+			if body["base_url"] != tc.wantBase {
+				t.Errorf("base_url = %v, want %s", body["base_url"], tc.wantBase)
+			}
+			if body["sample_share_url"] != tc.wantBase+"/s/example" {
+				t.Errorf("sample_share_url = %v", body["sample_share_url"])
+			}
+
+			reach, checked := body["reachability"].(map[string]interface{})
+			if tc.wantReachable == nil {
+				if checked {
+					t.Errorf("reachability reported without check: %v", reach)
+				}
+				return
+			}
+			if !checked || reach["reachable"] != tc.wantReachable || reach["status_code"] != float64(http.StatusNoContent) {
+				t.Errorf("reachability = %v", body["reachability"])
+			}
+		})
+	}
+}
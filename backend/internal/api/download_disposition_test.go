@@ -0,0 +1,48 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestDownloadDisposition(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	writeTestImage(t, filepath.Join(root, "photo.png"), 16, 16)
+	file := seedFile(t, db.DB, folder, "photo.png", "image")
+
+	app := fiber.New()
+	app.Get("/api/files/:id/download", asUser(owner), h.DownloadFile)
+
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"", `attachment; filename="photo.png"`},
+		{"?disposition=attachment", `attachment; filename="photo.png"`},
+		{"?disposition=bogus", `attachment; filename="photo.png"`},
+		{"?disposition=inline", `inline; filename="photo.png"`},
+	}
+	for _, tc := range cases {
+		resp := sendRequest(t, app, http.MethodGet, fmt.Sprintf("/api/files/%d/download%s", file, tc.query), "", nil)
+		resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("%q: status %d", tc.query, resp.StatusCode)
+			continue
+		}
+		if got := resp.Header.Get(fiber.HeaderContentDisposition); got != tc.want {
+			t.Errorf("%q: Content-Disposition %q, want %q", tc.query, got, tc.want)
+		}
+		if got := resp.Header.Get(fiber.HeaderContentType); got != "image/png" {
+			t.Errorf("%q: Content-Type %q, want image/png", tc.query, got)
+		}
+	}
+}
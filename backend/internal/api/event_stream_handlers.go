@@ -0,0 +1,133 @@
+package api
+
+import (
+	"log"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+// eventStreamPingInterval keeps idle websocket connections alive through proxies
+const eventStreamPingInterval = 30 * time.Second
+
+type EventStreamHandler struct {
+	events      *services.EventDispatcher
+	permService *services.PermissionGroupService
+}
+
+func NewEventStreamHandler(events *services.EventDispatcher, permService *services.PermissionGroupService) *EventStreamHandler {
+	return &EventStreamHandler{
+		events:      events,
+		permService: permService,
+	}
+}
+
+// Upgrade rejects plain HTTP requests to the event stream
+func (h *EventStreamHandler) Upgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return c.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{
+			"error": "WebSocket upgrade required",
+		})
+	}
+	return c.Next()
+}
+
+// StreamEvents pushes scan progress, job updates and indexing events over a websocket.
+// Users only receive events for folders they can access and jobs they started.
+// GET /api/ws/events
+func (h *EventStreamHandler) StreamEvents() fiber.Handler {
+	return websocket.New(func(conn *websocket.Conn) {
+		user, _ := conn.Locals(middleware.UserContextKey).(*models.User)
+		if user == nil {
+			return
+		}
+		session, _ := conn.Locals(middleware.SessionContextKey).(*models.Session)
+
+		events, unsubscribe := h.events.Listen()
+		defer unsubscribe()
+
+		// The client doesn't send anything; reading detects when it goes away
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		// End the stream when the session would have expired
+		var expired <-chan time.Time
+		if session != nil {
+			timer := time.NewTimer(time.Until(session.ExpiresAt))
+			defer timer.Stop()
+			expired = timer.C
+		}
+
+		ping := time.NewTicker(eventStreamPingInterval)
+		defer ping.Stop()
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if !h.canSee(user, event) {
+					continue
+				}
+				if err := conn.WriteJSON(event); err != nil {
+					return
+				}
+			case <-ping.C:
+				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+					return
+				}
+			case <-expired:
+				conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session expired"))
+				return
+			case <-closed:
+				return
+			}
+		}
+	})
+}
+
+// canSee decides whether an event may be sent to a user
+func (h *EventStreamHandler) canSee(user *models.User, event services.Event) bool {
+	isAdmin := user.Role == "admin" || user.Role == "server_owner"
+	if isAdmin {
+		return true
+	}
+
+	data, _ := event.Data.(map[string]interface{})
+	switch event.Type {
+	case services.EventFileIndexed, services.EventScanStarted, services.EventScanProgress, services.EventScanCompleted:
+		folderID, ok := data["folder_id"].(int64)
+		if !ok {
+			return false
+		}
+		hasAccess, err := h.permService.CheckFolderAccess(user.ID, folderID, false)
+		if err != nil {
+			log.Printf("Failed to check folder access for event stream: %v", err)
+			return false
+		}
+		return hasAccess
+	case services.EventJobUpdated:
+		createdBy, ok := data["created_by"].(int64)
+		return ok && createdBy == user.ID
+	case services.EventShareCreated:
+		ownerID, ok := data["owner_id"].(int64)
+		return ok && ownerID == user.ID
+	}
+
+	// Deleted files and share accesses can't be attributed to a user
+	return false
+}
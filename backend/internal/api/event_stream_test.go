@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+// dialEventStream serves app on a local port and connects a websocket client to path
+func dialEventStream(t *testing.T, app *fiber.App, path string) *websocket.Conn {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+ln.Addr().String()+path, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// nextEvent emits events until conn receives one, since the server registers its
+// listener only after the handshake; returns the first event received
+func nextEvent(t *testing.T, conn *websocket.Conn, emit func()) services.Event {
+	t.Helper()
+	for attempt := 0; attempt < 50; attempt++ {
+		emit()
+		conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		var event services.Event
+		err := conn.ReadJSON(&event)
+		if err == nil {
+			return event
+		}
+		if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+			t.Fatalf("read: %v", err)
+		}
+	}
+	t.Fatal("no event received")
+	return services.Event{}
+}
+
+func TestEventStreamScopedToUser(t *testing.T) {
+	db := newTestDB(t)
+	dispatcher := services.NewEventDispatcher(db.DB)
+	h := NewEventStreamHandler(dispatcher, services.NewPermissionGroupService(db.DB))
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	user := seedUser(t, db.DB, "user", "user")
+	visible := seedFolder(t, db.DB, "/visible", admin.ID)
+	hidden := seedFolder(t, db.DB, "/hidden", admin.ID)
+	grantFolder(t, db.DB, user.ID, visible, "read")
+
+	cases := []struct {
+		name string
+		user *models.User
+		// Emitted before the expected event; must be filtered out for this user
+		filtered []services.Event
+	}{
+		{"admin sees everything", admin, nil},
+		{"user sees own folders and jobs", user, []services.Event{
+			{Type: services.EventScanProgress, Data: map[string]interface{}{"folder_id": hidden, "files_processed": 100}},
+			{Type: services.EventJobUpdated, Data: map[string]interface{}{"job_id": int64(1), "created_by": admin.ID}},
+			{Type: services.EventFileDeleted, Data: map[string]interface{}{"file_id": int64(1)}},
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/api/ws/events", asUser(tc.user), h.Upgrade, h.StreamEvents())
+			conn := dialEventStream(t, app, "/api/ws/events")
+
+			event := nextEvent(t, conn, func() {
+				for _, e := range tc.filtered {
+					dispatcher.Emit(e.Type, e.Data)
+				}
+				dispatcher.Emit(services.EventScanProgress, map[string]interface{}{"folder_id": visible, "files_processed": 200})
+			})
+			data, _ := event.Data.(map[string]interface{})
+			if event.Type != services.EventScanProgress || data["folder_id"] != float64(visible) || data["files_processed"] != float64(200) {
+				t.Errorf("received %s %v, want scan progress for folder %d", event.Type, event.Data, visible)
+			}
+
+			// A job the user started is delivered too; skip progress events left over
+			// from retries above
+			emitJob := func() {
+				dispatcher.Emit(services.EventJobUpdated, map[string]interface{}{"job_id": int64(7), "created_by": tc.user.ID})
+			}
+			for event = nextEvent(t, conn, emitJob); event.Type == services.EventScanProgress; {
+				event = nextEvent(t, conn, emitJob)
+			}
+			if event.Type != services.EventJobUpdated {
+				t.Errorf("received %s, want job.updated", event.Type)
+			}
+		})
+	}
+}
+
+func TestEventStreamRequiresUpgrade(t *testing.T) {
+	db := newTestDB(t)
+	h := NewEventStreamHandler(services.NewEventDispatcher(db.DB), services.NewPermissionGroupService(db.DB))
+	admin := seedUser(t, db.DB, "admin", "admin")
+
+	app := fiber.New()
+	app.Get("/api/ws/events", asUser(admin), h.Upgrade, h.StreamEvents())
+	if status, _ := doRequest(t, app, http.MethodGet, "/api/ws/events", "", nil); status != fiber.StatusUpgradeRequired {
+		t.Errorf("plain request: status %d, want 426", status)
+	}
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/services"
+)
+
+type EventSubscriptionHandler struct {
+	events *services.EventDispatcher
+}
+
+func NewEventSubscriptionHandler(events *services.EventDispatcher) *EventSubscriptionHandler {
+	return &EventSubscriptionHandler{events: events}
+}
+
+// ListSubscriptions returns all webhook subscriptions
+// GET /api/event-subscriptions
+func (h *EventSubscriptionHandler) ListSubscriptions(c *fiber.Ctx) error {
+	subs, err := h.events.ListSubscriptions()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch event subscriptions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"subscriptions": subs,
+		"total":         len(subs),
+		"event_types":   services.EventTypes,
+	})
+}
+
+// GetSubscription returns a specific webhook subscription
+// GET /api/event-subscriptions/:id
+func (h *EventSubscriptionHandler) GetSubscription(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid subscription ID",
+		})
+	}
+
+	sub, err := h.events.GetSubscription(id)
+	if err != nil {
+		if err == services.ErrSubscriptionNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Event subscription not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch event subscription",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"subscription": sub,
+	})
+}
+
+// CreateSubscription registers a new webhook
+// POST /api/event-subscriptions
+func (h *EventSubscriptionHandler) CreateSubscription(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	var req struct {
+		EventType string `json:"event_type"`
+		URL       string `json:"url"`
+		Secret    string `json:"secret"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	sub, err := h.events.CreateSubscription(req.EventType, req.URL, req.Secret, user.ID)
+	if err != nil {
+		if err == services.ErrInvalidEventType || err == services.ErrInvalidWebhookURL {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create event subscription",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"subscription": sub,
+	})
+}
+
+// UpdateSubscription updates a webhook subscription
+// PUT /api/event-subscriptions/:id
+func (h *EventSubscriptionHandler) UpdateSubscription(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid subscription ID",
+		})
+	}
+
+	existing, err := h.events.GetSubscription(id)
+	if err != nil {
+		if err == services.ErrSubscriptionNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Event subscription not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch event subscription",
+		})
+	}
+
+	var req struct {
+		EventType *string `json:"event_type"`
+		URL       *string `json:"url"`
+		Secret    *string `json:"secret"`
+		Enabled   *bool   `json:"enabled"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	eventType := existing.EventType
+	if req.EventType != nil {
+		eventType = *req.EventType
+	}
+	webhookURL := existing.URL
+	if req.URL != nil {
+		webhookURL = *req.URL
+	}
+	enabled := existing.Enabled
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	err = h.events.UpdateSubscription(id, eventType, webhookURL, req.Secret, enabled)
+	if err != nil {
+		if err == services.ErrInvalidEventType || err == services.ErrInvalidWebhookURL {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update event subscription",
+		})
+	}
+
+	sub, err := h.events.GetSubscription(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch updated event subscription",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"subscription": sub,
+	})
+}
+
+// DeleteSubscription removes a webhook subscription
+// DELETE /api/event-subscriptions/:id
+func (h *EventSubscriptionHandler) DeleteSubscription(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid subscription ID",
+		})
+	}
+
+	if err := h.events.DeleteSubscription(id); err != nil {
+		if err == services.ErrSubscriptionNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Event subscription not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete event subscription",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Event subscription deleted successfully",
+	})
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestCreateEventSubscription(t *testing.T) {
+	db := newTestDB(t)
+	h := NewEventSubscriptionHandler(services.NewEventDispatcher(db.DB))
+	admin := seedUser(t, db.DB, "admin", "admin")
+
+	app := fiber.New()
+	app.Post("/api/event-subscriptions", asUser(admin), h.CreateSubscription)
+
+	status, body := doRequest(t, app, "POST", "/api/event-subscriptions", `{"event_type":"file.indexed","url":"https://example.com/hook","secret":"s3cret"}`, nil)
+	if status != fiber.StatusCreated {
+		t.Fatalf("create: status %d body %v", status, body)
+	}
+	sub := body["subscription"].(map[string]interface{})
+	if sub["has_secret"] != true {
+		t.Errorf("has_secret = %v, want true", sub["has_secret"])
+	}
+	if _, ok := sub["secret"]; ok {
+		t.Errorf("the signing secret is exposed: %v", sub)
+	}
+
+	for _, bad := range []string{
+		`{"event_type":"nope","url":"https://example.com/hook"}`,
+		`{"event_type":"*","url":"example.com/hook"}`,
+	} {
+		if status, _ := doRequest(t, app, "POST", "/api/event-subscriptions", bad, nil); status != fiber.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", bad, status)
+		}
+	}
+}
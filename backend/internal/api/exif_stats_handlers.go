@@ -0,0 +1,100 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/services"
+)
+
+// GetAlbumExifStats returns the ISO, aperture, focal length and shutter speed
+// distributions of an album's photos
+// GET /api/albums-v2/:id/exif-stats
+func (h *AlbumHandler) GetAlbumExifStats(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid album ID",
+		})
+	}
+
+	album, err := h.albumService.GetAlbum(id)
+	if err != nil {
+		if err == services.ErrAlbumNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Album not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch album",
+		})
+	}
+
+	if !h.canAccessAlbum(user, album, false) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	stats, err := h.albumService.AlbumExifStats(ctx, id, albumViewerID(user, album))
+	if err != nil {
+		if isQueryTimeout(err) {
+			return queryTimeoutError(c)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to compute EXIF statistics",
+		})
+	}
+
+	return c.JSON(stats)
+}
+
+// GetFolderExifStats returns the ISO, aperture, focal length and shutter speed
+// distributions of a folder's photos
+// GET /api/folders/:id/exif-stats
+func (h *Handler) GetFolderExifStats(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid folder ID"})
+	}
+
+	hasAccess, err := h.permService.CheckFolderAccess(user.ID, id, user.Role == "server_owner")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !hasAccess {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied"})
+	}
+
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	stats, err := h.folderService.FolderExifStats(ctx, id)
+	if err != nil {
+		if isQueryTimeout(err) {
+			return queryTimeoutError(c)
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to compute EXIF statistics"})
+	}
+
+	return c.JSON(stats)
+}
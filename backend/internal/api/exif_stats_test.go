@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestExifStatsEndpoints(t *testing.T) {
+	db := newTestDB(t)
+	albumService := services.NewAlbumService(db.DB)
+	albums := NewAlbumHandler(albumService, services.NewSettingsService(db.DB))
+	h := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	viewer := seedUser(t, db.DB, "viewer", "user")
+	stranger := seedUser(t, db.DB, "stranger", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	grantFolder(t, db.DB, owner.ID, folder, "write")
+	grantFolder(t, db.DB, viewer.ID, folder, "read")
+	for i, iso := range []int{100, 100, 800} {
+		id := seedFile(t, db.DB, folder, fmt.Sprintf("%d.jpg", i), "image")
+		mustExec(t, db.DB, "INSERT INTO photo_metadata (file_id, iso) VALUES (?, ?)", id, iso)
+	}
+	album, err := albumService.CreateAlbum("Album", "", owner.ID)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	mustExec(t, db.DB, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '')", album.ID, folder)
+
+	cases := []struct {
+		name   string
+		user   *models.User
+		path   string
+		status int
+	}{
+		{"album owner", owner, fmt.Sprintf("/api/albums-v2/%d/exif-stats", album.ID), fiber.StatusOK},
+		{"album stranger", stranger, fmt.Sprintf("/api/albums-v2/%d/exif-stats", album.ID), fiber.StatusForbidden},
+		{"unknown album", owner, "/api/albums-v2/9999/exif-stats", fiber.StatusNotFound},
+		{"folder reader", viewer, fmt.Sprintf("/api/folders/%d/exif-stats", folder), fiber.StatusOK},
+		{"folder stranger", stranger, fmt.Sprintf("/api/folders/%d/exif-stats", folder), fiber.StatusForbidden},
+	}
+	for _, tc := range cases {
+		app := fiber.New()
+		app.Get("/api/albums-v2/:id/exif-stats", asUser(tc.user), albums.GetAlbumExifStats)
+		app.Get("/api/folders/:id/exif-stats", asUser(tc.user), h.GetFolderExifStats)
+
+		status, resp := doRequest(t, app, http.MethodGet, tc.path, "", nil)
+		if status != tc.status {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.status, resp)
+			continue
+		}
+		if status != fiber.StatusOK {
+			continue
+		}
+		iso := resp["iso"].([]interface{})
+		if resp["photos"] != float64(3) || len(iso) != 2 || iso[0].(map[string]interface{})["count"] != float64(2) {
+			t.Errorf("%s: unexpected stats %v", tc.name, resp)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+)
+
+// Facet buckets are derived from the wall-clock taken_at as stored (EXIF local time),
+// so substr is used instead of SQLite date functions, which would convert to UTC.
+
+// timeOfDaySQL buckets pm.taken_at into morning (5-11), afternoon (12-16),
+// evening (17-20) and night (21-4)
+const timeOfDaySQL = `CASE
+	WHEN pm.taken_at IS NULL THEN NULL
+	WHEN CAST(substr(pm.taken_at, 12, 2) AS INTEGER) BETWEEN 5 AND 11 THEN 'morning'
+	WHEN CAST(substr(pm.taken_at, 12, 2) AS INTEGER) BETWEEN 12 AND 16 THEN 'afternoon'
+	WHEN CAST(substr(pm.taken_at, 12, 2) AS INTEGER) BETWEEN 17 AND 20 THEN 'evening'
+	ELSE 'night'
+END`
+
+// seasonSQL buckets pm.taken_at into meteorological seasons (Dec-Feb is winter),
+// shifted by half a year for photos with a southern hemisphere GPS position
+const seasonSQL = `CASE
+	WHEN pm.taken_at IS NULL THEN NULL
+	ELSE CASE (CAST(substr(pm.taken_at, 6, 2) AS INTEGER) % 12 / 3 + CASE WHEN pm.latitude < 0 THEN 2 ELSE 0 END) % 4
+		WHEN 0 THEN 'winter'
+		WHEN 1 THEN 'spring'
+		WHEN 2 THEN 'summer'
+		ELSE 'autumn'
+	END
+END`
+
+var (
+	timeOfDayBuckets = []string{"morning", "afternoon", "evening", "night"}
+	seasonBuckets    = []string{"spring", "summer", "autumn", "winter"}
+)
+
+// isFacetBucket reports whether value is one of buckets
+func isFacetBucket(buckets []string, value string) bool {
+	for _, bucket := range buckets {
+		if bucket == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFileFacets returns file counts per time-of-day and season bucket, limited to
+// files the user can access. Files without a capture time are not counted.
+// GET /api/files/facets?type=image
+func (h *Handler) GetFileFacets(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	query := `SELECT ` + timeOfDaySQL + ` AS time_of_day, ` + seasonSQL + ` AS season, COUNT(*)
+	          FROM files f
+	          INNER JOIN photo_metadata pm ON f.id = pm.file_id
+	          WHERE pm.taken_at IS NOT NULL`
+	args := []interface{}{}
+
+	if user.Role != "server_owner" {
+		query += ` AND f.id IN (
+			SELECT ffm.file_id FROM file_folder_mappings ffm
+			JOIN permission_group_folders pgf ON ffm.folder_id = pgf.folder_id
+			JOIN permission_group_permissions pgp ON pgf.permission_group_id = pgp.permission_group_id
+			WHERE pgp.user_id = ?
+		)`
+		args = append(args, user.ID)
+	}
+
+	if fileType := c.Query("type"); fileType != "" {
+		query += " AND f.file_type = ?"
+		args = append(args, fileType)
+	}
+
+	query += " GROUP BY time_of_day, season"
+
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		if isQueryTimeout(err) {
+			return queryTimeoutError(c)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to compute facets",
+		})
+	}
+	defer rows.Close()
+
+	timeOfDay := map[string]int{}
+	for _, bucket := range timeOfDayBuckets {
+		timeOfDay[bucket] = 0
+	}
+	season := map[string]int{}
+	for _, bucket := range seasonBuckets {
+		season[bucket] = 0
+	}
+
+	for rows.Next() {
+		var timeBucket, seasonBucket string
+		var count int
+		if err := rows.Scan(&timeBucket, &seasonBucket, &count); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to compute facets",
+			})
+		}
+		timeOfDay[timeBucket] += count
+		season[seasonBucket] += count
+	}
+	if err := rows.Err(); err != nil {
+		if isQueryTimeout(err) {
+			return queryTimeoutError(c)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to compute facets",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"time_of_day": timeOfDay,
+		"season":      season,
+	})
+}
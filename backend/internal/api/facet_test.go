@@ -0,0 +1,90 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestFileFacets(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	viewer := seedUser(t, db.DB, "viewer", "user")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	private := seedFolder(t, db.DB, t.TempDir(), owner.ID)
+	grantFolder(t, db.DB, viewer.ID, folder, "read")
+
+	seed := func(folderID int64, dir, name, takenAt string, latitude interface{}) {
+		os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644)
+		id := seedFile(t, db.DB, folderID, name, "image")
+		mustExec(t, db.DB, "INSERT INTO photo_metadata (file_id, taken_at, latitude) VALUES (?, ?, ?)", id, takenAt, latitude)
+	}
+	seed(folder, root, "sunrise.jpg", "2024-07-01 06:30:00", nil)     // morning, summer
+	seed(folder, root, "lunch.jpg", "2024-12-24 12:00:00", nil)       // afternoon, winter
+	seed(folder, root, "sunset.jpg", "2024-04-10 19:45:00", 48.8)     // evening, spring
+	seed(folder, root, "party.jpg", "2024-10-31 23:10:00", nil)       // night, autumn
+	seed(folder, root, "latenight.jpg", "2024-03-01 04:59:00", nil)   // night, spring
+	seed(folder, root, "sydney.jpg", "2024-01-15 20:00:00", -33.9)    // evening, summer in the south
+	seed(folder, root, "melbourne.jpg", "2024-07-15 16:59:00", -37.8) // afternoon, winter in the south
+	os.WriteFile(filepath.Join(root, "undated.jpg"), []byte("x"), 0644)
+	seedFile(t, db.DB, folder, "undated.jpg", "image")
+	seed(private, t.TempDir(), "hidden.jpg", "2024-07-01 06:00:00", nil)
+
+	app := fiber.New()
+	app.Get("/api/files/facets", asUser(viewer), h.GetFileFacets)
+	app.Get("/api/files", asUser(viewer), h.GetFiles)
+
+	status, body := doRequest(t, app, http.MethodGet, "/api/files/facets", "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("facets: status %d, body %v", status, body)
+	}
+	wantTime := map[string]float64{"morning": 1, "afternoon": 2, "evening": 2, "night": 2}
+	wantSeason := map[string]float64{"spring": 2, "summer": 2, "autumn": 1, "winter": 2}
+	if fmt.Sprint(body["time_of_day"]) != fmt.Sprint(wantTime) {
+		t.Errorf("time_of_day = %v, want %v", body["time_of_day"], wantTime)
+	}
+	if fmt.Sprint(body["season"]) != fmt.Sprint(wantSeason) {
+		t.Errorf("season = %v, want %v", body["season"], wantSeason)
+	}
+
+	filtered := func(query string) []string {
+		t.Helper()
+		status, body := doRequest(t, app, http.MethodGet, "/api/files?"+query, "", nil)
+		if status != fiber.StatusOK {
+			t.Fatalf("files?%s: status %d, body %v", query, status, body)
+		}
+		var names []string
+		for _, f := range body["files"].([]interface{}) {
+			names = append(names, f.(map[string]interface{})["filename"].(string))
+		}
+		sort.Strings(names)
+		return names
+	}
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"time_of_day=evening", "[sunset.jpg sydney.jpg]"},
+		{"time_of_day=night", "[latenight.jpg party.jpg]"},
+		{"season=summer", "[sunrise.jpg sydney.jpg]"},
+		{"season=winter&time_of_day=afternoon", "[lunch.jpg melbourne.jpg]"},
+	}
+	for _, tc := range cases {
+		if got := filtered(tc.query); fmt.Sprint(got) != tc.want {
+			t.Errorf("files?%s = %v, want %s", tc.query, got, tc.want)
+		}
+	}
+
+	for _, query := range []string{"time_of_day=noon", "season=monsoon"} {
+		if status, _ := doRequest(t, app, http.MethodGet, "/api/files?"+query, "", nil); status != fiber.StatusBadRequest {
+			t.Errorf("files?%s: status %d, want 400", query, status)
+		}
+	}
+}
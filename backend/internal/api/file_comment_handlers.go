@@ -0,0 +1,176 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+type FileCommentHandler struct {
+	commentService *services.FileCommentService
+	permService    *services.PermissionGroupService
+}
+
+func NewFileCommentHandler(commentService *services.FileCommentService, permService *services.PermissionGroupService) *FileCommentHandler {
+	return &FileCommentHandler{
+		commentService: commentService,
+		permService:    permService,
+	}
+}
+
+// canAccessFile reports whether a user may read and comment on a file
+func (h *FileCommentHandler) canAccessFile(user *models.User, fileID int64) bool {
+	isServerOwner := user.Role == "server_owner"
+	hasAccess, err := h.permService.CheckFileAccess(user.ID, fileID, isServerOwner)
+	return err == nil && hasAccess
+}
+
+// ListFileComments lists the comments on a file, oldest first
+// GET /api/files/:id/comments
+func (h *FileCommentHandler) ListFileComments(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	fileID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid file ID",
+		})
+	}
+
+	if !h.canAccessFile(user, fileID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	comments, err := h.commentService.ListComments(fileID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch comments",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"comments": comments,
+		"total":    len(comments),
+	})
+}
+
+// AddFileComment adds a comment to a file
+// POST /api/files/:id/comments
+func (h *FileCommentHandler) AddFileComment(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	fileID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid file ID",
+		})
+	}
+
+	if !h.canAccessFile(user, fileID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	var req struct {
+		Body string `json:"body"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	comment, err := h.commentService.AddComment(fileID, user.ID, req.Body)
+	if err != nil {
+		if err == services.ErrInvalidComment {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to add comment",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"comment": comment,
+	})
+}
+
+// DeleteFileComment deletes a comment (author or admin only)
+// DELETE /api/files/:id/comments/:commentId
+func (h *FileCommentHandler) DeleteFileComment(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	fileID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid file ID",
+		})
+	}
+
+	if !h.canAccessFile(user, fileID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	commentID, err := strconv.ParseInt(c.Params("commentId"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid comment ID",
+		})
+	}
+
+	comment, err := h.commentService.GetComment(fileID, commentID)
+	if err != nil {
+		if err == services.ErrCommentNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Comment not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch comment",
+		})
+	}
+
+	isAdmin := user.Role == "admin" || user.Role == "server_owner"
+	if comment.UserID != user.ID && !isAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the author or an admin can delete a comment",
+		})
+	}
+
+	if err := h.commentService.DeleteComment(commentID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete comment",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Comment deleted",
+	})
+}
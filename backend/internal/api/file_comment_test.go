@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestFileCommentHandlers(t *testing.T) {
+	db := newTestDB(t)
+	h := NewFileCommentHandler(services.NewFileCommentService(db.DB), services.NewPermissionGroupService(db.DB))
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	alice := seedUser(t, db.DB, "alice", "user")
+	bob := seedUser(t, db.DB, "bob", "user")
+	admin := seedUser(t, db.DB, "admin", "admin")
+	outsider := seedUser(t, db.DB, "outsider", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+	for _, u := range []*models.User{alice, bob, admin} {
+		grantFolder(t, db.DB, u.ID, folder, "read")
+	}
+
+	appFor := func(user *models.User) *fiber.App {
+		app := fiber.New()
+		app.Use(asUser(user))
+		app.Get("/api/files/:id/comments", h.ListFileComments)
+		app.Post("/api/files/:id/comments", h.AddFileComment)
+		app.Delete("/api/files/:id/comments/:commentId", h.DeleteFileComment)
+		return app
+	}
+	path := fmt.Sprintf("/api/files/%d/comments", file)
+
+	post := func(user *models.User, body string) int64 {
+		t.Helper()
+		status, resp := doRequest(t, appFor(user), http.MethodPost, path, fmt.Sprintf(`{"body":%q}`, body), nil)
+		if status != fiber.StatusCreated {
+			t.Fatalf("post as %s: status %d, body %v", user.Username, status, resp)
+		}
+		return int64(resp["comment"].(map[string]interface{})["id"].(float64))
+	}
+	aliceComment := post(alice, "First!")
+	bobComment := post(bob, "Second")
+	ownerComment := post(owner, "Third")
+
+	status, resp := doRequest(t, appFor(bob), http.MethodGet, path, "", nil)
+	if status != fiber.StatusOK || resp["total"] != float64(3) {
+		t.Fatalf("list: status %d, body %v", status, resp)
+	}
+	var order []string
+	for _, c := range resp["comments"].([]interface{}) {
+		order = append(order, c.(map[string]interface{})["body"].(string))
+	}
+	if fmt.Sprint(order) != "[First! Second Third]" {
+		t.Errorf("comment order = %v", order)
+	}
+
+	cases := []struct {
+		name   string
+		user   *models.User
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"anonymous list", nil, http.MethodGet, path, "", fiber.StatusUnauthorized},
+		{"no file access", outsider, http.MethodGet, path, "", fiber.StatusForbidden},
+		{"no file access post", outsider, http.MethodPost, path, `{"body":"hi"}`, fiber.StatusForbidden},
+		{"empty body", alice, http.MethodPost, path, `{"body":"  "}`, fiber.StatusBadRequest},
+		{"delete someone else's", bob, http.MethodDelete, fmt.Sprintf("%s/%d", path, aliceComment), "", fiber.StatusForbidden},
+		{"delete unknown", alice, http.MethodDelete, path + "/9999", "", fiber.StatusNotFound},
+		{"delete own", bob, http.MethodDelete, fmt.Sprintf("%s/%d", path, bobComment), "", fiber.StatusOK},
+		{"admin deletes any", admin, http.MethodDelete, fmt.Sprintf("%s/%d", path, aliceComment), "", fiber.StatusOK},
+		{"server owner deletes any", owner, http.MethodDelete, fmt.Sprintf("%s/%d", path, ownerComment), "", fiber.StatusOK},
+	}
+	for _, tc := range cases {
+		if status, body := doRequest(t, appFor(tc.user), tc.method, tc.path, tc.body, nil); status != tc.want {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.want, body)
+		}
+	}
+
+	_, resp = doRequest(t, appFor(alice), http.MethodGet, path, "", nil)
+	if resp["total"] != float64(0) {
+		t.Errorf("comments left after deleting all: %v", resp["comments"])
+	}
+}
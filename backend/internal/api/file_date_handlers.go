@@ -0,0 +1,197 @@
+package api
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/services"
+)
+
+// shiftDateRequest describes a date correction: either a relative offset or an absolute date
+type shiftDateRequest struct {
+	FileIDs []int64 `json:"file_ids"` // Bulk only
+	Offset  string  `json:"offset"`   // e.g. "+5h", "-30m", "+1 day", "-2d"
+	Date    string  `json:"date"`     // RFC3339 or YYYY-MM-DD, replaces taken_at
+}
+
+var dayOffsetPattern = regexp.MustCompile(`^([+-]?\d+)\s*(d|day|days)$`)
+
+// parseDateOffset parses offsets like "+5h", "-90m", "+1 day" or "-2d"
+func parseDateOffset(offset string) (time.Duration, error) {
+	offset = strings.ToLower(strings.TrimSpace(offset))
+	if m := dayOffsetPattern.FindStringSubmatch(offset); m != nil {
+		days, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(strings.ReplaceAll(offset, " ", ""))
+}
+
+// parseAbsoluteDate parses an RFC3339 timestamp or a plain YYYY-MM-DD date
+func parseAbsoluteDate(date string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", date)
+}
+
+// resolveDateChange validates the request and returns either an offset or an absolute date
+func resolveDateChange(req shiftDateRequest) (time.Duration, *time.Time, error) {
+	if req.Date != "" {
+		takenAt, err := parseAbsoluteDate(req.Date)
+		if err != nil {
+			return 0, nil, errors.New("Invalid date, expected RFC3339 or YYYY-MM-DD")
+		}
+		return 0, &takenAt, nil
+	}
+
+	offset, err := parseDateOffset(req.Offset)
+	if err != nil || offset == 0 {
+		return 0, nil, errors.New("Invalid offset, expected e.g. +5h, -30m or +1 day")
+	}
+	return offset, nil, nil
+}
+
+// applyDateChange updates taken_at of the given files
+func (h *Handler) applyDateChange(fileIDs []int64, offset time.Duration, date *time.Time) ([]services.DateChangeResult, error) {
+	if date != nil {
+		return h.metaService.SetTakenAt(fileIDs, *date)
+	}
+	return h.metaService.ShiftTakenAt(fileIDs, offset)
+}
+
+// ShiftFileDate corrects the capture date of a single file
+// POST /api/files/:id/shift-date
+func (h *Handler) ShiftFileDate(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid file ID"})
+	}
+
+	var req shiftDateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.Offset == "" && req.Date == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Offset or date is required",
+		})
+	}
+	offset, date, err := resolveDateChange(req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	isServerOwner := user.Role == "server_owner"
+	hasAccess, err := h.permService.CheckFileWriteAccess(user.ID, id, isServerOwner)
+	if err != nil || !hasAccess {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	results, err := h.applyDateChange([]int64{id}, offset, date)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update file date",
+		})
+	}
+
+	switch results[0].Status {
+	case services.DateChangeNotFound:
+		return c.Status(404).JSON(fiber.Map{"error": "File not found"})
+	case services.DateChangeNotImage:
+		return c.Status(400).JSON(fiber.Map{"error": "Only images have a capture date"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "File date updated",
+		"updated": services.CountDateChanges(results),
+	})
+}
+
+// BulkShiftFileDates corrects the capture date of multiple files at once
+// POST /api/files/bulk/shift-date
+func (h *Handler) BulkShiftFileDates(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	var req shiftDateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if len(req.FileIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "No file IDs provided",
+		})
+	}
+	if req.Offset == "" && req.Date == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Offset or date is required",
+		})
+	}
+	offset, date, err := resolveDateChange(req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Only touch files the user may modify
+	isServerOwner := user.Role == "server_owner"
+	var allowed []int64
+	denied := []int64{}
+	results := []services.DateChangeResult{}
+	for _, id := range req.FileIDs {
+		hasAccess, err := h.permService.CheckFileWriteAccess(user.ID, id, isServerOwner)
+		if err != nil || !hasAccess {
+			denied = append(denied, id)
+			results = append(results, services.DateChangeResult{FileID: id, Status: services.DateChangeDenied})
+			continue
+		}
+		allowed = append(allowed, id)
+	}
+
+	if len(allowed) > 0 {
+		changed, err := h.applyDateChange(allowed, offset, date)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update file dates",
+			})
+		}
+		results = append(results, changed...)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "File dates updated",
+		"updated": services.CountDateChanges(results),
+		"denied":  denied,
+		"results": results,
+		"total":   len(req.FileIDs),
+	})
+}
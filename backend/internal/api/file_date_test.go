@@ -0,0 +1,93 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+)
+
+func TestParseDateOffset(t *testing.T) {
+	cases := map[string]time.Duration{
+		"+5h":     5 * time.Hour,
+		"-30m":    -30 * time.Minute,
+		"+1 day":  24 * time.Hour,
+		"-2d":     -48 * time.Hour,
+		"3 days":  72 * time.Hour,
+		" +1h30m": 90 * time.Minute,
+	}
+	for offset, want := range cases {
+		got, err := parseDateOffset(offset)
+		if err != nil || got != want {
+			t.Errorf("parseDateOffset(%q) = %v, %v; want %v", offset, got, err, want)
+		}
+	}
+	for _, offset := range []string{"", "soon", "+1 week", "d"} {
+		if _, err := parseDateOffset(offset); err == nil {
+			t.Errorf("parseDateOffset(%q): expected an error", offset)
+		}
+	}
+}
+
+func TestResolveDateChange(t *testing.T) {
+	if _, date, err := resolveDateChange(shiftDateRequest{Date: "2021-03-04"}); err != nil || date == nil || date.Format("2006-01-02") != "2021-03-04" {
+		t.Errorf("plain date: %v, %v", date, err)
+	}
+	if _, date, err := resolveDateChange(shiftDateRequest{Date: "2021-03-04T05:06:07Z", Offset: "+1h"}); err != nil || date == nil || date.Hour() != 5 {
+		t.Errorf("date wins over offset: %v, %v", date, err)
+	}
+	if _, _, err := resolveDateChange(shiftDateRequest{Date: "04/03/2021"}); err == nil {
+		t.Errorf("invalid date accepted")
+	}
+	if _, _, err := resolveDateChange(shiftDateRequest{Offset: "0h"}); err == nil {
+		t.Errorf("zero offset accepted")
+	}
+}
+
+func TestBulkShiftFileDatesRequiresWriteAccess(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	admin := seedUser(t, db.DB, "admin", "admin")
+	writable := seedFolder(t, db.DB, "/photos/writable", owner.ID)
+	readable := seedFolder(t, db.DB, "/photos/readable", owner.ID)
+	grantFolder(t, db.DB, admin.ID, writable, "write")
+	grantFolder(t, db.DB, admin.ID, readable, "read")
+	a := seedFile(t, db.DB, writable, "a.jpg", "image")
+	b := seedFile(t, db.DB, readable, "b.jpg", "image")
+
+	request := func(user *models.User, body string) (int, map[string]interface{}) {
+		app := fiber.New()
+		app.Post("/api/files/bulk/shift-date", asUser(user), h.BulkShiftFileDates)
+		return doRequest(t, app, "POST", "/api/files/bulk/shift-date", body, nil)
+	}
+	body := `{"file_ids":[` + strconv.FormatInt(a, 10) + `,` + strconv.FormatInt(b, 10) + `],"date":"2022-02-02"}`
+
+	// Admins get no write bypass: only files in folders they may write are changed
+	status, resp := request(admin, body)
+	if status != fiber.StatusOK {
+		t.Fatalf("admin: status %d", status)
+	}
+	if resp["updated"] != float64(1) {
+		t.Errorf("admin: updated %v, want 1", resp["updated"])
+	}
+	denied := resp["denied"].([]interface{})
+	if len(denied) != 1 || denied[0] != float64(b) {
+		t.Errorf("admin: denied %v, want [%d]", denied, b)
+	}
+
+	// The server owner may change everything
+	if _, resp := request(owner, body); resp["updated"] != float64(2) {
+		t.Errorf("server owner: updated %v, want 2", resp["updated"])
+	}
+
+	for _, bad := range []string{`{"date":"2022-02-02"}`, `{"file_ids":[1]}`, `{"file_ids":[1],"offset":"sometime"}`} {
+		if status, _ := request(owner, bad); status != fiber.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", bad, status)
+		}
+	}
+}
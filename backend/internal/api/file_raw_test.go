@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+)
+
+func TestFileResponsesOmitAbsolutePath(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	writeTestImage(t, filepath.Join(root, "a.jpg"), 16, 16)
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+
+	app := fiber.New()
+	app.Get("/api/files", asUser(owner), h.GetFiles)
+	app.Get("/api/files/:id", asUser(owner), h.GetFileByID)
+
+	for _, path := range []string{"/api/files", fmt.Sprintf("/api/files/%d", file)} {
+		resp := sendRequest(t, app, http.MethodGet, path, "", nil)
+		raw, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("%s: status %d", path, resp.StatusCode)
+		}
+		if strings.Contains(string(raw), "absolute_path") || strings.Contains(string(raw), root) {
+			t.Errorf("%s exposes the file's absolute path: %s", path, raw)
+		}
+		if !strings.Contains(string(raw), "a.jpg") {
+			t.Errorf("%s did not return the file: %s", path, raw)
+		}
+	}
+}
+
+func TestGetFileRaw(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	viewer := seedUser(t, db.DB, "viewer", "user")
+	stranger := seedUser(t, db.DB, "stranger", "user")
+
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	grantFolder(t, db.DB, viewer.ID, folder, "read")
+
+	writeTestImage(t, filepath.Join(root, "a.png"), 16, 16)
+	png := seedFile(t, db.DB, folder, "a.png", "image")
+	if err := os.WriteFile(filepath.Join(root, "b.heic"), []byte("not really heic"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	heic := seedFile(t, db.DB, folder, "b.heic", "image")
+	missing := seedFile(t, db.DB, folder, "gone.jpg", "image")
+
+	appAs := func(user *models.User) *fiber.App {
+		app := fiber.New()
+		app.Get("/api/files/:id/raw", asUser(user), h.GetFileRaw)
+		return app
+	}
+
+	tests := []struct {
+		name        string
+		app         *fiber.App
+		fileID      int64
+		status      int
+		contentType string
+	}{
+		{"anonymous", appAs(nil), png, fiber.StatusUnauthorized, ""},
+		{"stranger", appAs(stranger), png, fiber.StatusForbidden, ""},
+		{"viewer png", appAs(viewer), png, fiber.StatusOK, "image/png"},
+		{"viewer heic", appAs(viewer), heic, fiber.StatusOK, "image/heic"},
+		{"owner missing file", appAs(owner), missing, fiber.StatusNotFound, ""},
+	}
+	for _, tc := range tests {
+		resp := sendRequest(t, tc.app, http.MethodGet, fmt.Sprintf("/api/files/%d/raw", tc.fileID), "", nil)
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s: status %d, want %d", tc.name, resp.StatusCode, tc.status)
+			continue
+		}
+		if tc.contentType == "" {
+			continue
+		}
+		if got := resp.Header.Get("Content-Type"); got != tc.contentType {
+			t.Errorf("%s: content type %q, want %q", tc.name, got, tc.contentType)
+		}
+		if got := resp.Header.Get("Content-Disposition"); got != "inline" {
+			t.Errorf("%s: content disposition %q, want inline", tc.name, got)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestGetFileStats(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	viewer := seedUser(t, db.DB, "viewer", "user")
+	stranger := seedUser(t, db.DB, "stranger", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+	grantFolder(t, db.DB, viewer.ID, folder, "read")
+
+	stats := services.NewFileStatsService(db.DB)
+	stats.RecordView(file)
+	stats.RecordView(file)
+	stats.RecordDownload(file)
+
+	get := func(user *models.User, id string) (int, map[string]interface{}) {
+		app := fiber.New()
+		app.Get("/api/files/:id/stats", asUser(user), h.GetFileStats)
+		return doRequest(t, app, "GET", "/api/files/"+id+"/stats", "", nil)
+	}
+	fileID := strconv.FormatInt(file, 10)
+
+	status, body := get(viewer, fileID)
+	if status != fiber.StatusOK {
+		t.Fatalf("viewer: status %d", status)
+	}
+	got := body["stats"].(map[string]interface{})
+	if got["view_count"] != float64(2) || got["download_count"] != float64(1) {
+		t.Errorf("viewer: stats %v, want 2 views and 1 download", got)
+	}
+
+	if status, _ := get(stranger, fileID); status != fiber.StatusForbidden {
+		t.Errorf("stranger: status %d, want 403", status)
+	}
+	if status, _ := get(nil, fileID); status != fiber.StatusUnauthorized {
+		t.Errorf("anonymous: status %d, want 401", status)
+	}
+	if status, _ := get(owner, "9999"); status != fiber.StatusNotFound {
+		t.Errorf("missing file: status %d, want 404", status)
+	}
+	if status, _ := get(owner, "abc"); status != fiber.StatusBadRequest {
+		t.Errorf("invalid ID: status %d, want 400", status)
+	}
+}
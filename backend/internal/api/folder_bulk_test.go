@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestBulkCreateFolders(t *testing.T) {
+	db := newTestDB(t)
+	folderService := services.NewFolderService(db.DB)
+	// The job queue is not started, so the queued scan never runs
+	jobs := services.NewJobQueue(db.DB, nil)
+	h := NewFolderHandler(folderService, services.NewFileScanner(db, folderService, t.TempDir(), nil), jobs)
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	user := seedUser(t, db.DB, "user", "user")
+	seedFolder(t, db.DB, "/data/existing", admin.ID)
+
+	appAs := func(u *models.User) *fiber.App {
+		app := fiber.New()
+		app.Post("/api/folders/bulk", asUser(u), h.BulkCreateFolders)
+		return app
+	}
+
+	body := `{"folders":[
+		{"name":"Photos","absolute_path":"/data/photos"},
+		{"name":"Conflict","absolute_path":"/data/existing"},
+		{"name":"Nested","absolute_path":"/data/photos/2024"},
+		{"name":"Videos","absolute_path":"/data/videos"}
+	]}`
+	status, resp := doRequest(t, appAs(admin), http.MethodPost, "/api/folders/bulk", body, nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d body %v", status, resp)
+	}
+	if resp["created"] != float64(2) || resp["failed"] != float64(2) {
+		t.Errorf("created %v failed %v, want 2 and 2", resp["created"], resp["failed"])
+	}
+	if _, ok := resp["job_id"]; !ok {
+		t.Error("expected a scan job for the created folders")
+	}
+
+	results := resp["results"].([]interface{})
+	wantSuccess := []bool{true, false, false, true}
+	for i, raw := range results {
+		r := raw.(map[string]interface{})
+		if r["success"] != wantSuccess[i] {
+			t.Errorf("result %d (%v): success %v, want %v", i, r["absolute_path"], r["success"], wantSuccess[i])
+		}
+		if !wantSuccess[i] && r["error"] != services.ErrFolderPathConflict.Error() {
+			t.Errorf("result %d: error %v", i, r["error"])
+		}
+	}
+
+	// Nothing valid to create: no scan is queued
+	status, resp = doRequest(t, appAs(admin), http.MethodPost, "/api/folders/bulk",
+		`{"folders":[{"name":"Again","absolute_path":"/data/photos"}]}`, nil)
+	if status != fiber.StatusOK || resp["created"] != float64(0) {
+		t.Errorf("all-conflict request: status %d body %v", status, resp)
+	}
+	if _, ok := resp["job_id"]; ok {
+		t.Error("no scan job expected when nothing was created")
+	}
+
+	tooMany := `{"folders":[` + strings.Repeat(`{"name":"x","absolute_path":"/x"},`, maxBulkFolders) + `{"name":"x","absolute_path":"/x"}]}`
+	cases := []struct {
+		name string
+		user *models.User
+		body string
+		want int
+	}{
+		{"regular user", user, body, fiber.StatusForbidden},
+		{"anonymous", nil, body, fiber.StatusUnauthorized},
+		{"empty", admin, `{"folders":[]}`, fiber.StatusBadRequest},
+		{"too many", admin, tooMany, fiber.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		if status, _ := doRequest(t, appAs(tc.user), http.MethodPost, "/api/folders/bulk", tc.body, nil); status != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, status, tc.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestFolderDeletePreviewAndGuard(t *testing.T) {
+	db := newTestDB(t)
+	folderService := services.NewFolderService(db.DB)
+	h := NewFolderHandler(folderService, services.NewFileScanner(db, folderService, t.TempDir(), nil), services.NewJobQueue(db.DB, nil))
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	used := seedFolder(t, db.DB, "/photos/used", admin.ID)
+	empty := seedFolder(t, db.DB, "/photos/empty", admin.ID)
+	seedFile(t, db.DB, used, "a.jpg", "image")
+	seedFile(t, db.DB, used, "b.jpg", "image")
+	grantFolder(t, db.DB, admin.ID, used, "read")
+
+	app := fiber.New()
+	app.Get("/api/folders/:id/delete-preview", asUser(admin), h.PreviewDeleteFolder)
+	app.Delete("/api/folders/:id", asUser(admin), h.DeleteFolder)
+
+	status, resp := doRequest(t, app, http.MethodGet, "/api/folders/"+strconv.FormatInt(used, 10)+"/delete-preview", "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("preview: status %d (%v)", status, resp)
+	}
+	if resp["mappings"] != float64(2) || resp["orphaned_files"] != float64(2) || resp["permission_groups"] != float64(1) {
+		t.Errorf("unexpected preview %v", resp)
+	}
+	if status, _ := doRequest(t, app, http.MethodGet, "/api/folders/9999/delete-preview", "", nil); status != fiber.StatusNotFound {
+		t.Errorf("preview of unknown folder: status %d, want 404", status)
+	}
+
+	cases := []struct {
+		name   string
+		folder int64
+		query  string
+		status int
+	}{
+		{"with dependencies", used, "", fiber.StatusConflict},
+		{"forced", used, "?force=true", fiber.StatusOK},
+		{"without dependencies", empty, "", fiber.StatusOK},
+	}
+	for _, tc := range cases {
+		status, resp := doRequest(t, app, http.MethodDelete, "/api/folders/"+strconv.FormatInt(tc.folder, 10)+tc.query, "", nil)
+		if status != tc.status {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.status, resp)
+			continue
+		}
+		if status == fiber.StatusConflict {
+			if preview, ok := resp["preview"].(map[string]interface{}); !ok || preview["mappings"] != float64(2) {
+				t.Errorf("%s: missing preview in %v", tc.name, resp)
+			}
+			if _, err := folderService.GetFolder(tc.folder); err != nil {
+				t.Errorf("%s: folder deleted despite conflict: %v", tc.name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestGetDiskTreeHandler(t *testing.T) {
+	db := newTestDB(t)
+	folderService := services.NewFolderService(db.DB)
+	h := NewFolderHandler(folderService, services.NewFileScanner(db, folderService, t.TempDir(), nil), services.NewJobQueue(db.DB, nil))
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	other := seedUser(t, db.DB, "other", "user")
+	root := t.TempDir()
+	// Folder IDs repeat across test databases and the tree cache is process-wide,
+	// so use an ID no other test uses
+	mustExec(t, db.DB, "INSERT INTO folders (id, name, absolute_path, enabled, created_by) VALUES (9480, 'root', ?, 1, ?)", root, owner.ID)
+	folderID := int64(9480)
+	grantFolder(t, db.DB, owner.ID, folderID, "read")
+	for _, dir := range []string{"2023/summer", "2024", ".hidden"} {
+		os.MkdirAll(filepath.Join(root, dir), 0755)
+	}
+
+	path := fmt.Sprintf("/api/folders/%d/disk-tree", folderID)
+	cases := []struct {
+		name   string
+		user   *models.User
+		path   string
+		status int
+	}{
+		{"anonymous", nil, path, fiber.StatusUnauthorized},
+		{"invalid id", owner, "/api/folders/abc/disk-tree", fiber.StatusBadRequest},
+		{"depth too large", owner, path + "?depth=6", fiber.StatusBadRequest},
+		{"depth zero", owner, path + "?depth=0", fiber.StatusBadRequest},
+		{"missing folder", owner, "/api/folders/9999/disk-tree", fiber.StatusNotFound},
+		{"no access", other, path, fiber.StatusForbidden},
+		{"owner", owner, path, fiber.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/api/folders/:id/disk-tree", asUser(tc.user), h.GetDiskTree)
+			status, body := doRequest(t, app, http.MethodGet, tc.path, "", nil)
+			if status != tc.status {
+				t.Fatalf("status %d, body %v, want %d", status, body, tc.status)
+			}
+			if status != fiber.StatusOK {
+				return
+			}
+
+			if body["depth"] != float64(2) || body["folder_id"] != float64(folderID) {
+				t.Errorf("depth %v, folder_id %v", body["depth"], body["folder_id"])
+			}
+			dirs := body["directories"].([]interface{})
+			if len(dirs) != 2 {
+				t.Fatalf("directories = %v, want 2023 and 2024", dirs)
+			}
+			y2023 := dirs[0].(map[string]interface{})
+			children := y2023["children"].([]interface{})
+			if y2023["path_prefix"] != "2023/" || len(children) != 1 || children[0].(map[string]interface{})["path_prefix"] != "2023/summer/" {
+				t.Errorf("2023 = %v", y2023)
+			}
+			if dirs[1].(map[string]interface{})["path_prefix"] != "2024/" {
+				t.Errorf("2024 = %v", dirs[1])
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,12 +15,36 @@ import (
 type FolderHandler struct {
 	folderService  *services.FolderService
 	scannerService *services.FileScanner
+	jobs           *services.JobQueue
 }
 
-func NewFolderHandler(folderService *services.FolderService, scannerService *services.FileScanner) *FolderHandler {
+func NewFolderHandler(folderService *services.FolderService, scannerService *services.FileScanner, jobs *services.JobQueue) *FolderHandler {
 	return &FolderHandler{
 		folderService:  folderService,
 		scannerService: scannerService,
+		jobs:           jobs,
+	}
+}
+
+// scanFoldersJob returns a job that scans the given folders one after another, reporting progress per folder
+func (h *FolderHandler) scanFoldersJob(folderIDs []int64) services.JobFunc {
+	return func(ctx context.Context, progress func(percent int)) (interface{}, error) {
+		failed := map[int64]string{}
+		for i, id := range folderIDs {
+			if err := h.scannerService.ScanFolder(id); err != nil {
+				log.Printf("Error scanning folder %d: %v", id, err)
+				failed[id] = err.Error()
+			}
+			progress((i + 1) * 100 / len(folderIDs))
+		}
+
+		if len(folderIDs) == 1 && len(failed) == 1 {
+			return nil, fmt.Errorf("scan of folder %d failed: %s", folderIDs[0], failed[folderIDs[0]])
+		}
+		return fiber.Map{
+			"folders_scanned": len(folderIDs) - len(failed),
+			"failed":          failed,
+		}, nil
 	}
 }
 
@@ -77,8 +104,82 @@ func (h *FolderHandler) CreateFolder(c *fiber.Ctx) error {
 	})
 }
 
-// ListFolders lists all folders accessible to the user
-// GET /api/folders
+// maxBulkFolders limits the number of folders created in one bulk request
+const maxBulkFolders = 100
+
+// BulkCreateFolders creates multiple folders and scans the created ones
+// POST /api/folders/bulk
+func (h *FolderHandler) BulkCreateFolders(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	// Only admins can create folders
+	if user.Role != "admin" && user.Role != "server_owner" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Admin privileges required",
+		})
+	}
+
+	var req struct {
+		Folders []services.FolderSpec `json:"folders"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.Folders) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "No folders provided",
+		})
+	}
+	if len(req.Folders) > maxBulkFolders {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Too many folders, maximum is %d", maxBulkFolders),
+		})
+	}
+
+	results, err := h.folderService.CreateFoldersBulk(req.Folders, user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create folders",
+		})
+	}
+
+	var createdIDs []int64
+	for _, result := range results {
+		if result.Folder != nil {
+			createdIDs = append(createdIDs, result.Folder.ID)
+		}
+	}
+
+	// Scan the new folders in background
+	response := fiber.Map{
+		"results": results,
+		"created": len(createdIDs),
+		"failed":  len(results) - len(createdIDs),
+	}
+	if len(createdIDs) > 0 {
+		job, err := h.jobs.Enqueue(services.JobTypeFolderScan, user.ID, h.scanFoldersJob(createdIDs))
+		if err != nil {
+			log.Printf("Failed to queue scan of new folders: %v", err)
+		} else {
+			response["job_id"] = job.ID
+		}
+	}
+
+	return c.JSON(response)
+}
+
+// ListFolders lists all folders accessible to the user.
+// Admins can pass include=stats to get file counts and permission groups per folder.
+// GET /api/folders?include=stats
 func (h *FolderHandler) ListFolders(c *fiber.Ctx) error {
 	user := middleware.GetUser(c)
 	if user == nil {
@@ -95,6 +196,26 @@ func (h *FolderHandler) ListFolders(c *fiber.Ctx) error {
 		})
 	}
 
+	if c.Query("include") == "stats" {
+		if !isAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Admin privileges required",
+			})
+		}
+
+		withStats, err := h.folderService.AttachFolderStats(folders)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to load folder stats",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"folders": withStats,
+			"total":   len(withStats),
+		})
+	}
+
 	return c.JSON(fiber.Map{
 		"folders": folders,
 		"total":   len(folders),
@@ -160,8 +281,11 @@ func (h *FolderHandler) UpdateFolder(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		Name         string `json:"name"`
-		AbsolutePath string `json:"absolute_path"`
+		Name              string  `json:"name"`
+		AbsolutePath      string  `json:"absolute_path"`
+		ScanThumbnailSize *string `json:"scan_thumbnail_size"` // '' = global setting, 'none', or a size name
+		IndexHidden       *string `json:"index_hidden"`        // '' = global setting, 'true' or 'false'
+		MediaTypes        *string `json:"media_types"`         // '' = images and videos, 'image' or 'video'
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -176,6 +300,24 @@ func (h *FolderHandler) UpdateFolder(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.ScanThumbnailSize != nil && !services.IsValidScanThumbnailSize(*req.ScanThumbnailSize) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "scan_thumbnail_size must be empty, 'none', 'small', 'medium' or 'large'",
+		})
+	}
+
+	if req.IndexHidden != nil && *req.IndexHidden != "" && *req.IndexHidden != "true" && *req.IndexHidden != "false" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "index_hidden must be empty, 'true' or 'false'",
+		})
+	}
+
+	if req.MediaTypes != nil && !services.IsValidMediaTypes(*req.MediaTypes) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "media_types must be empty, 'image' or 'video'",
+		})
+	}
+
 	err = h.folderService.UpdateFolder(id, req.Name, req.AbsolutePath)
 	if err != nil {
 		if err == services.ErrFolderPathConflict {
@@ -193,6 +335,30 @@ func (h *FolderHandler) UpdateFolder(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.ScanThumbnailSize != nil {
+		if err := h.folderService.SetScanThumbnailSize(id, *req.ScanThumbnailSize); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update folder",
+			})
+		}
+	}
+
+	if req.IndexHidden != nil {
+		if err := h.folderService.SetIndexHidden(id, *req.IndexHidden); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update folder",
+			})
+		}
+	}
+
+	if req.MediaTypes != nil {
+		if err := h.folderService.SetMediaTypes(id, *req.MediaTypes); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update folder",
+			})
+		}
+	}
+
 	updatedFolder, err := h.folderService.GetFolder(id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -205,8 +371,9 @@ func (h *FolderHandler) UpdateFolder(c *fiber.Ctx) error {
 	})
 }
 
-// DeleteFolder deletes a folder
-// DELETE /api/folders/:id
+// DeleteFolder deletes a folder. A folder with file mappings, album rules or permission
+// group links is only deleted with ?force=true; otherwise 409 returns the delete preview.
+// DELETE /api/folders/:id?force=true
 func (h *FolderHandler) DeleteFolder(c *fiber.Ctx) error {
 	user := middleware.GetUser(c)
 	if user == nil {
@@ -229,6 +396,27 @@ func (h *FolderHandler) DeleteFolder(c *fiber.Ctx) error {
 		})
 	}
 
+	// Refuse to cascade into mappings, album rules and group links unless confirmed
+	if !c.QueryBool("force") {
+		preview, err := h.folderService.PreviewDeleteFolder(id)
+		if err != nil {
+			if err == services.ErrFolderNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Folder not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to check folder dependencies",
+			})
+		}
+		if preview.HasDependencies() {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   "Folder has dependencies; pass force=true to delete it anyway",
+				"preview": preview,
+			})
+		}
+	}
+
 	err = h.folderService.DeleteFolder(id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -241,6 +429,32 @@ func (h *FolderHandler) DeleteFolder(c *fiber.Ctx) error {
 	})
 }
 
+// PreviewDeleteFolder reports what deleting a folder would remove: mappings, files left
+// in no other folder, album folder rules and permission group links
+// GET /api/folders/:id/delete-preview
+func (h *FolderHandler) PreviewDeleteFolder(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid folder ID",
+		})
+	}
+
+	preview, err := h.folderService.PreviewDeleteFolder(id)
+	if err != nil {
+		if err == services.ErrFolderNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Folder not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to preview folder deletion",
+		})
+	}
+
+	return c.JSON(preview)
+}
+
 // ToggleFolder enables/disables a folder
 // PUT /api/folders/:id/toggle
 func (h *FolderHandler) ToggleFolder(c *fiber.Ctx) error {
@@ -311,15 +525,26 @@ func (h *FolderHandler) ScanFolder(c *fiber.Ctx) error {
 		})
 	}
 
-	// Run scan in background
-	go func() {
-		if err := h.scannerService.ScanFolder(id); err != nil {
-			// Log error but don't fail the request
+	if _, err := h.folderService.GetFolder(id); err != nil {
+		if err == services.ErrFolderNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Folder not found",
+			})
 		}
-	}()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch folder",
+		})
+	}
 
-	return c.JSON(fiber.Map{
+	// Run scan in background; poll GET /api/jobs/:id for status
+	job, err := h.jobs.Enqueue(services.JobTypeFolderScan, user.ID, h.scanFoldersJob([]int64{id}))
+	if err != nil {
+		return jobQueueError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
 		"message": "Folder scan started",
+		"job_id":  job.ID,
 	})
 }
 
@@ -417,3 +642,208 @@ func (h *FolderHandler) BrowseDirectoryTree(c *fiber.Ctx) error {
 		"directories": directories,
 	})
 }
+
+// GetDiskTree returns a folder's subdirectory tree as found on disk, for picking album path prefixes
+// GET /api/folders/:id/disk-tree?depth=2
+func (h *FolderHandler) GetDiskTree(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid folder ID",
+		})
+	}
+
+	depth := c.QueryInt("depth", 2)
+	if depth < 1 || depth > services.MaxDiskTreeDepth {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("depth must be between 1 and %d", services.MaxDiskTreeDepth),
+		})
+	}
+
+	isAdmin := user.Role == "admin" || user.Role == "server_owner"
+	tree, err := h.folderService.GetDiskTree(id, user.ID, isAdmin, depth)
+	if err != nil {
+		switch err {
+		case services.ErrFolderNotFound:
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Folder not found",
+			})
+		case services.ErrFolderAccessDenied:
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Access denied",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read folder tree",
+		})
+	}
+
+	return c.JSON(tree)
+}
+
+// RepairFolderMappings fixes stale relative paths of a folder's files by re-walking it on disk
+// in a background job
+// POST /api/folders/:id/repair-mappings
+func (h *FolderHandler) RepairFolderMappings(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	// Only admins can repair folders
+	if user.Role != "admin" && user.Role != "server_owner" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Admin privileges required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid folder ID",
+		})
+	}
+
+	if _, err := h.folderService.GetFolder(id); err != nil {
+		if err == services.ErrFolderNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Folder not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch folder",
+		})
+	}
+
+	// Re-walking the folder takes a while; poll GET /api/jobs/:id for the corrections
+	job, err := h.jobs.Enqueue(services.JobTypeRepairMappings, user.ID,
+		func(ctx context.Context, progress func(percent int)) (interface{}, error) {
+			return h.folderService.RepairMappings(id)
+		})
+	if err != nil {
+		return jobQueueError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Mapping repair started",
+		"job_id":  job.ID,
+	})
+}
+
+// maxUnindexedListed bounds the number of unindexed files listed in one response
+const maxUnindexedListed = 1000
+
+// ListUnindexedFiles lists media files on disk that the folder has not indexed yet
+// GET /api/folders/:id/unindexed
+func (h *FolderHandler) ListUnindexedFiles(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	// Only admins can inspect folders on disk
+	if user.Role != "admin" && user.Role != "server_owner" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Admin privileges required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid folder ID",
+		})
+	}
+
+	unindexed, err := h.scannerService.FindUnindexed(id)
+	if err != nil {
+		if err == services.ErrFolderNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Folder not found",
+			})
+		}
+		log.Printf("Failed to find unindexed files of folder %d: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read folder",
+		})
+	}
+
+	count := len(unindexed)
+	if count > maxUnindexedListed {
+		unindexed = unindexed[:maxUnindexedListed]
+	}
+
+	return c.JSON(fiber.Map{
+		"folder_id": id,
+		"count":     count,
+		"files":     unindexed,
+		"truncated": count > len(unindexed),
+	})
+}
+
+// IndexUnindexedFiles indexes only the folder's unindexed files, without a full rescan
+// POST /api/folders/:id/unindexed
+func (h *FolderHandler) IndexUnindexedFiles(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	// Only admins can trigger indexing
+	if user.Role != "admin" && user.Role != "server_owner" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Admin privileges required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid folder ID",
+		})
+	}
+
+	if _, err := h.folderService.GetFolder(id); err != nil {
+		if err == services.ErrFolderNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Folder not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch folder",
+		})
+	}
+
+	// Run in background; poll GET /api/jobs/:id for status
+	job, err := h.jobs.Enqueue(services.JobTypeIndexUnindexed, user.ID, func(ctx context.Context, progress func(percent int)) (interface{}, error) {
+		indexed, failed, err := h.scannerService.IndexUnindexed(id, progress)
+		if err != nil {
+			return nil, err
+		}
+		return fiber.Map{
+			"indexed": indexed,
+			"failed":  failed,
+		}, nil
+	})
+	if err != nil {
+		return jobQueueError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Indexing started",
+		"job_id":  job.ID,
+	})
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestUpdateFolderMediaTypes(t *testing.T) {
+	db := newTestDB(t)
+	folderService := services.NewFolderService(db.DB)
+	h := NewFolderHandler(folderService, services.NewFileScanner(db, folderService, t.TempDir(), nil), services.NewJobQueue(db.DB, nil))
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	folderID := seedFolder(t, db.DB, "/photos", admin.ID)
+	path := "/api/folders/" + strconv.FormatInt(folderID, 10)
+
+	app := fiber.New()
+	app.Put("/api/folders/:id", asUser(admin), h.UpdateFolder)
+
+	cases := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{"videos only", `{"name":"Photos","absolute_path":"/photos","media_types":"video"}`, fiber.StatusOK, "video"},
+		{"omitted keeps setting", `{"name":"Photos","absolute_path":"/photos"}`, fiber.StatusOK, "video"},
+		{"invalid value", `{"name":"Photos","absolute_path":"/photos","media_types":"audio"}`, fiber.StatusBadRequest, "video"},
+		{"images only", `{"name":"Photos","absolute_path":"/photos","media_types":"image"}`, fiber.StatusOK, "image"},
+		{"both", `{"name":"Photos","absolute_path":"/photos","media_types":""}`, fiber.StatusOK, ""},
+	}
+	for _, tc := range cases {
+		status, resp := doRequest(t, app, http.MethodPut, path, tc.body, nil)
+		if status != tc.status {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.status, resp)
+		}
+		if status == fiber.StatusOK {
+			folder := resp["folder"].(map[string]interface{})
+			if got, _ := folder["media_types"].(string); got != tc.want {
+				t.Errorf("%s: response media_types %q, want %q", tc.name, got, tc.want)
+			}
+		}
+		got, err := folderService.GetMediaTypes(folderID)
+		if err != nil {
+			t.Fatalf("%s: get media types: %v", tc.name, err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: stored media_types %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
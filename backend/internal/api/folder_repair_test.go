@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestRepairFolderMappingsHandler(t *testing.T) {
+	db := newTestDB(t)
+	folderService := services.NewFolderService(db.DB)
+	jobs := services.NewJobQueue(db.DB, nil)
+	jobs.Start(1)
+	h := NewFolderHandler(folderService, services.NewFileScanner(db, folderService, t.TempDir(), nil), jobs)
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	user := seedUser(t, db.DB, "user", "user")
+	root := t.TempDir()
+	folderID := seedFolder(t, db.DB, root, admin.ID)
+	os.MkdirAll(filepath.Join(root, "2024"), 0755)
+	os.WriteFile(filepath.Join(root, "2024", "a.jpg"), []byte(strings.Repeat("x", 100)), 0644)
+	fileID := seedFile(t, db.DB, folderID, "photos/2024/a.jpg", "image")
+
+	path := fmt.Sprintf("/api/folders/%d/repair-mappings", folderID)
+	cases := []struct {
+		name   string
+		user   *models.User
+		path   string
+		status int
+	}{
+		{"anonymous", nil, path, fiber.StatusUnauthorized},
+		{"non-admin", user, path, fiber.StatusForbidden},
+		{"unknown folder", admin, "/api/folders/9999/repair-mappings", fiber.StatusNotFound},
+		{"invalid id", admin, "/api/folders/abc/repair-mappings", fiber.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		app := fiber.New()
+		app.Post("/api/folders/:id/repair-mappings", asUser(tc.user), h.RepairFolderMappings)
+		if status, body := doRequest(t, app, http.MethodPost, tc.path, "", nil); status != tc.status {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.status, body)
+		}
+	}
+
+	app := fiber.New()
+	app.Post("/api/folders/:id/repair-mappings", asUser(admin), h.RepairFolderMappings)
+	status, body := doRequest(t, app, http.MethodPost, path, "", nil)
+	if status != fiber.StatusAccepted {
+		t.Fatalf("repair: status %d, body %v", status, body)
+	}
+	job := waitForJob(t, jobs, int64(body["job_id"].(float64)))
+	if job.Status != services.JobStatusDone || job.Type != services.JobTypeRepairMappings {
+		t.Fatalf("repair job = %+v", job)
+	}
+	var result services.MappingRepairResult
+	if err := json.Unmarshal(job.Result, &result); err != nil {
+		t.Fatalf("decode job result: %v", err)
+	}
+	want := services.MappingCorrection{FileID: fileID, OldPath: "photos/2024/a.jpg", NewPath: filepath.Join("2024", "a.jpg")}
+	if len(result.Corrected) != 1 || result.Corrected[0] != want {
+		t.Errorf("corrections = %+v, want %+v", result.Corrected, want)
+	}
+
+	var stored string
+	db.QueryRow("SELECT relative_path FROM file_folder_mappings WHERE file_id = ?", fileID).Scan(&stored)
+	if stored != filepath.Join("2024", "a.jpg") {
+		t.Errorf("stored relative path %q", stored)
+	}
+}
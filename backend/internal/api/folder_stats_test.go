@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestListFoldersWithStats(t *testing.T) {
+	db := newTestDB(t)
+	folderService := services.NewFolderService(db.DB)
+	h := NewFolderHandler(folderService, services.NewFileScanner(db, folderService, t.TempDir(), nil), services.NewJobQueue(db.DB, nil))
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	user := seedUser(t, db.DB, "user", "user")
+	folderID := seedFolder(t, db.DB, "/photos", admin.ID)
+	seedFile(t, db.DB, folderID, "a.jpg", "image")
+	seedFile(t, db.DB, folderID, "b.jpg", "image")
+	grantFolder(t, db.DB, user.ID, folderID, "read")
+	grantFolder(t, db.DB, admin.ID, folderID, "write")
+
+	cases := []struct {
+		name   string
+		user   *models.User
+		query  string
+		status int
+		stats  bool
+	}{
+		{"admin with stats", admin, "?include=stats", fiber.StatusOK, true},
+		{"admin without stats", admin, "", fiber.StatusOK, false},
+		{"user asking for stats", user, "?include=stats", fiber.StatusForbidden, false},
+		{"user without stats", user, "", fiber.StatusOK, false},
+	}
+	for _, tc := range cases {
+		app := fiber.New()
+		app.Get("/api/folders", asUser(tc.user), h.ListFolders)
+
+		status, resp := doRequest(t, app, http.MethodGet, "/api/folders"+tc.query, "", nil)
+		if status != tc.status {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.status, resp)
+			continue
+		}
+		if status != fiber.StatusOK {
+			continue
+		}
+		folders := resp["folders"].([]interface{})
+		if len(folders) != 1 {
+			t.Fatalf("%s: got %d folders, want 1", tc.name, len(folders))
+		}
+		folder := folders[0].(map[string]interface{})
+		_, hasCount := folder["file_count"]
+		groups, hasGroups := folder["permission_groups"].([]interface{})
+		if hasCount != tc.stats || hasGroups != tc.stats {
+			t.Errorf("%s: stats present %t/%t, want %t", tc.name, hasCount, hasGroups, tc.stats)
+		}
+		if tc.stats && (folder["file_count"] != float64(2) || len(groups) != 2 || folder["enabled"] != true) {
+			t.Errorf("%s: unexpected stats %v", tc.name, folder)
+		}
+	}
+}
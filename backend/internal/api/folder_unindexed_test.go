@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestUnindexedFilesHandlers(t *testing.T) {
+	db := newTestDB(t)
+	folderService := services.NewFolderService(db.DB)
+	scanner := services.NewFileScanner(db, folderService, t.TempDir(), nil)
+	jobs := services.NewJobQueue(db.DB, nil)
+	jobs.Start(1)
+	h := NewFolderHandler(folderService, scanner, jobs)
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	user := seedUser(t, db.DB, "user", "user")
+	root := t.TempDir()
+	folderID := seedFolder(t, db.DB, root, admin.ID)
+	writeTestImage(t, filepath.Join(root, "a.png"), 4, 4)
+	if err := scanner.ScanFolder(folderID); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	writeTestImage(t, filepath.Join(root, "dropped.png"), 4, 4)
+
+	path := fmt.Sprintf("/api/folders/%d/unindexed", folderID)
+	appAs := func(u *models.User) *fiber.App {
+		app := fiber.New()
+		app.Get("/api/folders/:id/unindexed", asUser(u), h.ListUnindexedFiles)
+		app.Post("/api/folders/:id/unindexed", asUser(u), h.IndexUnindexedFiles)
+		return app
+	}
+
+	cases := []struct {
+		name   string
+		user   *models.User
+		path   string
+		status int
+	}{
+		{"anonymous", nil, path, fiber.StatusUnauthorized},
+		{"non-admin", user, path, fiber.StatusForbidden},
+		{"unknown folder", admin, "/api/folders/9999/unindexed", fiber.StatusNotFound},
+		{"invalid id", admin, "/api/folders/abc/unindexed", fiber.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			if status, body := doRequest(t, appAs(tc.user), method, tc.path, "", nil); status != tc.status {
+				t.Errorf("%s %s: status %d, want %d (%v)", method, tc.name, status, tc.status, body)
+			}
+		}
+	}
+
+	app := appAs(admin)
+	status, body := doRequest(t, app, http.MethodGet, path, "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("list: status %d, body %v", status, body)
+	}
+	if body["count"] != float64(1) || fmt.Sprint(body["files"]) != "[dropped.png]" || body["truncated"] != false {
+		t.Errorf("unindexed before indexing = %v", body)
+	}
+
+	status, body = doRequest(t, app, http.MethodPost, path, "", nil)
+	if status != fiber.StatusAccepted {
+		t.Fatalf("index: status %d, body %v", status, body)
+	}
+	job := waitForJob(t, jobs, int64(body["job_id"].(float64)))
+	if job.Status != services.JobStatusDone || job.Type != services.JobTypeIndexUnindexed {
+		t.Fatalf("index job = %+v", job)
+	}
+	var result struct {
+		Indexed int               `json:"indexed"`
+		Failed  map[string]string `json:"failed"`
+	}
+	if err := json.Unmarshal(job.Result, &result); err != nil {
+		t.Fatalf("decode job result: %v", err)
+	}
+	if result.Indexed != 1 || len(result.Failed) != 0 {
+		t.Errorf("index result = %+v", result)
+	}
+
+	_, body = doRequest(t, app, http.MethodGet, path, "", nil)
+	if body["count"] != float64(0) {
+		t.Errorf("unindexed after indexing = %v", body)
+	}
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/services"
+)
+
+// newGuestTestApp mounts the protected middleware chain in front of a handler
+// reporting the authenticated user
+func newGuestTestApp(authService *services.AuthService) *fiber.App {
+	app := fiber.New()
+	protected := app.Group("/api", middleware.AuthMiddleware(authService))
+	echo := func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"user": middleware.GetUser(c).Username})
+	}
+	protected.Get("/files", echo)
+	protected.Post("/files/bulk/delete", echo)
+	protected.Post("/auth/logout", echo)
+	return app
+}
+
+func TestGuestRoleIsReadOnly(t *testing.T) {
+	db := newTestDB(t)
+	authService := services.NewAuthService(db.DB)
+	app := newGuestTestApp(authService)
+
+	guest := seedUser(t, db.DB, "guest", services.GuestRole)
+	session, err := authService.CreateSession(guest.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	auth := map[string]string{"Authorization": "Bearer " + session.ID}
+
+	cases := []struct {
+		method, path string
+		want         int
+	}{
+		{"GET", "/api/files", fiber.StatusOK},
+		{"POST", "/api/files/bulk/delete", fiber.StatusForbidden},
+		{"POST", "/api/auth/logout", fiber.StatusOK},
+	}
+	for _, tc := range cases {
+		if status, _ := doRequest(t, app, tc.method, tc.path, "", auth); status != tc.want {
+			t.Errorf("%s %s: status %d, want %d", tc.method, tc.path, status, tc.want)
+		}
+	}
+
+	// Even an impersonation session that allows writes stays read-only for a guest
+	admin := seedUser(t, db.DB, "admin", "admin")
+	impersonation, err := authService.CreateImpersonationSession(guest.ID, admin.ID, time.Hour, false)
+	if err != nil {
+		t.Fatalf("create impersonation session: %v", err)
+	}
+	status, _ := doRequest(t, app, "POST", "/api/files/bulk/delete", "", map[string]string{"Authorization": "Bearer " + impersonation.ID})
+	if status != fiber.StatusForbidden {
+		t.Errorf("impersonated guest write: status %d, want %d", status, fiber.StatusForbidden)
+	}
+}
+
+func TestGuestGroupSettingSyncsGrants(t *testing.T) {
+	db := newTestDB(t)
+	authService := services.NewAuthService(db.DB)
+	permService := services.NewPermissionGroupService(db.DB)
+	settings := services.NewSettingsService(db.DB)
+	app := newGuestTestApp(authService)
+	h := NewSettingsHandler(settings, permService)
+	app.Put("/settings", h.UpdateSettings)
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	guest := seedUser(t, db.DB, "guest", services.GuestRole)
+	res := mustExec(t, db.DB, "INSERT INTO permission_groups (name, created_by) VALUES ('guests', ?)", admin.ID)
+	groupID, _ := res.LastInsertId()
+
+	grants := func() []string {
+		rows, err := db.Query("SELECT permission_group_id || ':' || permission FROM permission_group_permissions WHERE user_id = ?", guest.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var got []string
+		for rows.Next() {
+			var grant string
+			rows.Scan(&grant)
+			got = append(got, grant)
+		}
+		return got
+	}
+
+	body := `{"guest_permission_group_id": "` + strconv.FormatInt(groupID, 10) + `"}`
+	if status, resp := doRequest(t, app, "PUT", "/settings", body, map[string]string{"Content-Type": "application/json"}); status != fiber.StatusOK {
+		t.Fatalf("update settings: status %d (%v)", status, resp)
+	}
+	want := strconv.FormatInt(groupID, 10) + ":read"
+	if got := grants(); len(got) != 1 || got[0] != want {
+		t.Fatalf("guest grants after the setting changed = %v, want [%s]", got, want)
+	}
+
+	// Requests only read: a grant made elsewhere is left alone until the next sync
+	other := mustExec(t, db.DB, "INSERT INTO permission_groups (name, created_by) VALUES ('other', ?)", admin.ID)
+	otherID, _ := other.LastInsertId()
+	mustExec(t, db.DB, "INSERT INTO permission_group_permissions (permission_group_id, user_id, permission) VALUES (?, ?, 'read')", otherID, guest.ID)
+	session, err := authService.CreateSession(guest.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if status, _ := doRequest(t, app, "GET", "/api/files", "", map[string]string{"Authorization": "Bearer " + session.ID}); status != fiber.StatusOK {
+		t.Fatalf("status %d, want 200", status)
+	}
+	if got := grants(); len(got) != 2 {
+		t.Errorf("guest request changed grants: %v", got)
+	}
+
+	// Clearing the setting removes guest access
+	if status, _ := doRequest(t, app, "PUT", "/settings", `{"guest_permission_group_id": ""}`, map[string]string{"Content-Type": "application/json"}); status != fiber.StatusOK {
+		t.Fatalf("clear setting: status %d", status)
+	}
+	if got := grants(); len(got) != 0 {
+		t.Errorf("guest grants after the setting was cleared = %v", got)
+	}
+}
+
+func TestAnonymousGuestBrowsing(t *testing.T) {
+	db := newTestDB(t)
+	authService := services.NewAuthService(db.DB)
+	settings := services.NewSettingsService(db.DB)
+	app := newGuestTestApp(authService)
+
+	// Anonymous browsing is off by default
+	if status, _ := doRequest(t, app, "GET", "/api/files", "", nil); status != fiber.StatusUnauthorized {
+		t.Errorf("disabled: status %d, want 401", status)
+	}
+
+	// Pointing the setting at a non-guest account never grants its access
+	regular := seedUser(t, db.DB, "regular", "user")
+	if err := settings.SetSetting("guest_anonymous_user_id", strconv.FormatInt(regular.ID, 10)); err != nil {
+		t.Fatalf("set setting: %v", err)
+	}
+	if status, _ := doRequest(t, app, "GET", "/api/files", "", nil); status != fiber.StatusUnauthorized {
+		t.Errorf("non-guest account: status %d, want 401", status)
+	}
+
+	guest := seedUser(t, db.DB, "visitor", services.GuestRole)
+	if err := settings.SetSetting("guest_anonymous_user_id", strconv.FormatInt(guest.ID, 10)); err != nil {
+		t.Fatalf("set setting: %v", err)
+	}
+	status, body := doRequest(t, app, "GET", "/api/files", "", nil)
+	if status != fiber.StatusOK || body["user"] != "visitor" {
+		t.Errorf("guest account: status %d body %v, want 200 as visitor", status, body)
+	}
+	if status, _ := doRequest(t, app, "POST", "/api/files/bulk/delete", "", nil); status != fiber.StatusForbidden {
+		t.Errorf("anonymous write: status %d, want 403", status)
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"awesome-sharing/internal/models"
 	"awesome-sharing/internal/services"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -19,9 +22,16 @@ type Handler struct {
 	validator     *services.FileValidatorService
 	folderService *services.FolderService
 	permService   *services.PermissionGroupService
+	statsService  *services.FileStatsService
+	metaService   *services.PhotoMetadataService
+	settings      *services.SettingsService
+	shareService  *services.ShareService
+	albumService  *services.AlbumService
+	contentHashes *services.ContentHashService
+	jobs          *services.JobQueue
 }
 
-func NewHandler(db *database.DB, scanner *services.FileScanner, thumbService *services.ThumbnailService, validator *services.FileValidatorService, folderService *services.FolderService, permService *services.PermissionGroupService) *Handler {
+func NewHandler(db *database.DB, scanner *services.FileScanner, thumbService *services.ThumbnailService, validator *services.FileValidatorService, folderService *services.FolderService, permService *services.PermissionGroupService, statsService *services.FileStatsService, metaService *services.PhotoMetadataService, settings *services.SettingsService, shareService *services.ShareService, albumService *services.AlbumService, jobs *services.JobQueue) *Handler {
 	return &Handler{
 		db:            db,
 		scanner:       scanner,
@@ -29,6 +39,13 @@ func NewHandler(db *database.DB, scanner *services.FileScanner, thumbService *se
 		validator:     validator,
 		folderService: folderService,
 		permService:   permService,
+		statsService:  statsService,
+		metaService:   metaService,
+		settings:      settings,
+		shareService:  shareService,
+		albumService:  albumService,
+		contentHashes: services.NewContentHashService(db.DB),
+		jobs:          jobs,
 	}
 }
 
@@ -44,8 +61,18 @@ func (h *Handler) GetFiles(c *fiber.Ctx) error {
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "50"))
 	fileType := c.Query("type", "")
+	excludeScreenshots := c.Query("exclude_screenshots") == "true"
+	timeOfDay := c.Query("time_of_day")
+	season := c.Query("season")
 	offset := (page - 1) * limit
 
+	if timeOfDay != "" && !isFacetBucket(timeOfDayBuckets, timeOfDay) {
+		return c.Status(400).JSON(fiber.Map{"error": "time_of_day must be morning, afternoon, evening or night"})
+	}
+	if season != "" && !isFacetBucket(seasonBuckets, season) {
+		return c.Status(400).JSON(fiber.Map{"error": "season must be spring, summer, autumn or winter"})
+	}
+
 	isServerOwner := user.Role == "server_owner"
 
 	var query string
@@ -76,11 +103,31 @@ func (h *Handler) GetFiles(c *fiber.Ctx) error {
 		args = append(args, fileType)
 	}
 
+	if excludeScreenshots {
+		query += " AND COALESCE(pm.is_screenshot, 0) = 0"
+	}
+
+	if timeOfDay != "" {
+		query += " AND (" + timeOfDaySQL + ") = ?"
+		args = append(args, timeOfDay)
+	}
+
+	if season != "" {
+		query += " AND (" + seasonSQL + ") = ?"
+		args = append(args, season)
+	}
+
 	query += " ORDER BY pm.taken_at DESC LIMIT ? OFFSET ?"
 	args = append(args, limit, offset)
 
-	rows, err := h.db.Query(query, args...)
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	rows, err := h.db.QueryContext(ctx, query, args...)
 	if err != nil {
+		if isQueryTimeout(err) {
+			return queryTimeoutError(c)
+		}
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 	defer rows.Close()
@@ -105,9 +152,12 @@ func (h *Handler) GetFiles(c *fiber.Ctx) error {
 		if takenAt.Valid {
 			f.TakenAt = &takenAt.Time
 		}
-		f.ThumbnailURL = "/api/files/" + strconv.FormatInt(f.ID, 10) + "/thumbnail"
+		setThumbnailURLs(&f)
 		files = append(files, f)
 	}
+	if err := rows.Err(); err != nil && isQueryTimeout(err) {
+		return queryTimeoutError(c)
+	}
 
 	// Validate files and filter out deleted ones, also resolves absolute_path
 	files = h.validator.ValidateFiles(files)
@@ -131,6 +181,7 @@ func (h *Handler) GetTimeline(c *fiber.Ctx) error {
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "50"))
 	year := c.Query("year", "")
+	excludeScreenshots := c.Query("exclude_screenshots") == "true"
 	offset := (page - 1) * limit
 
 	isServerOwner := user.Role == "server_owner"
@@ -151,6 +202,10 @@ func (h *Handler) GetTimeline(c *fiber.Ctx) error {
 			args = append(args, year)
 		}
 
+		if excludeScreenshots {
+			query += " AND COALESCE(pm.is_screenshot, 0) = 0"
+		}
+
 		query += " ORDER BY pm.taken_at DESC LIMIT ? OFFSET ?"
 		args = append(args, limit, offset)
 	} else {
@@ -170,6 +225,10 @@ func (h *Handler) GetTimeline(c *fiber.Ctx) error {
 			args = append(args, year)
 		}
 
+		if excludeScreenshots {
+			query += " AND COALESCE(pm.is_screenshot, 0) = 0"
+		}
+
 		query += " ORDER BY pm.taken_at DESC LIMIT ? OFFSET ?"
 		args = append(args, limit, offset)
 	}
@@ -199,7 +258,7 @@ func (h *Handler) GetTimeline(c *fiber.Ctx) error {
 		if takenAt.Valid {
 			f.TakenAt = &takenAt.Time
 		}
-		f.ThumbnailURL = "/api/files/" + strconv.FormatInt(f.ID, 10) + "/thumbnail"
+		setThumbnailURLs(&f)
 		files = append(files, f)
 	}
 
@@ -265,17 +324,33 @@ func (h *Handler) GetFileByID(c *fiber.Ctx) error {
 		f.TakenAt = &takenAt.Time
 	}
 
-	// Resolve absolute path
-	absolutePath, err := h.folderService.ResolveAbsolutePath(f.ID)
-	if err == nil {
-		f.AbsolutePath = absolutePath
-	}
-
-	f.ThumbnailURL = "/api/files/" + strconv.FormatInt(f.ID, 10) + "/thumbnail"
+	setThumbnailURLs(&f)
 
 	return c.JSON(f)
 }
 
+// setThumbnailURLs fills in the default thumbnail URL and the URL of every
+// configured size. Thumbnails are generated lazily on first request.
+func setThumbnailURLs(f *models.File) {
+	base := services.BasePath() + "/api/files/" + strconv.FormatInt(f.ID, 10) + "/thumbnail"
+	f.ThumbnailURL = base
+	f.Thumbnails = make(map[string]string, len(services.ThumbnailSizes))
+	for name := range services.ThumbnailSizes {
+		f.Thumbnails[name] = base + "?size=" + name
+	}
+}
+
+// thumbnailMode validates a requested thumbnail mode, falling back to the configured default
+func (h *Handler) thumbnailMode(mode string) (string, error) {
+	if mode == "" {
+		return h.settings.GetThumbnailMode()
+	}
+	if !services.IsValidThumbnailMode(mode) {
+		return "", errors.New("Mode must be 'fit' or 'cover'")
+	}
+	return mode, nil
+}
+
 // GetFileThumbnail serves thumbnail for a file
 func (h *Handler) GetFileThumbnail(c *fiber.Ctx) error {
 	user := middleware.GetUser(c)
@@ -304,22 +379,139 @@ func (h *Handler) GetFileThumbnail(c *fiber.Ctx) error {
 	// Get size parameter (small, medium, large)
 	sizeType := c.Query("size", "small")
 
+	mode, err := h.thumbnailMode(c.Query("mode"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// Resolve absolute path through folder service
 	filePath, err := h.folderService.ResolveAbsolutePath(id)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"error": "File not found"})
 	}
 
-	thumbPath, err := h.thumbService.GetThumbnail(filePath, id, sizeType)
+	thumbPath, err := h.thumbService.GetThumbnail(filePath, id, sizeType, mode)
 	if err != nil {
-		log.Printf("Error getting thumbnail: %v", err)
+		if !errors.Is(err, services.ErrSourceTooLarge) && !errors.Is(err, services.ErrHEICUnsupported) {
+			log.Printf("Error getting thumbnail: %v", err)
+		}
+		return h.sendPlaceholderThumbnail(c, id, filePath, sizeType)
+	}
+
+	if setCacheValidators(c, id, thumbPath) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return sendThumbnail(c, thumbPath)
+}
+
+// sendPlaceholderThumbnail serves a generic per-type image for files that can't be
+// thumbnailed (unsupported formats, videos, images over the decode limit), so grids
+// never show broken images
+func (h *Handler) sendPlaceholderThumbnail(c *fiber.Ctx, fileID int64, filePath, sizeType string) error {
+	var fileType string
+	if err := h.db.QueryRow("SELECT file_type FROM files WHERE id = ?", fileID).Scan(&fileType); err != nil {
+		log.Printf("Failed to look up file type of %d: %v", fileID, err)
+	}
+
+	kind := services.PlaceholderKind(fileType, filePath)
+	placeholderPath, err := h.thumbService.GetPlaceholder(kind, sizeType)
+	if err != nil {
+		log.Printf("Error getting %s placeholder: %v", kind, err)
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to generate thumbnail"})
 	}
 
-	return c.SendFile(thumbPath)
+	// Not cached by the client: a real thumbnail may become available later
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Thumbnail-Placeholder", kind)
+	return sendFileAs(c, placeholderPath, "image/jpeg")
+}
+
+// maxPrefetchFiles limits the number of thumbnails a single prefetch request may generate
+const maxPrefetchFiles = 200
+
+// PrefetchThumbnails generates thumbnails for a batch of files without returning them
+// POST /api/files/thumbnails/prefetch
+func (h *Handler) PrefetchThumbnails(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	var req struct {
+		FileIDs []int64 `json:"file_ids"`
+		Size    string  `json:"size"`
+		Mode    string  `json:"mode"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.FileIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "No file IDs provided",
+		})
+	}
+	if len(req.FileIDs) > maxPrefetchFiles {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Too many files, maximum is " + strconv.Itoa(maxPrefetchFiles),
+		})
+	}
+	if req.Size == "" {
+		req.Size = "small"
+	}
+	if _, ok := services.ThumbnailSizes[req.Size]; !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid thumbnail size",
+		})
+	}
+
+	mode, err := h.thumbnailMode(req.Mode)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Resolve paths of the files the user can access
+	isServerOwner := user.Role == "server_owner"
+	paths := make(map[int64]string)
+	failed := []int64{}
+	for _, id := range req.FileIDs {
+		hasAccess, err := h.permService.CheckFileAccess(user.ID, id, isServerOwner)
+		if err != nil || !hasAccess {
+			failed = append(failed, id)
+			continue
+		}
+		filePath, err := h.folderService.ResolveAbsolutePath(id)
+		if err != nil {
+			failed = append(failed, id)
+			continue
+		}
+		paths[id] = filePath
+	}
+
+	ready, errs := h.thumbService.Prefetch(paths, req.Size, mode)
+	for id, err := range errs {
+		log.Printf("Error prefetching thumbnail for file %d: %v", id, err)
+		failed = append(failed, id)
+	}
+
+	return c.JSON(fiber.Map{
+		"size":   req.Size,
+		"mode":   mode,
+		"ready":  ready,
+		"failed": failed,
+	})
 }
 
-// DownloadFile sends the original file
+// DownloadFile sends the original file as an attachment, or inline with ?disposition=inline
+// GET /api/files/:id/download
 func (h *Handler) DownloadFile(c *fiber.Ctx) error {
 	user := middleware.GetUser(c)
 	if user == nil {
@@ -344,8 +536,8 @@ func (h *Handler) DownloadFile(c *fiber.Ctx) error {
 		}
 	}
 
-	var filename string
-	err = h.db.QueryRow("SELECT filename FROM files WHERE id = ?", id).Scan(&filename)
+	var filename, fileType string
+	err = h.db.QueryRow("SELECT filename, file_type FROM files WHERE id = ?", id).Scan(&filename, &fileType)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"error": "File not found"})
 	}
@@ -356,11 +548,172 @@ func (h *Handler) DownloadFile(c *fiber.Ctx) error {
 		return c.Status(404).JSON(fiber.Map{"error": "File not found"})
 	}
 
-	c.Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
-	return c.SendFile(filePath)
+	if setCacheValidators(c, id, filePath) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	setChecksumHeader(c, h.contentHashes, id, filePath, true)
+
+	// Default forces a download; disposition=inline lets the browser open the original in a tab
+	contentType := services.ContentTypeForFile(filePath, fileType)
+	if c.Query("disposition") != "inline" {
+		c.Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+		return sendFileAs(c, filePath, contentType)
+	}
+
+	if err := sendFileAs(c, filePath, contentType); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentDisposition, "inline; filename=\""+filename+"\"")
+	return nil
 }
 
-// SearchFiles searches files by name or tags
+// GetFileRaw streams the original file inline with its content type, for embedding
+// GET /api/files/:id/raw
+func (h *Handler) GetFileRaw(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid file ID"})
+	}
+
+	// Check if user has access to this file
+	isServerOwner := user.Role == "server_owner"
+	if !isServerOwner {
+		hasAccess, err := h.permService.CheckFileAccess(user.ID, id, isServerOwner)
+		if err != nil || !hasAccess {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Access denied",
+			})
+		}
+	}
+
+	var fileType string
+	if err := h.db.QueryRow("SELECT file_type FROM files WHERE id = ?", id).Scan(&fileType); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "File not found"})
+	}
+
+	// Resolve absolute path through folder service
+	filePath, err := h.folderService.ResolveAbsolutePath(id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "File not found"})
+	}
+
+	if setCacheValidators(c, id, filePath) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	if err := sendFileAs(c, filePath, services.ContentTypeForFile(filePath, fileType)); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentDisposition, "inline")
+	return nil
+}
+
+// GetFileStats returns view/download counters for a file, aggregated across all shares
+// GET /api/files/:id/stats
+func (h *Handler) GetFileStats(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid file ID"})
+	}
+
+	// Check if user has access to this file
+	isServerOwner := user.Role == "server_owner"
+	if !isServerOwner {
+		hasAccess, err := h.permService.CheckFileAccess(user.ID, id, isServerOwner)
+		if err != nil || !hasAccess {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Access denied",
+			})
+		}
+	}
+
+	var exists bool
+	if err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM files WHERE id = ?)", id).Scan(&exists); err != nil || !exists {
+		return c.Status(404).JSON(fiber.Map{"error": "File not found"})
+	}
+
+	stats, err := h.statsService.GetStats(id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"stats": stats})
+}
+
+// GetSimilarFiles returns files that look nearly identical to the given file (by perceptual hash)
+// GET /api/files/:id/similar?max_distance=10&limit=50
+func (h *Handler) GetSimilarFiles(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid file ID"})
+	}
+
+	isServerOwner := user.Role == "server_owner"
+	if !isServerOwner {
+		hasAccess, err := h.permService.CheckFileAccess(user.ID, id, isServerOwner)
+		if err != nil || !hasAccess {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Access denied",
+			})
+		}
+	}
+
+	maxDistance, _ := strconv.Atoi(c.Query("max_distance", "10"))
+	if maxDistance < 0 || maxDistance > 32 {
+		return c.Status(400).JSON(fiber.Map{"error": "max_distance must be between 0 and 32"})
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	var scope *int64
+	if !isServerOwner {
+		scope = &user.ID
+	}
+
+	similar, err := h.metaService.FindSimilar(id, maxDistance, limit, scope)
+	if err != nil {
+		if err == services.ErrNoPerceptualHash {
+			return c.Status(404).JSON(fiber.Map{"error": "No perceptual hash available for this file"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	for i := range similar {
+		setThumbnailURLs(&similar[i].File)
+	}
+
+	return c.JSON(fiber.Map{
+		"files":        similar,
+		"max_distance": maxDistance,
+	})
+}
+
+// SearchFiles searches files by name or tags, optionally scoped to a folder or album
+// GET /api/search?q=term&page=1&limit=50&folder_id=1&album_id=2
 func (h *Handler) SearchFiles(c *fiber.Ctx) error {
 	user := middleware.GetUser(c)
 	if user == nil {
@@ -374,42 +727,87 @@ func (h *Handler) SearchFiles(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Search query is required"})
 	}
 
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	offset := (page - 1) * limit
+
 	isServerOwner := user.Role == "server_owner"
 
-	var sqlQuery string
-	var args []interface{}
+	fromClause := `FROM files f
+	               LEFT JOIN photo_metadata pm ON f.id = pm.file_id
+	               LEFT JOIN file_tags ft ON f.id = ft.file_id
+	               LEFT JOIN tags t ON ft.tag_id = t.id`
+	whereClause := ` WHERE (f.filename LIKE ? OR t.name LIKE ?)`
+	args := []interface{}{"%" + query + "%", "%" + query + "%"}
 
-	if isServerOwner {
-		// Server owner can search all files
-		sqlQuery = `SELECT DISTINCT f.id, f.filename, f.file_type, f.size, f.created_at, f.updated_at,
-		                   pm.width, pm.height, pm.taken_at
-		            FROM files f
-		            LEFT JOIN photo_metadata pm ON f.id = pm.file_id
-		            LEFT JOIN file_tags ft ON f.id = ft.file_id
-		            LEFT JOIN tags t ON ft.tag_id = t.id
-		            WHERE f.filename LIKE ? OR t.name LIKE ?
-		            ORDER BY pm.taken_at DESC
-		            LIMIT 100`
-		args = []interface{}{"%" + query + "%", "%" + query + "%"}
-	} else {
+	if !isServerOwner {
 		// Regular users can only search files they have permission for
-		sqlQuery = `SELECT DISTINCT f.id, f.filename, f.file_type, f.size, f.created_at, f.updated_at,
-		                   pm.width, pm.height, pm.taken_at
-		            FROM files f
-		            LEFT JOIN photo_metadata pm ON f.id = pm.file_id
-		            LEFT JOIN file_tags ft ON f.id = ft.file_id
-		            LEFT JOIN tags t ON ft.tag_id = t.id
-		            JOIN file_folder_mappings ffm ON f.id = ffm.file_id
-		            JOIN permission_group_folders pgf ON ffm.folder_id = pgf.folder_id
-		            JOIN permission_group_permissions pgp ON pgf.permission_group_id = pgp.permission_group_id
-		            WHERE (f.filename LIKE ? OR t.name LIKE ?)
-		            AND pgp.user_id = ?
-		            ORDER BY pm.taken_at DESC
-		            LIMIT 100`
-		args = []interface{}{"%" + query + "%", "%" + query + "%", user.ID}
-	}
-
-	rows, err := h.db.Query(sqlQuery, args...)
+		fromClause += `
+	               JOIN file_folder_mappings ffm ON f.id = ffm.file_id
+	               JOIN permission_group_folders pgf ON ffm.folder_id = pgf.folder_id
+	               JOIN permission_group_permissions pgp ON pgf.permission_group_id = pgp.permission_group_id`
+		whereClause += " AND pgp.user_id = ?"
+		args = append(args, user.ID)
+	}
+
+	// Optional scope: a single folder
+	if folderIDStr := c.Query("folder_id"); folderIDStr != "" {
+		folderID, err := strconv.ParseInt(folderIDStr, 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid folder ID"})
+		}
+		hasAccess, err := h.permService.CheckFolderAccess(user.ID, folderID, isServerOwner)
+		if err != nil || !hasAccess {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Access denied",
+			})
+		}
+		whereClause += " AND EXISTS (SELECT 1 FROM file_folder_mappings sffm WHERE sffm.file_id = f.id AND sffm.folder_id = ?)"
+		args = append(args, folderID)
+	}
+
+	// Optional scope: the files of an album the user owns or collaborates on
+	// (results stay limited to files the user can access, see above)
+	if albumIDStr := c.Query("album_id"); albumIDStr != "" {
+		albumID, err := strconv.ParseInt(albumIDStr, 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid album ID"})
+		}
+		album, err := h.albumService.GetAlbum(albumID)
+		if err != nil {
+			if err == services.ErrAlbumNotFound {
+				return c.Status(404).JSON(fiber.Map{"error": "Album not found"})
+			}
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		if !isServerOwner && !canAccessAlbum(h.albumService, user, album, false) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Access denied",
+			})
+		}
+		whereClause += ` AND EXISTS (
+			SELECT 1 FROM album_folders af
+			JOIN file_folder_mappings ffm ON ffm.folder_id = af.folder_id
+			WHERE af.album_id = ? AND ffm.file_id = f.id AND ` + services.PathPrefixMatchSQL + `)`
+		args = append(args, albumID)
+	}
+
+	var total int
+	if err := h.db.QueryRow("SELECT COUNT(DISTINCT f.id) "+fromClause+whereClause, args...).Scan(&total); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	sqlQuery := `SELECT DISTINCT f.id, f.filename, f.file_type, f.size, f.created_at, f.updated_at,
+	                    pm.width, pm.height, pm.taken_at ` + fromClause + whereClause + `
+	             ORDER BY pm.taken_at DESC
+	             LIMIT ? OFFSET ?`
+	rows, err := h.db.Query(sqlQuery, append(args, limit, offset)...)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -434,14 +832,20 @@ func (h *Handler) SearchFiles(c *fiber.Ctx) error {
 		if takenAt.Valid {
 			f.TakenAt = &takenAt.Time
 		}
-		f.ThumbnailURL = "/api/files/" + strconv.FormatInt(f.ID, 10) + "/thumbnail"
+		setThumbnailURLs(&f)
 		files = append(files, f)
 	}
 
 	// Validate files and filter out deleted ones
 	files = h.validator.ValidateFiles(files)
 
-	return c.JSON(fiber.Map{"files": files})
+	return c.JSON(fiber.Map{
+		"files":       files,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": (total + limit - 1) / limit,
+	})
 }
 
 // GetMountPoints returns all mount points (deprecated, kept for compatibility)
@@ -458,15 +862,19 @@ func (h *Handler) TriggerScan(c *fiber.Ctx) error {
 	})
 }
 
-// CleanupDeletedFiles removes database records for files that no longer exist
+// CleanupDeletedFiles removes database records for files that no longer exist.
+// The run walks the whole library, so it is not bound by the query timeout;
+// VALIDATION_TIMEOUT_MINUTES limits it instead, and a partial run resumes next time.
 func (h *Handler) CleanupDeletedFiles(c *fiber.Ctx) error {
-	count, err := h.validator.CleanupAllInvalidFiles()
+	result, err := h.validator.CleanupAllInvalidFiles(c.UserContext())
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(fiber.Map{
-		"message": "Cleanup completed",
-		"deleted": count,
+		"message":      "Cleanup completed",
+		"deleted":      result.Deleted,
+		"empty_albums": result.EmptyAlbums,
+		"partial":      result.Partial,
 	})
 }
 
@@ -608,3 +1016,92 @@ func (h *Handler) GetTimelineYears(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{"years": years})
 }
+
+// GetTravel returns GPS clusters of a year's photos bucketed by month
+// GET /api/files/travel?year=2024&precision=1
+func (h *Handler) GetTravel(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	year, err := strconv.Atoi(c.Query("year", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid year"})
+	}
+
+	// Cluster size: coordinates are rounded to this many decimals (1 ≈ 11km)
+	precision, err := strconv.Atoi(c.Query("precision", "1"))
+	if err != nil || precision < 0 || precision > 3 {
+		return c.Status(400).JSON(fiber.Map{"error": "Precision must be between 0 and 3"})
+	}
+
+	isServerOwner := user.Role == "server_owner"
+
+	query := `SELECT strftime('%m', pm.taken_at) as month,
+	                 ROUND(pm.latitude, ?) as lat_bucket, ROUND(pm.longitude, ?) as lon_bucket,
+	                 AVG(pm.latitude), AVG(pm.longitude), COUNT(*), MIN(f.id)
+	          FROM files f
+	          INNER JOIN photo_metadata pm ON f.id = pm.file_id
+	          WHERE pm.taken_at IS NOT NULL
+	            AND pm.latitude IS NOT NULL AND pm.longitude IS NOT NULL
+	            AND strftime('%Y', pm.taken_at) = ?`
+	args := []interface{}{precision, precision, fmt.Sprintf("%04d", year)}
+
+	if !isServerOwner {
+		// Regular users can only see files they have permission for
+		query += ` AND f.id IN (
+		             SELECT ffm.file_id
+		             FROM file_folder_mappings ffm
+		             JOIN permission_group_folders pgf ON ffm.folder_id = pgf.folder_id
+		             JOIN permission_group_permissions pgp ON pgf.permission_group_id = pgp.permission_group_id
+		             WHERE pgp.user_id = ?)`
+		args = append(args, user.ID)
+	}
+
+	query += " GROUP BY month, lat_bucket, lon_bucket ORDER BY month, COUNT(*) DESC"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	defer rows.Close()
+
+	type Cluster struct {
+		Latitude     float64 `json:"latitude"`
+		Longitude    float64 `json:"longitude"`
+		Count        int     `json:"count"`
+		SampleFileID int64   `json:"sample_file_id"`
+	}
+	type MonthInfo struct {
+		Month    int       `json:"month"`
+		Count    int       `json:"count"`
+		Clusters []Cluster `json:"clusters"`
+	}
+
+	months := []MonthInfo{}
+	for rows.Next() {
+		var monthStr string
+		var latBucket, lonBucket float64
+		var cl Cluster
+		if err := rows.Scan(&monthStr, &latBucket, &lonBucket, &cl.Latitude, &cl.Longitude,
+			&cl.Count, &cl.SampleFileID); err != nil {
+			continue
+		}
+		month, _ := strconv.Atoi(monthStr)
+
+		if len(months) == 0 || months[len(months)-1].Month != month {
+			months = append(months, MonthInfo{Month: month, Clusters: []Cluster{}})
+		}
+		m := &months[len(months)-1]
+		m.Clusters = append(m.Clusters, cl)
+		m.Count += cl.Count
+	}
+
+	return c.JSON(fiber.Map{
+		"year":   year,
+		"months": months,
+	})
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/database"
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+// activityCount counts user activity log entries for a user, action and performer
+func activityCount(t *testing.T, db *database.DB, userID, performedBy int64, action string) int {
+	t.Helper()
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM user_activity_logs WHERE user_id = ? AND performed_by = ? AND action = ?",
+		userID, performedBy, action).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestImpersonateUser(t *testing.T) {
+	db := newTestDB(t)
+	authService := services.NewAuthService(db.DB)
+	admin := NewAdminHandler(authService, services.NewShareService(db.DB, nil), services.NewFolderService(db.DB))
+	auth := NewAuthHandler(authService, services.NewSettingsService(db.DB), nil)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	alice := seedUser(t, db.DB, "alice", "user")
+	disabled := seedUser(t, db.DB, "disabled", "user")
+	mustExec(t, db.DB, "UPDATE users SET enabled = 0 WHERE id = ?", disabled.ID)
+
+	impersonate := func(user *models.User, targetID int64, body string) (int, map[string]interface{}) {
+		app := fiber.New()
+		app.Post("/api/admin/impersonate/:id", asUser(user), middleware.ServerOwnerOnlyMiddleware(), admin.ImpersonateUser)
+		return doRequest(t, app, http.MethodPost, fmt.Sprintf("/api/admin/impersonate/%d", targetID), body, nil)
+	}
+
+	cases := []struct {
+		name   string
+		user   *models.User
+		target int64
+		body   string
+		want   int
+	}{
+		{"not the server owner", alice, owner.ID, "", fiber.StatusForbidden},
+		{"self", owner, owner.ID, "", fiber.StatusBadRequest},
+		{"unknown user", owner, 9999, "", fiber.StatusNotFound},
+		{"disabled user", owner, disabled.ID, "", fiber.StatusBadRequest},
+		{"duration too long", owner, alice.ID, `{"duration_minutes":241}`, fiber.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		if status, resp := impersonate(tc.user, tc.target, tc.body); status != tc.want {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.want, resp)
+		}
+	}
+
+	// Requests made with the issued token go through the real session middleware
+	app := fiber.New()
+	protected := app.Group("/api", middleware.AuthMiddleware(authService))
+	protected.Get("/auth/me", auth.Me)
+	protected.Post("/auth/impersonation/end", auth.EndImpersonation)
+	protected.Post("/things", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	protected.Post("/admin/impersonate/:id", admin.ImpersonateUser)
+
+	start := func(body string) map[string]string {
+		status, resp := impersonate(owner, alice.ID, body)
+		if status != fiber.StatusCreated {
+			t.Fatalf("impersonate %s: status %d (%v)", body, status, resp)
+		}
+		return map[string]string{"Authorization": "Bearer " + resp["session_id"].(string)}
+	}
+
+	// Default: read-only session scoped to the target user
+	token := start("")
+	if n := activityCount(t, db, alice.ID, owner.ID, "impersonation_started"); n != 1 {
+		t.Errorf("impersonation_started entries: %d, want 1", n)
+	}
+	status, me := doRequest(t, app, http.MethodGet, "/api/auth/me", "", token)
+	if status != fiber.StatusOK {
+		t.Fatalf("me: status %d", status)
+	}
+	if me["user"].(map[string]interface{})["id"] != float64(alice.ID) || me["impersonated_by"] != float64(owner.ID) ||
+		me["impersonation_read_only"] != true {
+		t.Errorf("me: unexpected response %v", me)
+	}
+	if status, _ := doRequest(t, app, http.MethodPost, "/api/things", "", token); status != fiber.StatusForbidden {
+		t.Errorf("read-only session mutation: status %d, want 403", status)
+	}
+	if status, _ := doRequest(t, app, http.MethodPost, fmt.Sprintf("/api/admin/impersonate/%d", owner.ID), "", token); status != fiber.StatusForbidden {
+		t.Errorf("nested impersonation: status %d, want 403", status)
+	}
+	if status, _ := doRequest(t, app, http.MethodPost, "/api/auth/impersonation/end", "", token); status != fiber.StatusOK {
+		t.Errorf("end read-only impersonation: status %d", status)
+	}
+	if status, _ := doRequest(t, app, http.MethodGet, "/api/auth/me", "", token); status != fiber.StatusUnauthorized {
+		t.Errorf("ended session still valid: status %d", status)
+	}
+
+	// Writable session: mutations are allowed and audited
+	token = start(`{"duration_minutes":15,"read_only":false}`)
+	if status, _ := doRequest(t, app, http.MethodPost, "/api/things", "", token); status != fiber.StatusOK {
+		t.Errorf("writable session mutation: status %d, want 200", status)
+	}
+	// Ending the read-only session above was recorded too
+	if n := activityCount(t, db, alice.ID, owner.ID, "impersonated_request"); n != 2 {
+		t.Errorf("impersonated_request entries: %d, want 2", n)
+	}
+	if status, _ := doRequest(t, app, http.MethodPost, "/api/auth/impersonation/end", "", token); status != fiber.StatusOK {
+		t.Errorf("end impersonation: status %d", status)
+	}
+	if n := activityCount(t, db, alice.ID, owner.ID, "impersonation_ended"); n != 2 {
+		t.Errorf("impersonation_ended entries: %d, want 2", n)
+	}
+
+	// A regular session cannot "end" impersonation
+	regular, err := authService.CreateSession(alice.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	headers := map[string]string{"Authorization": "Bearer " + regular.ID}
+	if status, _ := doRequest(t, app, http.MethodPost, "/api/auth/impersonation/end", "", headers); status != fiber.StatusBadRequest {
+		t.Errorf("end from regular session: status %d, want 400", status)
+	}
+}
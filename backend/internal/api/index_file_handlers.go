@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+// IndexFile indexes a single file by absolute path under the folder that covers it,
+// for debugging why a file doesn't show up without rescanning the whole folder
+// POST /api/admin/index-file
+func (h *Handler) IndexFile(c *fiber.Ctx) error {
+	var req struct {
+		AbsolutePath string `json:"absolute_path"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.AbsolutePath == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "absolute_path is required"})
+	}
+
+	indexed, err := h.scanner.IndexPath(req.AbsolutePath)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPathNotAbsolute), errors.Is(err, services.ErrNotMediaFile):
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, services.ErrNoFolderForPath):
+			return c.Status(422).JSON(fiber.Map{"error": "No folder covers this path; add its directory as a folder first"})
+		case errors.Is(err, os.ErrNotExist):
+			return c.Status(404).JSON(fiber.Map{"error": "File not found on disk"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var f models.File
+	err = h.db.QueryRow(`
+		SELECT f.id, f.filename, f.file_type, f.size, f.created_at, f.updated_at,
+		       COALESCE(pm.width, 0), COALESCE(pm.height, 0), pm.taken_at
+		FROM files f
+		LEFT JOIN photo_metadata pm ON f.id = pm.file_id
+		WHERE f.id = ?`, indexed.FileID).Scan(
+		&f.ID, &f.Filename, &f.FileType, &f.Size, &f.CreatedAt, &f.UpdatedAt,
+		&f.Width, &f.Height, &f.TakenAt)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	setThumbnailURLs(&f)
+
+	return c.JSON(fiber.Map{
+		"file":          f,
+		"folder_id":     indexed.FolderID,
+		"relative_path": indexed.RelativePath,
+		"created":       indexed.Created,
+	})
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestIndexFile(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	admin := seedUser(t, db.DB, "admin", "admin")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, admin.ID)
+	writeTestImage(t, filepath.Join(root, "a.png"), 40, 30)
+	outside := filepath.Join(t.TempDir(), "b.png")
+	writeTestImage(t, outside, 40, 30)
+
+	app := fiber.New()
+	app.Post("/api/admin/index-file", asUser(admin), h.IndexFile)
+	index := func(path string) (int, map[string]interface{}) {
+		return doRequest(t, app, http.MethodPost, "/api/admin/index-file", `{"absolute_path":"`+path+`"}`,
+			map[string]string{"Content-Type": "application/json"})
+	}
+
+	status, resp := index(filepath.Join(root, "a.png"))
+	if status != fiber.StatusOK {
+		t.Fatalf("index: status %d (%v)", status, resp)
+	}
+	file := resp["file"].(map[string]interface{})
+	if resp["folder_id"] != float64(folder) || resp["relative_path"] != "a.png" || resp["created"] != true ||
+		file["filename"] != "a.png" || file["width"] != float64(40) {
+		t.Errorf("unexpected response %v", resp)
+	}
+
+	cases := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"missing path", "", fiber.StatusBadRequest},
+		{"relative path", "a.png", fiber.StatusBadRequest},
+		{"no folder covers the file", outside, fiber.StatusUnprocessableEntity},
+		{"missing file", filepath.Join(root, "gone.png"), fiber.StatusNotFound},
+		{"directory", root, fiber.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		if status, resp := index(tc.path); status != tc.status {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.status, resp)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestExportInventoryHandler(t *testing.T) {
+	db := newTestDB(t)
+	h := NewAdminHandler(services.NewAuthService(db.DB), services.NewShareService(db.DB, nil), services.NewFolderService(db.DB))
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	user := seedUser(t, db.DB, "user", "user")
+	folder := seedFolder(t, db.DB, "/library/photos", admin.ID)
+	fileID := seedFile(t, db.DB, folder, "2024/beach.jpg", "image")
+
+	newApp := func(u *models.User) *fiber.App {
+		app := fiber.New()
+		app.Get("/api/admin/inventory.csv", asUser(u), middleware.AdminOnlyMiddleware(), h.ExportInventory)
+		return app
+	}
+
+	if status, _ := doRequest(t, newApp(user), http.MethodGet, "/api/admin/inventory.csv", "", nil); status != fiber.StatusForbidden {
+		t.Errorf("non-admin: status %d, want 403", status)
+	}
+
+	resp := sendRequest(t, newApp(admin), http.MethodGet, "/api/admin/inventory.csv", "", nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get("Content-Type") != "text/csv" {
+		t.Fatalf("status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Disposition"), "attachment; filename=inventory_") {
+		t.Errorf("Content-Disposition = %q", resp.Header.Get("Content-Disposition"))
+	}
+
+	reader := csv.NewReader(resp.Body)
+	header, err := reader.Read()
+	if err != nil {
+		t.Fatalf("read header: %v", err)
+	}
+	if strings.Join(header, ",") != "file_id,folder,relative_path,filename,size,file_type,taken_at,tags" {
+		t.Errorf("header = %v", header)
+	}
+	row, err := reader.Read()
+	if err != nil {
+		t.Fatalf("read first row: %v", err)
+	}
+	want := []string{strconv.FormatInt(fileID, 10), "photos", "2024/beach.jpg", "beach.jpg", "100", "image", "", ""}
+	if strings.Join(row, ",") != strings.Join(want, ",") {
+		t.Errorf("first row = %v, want %v", row, want)
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/services"
+)
+
+type JobHandler struct {
+	jobs *services.JobQueue
+}
+
+func NewJobHandler(jobs *services.JobQueue) *JobHandler {
+	return &JobHandler{jobs: jobs}
+}
+
+// GetJob returns the status and progress of a background job (creator or admin only)
+// GET /api/jobs/:id
+func (h *JobHandler) GetJob(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid job ID",
+		})
+	}
+
+	job, err := h.jobs.GetJob(id)
+	if err != nil {
+		if err == services.ErrJobNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Job not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch job",
+		})
+	}
+
+	isAdmin := user.Role == "admin" || user.Role == "server_owner"
+	if job.CreatedBy != user.ID && !isAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"job": job,
+	})
+}
+
+// DownloadJobFile downloads the file a finished job produced, e.g. an album export
+// (creator only)
+// GET /api/jobs/:id/download
+func (h *JobHandler) DownloadJobFile(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid job ID",
+		})
+	}
+
+	job, err := h.jobs.GetJob(id)
+	if err != nil {
+		if err == services.ErrJobNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Job not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch job",
+		})
+	}
+
+	// Exports contain the creator's files as they were allowed to see them
+	if job.CreatedBy != user.ID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	path, result, err := h.jobs.FilePath(job)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Job has no file to download",
+		})
+	}
+	if _, err := os.Stat(path); err != nil {
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{
+			"error": "Job file has expired",
+		})
+	}
+
+	return c.Download(path, result.Filename)
+}
+
+// jobQueueError maps an Enqueue error to an HTTP response
+func jobQueueError(c *fiber.Ctx, err error) error {
+	if err == services.ErrJobQueueFull {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Too many background jobs queued, please try again later",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to start background job",
+	})
+}
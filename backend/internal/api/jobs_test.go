@@ -0,0 +1,90 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestFolderScanJob(t *testing.T) {
+	db := newTestDB(t)
+	folderService := services.NewFolderService(db.DB)
+	jobs := services.NewJobQueue(db.DB, nil)
+	jobs.Start(1)
+	folders := NewFolderHandler(folderService, services.NewFileScanner(db, folderService, t.TempDir(), nil), jobs)
+	jobHandler := NewJobHandler(jobs)
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	otherAdmin := seedUser(t, db.DB, "other", "admin")
+	user := seedUser(t, db.DB, "user", "user")
+	root := t.TempDir()
+	folderID := seedFolder(t, db.DB, root, admin.ID)
+	writeTestImage(t, filepath.Join(root, "a.png"), 8, 8)
+	writeTestImage(t, filepath.Join(root, "b.png"), 8, 8)
+
+	app := fiber.New()
+	app.Post("/api/folders/:id/scan", asUser(admin), folders.ScanFolder)
+
+	if status, _ := doRequest(t, app, http.MethodPost, "/api/folders/9999/scan", "", nil); status != fiber.StatusNotFound {
+		t.Errorf("missing folder: status %d, want 404", status)
+	}
+
+	status, resp := doRequest(t, app, http.MethodPost, fmt.Sprintf("/api/folders/%d/scan", folderID), "", nil)
+	if status != fiber.StatusAccepted {
+		t.Fatalf("scan: status %d (%v)", status, resp)
+	}
+	jobID := int64(resp["job_id"].(float64))
+
+	getJob := func(u *models.User, id int64) (int, map[string]interface{}) {
+		poll := fiber.New()
+		poll.Get("/api/jobs/:id", asUser(u), jobHandler.GetJob)
+		return doRequest(t, poll, http.MethodGet, fmt.Sprintf("/api/jobs/%d", id), "", nil)
+	}
+
+	// Poll until the scan finishes
+	var job map[string]interface{}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		status, resp := getJob(admin, jobID)
+		if status != fiber.StatusOK {
+			t.Fatalf("poll: status %d (%v)", status, resp)
+		}
+		job = resp["job"].(map[string]interface{})
+		if job["status"] == services.JobStatusDone || job["status"] == services.JobStatusFailed || time.Now().After(deadline) {
+			break
+		}
+	}
+	if job["status"] != services.JobStatusDone || job["progress"] != float64(100) || job["type"] != services.JobTypeFolderScan {
+		t.Fatalf("finished job: %v", job)
+	}
+	if result := job["result"].(map[string]interface{}); result["folders_scanned"] != float64(1) {
+		t.Errorf("job result %v", result)
+	}
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM file_folder_mappings WHERE folder_id = ?", folderID).Scan(&count)
+	if count != 2 {
+		t.Errorf("scan indexed %d files, want 2", count)
+	}
+
+	cases := []struct {
+		name string
+		user *models.User
+		id   int64
+		want int
+	}{
+		{"another admin", otherAdmin, jobID, fiber.StatusOK},
+		{"regular user", user, jobID, fiber.StatusForbidden},
+		{"missing job", admin, 9999, fiber.StatusNotFound},
+	}
+	for _, tc := range cases {
+		if status, resp := getJob(tc.user, tc.id); status != tc.want {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.want, resp)
+		}
+	}
+}
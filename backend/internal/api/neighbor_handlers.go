@@ -0,0 +1,187 @@
+package api
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/services"
+)
+
+// neighborSortColumns qualifies the album sort columns for queries over files and photo_metadata
+var neighborSortColumns = map[string]string{
+	"taken_at":   "pm.taken_at",
+	"filename":   "f.filename",
+	"size":       "f.size",
+	"width":      "pm.width",
+	"height":     "pm.height",
+	"created_at": "f.created_at",
+	"updated_at": "f.updated_at",
+}
+
+// neighborOrderSQL turns a sort order (album sort syntax, default taken_at DESC) into an
+// ORDER BY clause with the file ID as tiebreaker, so neighbors are well defined
+func neighborOrderSQL(sortOrder string) (string, error) {
+	if sortOrder == "" {
+		sortOrder = "taken_at desc"
+	}
+	normalized, err := services.NormalizeAlbumSort(sortOrder)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(normalized)
+	return neighborSortColumns[fields[0]] + " " + fields[1] + ", f.id " + fields[1], nil
+}
+
+// GetFileNeighbors returns the previous and next file around a file in a viewing context:
+// the timeline, a folder or an album. Used by the viewer for prev/next navigation.
+// GET /api/files/:id/neighbors?context=timeline|folder:ID|album:ID&sort=taken_at+desc
+func (h *Handler) GetFileNeighbors(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	fileID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid file ID"})
+	}
+
+	viewContext := c.Query("context", "timeline")
+	kind, param, _ := strings.Cut(viewContext, ":")
+
+	var prevID, nextID sql.NullInt64
+	switch kind {
+	case "timeline", "folder":
+		orderBy, err := neighborOrderSQL(c.Query("sort"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid sort order"})
+		}
+
+		isServerOwner := user.Role == "server_owner"
+		where := "WHERE 1=1"
+		args := []interface{}{}
+		if kind == "timeline" {
+			// Same set as the timeline: dated files the user can see
+			where += " AND pm.taken_at IS NOT NULL"
+			if c.Query("exclude_screenshots") == "true" {
+				where += " AND COALESCE(pm.is_screenshot, 0) = 0"
+			}
+			if !isServerOwner {
+				where += ` AND f.id IN (
+					SELECT ffm.file_id FROM file_folder_mappings ffm
+					JOIN permission_group_folders pgf ON ffm.folder_id = pgf.folder_id
+					JOIN permission_group_permissions pgp ON pgf.permission_group_id = pgp.permission_group_id
+					WHERE pgp.user_id = ?)`
+				args = append(args, user.ID)
+			}
+		} else {
+			folderID, err := strconv.ParseInt(param, 10, 64)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "Invalid folder ID"})
+			}
+			hasAccess, err := h.permService.CheckFolderAccess(user.ID, folderID, isServerOwner)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+			if !hasAccess {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied"})
+			}
+			where += " AND f.id IN (SELECT file_id FROM file_folder_mappings WHERE folder_id = ?)"
+			args = append(args, folderID)
+		}
+
+		ctx, cancel := queryContext(c)
+		defer cancel()
+
+		err = h.db.QueryRowContext(ctx, `
+			SELECT prev_id, next_id FROM (
+				SELECT f.id, LAG(f.id) OVER w AS prev_id, LEAD(f.id) OVER w AS next_id
+				FROM files f
+				LEFT JOIN photo_metadata pm ON f.id = pm.file_id
+				`+where+`
+				WINDOW w AS (ORDER BY `+orderBy+`)
+			) WHERE id = ?
+		`, append(args, fileID)...).Scan(&prevID, &nextID)
+		if err == sql.ErrNoRows {
+			return c.Status(404).JSON(fiber.Map{"error": "File not found in this context"})
+		}
+		if err != nil {
+			if isQueryTimeout(err) {
+				return queryTimeoutError(c)
+			}
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+
+	case "album":
+		albumID, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid album ID"})
+		}
+		album, err := h.albumService.GetAlbum(albumID)
+		if err != nil {
+			if err == services.ErrAlbumNotFound {
+				return c.Status(404).JSON(fiber.Map{"error": "Album not found"})
+			}
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch album"})
+		}
+		if !canAccessAlbum(h.albumService, user, album, false) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Access denied"})
+		}
+
+		ctx, cancel := queryContext(c)
+		defer cancel()
+
+		// Same ordering as the album item list
+		files, err := h.albumService.ListItemsWithFiles(ctx, albumID, c.Query("sort", album.DefaultSort), c.Query("type"),
+			albumViewerID(user, album))
+		if err != nil {
+			if err == services.ErrInvalidAlbumSort {
+				return c.Status(400).JSON(fiber.Map{"error": "Invalid sort order"})
+			}
+			if isQueryTimeout(err) {
+				return queryTimeoutError(c)
+			}
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch album items"})
+		}
+
+		index := -1
+		for i, f := range files {
+			if f.ID == fileID {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return c.Status(404).JSON(fiber.Map{"error": "File not found in this context"})
+		}
+		if index > 0 {
+			prevID = sql.NullInt64{Int64: files[index-1].ID, Valid: true}
+		}
+		if index < len(files)-1 {
+			nextID = sql.NullInt64{Int64: files[index+1].ID, Valid: true}
+		}
+
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "context must be timeline, folder:ID or album:ID"})
+	}
+
+	response := fiber.Map{
+		"file_id":     fileID,
+		"context":     viewContext,
+		"previous_id": nil,
+		"next_id":     nil,
+	}
+	if prevID.Valid {
+		response["previous_id"] = prevID.Int64
+	}
+	if nextID.Valid {
+		response["next_id"] = nextID.Int64
+	}
+	return c.JSON(response)
+}
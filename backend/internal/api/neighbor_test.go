@@ -0,0 +1,104 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestGetFileNeighbors(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	viewer := seedUser(t, db.DB, "viewer", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	private := seedFolder(t, db.DB, "/private", owner.ID)
+	grantFolder(t, db.DB, viewer.ID, folder, "read")
+
+	seed := func(folderID int64, name, takenAt string) int64 {
+		id := seedFile(t, db.DB, folderID, name, "image")
+		if takenAt != "" {
+			mustExec(t, db.DB, "INSERT INTO photo_metadata (file_id, taken_at) VALUES (?, ?)", id, takenAt)
+		}
+		return id
+	}
+	jan := seed(folder, "c.jpg", "2024-01-01 10:00:00")
+	feb := seed(folder, "a.jpg", "2024-02-01 10:00:00")
+	hidden := seed(private, "hidden.jpg", "2024-02-15 10:00:00")
+	mar := seed(folder, "b.jpg", "2024-03-01 10:00:00")
+	undated := seed(folder, "d.jpg", "")
+
+	albumService := services.NewAlbumService(db.DB)
+	album, _ := albumService.CreateAlbum("Trip", "", owner.ID)
+	albumService.AddFolders(album.ID, []services.FolderConfig{{FolderID: folder}}, owner.ID, true)
+	privateAlbum, _ := albumService.CreateAlbum("Private", "", owner.ID)
+	if err := albumService.SetCollaborator(album.ID, viewer.ID, "read", owner.ID); err != nil {
+		t.Fatalf("share album: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		user     *models.User
+		file     int64
+		query    string
+		wantPrev int64 // 0 = none
+		wantNext int64
+	}{
+		{"timeline newest first", viewer, feb, "", mar, jan},
+		{"timeline first", viewer, mar, "context=timeline", 0, feb},
+		{"timeline last", viewer, jan, "context=timeline", feb, 0},
+		{"timeline oldest first", viewer, feb, "sort=" + url.QueryEscape("taken_at asc"), jan, mar},
+		{"timeline includes private folders for the owner", owner, feb, "", hidden, jan},
+		{"folder by filename", viewer, mar, fmt.Sprintf("context=folder:%d&sort=filename+asc", folder), feb, jan},
+		{"folder includes undated files", viewer, jan, fmt.Sprintf("context=folder:%d&sort=filename+asc", folder), mar, undated},
+		{"album default order", viewer, feb, fmt.Sprintf("context=album:%d", album.ID), mar, jan},
+		{"album by filename", viewer, feb, fmt.Sprintf("context=album:%d&sort=filename+asc", album.ID), 0, mar},
+	}
+	for _, tc := range cases {
+		app := fiber.New()
+		app.Get("/api/files/:id/neighbors", asUser(tc.user), h.GetFileNeighbors)
+		status, body := doRequest(t, app, http.MethodGet, fmt.Sprintf("/api/files/%d/neighbors?%s", tc.file, tc.query), "", nil)
+		if status != fiber.StatusOK {
+			t.Errorf("%s: status %d, body %v", tc.name, status, body)
+			continue
+		}
+		for key, want := range map[string]int64{"previous_id": tc.wantPrev, "next_id": tc.wantNext} {
+			var wantValue interface{}
+			if want != 0 {
+				wantValue = float64(want)
+			}
+			if body[key] != wantValue {
+				t.Errorf("%s: %s = %v, want %v", tc.name, key, body[key], wantValue)
+			}
+		}
+	}
+
+	errorCases := []struct {
+		name  string
+		file  int64
+		query string
+		want  int
+	}{
+		{"unknown context", feb, "context=library", fiber.StatusBadRequest},
+		{"invalid sort", feb, "sort=" + url.QueryEscape("random()"), fiber.StatusBadRequest},
+		{"undated file on the timeline", undated, "", fiber.StatusNotFound},
+		{"file of another folder", hidden, fmt.Sprintf("context=folder:%d", folder), fiber.StatusNotFound},
+		{"private folder", hidden, fmt.Sprintf("context=folder:%d", private), fiber.StatusForbidden},
+		{"private album", feb, fmt.Sprintf("context=album:%d", privateAlbum.ID), fiber.StatusForbidden},
+		{"unknown album", feb, "context=album:9999", fiber.StatusNotFound},
+		{"invalid album", feb, "context=album:abc", fiber.StatusBadRequest},
+	}
+	app := fiber.New()
+	app.Get("/api/files/:id/neighbors", asUser(viewer), h.GetFileNeighbors)
+	for _, tc := range errorCases {
+		if status, body := doRequest(t, app, http.MethodGet, fmt.Sprintf("/api/files/%d/neighbors?%s", tc.file, tc.query), "", nil); status != tc.want {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.want, body)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/services"
+)
+
+func TestChangePasswordVerifiesOldPasswordAndEndsOtherSessions(t *testing.T) {
+	db := newTestDB(t)
+	authService := services.NewAuthService(db.DB)
+	h := NewAuthHandler(authService, services.NewSettingsService(db.DB), nil)
+
+	user, err := authService.CreateUser("alice", "old password", "", "user")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	var sessions []string
+	for i := 0; i < 3; i++ {
+		session, err := authService.CreateSession(user.ID, time.Hour)
+		if err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+		sessions = append(sessions, session.ID)
+	}
+
+	app := fiber.New()
+	app.Post("/api/auth/change-password", middleware.AuthMiddleware(authService), h.ChangePassword)
+	auth := map[string]string{"Authorization": "Bearer " + sessions[0]}
+
+	status, _ := doRequest(t, app, "POST", "/api/auth/change-password", `{"old_password":"wrong","new_password":"new password"}`, auth)
+	if status != fiber.StatusUnauthorized {
+		t.Errorf("wrong old password: status %d, want 401", status)
+	}
+	if status, _ := doRequest(t, app, "POST", "/api/auth/change-password", `{"new_password":"new password"}`, auth); status != fiber.StatusBadRequest {
+		t.Errorf("missing old password: status %d, want 400", status)
+	}
+
+	status, body := doRequest(t, app, "POST", "/api/auth/change-password", `{"old_password":"old password","new_password":"new password"}`, auth)
+	if status != fiber.StatusOK || body["sessions_deleted"] != float64(2) {
+		t.Fatalf("change: status %d body %v, want 2 sessions deleted", status, body)
+	}
+
+	// The session that changed the password stays, the others are gone
+	if _, err := authService.GetValidSession(sessions[0]); err != nil {
+		t.Errorf("current session ended: %v", err)
+	}
+	for _, id := range sessions[1:] {
+		if _, err := authService.GetValidSession(id); err == nil {
+			t.Errorf("other session %s still valid", id)
+		}
+	}
+
+	if err := authService.VerifyUserPassword(user.ID, "new password"); err != nil {
+		t.Errorf("new password rejected: %v", err)
+	}
+	if err := authService.VerifyUserPassword(user.ID, "old password"); err != services.ErrInvalidCredentials {
+		t.Errorf("old password: got %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestResetPasswordEndsSessions(t *testing.T) {
+	db := newTestDB(t)
+	authService := services.NewAuthService(db.DB)
+	h := NewUserHandler(authService)
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	user, err := authService.CreateUser("alice", "old password", "", "user")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	session, err := authService.CreateSession(user.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	app := fiber.New()
+	app.Post("/api/users/:id/reset-password", asUser(admin), h.ResetPassword)
+	status, _ := doRequest(t, app, "POST", "/api/users/"+strconv.FormatInt(user.ID, 10)+"/reset-password", `{"new_password":"new password"}`, nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("reset: status %d", status)
+	}
+	if _, err := authService.GetValidSession(session.ID); err == nil {
+		t.Errorf("session survived a password reset")
+	}
+}
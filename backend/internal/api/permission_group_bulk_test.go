@@ -0,0 +1,70 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"awesome-sharing/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestBulkPermissionHandlers(t *testing.T) {
+	db := newTestDB(t)
+	h := NewPermissionGroupHandler(services.NewPermissionGroupService(db.DB))
+	admin := seedUser(t, db.DB, "admin", "admin")
+	alice := seedUser(t, db.DB, "alice", "user")
+	bob := seedUser(t, db.DB, "bob", "user")
+	res := mustExec(t, db.DB, "INSERT INTO permission_groups (name, created_by) VALUES ('family', ?)", admin.ID)
+	groupID, _ := res.LastInsertId()
+	path := fmt.Sprintf("/api/permission-groups/%d/permissions/bulk", groupID)
+
+	app := fiber.New()
+	app.Post("/api/permission-groups/:id/permissions/bulk", asUser(admin), h.BulkGrantPermission)
+	app.Delete("/api/permission-groups/:id/permissions/bulk", asUser(admin), h.BulkRevokePermission)
+
+	status, body := doRequest(t, app, http.MethodPost, path,
+		fmt.Sprintf(`{"user_ids":[%d,%d,424242],"permission":"read"}`, alice.ID, bob.ID), nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("grant: status %d, body %v", status, body)
+	}
+	if body["succeeded"] != float64(2) || body["failed"] != float64(1) {
+		t.Errorf("grant: succeeded %v, failed %v", body["succeeded"], body["failed"])
+	}
+	results := body["results"].([]interface{})
+	if last := results[2].(map[string]interface{}); last["user_id"] != float64(424242) || last["error"] == nil {
+		t.Errorf("unknown user result = %v", last)
+	}
+
+	status, body = doRequest(t, app, http.MethodDelete, path, fmt.Sprintf(`{"user_ids":[%d]}`, bob.ID), nil)
+	if status != fiber.StatusOK || body["succeeded"] != float64(1) {
+		t.Errorf("revoke: status %d, body %v", status, body)
+	}
+
+	ids := make([]string, 501)
+	for i := range ids {
+		ids[i] = "1"
+	}
+	cases := []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{"no users", http.MethodPost, path, `{"user_ids":[],"permission":"read"}`, fiber.StatusBadRequest},
+		{"bad permission", http.MethodPost, path, fmt.Sprintf(`{"user_ids":[%d],"permission":"admin"}`, alice.ID), fiber.StatusBadRequest},
+		{"too many users", http.MethodPost, path, `{"user_ids":[` + strings.Join(ids, ",") + `],"permission":"read"}`, fiber.StatusBadRequest},
+		{"unknown group", http.MethodPost, "/api/permission-groups/9999/permissions/bulk", fmt.Sprintf(`{"user_ids":[%d],"permission":"read"}`, alice.ID), fiber.StatusNotFound},
+	}
+	for _, tc := range cases {
+		if status, body := doRequest(t, app, tc.method, tc.path, tc.body, nil); status != tc.want {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.want, body)
+		}
+	}
+
+	app = fiber.New()
+	app.Post("/api/permission-groups/:id/permissions/bulk", asUser(alice), h.BulkGrantPermission)
+	if status, _ := doRequest(t, app, http.MethodPost, path, fmt.Sprintf(`{"user_ids":[%d],"permission":"write"}`, alice.ID), nil); status != fiber.StatusForbidden {
+		t.Errorf("non-admin: status %d, want 403", status)
+	}
+}
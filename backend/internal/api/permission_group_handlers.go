@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -452,6 +453,102 @@ func (h *PermissionGroupHandler) RevokePermission(c *fiber.Ctx) error {
 	})
 }
 
+// maxBulkPermissionUsers limits the number of users changed in one bulk request
+const maxBulkPermissionUsers = 500
+
+// BulkGrantPermission grants a permission on a group to several users at once
+// POST /api/permission-groups/:id/permissions/bulk
+func (h *PermissionGroupHandler) BulkGrantPermission(c *fiber.Ctx) error {
+	return h.bulkPermissions(c, true)
+}
+
+// BulkRevokePermission revokes several users' permissions on a group at once
+// DELETE /api/permission-groups/:id/permissions/bulk
+func (h *PermissionGroupHandler) BulkRevokePermission(c *fiber.Ctx) error {
+	return h.bulkPermissions(c, false)
+}
+
+// bulkPermissions handles bulk grant and revoke requests
+func (h *PermissionGroupHandler) bulkPermissions(c *fiber.Ctx, grant bool) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	// Only admins can modify permissions
+	if user.Role != "admin" && user.Role != "server_owner" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Admin privileges required",
+		})
+	}
+
+	groupID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid permission group ID",
+		})
+	}
+
+	var req struct {
+		UserIDs    []int64 `json:"user_ids"`
+		Permission string  `json:"permission"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.UserIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "No user IDs provided",
+		})
+	}
+	if len(req.UserIDs) > maxBulkPermissionUsers {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Too many users, maximum is %d", maxBulkPermissionUsers),
+		})
+	}
+
+	var results []services.BulkPermissionResult
+	if grant {
+		if req.Permission != "read" && req.Permission != "write" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Permission must be 'read' or 'write'",
+			})
+		}
+		results, err = h.permissionGroupService.BulkGrantPermission(groupID, req.UserIDs, req.Permission, user.ID, c.IP())
+	} else {
+		results, err = h.permissionGroupService.BulkRevokePermission(groupID, req.UserIDs, user.ID, c.IP())
+	}
+	if err != nil {
+		if err == services.ErrPermissionGroupNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Permission group not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update permissions",
+		})
+	}
+
+	succeeded := 0
+	for _, result := range results {
+		if result.Success {
+			succeeded++
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"results":   results,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	})
+}
+
 // ListPermissions lists all permissions for a permission group
 // GET /api/permission-groups/:id/permissions
 func (h *PermissionGroupHandler) ListPermissions(c *fiber.Ctx) error {
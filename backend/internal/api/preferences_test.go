@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestPreferencesEndpoints(t *testing.T) {
+	db := newTestDB(t)
+	h := NewAuthHandler(services.NewAuthService(db.DB), services.NewSettingsService(db.DB), services.NewUserPreferenceService(db.DB))
+
+	alice := seedUser(t, db.DB, "alice", "user")
+	bob := seedUser(t, db.DB, "bob", "user")
+
+	appAs := func(u *models.User) *fiber.App {
+		app := fiber.New()
+		app.Get("/api/auth/preferences", asUser(u), h.GetPreferences)
+		app.Put("/api/auth/preferences", asUser(u), h.UpdatePreferences)
+		return app
+	}
+
+	status, resp := doRequest(t, appAs(alice), http.MethodPut, "/api/auth/preferences", `{"theme":"dark","grid_size":"small"}`, nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("put: status %d body %v", status, resp)
+	}
+	status, resp = doRequest(t, appAs(alice), http.MethodPut, "/api/auth/preferences", `{"grid_size":null,"view_mode":"list"}`, nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("merge: status %d body %v", status, resp)
+	}
+	prefs := resp["preferences"].(map[string]interface{})
+	if len(prefs) != 2 || prefs["theme"] != "dark" || prefs["view_mode"] != "list" {
+		t.Errorf("merged preferences: %v", prefs)
+	}
+
+	// Preferences are per user
+	status, resp = doRequest(t, appAs(bob), http.MethodGet, "/api/auth/preferences", "", nil)
+	if status != fiber.StatusOK || len(resp["preferences"].(map[string]interface{})) != 0 {
+		t.Errorf("bob: status %d body %v", status, resp)
+	}
+	status, resp = doRequest(t, appAs(alice), http.MethodGet, "/api/auth/preferences", "", nil)
+	if status != fiber.StatusOK || resp["preferences"].(map[string]interface{})["theme"] != "dark" {
+		t.Errorf("alice: status %d body %v", status, resp)
+	}
+
+	cases := []struct {
+		name   string
+		user   *models.User
+		method string
+		body   string
+		want   int
+	}{
+		{"unknown key", alice, http.MethodPut, `{"role":"admin"}`, fiber.StatusBadRequest},
+		{"not an object", alice, http.MethodPut, `["theme"]`, fiber.StatusBadRequest},
+		{"anonymous get", nil, http.MethodGet, "", fiber.StatusUnauthorized},
+		{"anonymous put", nil, http.MethodPut, `{"theme":"dark"}`, fiber.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		if status, _ := doRequest(t, appAs(tc.user), tc.method, "/api/auth/preferences", tc.body, nil); status != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, status, tc.want)
+		}
+	}
+}
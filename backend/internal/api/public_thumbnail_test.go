@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+	"image"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGetPublicThumbnail(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	owner := seedUser(t, db.DB, "owner", "user")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	writeTestImage(t, filepath.Join(root, "shared.png"), 800, 600)
+	writeTestImage(t, filepath.Join(root, "private.png"), 80, 60)
+	shared := seedFile(t, db.DB, folder, "shared.png", "image")
+	private := seedFile(t, db.DB, folder, "private.png", "image")
+
+	share, err := h.shareService.CreateShare("file", shared, owner.ID, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	token, err := h.shareService.GenerateAccessToken(share.ID)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/api/public/files/:id/thumbnail", h.GetPublicThumbnail)
+	thumbPath := func(fileID int64, query string) string {
+		return fmt.Sprintf("/api/public/files/%d/thumbnail?%s", fileID, query)
+	}
+	tokenQuery := "token=" + url.QueryEscape(token)
+
+	resp := sendRequest(t, app, http.MethodGet, thumbPath(shared, tokenQuery+"&size=small"), "", nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("valid token: status %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get(fiber.HeaderCacheControl); got != "private, max-age=300" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+	img, _, err := image.Decode(resp.Body)
+	if err != nil {
+		t.Fatalf("decode thumbnail: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X > 300 || size.Y > 300 {
+		t.Errorf("served %v, want a small thumbnail rather than the original", size)
+	}
+
+	cases := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"no token", thumbPath(shared, ""), fiber.StatusUnauthorized},
+		{"invalid token", thumbPath(shared, "token=share:1:nonce:bad"), fiber.StatusForbidden},
+		{"other file", thumbPath(private, tokenQuery), fiber.StatusForbidden},
+		{"invalid size", thumbPath(shared, tokenQuery+"&size=huge"), fiber.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		if status, body := doRequest(t, app, http.MethodGet, tc.path, "", nil); status != tc.want {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.want, body)
+		}
+	}
+}
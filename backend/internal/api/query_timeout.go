@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// queryTimeout bounds the database queries issued while serving a single request
+var queryTimeout = 30 * time.Second
+
+// SetQueryTimeout configures the per-request database query timeout (0 disables it)
+func SetQueryTimeout(timeout time.Duration) {
+	queryTimeout = timeout
+}
+
+// queryContext returns a context for request-scoped queries, cancelled after queryTimeout
+func queryContext(c *fiber.Ctx) (context.Context, context.CancelFunc) {
+	if queryTimeout <= 0 {
+		return context.WithCancel(c.UserContext())
+	}
+	return context.WithTimeout(c.UserContext(), queryTimeout)
+}
+
+// isQueryTimeout reports whether err was caused by the request's query deadline
+func isQueryTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// queryTimeoutError responds with 503 when a request's queries took too long
+func queryTimeoutError(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+		"error": "Query timed out, please try again later",
+	})
+}
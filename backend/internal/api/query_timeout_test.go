@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestQueryTimeoutResponse(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	folderID := seedFolder(t, db.DB, t.TempDir(), owner.ID)
+	seedFile(t, db.DB, folderID, "a.jpg", "image")
+
+	app := fiber.New()
+	app.Get("/api/files", asUser(owner), h.GetFiles)
+	app.Post("/api/cleanup", asUser(owner), h.CleanupDeletedFiles)
+
+	defer SetQueryTimeout(queryTimeout)
+	cases := []struct {
+		name    string
+		timeout time.Duration
+		want    int
+	}{
+		{"deadline passes before the query runs", time.Nanosecond, fiber.StatusServiceUnavailable},
+		{"generous timeout", time.Minute, fiber.StatusOK},
+		{"timeout disabled", 0, fiber.StatusOK},
+	}
+	for _, tc := range cases {
+		SetQueryTimeout(tc.timeout)
+		if status, resp := doRequest(t, app, http.MethodGet, "/api/files", "", nil); status != tc.want {
+			t.Errorf("%s: list files status %d, want %d (%v)", tc.name, status, tc.want, resp)
+		}
+		// Cleanup walks the whole library; VALIDATION_TIMEOUT_MINUTES bounds it instead
+		if status, resp := doRequest(t, app, http.MethodPost, "/api/cleanup", "", nil); status != fiber.StatusOK {
+			t.Errorf("%s: cleanup status %d, want 200 (%v)", tc.name, status, resp)
+		}
+	}
+}
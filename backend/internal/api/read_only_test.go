@@ -0,0 +1,60 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	db := newTestDB(t)
+	settings := services.NewSettingsService(db.DB)
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) }
+
+	request := func(user *models.User, method string) int {
+		app := fiber.New()
+		app.Add(method, "/api/files", asUser(user), middleware.ReadOnlyMiddleware(settings), ok)
+		status, _ := doRequest(t, app, method, "/api/files", "", nil)
+		return status
+	}
+	admin := &models.User{ID: 1, Role: "admin"}
+	owner := &models.User{ID: 2, Role: "server_owner"}
+
+	// Off by default
+	if status := request(admin, "POST"); status != fiber.StatusNoContent {
+		t.Errorf("read-only off: status %d, want 204", status)
+	}
+
+	if err := settings.SetSetting("read_only", "true"); err != nil {
+		t.Fatalf("enable read-only: %v", err)
+	}
+	cases := []struct {
+		name   string
+		user   *models.User
+		method string
+		want   int
+	}{
+		{"admin reads", admin, "GET", fiber.StatusNoContent},
+		{"admin writes", admin, "POST", fiber.StatusServiceUnavailable},
+		{"admin deletes", admin, "DELETE", fiber.StatusServiceUnavailable},
+		{"anonymous registers", nil, "POST", fiber.StatusServiceUnavailable},
+		{"server owner writes", owner, "PUT", fiber.StatusNoContent},
+	}
+	for _, tc := range cases {
+		if status := request(tc.user, tc.method); status != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, status, tc.want)
+		}
+	}
+
+	// The public settings tell clients to hide editing controls
+	h := NewSettingsHandler(settings, services.NewPermissionGroupService(db.DB))
+	app := fiber.New()
+	app.Get("/api/settings/public", h.GetPublicSettings)
+	if _, body := doRequest(t, app, "GET", "/api/settings/public", "", nil); body["read_only"] != true {
+		t.Errorf("public settings: read_only = %v, want true", body["read_only"])
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"database/sql"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/models"
+)
+
+// indexTimeFormat matches the CURRENT_TIMESTAMP format of files.created_at
+const indexTimeFormat = "2006-01-02 15:04:05"
+
+// GetRecentFiles returns files indexed after a point in time, newest first.
+// Unlike the timeline this orders by index time (files.created_at), not capture date.
+// GET /api/files/recent?since=2024-05-01T10:00:00Z&page=1&limit=50
+func (h *Handler) GetRecentFiles(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	since := c.Query("since")
+	if since == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "since is required"})
+	}
+	sinceTime, err := parseAbsoluteDate(since)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid since, expected RFC3339 or YYYY-MM-DD"})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	offset := (page - 1) * limit
+
+	var query string
+	args := []interface{}{}
+
+	if user.Role == "server_owner" {
+		query = `SELECT f.id, f.filename, f.file_type, f.size, f.created_at, f.updated_at,
+		                pm.width, pm.height, pm.taken_at
+		         FROM files f
+		         LEFT JOIN photo_metadata pm ON f.id = pm.file_id
+		         WHERE f.created_at > ?`
+		args = append(args, sinceTime.UTC().Format(indexTimeFormat))
+	} else {
+		query = `SELECT DISTINCT f.id, f.filename, f.file_type, f.size, f.created_at, f.updated_at,
+		                pm.width, pm.height, pm.taken_at
+		         FROM files f
+		         LEFT JOIN photo_metadata pm ON f.id = pm.file_id
+		         JOIN file_folder_mappings ffm ON f.id = ffm.file_id
+		         JOIN permission_group_folders pgf ON ffm.folder_id = pgf.folder_id
+		         JOIN permission_group_permissions pgp ON pgf.permission_group_id = pgp.permission_group_id
+		         WHERE pgp.user_id = ? AND f.created_at > ?`
+		args = append(args, user.ID, sinceTime.UTC().Format(indexTimeFormat))
+	}
+
+	query += " ORDER BY f.created_at DESC, f.id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		if isQueryTimeout(err) {
+			return queryTimeoutError(c)
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	defer rows.Close()
+
+	files := []models.File{}
+	for rows.Next() {
+		var f models.File
+		var width, height sql.NullInt32
+		var takenAt sql.NullTime
+		if err := rows.Scan(&f.ID, &f.Filename, &f.FileType, &f.Size, &f.CreatedAt, &f.UpdatedAt,
+			&width, &height, &takenAt); err != nil {
+			log.Printf("Error scanning file: %v", err)
+			continue
+		}
+		if width.Valid {
+			f.Width = int(width.Int32)
+		}
+		if height.Valid {
+			f.Height = int(height.Int32)
+		}
+		if takenAt.Valid {
+			f.TakenAt = &takenAt.Time
+		}
+		setThumbnailURLs(&f)
+		files = append(files, f)
+	}
+	if err := rows.Err(); err != nil && isQueryTimeout(err) {
+		return queryTimeoutError(c)
+	}
+
+	// Validate files and filter out deleted ones, also resolves absolute_path
+	files = h.validator.ValidateFiles(files)
+
+	return c.JSON(fiber.Map{
+		"files": files,
+		"since": sinceTime,
+		"page":  page,
+		"limit": limit,
+	})
+}
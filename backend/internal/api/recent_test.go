@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+)
+
+func TestGetRecentFiles(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	viewer := seedUser(t, db.DB, "viewer", "user")
+	root := t.TempDir()
+	private := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	privateFolder := seedFolder(t, db.DB, private, owner.ID)
+	grantFolder(t, db.DB, viewer.ID, folder, "read")
+
+	seed := func(folderID int64, dir, name, indexedAt, takenAt string) {
+		os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644)
+		id := seedFile(t, db.DB, folderID, name, "image")
+		mustExec(t, db.DB, "UPDATE files SET created_at = ? WHERE id = ?", indexedAt, id)
+		mustExec(t, db.DB, "INSERT INTO photo_metadata (file_id, taken_at) VALUES (?, ?)", id, takenAt)
+	}
+	seed(folder, root, "old.jpg", "2024-04-30 09:00:00", "2024-04-29 09:00:00")
+	// Captured long ago but indexed recently: the feed goes by index time
+	seed(folder, root, "scanned.jpg", "2024-05-02 08:00:00", "1998-06-01 12:00:00")
+	seed(folder, root, "new.jpg", "2024-05-01 12:00:00", "2024-05-01 11:00:00")
+	seed(privateFolder, private, "private.jpg", "2024-05-03 08:00:00", "2024-05-03 07:00:00")
+
+	names := func(u *models.User, query string) []string {
+		t.Helper()
+		app := fiber.New()
+		app.Get("/api/files/recent", asUser(u), h.GetRecentFiles)
+		status, body := doRequest(t, app, http.MethodGet, "/api/files/recent?"+query, "", nil)
+		if status != fiber.StatusOK {
+			t.Fatalf("%s: status %d, body %v", query, status, body)
+		}
+		var got []string
+		for _, f := range body["files"].([]interface{}) {
+			got = append(got, f.(map[string]interface{})["filename"].(string))
+		}
+		return got
+	}
+
+	cases := []struct {
+		user  *models.User
+		query string
+		want  string
+	}{
+		{owner, "since=2024-05-01T10:00:00Z", "[private.jpg scanned.jpg new.jpg]"},
+		{viewer, "since=2024-05-01T10:00:00Z", "[scanned.jpg new.jpg]"},
+		{viewer, "since=2024-05-01", "[scanned.jpg new.jpg]"},
+		{viewer, "since=2024-05-01T12:00:00Z", "[scanned.jpg]"},
+		{viewer, "since=2024-04-01&limit=2", "[scanned.jpg new.jpg]"},
+		{viewer, "since=2024-04-01&limit=2&page=2", "[old.jpg]"},
+	}
+	for _, tc := range cases {
+		if got := fmt.Sprint(names(tc.user, tc.query)); got != tc.want {
+			t.Errorf("%s as %s: %s, want %s", tc.query, tc.user.Username, got, tc.want)
+		}
+	}
+
+	app := fiber.New()
+	app.Get("/api/files/recent", asUser(viewer), h.GetRecentFiles)
+	for _, query := range []string{"", "since=yesterday"} {
+		if status, _ := doRequest(t, app, http.MethodGet, "/api/files/recent?"+query, "", nil); status != fiber.StatusBadRequest {
+			t.Errorf("%q: status %d, want 400", query, status)
+		}
+	}
+}
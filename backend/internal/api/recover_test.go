@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+
+	"awesome-sharing/internal/middleware"
+)
+
+func TestRecoverMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(requestid.New())
+	app.Use(middleware.RecoverMiddleware())
+	app.Get("/panic", func(c *fiber.Ctx) error {
+		panic("secret internals")
+	})
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+	app.Get("/ok", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"ok": true})
+	})
+
+	resp := sendRequest(t, app, http.MethodGet, "/panic", "", nil)
+	defer resp.Body.Close()
+	if resp.StatusCode != fiber.StatusInternalServerError {
+		t.Fatalf("panic: status %d, want 500", resp.StatusCode)
+	}
+	if !strings.HasPrefix(resp.Header.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON) {
+		t.Errorf("panic: Content-Type %q, want JSON", resp.Header.Get(fiber.HeaderContentType))
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode panic response: %v", err)
+	}
+	requestID := resp.Header.Get(fiber.HeaderXRequestID)
+	if requestID == "" || body["request_id"] != requestID {
+		t.Errorf("request_id = %v, want header %q", body["request_id"], requestID)
+	}
+	if msg, _ := body["error"].(string); msg == "" || strings.Contains(msg, "secret") {
+		t.Errorf("error = %q, want a sanitized message", msg)
+	}
+
+	if status, _ := doRequest(t, app, http.MethodGet, "/missing", "", nil); status != fiber.StatusNotFound {
+		t.Errorf("returned error: status %d, want 404", status)
+	}
+	if status, resp := doRequest(t, app, http.MethodGet, "/ok", "", nil); status != fiber.StatusOK || resp["ok"] != true {
+		t.Errorf("normal handler: status %d (%v)", status, resp)
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestRegisterJoinsDefaultGroup(t *testing.T) {
+	db := newTestDB(t)
+	settings := services.NewSettingsService(db.DB)
+	auth := services.NewAuthService(db.DB)
+	auth.SetRegistrationDefaults(settings)
+	h := NewAuthHandler(auth, settings, services.NewUserPreferenceService(db.DB))
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	res := mustExec(t, db.DB, "INSERT INTO permission_groups (name, created_by) VALUES ('public', ?)", admin.ID)
+	group, _ := res.LastInsertId()
+	settings.SetSetting("allow_registration", "true")
+	settings.SetSetting("default_permission_group_id", strconv.FormatInt(group, 10))
+
+	register := func(u *models.User, body string) map[string]interface{} {
+		t.Helper()
+		app := fiber.New()
+		app.Post("/api/auth/register", asUser(u), h.Register)
+		status, resp := doRequest(t, app, http.MethodPost, "/api/auth/register", body, nil)
+		if status != fiber.StatusCreated {
+			t.Fatalf("register %s: status %d, body %v", body, status, resp)
+		}
+		return resp["user"].(map[string]interface{})
+	}
+	inGroup := func(userID float64) bool {
+		var n int
+		db.QueryRow("SELECT COUNT(*) FROM permission_group_permissions WHERE permission_group_id = ? AND user_id = ?", group, int64(userID)).Scan(&n)
+		return n == 1
+	}
+
+	// Anonymous visitors can't pick their role
+	newcomer := register(nil, `{"username":"newcomer","password":"secret123","role":"admin"}`)
+	if newcomer["role"] != "user" || !inGroup(newcomer["id"].(float64)) {
+		t.Errorf("self-registered user: role %v, in default group %v", newcomer["role"], inGroup(newcomer["id"].(float64)))
+	}
+
+	// Accounts an admin creates with an explicit role skip the defaults
+	colleague := register(admin, `{"username":"colleague","password":"secret123","role":"admin"}`)
+	if colleague["role"] != "admin" || inGroup(colleague["id"].(float64)) {
+		t.Errorf("admin-created user: role %v, in default group %v", colleague["role"], inGroup(colleague["id"].(float64)))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 
 	"awesome-sharing/internal/middleware"
 	"awesome-sharing/internal/services"
@@ -25,15 +26,22 @@ func SetupRoutesV2(
 	settingsHandler *SettingsHandler,
 	domainConfigHandler *DomainConfigHandlers,
 	uploadHandler *UploadHandler,
+	adminHandler *AdminHandler,
+	eventSubscriptionHandler *EventSubscriptionHandler,
+	jobHandler *JobHandler,
+	eventStreamHandler *EventStreamHandler,
+	fileCommentHandler *FileCommentHandler,
 	authService *services.AuthService,
 	allowedOrigin string,
 ) {
 	// Middleware
+	app.Use(requestid.New())
 	app.Use(logger.New())
+	app.Use(middleware.RecoverMiddleware())
 
 	// CORS configuration
 	corsConfig := cors.Config{
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders:     "Origin, Content-Type, Accept, " + middleware.SessionHeaderName() + ", " + middleware.CSRFHeaderName,
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
 		ExposeHeaders:    "Set-Cookie",
 	}
@@ -71,26 +79,57 @@ func SetupRoutesV2(
 		// Public file access (requires valid share token)
 		public.Get("/public/files/:id", shareHandler.GetPublicFile)
 		public.Get("/public/files/:id/download", shareHandler.DownloadPublicFile)
+		public.Get("/public/files/:id/thumbnail", handler.GetPublicThumbnail)
+
+		// Signed thumbnail URLs for embedding on external pages
+		public.Get("/public/thumbnails/:id", handler.GetSignedThumbnail)
 	}
 
+	// Read-only mode blocks mutating requests (server owner exempt)
+	readOnly := middleware.ReadOnlyMiddleware(settingsHandler.settingsService)
+
+	// Cookie-authenticated mutating requests must echo the CSRF token (Bearer clients exempt)
+	csrf := middleware.CSRFMiddleware()
+
 	// Auth routes (some require auth, some don't)
 	auth := api.Group("/auth")
 	{
 		auth.Post("/login", authHandler.Login)
-		auth.Post("/register", middleware.OptionalAuthMiddleware(authService), authHandler.Register)
-		auth.Post("/logout", middleware.AuthMiddleware(authService), authHandler.Logout)
-		auth.Get("/me", middleware.AuthMiddleware(authService), authHandler.Me)
-		auth.Post("/change-password", middleware.AuthMiddleware(authService), authHandler.ChangePassword)
+		auth.Get("/csrf", authHandler.GetCSRFToken)
+		auth.Post("/register", csrf, middleware.OptionalAuthMiddleware(authService), readOnly, authHandler.Register)
+		auth.Post("/logout", csrf, middleware.AuthMiddleware(authService), authHandler.Logout)
+		auth.Get("/me", csrf, middleware.AuthMiddleware(authService), authHandler.Me)
+		auth.Get("/limits", csrf, middleware.AuthMiddleware(authService), authHandler.GetLimits)
+		auth.Post("/change-password", csrf, middleware.AuthMiddleware(authService), readOnly, authHandler.ChangePassword)
+		auth.Post("/impersonation/end", csrf, middleware.AuthMiddleware(authService), authHandler.EndImpersonation)
+		auth.Get("/preferences", csrf, middleware.AuthMiddleware(authService), authHandler.GetPreferences)
+		auth.Put("/preferences", csrf, middleware.AuthMiddleware(authService), readOnly, authHandler.UpdatePreferences)
 	}
 
 	// Protected routes (require authentication)
-	protected := api.Group("", middleware.AuthMiddleware(authService))
+	protected := api.Group("", csrf, middleware.AuthMiddleware(authService), readOnly)
 	{
 		// Legacy file routes (keep for backwards compatibility)
 		protected.Get("/files", handler.GetFiles)
+		protected.Get("/files/travel", handler.GetTravel)
+		protected.Get("/files/facets", handler.GetFileFacets)
+		protected.Get("/files/recent", handler.GetRecentFiles)
 		protected.Get("/files/:id", handler.GetFileByID)
 		protected.Get("/files/:id/thumbnail", handler.GetFileThumbnail)
+		protected.Post("/files/:id/thumbnail/invalidate", handler.InvalidateThumbnail)
+		protected.Post("/files/:id/thumbnail-url", handler.CreateSignedThumbnailURL)
 		protected.Get("/files/:id/download", handler.DownloadFile)
+		protected.Get("/files/:id/raw", handler.GetFileRaw)
+		protected.Get("/files/:id/checksum", handler.GetFileChecksum)
+		protected.Get("/files/:id/stats", handler.GetFileStats)
+		protected.Get("/files/:id/similar", handler.GetSimilarFiles)
+		protected.Get("/files/:id/neighbors", handler.GetFileNeighbors)
+		protected.Get("/files/:id/comments", fileCommentHandler.ListFileComments)
+		protected.Post("/files/:id/comments", fileCommentHandler.AddFileComment)
+		protected.Delete("/files/:id/comments/:commentId", fileCommentHandler.DeleteFileComment)
+		protected.Post("/files/thumbnails/prefetch", handler.PrefetchThumbnails)
+		protected.Post("/files/bulk/shift-date", handler.BulkShiftFileDates)
+		protected.Post("/files/:id/shift-date", handler.ShiftFileDate)
 		protected.Get("/timeline", handler.GetTimeline)
 		protected.Get("/timeline/years", handler.GetTimelineYears)
 		protected.Get("/search", handler.SearchFiles)
@@ -98,6 +137,7 @@ func SetupRoutesV2(
 		protected.Post("/scan", handler.TriggerScan)
 		protected.Post("/cleanup", handler.CleanupDeletedFiles)
 		protected.Get("/tags", handler.GetTags)
+		protected.Get("/tags/counts", handler.GetTagCounts)
 		protected.Post("/tags", handler.CreateTag)
 
 		// Legacy album routes (keep for compatibility)
@@ -112,6 +152,7 @@ func SetupRoutesV2(
 			users.Get("/stats", userHandler.GetUserStats)
 			users.Post("", userHandler.CreateUser)
 			users.Post("/export", userHandler.ExportUsers)
+			users.Post("/import", userHandler.ImportUsers)
 			users.Post("/bulk/enable-disable", userHandler.BulkEnableDisable)
 			users.Post("/bulk/delete", userHandler.BulkDelete)
 			users.Get("/:id", userHandler.GetUser)
@@ -127,17 +168,25 @@ func SetupRoutesV2(
 		{
 			folders.Get("", folderHandler.ListFolders)
 			folders.Post("", middleware.AdminOnlyMiddleware(), folderHandler.CreateFolder)
+			folders.Post("/bulk", middleware.AdminOnlyMiddleware(), folderHandler.BulkCreateFolders)
 			folders.Post("/browse", middleware.AdminOnlyMiddleware(), folderHandler.BrowseDirectoryTree)
 			folders.Get("/:id", folderHandler.GetFolder)
 			folders.Put("/:id", middleware.AdminOnlyMiddleware(), folderHandler.UpdateFolder)
 			folders.Delete("/:id", middleware.AdminOnlyMiddleware(), folderHandler.DeleteFolder)
+			folders.Get("/:id/delete-preview", middleware.AdminOnlyMiddleware(), folderHandler.PreviewDeleteFolder)
 
 			// Folder operations
 			folders.Put("/:id/toggle", middleware.AdminOnlyMiddleware(), folderHandler.ToggleFolder)
 			folders.Post("/:id/scan", middleware.AdminOnlyMiddleware(), folderHandler.ScanFolder)
+			folders.Post("/:id/repair-mappings", middleware.AdminOnlyMiddleware(), folderHandler.RepairFolderMappings)
+			folders.Get("/:id/unindexed", middleware.AdminOnlyMiddleware(), folderHandler.ListUnindexedFiles)
+			folders.Post("/:id/unindexed", middleware.AdminOnlyMiddleware(), folderHandler.IndexUnindexedFiles)
+			folders.Post("/:id/auto-albums", albumHandler.CreateAutoAlbums)
 
 			// Folder files
 			folders.Get("/:id/files", folderHandler.ListFilesInFolder)
+			folders.Get("/:id/disk-tree", folderHandler.GetDiskTree)
+			folders.Get("/:id/exif-stats", handler.GetFolderExifStats)
 		}
 
 		// Permission Groups (for managing folder access)
@@ -157,6 +206,8 @@ func SetupRoutesV2(
 			// Permission management
 			permissionGroups.Get("/:id/permissions", permissionGroupHandler.ListPermissions)
 			permissionGroups.Post("/:id/permissions", middleware.AdminOnlyMiddleware(), permissionGroupHandler.GrantPermission)
+			permissionGroups.Post("/:id/permissions/bulk", middleware.AdminOnlyMiddleware(), permissionGroupHandler.BulkGrantPermission)
+			permissionGroups.Delete("/:id/permissions/bulk", middleware.AdminOnlyMiddleware(), permissionGroupHandler.BulkRevokePermission)
 			permissionGroups.Delete("/:id/permissions/:userId", middleware.AdminOnlyMiddleware(), permissionGroupHandler.RevokePermission)
 		}
 
@@ -165,17 +216,28 @@ func SetupRoutesV2(
 		{
 			albums.Get("", albumHandler.ListAlbums)
 			albums.Post("", albumHandler.CreateAlbum)
+			albums.Get("/counts", albumHandler.GetAlbumCounts)
 			albums.Get("/:id", albumHandler.GetAlbum)
 			albums.Put("/:id", albumHandler.UpdateAlbum)
 			albums.Delete("/:id", albumHandler.DeleteAlbum)
+			albums.Post("/:id/clone", albumHandler.CloneAlbum)
 
 			// Album items (dynamic query from file_folder_mappings)
 			albums.Get("/:id/items", albumHandler.ListAlbumItems)
+			albums.Get("/:id/exif-stats", albumHandler.GetAlbumExifStats)
+			albums.Get("/:id/export-html", handler.ExportAlbumHTML)
 
 			// Album folders (folder-based configuration)
 			albums.Get("/:id/folders", albumHandler.ListAlbumFolders)
 			albums.Post("/:id/folders", albumHandler.AddAlbumFolders)
+			albums.Get("/:id/folders/validate", albumHandler.ValidateAlbumFolders)
+			albums.Post("/:id/folders/prune", albumHandler.PruneAlbumFolders)
 			albums.Delete("/:id/folders/:folderId", albumHandler.RemoveAlbumFolder)
+
+			// Album collaborators (albums shared with other users)
+			albums.Get("/:id/collaborators", albumHandler.ListAlbumCollaborators)
+			albums.Post("/:id/collaborators", albumHandler.SetAlbumCollaborator)
+			albums.Delete("/:id/collaborators/:userId", albumHandler.RemoveAlbumCollaborator)
 		}
 
 		// Shares
@@ -189,7 +251,10 @@ func SetupRoutesV2(
 
 			// Share operations
 			shares.Post("/:id/extend", shareHandler.ExtendShare)
+			shares.Post("/:id/rotate", shareHandler.RotateShare)
+			shares.Delete("/:id/tokens", shareHandler.RevokeShareTokens)
 			shares.Get("/:id/access-log", shareHandler.GetShareAccessLog)
+			shares.Get("/:id/access-log/export", shareHandler.ExportShareAccessLog)
 
 			// Share permissions (for private shares)
 			shares.Post("/:id/permissions", shareHandler.GrantSharePermission)
@@ -216,11 +281,45 @@ func SetupRoutesV2(
 			settings.Put("/domain", settingsHandler.UpdateDomain)
 		}
 
+		// Server administration
+		admin := protected.Group("/admin")
+		{
+			admin.Post("/security/rotate", middleware.ServerOwnerOnlyMiddleware(), adminHandler.RotateSecurity)
+			admin.Post("/impersonate/:id", middleware.ServerOwnerOnlyMiddleware(), adminHandler.ImpersonateUser)
+			admin.Get("/folders/overlaps", middleware.AdminOnlyMiddleware(), adminHandler.ListFolderOverlaps)
+			admin.Get("/inventory.csv", middleware.AdminOnlyMiddleware(), adminHandler.ExportInventory)
+			admin.Get("/thumbnails/missing", middleware.AdminOnlyMiddleware(), handler.ListMissingThumbnails)
+			admin.Get("/storage/by-folder", middleware.AdminOnlyMiddleware(), adminHandler.GetStorageByFolder)
+			admin.Post("/folders/:id/merge", middleware.AdminOnlyMiddleware(), adminHandler.MergeFolder)
+			admin.Post("/files/bulk-move", middleware.AdminOnlyMiddleware(), adminHandler.BulkMoveFiles)
+			admin.Post("/index-file", middleware.AdminOnlyMiddleware(), handler.IndexFile)
+			admin.Get("/albums", middleware.AdminOnlyMiddleware(), albumHandler.ListAllAlbums)
+			admin.Post("/albums/recount", middleware.AdminOnlyMiddleware(), albumHandler.RecountAlbums)
+		}
+
+		// Event subscriptions / webhooks (admin only)
+		eventSubscriptions := protected.Group("/event-subscriptions", middleware.AdminOnlyMiddleware())
+		{
+			eventSubscriptions.Get("", eventSubscriptionHandler.ListSubscriptions)
+			eventSubscriptions.Post("", eventSubscriptionHandler.CreateSubscription)
+			eventSubscriptions.Get("/:id", eventSubscriptionHandler.GetSubscription)
+			eventSubscriptions.Put("/:id", eventSubscriptionHandler.UpdateSubscription)
+			eventSubscriptions.Delete("/:id", eventSubscriptionHandler.DeleteSubscription)
+		}
+
+		// Background jobs
+		protected.Get("/jobs/:id", jobHandler.GetJob)
+		protected.Get("/jobs/:id/download", jobHandler.DownloadJobFile)
+
+		// Live events (scan progress, job updates, indexed files) over websocket
+		protected.Get("/ws/events", eventStreamHandler.Upgrade, eventStreamHandler.StreamEvents())
+
 		// Domain configuration (admin only)
 		domainConfig := protected.Group("/domain-config", middleware.AdminOnlyMiddleware())
 		{
 			domainConfig.Get("", domainConfigHandler.GetDomainConfig)
 			domainConfig.Post("", domainConfigHandler.SaveDomainConfig)
+			domainConfig.Get("/test", domainConfigHandler.TestDomainConfig)
 		}
 	}
 }
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestUpdateFolderScanThumbnailSize(t *testing.T) {
+	db := newTestDB(t)
+	folderService := services.NewFolderService(db.DB)
+	h := NewFolderHandler(folderService, services.NewFileScanner(db, folderService, t.TempDir(), nil), services.NewJobQueue(db.DB, nil))
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	folderID := seedFolder(t, db.DB, "/photos", admin.ID)
+	path := "/api/folders/" + strconv.FormatInt(folderID, 10)
+
+	app := fiber.New()
+	app.Put("/api/folders/:id", asUser(admin), h.UpdateFolder)
+
+	cases := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{"set medium", `{"name":"Photos","absolute_path":"/photos","scan_thumbnail_size":"medium"}`, fiber.StatusOK, "medium"},
+		{"omitted keeps override", `{"name":"Photos","absolute_path":"/photos"}`, fiber.StatusOK, "medium"},
+		{"opt out", `{"name":"Photos","absolute_path":"/photos","scan_thumbnail_size":"none"}`, fiber.StatusOK, "none"},
+		{"invalid size", `{"name":"Photos","absolute_path":"/photos","scan_thumbnail_size":"huge"}`, fiber.StatusBadRequest, "none"},
+		{"inherit global", `{"name":"Photos","absolute_path":"/photos","scan_thumbnail_size":""}`, fiber.StatusOK, ""},
+	}
+	for _, tc := range cases {
+		status, resp := doRequest(t, app, http.MethodPut, path, tc.body, nil)
+		if status != tc.status {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.status, resp)
+		}
+		got, err := folderService.GetScanThumbnailSize(folderID)
+		if err != nil {
+			t.Fatalf("%s: get size: %v", tc.name, err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: stored size %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+)
+
+func TestExcludeScreenshotsFilter(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	viewer := seedUser(t, db.DB, "viewer", "user")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	grantFolder(t, db.DB, viewer.ID, folder, "read")
+
+	for name, screenshot := range map[string]bool{"camera.png": false, "screen.png": true} {
+		writeTestImage(t, filepath.Join(root, name), 8, 8)
+		id := seedFile(t, db.DB, folder, name, "image")
+		mustExec(t, db.DB, "INSERT INTO photo_metadata (file_id, width, height, taken_at, is_screenshot) VALUES (?, 8, 8, '2024-05-01 10:00:00', ?)",
+			id, screenshot)
+	}
+
+	filenames := func(resp map[string]interface{}) []string {
+		names := []string{}
+		for _, raw := range resp["files"].([]interface{}) {
+			names = append(names, raw.(map[string]interface{})["filename"].(string))
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	for _, user := range []*models.User{owner, viewer} {
+		app := fiber.New()
+		app.Get("/api/files", asUser(user), h.GetFiles)
+		app.Get("/api/timeline", asUser(user), h.GetTimeline)
+
+		for _, base := range []string{"/api/files", "/api/timeline?year=2024"} {
+			sep := "?"
+			if base != "/api/files" {
+				sep = "&"
+			}
+			cases := []struct {
+				query string
+				want  []string
+			}{
+				{"", []string{"camera.png", "screen.png"}},
+				{sep + "exclude_screenshots=false", []string{"camera.png", "screen.png"}},
+				{sep + "exclude_screenshots=true", []string{"camera.png"}},
+			}
+			for _, tc := range cases {
+				status, resp := doRequest(t, app, http.MethodGet, base+tc.query, "", nil)
+				if status != fiber.StatusOK {
+					t.Fatalf("%s %s: status %d body %v", user.Username, base+tc.query, status, resp)
+				}
+				got := filenames(resp)
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("%s %s: got %v, want %v", user.Username, base+tc.query, got, tc.want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+// seedFileOnDisk creates an empty file under root and indexes it in folderID
+func seedFileOnDisk(t *testing.T, db *sql.DB, folderID int64, root, relativePath string) int64 {
+	t.Helper()
+	path := filepath.Join(root, relativePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return seedFile(t, db, folderID, relativePath, "image")
+}
+
+func TestSearchFilesPaging(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	viewer := seedUser(t, db.DB, "viewer", "user")
+	sharedRoot, privateRoot := t.TempDir(), t.TempDir()
+	shared := seedFolder(t, db.DB, sharedRoot, owner.ID)
+	private := seedFolder(t, db.DB, privateRoot, owner.ID)
+	grantFolder(t, db.DB, viewer.ID, shared, "read")
+
+	for i := 0; i < 130; i++ {
+		seedFileOnDisk(t, db.DB, shared, sharedRoot, fmt.Sprintf("beach_%03d.jpg", i))
+	}
+	for i := 0; i < 5; i++ {
+		seedFileOnDisk(t, db.DB, private, privateRoot, fmt.Sprintf("beach_private_%d.jpg", i))
+	}
+	seedFileOnDisk(t, db.DB, shared, sharedRoot, "mountain.jpg")
+
+	cases := []struct {
+		name  string
+		user  *models.User
+		total int
+	}{
+		{"owner sees every match", owner, 135},
+		{"viewer only counts accessible files", viewer, 130},
+	}
+	for _, tc := range cases {
+		app := fiber.New()
+		app.Get("/api/search", asUser(tc.user), h.SearchFiles)
+
+		seen := map[float64]bool{}
+		pages := (tc.total + 49) / 50
+		for page := 1; page <= pages+1; page++ {
+			status, resp := doRequest(t, app, http.MethodGet, fmt.Sprintf("/api/search?q=beach&limit=50&page=%d", page), "", nil)
+			if status != fiber.StatusOK {
+				t.Fatalf("%s page %d: status %d (%v)", tc.name, page, status, resp)
+			}
+			if resp["total"] != float64(tc.total) || resp["total_pages"] != float64(pages) || resp["page"] != float64(page) {
+				t.Errorf("%s page %d: envelope %v", tc.name, page, resp)
+			}
+			files := resp["files"].([]interface{})
+			want := 50
+			switch {
+			case page == pages:
+				want = tc.total - (pages-1)*50
+			case page > pages:
+				want = 0
+			}
+			if len(files) != want {
+				t.Errorf("%s page %d: got %d files, want %d", tc.name, page, len(files), want)
+			}
+			for _, f := range files {
+				seen[f.(map[string]interface{})["id"].(float64)] = true
+			}
+		}
+		if len(seen) != tc.total {
+			t.Errorf("%s: paging returned %d distinct files, want %d", tc.name, len(seen), tc.total)
+		}
+	}
+}
+
+func TestSearchFilesScope(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	albumService := services.NewAlbumService(db.DB)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	viewer := seedUser(t, db.DB, "viewer", "user")
+	sharedRoot, privateRoot := t.TempDir(), t.TempDir()
+	shared := seedFolder(t, db.DB, sharedRoot, owner.ID)
+	private := seedFolder(t, db.DB, privateRoot, owner.ID)
+	grantFolder(t, db.DB, viewer.ID, shared, "read")
+
+	seedFileOnDisk(t, db.DB, shared, sharedRoot, "2024/beach_a.jpg")
+	seedFileOnDisk(t, db.DB, shared, sharedRoot, "2024/beach_b.jpg")
+	seedFileOnDisk(t, db.DB, shared, sharedRoot, "2023/beach_c.jpg")
+	seedFileOnDisk(t, db.DB, private, privateRoot, "beach_d.jpg")
+
+	album, err := albumService.CreateAlbum("2024", "", viewer.ID)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	mustExec(t, db.DB, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '2024/')", album.ID, shared)
+	otherAlbum, err := albumService.CreateAlbum("Private", "", owner.ID)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		user   *models.User
+		query  string
+		status int
+		total  int
+	}{
+		{"folder scope", owner, fmt.Sprintf("folder_id=%d", shared), fiber.StatusOK, 3},
+		{"private folder scope", owner, fmt.Sprintf("folder_id=%d", private), fiber.StatusOK, 1},
+		{"viewer folder scope", viewer, fmt.Sprintf("folder_id=%d", shared), fiber.StatusOK, 3},
+		{"viewer inaccessible folder", viewer, fmt.Sprintf("folder_id=%d", private), fiber.StatusForbidden, 0},
+		{"invalid folder", owner, "folder_id=abc", fiber.StatusBadRequest, 0},
+		{"album scope", viewer, fmt.Sprintf("album_id=%d", album.ID), fiber.StatusOK, 2},
+		{"someone else's album", viewer, fmt.Sprintf("album_id=%d", otherAlbum.ID), fiber.StatusForbidden, 0},
+		{"missing album", viewer, "album_id=9999", fiber.StatusNotFound, 0},
+		{"folder and album", owner, fmt.Sprintf("folder_id=%d&album_id=%d", private, album.ID), fiber.StatusOK, 0},
+	}
+	for _, tc := range cases {
+		app := fiber.New()
+		app.Get("/api/search", asUser(tc.user), h.SearchFiles)
+
+		status, resp := doRequest(t, app, http.MethodGet, "/api/search?q=beach&"+tc.query, "", nil)
+		if status != tc.status {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.status, resp)
+			continue
+		}
+		if status == fiber.StatusOK && resp["total"] != float64(tc.total) {
+			t.Errorf("%s: total %v, want %d", tc.name, resp["total"], tc.total)
+		}
+	}
+}
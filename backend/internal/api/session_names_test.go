@@ -0,0 +1,111 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/services"
+)
+
+// useSessionNames configures the session cookie and header names for one test
+func useSessionNames(t *testing.T, cookieName, headerName string) {
+	t.Helper()
+	defaultCookie, defaultHeader := middleware.SessionCookieName(), middleware.SessionHeaderName()
+	middleware.SetSessionNames(cookieName, headerName)
+	t.Cleanup(func() { middleware.SetSessionNames(defaultCookie, defaultHeader) })
+}
+
+func TestSetSessionNamesKeepsDefaultsForEmptyNames(t *testing.T) {
+	useSessionNames(t, "", "")
+	if got := middleware.SessionCookieName(); got != "session_id" {
+		t.Errorf("cookie name = %q, want session_id", got)
+	}
+	if got := middleware.SessionHeaderName(); got != fiber.HeaderAuthorization {
+		t.Errorf("header name = %q, want %s", got, fiber.HeaderAuthorization)
+	}
+}
+
+func TestConfiguredSessionCookieName(t *testing.T) {
+	useSessionNames(t, "gallery_session", "X-Gallery-Token")
+
+	db := newTestDB(t)
+	authService := services.NewAuthService(db.DB)
+	if _, err := authService.CreateUser("alice", "correct horse", "", "user"); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	authHandler := NewAuthHandler(authService, services.NewSettingsService(db.DB), nil)
+
+	app := fiber.New()
+	app.Post("/api/auth/login", authHandler.Login)
+	app.Get("/api/me", middleware.AuthMiddleware(authService), func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"user": middleware.GetUser(c).Username})
+	})
+
+	// Login sets the session under the configured cookie name
+	resp := sendRequest(t, app, "POST", "/api/auth/login", `{"username":"alice","password":"correct horse"}`, nil)
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("login: status %d", resp.StatusCode)
+	}
+	var sessionID string
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "session_id" {
+			t.Errorf("login set the default session cookie name")
+		}
+		if cookie.Name == "gallery_session" {
+			sessionID = cookie.Value
+		}
+	}
+	if sessionID == "" {
+		t.Fatalf("login did not set the gallery_session cookie")
+	}
+
+	cases := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"configured cookie", map[string]string{"Cookie": "gallery_session=" + sessionID}, fiber.StatusOK},
+		{"configured header", map[string]string{"X-Gallery-Token": "Bearer " + sessionID}, fiber.StatusOK},
+		{"default cookie", map[string]string{"Cookie": "session_id=" + sessionID}, fiber.StatusUnauthorized},
+		{"default header", map[string]string{"Authorization": "Bearer " + sessionID}, fiber.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		if status, _ := doRequest(t, app, "GET", "/api/me", "", tc.headers); status != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, status, tc.want)
+		}
+	}
+}
+
+func TestCSRFUsesConfiguredSessionCookieName(t *testing.T) {
+	useSessionNames(t, "gallery_session", "")
+
+	app := fiber.New()
+	app.Post("/api/files", middleware.CSRFMiddleware(), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// The renamed session cookie is what triggers the CSRF check
+	if status, _ := doRequest(t, app, "POST", "/api/files", "", map[string]string{"Cookie": "gallery_session=abc"}); status != fiber.StatusForbidden {
+		t.Errorf("renamed cookie without token: status %d, want 403", status)
+	}
+	if status, _ := doRequest(t, app, "POST", "/api/files", "", map[string]string{"Cookie": "session_id=abc"}); status != fiber.StatusNoContent {
+		t.Errorf("old cookie name: status %d, want 204", status)
+	}
+
+	// Sessions get a CSRF token on their first safe request
+	app.Get("/api/files", middleware.CSRFMiddleware(), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	resp := sendRequest(t, app, "GET", "/api/files", "", map[string]string{"Cookie": "gallery_session=abc"})
+	resp.Body.Close()
+	found := false
+	for _, cookie := range resp.Cookies() {
+		found = found || cookie.Name == middleware.CSRFCookieName
+	}
+	if !found {
+		t.Errorf("no CSRF token issued for the renamed session cookie")
+	}
+}
@@ -1,18 +1,22 @@
 package api
 
 import (
+	"log"
+
 	"github.com/gofiber/fiber/v2"
 
 	"awesome-sharing/internal/services"
 )
 
 type SettingsHandler struct {
-	settingsService *services.SettingsService
+	settingsService        *services.SettingsService
+	permissionGroupService *services.PermissionGroupService
 }
 
-func NewSettingsHandler(settingsService *services.SettingsService) *SettingsHandler {
+func NewSettingsHandler(settingsService *services.SettingsService, permissionGroupService *services.PermissionGroupService) *SettingsHandler {
 	return &SettingsHandler{
-		settingsService: settingsService,
+		settingsService:        settingsService,
+		permissionGroupService: permissionGroupService,
 	}
 }
 
@@ -36,10 +40,12 @@ func (h *SettingsHandler) GetSettings(c *fiber.Ctx) error {
 func (h *SettingsHandler) GetPublicSettings(c *fiber.Ctx) error {
 	siteName, _ := h.settingsService.GetSiteName()
 	allowRegistration, _ := h.settingsService.IsRegistrationAllowed()
+	readOnly, _ := h.settingsService.IsReadOnly()
 
 	return c.JSON(fiber.Map{
 		"site_name":          siteName,
 		"allow_registration": allowRegistration,
+		"read_only":          readOnly,
 	})
 }
 
@@ -67,6 +73,20 @@ func (h *SettingsHandler) UpdateSettings(c *fiber.Ctx) error {
 		})
 	}
 
+	// Guests read exactly the guest permission group, so their grants follow the setting
+	if services.AffectsGuestAccess(req) {
+		groupID, err := h.settingsService.GetGuestPermissionGroupID()
+		if err == nil {
+			err = h.permissionGroupService.SyncAllGuestAccess(groupID)
+		}
+		if err != nil {
+			log.Printf("Failed to sync guest access: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update guest access",
+			})
+		}
+	}
+
 	// Return updated settings
 	settings, err := h.settingsService.GetAllSettings()
 	if err != nil {
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAlbumShareFollowsFolderContents(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+	thumbs := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	writeTestImage(t, filepath.Join(root, "a.png"), 40, 30)
+	writeTestImage(t, filepath.Join(root, "private.png"), 40, 30)
+	first := seedFile(t, db.DB, folder, "a.png", "image")
+	private := seedFile(t, db.DB, seedFolder(t, db.DB, t.TempDir(), owner.ID), "private.png", "image")
+
+	album, err := h.albumService.CreateAlbum("trip", "", owner.ID)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	mustExec(t, db.DB, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '')", album.ID, folder)
+	share, err := h.shareService.CreateShare("album", album.ID, owner.ID, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	// New subfolder content after the share exists
+	if err := os.Mkdir(filepath.Join(root, "day2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestImage(t, filepath.Join(root, "day2", "b.png"), 40, 30)
+	added := seedFile(t, db.DB, folder, "day2/b.png", "image")
+
+	app := fiber.New()
+	app.Get("/s/:id", h.AccessShare)
+	app.Get("/api/public/files/:id", h.GetPublicFile)
+	app.Get("/api/public/files/:id/thumbnail", thumbs.GetPublicThumbnail)
+
+	status, resp := doRequest(t, app, http.MethodGet, "/s/"+share.ID, "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("access share: status %d (%v)", status, resp)
+	}
+	if name := resp["album"].(map[string]interface{})["name"]; name != "trip" {
+		t.Errorf("album name = %v, want trip", name)
+	}
+	files := resp["files"].([]interface{})
+	got := map[float64]bool{}
+	for _, f := range files {
+		got[f.(map[string]interface{})["id"].(float64)] = true
+	}
+	if len(files) != 2 || !got[float64(first)] || !got[float64(added)] {
+		t.Errorf("shared album files = %v, want %d and %d", got, first, added)
+	}
+
+	token := url.QueryEscape(resp["access_token"].(string))
+	cases := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"added file", fmt.Sprintf("/api/public/files/%d?token=%s", added, token), fiber.StatusOK},
+		{"added file thumbnail", fmt.Sprintf("/api/public/files/%d/thumbnail?token=%s", added, token), fiber.StatusOK},
+		{"file outside the album", fmt.Sprintf("/api/public/files/%d?token=%s", private, token), fiber.StatusForbidden},
+		{"thumbnail outside the album", fmt.Sprintf("/api/public/files/%d/thumbnail?token=%s", private, token), fiber.StatusForbidden},
+	}
+	for _, tc := range cases {
+		resp := sendRequest(t, app, http.MethodGet, tc.path, "", nil)
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s: status %d, want %d", tc.name, resp.StatusCode, tc.status)
+		}
+	}
+}
+
+func TestAlbumSharePaginated(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("%d.png", i)
+		writeTestImage(t, filepath.Join(root, name), 8, 8)
+		seedFile(t, db.DB, folder, name, "image")
+	}
+
+	album, err := h.albumService.CreateAlbum("trip", "", owner.ID)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	mustExec(t, db.DB, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '')", album.ID, folder)
+	share, err := h.shareService.CreateShare("album", album.ID, owner.ID, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/s/:id", h.AccessShare)
+
+	seen := map[float64]bool{}
+	for _, tc := range []struct {
+		query string
+		files int
+		limit float64
+	}{
+		{"?page=1&limit=2", 2, 2},
+		{"?page=2&limit=2", 2, 2},
+		{"?page=3&limit=2", 1, 2},
+		{"?page=4&limit=2", 0, 2},
+		{"?limit=100000", 5, 100},
+	} {
+		status, resp := doRequest(t, app, http.MethodGet, "/s/"+share.ID+tc.query, "", nil)
+		if status != fiber.StatusOK {
+			t.Fatalf("%s: status %d (%v)", tc.query, status, resp)
+		}
+		files := resp["files"].([]interface{})
+		if len(files) != tc.files || resp["total"] != float64(5) || resp["limit"] != tc.limit {
+			t.Errorf("%s: %d files, total %v, limit %v", tc.query, len(files), resp["total"], resp["limit"])
+		}
+		if tc.limit == 2 {
+			for _, f := range files {
+				id := f.(map[string]interface{})["id"].(float64)
+				if seen[id] {
+					t.Errorf("%s: file %v already on an earlier page", tc.query, id)
+				}
+				seen[id] = true
+			}
+		}
+	}
+	if len(seen) != 5 {
+		t.Errorf("pages covered %d files, want 5", len(seen))
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestShareDigestPreference(t *testing.T) {
+	db := newTestDB(t)
+	h := NewAuthHandler(services.NewAuthService(db.DB), services.NewSettingsService(db.DB), services.NewUserPreferenceService(db.DB))
+	alice := seedUser(t, db.DB, "alice", "user")
+
+	app := fiber.New()
+	app.Put("/api/auth/preferences", asUser(alice), h.UpdatePreferences)
+
+	cases := []struct {
+		body string
+		want int
+	}{
+		{`{"share_digest":"weekly"}`, fiber.StatusOK},
+		{`{"share_digest":"off"}`, fiber.StatusOK},
+		{`{"share_digest":"hourly"}`, fiber.StatusBadRequest},
+		{`{"share_digest":null}`, fiber.StatusOK},
+	}
+	for _, tc := range cases {
+		if status, body := doRequest(t, app, http.MethodPut, "/api/auth/preferences", tc.body, nil); status != tc.want {
+			t.Errorf("%s: status %d, want %d (body %v)", tc.body, status, tc.want, body)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCreateShareRequiresDomain(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+	owner := seedUser(t, db.DB, "owner", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	grantFolder(t, db.DB, owner.ID, folder, "read")
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+
+	app := fiber.New()
+	app.Post("/api/shares", asUser(owner), h.CreateShare)
+	body := fmt.Sprintf(`{"share_type":"file","resource_id":%d}`, file)
+
+	mustExec(t, db.DB, "UPDATE domain_config SET domain = ''")
+	status, resp := doRequest(t, app, http.MethodPost, "/api/shares", body, nil)
+	if status != fiber.StatusBadRequest {
+		t.Fatalf("unconfigured domain: status %d, body %v", status, resp)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM shares").Scan(&count); err != nil {
+		t.Fatalf("count shares: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("%d shares created without a domain", count)
+	}
+
+	mustExec(t, db.DB, "UPDATE domain_config SET domain = 'photos.example.com'")
+	status, resp = doRequest(t, app, http.MethodPost, "/api/shares", body, nil)
+	if status != fiber.StatusCreated {
+		t.Fatalf("configured domain: status %d, body %v", status, resp)
+	}
+	shareID := resp["share"].(map[string]interface{})["id"].(string)
+	if resp["url"] != "https://photos.example.com/s/"+shareID {
+		t.Errorf("url = %v", resp["url"])
+	}
+}
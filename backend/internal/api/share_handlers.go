@@ -1,8 +1,13 @@
 package api
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
 	"strconv"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gofiber/fiber/v2"
 
@@ -18,18 +23,37 @@ type ShareHandler struct {
 	domainConfigService *services.DomainConfigService
 	db                  *database.DB
 	validator           *services.FileValidatorService
+	fileStatsService    *services.FileStatsService
+	stripper            *services.MetadataStripper
+	albumService        *services.AlbumService
+	contentHashes       *services.ContentHashService
 }
 
-func NewShareHandler(shareService *services.ShareService, settingsService *services.SettingsService, domainConfigService *services.DomainConfigService, db *database.DB, validator *services.FileValidatorService) *ShareHandler {
+func NewShareHandler(shareService *services.ShareService, settingsService *services.SettingsService, domainConfigService *services.DomainConfigService, db *database.DB, validator *services.FileValidatorService, fileStatsService *services.FileStatsService, stripper *services.MetadataStripper, albumService *services.AlbumService) *ShareHandler {
 	return &ShareHandler{
 		shareService:        shareService,
 		settingsService:     settingsService,
 		domainConfigService: domainConfigService,
 		db:                  db,
 		validator:           validator,
+		fileStatsService:    fileStatsService,
+		stripper:            stripper,
+		albumService:        albumService,
+		contentHashes:       services.NewContentHashService(db.DB),
 	}
 }
 
+// validateShareText enforces the length limits of share title and description
+func validateShareText(title, description string) error {
+	if utf8.RuneCountInString(services.SanitizeShareText(title)) > services.MaxShareTitleLength {
+		return fmt.Errorf("Title must be at most %d characters", services.MaxShareTitleLength)
+	}
+	if utf8.RuneCountInString(services.SanitizeShareText(description)) > services.MaxShareDescriptionLength {
+		return fmt.Errorf("Description must be at most %d characters", services.MaxShareDescriptionLength)
+	}
+	return nil
+}
+
 // ListShares returns all shares for the current user
 // GET /api/shares
 func (h *ShareHandler) ListShares(c *fiber.Ctx) error {
@@ -99,13 +123,17 @@ func (h *ShareHandler) CreateShare(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		ShareType    string     `json:"share_type"`   // 'file' or 'album'
-		ResourceID   int64      `json:"resource_id"`
-		AccessType   string     `json:"access_type"`  // 'public' or 'private'
-		Password     string     `json:"password"`
-		RequiresAuth bool       `json:"requires_auth"`
-		ExpiresIn    *int       `json:"expires_in"`   // Hours
-		MaxViews     *int       `json:"max_views"`
+		ShareType     string   `json:"share_type"` // 'file' or 'album'
+		ResourceID    int64    `json:"resource_id"`
+		Title         string   `json:"title"`
+		Description   string   `json:"description"`
+		AccessType    string   `json:"access_type"` // 'public' or 'private'
+		Password      string   `json:"password"`
+		RequiresAuth  bool     `json:"requires_auth"`
+		StripEXIF     bool     `json:"strip_exif"`
+		ExpiresIn     *int     `json:"expires_in"` // Hours
+		MaxViews      *int     `json:"max_views"`
+		AllowedEmails []string `json:"allowed_emails"` // Addresses or @domain patterns allowed after login
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -131,12 +159,47 @@ func (h *ShareHandler) CreateShare(c *fiber.Ctx) error {
 		req.AccessType = "public"
 	}
 
+	if err := validateShareText(req.Title, req.Description); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	if req.AccessType != "public" && req.AccessType != "private" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Access type must be 'public' or 'private'",
 		})
 	}
 
+	allowedEmails, err := services.NormalizeAllowedEmails(req.AllowedEmails)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if user.Role != "admin" && user.Role != "server_owner" {
+		if err := h.settingsService.CheckShareLimit(user.ID); err != nil {
+			if err == services.ErrShareLimitReached {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Share limit reached",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to check share limit",
+			})
+		}
+	}
+
+	// Resolve the share URL base before inserting anything, so a missing domain
+	// configuration doesn't leave a share behind that has no usable link
+	baseURL, err := h.domainConfigService.GetFullURL()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Domain not configured. Please configure the domain in settings first.",
+		})
+	}
+
 	// Calculate expiration
 	var expiresAt *time.Time
 	if req.ExpiresIn != nil && *req.ExpiresIn > 0 {
@@ -148,9 +211,12 @@ func (h *ShareHandler) CreateShare(c *fiber.Ctx) error {
 		req.ShareType,
 		req.ResourceID,
 		user.ID,
+		req.Title,
+		req.Description,
 		req.AccessType,
 		req.Password,
 		req.RequiresAuth,
+		req.StripEXIF,
 		expiresAt,
 		req.MaxViews,
 	)
@@ -160,12 +226,14 @@ func (h *ShareHandler) CreateShare(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get domain for full URL
-	baseURL, err := h.domainConfigService.GetFullURL()
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Domain not configured. Please configure the domain in settings first.",
-		})
+	if len(allowedEmails) > 0 {
+		if err := h.shareService.UpdateShare(share.ID, map[string]interface{}{"allowed_emails": allowedEmails}); err != nil {
+			h.shareService.DeleteShare(share.ID)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to create share",
+			})
+		}
+		share.AllowedEmails = allowedEmails
 	}
 
 	fullURL := baseURL + "/s/" + share.ID
@@ -208,11 +276,15 @@ func (h *ShareHandler) UpdateShare(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		Enabled      *bool   `json:"enabled"`
-		MaxViews     *int    `json:"max_views"`
-		Password     *string `json:"password"`
-		RequiresAuth *bool   `json:"requires_auth"`
-		ExpiresIn    *int    `json:"expires_in"` // Hours from now, null to remove expiration
+		Enabled       *bool     `json:"enabled"`
+		Title         *string   `json:"title"`
+		Description   *string   `json:"description"`
+		MaxViews      *int      `json:"max_views"`
+		Password      *string   `json:"password"`
+		RequiresAuth  *bool     `json:"requires_auth"`
+		StripEXIF     *bool     `json:"strip_exif"`
+		ExpiresIn     *int      `json:"expires_in"`     // Hours from now, null to remove expiration
+		AllowedEmails *[]string `json:"allowed_emails"` // Empty list removes the allowlist
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -225,6 +297,22 @@ func (h *ShareHandler) UpdateShare(c *fiber.Ctx) error {
 	if req.Enabled != nil {
 		updates["enabled"] = *req.Enabled
 	}
+	if req.Title != nil || req.Description != nil {
+		var title, description string
+		if req.Title != nil {
+			title = *req.Title
+			updates["title"] = title
+		}
+		if req.Description != nil {
+			description = *req.Description
+			updates["description"] = description
+		}
+		if err := validateShareText(title, description); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
 	if req.MaxViews != nil {
 		updates["max_views"] = *req.MaxViews
 	}
@@ -234,6 +322,18 @@ func (h *ShareHandler) UpdateShare(c *fiber.Ctx) error {
 	if req.RequiresAuth != nil {
 		updates["requires_auth"] = *req.RequiresAuth
 	}
+	if req.StripEXIF != nil {
+		updates["strip_exif"] = *req.StripEXIF
+	}
+	if req.AllowedEmails != nil {
+		allowedEmails, err := services.NormalizeAllowedEmails(*req.AllowedEmails)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		updates["allowed_emails"] = allowedEmails
+	}
 	if req.ExpiresIn != nil {
 		if *req.ExpiresIn > 0 {
 			expiry := time.Now().Add(time.Duration(*req.ExpiresIn) * time.Hour)
@@ -378,6 +478,103 @@ func (h *ShareHandler) ExtendShare(c *fiber.Ctx) error {
 	})
 }
 
+// RotateShare gives a share a new ID, invalidating the old link while keeping its settings and history
+// POST /api/shares/:id/rotate
+func (h *ShareHandler) RotateShare(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id := c.Params("id")
+
+	// Check ownership
+	share, err := h.shareService.GetShare(id)
+	if err != nil {
+		if err == services.ErrShareNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Share not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch share",
+		})
+	}
+
+	if share.OwnerID != user.ID && user.Role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	// Resolve the domain first so a misconfiguration doesn't leave the caller without the new link
+	baseURL, err := h.domainConfigService.GetFullURL()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Domain not configured. Please configure the domain in settings first.",
+		})
+	}
+
+	rotated, err := h.shareService.RotateShareID(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to rotate share link",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"share":  rotated,
+		"url":    baseURL + "/s/" + rotated.ID,
+		"old_id": id,
+	})
+}
+
+// RevokeShareTokens revokes every access token issued for a share, so visitors
+// have to open the share link again before they can load its files
+// DELETE /api/shares/:id/tokens
+func (h *ShareHandler) RevokeShareTokens(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id := c.Params("id")
+
+	// Check ownership
+	share, err := h.shareService.GetShare(id)
+	if err != nil {
+		if err == services.ErrShareNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Share not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch share",
+		})
+	}
+
+	if share.OwnerID != user.ID && user.Role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	revoked, err := h.shareService.RevokeAccessTokens(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revoke access tokens",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"revoked": revoked,
+	})
+}
+
 // DeleteExpiredShares deletes all expired shares
 // DELETE /api/shares/expired
 func (h *ShareHandler) DeleteExpiredShares(c *fiber.Ctx) error {
@@ -439,6 +636,48 @@ func (h *ShareHandler) GetShareAccessLog(c *fiber.Ctx) error {
 	})
 }
 
+// ExportShareAccessLog streams the complete access log of a share as CSV
+// GET /api/shares/:id/access-log/export
+func (h *ShareHandler) ExportShareAccessLog(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id := c.Params("id")
+
+	// Check ownership
+	share, err := h.shareService.GetShare(id)
+	if err != nil {
+		if err == services.ErrShareNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Share not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch share",
+		})
+	}
+
+	if share.OwnerID != user.ID && user.Role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Access denied",
+		})
+	}
+
+	c.Set("Content-Type", "text/csv")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=share_%s_access_log.csv", share.ID))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := h.shareService.ExportAccessLog(share.ID, w); err != nil {
+			log.Printf("Failed to export access log for share %s: %v", share.ID, err)
+		}
+		w.Flush()
+	})
+	return nil
+}
+
 // AccessShare - Public endpoint for accessing a share
 // GET /api/s/:id
 func (h *ShareHandler) AccessShare(c *fiber.Ctx) error {
@@ -465,6 +704,12 @@ func (h *ShareHandler) AccessShare(c *fiber.Ctx) error {
 				"error": "This share has expired",
 			})
 		}
+		if err == services.ErrShareResourceGone {
+			return c.Status(fiber.StatusGone).JSON(fiber.Map{
+				"error":       "The shared item is no longer available",
+				"unavailable": true,
+			})
+		}
 		if err == services.ErrShareDisabled {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"error": "This share has been disabled",
@@ -492,18 +737,23 @@ func (h *ShareHandler) AccessShare(c *fiber.Ctx) error {
 		})
 	}
 
-	// Log access
-	ipAddress := c.IP()
-	userAgent := c.Get("User-Agent")
-	err = h.shareService.LogAccess(id, userID, ipAddress, userAgent)
-	if err != nil {
-		// Log error but don't fail the request
-		// log.Printf("Failed to log share access: %v", err)
-	}
+	// Owners and admins checking a share get a preview that doesn't count as a view
+	preview := user != nil && (share.OwnerID == user.ID || user.Role == "admin" || user.Role == "server_owner")
 
-	// Refresh share to get updated view_count (after LogAccess incremented it)
-	share, err = h.shareService.GetShare(id)
-	if err != nil {
+	if !preview {
+		// Log access
+		ipAddress := c.IP()
+		userAgent := c.Get("User-Agent")
+		err = h.shareService.LogAccess(id, userID, ipAddress, userAgent)
+		if err != nil {
+			// Log error but don't fail the request
+			// log.Printf("Failed to log share access: %v", err)
+		}
+
+		// Refresh share to get updated view_count (after LogAccess incremented it)
+		if refreshed, err := h.shareService.GetShare(id); err == nil {
+			share = refreshed
+		}
 		// If refresh fails, continue with old data (non-critical)
 		// The view_count will be off by 1, but share is still accessible
 	}
@@ -516,10 +766,65 @@ func (h *ShareHandler) AccessShare(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.JSON(fiber.Map{
+	response := fiber.Map{
 		"share":        share,
 		"access_token": accessToken,
-	})
+		"preview":      preview,
+	}
+
+	// Album contents are evaluated now, so files added to the album's folders after
+	// the share was created show up
+	if share.ShareType == "album" {
+		album, err := h.albumService.GetAlbum(share.ResourceID)
+		if err != nil {
+			if err == services.ErrAlbumNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Shared album not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch album",
+			})
+		}
+
+		// Paged like the album folder listing, so a large album isn't returned at once
+		page := c.QueryInt("page", 1)
+		limit := c.QueryInt("limit", 100)
+		if page < 1 {
+			page = 1
+		}
+		if limit < 1 || limit > 500 {
+			limit = 100
+		}
+
+		ctx, cancel := queryContext(c)
+		defer cancel()
+
+		files, total, err := h.albumService.ListItemsPage(ctx, album.ID, album.DefaultSort, "", 0, page, limit)
+		if err != nil {
+			if isQueryTimeout(err) {
+				return queryTimeoutError(c)
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch album items",
+			})
+		}
+		if files == nil {
+			files = []models.File{}
+		}
+
+		response["album"] = fiber.Map{
+			"id":          album.ID,
+			"name":        album.Name,
+			"description": album.Description,
+		}
+		response["files"] = h.validator.ValidateFiles(files)
+		response["total"] = total
+		response["page"] = page
+		response["limit"] = limit
+	}
+
+	return c.JSON(response)
 }
 
 // GrantSharePermission grants a user access to a private share
@@ -633,7 +938,7 @@ func (h *ShareHandler) GetPublicFile(c *fiber.Ctx) error {
 	}
 
 	// Validate the access token
-	_, resourceID, err := h.shareService.ValidateAccessToken(token)
+	shareID, _, err := h.shareService.ValidateAccessToken(token)
 	if err != nil {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "Invalid or expired access token",
@@ -648,18 +953,27 @@ func (h *ShareHandler) GetPublicFile(c *fiber.Ctx) error {
 		})
 	}
 
-	// Verify the file ID matches the shared resource
-	if fileID != resourceID {
+	// Verify the file is the shared file or currently in the shared album
+	included, err := h.shareService.ShareIncludesFile(shareID, fileID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to check shared resource",
+		})
+	}
+	if !included {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "File does not match shared resource",
 		})
 	}
 
-	// Get the file
+	// Get the file (photo fields live in photo_metadata)
 	var file models.File
 	err = h.db.QueryRow(`
-		SELECT id, filename, file_type, size, width, height, taken_at, created_at, updated_at
-		FROM files WHERE id = ?
+		SELECT f.id, f.filename, f.file_type, f.size, COALESCE(pm.width, 0), COALESCE(pm.height, 0),
+		       pm.taken_at, f.created_at, f.updated_at
+		FROM files f
+		LEFT JOIN photo_metadata pm ON f.id = pm.file_id
+		WHERE f.id = ?
 	`, fileID).Scan(&file.ID, &file.Filename, &file.FileType, &file.Size, &file.Width, &file.Height,
 		&file.TakenAt, &file.CreatedAt, &file.UpdatedAt)
 
@@ -677,6 +991,10 @@ func (h *ShareHandler) GetPublicFile(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := h.fileStatsService.RecordView(fileID); err != nil {
+		log.Printf("Failed to record view for file %d: %v", fileID, err)
+	}
+
 	return c.JSON(files[0])
 }
 
@@ -693,7 +1011,7 @@ func (h *ShareHandler) DownloadPublicFile(c *fiber.Ctx) error {
 	}
 
 	// Validate the access token
-	_, resourceID, err := h.shareService.ValidateAccessToken(token)
+	shareID, _, err := h.shareService.ValidateAccessToken(token)
 	if err != nil {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "Invalid or expired access token",
@@ -708,18 +1026,27 @@ func (h *ShareHandler) DownloadPublicFile(c *fiber.Ctx) error {
 		})
 	}
 
-	// Verify the file ID matches the shared resource
-	if fileID != resourceID {
+	// Verify the file is the shared file or currently in the shared album
+	included, err := h.shareService.ShareIncludesFile(shareID, fileID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to check shared resource",
+		})
+	}
+	if !included {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "File does not match shared resource",
 		})
 	}
 
-	// Get the file
+	// Get the file (photo fields live in photo_metadata)
 	var file models.File
 	err = h.db.QueryRow(`
-		SELECT id, filename, file_type, size, width, height, taken_at, created_at, updated_at
-		FROM files WHERE id = ?
+		SELECT f.id, f.filename, f.file_type, f.size, COALESCE(pm.width, 0), COALESCE(pm.height, 0),
+		       pm.taken_at, f.created_at, f.updated_at
+		FROM files f
+		LEFT JOIN photo_metadata pm ON f.id = pm.file_id
+		WHERE f.id = ?
 	`, fileID).Scan(&file.ID, &file.Filename, &file.FileType, &file.Size, &file.Width, &file.Height,
 		&file.TakenAt, &file.CreatedAt, &file.UpdatedAt)
 
@@ -737,9 +1064,58 @@ func (h *ShareHandler) DownloadPublicFile(c *fiber.Ctx) error {
 		})
 	}
 
+	// Serve a metadata-free copy of images when the share asks for it
+	stripEXIF := false
+	if file.FileType == "image" {
+		share, err := h.shareService.GetShare(shareID)
+		if err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Share not found",
+			})
+		}
+		stripEXIF = share.StripEXIF
+	}
+
+	// The stripped copy is derived from the original, so it is validated against the
+	// original with its own ETag; toggling strip_exif never serves a stale cached variant
+	variant := ""
+	if stripEXIF {
+		variant = "stripped"
+	}
+	if setVariantCacheValidators(c, fileID, files[0].AbsolutePath, variant) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	sendPath := files[0].AbsolutePath
+	if stripEXIF {
+		sendPath, err = h.stripper.StrippedPath(fileID, files[0].AbsolutePath)
+		if err != nil {
+			if err == services.ErrStripUnsupported {
+				return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+					"error": "This file type cannot be shared without its metadata",
+				})
+			}
+			if errors.Is(err, services.ErrSourceTooLarge) {
+				return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+					"error": "Image is too large to strip its metadata",
+				})
+			}
+			log.Printf("Failed to strip metadata from file %d: %v", fileID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to prepare file",
+			})
+		}
+	}
+
+	if err := h.fileStatsService.RecordDownload(fileID); err != nil {
+		log.Printf("Failed to record download for file %d: %v", fileID, err)
+	}
+
+	setChecksumHeader(c, h.contentHashes, fileID, sendPath, sendPath == files[0].AbsolutePath)
+
 	// Set Content-Disposition header to force download
 	c.Set("Content-Disposition", "attachment; filename=\""+files[0].Filename+"\"")
 
 	// Send file
-	return c.SendFile(files[0].AbsolutePath)
+	return sendFileAs(c, sendPath, services.ContentTypeForFile(sendPath, file.FileType))
 }
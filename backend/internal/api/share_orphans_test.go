@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAccessShareOfDeletedFile(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+	share, err := h.shareService.CreateShare("file", file, owner.ID, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/s/:id", h.AccessShare)
+	if status, resp := doRequest(t, app, http.MethodGet, "/s/"+share.ID, "", nil); status != fiber.StatusOK {
+		t.Fatalf("before delete: status %d (%v)", status, resp)
+	}
+
+	mustExec(t, db.DB, "DELETE FROM files WHERE id = ?", file)
+	for _, enabled := range []int{1, 0} {
+		mustExec(t, db.DB, "UPDATE shares SET enabled = ? WHERE id = ?", enabled, share.ID)
+		status, resp := doRequest(t, app, http.MethodGet, "/s/"+share.ID, "", nil)
+		if status != fiber.StatusGone || resp["unavailable"] != true {
+			t.Errorf("enabled=%d: status %d (%v), want 410 unavailable", enabled, status, resp)
+		}
+	}
+}
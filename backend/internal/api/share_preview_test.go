@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"awesome-sharing/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// shareViews returns the view count and the number of access log rows for a share
+func shareViews(t *testing.T, h *ShareHandler, shareID string) (int, int) {
+	t.Helper()
+	var views, logged int
+	if err := h.db.QueryRow("SELECT view_count FROM shares WHERE id = ?", shareID).Scan(&views); err != nil {
+		t.Fatalf("read view count: %v", err)
+	}
+	if err := h.db.QueryRow("SELECT COUNT(*) FROM share_access_log WHERE share_id = ?", shareID).Scan(&logged); err != nil {
+		t.Fatalf("count access log: %v", err)
+	}
+	return views, logged
+}
+
+func TestAccessSharePreview(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	admin := seedUser(t, db.DB, "admin", "admin")
+	serverOwner := seedUser(t, db.DB, "root", "server_owner")
+	other := seedUser(t, db.DB, "other", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+
+	share, err := h.shareService.CreateShare("file", file, owner.ID, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	cases := []struct {
+		name        string
+		user        *models.User
+		wantPreview bool
+	}{
+		{"owner", owner, true},
+		{"admin", admin, true},
+		{"server owner", serverOwner, true},
+		{"other user", other, false},
+		{"anonymous", nil, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			viewsBefore, loggedBefore := shareViews(t, h, share.ID)
+
+			app := fiber.New()
+			app.Get("/s/:id", asUser(tc.user), h.AccessShare)
+			status, body := doRequest(t, app, http.MethodGet, "/s/"+share.ID, "", nil)
+			if status != fiber.StatusOK {
+				t.Fatalf("status %d, body %v", status, body)
+			}
+			if body["preview"] != tc.wantPreview {
+				t.Errorf("preview = %v, want %v", body["preview"], tc.wantPreview)
+			}
+			if body["access_token"] == "" || body["access_token"] == nil {
+				t.Error("expected an access token")
+			}
+
+			views, logged := shareViews(t, h, share.ID)
+			wantDelta := 1
+			if tc.wantPreview {
+				wantDelta = 0
+			}
+			if views-viewsBefore != wantDelta || logged-loggedBefore != wantDelta {
+				t.Errorf("views %d -> %d, log rows %d -> %d, want +%d", viewsBefore, views, loggedBefore, logged, wantDelta)
+			}
+			got := int(body["share"].(map[string]interface{})["view_count"].(float64))
+			if got != views {
+				t.Errorf("response view_count %d, stored %d", got, views)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+)
+
+func TestRotateShareHandler(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	other := seedUser(t, db.DB, "other", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+	share, err := h.shareService.CreateShare("file", file, owner.ID, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	newApp := func(user *models.User) *fiber.App {
+		app := fiber.New()
+		app.Get("/s/:id", h.AccessShare)
+		app.Post("/api/shares/:id/rotate", asUser(user), h.RotateShare)
+		return app
+	}
+
+	// Some history on the old link
+	if status, _ := doRequest(t, newApp(nil), http.MethodGet, "/s/"+share.ID, "", nil); status != fiber.StatusOK {
+		t.Fatalf("access before rotation: status %d", status)
+	}
+
+	for _, tc := range []struct {
+		name string
+		user *models.User
+		path string
+		want int
+	}{
+		{"anonymous", nil, "/api/shares/" + share.ID + "/rotate", fiber.StatusUnauthorized},
+		{"missing share", owner, "/api/shares/nope1234/rotate", fiber.StatusNotFound},
+		{"not the owner", other, "/api/shares/" + share.ID + "/rotate", fiber.StatusForbidden},
+	} {
+		if status, body := doRequest(t, newApp(tc.user), http.MethodPost, tc.path, "", nil); status != tc.want {
+			t.Errorf("%s: status %d, body %v, want %d", tc.name, status, body, tc.want)
+		}
+	}
+
+	app := newApp(owner)
+	status, body := doRequest(t, app, http.MethodPost, "/api/shares/"+share.ID+"/rotate", "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("rotate: status %d, body %v", status, body)
+	}
+	newID := body["share"].(map[string]interface{})["id"].(string)
+	if newID == share.ID || body["old_id"] != share.ID {
+		t.Fatalf("rotate returned id %q, old_id %v", newID, body["old_id"])
+	}
+	if url, _ := body["url"].(string); !strings.HasPrefix(url, "https://photos.example.com") || !strings.HasSuffix(url, "/s/"+newID) {
+		t.Errorf("url = %q", body["url"])
+	}
+
+	if status, _ := doRequest(t, app, http.MethodGet, "/s/"+share.ID, "", nil); status != fiber.StatusNotFound {
+		t.Errorf("old link: status %d, want 404", status)
+	}
+	status, body = doRequest(t, app, http.MethodGet, "/s/"+newID, "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("new link: status %d, body %v", status, body)
+	}
+	if views := body["share"].(map[string]interface{})["view_count"].(float64); views != 2 {
+		t.Errorf("view_count = %v, want 2 (history preserved)", views)
+	}
+	var logged int
+	db.QueryRow("SELECT COUNT(*) FROM share_access_log WHERE share_id = ?", newID).Scan(&logged)
+	if logged != 2 {
+		t.Errorf("access log rows = %d, want 2", logged)
+	}
+}
+
+func TestRotateShareRequiresDomain(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+	mustExec(t, db.DB, "UPDATE domain_config SET domain = ''")
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+	share, err := h.shareService.CreateShare("file", file, owner.ID, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	app := fiber.New()
+	app.Post("/api/shares/:id/rotate", asUser(owner), h.RotateShare)
+	if status, _ := doRequest(t, app, http.MethodPost, "/api/shares/"+share.ID+"/rotate", "", nil); status != fiber.StatusBadRequest {
+		t.Errorf("status %d, want 400", status)
+	}
+	// The link must not change when the new URL can't be reported
+	if _, err := h.shareService.GetShare(share.ID); err != nil {
+		t.Errorf("share was rotated anyway: %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestShareDescriptionCreateToAccess(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+
+	app := fiber.New()
+	app.Post("/api/shares", asUser(owner), h.CreateShare)
+	app.Put("/api/shares/:id", asUser(owner), h.UpdateShare)
+	app.Get("/s/:id", h.AccessShare)
+
+	status, body := doRequest(t, app, http.MethodPost, "/api/shares",
+		fmt.Sprintf(`{"share_type":"file","resource_id":%d,"title":"May shoot","description":"Proofs from the May shoot - pick your favorites"}`, file), nil)
+	if status != fiber.StatusCreated {
+		t.Fatalf("create: status %d, body %v", status, body)
+	}
+	shareID := body["share"].(map[string]interface{})["id"].(string)
+
+	status, body = doRequest(t, app, http.MethodGet, "/s/"+shareID, "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("access: status %d, body %v", status, body)
+	}
+	share := body["share"].(map[string]interface{})
+	if share["title"] != "May shoot" || share["description"] != "Proofs from the May shoot - pick your favorites" {
+		t.Errorf("access response: title %v, description %v", share["title"], share["description"])
+	}
+
+	status, _ = doRequest(t, app, http.MethodPut, "/api/shares/"+shareID, `{"description":"Final picks"}`, nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("update: status %d", status)
+	}
+	_, body = doRequest(t, app, http.MethodGet, "/s/"+shareID, "", nil)
+	share = body["share"].(map[string]interface{})
+	if share["title"] != "May shoot" || share["description"] != "Final picks" {
+		t.Errorf("after update: title %v, description %v", share["title"], share["description"])
+	}
+}
+
+func TestShareTextLengthLimits(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+
+	app := fiber.New()
+	app.Post("/api/shares", asUser(owner), h.CreateShare)
+	app.Put("/api/shares/:id", asUser(owner), h.UpdateShare)
+
+	longTitle := strings.Repeat("é", 201)
+	longDescription := strings.Repeat("x", 2001)
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"title too long", fmt.Sprintf(`{"share_type":"file","resource_id":%d,"title":%q}`, file, longTitle), fiber.StatusBadRequest},
+		{"description too long", fmt.Sprintf(`{"share_type":"file","resource_id":%d,"description":%q}`, file, longDescription), fiber.StatusBadRequest},
+		{"title at limit", fmt.Sprintf(`{"share_type":"file","resource_id":%d,"title":%q}`, file, strings.Repeat("é", 200)), fiber.StatusCreated},
+	}
+	var shareID string
+	for _, tc := range cases {
+		status, body := doRequest(t, app, http.MethodPost, "/api/shares", tc.body, nil)
+		if status != tc.want {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.want, body)
+		}
+		if status == fiber.StatusCreated {
+			shareID = body["share"].(map[string]interface{})["id"].(string)
+		}
+	}
+
+	status, _ := doRequest(t, app, http.MethodPut, "/api/shares/"+shareID, fmt.Sprintf(`{"description":%q}`, longDescription), nil)
+	if status != fiber.StatusBadRequest {
+		t.Errorf("update with long description: status %d, want 400", status)
+	}
+}
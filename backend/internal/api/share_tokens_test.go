@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestRevokeShareTokens(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+	owner := seedUser(t, db.DB, "owner", "user")
+	other := seedUser(t, db.DB, "other", "user")
+	admin := seedUser(t, db.DB, "admin", "admin")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+	share, err := h.shareService.CreateShare("file", file, owner.ID, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	// Visitors receive an access token when opening the share
+	access := fiber.New()
+	access.Get("/s/:id", h.AccessShare)
+	_, body := doRequest(t, access, http.MethodGet, "/s/"+share.ID, "", nil)
+	token, _ := body["access_token"].(string)
+	if _, _, err := h.shareService.ValidateAccessToken(token); err != nil {
+		t.Fatalf("visitor token invalid before revocation: %v", err)
+	}
+
+	cases := []struct {
+		name        string
+		user        *models.User
+		path        string
+		wantStatus  int
+		wantRevoked float64
+	}{
+		{"anonymous", nil, "/api/shares/" + share.ID + "/tokens", fiber.StatusUnauthorized, 0},
+		{"missing share", owner, "/api/shares/nope/tokens", fiber.StatusNotFound, 0},
+		{"other user", other, "/api/shares/" + share.ID + "/tokens", fiber.StatusForbidden, 0},
+		{"owner", owner, "/api/shares/" + share.ID + "/tokens", fiber.StatusOK, 1},
+		{"admin", admin, "/api/shares/" + share.ID + "/tokens", fiber.StatusOK, 0},
+	}
+	for _, tc := range cases {
+		app := fiber.New()
+		app.Delete("/api/shares/:id/tokens", asUser(tc.user), h.RevokeShareTokens)
+		status, body := doRequest(t, app, http.MethodDelete, tc.path, "", nil)
+		if status != tc.wantStatus {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.wantStatus, body)
+			continue
+		}
+		if status == fiber.StatusOK && body["revoked"] != tc.wantRevoked {
+			t.Errorf("%s: revoked %v, want %v", tc.name, body["revoked"], tc.wantRevoked)
+		}
+	}
+
+	if _, _, err := h.shareService.ValidateAccessToken(token); !errors.Is(err, services.ErrTokenRevoked) {
+		t.Errorf("visitor token after revocation: err = %v, want ErrTokenRevoked", err)
+	}
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/services"
+)
+
+const (
+	// defaultSignedThumbnailTTL is how long a signed thumbnail URL stays valid by default
+	defaultSignedThumbnailTTL = time.Hour
+	// maxSignedThumbnailTTL bounds the lifetime of a signed thumbnail URL
+	maxSignedThumbnailTTL = 7 * 24 * time.Hour
+)
+
+// CreateSignedThumbnailURL returns a short-lived signed URL serving a file's thumbnail without a session,
+// for embedding on third-party pages
+// POST /api/files/:id/thumbnail-url?size=small&ttl=3600
+func (h *Handler) CreateSignedThumbnailURL(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid file ID"})
+	}
+
+	isServerOwner := user.Role == "server_owner"
+	if !isServerOwner {
+		hasAccess, err := h.permService.CheckFileAccess(user.ID, id, isServerOwner)
+		if err != nil || !hasAccess {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Access denied",
+			})
+		}
+	}
+
+	sizeType := c.Query("size", "small")
+	if _, ok := services.ThumbnailSizes[sizeType]; !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Size must be 'small', 'medium' or 'large'"})
+	}
+
+	ttl := defaultSignedThumbnailTTL
+	if ttlStr := c.Query("ttl"); ttlStr != "" {
+		seconds, err := strconv.Atoi(ttlStr)
+		if err != nil || seconds <= 0 || time.Duration(seconds)*time.Second > maxSignedThumbnailTTL {
+			return c.Status(400).JSON(fiber.Map{
+				"error": fmt.Sprintf("ttl must be between 1 and %d seconds", int(maxSignedThumbnailTTL.Seconds())),
+			})
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+
+	if _, err := h.folderService.ResolveAbsolutePath(id); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "File not found"})
+	}
+
+	expiresAt := time.Now().Add(ttl).Unix()
+	signature, err := h.shareService.SignThumbnail(id, sizeType, expiresAt)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to sign thumbnail URL"})
+	}
+
+	query := url.Values{}
+	query.Set("size", sizeType)
+	query.Set("exp", strconv.FormatInt(expiresAt, 10))
+	query.Set("sig", signature)
+
+	return c.JSON(fiber.Map{
+		"url":        fmt.Sprintf("%s/api/public/thumbnails/%d?%s", services.BasePath(), id, query.Encode()),
+		"expires_at": time.Unix(expiresAt, 0).UTC(),
+	})
+}
+
+// GetSignedThumbnail serves a thumbnail through a signed URL (no session required)
+// GET /api/public/thumbnails/:id?size=small&exp=...&sig=...
+func (h *Handler) GetSignedThumbnail(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid file ID"})
+	}
+
+	sizeType := c.Query("size", "small")
+	signature := c.Query("sig")
+	expiresAt, err := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if err != nil || signature == "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Missing or invalid signature"})
+	}
+
+	if err := h.shareService.VerifyThumbnailSignature(id, sizeType, expiresAt, signature); err != nil {
+		if errors.Is(err, services.ErrSignatureExpired) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Signed URL has expired"})
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Missing or invalid signature"})
+	}
+
+	filePath, err := h.folderService.ResolveAbsolutePath(id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "File not found"})
+	}
+
+	mode, err := h.settings.GetThumbnailMode()
+	if err != nil {
+		mode = services.ThumbnailModeFit
+	}
+
+	thumbPath, err := h.thumbService.GetThumbnail(filePath, id, sizeType, mode)
+	if err != nil {
+		if !errors.Is(err, services.ErrSourceTooLarge) && !errors.Is(err, services.ErrHEICUnsupported) {
+			log.Printf("Error getting signed thumbnail: %v", err)
+		}
+		return h.sendPlaceholderThumbnail(c, id, filePath, sizeType)
+	}
+
+	// Cacheable by embedding pages, but never beyond the URL's expiry
+	if remaining := expiresAt - time.Now().Unix(); remaining > 0 {
+		c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", remaining))
+	}
+
+	if setCacheValidators(c, id, thumbPath) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return sendThumbnail(c, thumbPath)
+}
+
+// GetPublicThumbnail serves a shared file's thumbnail through a share access token,
+// so public share pages can show previews without loading originals
+// GET /api/public/files/:id/thumbnail?token=...&size=small
+func (h *Handler) GetPublicThumbnail(c *fiber.Ctx) error {
+	token := c.Query("token", "")
+	if token == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Access token required",
+		})
+	}
+
+	shareID, _, err := h.shareService.ValidateAccessToken(token)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Invalid or expired access token",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid file ID"})
+	}
+
+	// Verify the file is the shared file or currently in the shared album
+	included, err := h.shareService.ShareIncludesFile(shareID, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to check shared resource"})
+	}
+	if !included {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "File does not match shared resource",
+		})
+	}
+
+	sizeType := c.Query("size", "small")
+	if _, ok := services.ThumbnailSizes[sizeType]; !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Size must be 'small', 'medium' or 'large'"})
+	}
+
+	filePath, err := h.folderService.ResolveAbsolutePath(id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "File not found"})
+	}
+
+	mode, err := h.settings.GetThumbnailMode()
+	if err != nil {
+		mode = services.ThumbnailModeFit
+	}
+
+	thumbPath, err := h.thumbService.GetThumbnail(filePath, id, sizeType, mode)
+	if err != nil {
+		if !errors.Is(err, services.ErrSourceTooLarge) && !errors.Is(err, services.ErrHEICUnsupported) {
+			log.Printf("Error getting public thumbnail: %v", err)
+		}
+		return h.sendPlaceholderThumbnail(c, id, filePath, sizeType)
+	}
+
+	// Tokens can be revoked, so only the visitor's browser may cache the image
+	c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+
+	if setCacheValidators(c, id, thumbPath) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return sendThumbnail(c, thumbPath)
+}
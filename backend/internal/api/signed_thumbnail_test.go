@@ -0,0 +1,90 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+)
+
+func TestSignedThumbnailURL(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	viewer := seedUser(t, db.DB, "viewer", "user")
+	root := t.TempDir()
+	shared := seedFolder(t, db.DB, root, owner.ID)
+	private := seedFolder(t, db.DB, filepath.Join(root, "private"), owner.ID)
+	grantFolder(t, db.DB, viewer.ID, shared, "read")
+	writeTestImage(t, filepath.Join(root, "a.png"), 64, 48)
+	file := seedFile(t, db.DB, shared, "a.png", "image")
+	hidden := seedFile(t, db.DB, private, "b.png", "image")
+
+	app := fiber.New()
+	app.Get("/api/public/thumbnails/:id", h.GetSignedThumbnail)
+	createURL := func(user *models.User, path string) (int, map[string]interface{}) {
+		signer := fiber.New()
+		signer.Post("/api/files/:id/thumbnail-url", asUser(user), h.CreateSignedThumbnailURL)
+		return doRequest(t, signer, http.MethodPost, path, "", nil)
+	}
+
+	creates := []struct {
+		name string
+		user *models.User
+		path string
+		want int
+	}{
+		{"inaccessible file", viewer, fmt.Sprintf("/api/files/%d/thumbnail-url", hidden), fiber.StatusForbidden},
+		{"unknown size", viewer, fmt.Sprintf("/api/files/%d/thumbnail-url?size=huge", file), fiber.StatusBadRequest},
+		{"ttl too long", viewer, fmt.Sprintf("/api/files/%d/thumbnail-url?ttl=9999999", file), fiber.StatusBadRequest},
+		{"missing file", owner, "/api/files/9999/thumbnail-url", fiber.StatusNotFound},
+	}
+	for _, tc := range creates {
+		if status, resp := createURL(tc.user, tc.path); status != tc.want {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.want, resp)
+		}
+	}
+
+	status, resp := createURL(viewer, fmt.Sprintf("/api/files/%d/thumbnail-url?size=medium&ttl=600", file))
+	if status != fiber.StatusOK {
+		t.Fatalf("create url: status %d (%v)", status, resp)
+	}
+	signed := resp["url"].(string)
+
+	// The signed URL works without a session
+	res := sendRequest(t, app, http.MethodGet, signed, "", nil)
+	res.Body.Close()
+	if res.StatusCode != fiber.StatusOK || res.Header.Get(fiber.HeaderContentType) != "image/jpeg" {
+		t.Fatalf("signed url: status %d, content type %q", res.StatusCode, res.Header.Get(fiber.HeaderContentType))
+	}
+	if cc := res.Header.Get(fiber.HeaderCacheControl); !strings.HasPrefix(cc, "public, max-age=") {
+		t.Errorf("cache control %q", cc)
+	}
+
+	past := time.Now().Add(-time.Minute).Unix()
+	sig, err := h.shareService.SignThumbnail(file, "small", past)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := fmt.Sprintf("/api/public/thumbnails/%d?size=small&exp=%d&sig=%s", file, past, sig)
+
+	rejected := map[string]string{
+		"other file":    strings.Replace(signed, fmt.Sprintf("/%d?", file), fmt.Sprintf("/%d?", hidden), 1),
+		"other size":    strings.Replace(signed, "size=medium", "size=large", 1),
+		"no signature":  signed[:strings.Index(signed, "&sig=")],
+		"bad signature": strings.Replace(signed, "sig=", "sig=x", 1),
+		"expired":       expired,
+	}
+	for name, path := range rejected {
+		if status, resp := doRequest(t, app, http.MethodGet, path, "", nil); status != fiber.StatusForbidden {
+			t.Errorf("%s: status %d, want 403 (%v)", name, status, resp)
+		}
+	}
+}
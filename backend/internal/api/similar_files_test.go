@@ -0,0 +1,79 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+)
+
+// seedHashedPhoto inserts an image with a precomputed perceptual hash
+func seedHashedPhoto(t *testing.T, db *sql.DB, folderID int64, name string, phash int64) int64 {
+	t.Helper()
+	id := seedFile(t, db, folderID, name, "image")
+	mustExec(t, db, "INSERT INTO photo_metadata (file_id, phash) VALUES (?, ?)", id, phash)
+	return id
+}
+
+func TestGetSimilarFiles(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	viewer := seedUser(t, db.DB, "viewer", "user")
+	shared := seedFolder(t, db.DB, "/photos/shared", owner.ID)
+	private := seedFolder(t, db.DB, "/photos/private", owner.ID)
+	grantFolder(t, db.DB, viewer.ID, shared, "read")
+
+	ref := seedHashedPhoto(t, db.DB, shared, "burst1.jpg", 0x0F0F)
+	seedHashedPhoto(t, db.DB, shared, "burst2.jpg", 0x0F0E)  // distance 1
+	seedHashedPhoto(t, db.DB, private, "burst3.jpg", 0x0F00) // distance 4, not visible to viewer
+	seedHashedPhoto(t, db.DB, shared, "other.jpg", -0x0F10)  // far away
+	noHash := seedFile(t, db.DB, shared, "clip.mp4", "video")
+	hidden := seedHashedPhoto(t, db.DB, private, "secret.jpg", 0x0F0F)
+
+	cases := []struct {
+		name   string
+		user   *models.User
+		path   string
+		status int
+		want   []string
+	}{
+		{"owner sees all near matches", owner, fmt.Sprintf("/api/files/%d/similar", ref), fiber.StatusOK,
+			[]string{"secret.jpg", "burst2.jpg", "burst3.jpg"}},
+		{"viewer is scoped to granted folders", viewer, fmt.Sprintf("/api/files/%d/similar", ref), fiber.StatusOK,
+			[]string{"burst2.jpg"}},
+		{"tight threshold", owner, fmt.Sprintf("/api/files/%d/similar?max_distance=1", ref), fiber.StatusOK,
+			[]string{"secret.jpg", "burst2.jpg"}},
+		{"threshold too large", owner, fmt.Sprintf("/api/files/%d/similar?max_distance=33", ref), fiber.StatusBadRequest, nil},
+		{"no hash", owner, fmt.Sprintf("/api/files/%d/similar", noHash), fiber.StatusNotFound, nil},
+		{"no access", viewer, fmt.Sprintf("/api/files/%d/similar", hidden), fiber.StatusForbidden, nil},
+		{"bad id", owner, "/api/files/abc/similar", fiber.StatusBadRequest, nil},
+		{"anonymous", nil, fmt.Sprintf("/api/files/%d/similar", ref), fiber.StatusUnauthorized, nil},
+	}
+	for _, tc := range cases {
+		app := fiber.New()
+		app.Get("/api/files/:id/similar", asUser(tc.user), h.GetSimilarFiles)
+
+		status, resp := doRequest(t, app, http.MethodGet, tc.path, "", nil)
+		if status != tc.status {
+			t.Errorf("%s: status %d, want %d (%v)", tc.name, status, tc.status, resp)
+			continue
+		}
+		if tc.want == nil {
+			continue
+		}
+		files, _ := resp["files"].([]interface{})
+		var got []string
+		for _, f := range files {
+			got = append(got, f.(map[string]interface{})["filename"].(string))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestThumbnailSourceTooLarge(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	// 2000 pixels: the 40x30 image fits, the 400x300 one does not
+	h.thumbService.SetMaxSourcePixels(2000)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	writeTestImage(t, filepath.Join(root, "small.png"), 40, 30)
+	writeTestImage(t, filepath.Join(root, "large.png"), 400, 300)
+	small := seedFile(t, db.DB, folder, "small.png", "image")
+	large := seedFile(t, db.DB, folder, "large.png", "image")
+
+	app := fiber.New()
+	app.Get("/api/files/:id/thumbnail", asUser(owner), h.GetFileThumbnail)
+
+	// Images over the cap are never decoded; the grid gets a placeholder instead
+	for id, placeholder := range map[int64]bool{small: false, large: true} {
+		resp := sendRequest(t, app, http.MethodGet, fmt.Sprintf("/api/files/%d/thumbnail", id), "", nil)
+		resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("file %d: status %d, want 200", id, resp.StatusCode)
+		}
+		if got := resp.Header.Get("X-Thumbnail-Placeholder") != ""; got != placeholder {
+			t.Errorf("file %d: placeholder %t, want %t", id, got, placeholder)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestGetStorageByFolder(t *testing.T) {
+	db := newTestDB(t)
+	h := NewAdminHandler(services.NewAuthService(db.DB), services.NewShareService(db.DB, nil), services.NewFolderService(db.DB))
+
+	admin := seedUser(t, db.DB, "admin", "admin")
+	small := seedFolder(t, db.DB, "/small", admin.ID)
+	large := seedFolder(t, db.DB, "/large", admin.ID)
+	files := []struct {
+		folder int64
+		path   string
+		size   int64
+	}{
+		{small, "a.jpg", 100},
+		{small, "b.jpg", 200},
+		{large, "c.mp4", 5000},
+	}
+	for _, f := range files {
+		id := seedFile(t, db.DB, f.folder, f.path, "image")
+		mustExec(t, db.DB, "UPDATE files SET size = ? WHERE id = ?", f.size, id)
+	}
+
+	app := fiber.New()
+	app.Get("/api/admin/storage/by-folder", asUser(admin), h.GetStorageByFolder)
+	status, body := doRequest(t, app, http.MethodGet, "/api/admin/storage/by-folder", "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, body %v", status, body)
+	}
+
+	folders := body["folders"].([]interface{})
+	if len(folders) != 2 {
+		t.Fatalf("folders = %v, want 2", folders)
+	}
+	first, second := folders[0].(map[string]interface{}), folders[1].(map[string]interface{})
+	if first["folder_id"] != float64(large) || first["total_bytes"] != float64(5000) || first["file_count"] != float64(1) {
+		t.Errorf("largest folder = %v, want /large with 5000 bytes", first)
+	}
+	if second["folder_id"] != float64(small) || second["total_bytes"] != float64(300) || second["file_count"] != float64(2) {
+		t.Errorf("second folder = %v, want /small with 300 bytes", second)
+	}
+	if body["total_bytes"] != float64(5300) || body["file_count"] != float64(3) {
+		t.Errorf("totals = %v files, %v bytes; want 3, 5300", body["file_count"], body["total_bytes"])
+	}
+}
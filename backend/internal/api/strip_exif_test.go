@@ -0,0 +1,142 @@
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/pkg/exif"
+)
+
+// writeGPSJPEG writes a small JPEG whose EXIF block places it at 52.5N 13.4E
+func writeGPSJPEG(t *testing.T, path string) {
+	t.Helper()
+	var tiff bytes.Buffer
+	be := binary.BigEndian
+	for _, v := range []interface{}{
+		[]byte("MM\x00\x2a"), uint32(8),
+		uint16(1), uint16(0x8825), uint16(4), uint32(1), uint32(26), uint32(0), // IFD0 -> GPS IFD
+		uint16(4), // GPS IFD
+		uint16(1), uint16(2), uint32(2), uint32('N') << 24,
+		uint16(2), uint16(5), uint32(3), uint32(80),
+		uint16(3), uint16(2), uint32(2), uint32('E') << 24,
+		uint16(4), uint16(5), uint32(3), uint32(104),
+		uint32(0),
+		[]uint32{52, 1, 30, 1, 0, 1, 13, 1, 24, 1, 0, 1},
+	} {
+		binary.Write(&tiff, be, v)
+	}
+
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	be.PutUint16(segment[2:], uint16(len(payload)+2))
+
+	out := append([]byte{}, img.Bytes()[:2]...)
+	out = append(out, segment...)
+	out = append(out, payload...)
+	out = append(out, img.Bytes()[2:]...)
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPublicDownloadStripsEXIF(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	src := filepath.Join(root, "gps.jpg")
+	writeGPSJPEG(t, src)
+	file := seedFile(t, db.DB, folder, "gps.jpg", "image")
+	original, _ := os.ReadFile(src)
+
+	if data, err := exif.ExtractEXIF(src); err != nil || data.Latitude == nil {
+		t.Fatalf("fixture has no GPS position (err %v)", err)
+	}
+
+	app := fiber.New()
+	app.Get("/api/public/files/:id/download", h.DownloadPublicFile)
+
+	download := func(stripEXIF bool) ([]byte, string) {
+		t.Helper()
+		share, err := h.shareService.CreateShare("file", file, owner.ID, "", "", "public", "", false, stripEXIF, nil, nil)
+		if err != nil {
+			t.Fatalf("create share: %v", err)
+		}
+		token, err := h.shareService.GenerateAccessToken(share.ID)
+		if err != nil {
+			t.Fatalf("access token: %v", err)
+		}
+		resp := sendRequest(t, app, http.MethodGet, fmt.Sprintf("/api/public/files/%d/download?token=%s", file, token), "", nil)
+		defer resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("strip_exif=%v: status %d", stripEXIF, resp.StatusCode)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return body, resp.Header.Get("ETag")
+	}
+
+	plain, plainETag := download(false)
+	if !bytes.Equal(plain, original) {
+		t.Error("share without strip_exif should serve the original bytes")
+	}
+
+	stripped, strippedETag := download(true)
+	if bytes.Contains(stripped, []byte("Exif\x00\x00")) {
+		t.Error("stripped download still carries an EXIF block")
+	}
+	out := filepath.Join(t.TempDir(), "served.jpg")
+	os.WriteFile(out, stripped, 0644)
+	if data, err := exif.ExtractEXIF(out); err == nil && data.Latitude != nil {
+		t.Error("stripped download still has a GPS position")
+	}
+	if strippedETag == "" || strippedETag == plainETag {
+		t.Errorf("stripped ETag %q should differ from original ETag %q", strippedETag, plainETag)
+	}
+
+	if now, _ := os.ReadFile(src); !bytes.Equal(now, original) {
+		t.Error("stored original was modified")
+	}
+}
+
+func TestPublicDownloadStripEXIFSkipsVideos(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "user")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	content := []byte("\x00\x00\x00\x18ftypmp42 not really a movie")
+	os.WriteFile(filepath.Join(root, "clip.mp4"), content, 0644)
+	file := seedFile(t, db.DB, folder, "clip.mp4", "video")
+
+	share, err := h.shareService.CreateShare("file", file, owner.ID, "", "", "public", "", false, true, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	token, _ := h.shareService.GenerateAccessToken(share.ID)
+
+	app := fiber.New()
+	app.Get("/api/public/files/:id/download", h.DownloadPublicFile)
+	resp := sendRequest(t, app, http.MethodGet, fmt.Sprintf("/api/public/files/%d/download?token=%s", file, token), "", nil)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusOK || !bytes.Equal(body, content) {
+		t.Errorf("video: status %d, body %q, want the original", resp.StatusCode, body)
+	}
+}
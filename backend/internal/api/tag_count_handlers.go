@@ -0,0 +1,73 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+)
+
+// TagCount is a tag with the number of files carrying it that the caller can access
+type TagCount struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	Count int    `json:"count"`
+}
+
+// GetTagCounts returns every tag with the number of the caller's accessible files
+// carrying it, most used first (for tag clouds)
+// GET /api/tags/counts
+func (h *Handler) GetTagCounts(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	scope := ""
+	args := []interface{}{}
+	if user.Role != "server_owner" {
+		scope = ` AND ft.file_id IN (
+			SELECT ffm.file_id FROM file_folder_mappings ffm
+			JOIN permission_group_folders pgf ON ffm.folder_id = pgf.folder_id
+			JOIN permission_group_permissions pgp ON pgf.permission_group_id = pgp.permission_group_id
+			WHERE pgp.user_id = ?)`
+		args = append(args, user.ID)
+	}
+
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT t.id, t.name, COALESCE(t.color, ''), COUNT(ft.file_id)
+		FROM tags t
+		LEFT JOIN file_tags ft ON ft.tag_id = t.id`+scope+`
+		GROUP BY t.id
+		ORDER BY 4 DESC, t.name
+	`, args...)
+	if err != nil {
+		if isQueryTimeout(err) {
+			return queryTimeoutError(c)
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	defer rows.Close()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var t TagCount
+		if err := rows.Scan(&t.ID, &t.Name, &t.Color, &t.Count); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		tags = append(tags, t)
+	}
+	if err := rows.Err(); err != nil {
+		if isQueryTimeout(err) {
+			return queryTimeoutError(c)
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"tags": tags})
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+)
+
+func TestGetTagCounts(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	alice := seedUser(t, db.DB, "alice", "user")
+	bob := seedUser(t, db.DB, "bob", "user")
+	trips := seedFolder(t, db.DB, "/photos/trips", owner.ID)
+	family := seedFolder(t, db.DB, "/photos/family", owner.ID)
+	grantFolder(t, db.DB, alice.ID, trips, "read")
+	grantFolder(t, db.DB, bob.ID, trips, "read")
+	grantFolder(t, db.DB, bob.ID, family, "read")
+
+	beach1 := seedFile(t, db.DB, trips, "beach1.jpg", "image")
+	beach2 := seedFile(t, db.DB, family, "beach2.jpg", "image")
+	both := seedFile(t, db.DB, trips, "both.jpg", "image")
+	mustExec(t, db.DB, "INSERT INTO file_folder_mappings (file_id, folder_id, relative_path) VALUES (?, ?, 'both.jpg')", both, family)
+	mustExec(t, db.DB, "INSERT INTO tags (id, name) VALUES (1, 'beach'), (2, 'kids'), (3, 'unused')")
+	mustExec(t, db.DB, "INSERT INTO file_tags (file_id, tag_id) VALUES (?, 1), (?, 1), (?, 1), (?, 2)", beach1, beach2, both, beach2)
+
+	cases := []struct {
+		name string
+		user *models.User
+		want map[string]float64
+	}{
+		{"owner sees every file", owner, map[string]float64{"beach": 3, "kids": 1, "unused": 0}},
+		{"trips only", alice, map[string]float64{"beach": 2, "kids": 0, "unused": 0}},
+		{"file in two granted folders counted once", bob, map[string]float64{"beach": 3, "kids": 1, "unused": 0}},
+	}
+	for _, tc := range cases {
+		app := fiber.New()
+		app.Get("/api/tags/counts", asUser(tc.user), h.GetTagCounts)
+
+		status, resp := doRequest(t, app, http.MethodGet, "/api/tags/counts", "", nil)
+		if status != fiber.StatusOK {
+			t.Errorf("%s: status %d (%v)", tc.name, status, resp)
+			continue
+		}
+		tags := resp["tags"].([]interface{})
+		if len(tags) != len(tc.want) {
+			t.Errorf("%s: got %d tags, want %d", tc.name, len(tags), len(tc.want))
+		}
+		for _, raw := range tags {
+			tag := raw.(map[string]interface{})
+			if want := tc.want[tag["name"].(string)]; tag["count"] != want {
+				t.Errorf("%s: %s counted %v, want %v", tc.name, tag["name"], tag["count"], want)
+			}
+		}
+		if first := tags[0].(map[string]interface{}); first["name"] != "beach" {
+			t.Errorf("%s: most used tag %v, want beach first", tc.name, first["name"])
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"image"
+	"image/color"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/database"
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+// newTestDB opens a fresh, fully migrated database in a temporary directory
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.Initialize(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("initialize database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// mustExec runs a statement and fails the test on error
+func mustExec(t *testing.T, db *sql.DB, query string, args ...interface{}) sql.Result {
+	t.Helper()
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		t.Fatalf("exec %q: %v", query, err)
+	}
+	return res
+}
+
+// seedUser inserts an enabled user with the given role
+func seedUser(t *testing.T, db *sql.DB, username, role string) *models.User {
+	t.Helper()
+	res := mustExec(t, db, "INSERT INTO users (username, password_hash, email, role, enabled) VALUES (?, 'x', '', ?, 1)", username, role)
+	id, err := res.LastInsertId()
+	if err != nil {
+		t.Fatalf("last insert id: %v", err)
+	}
+	return &models.User{ID: id, Username: username, Role: role, Enabled: true}
+}
+
+// seedFolder inserts an enabled folder
+func seedFolder(t *testing.T, db *sql.DB, path string, createdBy int64) int64 {
+	t.Helper()
+	res := mustExec(t, db, "INSERT INTO folders (name, absolute_path, enabled, created_by) VALUES (?, ?, 1, ?)", filepath.Base(path), path, createdBy)
+	id, _ := res.LastInsertId()
+	return id
+}
+
+// seedFile inserts a file and maps it into a folder
+func seedFile(t *testing.T, db *sql.DB, folderID int64, relativePath, fileType string) int64 {
+	t.Helper()
+	res := mustExec(t, db, "INSERT INTO files (filename, file_type, size) VALUES (?, ?, 100)", filepath.Base(relativePath), fileType)
+	id, _ := res.LastInsertId()
+	mustExec(t, db, "INSERT INTO file_folder_mappings (file_id, folder_id, relative_path) VALUES (?, ?, ?)", id, folderID, relativePath)
+	return id
+}
+
+// grantFolder gives a user access to a folder through a new permission group
+func grantFolder(t *testing.T, db *sql.DB, userID, folderID int64, permission string) int64 {
+	t.Helper()
+	res := mustExec(t, db, "INSERT INTO permission_groups (name, created_by) VALUES ('group', ?)", userID)
+	groupID, _ := res.LastInsertId()
+	mustExec(t, db, "INSERT INTO permission_group_folders (permission_group_id, folder_id) VALUES (?, ?)", groupID, folderID)
+	mustExec(t, db, "INSERT INTO permission_group_permissions (permission_group_id, user_id, permission) VALUES (?, ?, ?)", groupID, userID, permission)
+	return groupID
+}
+
+// newTestHandler builds a file Handler backed by db, with thumbnails in a temporary directory
+func newTestHandler(t *testing.T, db *database.DB) *Handler {
+	t.Helper()
+	folderService := services.NewFolderService(db.DB)
+	settings := services.NewSettingsService(db.DB)
+	thumbsDir := t.TempDir()
+	return NewHandler(db,
+		services.NewFileScanner(db, folderService, thumbsDir, nil),
+		services.NewThumbnailService(thumbsDir),
+		services.NewFileValidatorService(db.DB, folderService, settings, nil),
+		folderService,
+		services.NewPermissionGroupService(db.DB),
+		services.NewFileStatsService(db.DB),
+		services.NewPhotoMetadataService(db.DB),
+		settings,
+		services.NewShareService(db.DB, nil),
+		services.NewAlbumService(db.DB),
+		services.NewJobQueue(db.DB, nil),
+	)
+}
+
+// writeTestImage saves a width x height gradient image; the format follows the extension
+func writeTestImage(t *testing.T, path string, width, height int) {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xff})
+		}
+	}
+	if err := imaging.Save(img, path); err != nil {
+		t.Fatalf("save test image: %v", err)
+	}
+}
+
+// asUser returns a middleware that authenticates every request as user
+func asUser(user *models.User) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if user != nil {
+			c.Locals(middleware.UserContextKey, user)
+		}
+		return c.Next()
+	}
+}
+
+// doRequest sends a request to app and returns the status code and the decoded JSON body
+func doRequest(t *testing.T, app *fiber.App, method, path, body string, headers map[string]string) (int, map[string]interface{}) {
+	t.Helper()
+	resp := sendRequest(t, app, method, path, body, headers)
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	result := map[string]interface{}{}
+	if len(raw) > 0 && strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		if err := json.Unmarshal(raw, &result); err != nil {
+			t.Fatalf("decode %s %s response %q: %v", method, path, raw, err)
+		}
+	}
+	return resp.StatusCode, result
+}
+
+// sendRequest sends a request to app; a non-empty body is sent as JSON
+func sendRequest(t *testing.T, app *fiber.App, method, path, body string, headers map[string]string) *http.Response {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	return resp
+}
+
+// newTestShareHandler builds a ShareHandler backed by db, with the share domain configured
+func newTestShareHandler(t *testing.T, db *database.DB) *ShareHandler {
+	t.Helper()
+	mustExec(t, db.DB, "INSERT INTO domain_config (protocol, domain, port) VALUES ('https', 'photos.example.com', '443')")
+	folderService := services.NewFolderService(db.DB)
+	settings := services.NewSettingsService(db.DB)
+	return NewShareHandler(
+		services.NewShareService(db.DB, nil),
+		settings,
+		services.NewDomainConfigService(db),
+		db,
+		services.NewFileValidatorService(db.DB, folderService, settings, nil),
+		services.NewFileStatsService(db.DB),
+		services.NewMetadataStripper(t.TempDir()),
+		services.NewAlbumService(db.DB),
+	)
+}
+
+// waitForJob polls a job until it is done or failed and returns it
+func waitForJob(t *testing.T, jobs *services.JobQueue, id int64) *models.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := jobs.GetJob(id)
+		if err != nil {
+			t.Fatalf("get job %d: %v", id, err)
+		}
+		if job.Status == services.JobStatusDone || job.Status == services.JobStatusFailed {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %d still %s", id, job.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
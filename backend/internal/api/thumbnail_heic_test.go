@@ -0,0 +1,32 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestHEICThumbnailFallsBackToPlaceholder(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	os.WriteFile(filepath.Join(root, "IMG_0001.HEIC"), []byte("ftypheic"), 0644)
+	id := seedFile(t, db.DB, folder, "IMG_0001.HEIC", "image")
+
+	app := fiber.New()
+	app.Get("/api/files/:id/thumbnail", asUser(owner), h.GetFileThumbnail)
+	resp := sendRequest(t, app, http.MethodGet, fmt.Sprintf("/api/files/%d/thumbnail", id), "", nil)
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status %d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("X-Thumbnail-Placeholder"); got != "photo" {
+		t.Errorf("placeholder %q, want photo", got)
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestInvalidateThumbnail(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	owner := seedUser(t, db.DB, "owner", "user")
+	stranger := seedUser(t, db.DB, "stranger", "user")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	grantFolder(t, db.DB, owner.ID, folder, "read")
+	src := filepath.Join(root, "a.png")
+	writeTestImage(t, src, 80, 60)
+	id := seedFile(t, db.DB, folder, "a.png", "image")
+
+	app := fiber.New()
+	app.Get("/api/files/:id/thumbnail", asUser(owner), h.GetFileThumbnail)
+	app.Post("/api/files/:id/thumbnail/invalidate", asUser(owner), h.InvalidateThumbnail)
+	thumbURL := fmt.Sprintf("/api/files/%d/thumbnail", id)
+	invalidateURL := thumbURL + "/invalidate"
+
+	// A corrupt cached thumbnail keeps being served until it is invalidated
+	thumbPath, err := h.thumbService.GetThumbnail(src, id, "small", services.ThumbnailModeFit)
+	if err != nil {
+		t.Fatalf("generate thumbnail: %v", err)
+	}
+	if err := os.WriteFile(thumbPath, []byte("truncated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	status, body := doRequest(t, app, http.MethodPost, invalidateURL+"?size=small", "", nil)
+	if status != fiber.StatusOK || body["removed"].(float64) < 1 {
+		t.Fatalf("invalidate: status %d (%v), want at least one thumbnail removed", status, body)
+	}
+	if _, err := os.Stat(thumbPath); !os.IsNotExist(err) {
+		t.Errorf("cached thumbnail still on disk (%v)", err)
+	}
+
+	resp := sendRequest(t, app, http.MethodGet, thumbURL, "", nil)
+	_, _, err = image.Decode(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get("X-Thumbnail-Placeholder") != "" {
+		t.Fatalf("refetch: status %d, placeholder %q", resp.StatusCode, resp.Header.Get("X-Thumbnail-Placeholder"))
+	}
+	if err != nil {
+		t.Errorf("regenerated thumbnail does not decode: %v", err)
+	}
+	if _, err := os.Stat(thumbPath); err != nil {
+		t.Errorf("thumbnail was not regenerated: %v", err)
+	}
+
+	strangerApp := fiber.New()
+	strangerApp.Post("/api/files/:id/thumbnail/invalidate", asUser(stranger), h.InvalidateThumbnail)
+	for _, tc := range []struct {
+		name   string
+		app    *fiber.App
+		path   string
+		status int
+	}{
+		{"stranger", strangerApp, invalidateURL, fiber.StatusForbidden},
+		{"unknown size", app, invalidateURL + "?size=huge", fiber.StatusBadRequest},
+		{"bad id", app, "/api/files/abc/thumbnail/invalidate", fiber.StatusBadRequest},
+	} {
+		if status, body := doRequest(t, tc.app, http.MethodPost, tc.path, "", nil); status != tc.status {
+			t.Errorf("%s: status %d (%v), want %d", tc.name, status, body, tc.status)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestThumbnailModeParameter(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	settings := services.NewSettingsService(db.DB)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	writeTestImage(t, filepath.Join(root, "wide.png"), 600, 300)
+	file := seedFile(t, db.DB, folder, "wide.png", "image")
+
+	app := fiber.New()
+	app.Get("/api/files/:id/thumbnail", asUser(owner), h.GetFileThumbnail)
+
+	thumbnailSize := func(query string) (int, int, int) {
+		t.Helper()
+		resp := sendRequest(t, app, http.MethodGet, fmt.Sprintf("/api/files/%d/thumbnail%s", file, query), "", nil)
+		defer resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK {
+			return resp.StatusCode, 0, 0
+		}
+		img, err := imaging.Decode(resp.Body)
+		if err != nil {
+			t.Fatalf("%s: decode thumbnail: %v", query, err)
+		}
+		return resp.StatusCode, img.Bounds().Dx(), img.Bounds().Dy()
+	}
+
+	cases := []struct {
+		name          string
+		setting       string
+		query         string
+		status        int
+		width, height int
+	}{
+		{"default fit", "", "", fiber.StatusOK, 300, 150},
+		{"explicit cover", "", "?mode=cover", fiber.StatusOK, 300, 300},
+		{"setting cover", "cover", "", fiber.StatusOK, 300, 300},
+		{"query overrides setting", "cover", "?mode=fit", fiber.StatusOK, 300, 150},
+		{"invalid mode", "", "?mode=stretch", fiber.StatusBadRequest, 0, 0},
+	}
+	for _, tc := range cases {
+		if err := settings.SetSetting("thumbnail_mode", tc.setting); err != nil {
+			t.Fatalf("set mode: %v", err)
+		}
+		status, width, height := thumbnailSize(tc.query)
+		if status != tc.status || width != tc.width || height != tc.height {
+			t.Errorf("%s: got %d %dx%d, want %d %dx%d", tc.name, status, width, height, tc.status, tc.width, tc.height)
+		}
+	}
+}
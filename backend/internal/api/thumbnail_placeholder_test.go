@@ -0,0 +1,49 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestThumbnailPlaceholder(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+
+	writeTestImage(t, filepath.Join(root, "good.png"), 40, 30)
+	os.WriteFile(filepath.Join(root, "IMG_0001.CR2"), []byte("not decodable"), 0644)
+	os.WriteFile(filepath.Join(root, "broken.jpg"), []byte("not a jpeg"), 0644)
+
+	cases := []struct {
+		name, path, fileType string
+		wantPlaceholder      string
+	}{
+		{"decodable image", "good.png", "image", ""},
+		{"raw", "IMG_0001.CR2", "image", "raw"},
+		{"corrupt image", "broken.jpg", "image", "photo"},
+	}
+
+	app := fiber.New()
+	app.Get("/api/files/:id/thumbnail", asUser(owner), h.GetFileThumbnail)
+	for _, tc := range cases {
+		id := seedFile(t, db.DB, folder, tc.path, tc.fileType)
+		resp := sendRequest(t, app, http.MethodGet, fmt.Sprintf("/api/files/%d/thumbnail", id), "", nil)
+		resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("%s: status %d, want 200", tc.name, resp.StatusCode)
+		}
+		if got := resp.Header.Get("X-Thumbnail-Placeholder"); got != tc.wantPlaceholder {
+			t.Errorf("%s: placeholder %q, want %q", tc.name, got, tc.wantPlaceholder)
+		}
+		if tc.wantPlaceholder != "" && resp.Header.Get(fiber.HeaderCacheControl) != "no-cache" {
+			t.Errorf("%s: placeholder is cacheable (%q)", tc.name, resp.Header.Get(fiber.HeaderCacheControl))
+		}
+	}
+}
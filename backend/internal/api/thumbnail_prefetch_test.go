@@ -0,0 +1,51 @@
+package api
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestPrefetchThumbnails(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+
+	root := t.TempDir()
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	viewer := seedUser(t, db.DB, "viewer", "user")
+	shared := seedFolder(t, db.DB, root, owner.ID)
+	private := seedFolder(t, db.DB, filepath.Join(root, "private"), owner.ID)
+	grantFolder(t, db.DB, viewer.ID, shared, "read")
+
+	writeTestImage(t, filepath.Join(root, "shared.png"), 20, 20)
+	visible := seedFile(t, db.DB, shared, "shared.png", "image")
+	hidden := seedFile(t, db.DB, private, "private.png", "image")
+
+	app := fiber.New()
+	app.Post("/api/files/thumbnails/prefetch", asUser(viewer), h.PrefetchThumbnails)
+
+	body := fmt.Sprintf(`{"file_ids":[%d,%d],"size":"medium"}`, visible, hidden)
+	status, resp := doRequest(t, app, "POST", "/api/files/thumbnails/prefetch", body, nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d body %v", status, resp)
+	}
+	ready := resp["ready"].([]interface{})
+	failed := resp["failed"].([]interface{})
+	if len(ready) != 1 || ready[0] != float64(visible) {
+		t.Errorf("ready = %v, want [%d]", ready, visible)
+	}
+	// Files the user can't access are reported as failed, not generated
+	if len(failed) != 1 || failed[0] != float64(hidden) {
+		t.Errorf("failed = %v, want [%d]", failed, hidden)
+	}
+
+	tooMany := `{"file_ids":[` + strings.Repeat("1,", maxPrefetchFiles) + `1]}`
+	for _, bad := range []string{`{"file_ids":[]}`, `{"file_ids":[1],"size":"huge"}`, `{"file_ids":[1],"mode":"stretch"}`, tooMany} {
+		if status, _ := doRequest(t, app, "POST", "/api/files/thumbnails/prefetch", bad, nil); status != fiber.StatusBadRequest {
+			t.Errorf("%.40s: status %d, want 400", bad, status)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestFileThumbnailFollowsSourceEdits(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	h.thumbService.SetContentHashes(services.NewContentHashService(db.DB))
+	h.thumbService.SetRegenerateOnChange(true)
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, owner.ID)
+	src := filepath.Join(root, "a.png")
+	file := seedFile(t, db.DB, folder, "a.png", "image")
+
+	app := fiber.New()
+	app.Get("/api/files/:id/thumbnail", asUser(owner), h.GetFileThumbnail)
+
+	// thumbnailSize fetches the thumbnail and returns its dimensions
+	thumbnailSize := func() image.Point {
+		t.Helper()
+		resp := sendRequest(t, app, http.MethodGet, fmt.Sprintf("/api/files/%d/thumbnail", file), "", nil)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("status %d, body %s", resp.StatusCode, data)
+		}
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("decode thumbnail: %v", err)
+		}
+		return image.Pt(config.Width, config.Height)
+	}
+
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	writeTestImage(t, src, 80, 40)
+	os.Chtimes(src, modTime, modTime)
+	if size := thumbnailSize(); size.X <= size.Y {
+		t.Fatalf("first thumbnail %v, want landscape", size)
+	}
+
+	writeTestImage(t, src, 40, 80)
+	os.Chtimes(src, modTime.Add(time.Hour), modTime.Add(time.Hour))
+	if size := thumbnailSize(); size.X >= size.Y {
+		t.Errorf("thumbnail after the edit %v, want portrait", size)
+	}
+}
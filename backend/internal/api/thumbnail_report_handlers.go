@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/middleware"
+	"awesome-sharing/internal/services"
+)
+
+const (
+	// defaultMissingThumbnailsLimit is the number of missing files listed by default
+	defaultMissingThumbnailsLimit = 500
+	// maxMissingThumbnailsLimit bounds the number of missing files listed in one response
+	maxMissingThumbnailsLimit = 5000
+)
+
+// missingThumbnail is an image file without a cached thumbnail
+type missingThumbnail struct {
+	FileID   int64  `json:"file_id"`
+	Filename string `json:"filename"`
+	Path     string `json:"path"`
+}
+
+// missingThumbnailsReport is the result of a missing thumbnails job
+type missingThumbnailsReport struct {
+	Size         string             `json:"size"`
+	Mode         string             `json:"mode"`
+	Checked      int                `json:"checked"`
+	MissingCount int                `json:"missing_count"`
+	Missing      []missingThumbnail `json:"missing"`
+	Truncated    bool               `json:"truncated"`
+}
+
+// ListMissingThumbnails starts a background job reporting image files that have no cached
+// thumbnail of a size (admin only). Every image is checked on disk; at most limit of them
+// are listed. Poll GET /api/jobs/:id for the report.
+// GET /api/admin/thumbnails/missing?size=small&mode=fit&limit=500
+func (h *Handler) ListMissingThumbnails(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	sizeType := c.Query("size", "small")
+	if _, ok := services.ThumbnailSizes[sizeType]; !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Size must be 'small', 'medium' or 'large'"})
+	}
+
+	mode, err := h.thumbnailMode(c.Query("mode"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	limit := c.QueryInt("limit", defaultMissingThumbnailsLimit)
+	if limit < 1 || limit > maxMissingThumbnailsLimit {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "limit must be between 1 and " + strconv.Itoa(maxMissingThumbnailsLimit),
+		})
+	}
+
+	job, err := h.jobs.Enqueue(services.JobTypeMissingThumbnails, user.ID,
+		func(ctx context.Context, progress func(percent int)) (interface{}, error) {
+			return h.findMissingThumbnails(ctx, sizeType, mode, limit, progress)
+		})
+	if err != nil {
+		return jobQueueError(c, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Missing thumbnails report started",
+		"job_id":  job.ID,
+	})
+}
+
+// findMissingThumbnails checks every image file for a cached thumbnail of a size and mode
+func (h *Handler) findMissingThumbnails(ctx context.Context, sizeType, mode string, limit int, progress func(percent int)) (*missingThumbnailsReport, error) {
+	var total int
+	if err := h.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM files
+		WHERE file_type = 'image' AND (is_thumbnail IS NULL OR is_thumbnail = 0)
+		  AND id IN (SELECT file_id FROM file_folder_mappings)
+	`).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	// Same mapping ResolveAbsolutePath would pick: the file's first folder
+	rows, err := h.db.QueryContext(ctx, `
+		SELECT f.id, f.filename, f.content_hash, fo.absolute_path, ffm.relative_path
+		FROM files f
+		INNER JOIN file_folder_mappings ffm ON f.id = ffm.file_id
+		INNER JOIN folders fo ON ffm.folder_id = fo.id
+		WHERE f.file_type = 'image'
+		  AND (f.is_thumbnail IS NULL OR f.is_thumbnail = 0)
+		  AND ffm.folder_id = (SELECT MIN(folder_id) FROM file_folder_mappings WHERE file_id = f.id)
+		ORDER BY f.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := &missingThumbnailsReport{Size: sizeType, Mode: mode, Missing: []missingThumbnail{}}
+	for rows.Next() {
+		var fileID int64
+		var filename, folderPath, relativePath string
+		var contentHash sql.NullString
+		if err := rows.Scan(&fileID, &filename, &contentHash, &folderPath, &relativePath); err != nil {
+			return nil, err
+		}
+		report.Checked++
+		if total > 0 {
+			progress(report.Checked * 100 / total)
+		}
+
+		filePath := filepath.Join(folderPath, relativePath)
+		if h.thumbService.HasThumbnail(filePath, fileID, contentHash.String, sizeType, mode) {
+			continue
+		}
+
+		report.MissingCount++
+		if len(report.Missing) < limit {
+			report.Missing = append(report.Missing, missingThumbnail{
+				FileID:   fileID,
+				Filename: filename,
+				Path:     filePath,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report.Truncated = report.MissingCount > len(report.Missing)
+	return report, nil
+}
+
+// InvalidateThumbnail deletes a file's cached thumbnails so the next request regenerates
+// them, for clients that received a broken image. Without size every size is invalidated.
+// POST /api/files/:id/thumbnail/invalidate?size=small
+func (h *Handler) InvalidateThumbnail(c *fiber.Ctx) error {
+	user := middleware.GetUser(c)
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid file ID"})
+	}
+
+	sizeType := c.Query("size")
+	if _, ok := services.ThumbnailSizes[sizeType]; sizeType != "" && !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Size must be 'small', 'medium' or 'large'"})
+	}
+
+	isServerOwner := user.Role == "server_owner"
+	if !isServerOwner {
+		hasAccess, err := h.permService.CheckFileAccess(user.ID, id, isServerOwner)
+		if err != nil || !hasAccess {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Access denied",
+			})
+		}
+	}
+
+	filePath, err := h.folderService.ResolveAbsolutePath(id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "File not found"})
+	}
+
+	var contentHash sql.NullString
+	if err := h.db.QueryRow("SELECT content_hash FROM files WHERE id = ?", id).Scan(&contentHash); err != nil {
+		log.Printf("Failed to look up content hash of %d: %v", id, err)
+	}
+
+	removed, err := h.thumbService.InvalidateThumbnail(filePath, id, contentHash.String, sizeType)
+	if err != nil {
+		log.Printf("Error invalidating thumbnails of %d: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to invalidate thumbnail",
+		})
+	}
+
+	// Registry rows describe the removed files
+	query := "DELETE FROM image_thumbnails WHERE file_id = ?"
+	args := []interface{}{id}
+	if sizeType != "" {
+		query += " AND size_type = ?"
+		args = append(args, sizeType)
+	}
+	if _, err := h.db.Exec(query, args...); err != nil {
+		log.Printf("Failed to delete thumbnail records of %d: %v", id, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"file_id": id,
+		"removed": removed,
+	})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+// missingReport starts a missing thumbnails job and returns its report
+func missingReport(t *testing.T, h *Handler, app *fiber.App, query string) missingThumbnailsReport {
+	t.Helper()
+	status, body := doRequest(t, app, http.MethodGet, "/api/admin/thumbnails/missing?"+query, "", nil)
+	if status != fiber.StatusAccepted {
+		t.Fatalf("%s: status %d, body %v", query, status, body)
+	}
+	job := waitForJob(t, h.jobs, int64(body["job_id"].(float64)))
+	if job.Status != services.JobStatusDone {
+		t.Fatalf("%s: job = %+v", query, job)
+	}
+	var report missingThumbnailsReport
+	if err := json.Unmarshal(job.Result, &report); err != nil {
+		t.Fatalf("decode job result: %v", err)
+	}
+	return report
+}
+
+func TestListMissingThumbnails(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	h.jobs.Start(1)
+	admin := seedUser(t, db.DB, "admin", "admin")
+	root := t.TempDir()
+	folder := seedFolder(t, db.DB, root, admin.ID)
+
+	writeTestImage(t, filepath.Join(root, "done.png"), 40, 30)
+	writeTestImage(t, filepath.Join(root, "todo.png"), 40, 30)
+	done := seedFile(t, db.DB, folder, "done.png", "image")
+	todo := seedFile(t, db.DB, folder, "todo.png", "image")
+	seedFile(t, db.DB, folder, "clip.mp4", "video")
+	if _, err := h.thumbService.GetThumbnail(filepath.Join(root, "done.png"), done, "small", services.ThumbnailModeFit); err != nil {
+		t.Fatalf("generate thumbnail: %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/api/admin/thumbnails/missing", asUser(admin), h.ListMissingThumbnails)
+
+	report := missingReport(t, h, app, "size=small")
+	if report.Checked != 2 || report.MissingCount != 1 || report.Truncated {
+		t.Errorf("checked %d, missing_count %d, truncated %v; want 2, 1, false", report.Checked, report.MissingCount, report.Truncated)
+	}
+	want := missingThumbnail{FileID: todo, Filename: "todo.png", Path: filepath.Join(root, "todo.png")}
+	if len(report.Missing) != 1 || report.Missing[0] != want {
+		t.Errorf("missing = %+v, want [%+v]", report.Missing, want)
+	}
+
+	// No medium thumbnails were generated
+	if report := missingReport(t, h, app, "size=medium"); report.MissingCount != 2 {
+		t.Errorf("medium missing_count = %d, want 2", report.MissingCount)
+	}
+
+	// The list is capped, the count is not
+	report = missingReport(t, h, app, "size=medium&limit=1")
+	if len(report.Missing) != 1 || report.MissingCount != 2 || !report.Truncated {
+		t.Errorf("limited report = %+v", report)
+	}
+
+	for _, query := range []string{"size=huge", "mode=stretch", "limit=0", fmt.Sprintf("limit=%d", maxMissingThumbnailsLimit+1)} {
+		status, _ := doRequest(t, app, http.MethodGet, "/api/admin/thumbnails/missing?"+query, "", nil)
+		if status != fiber.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, status)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestSetThumbnailURLs(t *testing.T) {
+	f := models.File{ID: 42}
+	setThumbnailURLs(&f)
+
+	if f.ThumbnailURL != "/api/files/42/thumbnail" {
+		t.Errorf("thumbnail_url = %q", f.ThumbnailURL)
+	}
+	if len(f.Thumbnails) != len(services.ThumbnailSizes) {
+		t.Errorf("got %d thumbnail sizes, want %d", len(f.Thumbnails), len(services.ThumbnailSizes))
+	}
+	for size := range services.ThumbnailSizes {
+		if want := "/api/files/42/thumbnail?size=" + size; f.Thumbnails[size] != want {
+			t.Errorf("%s: got %q, want %q", size, f.Thumbnails[size], want)
+		}
+	}
+}
+
+func TestGetFileByIDReturnsThumbnailSizes(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	folder := seedFolder(t, db.DB, "/photos", owner.ID)
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+
+	app := fiber.New()
+	app.Get("/api/files/:id", asUser(owner), h.GetFileByID)
+	id := strconv.FormatInt(file, 10)
+	status, body := doRequest(t, app, "GET", "/api/files/"+id, "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	thumbnails, ok := body["thumbnails"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("no thumbnails in %v", body)
+	}
+	if thumbnails["large"] != "/api/files/"+id+"/thumbnail?size=large" {
+		t.Errorf("large thumbnail = %v", thumbnails["large"])
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+)
+
+// seedGeotaggedPhoto inserts an image with a capture date and optional coordinates
+func seedGeotaggedPhoto(t *testing.T, db *sql.DB, folderID int64, name, takenAt string, lat, lon interface{}) int64 {
+	t.Helper()
+	id := seedFile(t, db, folderID, name, "image")
+	mustExec(t, db, "INSERT INTO photo_metadata (file_id, taken_at, latitude, longitude) VALUES (?, ?, ?, ?)",
+		id, takenAt, lat, lon)
+	return id
+}
+
+func TestGetTravel(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestHandler(t, db)
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	viewer := seedUser(t, db.DB, "viewer", "user")
+	shared := seedFolder(t, db.DB, "/photos/shared", owner.ID)
+	private := seedFolder(t, db.DB, "/photos/private", owner.ID)
+	grantFolder(t, db.DB, viewer.ID, shared, "read")
+
+	// March: two photos in Paris, one in Lyon
+	seedGeotaggedPhoto(t, db.DB, shared, "paris1.jpg", "2024-03-02 10:00:00", 48.8566, 2.3522)
+	seedGeotaggedPhoto(t, db.DB, shared, "paris2.jpg", "2024-03-03 11:00:00", 48.8600, 2.3600)
+	seedGeotaggedPhoto(t, db.DB, shared, "lyon.jpg", "2024-03-20 09:00:00", 45.7640, 4.8357)
+	// July: one photo in Rome, one private photo in Oslo
+	seedGeotaggedPhoto(t, db.DB, shared, "rome.jpg", "2024-07-14 18:00:00", 41.9028, 12.4964)
+	seedGeotaggedPhoto(t, db.DB, private, "oslo.jpg", "2024-07-01 12:00:00", 59.9139, 10.7522)
+	// Excluded: no coordinates, or another year
+	seedGeotaggedPhoto(t, db.DB, shared, "nogps.jpg", "2024-03-05 12:00:00", nil, nil)
+	seedGeotaggedPhoto(t, db.DB, shared, "old.jpg", "2023-03-05 12:00:00", 48.8566, 2.3522)
+
+	type month struct {
+		month    int
+		count    int
+		clusters []int // cluster counts, largest first
+	}
+	cases := []struct {
+		name string
+		user *models.User
+		want []month
+	}{
+		{"owner", owner, []month{{3, 3, []int{2, 1}}, {7, 2, []int{1, 1}}}},
+		{"viewer", viewer, []month{{3, 3, []int{2, 1}}, {7, 1, []int{1}}}},
+	}
+	for _, tc := range cases {
+		app := fiber.New()
+		app.Get("/api/files/travel", asUser(tc.user), h.GetTravel)
+
+		status, resp := doRequest(t, app, http.MethodGet, "/api/files/travel?year=2024", "", nil)
+		if status != fiber.StatusOK {
+			t.Fatalf("%s: status %d body %v", tc.name, status, resp)
+		}
+		months := resp["months"].([]interface{})
+		if len(months) != len(tc.want) {
+			t.Fatalf("%s: got %d months, want %d: %v", tc.name, len(months), len(tc.want), months)
+		}
+		for i, raw := range months {
+			m := raw.(map[string]interface{})
+			want := tc.want[i]
+			if m["month"] != float64(want.month) || m["count"] != float64(want.count) {
+				t.Errorf("%s: month %v count %v, want %d and %d", tc.name, m["month"], m["count"], want.month, want.count)
+			}
+			clusters := m["clusters"].([]interface{})
+			if len(clusters) != len(want.clusters) {
+				t.Errorf("%s month %d: got %d clusters, want %d", tc.name, want.month, len(clusters), len(want.clusters))
+				continue
+			}
+			for j, rc := range clusters {
+				if got := rc.(map[string]interface{})["count"]; got != float64(want.clusters[j]) {
+					t.Errorf("%s month %d cluster %d: count %v, want %d", tc.name, want.month, j, got, want.clusters[j])
+				}
+			}
+		}
+	}
+
+	app := fiber.New()
+	app.Get("/api/files/travel", asUser(owner), h.GetTravel)
+
+	// Nothing was taken in 2022
+	if status, resp := doRequest(t, app, http.MethodGet, "/api/files/travel?year=2022", "", nil); status != fiber.StatusOK || len(resp["months"].([]interface{})) != 0 {
+		t.Errorf("empty year: status %d body %v", status, resp)
+	}
+	for _, bad := range []string{"?year=abc", "?year=2024&precision=4", "?year=2024&precision=-1"} {
+		if status, _ := doRequest(t, app, http.MethodGet, "/api/files/travel"+bad, "", nil); status != fiber.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", bad, status)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+func TestSanitizeUploadFilename(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"photo.jpg", "photo.jpg", false},
+		{"../../evil.jpg", "evil.jpg", false},
+		{`..\..\evil.jpg`, "evil.jpg", false},
+		{"/etc/cron.d/evil.jpg", "evil.jpg", false},
+		{"bad\x00name\r\n.jpg", "badname.jpg", false},
+		{"  spaced.jpg ", "spaced.jpg", false},
+		{"", "", true},
+		{".", "", true},
+		{"..", "", true},
+		{"uploads/..", "", true},
+		{"/", "", true},
+		{"\x01\x02", "", true},
+	}
+	for _, tc := range cases {
+		got, err := sanitizeUploadFilename(tc.in)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("sanitizeUploadFilename(%q) = %q, %v, want %q (error %v)", tc.in, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestUploadFilenameTraversal(t *testing.T) {
+	db := newTestDB(t)
+	folderService := services.NewFolderService(db.DB)
+	h := NewUploadHandler(folderService, services.NewFileScanner(db, folderService, t.TempDir(), nil), services.NewSettingsService(db.DB))
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	app := fiber.New()
+	app.Post("/api/upload", asUser(owner), h.UploadFiles)
+
+	base := t.TempDir()
+	targetDir := filepath.Join(base, "uploads", "inbox")
+	os.MkdirAll(targetDir, 0755)
+	jpeg := []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("target_path", targetDir)
+	for _, name := range []string{"../../evil.jpg", `..\..\sneaky.jpg`, ".."} {
+		part, _ := form.CreateFormFile("files", name)
+		part.Write(jpeg)
+	}
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Uploaded []string            `json:"uploaded"`
+		Failed   []map[string]string `json:"failed"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	sort.Strings(result.Uploaded)
+	if strings.Join(result.Uploaded, ",") != "evil.jpg,sneaky.jpg" {
+		t.Errorf("uploaded = %v, failed = %v", result.Uploaded, result.Failed)
+	}
+	if len(result.Failed) != 1 || result.Failed[0]["error"] != "Invalid filename" {
+		t.Errorf("failed = %v, want the \"..\" upload rejected", result.Failed)
+	}
+
+	// Everything landed in the target directory and nothing outside it
+	for _, name := range []string{"evil.jpg", "sneaky.jpg"} {
+		if _, err := os.Stat(filepath.Join(targetDir, name)); err != nil {
+			t.Errorf("%s not saved in the target directory: %v", name, err)
+		}
+	}
+	filepath.Walk(base, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && filepath.Dir(path) != targetDir {
+			t.Errorf("file written outside the target directory: %s", path)
+		}
+		return nil
+	})
+}
@@ -1,11 +1,14 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"unicode"
 
 	"github.com/gofiber/fiber/v2"
 
@@ -14,17 +17,27 @@ import (
 )
 
 type UploadHandler struct {
-	folderService  *services.FolderService
-	scannerService *services.FileScanner
+	folderService   *services.FolderService
+	scannerService  *services.FileScanner
+	settingsService *services.SettingsService
 }
 
-func NewUploadHandler(folderService *services.FolderService, scannerService *services.FileScanner) *UploadHandler {
+func NewUploadHandler(folderService *services.FolderService, scannerService *services.FileScanner, settingsService *services.SettingsService) *UploadHandler {
 	return &UploadHandler{
-		folderService:  folderService,
-		scannerService: scannerService,
+		folderService:   folderService,
+		scannerService:  scannerService,
+		settingsService: settingsService,
 	}
 }
 
+// maxUploadSize bounds the body of an upload request in bytes
+var maxUploadSize = 2048 << 20
+
+// SetMaxUploadSize sets the largest upload request body accepted, in bytes
+func SetMaxUploadSize(size int) {
+	maxUploadSize = size
+}
+
 // UploadFiles handles file uploads
 // POST /api/upload
 func (h *UploadHandler) UploadFiles(c *fiber.Ctx) error {
@@ -35,6 +48,18 @@ func (h *UploadHandler) UploadFiles(c *fiber.Ctx) error {
 		})
 	}
 
+	// The body is streamed to disk as the form is parsed; its declared length bounds it
+	switch length := c.Request().Header.ContentLength(); {
+	case length < 0:
+		return c.Status(fiber.StatusLengthRequired).JSON(fiber.Map{
+			"error": "Uploads must declare a Content-Length",
+		})
+	case length > maxUploadSize:
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": fmt.Sprintf("Upload exceeds the %d MB limit", maxUploadSize>>20),
+		})
+	}
+
 	// Get target path from form
 	targetPath := c.FormValue("target_path")
 	if targetPath == "" {
@@ -75,7 +100,15 @@ func (h *UploadHandler) UploadFiles(c *fiber.Ctx) error {
 		})
 	}
 
-	// Supported image extensions
+	// Allowed MIME types and their size caps
+	policy, err := h.settingsService.GetUploadPolicy()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Invalid upload policy configuration",
+		})
+	}
+
+	// Supported media extensions
 	supportedExts := map[string]bool{
 		".jpg":  true,
 		".jpeg": true,
@@ -87,14 +120,30 @@ func (h *UploadHandler) UploadFiles(c *fiber.Ctx) error {
 		".heif": true,
 		".tif":  true,
 		".tiff": true,
+		".mp4":  true,
+		".mov":  true,
+		".avi":  true,
+		".mkv":  true,
+		".webm": true,
+		".m4v":  true,
 	}
 
 	var uploadedFiles []string
 	var failedFiles []map[string]string
 
 	for _, file := range files {
+		// The client controls the filename; keep only a safe base name
+		filename, err := sanitizeUploadFilename(file.Filename)
+		if err != nil {
+			failedFiles = append(failedFiles, map[string]string{
+				"filename": file.Filename,
+				"error":    err.Error(),
+			})
+			continue
+		}
+
 		// Check file extension
-		ext := strings.ToLower(filepath.Ext(file.Filename))
+		ext := strings.ToLower(filepath.Ext(filename))
 		if !supportedExts[ext] {
 			failedFiles = append(failedFiles, map[string]string{
 				"filename": file.Filename,
@@ -104,7 +153,7 @@ func (h *UploadHandler) UploadFiles(c *fiber.Ctx) error {
 		}
 
 		// Generate destination path
-		destPath := filepath.Join(targetPath, file.Filename)
+		destPath := filepath.Join(targetPath, filename)
 
 		// Check if file already exists
 		if _, err := os.Stat(destPath); err == nil {
@@ -125,6 +174,36 @@ func (h *UploadHandler) UploadFiles(c *fiber.Ctx) error {
 			continue
 		}
 
+		// Sniff content type and enforce the MIME policy
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(src, head)
+		mimeType := services.DetectMIMEType(head[:n], filename)
+		maxSize, allowed := services.MatchUploadPolicy(policy, mimeType)
+		if !allowed {
+			src.Close()
+			failedFiles = append(failedFiles, map[string]string{
+				"filename": file.Filename,
+				"error":    fmt.Sprintf("File type %s is not allowed", mimeType),
+			})
+			continue
+		}
+		if file.Size > maxSize {
+			src.Close()
+			failedFiles = append(failedFiles, map[string]string{
+				"filename": file.Filename,
+				"error":    fmt.Sprintf("File too large for type %s (%d bytes, maximum %d bytes)", mimeType, file.Size, maxSize),
+			})
+			continue
+		}
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			src.Close()
+			failedFiles = append(failedFiles, map[string]string{
+				"filename": file.Filename,
+				"error":    fmt.Sprintf("Failed to read file: %v", err),
+			})
+			continue
+		}
+
 		// Create destination file
 		dst, err := os.Create(destPath)
 		if err != nil {
@@ -151,7 +230,7 @@ func (h *UploadHandler) UploadFiles(c *fiber.Ctx) error {
 		src.Close()
 		dst.Close()
 
-		uploadedFiles = append(uploadedFiles, file.Filename)
+		uploadedFiles = append(uploadedFiles, filename)
 	}
 
 	// Trigger scan of the target directory
@@ -172,6 +251,26 @@ func (h *UploadHandler) UploadFiles(c *fiber.Ctx) error {
 	})
 }
 
+// sanitizeUploadFilename reduces a client-supplied filename to its base name
+// (treating backslashes as separators too) and strips control characters, so an
+// upload can never be written outside the target directory
+func sanitizeUploadFilename(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = path.Base(name)
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "", errors.New("Invalid filename")
+	}
+	return name, nil
+}
+
 // CreateDirectory creates a new directory in the file system
 // POST /api/upload/create-directory
 func (h *UploadHandler) CreateDirectory(c *fiber.Ctx) error {
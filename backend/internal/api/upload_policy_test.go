@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+// mp4Content returns size bytes that sniff as video/mp4
+func mp4Content(size int) []byte {
+	content := make([]byte, size)
+	copy(content, append([]byte{0, 0, 0, 0x18}, []byte("ftypmp42\x00\x00\x00\x00mp42isom")...))
+	return content
+}
+
+func TestUploadMIMEPolicy(t *testing.T) {
+	db := newTestDB(t)
+	folderService := services.NewFolderService(db.DB)
+	settings := services.NewSettingsService(db.DB)
+	h := NewUploadHandler(folderService, services.NewFileScanner(db, folderService, t.TempDir(), nil), settings)
+
+	if err := settings.SetSetting("upload_mime_policy", `{"video/*": 4096, "image/png": 1024}`); err != nil {
+		t.Fatalf("set policy: %v", err)
+	}
+
+	owner := seedUser(t, db.DB, "owner", "server_owner")
+	app := fiber.New()
+	app.Post("/api/upload", asUser(owner), h.UploadFiles)
+
+	targetDir := t.TempDir()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("target_path", targetDir)
+	files := map[string][]byte{
+		"small.mp4": mp4Content(2048),
+		"large.mp4": mp4Content(8192),
+		"photo.jpg": {0xff, 0xd8, 0xff, 0xe0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00},
+	}
+	for name, content := range files {
+		part, _ := form.CreateFormFile("files", name)
+		part.Write(content)
+	}
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("upload: status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Uploaded []string            `json:"uploaded"`
+		Failed   []map[string]string `json:"failed"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	failures := map[string]string{}
+	for _, f := range result.Failed {
+		failures[f["filename"]] = f["error"]
+	}
+	if !strings.Contains(failures["large.mp4"], "too large for type video/mp4") {
+		t.Errorf("large.mp4 error: %q", failures["large.mp4"])
+	}
+	if !strings.Contains(failures["photo.jpg"], "image/jpeg is not allowed") {
+		t.Errorf("photo.jpg error: %q", failures["photo.jpg"])
+	}
+
+	if _, err := os.Stat(filepath.Join(targetDir, "small.mp4")); err != nil {
+		t.Errorf("video under the cap was not saved: %v", err)
+	}
+	for _, name := range []string{"large.mp4", "photo.jpg"} {
+		if _, err := os.Stat(filepath.Join(targetDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should have been rejected", name)
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package api
 
 import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -128,15 +133,10 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Default role to 'user' if not specified
-	if req.Role == "" {
-		req.Role = "user"
-	}
-
-	// Validate role
-	if req.Role != "admin" && req.Role != "user" && req.Role != "server_owner" {
+	// Validate role; without one CreateUser applies the registration defaults
+	if req.Role != "" && req.Role != "admin" && req.Role != "user" && req.Role != services.GuestRole && req.Role != "server_owner" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Role must be 'admin', 'user', or 'server_owner'",
+			"error": "Role must be 'admin', 'user', 'guest', or 'server_owner'",
 		})
 	}
 
@@ -169,6 +169,13 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 		})
 	}
 
+	// Addresses entered by an administrator are trusted
+	if req.Email != "" {
+		if err := h.authService.UpdateUser(user.ID, map[string]interface{}{"email_verified": true}); err != nil {
+			log.Printf("Failed to mark the email of user %d verified: %v", user.ID, err)
+		}
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"user": user,
 	})
@@ -219,13 +226,15 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	// Build updates map
 	updates := make(map[string]interface{})
 	if req.Email != nil {
+		// Addresses entered by an administrator are trusted
 		updates["email"] = *req.Email
+		updates["email_verified"] = *req.Email != ""
 	}
 	if req.Role != nil {
 		// Validate role
-		if *req.Role != "admin" && *req.Role != "user" && *req.Role != "server_owner" {
+		if *req.Role != "admin" && *req.Role != "user" && *req.Role != services.GuestRole && *req.Role != "server_owner" {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Role must be 'admin', 'user', or 'server_owner'",
+				"error": "Role must be 'admin', 'user', 'guest', or 'server_owner'",
 			})
 		}
 
@@ -457,6 +466,15 @@ func (h *UserHandler) ResetPassword(c *fiber.Ctx) error {
 		})
 	}
 
+	// Invalidate the user's sessions (keep the caller's own session when resetting themselves)
+	exceptSessionID := ""
+	if currentUser != nil && currentUser.ID == id {
+		exceptSessionID = middleware.GetSessionID(c)
+	}
+	if _, err := h.authService.DeleteSessionsForUser(id, exceptSessionID); err != nil {
+		log.Printf("Failed to invalidate sessions for user %d: %v", id, err)
+	}
+
 	// Log activity
 	h.authService.LogUserActivity(id, currentUser.ID, "password_reset", "", c.IP())
 
@@ -668,6 +686,71 @@ func (h *UserHandler) ExportUsers(c *fiber.Ctx) error {
 	return c.Send(csvData)
 }
 
+// ImportUsers creates users from an uploaded CSV (admin only)
+// Columns: username,email,role,password (blank password = generated).
+// Responds with a CSV of per-row outcomes including generated passwords.
+// POST /api/users/import
+func (h *UserHandler) ImportUsers(c *fiber.Ctx) error {
+	currentUser := middleware.GetUser(c)
+	if currentUser == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	// Accept a multipart "file" field or a raw text/csv body
+	var data io.Reader = bytes.NewReader(c.Body())
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Failed to read uploaded file",
+			})
+		}
+		defer file.Close()
+		data = file
+	}
+
+	// Admin cannot create other admin accounts
+	allowAdmin := currentUser.Role == "server_owner"
+	results, err := h.authService.ImportUsers(data, allowAdmin)
+	if err != nil {
+		if err == services.ErrTooManyImportRows {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Too many rows, import at most 1000 users at a time",
+			})
+		}
+		if _, ok := err.(*csv.ParseError); ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid CSV: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to import users",
+		})
+	}
+
+	created := 0
+	for _, result := range results {
+		if result.Created {
+			created++
+		}
+	}
+	h.authService.LogUserActivity(currentUser.ID, currentUser.ID, "imported_users",
+		fmt.Sprintf(`{"created":%d,"failed":%d}`, created, len(results)-created), c.IP())
+
+	csvData, err := services.UserImportResultsCSV(results)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build import results",
+		})
+	}
+
+	c.Set("Content-Type", "text/csv")
+	c.Set("Content-Disposition", "attachment; filename=users_import_results.csv")
+	return c.Send(csvData)
+}
+
 // GetUserStats returns user statistics (admin only)
 // GET /api/users/stats
 func (h *UserHandler) GetUserStats(c *fiber.Ctx) error {
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"awesome-sharing/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// importResultRows posts a CSV body to the import endpoint and parses the result CSV
+func importResultRows(t *testing.T, app *fiber.App, req *http.Request) [][]string {
+	t.Helper()
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status %d, body %s", resp.StatusCode, raw)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Content-Type = %q, want text/csv", ct)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(raw)).ReadAll()
+	if err != nil {
+		t.Fatalf("parse result csv %q: %v", raw, err)
+	}
+	return rows
+}
+
+func TestImportUsersHandler(t *testing.T) {
+	db := newTestDB(t)
+	h := NewUserHandler(services.NewAuthService(db.DB))
+	admin := seedUser(t, db.DB, "admin", "admin")
+
+	app := fiber.New()
+	app.Post("/api/users/import", asUser(admin), h.ImportUsers)
+
+	body := "username,email,role,password\n" +
+		"alice,alice@example.com,user,\n" +
+		"alice,alice2@example.com,user,\n" +
+		"carol,carol@example.com,owner,\n" +
+		"dave,dave@example.com,admin,\n"
+	req := httptest.NewRequest(http.MethodPost, "/api/users/import", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "text/csv")
+	rows := importResultRows(t, app, req)
+
+	if len(rows) != 5 {
+		t.Fatalf("got %d rows, want header + 4: %v", len(rows), rows)
+	}
+	want := []struct{ line, username, status string }{
+		{"2", "alice", "created"},
+		{"3", "alice", "failed"},
+		{"4", "carol", "failed"},
+		{"5", "dave", "failed"},
+	}
+	for i, w := range want {
+		row := rows[i+1]
+		if row[0] != w.line || row[1] != w.username || row[5] != w.status {
+			t.Errorf("row %d = %v, want line %s %s %s", i+1, row, w.line, w.username, w.status)
+		}
+	}
+	if rows[1][4] == "" {
+		t.Error("expected a generated password for alice")
+	}
+	if rows[4][6] != "admin users cannot create other admin accounts" {
+		t.Errorf("dave error = %q", rows[4][6])
+	}
+
+	var logged int
+	db.QueryRow("SELECT COUNT(*) FROM user_activity_logs WHERE action = 'imported_users'").Scan(&logged)
+	if logged != 1 {
+		t.Errorf("imported_users activity rows = %d, want 1", logged)
+	}
+}
+
+func TestImportUsersHandlerMultipart(t *testing.T) {
+	db := newTestDB(t)
+	h := NewUserHandler(services.NewAuthService(db.DB))
+	owner := seedUser(t, db.DB, "root", "server_owner")
+
+	app := fiber.New()
+	app.Post("/api/users/import", asUser(owner), h.ImportUsers)
+
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, _ := form.CreateFormFile("file", "users.csv")
+	part.Write([]byte("erin,erin@example.com,admin,\n"))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users/import", &buf)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rows := importResultRows(t, app, req)
+
+	// The server owner may create admins
+	if len(rows) != 2 || rows[1][1] != "erin" || rows[1][3] != "admin" || rows[1][5] != "created" {
+		t.Errorf("rows = %v, want erin created as admin", rows)
+	}
+}
+
+func TestImportUsersHandlerErrors(t *testing.T) {
+	db := newTestDB(t)
+	h := NewUserHandler(services.NewAuthService(db.DB))
+	admin := seedUser(t, db.DB, "admin", "admin")
+
+	app := fiber.New()
+	app.Post("/api/users/import", asUser(admin), h.ImportUsers)
+	app.Post("/anon/import", h.ImportUsers)
+
+	status, _ := doRequest(t, app, http.MethodPost, "/anon/import", "", nil)
+	if status != fiber.StatusUnauthorized {
+		t.Errorf("anonymous: status %d, want 401", status)
+	}
+
+	status, body := doRequest(t, app, http.MethodPost, "/api/users/import", "alice,\"unterminated\n", map[string]string{"Content-Type": "text/csv"})
+	if status != fiber.StatusBadRequest {
+		t.Errorf("malformed csv: status %d, body %v, want 400", status, body)
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/models"
+	"awesome-sharing/internal/services"
+)
+
+func TestAlbumLimit(t *testing.T) {
+	db := newTestDB(t)
+	settings := services.NewSettingsService(db.DB)
+	albumService := services.NewAlbumService(db.DB)
+	h := NewAlbumHandler(albumService, settings)
+	settings.SetSetting("max_albums_per_user", "2")
+
+	user := seedUser(t, db.DB, "user", "user")
+	admin := seedUser(t, db.DB, "admin", "admin")
+	folder := seedFolder(t, db.DB, "/photos", user.ID)
+	grantFolder(t, db.DB, user.ID, folder, "read")
+	seedFile(t, db.DB, folder, "2023/a.jpg", "image")
+	seedFile(t, db.DB, folder, "2024/b.jpg", "image")
+
+	appFor := func(u *models.User) *fiber.App {
+		app := fiber.New()
+		app.Use(asUser(u))
+		app.Post("/api/albums", h.CreateAlbum)
+		app.Post("/api/albums/:id/clone", h.CloneAlbum)
+		app.Post("/api/folders/:id/auto-albums", h.CreateAutoAlbums)
+		return app
+	}
+	app := appFor(user)
+	autoPath := fmt.Sprintf("/api/folders/%d/auto-albums", folder)
+
+	status, body := doRequest(t, app, http.MethodPost, "/api/albums", `{"name":"First"}`, nil)
+	if status != fiber.StatusCreated {
+		t.Fatalf("first album: status %d, body %v", status, body)
+	}
+	firstID := int64(body["album"].(map[string]interface{})["id"].(float64))
+
+	// Two subdirectories don't fit in the one remaining album
+	if status, body := doRequest(t, app, http.MethodPost, autoPath, "", nil); status != fiber.StatusForbidden {
+		t.Errorf("auto albums over the limit: status %d, body %v", status, body)
+	}
+
+	if status, body := doRequest(t, app, http.MethodPost, "/api/albums", `{"name":"Second"}`, nil); status != fiber.StatusCreated {
+		t.Fatalf("second album: status %d, body %v", status, body)
+	}
+
+	cases := []struct {
+		name, path, body string
+	}{
+		{"create", "/api/albums", `{"name":"Third"}`},
+		{"clone", fmt.Sprintf("/api/albums/%d/clone", firstID), ""},
+		{"auto albums", autoPath, ""},
+	}
+	for _, tc := range cases {
+		status, body := doRequest(t, app, http.MethodPost, tc.path, tc.body, nil)
+		if status != fiber.StatusForbidden || body["error"] != "Album limit reached" {
+			t.Errorf("%s at the limit: status %d, body %v", tc.name, status, body)
+		}
+	}
+
+	// Admins are exempt
+	for i := 0; i < 3; i++ {
+		if status, body := doRequest(t, appFor(admin), http.MethodPost, "/api/albums", `{"name":"Admin"}`, nil); status != fiber.StatusCreated {
+			t.Fatalf("admin album %d: status %d, body %v", i, status, body)
+		}
+	}
+}
+
+func TestShareLimitAndLimitsEndpoint(t *testing.T) {
+	db := newTestDB(t)
+	h := newTestShareHandler(t, db)
+	settings := services.NewSettingsService(db.DB)
+	auth := NewAuthHandler(services.NewAuthService(db.DB), settings, nil)
+	settings.SetSetting("max_shares_per_user", "1")
+
+	user := seedUser(t, db.DB, "user", "user")
+	admin := seedUser(t, db.DB, "admin", "admin")
+	folder := seedFolder(t, db.DB, "/photos", user.ID)
+	grantFolder(t, db.DB, user.ID, folder, "read")
+	grantFolder(t, db.DB, admin.ID, folder, "read")
+	file := seedFile(t, db.DB, folder, "a.jpg", "image")
+	shareBody := fmt.Sprintf(`{"share_type":"file","resource_id":%d}`, file)
+
+	appFor := func(u *models.User) *fiber.App {
+		app := fiber.New()
+		app.Use(asUser(u))
+		app.Post("/api/shares", h.CreateShare)
+		app.Get("/api/auth/limits", auth.GetLimits)
+		return app
+	}
+
+	if status, body := doRequest(t, appFor(user), http.MethodPost, "/api/shares", shareBody, nil); status != fiber.StatusCreated {
+		t.Fatalf("first share: status %d, body %v", status, body)
+	}
+	if status, body := doRequest(t, appFor(user), http.MethodPost, "/api/shares", shareBody, nil); status != fiber.StatusForbidden {
+		t.Errorf("share over the limit: status %d, body %v", status, body)
+	}
+	for i := 0; i < 2; i++ {
+		if status, body := doRequest(t, appFor(admin), http.MethodPost, "/api/shares", shareBody, nil); status != fiber.StatusCreated {
+			t.Errorf("admin share %d: status %d, body %v", i, status, body)
+		}
+	}
+
+	status, body := doRequest(t, appFor(user), http.MethodGet, "/api/auth/limits", "", nil)
+	if status != fiber.StatusOK {
+		t.Fatalf("limits: status %d, body %v", status, body)
+	}
+	limits := body["limits"].(map[string]interface{})
+	if fmt.Sprint(limits["shares"]) != "map[count:1 limit:1]" || fmt.Sprint(limits["albums"]) != "map[count:0 limit:0]" {
+		t.Errorf("limits = %v", limits)
+	}
+	if body["exempt"] != false {
+		t.Errorf("user exempt = %v", body["exempt"])
+	}
+
+	_, body = doRequest(t, appFor(admin), http.MethodGet, "/api/auth/limits", "", nil)
+	if body["exempt"] != true {
+		t.Errorf("admin exempt = %v", body["exempt"])
+	}
+
+	if status, _ := doRequest(t, appFor(nil), http.MethodGet, "/api/auth/limits", "", nil); status != fiber.StatusUnauthorized {
+		t.Errorf("anonymous limits: status %d, want 401", status)
+	}
+}
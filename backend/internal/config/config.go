@@ -4,16 +4,73 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	Port          string
-	DBPath        string
-	ConfigDir     string
-	UploadDir     string
-	ThumbsDir     string
-	MountedDirs   []string
-	AllowedOrigin string
+	Port           string
+	DBPath         string
+	ConfigDir      string
+	UploadDir      string
+	ThumbsDir      string
+	TempDir        string            // Temporary files (spooled uploads, converter output)
+	TempMaxAge     time.Duration     // Temp files older than this are removed by the cleanup task
+	ThumbSizeDirs  map[string]string // Optional per-size thumbnail directories
+	MountedDirs    []string
+	AllowedOrigin  string
+	BasePath       string // Path prefix when served under a subpath by a reverse proxy, e.g. "/photos"
+	MaxBodySize    int    // Maximum upload request size in bytes
+	MaxRequestSize int    // Maximum body size in bytes of every other request
+
+	// Startup background jobs
+	ScanOnStartup          bool          // Run a full folder scan shortly after boot
+	InitialScanDelay       time.Duration // Delay before the startup scan
+	InitialValidationDelay time.Duration // Delay before the first file validation run
+	ValidationConcurrency  int           // Files checked in parallel during a validation run
+	ValidationTimeout      time.Duration // Longest a validation run may take before resuming in the next run (0 disables)
+
+	PerceptualHash bool // Compute perceptual hashes for similar-photo grouping during scans
+
+	QueryTimeout time.Duration // Upper bound for database queries issued by a single request
+
+	DownloadChecksums bool // Send X-Content-SHA256 with file downloads
+
+	MaxThumbnailSourcePixels int64 // Images above this pixel count are not decoded for thumbnails
+
+	ThumbnailPlaceholderDir string // Optional custom placeholders (<kind>.png) for files without thumbnails
+
+	ThumbnailConcurrency int // Maximum concurrent thumbnail generations (0 = number of CPUs)
+
+	ThumbnailRegenerateOnChange bool // Regenerate cached thumbnails when the source file is modified
+
+	HEICConvertCommand string // External HEIC/HEIF to JPEG converter with {input} and {output} placeholders
+
+	ThumbnailBackground string // #rrggbb color transparent images are flattened onto
+
+	// Outgoing mail (share activity digests); mail is disabled when SMTPHost is empty
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// Reverse proxy support: the client IP is read from ProxyHeader only when the
+	// direct peer matches one of TrustedProxies (IPs or CIDRs)
+	TrustedProxies []string
+	ProxyHeader    string
+
+	// Session credential names; change them when several instances share a domain
+	SessionCookieName string
+	SessionHeaderName string
+
+	// CSRF protection: origins allowed to send cookie-authenticated mutating requests,
+	// and whether cookies are Secure even on plain HTTP requests
+	AllowedOrigins []string
+	CookieSecure   bool
+
+	IdleTimeout time.Duration // Sessions without a request for this long are ended (0 disables)
 }
 
 func Load() *Config {
@@ -21,13 +78,70 @@ func Load() *Config {
 	uploadDir := getEnv("UPLOAD_DIR", "/upload")
 
 	cfg := &Config{
-		Port:          getEnv("PORT", "8080"),
-		ConfigDir:     configDir,
-		UploadDir:     uploadDir,
-		DBPath:        filepath.Join(configDir, "awesome-sharing.db"),
-		ThumbsDir:     filepath.Join(configDir, "thumbs"),
-		AllowedOrigin: getEnv("ALLOWED_ORIGIN", "*"),
-		MountedDirs:   []string{configDir, uploadDir},
+		Port:           getEnv("PORT", "8080"),
+		ConfigDir:      configDir,
+		UploadDir:      uploadDir,
+		DBPath:         filepath.Join(configDir, "awesome-sharing.db"),
+		ThumbsDir:      getEnv("THUMBS_DIR", filepath.Join(configDir, "thumbs")),
+		TempDir:        getEnv("TEMP_DIR", filepath.Join(configDir, "tmp")),
+		TempMaxAge:     time.Duration(getEnvInt("TEMP_MAX_AGE_HOURS", 24)) * time.Hour,
+		ThumbSizeDirs:  make(map[string]string),
+		BasePath:       normalizeBasePath(getEnv("BASE_PATH", "")),
+		AllowedOrigin:  getEnv("ALLOWED_ORIGIN", "*"),
+		MountedDirs:    []string{configDir, uploadDir},
+		MaxBodySize:    getEnvInt("MAX_BODY_SIZE_MB", 2048) << 20,
+		MaxRequestSize: getEnvInt("MAX_REQUEST_SIZE_MB", 4) << 20,
+
+		ScanOnStartup:          getEnvBool("SCAN_ON_STARTUP", true),
+		InitialScanDelay:       time.Duration(getEnvInt("INITIAL_SCAN_DELAY_SECONDS", 5)) * time.Second,
+		InitialValidationDelay: time.Duration(getEnvInt("INITIAL_VALIDATION_DELAY_SECONDS", 30)) * time.Second,
+		ValidationConcurrency:  getEnvInt("VALIDATION_CONCURRENCY", 4),
+		ValidationTimeout:      time.Duration(getEnvInt("VALIDATION_TIMEOUT_MINUTES", 0)) * time.Minute,
+
+		PerceptualHash: getEnvBool("PERCEPTUAL_HASH", true),
+
+		QueryTimeout: time.Duration(getEnvInt("QUERY_TIMEOUT_SECONDS", 30)) * time.Second,
+
+		DownloadChecksums: getEnvBool("DOWNLOAD_CHECKSUMS", false),
+
+		MaxThumbnailSourcePixels: int64(getEnvInt("MAX_THUMBNAIL_SOURCE_MEGAPIXELS", 100)) * 1000000,
+		ThumbnailPlaceholderDir:  getEnv("THUMBNAIL_PLACEHOLDER_DIR", ""),
+		ThumbnailConcurrency:     getEnvInt("THUMBNAIL_CONCURRENCY", 0),
+
+		ThumbnailRegenerateOnChange: getEnvBool("THUMBNAIL_REGENERATE_ON_CHANGE", false),
+
+		HEICConvertCommand: getEnv("HEIC_CONVERT_COMMAND", ""),
+
+		ThumbnailBackground: getEnv("THUMBNAIL_BACKGROUND", "#ffffff"),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", ""),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+		ProxyHeader:    getEnv("PROXY_HEADER", "X-Forwarded-For"),
+
+		SessionCookieName: getEnv("SESSION_COOKIE_NAME", "session_id"),
+		SessionHeaderName: getEnv("SESSION_HEADER_NAME", "Authorization"),
+
+		AllowedOrigins: getEnvList("ALLOWED_ORIGINS"),
+		CookieSecure:   getEnvBool("COOKIE_SECURE", false),
+
+		IdleTimeout: time.Duration(getEnvInt("IDLE_TIMEOUT_MINUTES", 0)) * time.Minute,
+	}
+
+	// Frontends allowed by CORS may send mutating requests unless ALLOWED_ORIGINS says otherwise
+	if len(cfg.AllowedOrigins) == 0 && cfg.AllowedOrigin != "*" {
+		cfg.AllowedOrigins = strings.Split(cfg.AllowedOrigin, ",")
+	}
+
+	// Per-size thumbnail directories, e.g. THUMBS_DIR_LARGE=/cache/large
+	for _, size := range []string{"small", "medium", "large"} {
+		if dir := os.Getenv("THUMBS_DIR_" + strings.ToUpper(size)); dir != "" {
+			cfg.ThumbSizeDirs[size] = dir
+		}
 	}
 
 	// Ensure all required directories exist
@@ -40,13 +154,56 @@ func Load() *Config {
 	if err := os.MkdirAll(cfg.ThumbsDir, 0755); err != nil {
 		log.Printf("Warning: could not create thumbs directory: %v", err)
 	}
+	if err := os.MkdirAll(cfg.TempDir, 0755); err != nil {
+		log.Printf("Warning: could not create temp directory: %v", err)
+	}
 
 	return cfg
 }
 
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+		log.Printf("Warning: invalid value for %s: %q, using default %d", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+		log.Printf("Warning: invalid value for %s: %q, using default %t", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+// getEnvList splits a comma-separated env var, dropping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+// normalizeBasePath turns "photos", "/photos/" or "/photos" into "/photos", and "/" into ""
+func normalizeBasePath(basePath string) string {
+	basePath = strings.Trim(strings.TrimSpace(basePath), "/")
+	if basePath == "" {
+		return ""
+	}
+	return "/" + basePath
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadWith loads the configuration with the given environment, keeping all directories
+// inside a temporary directory
+func loadWith(t *testing.T, env map[string]string) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("CONFIG_DIR", dir)
+	t.Setenv("UPLOAD_DIR", dir+"/upload")
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	return Load()
+}
+
+func TestStartupScanConfig(t *testing.T) {
+	cases := []struct {
+		name            string
+		env             map[string]string
+		scan            bool
+		scanDelay       time.Duration
+		validationDelay time.Duration
+	}{
+		{"defaults", nil, true, 5 * time.Second, 30 * time.Second},
+		{"disabled", map[string]string{"SCAN_ON_STARTUP": "false"}, false, 5 * time.Second, 30 * time.Second},
+		{"disabled numeric", map[string]string{"SCAN_ON_STARTUP": "0"}, false, 5 * time.Second, 30 * time.Second},
+		{"invalid keeps default", map[string]string{"SCAN_ON_STARTUP": "nope", "INITIAL_SCAN_DELAY_SECONDS": "soon"}, true, 5 * time.Second, 30 * time.Second},
+		{"custom delays", map[string]string{"INITIAL_SCAN_DELAY_SECONDS": "60", "INITIAL_VALIDATION_DELAY_SECONDS": "300"}, true, time.Minute, 5 * time.Minute},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := loadWith(t, tc.env)
+			if cfg.ScanOnStartup != tc.scan {
+				t.Errorf("ScanOnStartup = %v, want %v", cfg.ScanOnStartup, tc.scan)
+			}
+			if cfg.InitialScanDelay != tc.scanDelay {
+				t.Errorf("InitialScanDelay = %s, want %s", cfg.InitialScanDelay, tc.scanDelay)
+			}
+			if cfg.InitialValidationDelay != tc.validationDelay {
+				t.Errorf("InitialValidationDelay = %s, want %s", cfg.InitialValidationDelay, tc.validationDelay)
+			}
+		})
+	}
+}
+
+func TestBasePathConfig(t *testing.T) {
+	cases := []struct {
+		value, want string
+	}{
+		{"", ""},
+		{"/", ""},
+		{"photos", "/photos"},
+		{"/photos/", "/photos"},
+		{" /apps/photos ", "/apps/photos"},
+	}
+	for _, tc := range cases {
+		if got := loadWith(t, map[string]string{"BASE_PATH": tc.value}).BasePath; got != tc.want {
+			t.Errorf("BASE_PATH=%q: BasePath = %q, want %q", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestAllowedOriginsConfig(t *testing.T) {
+	cases := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"wildcard CORS", nil, ""},
+		{"CORS origins", map[string]string{"ALLOWED_ORIGIN": "https://a.example.com,https://b.example.com"}, "https://a.example.com,https://b.example.com"},
+		{"explicit", map[string]string{"ALLOWED_ORIGIN": "https://a.example.com", "ALLOWED_ORIGINS": "https://c.example.com"}, "https://c.example.com"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := strings.Join(loadWith(t, tc.env).AllowedOrigins, ","); got != tc.want {
+				t.Errorf("AllowedOrigins = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -45,6 +45,11 @@ func Initialize(dbPath string) (*DB, error) {
 		return nil, err
 	}
 
+	// Apply tables and columns added after the latest schema version
+	if err := database.ensureSchemaExtensions(); err != nil {
+		return nil, err
+	}
+
 	return database, nil
 }
 
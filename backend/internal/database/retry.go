@@ -0,0 +1,45 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	// writeRetryAttempts is the total number of tries for a busy write
+	writeRetryAttempts = 5
+	// writeRetryBaseDelay doubles after each busy attempt (50ms, 100ms, 200ms, 400ms)
+	writeRetryBaseDelay = 50 * time.Millisecond
+)
+
+// Execer is implemented by *sql.DB, *sql.Tx and *DB
+type Execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// IsBusyError reports whether err is SQLite's SQLITE_BUSY or SQLITE_LOCKED
+func IsBusyError(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
+
+// ExecWithRetry runs a write and retries it with exponential backoff while the
+// database is busy. The busy timeout covers most contention, but concurrent scans,
+// uploads and cleanups can still outlast it. Other errors are returned immediately.
+func ExecWithRetry(db Execer, query string, args ...interface{}) (sql.Result, error) {
+	delay := writeRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		result, err := db.Exec(query, args...)
+		if err == nil || !IsBusyError(err) || attempt == writeRetryAttempts {
+			return result, err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
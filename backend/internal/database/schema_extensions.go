@@ -0,0 +1,177 @@
+package database
+
+import (
+	"fmt"
+	"log"
+)
+
+// schemaExtensions contains tables added on top of schema v5.
+// Every statement must be idempotent because it runs on each startup.
+const schemaExtensions = `
+-- Server Secrets (服务器密钥 - kept out of system_settings and the settings API)
+CREATE TABLE IF NOT EXISTS server_secrets (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- File Access Stats (文件访问统计 - aggregated across all shares)
+CREATE TABLE IF NOT EXISTS file_access_stats (
+    file_id INTEGER PRIMARY KEY,
+    view_count INTEGER NOT NULL DEFAULT 0,
+    download_count INTEGER NOT NULL DEFAULT 0,
+    last_accessed DATETIME,
+    FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE
+);
+
+-- Event Subscriptions (事件订阅 - outbound webhooks)
+CREATE TABLE IF NOT EXISTS event_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_type TEXT NOT NULL, -- e.g. 'file.indexed', or '*' for all events
+    url TEXT NOT NULL,
+    secret TEXT, -- HMAC-SHA256 signing secret (optional)
+    enabled BOOLEAN DEFAULT 1,
+    created_by INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_subscriptions_event_type ON event_subscriptions(event_type);
+
+-- Background Jobs (后台任务 - long-running operations with pollable status)
+CREATE TABLE IF NOT EXISTS jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    type TEXT NOT NULL, -- e.g. 'folder_scan'
+    status TEXT NOT NULL, -- 'queued', 'running', 'done', 'failed'
+    progress INTEGER NOT NULL DEFAULT 0, -- 0-100
+    result TEXT, -- JSON
+    error TEXT,
+    created_by INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    started_at DATETIME,
+    finished_at DATETIME,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status);
+
+-- User Preferences (用户偏好 - UI settings synced across devices)
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, key),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Album Collaborators (相册协作者 - albums shared with other users)
+CREATE TABLE IF NOT EXISTS album_collaborators (
+    album_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    permission TEXT NOT NULL CHECK(permission IN ('read', 'write')),
+    added_by INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (album_id, user_id),
+    FOREIGN KEY (album_id) REFERENCES albums_v2(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (added_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_album_collaborators_user ON album_collaborators(user_id);
+
+-- File Comments (文件评论 - review notes on individual files)
+CREATE TABLE IF NOT EXISTS file_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    file_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_file_comments_file ON file_comments(file_id, created_at);
+
+-- Access Tokens (分享访问令牌 - issued per share visit, revocable)
+CREATE TABLE IF NOT EXISTS access_tokens (
+    token_hash TEXT PRIMARY KEY,
+    share_id TEXT NOT NULL,
+    resource_id INTEGER NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (share_id) REFERENCES shares(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_access_tokens_share ON access_tokens(share_id);
+
+-- Share Digests (分享动态摘要 - when each user last received the activity digest)
+CREATE TABLE IF NOT EXISTS share_digests (
+    user_id INTEGER PRIMARY KEY,
+    last_sent_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+`
+
+// columnExtension describes a column added to an existing table after schema v5
+type columnExtension struct {
+	table      string
+	column     string
+	definition string
+}
+
+// columnExtensions lists columns added to existing tables after schema v5
+var columnExtensions = []columnExtension{
+	{"shares", "title", "TEXT NOT NULL DEFAULT ''"},
+	{"shares", "description", "TEXT NOT NULL DEFAULT ''"},
+	{"photo_metadata", "is_screenshot", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"photo_metadata", "phash", "INTEGER"},                         // 64-bit dHash for similar-photo grouping
+	{"folders", "scan_thumbnail_size", "TEXT NOT NULL DEFAULT ''"}, // '' = use global setting, 'none' = disabled
+	{"sessions", "impersonated_by", "INTEGER REFERENCES users(id) ON DELETE CASCADE"},
+	{"sessions", "impersonation_read_only", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"sessions", "last_activity_at", "DATETIME"},              // NULL = no request since created_at
+	{"shares", "strip_exif", "BOOLEAN NOT NULL DEFAULT 0"},    // Serve public downloads without embedded metadata
+	{"files", "content_hash", "TEXT"},                         // Hex SHA-256 of the content, filled on first use
+	{"folders", "index_hidden", "TEXT NOT NULL DEFAULT ''"},   // '' = use global setting, 'true' or 'false'
+	{"albums_v2", "default_sort", "TEXT NOT NULL DEFAULT ''"}, // '' = taken_at DESC
+	{"folders", "media_types", "TEXT NOT NULL DEFAULT ''"},    // '' = images and videos, 'image' or 'video'
+	{"albums_v2", "emptied_at", "DATETIME"},                   // Set when cleanup left the album without files
+	{"shares", "allowed_emails", "TEXT NOT NULL DEFAULT ''"},  // Comma separated addresses or @domain patterns, '' = anyone
+	{"users", "email_verified", "BOOLEAN NOT NULL DEFAULT 0"}, // Set for addresses entered by an administrator
+	{"files", "content_hash_mtime", "INTEGER"},                // Source mtime (Unix nanoseconds) the content hash was computed at
+}
+
+// ensureSchemaExtensions creates tables and columns added after schema v5
+func (db *DB) ensureSchemaExtensions() error {
+	if _, err := db.Exec(schemaExtensions); err != nil {
+		return fmt.Errorf("failed to apply schema extensions: %w", err)
+	}
+
+	for _, ext := range columnExtensions {
+		if err := db.ensureColumn(ext.table, ext.column, ext.definition); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureColumn adds a column to a table if it does not exist yet
+func (db *DB) ensureColumn(table, column, definition string) error {
+	var columnExists int
+	err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&columnExists)
+	if err != nil {
+		return err
+	}
+	if columnExists > 0 {
+		return nil
+	}
+
+	log.Printf("Adding %s column to %s table...", column, table)
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add %s.%s: %w", table, column, err)
+	}
+	log.Printf("✓ Added %s column to %s table", column, table)
+	return nil
+}
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"fmt"
+	"log"
+
 	"github.com/gofiber/fiber/v2"
 
 	"awesome-sharing/internal/models"
@@ -8,30 +11,61 @@ import (
 )
 
 const (
-	UserContextKey = "user"
+	UserContextKey    = "user"
+	SessionContextKey = "session"
+)
+
+// Names of the session cookie and of the header carrying "Bearer <session ID>"
+var (
+	sessionCookieName = "session_id"
+	sessionHeaderName = fiber.HeaderAuthorization
 )
 
+// SetSessionNames configures the session cookie and token header names, so several
+// instances can share a domain. Empty names keep the defaults. Must be called before
+// serving requests.
+func SetSessionNames(cookieName, headerName string) {
+	if cookieName != "" {
+		sessionCookieName = cookieName
+	}
+	if headerName != "" {
+		sessionHeaderName = headerName
+	}
+}
+
+// SessionCookieName returns the name of the session cookie
+func SessionCookieName() string {
+	return sessionCookieName
+}
+
+// SessionHeaderName returns the name of the header carrying the session token
+func SessionHeaderName() string {
+	return sessionHeaderName
+}
+
 // AuthMiddleware creates a middleware that validates session and injects user into context
 func AuthMiddleware(authService *services.AuthService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Get session ID from cookie
-		sessionID := c.Cookies("session_id")
+		sessionID := GetSessionID(c)
 		if sessionID == "" {
-			// Also check Authorization header
-			sessionID = c.Get("Authorization")
-			if sessionID != "" && len(sessionID) > 7 && sessionID[:7] == "Bearer " {
-				sessionID = sessionID[7:]
+			// Visitors without a session browse as the anonymous guest account, if enabled
+			if guest, err := authService.GetAnonymousGuest(); err == nil {
+				c.Locals(UserContextKey, guest)
+				return handleGuestRequest(c)
 			}
-		}
-
-		if sessionID == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "No session provided",
 			})
 		}
 
 		// Validate session
-		user, err := authService.ValidateSession(sessionID)
+		session, err := authService.GetValidSession(sessionID)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid or expired session",
+			})
+		}
+		user, err := authService.GetUserByID(session.UserID)
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Invalid or expired session",
@@ -45,24 +79,53 @@ func AuthMiddleware(authService *services.AuthService) fiber.Handler {
 			})
 		}
 
-		// Store user in context
+		// Keep the session from idling out
+		if err := authService.TouchSession(session); err != nil {
+			log.Printf("Failed to record activity of session for user %d: %v", user.ID, err)
+		}
+
+		// Store user and session in context
 		c.Locals(UserContextKey, user)
+		c.Locals(SessionContextKey, session)
+
+		// Guests stay read-only, even when impersonated
+		if user.Role == services.GuestRole {
+			return handleGuestRequest(c)
+		}
+
+		if session.ImpersonatedBy != nil {
+			return handleImpersonatedRequest(c, authService, user, session)
+		}
 
 		return c.Next()
 	}
 }
 
+// handleImpersonatedRequest runs a request made with an impersonation session.
+// Mutating requests are blocked for read-only impersonation (except ending it) and
+// recorded in the target user's activity log otherwise.
+func handleImpersonatedRequest(c *fiber.Ctx, authService *services.AuthService, user *models.User, session *models.Session) error {
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return c.Next()
+	}
+
+	if session.ImpersonationReadOnly && c.Path() != "/api/auth/impersonation/end" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Read-only impersonation session",
+		})
+	}
+
+	err := c.Next()
+	authService.LogUserActivity(user.ID, *session.ImpersonatedBy, "impersonated_request",
+		fmt.Sprintf(`{"method":%q,"path":%q,"status":%d}`, c.Method(), c.Path(), c.Response().StatusCode()), c.IP())
+	return err
+}
+
 // OptionalAuthMiddleware is like AuthMiddleware but doesn't fail if no session
 func OptionalAuthMiddleware(authService *services.AuthService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		sessionID := c.Cookies("session_id")
-		if sessionID == "" {
-			sessionID = c.Get("Authorization")
-			if sessionID != "" && len(sessionID) > 7 && sessionID[:7] == "Bearer " {
-				sessionID = sessionID[7:]
-			}
-		}
-
+		sessionID := GetSessionID(c)
 		if sessionID != "" {
 			user, err := authService.ValidateSession(sessionID)
 			if err == nil && user.Enabled {
@@ -134,6 +197,20 @@ func AdminOrOwnerMiddleware() fiber.Handler {
 	}
 }
 
+// GetSessionID returns the session ID from the session cookie or the token header
+func GetSessionID(c *fiber.Ctx) string {
+	// Get session ID from cookie
+	sessionID := c.Cookies(sessionCookieName)
+	if sessionID == "" {
+		// Also check the token header
+		sessionID = c.Get(sessionHeaderName)
+		if sessionID != "" && len(sessionID) > 7 && sessionID[:7] == "Bearer " {
+			sessionID = sessionID[7:]
+		}
+	}
+	return sessionID
+}
+
 // GetUser retrieves the user from the fiber context
 func GetUser(c *fiber.Ctx) *models.User {
 	user := c.Locals(UserContextKey)
@@ -143,6 +220,15 @@ func GetUser(c *fiber.Ctx) *models.User {
 	return user.(*models.User)
 }
 
+// GetSession retrieves the current session from the fiber context
+func GetSession(c *fiber.Ctx) *models.Session {
+	session := c.Locals(SessionContextKey)
+	if session == nil {
+		return nil
+	}
+	return session.(*models.Session)
+}
+
 // IsAdmin checks if the current user is an admin
 func IsAdmin(c *fiber.Ctx) bool {
 	user := GetUser(c)
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// basePathStrippedKey marks requests whose base path was already removed
+const basePathStrippedKey = "base_path_stripped"
+
+// BasePathMiddleware serves the routes under basePath (e.g. "/photos") as well as at the
+// root, so a reverse proxy may forward subpath requests with or without the prefix.
+// Register it before any other handler.
+func BasePathMiddleware(basePath string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Locals(basePathStrippedKey) != nil {
+			return c.Next()
+		}
+
+		path := c.Path()
+		if path != basePath && !strings.HasPrefix(path, basePath+"/") {
+			return c.Next()
+		}
+
+		// Route again with the prefix removed; the flag keeps "/photos/photos/..." intact
+		stripped := strings.TrimPrefix(path, basePath)
+		if stripped == "" {
+			stripped = "/"
+		}
+		c.Locals(basePathStrippedKey, true)
+		c.Path(stripped)
+		return c.RestartRouting()
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimitMiddleware rejects request bodies larger than limit bytes, except on the
+// streamPaths routes, which enforce their own limits. The server streams request bodies
+// over the limit instead of buffering them, so this is what keeps a large POST from being
+// read into memory. Chunked bodies are read up to the limit before any handler sees them.
+//
+// A streamed body left unread would be parsed as the next request on the connection, so
+// every request whose body may not be consumed closes its connection.
+func BodyLimitMiddleware(limit int, streamPaths ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req := c.Request()
+		length := req.Header.ContentLength()
+
+		for _, path := range streamPaths {
+			if c.Path() == path {
+				if length < 0 || length > limit {
+					c.Context().SetConnectionClose()
+				}
+				return c.Next()
+			}
+		}
+
+		if length > limit {
+			c.Context().SetConnectionClose()
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+				"error": "Request body too large",
+			})
+		}
+
+		// Without a Content-Length the stream length is unknown: buffer at most limit bytes
+		if length < 0 && req.IsBodyStream() {
+			body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+			if err != nil {
+				c.Context().SetConnectionClose()
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Failed to read request body",
+				})
+			}
+			if len(body) > limit {
+				c.Context().SetConnectionClose()
+				return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+					"error": "Request body too large",
+				})
+			}
+			req.SetBody(body)
+		}
+
+		return c.Next()
+	}
+}
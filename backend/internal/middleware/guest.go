@@ -0,0 +1,25 @@
+package middleware
+
+import "github.com/gofiber/fiber/v2"
+
+// guestAllowedPaths are the mutating requests guests may still make
+var guestAllowedPaths = map[string]bool{
+	"/api/auth/logout":            true,
+	"/api/auth/impersonation/end": true,
+}
+
+// handleGuestRequest runs a request made by a guest. Guests are read-only, so mutating
+// requests are rejected whatever their permission grants say.
+func handleGuestRequest(c *fiber.Ctx) error {
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return c.Next()
+	}
+
+	if guestAllowedPaths[c.Path()] {
+		return c.Next()
+	}
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error": "Guests have read-only access",
+	})
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"awesome-sharing/internal/services"
+)
+
+// ReadOnlyMiddleware rejects mutating requests while the server is in read-only mode.
+// Server owners are exempt so they can still manage the server (and turn the mode off).
+// Must run after AuthMiddleware/OptionalAuthMiddleware to see the current user.
+func ReadOnlyMiddleware(settingsService *services.SettingsService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		}
+
+		if IsServerOwner(c) {
+			return c.Next()
+		}
+
+		readOnly, err := settingsService.IsReadOnly()
+		if err == nil && readOnly {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": "Server is in read-only mode",
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"log"
+	"runtime/debug"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+// panicContextKey marks requests whose handler panicked
+const panicContextKey = "panicked"
+
+// RecoverMiddleware turns a panic in a later handler into a 500 JSON response carrying
+// only the request ID, and logs the panic with its stack. Register it after the
+// requestid middleware so the ID is available.
+func RecoverMiddleware() fiber.Handler {
+	recoverPanics := recover.New(recover.Config{
+		EnableStackTrace: true,
+		StackTraceHandler: func(c *fiber.Ctx, e interface{}) {
+			c.Locals(panicContextKey, true)
+			log.Printf("panic in %s %s (request %s): %v\n%s",
+				c.Method(), c.Path(), c.GetRespHeader(fiber.HeaderXRequestID), e, debug.Stack())
+		},
+	})
+
+	return func(c *fiber.Ctx) error {
+		err := recoverPanics(c)
+		if err == nil || c.Locals(panicContextKey) == nil {
+			return err
+		}
+
+		// The panic value may contain internals, so it stays in the log
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":      "Internal server error",
+			"request_id": c.GetRespHeader(fiber.HeaderXRequestID),
+		})
+	}
+}
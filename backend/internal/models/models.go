@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // User represents a system user
 type User struct {
@@ -22,6 +25,12 @@ type Session struct {
 	UserID    int64     `json:"user_id"`
 	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
+
+	LastActivityAt *time.Time `json:"last_activity_at,omitempty"` // Last authenticated request, recorded at most once a minute
+
+	// Impersonation (set when a server owner acts as this user)
+	ImpersonatedBy        *int64 `json:"impersonated_by,omitempty"`
+	ImpersonationReadOnly bool   `json:"impersonation_read_only,omitempty"`
 }
 
 // UserActivityLog represents an audit log entry for user management actions
@@ -44,6 +53,28 @@ type Folder struct {
 	CreatedBy    int64     `json:"created_by"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Thumbnail size generated at scan time: '' = global setting, 'none' = disabled
+	ScanThumbnailSize string `json:"scan_thumbnail_size,omitempty"`
+
+	// Index dot-prefixed files and directories: '' = global setting, 'true' or 'false'
+	IndexHidden string `json:"index_hidden,omitempty"`
+
+	// Media types indexed by the scanner: '' = images and videos, 'image' or 'video'
+	MediaTypes string `json:"media_types,omitempty"`
+}
+
+// FolderGroupRef is a short reference to a permission group containing a folder
+type FolderGroupRef struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// FolderWithStats is a folder augmented with its file count and permission groups (admin list)
+type FolderWithStats struct {
+	Folder
+	FileCount        int              `json:"file_count"`
+	PermissionGroups []FolderGroupRef `json:"permission_groups"`
 }
 
 // FileFolderMapping represents the mapping between files and folders (文件到文件夹的映射)
@@ -91,7 +122,8 @@ type File struct {
 	IsThumbnail   bool       `json:"is_thumbnail"`
 	ParentFileID  *int64     `json:"parent_file_id,omitempty"`
 	ThumbnailURL  string     `json:"thumbnail_url,omitempty"`
-	AbsolutePath  string     `json:"absolute_path,omitempty"` // Computed field, not stored in DB
+	Thumbnails    map[string]string `json:"thumbnails,omitempty"` // Size name -> thumbnail URL
+	AbsolutePath  string     `json:"-"` // Computed field for internal use, never exposed in API responses
 
 	// Photo-specific fields (joined from photo_metadata table for images)
 	// These fields will be populated via LEFT JOIN for backward compatibility in API responses
@@ -100,6 +132,12 @@ type File struct {
 	TakenAt       *time.Time `json:"taken_at,omitempty"`
 }
 
+// SimilarFile is a file whose perceptual hash is close to a reference file
+type SimilarFile struct {
+	File
+	Distance int `json:"distance"` // Hamming distance between perceptual hashes (0 = identical)
+}
+
 // PhotoMetadata represents photo-specific metadata extracted from EXIF
 type PhotoMetadata struct {
 	ID       int64     `json:"id"`
@@ -130,10 +168,21 @@ type PhotoMetadata struct {
 	// Orientation
 	Orientation int       `json:"orientation"`
 
+	// Classified at scan time: no camera info/GPS and screen-sized, or screenshot filename
+	IsScreenshot bool     `json:"is_screenshot"`
+
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// FileAccessStats represents view/download counters for a file, aggregated across all shares
+type FileAccessStats struct {
+	FileID        int64      `json:"file_id"`
+	ViewCount     int        `json:"view_count"`
+	DownloadCount int        `json:"download_count"`
+	LastAccessed  *time.Time `json:"last_accessed,omitempty"`
+}
+
 // ImageThumbnail represents a generated thumbnail for an image
 type ImageThumbnail struct {
 	ID        int64     `json:"id"`
@@ -148,13 +197,43 @@ type ImageThumbnail struct {
 
 // Album represents a collection of files with soft links
 type Album struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description,omitempty"`
-	OwnerID     int64     `json:"owner_id"`
-	CoverFileID *int64    `json:"cover_file_id,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	OwnerID     int64      `json:"owner_id"`
+	CoverFileID *int64     `json:"cover_file_id,omitempty"`
+	DefaultSort string     `json:"default_sort,omitempty"` // Item order used when a request has no sort
+	Access      string     `json:"access,omitempty"`       // Requesting user's access in listings: 'owner', 'read' or 'write'
+	EmptiedAt   *time.Time `json:"emptied_at,omitempty"`   // Set when file cleanup left the album without files
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// AlbumOverview is an album with its owner and file count, as listed for admins
+type AlbumOverview struct {
+	Album
+	OwnerUsername string `json:"owner_username"`
+	FileCount     int    `json:"file_count"` // Files the album's folder rules currently match
+}
+
+// FileComment is a note left by a user on a file
+type FileComment struct {
+	ID        int64     `json:"id"`
+	FileID    int64     `json:"file_id"`
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AlbumCollaborator is a user an album is shared with
+type AlbumCollaborator struct {
+	AlbumID    int64     `json:"album_id"`
+	UserID     int64     `json:"user_id"`
+	Username   string    `json:"username"`
+	Permission string    `json:"permission"` // 'read' or 'write'
+	AddedBy    *int64    `json:"added_by"` // NULL once the user who added them is deleted
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // AlbumItem represents a soft link to a file via folder + relative path
@@ -176,6 +255,25 @@ type AlbumFolder struct {
 	AddedAt    time.Time `json:"added_at"`
 }
 
+// AlbumFolderDetail is an album folder configuration with its folder's details and match count
+type AlbumFolderDetail struct {
+	AlbumFolder
+	FolderName   string `json:"folder_name"`
+	AbsolutePath string `json:"absolute_path"`
+	Enabled      bool   `json:"enabled"`
+	FileCount    int    `json:"file_count"` // Files matching folder + path prefix
+}
+
+// AlbumFolderStatus reports the health of an album folder configuration
+type AlbumFolderStatus struct {
+	AlbumFolder
+	FolderName   string `json:"folder_name,omitempty"`
+	FolderExists bool   `json:"folder_exists"`
+	Enabled      bool   `json:"enabled"`
+	FileCount    int    `json:"file_count"`
+	Status       string `json:"status"` // 'ok', 'missing', 'disabled', or 'empty'
+}
+
 // Tag represents a label for files
 type Tag struct {
 	ID        int64     `json:"id"`
@@ -199,19 +297,49 @@ type SystemSetting struct {
 
 // Share represents a shareable link
 type Share struct {
-	ID           string     `json:"id"` // Short ID
-	ShareType    string     `json:"share_type"` // 'file' or 'album'
-	ResourceID   int64      `json:"resource_id"`
-	OwnerID      int64      `json:"owner_id"`
-	AccessType   string     `json:"access_type"` // 'public' or 'private'
-	PasswordHash string     `json:"-"` // Optional password (not exposed to frontend)
-	HasPassword  bool       `json:"has_password"` // Whether password is set (for frontend display)
-	RequiresAuth bool       `json:"requires_auth"` // Whether authentication is required
-	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
-	MaxViews     *int       `json:"max_views,omitempty"`
-	ViewCount    int        `json:"view_count"`
-	Enabled      bool       `json:"enabled"`
-	CreatedAt    time.Time  `json:"created_at"`
+	ID            string     `json:"id"`         // Short ID
+	ShareType     string     `json:"share_type"` // 'file' or 'album'
+	ResourceID    int64      `json:"resource_id"`
+	OwnerID       int64      `json:"owner_id"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`              // Note shown on the share landing page
+	AccessType    string     `json:"access_type"`              // 'public' or 'private'
+	PasswordHash  string     `json:"-"`                        // Optional password (not exposed to frontend)
+	HasPassword   bool       `json:"has_password"`             // Whether password is set (for frontend display)
+	RequiresAuth  bool       `json:"requires_auth"`            // Whether authentication is required
+	StripEXIF     bool       `json:"strip_exif"`               // Remove EXIF/XMP (GPS, camera) from downloaded images
+	AllowedEmails []string   `json:"allowed_emails,omitempty"` // Logged-in users allowed to view: addresses or @domain patterns
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	MaxViews      *int       `json:"max_views,omitempty"`
+	ViewCount     int        `json:"view_count"`
+	Enabled       bool       `json:"enabled"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// EventSubscription represents an outbound webhook for library events
+type EventSubscription struct {
+	ID        int64     `json:"id"`
+	EventType string    `json:"event_type"` // e.g. 'file.indexed', or '*' for all events
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`          // Signing secret (not exposed to frontend)
+	HasSecret bool      `json:"has_secret"` // Whether payloads are signed
+	Enabled   bool      `json:"enabled"`
+	CreatedBy int64     `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Job represents a long-running background operation
+type Job struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`     // e.g. 'folder_scan'
+	Status     string          `json:"status"`   // 'queued', 'running', 'done', or 'failed'
+	Progress   int             `json:"progress"` // 0-100
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedBy  int64           `json:"created_by"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
 }
 
 // SharePermission represents user access to a private share
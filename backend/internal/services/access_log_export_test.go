@@ -0,0 +1,96 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"testing"
+)
+
+func TestExportAccessLog(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewShareService(db, nil)
+
+	owner := seedUser(t, db, "owner", "user", "owner@example.com", true)
+	visitor := seedUser(t, db, "visitor", "user", "visitor@example.com", true)
+	folder := seedFolder(t, db, "/photos", owner)
+	file := seedFile(t, db, folder, "a.jpg", "image")
+
+	share, err := svc.CreateShare("file", file, owner, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	other, err := svc.CreateShare("file", file, owner, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	// More than one page of entries, plus one for another share that must not appear
+	total := 2*accessLogExportPageSize + 3
+	for i := 0; i < total; i++ {
+		mustExec(t, db, "INSERT INTO share_access_log (share_id, ip_address, user_agent) VALUES (?, ?, 'curl/8.0')",
+			share.ID, fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+	}
+	if err := svc.LogAccess(share.ID, &visitor, "192.0.2.1", "=HYPERLINK(\"http://evil\")"); err != nil {
+		t.Fatalf("log access: %v", err)
+	}
+	if err := svc.LogAccess(other.ID, nil, "198.51.100.1", "other"); err != nil {
+		t.Fatalf("log access: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := svc.ExportAccessLog(share.ID, &buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+
+	if got := records[0]; len(got) != 4 || got[0] != "accessed_at" || got[3] != "accessed_by" {
+		t.Fatalf("unexpected header %v", got)
+	}
+	rows := records[1:]
+	if len(rows) != total+1 {
+		t.Fatalf("got %d rows, want %d", len(rows), total+1)
+	}
+
+	// Oldest first, no entry repeated across pages
+	seen := map[string]bool{}
+	for _, row := range rows[:total] {
+		if seen[row[1]] {
+			t.Fatalf("duplicate row for %s", row[1])
+		}
+		seen[row[1]] = true
+	}
+	if rows[0][1] != "10.0.0.0" {
+		t.Errorf("first row ip %q, want 10.0.0.0", rows[0][1])
+	}
+
+	last := rows[total]
+	if last[1] != "192.0.2.1" || last[3] != "visitor" {
+		t.Errorf("last row %v, want visitor from 192.0.2.1", last)
+	}
+	if last[2] != "'=HYPERLINK(\"http://evil\")" {
+		t.Errorf("formula cell not escaped: %q", last[2])
+	}
+}
+
+func TestCSVCell(t *testing.T) {
+	cases := map[string]string{
+		"":            "",
+		"Mozilla/5.0": "Mozilla/5.0",
+		"=1+1":        "'=1+1",
+		"+1":          "'+1",
+		"-1":          "'-1",
+		"@SUM(A1)":    "'@SUM(A1)",
+		"\tindented":  "'\tindented",
+		"a=b":         "a=b",
+		"2001:db8::1": "2001:db8::1",
+	}
+	for input, want := range cases {
+		if got := csvCell(input); got != want {
+			t.Errorf("csvCell(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRevokeAccessTokens(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewShareService(db, nil)
+	shareID := seedFileShare(t, svc)
+	owner := seedUser(t, db, "other-owner", "user", "", false)
+	otherFolder := seedFolder(t, db, "/other", owner)
+	otherShare, err := svc.CreateShare("file", seedFile(t, db, otherFolder, "b.jpg", "image"), owner, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	first, _ := svc.GenerateAccessToken(shareID)
+	second, _ := svc.GenerateAccessToken(shareID)
+	other, _ := svc.GenerateAccessToken(otherShare.ID)
+
+	// Only the hash of a token is stored
+	var stored int
+	db.QueryRow("SELECT COUNT(*) FROM access_tokens WHERE token_hash IN (?, ?)", first, hashAccessToken(first)).Scan(&stored)
+	if stored != 1 {
+		t.Fatalf("found %d stored rows for the token, want only its hash", stored)
+	}
+
+	revoked, err := svc.RevokeAccessTokens(shareID)
+	if err != nil || revoked != 2 {
+		t.Fatalf("RevokeAccessTokens = %d, %v, want 2", revoked, err)
+	}
+	for _, token := range []string{first, second} {
+		if _, _, err := svc.ValidateAccessToken(token); !errors.Is(err, ErrTokenRevoked) {
+			t.Errorf("revoked token: err = %v, want ErrTokenRevoked", err)
+		}
+	}
+	if _, _, err := svc.ValidateAccessToken(other); err != nil {
+		t.Errorf("another share's token was revoked: %v", err)
+	}
+
+	// New visits get working tokens, and revoking again skips already revoked ones
+	fresh, _ := svc.GenerateAccessToken(shareID)
+	if _, _, err := svc.ValidateAccessToken(fresh); err != nil {
+		t.Errorf("token issued after revocation: %v", err)
+	}
+	if revoked, _ := svc.RevokeAccessTokens(shareID); revoked != 1 {
+		t.Errorf("second revocation revoked %d tokens, want 1", revoked)
+	}
+}
+
+func TestAccessTokenRecord(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewShareService(db, nil)
+	shareID := seedFileShare(t, svc)
+
+	expired, _ := svc.GenerateAccessToken(shareID)
+	mustExec(t, db, "UPDATE access_tokens SET expires_at = ? WHERE token_hash = ?", time.Now().Add(-time.Minute), hashAccessToken(expired))
+	if _, _, err := svc.ValidateAccessToken(expired); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("expired token: err = %v, want ErrTokenExpired", err)
+	}
+
+	// A correctly signed token that was never recorded is rejected
+	unrecorded, _ := svc.GenerateAccessToken(shareID)
+	mustExec(t, db, "DELETE FROM access_tokens WHERE token_hash = ?", hashAccessToken(unrecorded))
+	if _, _, err := svc.ValidateAccessToken(unrecorded); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("unrecorded token: err = %v, want ErrInvalidToken", err)
+	}
+
+	// Issuing a token clears the share's expired records
+	if _, err := svc.GenerateAccessToken(shareID); err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	var remaining int
+	db.QueryRow("SELECT COUNT(*) FROM access_tokens WHERE token_hash = ?", hashAccessToken(expired)).Scan(&remaining)
+	if remaining != 0 {
+		t.Error("expired token record was not cleaned up")
+	}
+}
@@ -1,8 +1,11 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"awesome-sharing/internal/models"
@@ -10,8 +13,46 @@ import (
 
 var (
 	ErrAlbumNotFound = errors.New("album not found")
+	ErrFolderAccessDenied = errors.New("no access to folder")
+	ErrInvalidAlbumSort = errors.New("invalid album sort order")
+	ErrInvalidCollaboratorPermission = errors.New("collaborator permission must be 'read' or 'write'")
+	ErrCollaboratorIsOwner = errors.New("album owner cannot be a collaborator")
 )
 
+// defaultAlbumSort orders album items when neither the request nor the album sets a sort
+const defaultAlbumSort = "taken_at DESC"
+
+// albumSortColumns are the columns album items can be ordered by
+var albumSortColumns = map[string]bool{
+	"taken_at":   true,
+	"filename":   true,
+	"size":       true,
+	"width":      true,
+	"height":     true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// NormalizeAlbumSort validates a "column [asc|desc]" sort order against the allowed
+// columns and returns it in canonical form (e.g. "filename ASC")
+func NormalizeAlbumSort(sortOrder string) (string, error) {
+	fields := strings.Fields(strings.ToLower(sortOrder))
+	if len(fields) == 0 || len(fields) > 2 || !albumSortColumns[fields[0]] {
+		return "", ErrInvalidAlbumSort
+	}
+	direction := "ASC"
+	if len(fields) == 2 {
+		switch fields[1] {
+		case "asc":
+		case "desc":
+			direction = "DESC"
+		default:
+			return "", ErrInvalidAlbumSort
+		}
+	}
+	return fields[0] + " " + direction, nil
+}
+
 type AlbumService struct {
 	db *sql.DB
 }
@@ -42,10 +83,10 @@ func (s *AlbumService) CreateAlbum(name, description string, ownerID int64) (*mo
 func (s *AlbumService) GetAlbum(id int64) (*models.Album, error) {
 	var album models.Album
 	err := s.db.QueryRow(`
-		SELECT id, name, description, owner_id, cover_file_id, created_at, updated_at
+		SELECT id, name, description, owner_id, cover_file_id, default_sort, emptied_at, created_at, updated_at
 		FROM albums_v2 WHERE id = ?
 	`, id).Scan(&album.ID, &album.Name, &album.Description, &album.OwnerID,
-		&album.CoverFileID, &album.CreatedAt, &album.UpdatedAt)
+		&album.CoverFileID, &album.DefaultSort, &album.EmptiedAt, &album.CreatedAt, &album.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrAlbumNotFound
@@ -57,13 +98,17 @@ func (s *AlbumService) GetAlbum(id int64) (*models.Album, error) {
 	return &album, nil
 }
 
-// ListAlbums retrieves all albums for a user
-func (s *AlbumService) ListAlbums(ownerID int64) ([]models.Album, error) {
+// ListAlbums retrieves the albums a user owns or collaborates on.
+// Each album's Access is 'owner' or the collaborator permission.
+func (s *AlbumService) ListAlbums(userID int64) ([]models.Album, error) {
 	rows, err := s.db.Query(`
-		SELECT id, name, description, owner_id, cover_file_id, created_at, updated_at
-		FROM albums_v2 WHERE owner_id = ?
-		ORDER BY created_at DESC
-	`, ownerID)
+		SELECT a.id, a.name, a.description, a.owner_id, a.cover_file_id, a.default_sort, a.emptied_at,
+			a.created_at, a.updated_at, CASE WHEN a.owner_id = ? THEN 'owner' ELSE ac.permission END
+		FROM albums_v2 a
+		LEFT JOIN album_collaborators ac ON ac.album_id = a.id AND ac.user_id = ?
+		WHERE a.owner_id = ? OR ac.user_id IS NOT NULL
+		ORDER BY a.created_at DESC
+	`, userID, userID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -73,7 +118,8 @@ func (s *AlbumService) ListAlbums(ownerID int64) ([]models.Album, error) {
 	for rows.Next() {
 		var album models.Album
 		if err := rows.Scan(&album.ID, &album.Name, &album.Description, &album.OwnerID,
-			&album.CoverFileID, &album.CreatedAt, &album.UpdatedAt); err != nil {
+			&album.CoverFileID, &album.DefaultSort, &album.EmptiedAt, &album.CreatedAt, &album.UpdatedAt,
+			&album.Access); err != nil {
 			return nil, err
 		}
 		albums = append(albums, album)
@@ -82,6 +128,102 @@ func (s *AlbumService) ListAlbums(ownerID int64) ([]models.Album, error) {
 	return albums, nil
 }
 
+// GetCollaboratorPermission returns a user's collaborator permission on an album,
+// or an empty string if the user isn't a collaborator
+func (s *AlbumService) GetCollaboratorPermission(albumID, userID int64) (string, error) {
+	var permission string
+	err := s.db.QueryRow(`
+		SELECT permission FROM album_collaborators WHERE album_id = ? AND user_id = ?
+	`, albumID, userID).Scan(&permission)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return permission, err
+}
+
+// ListCollaborators lists the users an album is shared with
+func (s *AlbumService) ListCollaborators(albumID int64) ([]models.AlbumCollaborator, error) {
+	rows, err := s.db.Query(`
+		SELECT ac.album_id, ac.user_id, u.username, ac.permission, ac.added_by, ac.created_at
+		FROM album_collaborators ac
+		INNER JOIN users u ON u.id = ac.user_id
+		WHERE ac.album_id = ?
+		ORDER BY u.username
+	`, albumID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collaborators := []models.AlbumCollaborator{}
+	for rows.Next() {
+		var collaborator models.AlbumCollaborator
+		if err := rows.Scan(&collaborator.AlbumID, &collaborator.UserID, &collaborator.Username,
+			&collaborator.Permission, &collaborator.AddedBy, &collaborator.CreatedAt); err != nil {
+			return nil, err
+		}
+		collaborators = append(collaborators, collaborator)
+	}
+
+	return collaborators, nil
+}
+
+// SetCollaborator adds a collaborator to an album or changes their permission
+func (s *AlbumService) SetCollaborator(albumID, userID int64, permission string, addedBy int64) error {
+	if permission != "read" && permission != "write" {
+		return ErrInvalidCollaboratorPermission
+	}
+
+	var ownerID int64
+	err := s.db.QueryRow("SELECT owner_id FROM albums_v2 WHERE id = ?", albumID).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		return ErrAlbumNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if ownerID == userID {
+		return ErrCollaboratorIsOwner
+	}
+
+	var userExists bool
+	if err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", userID).Scan(&userExists); err != nil {
+		return err
+	}
+	if !userExists {
+		return ErrUserNotFound
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO album_collaborators (album_id, user_id, permission, added_by)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(album_id, user_id) DO UPDATE SET permission = excluded.permission
+	`, albumID, userID, permission, addedBy)
+	return err
+}
+
+// RemoveCollaborator removes a user from an album's collaborators
+func (s *AlbumService) RemoveCollaborator(albumID, userID int64) error {
+	_, err := s.db.Exec("DELETE FROM album_collaborators WHERE album_id = ? AND user_id = ?", albumID, userID)
+	return err
+}
+
+// SetDefaultSort sets the item order used when listing an album without a sort.
+// An empty value restores the default (taken_at DESC).
+func (s *AlbumService) SetDefaultSort(id int64, sortOrder string) error {
+	if sortOrder != "" {
+		normalized, err := NormalizeAlbumSort(sortOrder)
+		if err != nil {
+			return err
+		}
+		sortOrder = normalized
+	}
+	_, err := s.db.Exec(`
+		UPDATE albums_v2 SET default_sort = ?, updated_at = ? WHERE id = ?
+	`, sortOrder, time.Now(), id)
+	return err
+}
+
 // UpdateAlbum updates album information
 func (s *AlbumService) UpdateAlbum(id int64, name, description string, coverFileID *int64) error {
 	_, err := s.db.Exec(`
@@ -98,17 +240,114 @@ func (s *AlbumService) DeleteAlbum(id int64) error {
 	return err
 }
 
+// CloneAlbum copies an album's name, description, cover and folder configurations
+// into a new album owned by ownerID. Albums have no manual items, so the clone
+// resolves to the same files as the source.
+func (s *AlbumService) CloneAlbum(sourceID, ownerID int64) (*models.Album, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO albums_v2 (name, description, owner_id, cover_file_id, default_sort)
+		SELECT name || ' (copy)', description, ?, cover_file_id, default_sort
+		FROM albums_v2 WHERE id = ?
+	`, ownerID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrAlbumNotFound
+	}
+
+	cloneID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO album_folders (album_id, folder_id, path_prefix)
+		SELECT ?, folder_id, path_prefix
+		FROM album_folders WHERE album_id = ?
+	`, cloneID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetAlbum(cloneID)
+}
+
 // ListItemsWithFiles retrieves album files directly from file_folder_mappings
-// based on album folder configurations (dynamic query, no album_items table)
-func (s *AlbumService) ListItemsWithFiles(albumID int64, sortOrder string) ([]models.File, error) {
+// based on album folder configurations (dynamic query, no album_items table).
+// sortOrder must pass NormalizeAlbumSort; empty means taken_at DESC.
+// A non-empty fileType ("image" or "video") limits the result to that type.
+// A non-zero viewerID limits the result to files that user can access through permission
+// groups; collaborators must not see files of folders they were never granted.
+func (s *AlbumService) ListItemsWithFiles(ctx context.Context, albumID int64, sortOrder, fileType string, viewerID int64) ([]models.File, error) {
+	if sortOrder == "" {
+		sortOrder = defaultAlbumSort
+	}
+	sortOrder, err := NormalizeAlbumSort(sortOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err := s.albumItemsQuery(albumID, fileType, viewerID)
+	if err != nil || query == "" {
+		return []models.File{}, err
+	}
+
+	// sortOrder was validated above, so it is safe to inline
+	return s.queryAlbumItems(ctx, query+" ORDER BY "+sortOrder, args)
+}
+
+// ListItemsPage is ListItemsWithFiles limited to one page (1-based) of limit files. Also
+// returns the number of files across all pages.
+func (s *AlbumService) ListItemsPage(ctx context.Context, albumID int64, sortOrder, fileType string, viewerID int64, page, limit int) ([]models.File, int, error) {
+	if sortOrder == "" {
+		sortOrder = defaultAlbumSort
+	}
+	sortOrder, err := NormalizeAlbumSort(sortOrder)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query, args, err := s.albumItemsQuery(albumID, fileType, viewerID)
+	if err != nil || query == "" {
+		return []models.File{}, 0, err
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM ("+query+")", args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// Ties are broken by ID, so files don't move between pages
+	query += " ORDER BY " + sortOrder + ", id LIMIT ? OFFSET ?"
+	files, err := s.queryAlbumItems(ctx, query, append(args, limit, (page-1)*limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	return files, total, nil
+}
+
+// albumItemsQuery builds the unordered query selecting the files of an album, as
+// described on ListItemsWithFiles. The query is empty when the album has no folders.
+func (s *AlbumService) albumItemsQuery(albumID int64, fileType string, viewerID int64) (string, []interface{}, error) {
 	// Get all folder configurations for this album
 	folderConfigs, err := s.ListAlbumFolders(albumID)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
 	if len(folderConfigs) == 0 {
-		return []models.File{}, nil
+		return "", nil, nil
 	}
 
 	// Build dynamic query to get all matching files
@@ -139,9 +378,9 @@ func (s *AlbumService) ListItemsWithFiles(albumID int64, sortOrder string) ([]mo
 				FROM files f
 				INNER JOIN file_folder_mappings ffm ON f.id = ffm.file_id
 				LEFT JOIN photo_metadata pm ON f.id = pm.file_id
-				WHERE ffm.folder_id = ? AND ffm.relative_path LIKE ?
+				WHERE ffm.folder_id = ? AND ffm.relative_path LIKE ? ESCAPE '\'
 			`)
-			args = append(args, config.FolderID, config.PathPrefix+"%")
+			args = append(args, config.FolderID, pathPrefixPattern(config.PathPrefix))
 		}
 	}
 
@@ -150,16 +389,23 @@ func (s *AlbumService) ListItemsWithFiles(albumID int64, sortOrder string) ([]mo
 	for i := 1; i < len(queryParts); i++ {
 		query += " UNION " + queryParts[i]
 	}
-	query += ")"
+	query += ") WHERE 1=1"
 
-	// Add ORDER BY based on sortOrder parameter
-	// Default to taken_at DESC if not specified
-	if sortOrder == "" {
-		sortOrder = "taken_at DESC"
+	if fileType != "" {
+		query += " AND file_type = ?"
+		args = append(args, fileType)
+	}
+	if viewerID != 0 {
+		query += " AND id IN (" + AccessibleFileIDsSQL + ")"
+		args = append(args, viewerID)
 	}
-	query += " ORDER BY " + sortOrder
 
-	rows, err := s.db.Query(query, args...)
+	return query, args, nil
+}
+
+// queryAlbumItems runs a query built by albumItemsQuery and scans the files
+func (s *AlbumService) queryAlbumItems(ctx context.Context, query string, args []interface{}) ([]models.File, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -174,6 +420,9 @@ func (s *AlbumService) ListItemsWithFiles(albumID int64, sortOrder string) ([]mo
 		}
 		files = append(files, f)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	return files, nil
 }
@@ -206,9 +455,9 @@ func (s *AlbumService) GetAlbumFileCount(albumID int64) (int, error) {
 			queryParts = append(queryParts, `
 				SELECT DISTINCT ffm.file_id
 				FROM file_folder_mappings ffm
-				WHERE ffm.folder_id = ? AND ffm.relative_path LIKE ?
+				WHERE ffm.folder_id = ? AND ffm.relative_path LIKE ? ESCAPE '\'
 			`)
-			args = append(args, config.FolderID, config.PathPrefix+"%")
+			args = append(args, config.FolderID, pathPrefixPattern(config.PathPrefix))
 		}
 	}
 
@@ -230,22 +479,202 @@ type FolderConfig struct {
 	PathPrefix string `json:"path_prefix"`
 }
 
-// AddFolders adds folder configurations to an album
-func (s *AlbumService) AddFolders(albumID int64, folderConfigs []FolderConfig) error {
+// AddFolders adds folder configurations to an album.
+// Every folder must exist and be accessible to the caller (admins can use any folder);
+// the batch is applied in a single transaction so a bad entry leaves the album unchanged.
+func (s *AlbumService) AddFolders(albumID int64, folderConfigs []FolderConfig, userID int64, isAdmin bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO album_folders (album_id, folder_id, path_prefix)
+		VALUES (?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
 	for _, config := range folderConfigs {
-		_, err := s.db.Exec(`
-			INSERT OR IGNORE INTO album_folders (album_id, folder_id, path_prefix)
-			VALUES (?, ?, ?)
-		`, albumID, config.FolderID, config.PathPrefix)
+		prefix, err := NormalizePathPrefix(config.PathPrefix)
 		if err != nil {
+			return fmt.Errorf("%w: %q", err, config.PathPrefix)
+		}
+
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM folders WHERE id = ?)", config.FolderID).Scan(&exists); err != nil {
 			return err
 		}
+		if !exists {
+			return fmt.Errorf("%w: %d", ErrFolderNotFound, config.FolderID)
+		}
+
+		if !isAdmin {
+			hasAccess, err := hasFolderAccess(tx, userID, config.FolderID)
+			if err != nil {
+				return err
+			}
+			if !hasAccess {
+				return fmt.Errorf("%w: %d", ErrFolderAccessDenied, config.FolderID)
+			}
+		}
+
+		if _, err := stmt.Exec(albumID, config.FolderID, prefix); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// hasFolderAccess checks whether a user reaches a folder through a permission group
+func hasFolderAccess(tx *sql.Tx, userID, folderID int64) (bool, error) {
+	var hasAccess bool
+	err := tx.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM permission_group_permissions pgp
+			INNER JOIN permission_group_folders pgf ON pgp.permission_group_id = pgf.permission_group_id
+			WHERE pgp.user_id = ? AND pgf.folder_id = ?
+		)
+	`, userID, folderID).Scan(&hasAccess)
+	return hasAccess, err
+}
+
+// CreateAutoAlbums creates one album per top-level subdirectory of a folder, owned by ownerID.
+// Each album is configured with the folder and a "<subdir>/" path prefix; subdirectories the
+// owner already has an album for are skipped. Returns the albums that were created.
+// maxAlbums caps the owner's total album count (0 = unlimited); if the new albums would
+// exceed it nothing is created and ErrAlbumLimitReached is returned.
+func (s *AlbumService) CreateAutoAlbums(folderID, ownerID int64, isAdmin bool, maxAlbums int) ([]models.Album, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM folders WHERE id = ?)", folderID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrFolderNotFound
+	}
+	if !isAdmin {
+		hasAccess, err := hasFolderAccess(tx, ownerID, folderID)
+		if err != nil {
+			return nil, err
+		}
+		if !hasAccess {
+			return nil, ErrFolderAccessDenied
+		}
+	}
+
+	rows, err := tx.Query(`
+		SELECT DISTINCT substr(relative_path, 1, instr(relative_path, '/') - 1) AS subdir
+		FROM file_folder_mappings
+		WHERE folder_id = ? AND instr(relative_path, '/') > 1
+		ORDER BY subdir
+	`, folderID)
+	if err != nil {
+		return nil, err
+	}
+	var subdirs []string
+	for rows.Next() {
+		var subdir string
+		if err := rows.Scan(&subdir); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		subdirs = append(subdirs, subdir)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var pending []string
+	for _, subdir := range subdirs {
+		var hasAlbum bool
+		err := tx.QueryRow(`
+			SELECT EXISTS(
+				SELECT 1 FROM album_folders af
+				INNER JOIN albums_v2 a ON af.album_id = a.id
+				WHERE a.owner_id = ? AND af.folder_id = ? AND af.path_prefix = ?
+			)
+		`, ownerID, folderID, subdir+"/").Scan(&hasAlbum)
+		if err != nil {
+			return nil, err
+		}
+		if !hasAlbum {
+			pending = append(pending, subdir)
+		}
+	}
+
+	if maxAlbums > 0 && len(pending) > 0 {
+		var owned int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM albums_v2 WHERE owner_id = ?", ownerID).Scan(&owned); err != nil {
+			return nil, err
+		}
+		if owned+len(pending) > maxAlbums {
+			return nil, fmt.Errorf("%w: %d albums needed, %d remaining", ErrAlbumLimitReached, len(pending), max(maxAlbums-owned, 0))
+		}
+	}
+
+	var createdIDs []int64
+	for _, subdir := range pending {
+		prefix := subdir + "/"
+
+		result, err := tx.Exec("INSERT INTO albums_v2 (name, description, owner_id) VALUES (?, '', ?)", subdir, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		albumID, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO album_folders (album_id, folder_id, path_prefix)
+			VALUES (?, ?, ?)
+		`, albumID, folderID, prefix); err != nil {
+			return nil, err
+		}
+		createdIDs = append(createdIDs, albumID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	albums := []models.Album{}
+	for _, id := range createdIDs {
+		album, err := s.GetAlbum(id)
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, *album)
 	}
-	return nil
+	return albums, nil
 }
 
 // RemoveFolder removes a folder configuration from an album
 func (s *AlbumService) RemoveFolder(albumID, folderID int64, pathPrefix string) error {
+	// Match the stored form; fall back to the raw value for prefixes saved before normalization
+	if normalized, err := NormalizePathPrefix(pathPrefix); err == nil && normalized != pathPrefix {
+		result, err := s.db.Exec(`
+			DELETE FROM album_folders
+			WHERE album_id = ? AND folder_id = ? AND path_prefix = ?
+		`, albumID, folderID, normalized)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			return nil
+		}
+	}
+
 	_, err := s.db.Exec(`
 		DELETE FROM album_folders
 		WHERE album_id = ? AND folder_id = ? AND path_prefix = ?
@@ -279,3 +708,125 @@ func (s *AlbumService) ListAlbumFolders(albumID int64) ([]models.AlbumFolder, er
 	return folders, nil
 }
 
+// ListAlbumFolderDetails retrieves a page of an album's folder configurations with each
+// folder's name, path, enabled state and the number of files the configuration matches.
+// folderID optionally restricts the list to one folder. Returns the page and the total.
+func (s *AlbumService) ListAlbumFolderDetails(albumID int64, folderID *int64, page, limit int) ([]models.AlbumFolderDetail, int, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	where := "WHERE af.album_id = ?"
+	args := []interface{}{albumID}
+	if folderID != nil {
+		where += " AND af.folder_id = ?"
+		args = append(args, *folderID)
+	}
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM album_folders af "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// Match count uses the same prefix rule as ListItemsWithFiles
+	rows, err := s.db.Query(`
+		SELECT af.id, af.album_id, af.folder_id, af.path_prefix, af.added_at,
+		       COALESCE(f.name, ''), COALESCE(f.absolute_path, ''), COALESCE(f.enabled, 0),
+		       (SELECT COUNT(DISTINCT ffm.file_id) FROM file_folder_mappings ffm
+		        WHERE ffm.folder_id = af.folder_id AND `+PathPrefixMatchSQL+`)
+		FROM album_folders af
+		LEFT JOIN folders f ON af.folder_id = f.id
+		`+where+`
+		ORDER BY af.added_at DESC
+		LIMIT ? OFFSET ?
+	`, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	folders := []models.AlbumFolderDetail{}
+	for rows.Next() {
+		var folder models.AlbumFolderDetail
+		if err := rows.Scan(&folder.ID, &folder.AlbumID, &folder.FolderID,
+			&folder.PathPrefix, &folder.AddedAt, &folder.FolderName, &folder.AbsolutePath,
+			&folder.Enabled, &folder.FileCount); err != nil {
+			return nil, 0, err
+		}
+		folders = append(folders, folder)
+	}
+
+	return folders, total, rows.Err()
+}
+
+// ValidateAlbumFolders reports the status of each folder configuration of an album,
+// explaining why an album may look empty (folder deleted, disabled, or without files)
+func (s *AlbumService) ValidateAlbumFolders(albumID int64) ([]models.AlbumFolderStatus, error) {
+	rows, err := s.db.Query(`
+		SELECT af.id, af.album_id, af.folder_id, af.path_prefix, af.added_at,
+		       f.id IS NOT NULL, COALESCE(f.name, ''), COALESCE(f.enabled, 0)
+		FROM album_folders af
+		LEFT JOIN folders f ON af.folder_id = f.id
+		WHERE af.album_id = ?
+		ORDER BY af.added_at DESC
+	`, albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []models.AlbumFolderStatus
+	for rows.Next() {
+		var st models.AlbumFolderStatus
+		if err := rows.Scan(&st.ID, &st.AlbumID, &st.FolderID, &st.PathPrefix, &st.AddedAt,
+			&st.FolderExists, &st.FolderName, &st.Enabled); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		statuses = append(statuses, st)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range statuses {
+		st := &statuses[i]
+		if !st.FolderExists {
+			st.Status = "missing"
+			continue
+		}
+
+		if err := s.db.QueryRow(`
+			SELECT COUNT(DISTINCT file_id) FROM file_folder_mappings
+			WHERE folder_id = ? AND relative_path LIKE ? ESCAPE '\'
+		`, st.FolderID, pathPrefixPattern(st.PathPrefix)).Scan(&st.FileCount); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case !st.Enabled:
+			st.Status = "disabled"
+		case st.FileCount == 0:
+			st.Status = "empty"
+		default:
+			st.Status = "ok"
+		}
+	}
+
+	return statuses, nil
+}
+
+// PruneAlbumFolders removes folder configurations whose folder no longer exists
+func (s *AlbumService) PruneAlbumFolders(albumID int64) (int64, error) {
+	result, err := s.db.Exec(`
+		DELETE FROM album_folders
+		WHERE album_id = ? AND folder_id NOT IN (SELECT id FROM folders)
+	`, albumID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func albumFolderCount(t *testing.T, svc *AlbumService, albumID int64) int {
+	t.Helper()
+	var count int
+	if err := svc.db.QueryRow("SELECT COUNT(*) FROM album_folders WHERE album_id = ?", albumID).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestAddFoldersAllOrNothing(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+
+	owner := seedUser(t, db, "owner", "user", "", false)
+	other := seedUser(t, db, "other", "user", "", false)
+	first := seedFolder(t, db, "/photos/first", owner)
+	second := seedFolder(t, db, "/photos/second", owner)
+	foreign := seedFolder(t, db, "/photos/foreign", other)
+	grantFolder(t, db, owner, first, "read")
+	grantFolder(t, db, owner, second, "read")
+
+	album, err := svc.CreateAlbum("Album", "", owner)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		configs []FolderConfig
+		isAdmin bool
+		wantErr error
+		want    int
+	}{
+		{"missing folder in the middle", []FolderConfig{{FolderID: first}, {FolderID: 9999}, {FolderID: second}}, false, ErrFolderNotFound, 0},
+		{"inaccessible folder in the middle", []FolderConfig{{FolderID: first}, {FolderID: foreign}, {FolderID: second}}, false, ErrFolderAccessDenied, 0},
+		{"accessible folders", []FolderConfig{{FolderID: first}, {FolderID: second, PathPrefix: "2024/"}}, false, nil, 2},
+		{"duplicates are ignored", []FolderConfig{{FolderID: first}}, false, nil, 2},
+		{"admin may use any folder", []FolderConfig{{FolderID: foreign}}, true, nil, 3},
+	}
+	for _, tc := range cases {
+		err := svc.AddFolders(album.ID, tc.configs, owner, tc.isAdmin)
+		if tc.wantErr == nil && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.wantErr)
+		}
+		if got := albumFolderCount(t, svc, album.ID); got != tc.want {
+			t.Errorf("%s: album has %d folder configs, want %d", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"awesome-sharing/internal/models"
+)
+
+// ErrInvalidAlbumListSort is returned for admin album listings with an unknown sort order
+var ErrInvalidAlbumListSort = errors.New("sort must be name, owner, file_count or created_at, optionally followed by asc or desc")
+
+// albumListSortColumns maps the sort keys of the admin album listing to SQL expressions
+var albumListSortColumns = map[string]string{
+	"name":       "a.name COLLATE NOCASE",
+	"owner":      "u.username COLLATE NOCASE",
+	"file_count": "file_count",
+	"created_at": "a.created_at",
+}
+
+// albumFileCountSQL counts the distinct files an album's folder rules match, as GetAlbumFileCount does
+const albumFileCountSQL = `(SELECT COUNT(DISTINCT ffm.file_id) FROM album_folders af
+	INNER JOIN file_folder_mappings ffm ON ffm.folder_id = af.folder_id
+	WHERE af.album_id = a.id AND ` + PathPrefixMatchSQL + `)`
+
+// ListAllAlbums returns a page of every user's albums with owner username and file count,
+// ordered by sortOrder ("created_at desc" when empty). ownerID optionally restricts the
+// list to one owner. Returns the page and the total.
+func (s *AlbumService) ListAllAlbums(ctx context.Context, ownerID *int64, sortOrder string, page, limit int) ([]models.AlbumOverview, int, error) {
+	if sortOrder == "" {
+		sortOrder = "created_at desc"
+	}
+	fields := strings.Fields(strings.ToLower(sortOrder))
+	if len(fields) == 0 || len(fields) > 2 || albumListSortColumns[fields[0]] == "" {
+		return nil, 0, ErrInvalidAlbumListSort
+	}
+	direction := "ASC"
+	if len(fields) == 2 {
+		switch fields[1] {
+		case "asc":
+		case "desc":
+			direction = "DESC"
+		default:
+			return nil, 0, ErrInvalidAlbumListSort
+		}
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	where := ""
+	var args []interface{}
+	if ownerID != nil {
+		where = "WHERE a.owner_id = ?"
+		args = append(args, *ownerID)
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM albums_v2 a "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// id breaks ties so pages don't overlap
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.id, a.name, COALESCE(a.description, ''), a.owner_id, a.cover_file_id, a.default_sort,
+		       a.emptied_at, a.created_at, a.updated_at, COALESCE(u.username, ''), `+albumFileCountSQL+` AS file_count
+		FROM albums_v2 a
+		LEFT JOIN users u ON u.id = a.owner_id
+		`+where+`
+		ORDER BY `+albumListSortColumns[fields[0]]+` `+direction+`, a.id `+direction+`
+		LIMIT ? OFFSET ?
+	`, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	albums := []models.AlbumOverview{}
+	for rows.Next() {
+		var album models.AlbumOverview
+		if err := rows.Scan(&album.ID, &album.Name, &album.Description, &album.OwnerID,
+			&album.CoverFileID, &album.DefaultSort, &album.EmptiedAt, &album.CreatedAt, &album.UpdatedAt,
+			&album.OwnerUsername, &album.FileCount); err != nil {
+			return nil, 0, err
+		}
+		albums = append(albums, album)
+	}
+
+	return albums, total, rows.Err()
+}
+
+// AlbumFileCounts computes the file count of many albums in one query, keyed by album ID.
+// userID restricts it to the albums the user owns or collaborates on (as ListAlbums);
+// nil counts every album. Counts match GetAlbumFileCount.
+func (s *AlbumService) AlbumFileCounts(ctx context.Context, userID *int64) (map[int64]int, error) {
+	where := ""
+	var args []interface{}
+	if userID != nil {
+		where = `WHERE a.owner_id = ? OR EXISTS (
+			SELECT 1 FROM album_collaborators ac WHERE ac.album_id = a.id AND ac.user_id = ?)`
+		args = append(args, *userID, *userID)
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT a.id, "+albumFileCountSQL+" FROM albums_v2 a "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[int64]int{}
+	for rows.Next() {
+		var albumID int64
+		var count int
+		if err := rows.Scan(&albumID, &count); err != nil {
+			return nil, err
+		}
+		counts[albumID] = count
+	}
+
+	return counts, rows.Err()
+}
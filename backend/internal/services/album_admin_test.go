@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestListAllAlbums(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+
+	alice := seedUser(t, db, "alice", "user", "", false)
+	bob := seedUser(t, db, "Bob", "user", "", false)
+	folder := seedFolder(t, db, "/photos", alice)
+	seedFile(t, db, folder, "2024/a.jpg", "image")
+	seedFile(t, db, folder, "2024/b.jpg", "image")
+	seedFile(t, db, folder, "2023/c.jpg", "image")
+
+	seeds := []struct {
+		name    string
+		owner   int64
+		prefix  string
+		created string
+	}{
+		{"beach", alice, "", "2024-03-01"},
+		{"Attic", bob, "2023", "2024-01-01"},
+		{"zoo", alice, "-", "2024-02-01"},
+	}
+	ids := map[string]int64{}
+	for _, s := range seeds {
+		album, err := svc.CreateAlbum(s.name, "", s.owner)
+		if err != nil {
+			t.Fatalf("create %s: %v", s.name, err)
+		}
+		ids[s.name] = album.ID
+		mustExec(t, db, "UPDATE albums_v2 SET created_at = ? WHERE id = ?", s.created, album.ID)
+		if s.prefix != "-" {
+			mustExec(t, db, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, ?)", album.ID, folder, s.prefix)
+		}
+	}
+
+	all, total, err := svc.ListAllAlbums(context.Background(), nil, "name", 1, 50)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if total != 3 || len(all) != 3 {
+		t.Fatalf("got %d of %d albums, want 3", len(all), total)
+	}
+	want := map[string]struct {
+		owner string
+		files int
+	}{"Attic": {"Bob", 1}, "beach": {"alice", 3}, "zoo": {"alice", 0}}
+	for _, album := range all {
+		if w := want[album.Name]; album.OwnerUsername != w.owner || album.FileCount != w.files {
+			t.Errorf("%s: owner %q with %d files, want %q with %d", album.Name, album.OwnerUsername, album.FileCount, w.owner, w.files)
+		}
+	}
+
+	aliceID := alice
+	cases := []struct {
+		name  string
+		owner *int64
+		sort  string
+		page  int
+		limit int
+		want  string
+		total int
+	}{
+		{"default newest first", nil, "", 1, 50, "[beach zoo Attic]", 3},
+		{"name ignores case", nil, "name", 1, 50, "[Attic beach zoo]", 3},
+		{"owner descending ignores case", nil, "owner desc", 1, 50, "[Attic zoo beach]", 3},
+		{"file count", nil, "file_count desc", 1, 50, "[beach Attic zoo]", 3},
+		{"second page", nil, "name", 2, 2, "[zoo]", 3},
+		{"owner filter", &aliceID, "name", 1, 50, "[beach zoo]", 2},
+	}
+	for _, tc := range cases {
+		albums, total, err := svc.ListAllAlbums(context.Background(), tc.owner, tc.sort, tc.page, tc.limit)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		names := []string{}
+		for _, album := range albums {
+			names = append(names, album.Name)
+		}
+		if got := fmt.Sprint(names); got != tc.want || total != tc.total {
+			t.Errorf("%s: got %s of %d, want %s of %d", tc.name, got, total, tc.want, tc.total)
+		}
+	}
+
+	for _, sort := range []string{"size", "name sideways", "name asc extra"} {
+		if _, _, err := svc.ListAllAlbums(context.Background(), nil, sort, 1, 50); !errors.Is(err, ErrInvalidAlbumListSort) {
+			t.Errorf("sort %q: err = %v, want ErrInvalidAlbumListSort", sort, err)
+		}
+	}
+}
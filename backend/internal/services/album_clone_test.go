@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCloneAlbum(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+
+	owner := seedUser(t, db, "owner", "user", "", false)
+	copier := seedUser(t, db, "copier", "user", "", false)
+	photos := seedFolder(t, db, "/photos", owner)
+	cover := seedFile(t, db, photos, "2024/a.jpg", "image")
+	seedFile(t, db, photos, "2024/b.jpg", "image")
+	seedFile(t, db, photos, "2023/c.jpg", "image")
+	extra := seedFolder(t, db, "/extra", owner)
+	seedFile(t, db, extra, "d.jpg", "image")
+
+	source, err := svc.CreateAlbum("Holidays", "Summer trips", owner)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	if err := svc.UpdateAlbum(source.ID, source.Name, source.Description, &cover); err != nil {
+		t.Fatalf("set cover: %v", err)
+	}
+	if err := svc.SetDefaultSort(source.ID, "filename ASC"); err != nil {
+		t.Fatalf("set sort: %v", err)
+	}
+	if err := svc.AddFolders(source.ID, []FolderConfig{{FolderID: photos, PathPrefix: "2024/"}, {FolderID: extra}}, owner, true); err != nil {
+		t.Fatalf("add folders: %v", err)
+	}
+
+	clone, err := svc.CloneAlbum(source.ID, copier)
+	if err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	if clone.ID == source.ID || clone.OwnerID != copier {
+		t.Errorf("clone should be a new album owned by the caller, got %+v", clone)
+	}
+	if clone.Name != "Holidays (copy)" || clone.Description != "Summer trips" {
+		t.Errorf("clone name/description: %q / %q", clone.Name, clone.Description)
+	}
+	if clone.CoverFileID == nil || *clone.CoverFileID != cover {
+		t.Errorf("clone cover: got %v, want %d", clone.CoverFileID, cover)
+	}
+	if clone.DefaultSort != "filename ASC" {
+		t.Errorf("clone default sort: got %q", clone.DefaultSort)
+	}
+
+	folders := func(albumID int64) string {
+		configs, err := svc.ListAlbumFolders(albumID)
+		if err != nil {
+			t.Fatalf("list folders: %v", err)
+		}
+		var out []string
+		for _, c := range configs {
+			out = append(out, fmt.Sprintf("%d:%s", c.FolderID, c.PathPrefix))
+		}
+		return fmt.Sprint(out)
+	}
+	if got, want := folders(clone.ID), folders(source.ID); got != want {
+		t.Errorf("clone folders %s, want %s", got, want)
+	}
+
+	sourceCount, _ := svc.GetAlbumFileCount(source.ID)
+	cloneCount, _ := svc.GetAlbumFileCount(clone.ID)
+	if sourceCount != 3 || cloneCount != sourceCount {
+		t.Errorf("file counts: source %d, clone %d, want 3", sourceCount, cloneCount)
+	}
+
+	if _, err := svc.CloneAlbum(9999, copier); !errors.Is(err, ErrAlbumNotFound) {
+		t.Errorf("missing source: got %v, want ErrAlbumNotFound", err)
+	}
+}
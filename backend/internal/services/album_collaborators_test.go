@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestListAlbumsIncludesCollaborations(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+	alice := seedUser(t, db, "alice", "user", "", false)
+	bob := seedUser(t, db, "bob", "user", "", false)
+	carol := seedUser(t, db, "carol", "user", "", false)
+
+	own, _ := svc.CreateAlbum("Bob's own", "", bob)
+	readable, _ := svc.CreateAlbum("Trip", "", alice)
+	writable, _ := svc.CreateAlbum("Wedding", "", alice)
+	if _, err := svc.CreateAlbum("Private", "", alice); err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	if err := svc.SetCollaborator(readable.ID, bob, "read", alice); err != nil {
+		t.Fatalf("add read collaborator: %v", err)
+	}
+	if err := svc.SetCollaborator(writable.ID, bob, "write", alice); err != nil {
+		t.Fatalf("add write collaborator: %v", err)
+	}
+	if err := svc.SetCollaborator(writable.ID, carol, "read", alice); err != nil {
+		t.Fatalf("add second collaborator: %v", err)
+	}
+
+	albums, err := svc.ListAlbums(bob)
+	if err != nil {
+		t.Fatalf("list albums: %v", err)
+	}
+	access := map[int64]string{}
+	for _, album := range albums {
+		access[album.ID] = album.Access
+	}
+	want := map[int64]string{own.ID: "owner", readable.ID: "read", writable.ID: "write"}
+	if fmt.Sprint(access) != fmt.Sprint(want) {
+		t.Errorf("bob's albums = %v, want %v", access, want)
+	}
+
+	albums, _ = svc.ListAlbums(alice)
+	for _, album := range albums {
+		if album.Access != "owner" {
+			t.Errorf("owner's album %q has access %q", album.Name, album.Access)
+		}
+	}
+	if len(albums) != 3 {
+		t.Errorf("alice sees %d albums, want 3", len(albums))
+	}
+}
+
+func TestSetCollaborator(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+	alice := seedUser(t, db, "alice", "user", "", false)
+	bob := seedUser(t, db, "bob", "user", "", false)
+	album, _ := svc.CreateAlbum("Trip", "", alice)
+
+	cases := []struct {
+		name       string
+		albumID    int64
+		userID     int64
+		permission string
+		want       error
+	}{
+		{"bad permission", album.ID, bob, "admin", ErrInvalidCollaboratorPermission},
+		{"owner", album.ID, alice, "read", ErrCollaboratorIsOwner},
+		{"unknown user", album.ID, 9999, "read", ErrUserNotFound},
+		{"unknown album", album.ID + 100, bob, "read", ErrAlbumNotFound},
+		{"read", album.ID, bob, "read", nil},
+		{"upgrade to write", album.ID, bob, "write", nil},
+	}
+	for _, tc := range cases {
+		if err := svc.SetCollaborator(tc.albumID, tc.userID, tc.permission, alice); err != tc.want {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	collaborators, err := svc.ListCollaborators(album.ID)
+	if err != nil {
+		t.Fatalf("list collaborators: %v", err)
+	}
+	if len(collaborators) != 1 || collaborators[0].Username != "bob" || collaborators[0].Permission != "write" {
+		t.Fatalf("collaborators = %+v", collaborators)
+	}
+	if permission, _ := svc.GetCollaboratorPermission(album.ID, bob); permission != "write" {
+		t.Errorf("permission = %q, want write", permission)
+	}
+
+	if err := svc.RemoveCollaborator(album.ID, bob); err != nil {
+		t.Fatalf("remove collaborator: %v", err)
+	}
+	if permission, err := svc.GetCollaboratorPermission(album.ID, bob); permission != "" || err != nil {
+		t.Errorf("after removal: permission %q, err %v", permission, err)
+	}
+}
+
+func TestCollaboratorSurvivesSharerDeletion(t *testing.T) {
+	db := newTestDB(t)
+	// Foreign keys are enabled per connection, keep to one
+	db.SetMaxOpenConns(1)
+	mustExec(t, db, "PRAGMA foreign_keys = ON")
+	svc := NewAlbumService(db)
+	alice := seedUser(t, db, "alice", "user", "", false)
+	bob := seedUser(t, db, "bob", "user", "", false)
+	admin := seedUser(t, db, "admin", "admin", "", false)
+	album, _ := svc.CreateAlbum("Trip", "", alice)
+
+	if err := svc.SetCollaborator(album.ID, bob, "read", admin); err != nil {
+		t.Fatalf("add collaborator: %v", err)
+	}
+	mustExec(t, db, "DELETE FROM users WHERE id = ?", admin)
+
+	collaborators, err := svc.ListCollaborators(album.ID)
+	if err != nil {
+		t.Fatalf("list collaborators: %v", err)
+	}
+	if len(collaborators) != 1 || collaborators[0].AddedBy != nil {
+		t.Errorf("collaborators after deleting the sharer = %+v", collaborators)
+	}
+}
+
+func TestAlbumItemsLimitedToViewer(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+	alice := seedUser(t, db, "alice", "user", "", false)
+	bob := seedUser(t, db, "bob", "user", "", false)
+	shared := seedFolder(t, db, "/shared", alice)
+	private := seedFolder(t, db, "/private", alice)
+	seedFile(t, db, shared, "shared.jpg", "image")
+	seedFile(t, db, private, "private.jpg", "image")
+	grantFolder(t, db, bob, shared, "read")
+
+	album, _ := svc.CreateAlbum("Mixed", "", alice)
+	if err := svc.AddFolders(album.ID, []FolderConfig{{FolderID: shared}, {FolderID: private}}, alice, true); err != nil {
+		t.Fatalf("add folders: %v", err)
+	}
+
+	for viewer, want := range map[int64]string{0: "[private.jpg shared.jpg]", bob: "[shared.jpg]"} {
+		files, err := svc.ListItemsWithFiles(context.Background(), album.ID, "filename ASC", "", viewer)
+		if err != nil {
+			t.Fatalf("list items: %v", err)
+		}
+		var names []string
+		for _, f := range files {
+			names = append(names, f.Filename)
+		}
+		if fmt.Sprint(names) != want {
+			t.Errorf("viewer %d sees %v, want %s", viewer, names, want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAlbumFileCounts(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+
+	alice := seedUser(t, db, "alice", "user", "", false)
+	bob := seedUser(t, db, "bob", "user", "", false)
+	photos := seedFolder(t, db, "/photos", alice)
+	more := seedFolder(t, db, "/more", alice)
+	seedFile(t, db, photos, "2024/a.jpg", "image")
+	seedFile(t, db, photos, "2024/b.jpg", "image")
+	seedFile(t, db, photos, "2023/c.jpg", "image")
+	shared := seedFile(t, db, more, "2024/d.jpg", "image")
+	mustExec(t, db, "INSERT INTO file_folder_mappings (file_id, folder_id, relative_path) VALUES (?, ?, '2024/d.jpg')", shared, photos)
+
+	everything, _ := svc.CreateAlbum("everything", "", alice)
+	recent, _ := svc.CreateAlbum("recent", "", bob)
+	empty, _ := svc.CreateAlbum("empty", "", bob)
+	mustExec(t, db, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, ''), (?, ?, '')",
+		everything.ID, photos, everything.ID, more)
+	mustExec(t, db, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '2024')", recent.ID, photos)
+	if err := svc.SetCollaborator(recent.ID, alice, "read", bob); err != nil {
+		t.Fatalf("add collaborator: %v", err)
+	}
+
+	counts, err := svc.AlbumFileCounts(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("count all: %v", err)
+	}
+	if len(counts) != 3 {
+		t.Errorf("counted %d albums, want 3", len(counts))
+	}
+	for _, id := range []int64{everything.ID, recent.ID, empty.ID} {
+		want, err := svc.GetAlbumFileCount(id)
+		if err != nil {
+			t.Fatalf("count album %d: %v", id, err)
+		}
+		if counts[id] != want {
+			t.Errorf("album %d: batch count %d, want %d", id, counts[id], want)
+		}
+	}
+	if counts[everything.ID] != 4 || counts[recent.ID] != 3 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+
+	mine, err := svc.AlbumFileCounts(context.Background(), &alice)
+	if err != nil {
+		t.Fatalf("count alice's albums: %v", err)
+	}
+	if _, ok := mine[empty.ID]; len(mine) != 2 || ok {
+		t.Errorf("alice's counts %v, want her album and the one she collaborates on", mine)
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestNormalizeAlbumSort(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"filename", "filename ASC", false},
+		{"filename asc", "filename ASC", false},
+		{"  Taken_At   DESC ", "taken_at DESC", false},
+		{"size desc", "size DESC", false},
+		{"", "", true},
+		{"name asc", "", true},
+		{"filename sideways", "", true},
+		{"filename asc, size", "", true},
+		{"filename; DROP TABLE files", "", true},
+	}
+	for _, tc := range cases {
+		got, err := NormalizeAlbumSort(tc.in)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("NormalizeAlbumSort(%q) = %q, %v, want %q (error %v)", tc.in, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+// albumItemNames lists an album's items with the given sort and returns their filenames
+func albumItemNames(t *testing.T, svc *AlbumService, albumID int64, sortOrder string) []string {
+	t.Helper()
+	files, err := svc.ListItemsWithFiles(context.Background(), albumID, sortOrder, "", 0)
+	if err != nil {
+		t.Fatalf("list items (sort %q): %v", sortOrder, err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Filename)
+	}
+	return names
+}
+
+func TestAlbumDefaultSort(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+
+	owner := seedUser(t, db, "owner", "user", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	for name, takenAt := range map[string]string{
+		"b.jpg": "2024-03-01 00:00:00",
+		"c.jpg": "2024-01-01 00:00:00",
+		"a.jpg": "2024-02-01 00:00:00",
+	} {
+		id := seedFile(t, db, folder, name, "image")
+		mustExec(t, db, "INSERT INTO photo_metadata (file_id, taken_at) VALUES (?, ?)", id, takenAt)
+	}
+
+	album, err := svc.CreateAlbum("Slideshow", "", owner)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	if err := svc.AddFolders(album.ID, []FolderConfig{{FolderID: folder}}, owner, true); err != nil {
+		t.Fatalf("add folder: %v", err)
+	}
+
+	// Without a default the newest photo comes first
+	if got := albumItemNames(t, svc, album.ID, ""); fmt.Sprint(got) != "[b.jpg a.jpg c.jpg]" {
+		t.Errorf("no default: %v, want taken_at DESC", got)
+	}
+
+	if err := svc.SetDefaultSort(album.ID, "filename asc"); err != nil {
+		t.Fatalf("SetDefaultSort: %v", err)
+	}
+	album, _ = svc.GetAlbum(album.ID)
+	if album.DefaultSort != "filename ASC" {
+		t.Fatalf("stored default_sort = %q, want normalized \"filename ASC\"", album.DefaultSort)
+	}
+	if got := albumItemNames(t, svc, album.ID, album.DefaultSort); fmt.Sprint(got) != "[a.jpg b.jpg c.jpg]" {
+		t.Errorf("default sort: %v, want filename order", got)
+	}
+
+	// Clones keep the default
+	clone, err := svc.CloneAlbum(album.ID, owner)
+	if err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	if clone.DefaultSort != "filename ASC" {
+		t.Errorf("clone default_sort = %q", clone.DefaultSort)
+	}
+
+	if err := svc.SetDefaultSort(album.ID, "random"); err != ErrInvalidAlbumSort {
+		t.Errorf("invalid default: err = %v, want ErrInvalidAlbumSort", err)
+	}
+	if err := svc.SetDefaultSort(album.ID, ""); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if album, _ = svc.GetAlbum(album.ID); album.DefaultSort != "" {
+		t.Errorf("after reset default_sort = %q", album.DefaultSort)
+	}
+
+	if _, err := svc.ListItemsWithFiles(context.Background(), album.ID, "filename; --", "", 0); err != ErrInvalidAlbumSort {
+		t.Errorf("invalid sort: err = %v, want ErrInvalidAlbumSort", err)
+	}
+}
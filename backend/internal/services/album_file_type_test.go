@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestAlbumItemsFilteredByType(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	for name, fileType := range map[string]string{"a.jpg": "image", "b.mp4": "video", "c.jpg": "image", "d.mov": "video"} {
+		seedFile(t, db, folder, name, fileType)
+	}
+	album, _ := svc.CreateAlbum("Mixed", "", owner)
+	mustExec(t, db, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '')", album.ID, folder)
+
+	for fileType, want := range map[string]string{
+		"":      "[a.jpg b.mp4 c.jpg d.mov]",
+		"image": "[a.jpg c.jpg]",
+		"video": "[b.mp4 d.mov]",
+	} {
+		files, err := svc.ListItemsWithFiles(context.Background(), album.ID, "filename ASC", fileType, 0)
+		if err != nil {
+			t.Fatalf("list items (type %q): %v", fileType, err)
+		}
+		var names []string
+		for _, f := range files {
+			names = append(names, f.Filename)
+		}
+		if got := fmt.Sprint(names); got != want {
+			t.Errorf("type %q: %s, want %s", fileType, got, want)
+		}
+	}
+
+	// The filter keeps the requested sort
+	files, _ := svc.ListItemsWithFiles(context.Background(), album.ID, "filename DESC", "video", 0)
+	if len(files) != 2 || files[0].Filename != "d.mov" {
+		t.Errorf("videos by filename DESC = %+v", files)
+	}
+}
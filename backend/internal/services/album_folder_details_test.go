@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestListAlbumFolderDetails(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+
+	owner := seedUser(t, db, "owner", "user", "", false)
+	photos := seedFolder(t, db, "/photos", owner)
+	archive := seedFolder(t, db, "/archive", owner)
+	mustExec(t, db, "UPDATE folders SET enabled = 0 WHERE id = ?", archive)
+	seedFile(t, db, photos, "2023/a.jpg", "image")
+	seedFile(t, db, photos, "2023/b.jpg", "image")
+	seedFile(t, db, photos, "2024/c.jpg", "image")
+	seedFile(t, db, archive, "old.jpg", "image")
+
+	album, err := svc.CreateAlbum("Album", "", owner)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	// Newest first, so insert with explicit, increasing timestamps
+	for i, config := range []struct {
+		folder int64
+		prefix string
+	}{
+		{photos, ""},
+		{photos, "2023/"},
+		{archive, ""},
+	} {
+		mustExec(t, db, "INSERT INTO album_folders (album_id, folder_id, path_prefix, added_at) VALUES (?, ?, ?, datetime('2024-01-01', ?))",
+			album.ID, config.folder, config.prefix, fmt.Sprintf("+%d minutes", i))
+	}
+
+	folders, total, err := svc.ListAlbumFolderDetails(album.ID, nil, 1, 100)
+	if err != nil {
+		t.Fatalf("ListAlbumFolderDetails: %v", err)
+	}
+	if total != 3 || len(folders) != 3 {
+		t.Fatalf("total %d, got %d configs, want 3", total, len(folders))
+	}
+	want := []struct {
+		name    string
+		path    string
+		prefix  string
+		enabled bool
+		count   int
+	}{
+		{"archive", "/archive", "", false, 1},
+		{"photos", "/photos", "2023/", true, 2},
+		{"photos", "/photos", "", true, 3},
+	}
+	for i, w := range want {
+		f := folders[i]
+		if f.FolderName != w.name || f.AbsolutePath != w.path || f.PathPrefix != w.prefix || f.Enabled != w.enabled || f.FileCount != w.count {
+			t.Errorf("config %d = %+v, want %+v", i, f, w)
+		}
+	}
+
+	// Paging keeps the total
+	page, total, err := svc.ListAlbumFolderDetails(album.ID, nil, 2, 2)
+	if err != nil {
+		t.Fatalf("page 2: %v", err)
+	}
+	if total != 3 || len(page) != 1 || page[0].PathPrefix != "" || page[0].FolderID != photos {
+		t.Errorf("page 2 = %+v (total %d), want the whole-folder photos config", page, total)
+	}
+
+	// Folder filter
+	filtered, total, err := svc.ListAlbumFolderDetails(album.ID, &photos, 1, 100)
+	if err != nil {
+		t.Fatalf("filtered: %v", err)
+	}
+	if total != 2 || len(filtered) != 2 {
+		t.Errorf("filtered: total %d, got %d, want 2", total, len(filtered))
+	}
+	for _, f := range filtered {
+		if f.FolderID != photos {
+			t.Errorf("filtered config from folder %d", f.FolderID)
+		}
+	}
+}
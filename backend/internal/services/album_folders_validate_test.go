@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+// insertDanglingAlbumFolder adds an album folder config pointing at a folder that
+// doesn't exist, as left behind by databases without foreign key enforcement
+func insertDanglingAlbumFolder(t *testing.T, svc *AlbumService, albumID, folderID int64) {
+	t.Helper()
+	ctx := context.Background()
+	conn, err := svc.db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+	if _, err := conn.ExecContext(ctx, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '')", albumID, folderID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateAndPruneAlbumFolders(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+	owner := seedUser(t, db, "owner", "user", "", false)
+
+	healthy := seedFolder(t, db, "/photos/healthy", owner)
+	seedFile(t, db, healthy, "trip/a.jpg", "image")
+	disabled := seedFolder(t, db, "/photos/disabled", owner)
+	seedFile(t, db, disabled, "b.jpg", "image")
+	mustExec(t, db, "UPDATE folders SET enabled = 0 WHERE id = ?", disabled)
+	empty := seedFolder(t, db, "/photos/empty", owner)
+
+	album, err := svc.CreateAlbum("Album", "", owner)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	for _, cfg := range []struct {
+		folderID int64
+		prefix   string
+	}{{healthy, "trip/"}, {healthy, "other/"}, {disabled, ""}, {empty, ""}} {
+		mustExec(t, db, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, ?)", album.ID, cfg.folderID, cfg.prefix)
+	}
+	insertDanglingAlbumFolder(t, svc, album.ID, 9999)
+
+	statuses, err := svc.ValidateAlbumFolders(album.ID)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	got := map[string]int{}
+	for _, st := range statuses {
+		got[st.Status]++
+		if st.Status == "ok" && (st.FileCount != 1 || st.PathPrefix != "trip/") {
+			t.Errorf("ok config: got %+v", st)
+		}
+		if st.Status == "missing" && (st.FolderExists || st.FolderID != 9999) {
+			t.Errorf("missing config: got %+v", st)
+		}
+	}
+	want := map[string]int{"ok": 1, "empty": 2, "disabled": 1, "missing": 1}
+	for status, n := range want {
+		if got[status] != n {
+			t.Errorf("%d configs %s, want %d (all: %v)", got[status], status, n, got)
+		}
+	}
+
+	// Pruning only drops configs of folders that no longer exist
+	removed, err := svc.PruneAlbumFolders(album.ID)
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("pruned %d configs, want 1", removed)
+	}
+	statuses, err = svc.ValidateAlbumFolders(album.ID)
+	if err != nil {
+		t.Fatalf("validate after prune: %v", err)
+	}
+	if len(statuses) != 4 {
+		t.Errorf("%d configs left after prune, want 4", len(statuses))
+	}
+}
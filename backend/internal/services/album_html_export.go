@@ -0,0 +1,174 @@
+package services
+
+import (
+	"archive/zip"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GalleryImage is one image of an HTML gallery bundle
+type GalleryImage struct {
+	Name    string                 // File name inside the bundle's images/ directory
+	Caption string                 // Shown under the image in the lightbox
+	Source  func() (string, error) // Resolves the file to copy, called while writing
+}
+
+// GalleryImageName returns a unique bundle file name for the index-th image that keeps
+// the album order; ext replaces the file's extension when set (e.g. ".jpg" for thumbnails)
+func GalleryImageName(index int, filename, ext string) string {
+	base := strings.Map(func(r rune) rune {
+		// Characters that aren't valid in file names on every platform, and '#' and '%',
+		// which index.html's relative URLs would read as a fragment or an escape
+		if r < ' ' || strings.ContainsRune(`/\:*?"<>|#%`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSuffix(filename, filepath.Ext(filename)))
+	if ext == "" {
+		ext = filepath.Ext(filename)
+	}
+	return fmt.Sprintf("%04d-%s%s", index+1, base, ext)
+}
+
+// WriteHTMLGallery writes a ZIP with the images, an index.html grid with a lightbox and its
+// CSS/JS, which opens straight from disk. Images whose source can't be read are left out
+// (and logged); index.html is written last so it only references images in the bundle.
+func WriteHTMLGallery(w io.Writer, title, description string, images []GalleryImage) error {
+	zw := zip.NewWriter(w)
+
+	included := make([]GalleryImage, 0, len(images))
+	for _, image := range images {
+		path, err := image.Source()
+		if err == nil {
+			err = copyIntoZip(zw, "images/"+image.Name, path)
+		}
+		if err != nil {
+			log.Printf("HTML gallery: skipping %s: %v", image.Name, err)
+			continue
+		}
+		included = append(included, image)
+	}
+
+	if err := writeZipText(zw, "gallery.css", galleryCSS); err != nil {
+		return err
+	}
+	if err := writeZipText(zw, "gallery.js", galleryJS); err != nil {
+		return err
+	}
+	index, err := zw.Create("index.html")
+	if err != nil {
+		return err
+	}
+	if err := galleryTemplate.Execute(index, map[string]interface{}{
+		"Title":       title,
+		"Description": description,
+		"Images":      included,
+	}); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// copyIntoZip stores a file uncompressed; images are compressed already
+func copyIntoZip(zw *zip.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	entry, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, f)
+	return err
+}
+
+func writeZipText(zw *zip.Writer, name, content string) error {
+	entry, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(entry, content)
+	return err
+}
+
+var galleryTemplate = template.Must(template.New("index.html").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="gallery.css">
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+</header>
+<main class="grid">
+{{range .Images}}<a href="images/{{.Name}}" data-caption="{{.Caption}}"><img src="images/{{.Name}}" alt="{{.Caption}}" loading="lazy"></a>
+{{end}}</main>
+<div id="lightbox" hidden>
+<button class="close" aria-label="Close">&times;</button>
+<button class="prev" aria-label="Previous">&lsaquo;</button>
+<figure><img alt=""><figcaption></figcaption></figure>
+<button class="next" aria-label="Next">&rsaquo;</button>
+</div>
+<script src="gallery.js"></script>
+</body>
+</html>
+`))
+
+const galleryCSS = `body { margin: 0; font-family: system-ui, sans-serif; background: #111; color: #eee; }
+header { padding: 1.5rem 2rem 0.5rem; }
+h1 { margin: 0 0 0.5rem; font-weight: 500; }
+.grid { display: grid; grid-template-columns: repeat(auto-fill, minmax(200px, 1fr)); gap: 6px; padding: 1rem 2rem 2rem; }
+.grid a { display: block; aspect-ratio: 1; overflow: hidden; background: #222; }
+.grid img { width: 100%; height: 100%; object-fit: cover; display: block; }
+#lightbox { position: fixed; inset: 0; background: rgba(0, 0, 0, 0.92); display: flex; align-items: center; justify-content: center; }
+#lightbox[hidden] { display: none; }
+#lightbox figure { margin: 0; text-align: center; }
+#lightbox img { max-width: 90vw; max-height: 85vh; }
+#lightbox figcaption { margin-top: 0.5rem; color: #bbb; }
+#lightbox button { position: absolute; background: none; border: 0; color: #fff; font-size: 3rem; cursor: pointer; padding: 1rem; }
+#lightbox .close { top: 0; right: 0; }
+#lightbox .prev { left: 0; }
+#lightbox .next { right: 0; }
+`
+
+const galleryJS = `(function () {
+  var links = Array.prototype.slice.call(document.querySelectorAll('.grid a'));
+  var box = document.getElementById('lightbox');
+  var img = box.querySelector('img');
+  var caption = box.querySelector('figcaption');
+  var current = 0;
+
+  function show(i) {
+    current = (i + links.length) % links.length;
+    img.src = links[current].getAttribute('href');
+    caption.textContent = links[current].getAttribute('data-caption');
+    box.hidden = false;
+  }
+
+  links.forEach(function (link, i) {
+    link.addEventListener('click', function (e) { e.preventDefault(); show(i); });
+  });
+  box.querySelector('.close').addEventListener('click', function () { box.hidden = true; });
+  box.querySelector('.prev').addEventListener('click', function () { show(current - 1); });
+  box.querySelector('.next').addEventListener('click', function () { show(current + 1); });
+  document.addEventListener('keydown', function (e) {
+    if (box.hidden) return;
+    if (e.key === 'Escape') box.hidden = true;
+    if (e.key === 'ArrowLeft') show(current - 1);
+    if (e.key === 'ArrowRight') show(current + 1);
+  });
+})();
+`
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// zipEntries reads every entry of a ZIP archive into memory, keyed by name
+func zipEntries(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	entries := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		entries[f.Name] = string(content)
+	}
+	return entries
+}
+
+func TestWriteHTMLGallery(t *testing.T) {
+	dir := t.TempDir()
+	writeTestImage(t, filepath.Join(dir, "a.png"), 8, 8)
+	writeTestImage(t, filepath.Join(dir, "b.png"), 8, 8)
+	source := func(name string) func() (string, error) {
+		return func() (string, error) { return filepath.Join(dir, name), nil }
+	}
+
+	images := []GalleryImage{
+		{Name: "0001-a.png", Caption: "a.png", Source: source("a.png")},
+		{Name: "0002-gone.png", Caption: "gone.png", Source: source("gone.png")},
+		{Name: "0003-unresolved.png", Caption: "unresolved.png", Source: func() (string, error) { return "", errors.New("no path") }},
+		{Name: "0004-b.png", Caption: "<b>b</b>", Source: source("b.png")},
+	}
+	var buf bytes.Buffer
+	if err := WriteHTMLGallery(&buf, "Summer & Sea", "Trip", images); err != nil {
+		t.Fatalf("write gallery: %v", err)
+	}
+	entries := zipEntries(t, buf.Bytes())
+
+	for _, name := range []string{"index.html", "gallery.css", "gallery.js", "images/0001-a.png", "images/0004-b.png"} {
+		if _, ok := entries[name]; !ok {
+			t.Errorf("bundle is missing %s", name)
+		}
+	}
+	index := entries["index.html"]
+	for _, ref := range []string{`src="images/0001-a.png"`, `src="images/0004-b.png"`, "Summer &amp; Sea", "&lt;b&gt;b&lt;/b&gt;"} {
+		if !strings.Contains(index, ref) {
+			t.Errorf("index.html does not contain %s", ref)
+		}
+	}
+	for _, skipped := range []string{"gone.png", "unresolved.png"} {
+		if strings.Contains(index, skipped) {
+			t.Errorf("index.html references %s, which is not in the bundle", skipped)
+		}
+		if _, ok := entries["images/"+skipped]; ok {
+			t.Errorf("bundle contains unreadable %s", skipped)
+		}
+	}
+	if strings.Contains(index, "<b>") {
+		t.Error("caption markup was not escaped")
+	}
+}
+
+func TestGalleryImageName(t *testing.T) {
+	cases := []struct {
+		index     int
+		filename  string
+		ext, want string
+	}{
+		{0, "beach.jpg", "", "0001-beach.jpg"},
+		{9, "beach.HEIC", ".jpg", "0010-beach.jpg"},
+		{1, `a:b*c?"d".png`, "", "0002-a_b_c__d_.png"},
+		{2, "#1 100%.jpg", "", "0003-_1 100_.jpg"},
+	}
+	for _, tc := range cases {
+		if got := GalleryImageName(tc.index, tc.filename, tc.ext); got != tc.want {
+			t.Errorf("GalleryImageName(%d, %q, %q) = %q, want %q", tc.index, tc.filename, tc.ext, got, tc.want)
+		}
+	}
+}
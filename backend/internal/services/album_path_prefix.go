@@ -0,0 +1,42 @@
+package services
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+// ErrInvalidPathPrefix is returned for album folder prefixes that leave the folder root
+var ErrInvalidPathPrefix = errors.New("path_prefix must be a directory inside the folder")
+
+// PathPrefixMatchSQL matches ffm.relative_path against the stored af.path_prefix,
+// escaping LIKE wildcards so the prefix is compared literally
+const PathPrefixMatchSQL = `ffm.relative_path LIKE replace(replace(replace(af.path_prefix, '\', '\\'), '%', '\%'), '_', '\_') || '%' ESCAPE '\'`
+
+// NormalizePathPrefix turns a user supplied album folder prefix into the stored form:
+// a slash separated directory path relative to the folder root with a trailing slash,
+// or "" for the entire folder. Prefixes containing ".." segments are rejected.
+func NormalizePathPrefix(prefix string) (string, error) {
+	prefix = strings.TrimSpace(strings.ReplaceAll(prefix, "\\", "/"))
+	for _, segment := range strings.Split(prefix, "/") {
+		if segment == ".." {
+			return "", ErrInvalidPathPrefix
+		}
+	}
+
+	cleaned := strings.Trim(path.Clean("/"+prefix), "/")
+	if cleaned == "" {
+		return "", nil
+	}
+	return cleaned + "/", nil
+}
+
+// escapeLike escapes LIKE wildcards in s for use with ESCAPE '\'
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// pathPrefixPattern returns the LIKE pattern matching relative paths under prefix
+func pathPrefixPattern(prefix string) string {
+	return escapeLike(prefix) + "%"
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNormalizePathPrefix(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"/", "", false},
+		{".", "", false},
+		{"2024", "2024/", false},
+		{"2024/", "2024/", false},
+		{"/2024/summer", "2024/summer/", false},
+		{"2024//summer/./", "2024/summer/", false},
+		{`2024\summer`, "2024/summer/", false},
+		{" trips ", "trips/", false},
+		{"100%_done", "100%_done/", false},
+		{"../secret", "", true},
+		{"2024/../../etc", "", true},
+		{"..", "", true},
+	}
+	for _, tc := range cases {
+		got, err := NormalizePathPrefix(tc.in)
+		if tc.wantErr {
+			if !errors.Is(err, ErrInvalidPathPrefix) {
+				t.Errorf("NormalizePathPrefix(%q) error = %v, want ErrInvalidPathPrefix", tc.in, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("NormalizePathPrefix(%q) = %q, %v, want %q", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func TestAlbumPathPrefixMatchesLiterally(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	for _, path := range []string{"100%/a.jpg", "1000/b.jpg", "a_b/c.jpg", "axb/d.jpg", "2024/e.jpg", "2024-old/f.jpg"} {
+		seedFile(t, db, folder, path, "image")
+	}
+
+	cases := []struct {
+		prefix    string
+		wantFiles string
+	}{
+		{"100%", "[a.jpg]"},
+		{"a_b/", "[c.jpg]"},
+		// A prefix is a directory, not a name prefix
+		{"/2024", "[e.jpg]"},
+	}
+	for _, tc := range cases {
+		album, _ := svc.CreateAlbum("Prefix "+tc.prefix, "", owner)
+		if err := svc.AddFolders(album.ID, []FolderConfig{{FolderID: folder, PathPrefix: tc.prefix}}, owner, true); err != nil {
+			t.Fatalf("%q: add folder: %v", tc.prefix, err)
+		}
+		if got := fmt.Sprint(albumItemNames(t, svc, album.ID, "filename ASC")); got != tc.wantFiles {
+			t.Errorf("%q: items %s, want %s", tc.prefix, got, tc.wantFiles)
+		}
+		if count, err := svc.GetAlbumFileCount(album.ID); err != nil || count != 1 {
+			t.Errorf("%q: file count %d, %v, want 1", tc.prefix, count, err)
+		}
+		details, _, err := svc.ListAlbumFolderDetails(album.ID, nil, 1, 10)
+		if err != nil || len(details) != 1 || details[0].FileCount != 1 {
+			t.Errorf("%q: folder details %+v, %v, want one folder with 1 file", tc.prefix, details, err)
+		}
+
+		// Removing with the raw prefix finds the normalized row
+		if err := svc.RemoveFolder(album.ID, folder, tc.prefix); err != nil {
+			t.Fatalf("%q: remove folder: %v", tc.prefix, err)
+		}
+		if folders, _ := svc.ListAlbumFolders(album.ID); len(folders) != 0 {
+			t.Errorf("%q: folders after removal %+v", tc.prefix, folders)
+		}
+	}
+
+	album, _ := svc.CreateAlbum("Escape", "", owner)
+	err := svc.AddFolders(album.ID, []FolderConfig{{FolderID: folder, PathPrefix: "../etc"}}, owner, true)
+	if !errors.Is(err, ErrInvalidPathPrefix) {
+		t.Errorf("../ prefix: got %v, want ErrInvalidPathPrefix", err)
+	}
+	if folders, _ := svc.ListAlbumFolders(album.ID); len(folders) != 0 {
+		t.Errorf("rejected prefix was stored: %+v", folders)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -21,13 +22,30 @@ var (
 )
 
 type AuthService struct {
-	db *sql.DB
+	db          *sql.DB
+	idleTimeout time.Duration
+	settings    *SettingsService // Optional: registration defaults for users created without a role
 }
 
 func NewAuthService(db *sql.DB) *AuthService {
 	return &AuthService{db: db}
 }
 
+// sessionActivityInterval throttles last_activity_at updates to one write per session per interval
+const sessionActivityInterval = time.Minute
+
+// SetIdleTimeout ends sessions with no request for longer than timeout, independently
+// of their expiry (0 disables)
+func (s *AuthService) SetIdleTimeout(timeout time.Duration) {
+	s.idleTimeout = timeout
+}
+
+// SetRegistrationDefaults makes CreateUser apply the configured default role and
+// permission group to users created without a role
+func (s *AuthService) SetRegistrationDefaults(settings *SettingsService) {
+	s.settings = settings
+}
+
 // HashPassword hashes a plain password using bcrypt
 func (s *AuthService) HashPassword(password string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -42,7 +60,8 @@ func (s *AuthService) CheckPassword(password, hash string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
-// CreateUser creates a new user
+// CreateUser creates a new user. An empty role gets the registration defaults: the
+// configured default role, and membership of the default permission group.
 func (s *AuthService) CreateUser(username, password, email, role string) (*models.User, error) {
 	// Check if user exists
 	var exists bool
@@ -60,6 +79,16 @@ func (s *AuthService) CreateUser(username, password, email, role string) (*model
 		return nil, err
 	}
 
+	applyDefaults := role == ""
+	if applyDefaults {
+		role = "user"
+		if s.settings != nil {
+			if role, err = s.settings.GetDefaultUserRole(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// Insert user
 	result, err := s.db.Exec(`
 		INSERT INTO users (username, password_hash, email, role, enabled)
@@ -74,6 +103,18 @@ func (s *AuthService) CreateUser(username, password, email, role string) (*model
 		return nil, err
 	}
 
+	// Give newcomers access to the default permission group, if one is configured;
+	// guests only ever read the guest permission group
+	if role == GuestRole {
+		if err := s.syncGuestAccess(id); err != nil {
+			log.Printf("Failed to grant guest %d access to the guest permission group: %v", id, err)
+		}
+	} else if applyDefaults && s.settings != nil {
+		if _, err := s.settings.JoinDefaultPermissionGroup(id); err != nil {
+			log.Printf("Failed to add user %d to the default permission group: %v", id, err)
+		}
+	}
+
 	// Return user
 	return s.GetUserByID(id)
 }
@@ -149,13 +190,44 @@ func (s *AuthService) CreateSession(userID int64, duration time.Duration) (*mode
 	}, nil
 }
 
-// ValidateSession validates a session and returns the associated user
-func (s *AuthService) ValidateSession(sessionID string) (*models.User, error) {
+// CreateImpersonationSession creates a short-lived session for userID on behalf of impersonatorID.
+// When readOnly is set, the session may not perform mutating requests.
+func (s *AuthService) CreateImpersonationSession(userID, impersonatorID int64, duration time.Duration, readOnly bool) (*models.Session, error) {
+	sessionID, err := generateRandomID(32)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(duration)
+
+	_, err = s.db.Exec(`
+		INSERT INTO sessions (id, user_id, expires_at, impersonated_by, impersonation_read_only)
+		VALUES (?, ?, ?, ?, ?)
+	`, sessionID, userID, expiresAt, impersonatorID, readOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Session{
+		ID:                    sessionID,
+		UserID:                userID,
+		ExpiresAt:             expiresAt,
+		CreatedAt:             time.Now(),
+		ImpersonatedBy:        &impersonatorID,
+		ImpersonationReadOnly: readOnly,
+	}, nil
+}
+
+// GetValidSession returns a session if it exists and has not expired
+func (s *AuthService) GetValidSession(sessionID string) (*models.Session, error) {
 	var session models.Session
+	var impersonatedBy sql.NullInt64
+	var lastActivityAt sql.NullTime
 	err := s.db.QueryRow(`
-		SELECT id, user_id, expires_at, created_at
+		SELECT id, user_id, expires_at, created_at, last_activity_at, impersonated_by, impersonation_read_only
 		FROM sessions WHERE id = ?
-	`, sessionID).Scan(&session.ID, &session.UserID, &session.ExpiresAt, &session.CreatedAt)
+	`, sessionID).Scan(&session.ID, &session.UserID, &session.ExpiresAt, &session.CreatedAt,
+		&lastActivityAt, &impersonatedBy, &session.ImpersonationReadOnly)
 
 	if err == sql.ErrNoRows {
 		return nil, errors.New("invalid session")
@@ -170,6 +242,50 @@ func (s *AuthService) ValidateSession(sessionID string) (*models.User, error) {
 		return nil, errors.New("session expired")
 	}
 
+	// Check if session was idle too long; sessions without activity count from creation
+	if lastActivityAt.Valid {
+		session.LastActivityAt = &lastActivityAt.Time
+	}
+	if s.idleTimeout > 0 && time.Since(sessionLastActive(&session)) > s.idleTimeout {
+		s.DeleteSession(sessionID)
+		return nil, errors.New("session idle timeout")
+	}
+
+	if impersonatedBy.Valid {
+		session.ImpersonatedBy = &impersonatedBy.Int64
+	}
+
+	return &session, nil
+}
+
+// sessionLastActive returns when a session was last used: its last recorded request or its creation
+func sessionLastActive(session *models.Session) time.Time {
+	if session.LastActivityAt != nil {
+		return *session.LastActivityAt
+	}
+	return session.CreatedAt
+}
+
+// TouchSession records a request on a session, at most once per sessionActivityInterval
+func (s *AuthService) TouchSession(session *models.Session) error {
+	if time.Since(sessionLastActive(session)) < sessionActivityInterval {
+		return nil
+	}
+	now := time.Now()
+	if _, err := s.db.Exec("UPDATE sessions SET last_activity_at = ? WHERE id = ?", now, session.ID); err != nil {
+		return err
+	}
+	session.LastActivityAt = &now
+	return nil
+}
+
+// ValidateSession validates a session and returns the associated user
+func (s *AuthService) ValidateSession(sessionID string) (*models.User, error) {
+	session, err := s.GetValidSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get user
 	return s.GetUserByID(session.UserID)
 }
@@ -180,6 +296,41 @@ func (s *AuthService) DeleteSession(sessionID string) error {
 	return err
 }
 
+// DeleteAllSessions deletes every session, forcing all users to log in again
+func (s *AuthService) DeleteAllSessions() (int64, error) {
+	result, err := s.db.Exec("DELETE FROM sessions")
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteSessionsForUser deletes all sessions of a user except exceptSessionID (may be empty)
+func (s *AuthService) DeleteSessionsForUser(userID int64, exceptSessionID string) (int64, error) {
+	result, err := s.db.Exec("DELETE FROM sessions WHERE user_id = ? AND id != ?", userID, exceptSessionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// VerifyUserPassword checks a user's current password
+func (s *AuthService) VerifyUserPassword(userID int64, password string) error {
+	var passwordHash string
+	err := s.db.QueryRow("SELECT password_hash FROM users WHERE id = ?", userID).Scan(&passwordHash)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.CheckPassword(password, passwordHash); err != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
 // GetUserByID retrieves a user by ID
 func (s *AuthService) GetUserByID(id int64) (*models.User, error) {
 	var user models.User
@@ -246,20 +397,34 @@ func (s *AuthService) ListUsers() ([]models.User, error) {
 func (s *AuthService) UpdateUser(id int64, updates map[string]interface{}) error {
 	// Build dynamic update query
 	// For simplicity, we'll handle specific fields
+	// A changed address is unverified unless the update says otherwise
 	if email, ok := updates["email"]; ok {
-		_, err := s.db.Exec("UPDATE users SET email = ?, updated_at = ? WHERE id = ?",
+		_, err := s.db.Exec("UPDATE users SET email = ?, email_verified = 0, updated_at = ? WHERE id = ?",
 			email, time.Now(), id)
 		if err != nil {
 			return err
 		}
 	}
 
+	if verified, ok := updates["email_verified"]; ok {
+		_, err := s.db.Exec("UPDATE users SET email_verified = ?, updated_at = ? WHERE id = ?",
+			verified, time.Now(), id)
+		if err != nil {
+			return err
+		}
+	}
+
 	if role, ok := updates["role"]; ok {
 		_, err := s.db.Exec("UPDATE users SET role = ?, updated_at = ? WHERE id = ?",
 			role, time.Now(), id)
 		if err != nil {
 			return err
 		}
+		if role == GuestRole {
+			if err := s.syncGuestAccess(id); err != nil {
+				return err
+			}
+		}
 	}
 
 	if enabled, ok := updates["enabled"]; ok {
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// backdateSession moves a session's creation and last activity into the past
+func backdateSession(t *testing.T, svc *AuthService, sessionID string, createdAgo time.Duration, lastActivityAgo *time.Duration) {
+	t.Helper()
+	var lastActivity interface{}
+	if lastActivityAgo != nil {
+		lastActivity = time.Now().Add(-*lastActivityAgo)
+	}
+	mustExec(t, svc.db, "UPDATE sessions SET created_at = ?, last_activity_at = ? WHERE id = ?",
+		time.Now().Add(-createdAgo), lastActivity, sessionID)
+}
+
+func TestIdleTimeout(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAuthService(db)
+	user := seedUser(t, db, "alice", "user", "", false)
+
+	fiveMinutes := 5 * time.Minute
+	twoHours := 2 * time.Hour
+	cases := []struct {
+		name            string
+		idleTimeout     time.Duration
+		createdAgo      time.Duration
+		lastActivityAgo *time.Duration
+		wantValid       bool
+	}{
+		{"disabled", 0, 48 * time.Hour, nil, true},
+		{"fresh", 30 * time.Minute, time.Minute, nil, true},
+		{"never used since creation", 30 * time.Minute, time.Hour, nil, false},
+		{"recent activity", 30 * time.Minute, time.Hour, &fiveMinutes, true},
+		{"stale activity", 30 * time.Minute, 3 * time.Hour, &twoHours, false},
+	}
+	for _, tc := range cases {
+		svc.SetIdleTimeout(tc.idleTimeout)
+		session, err := svc.CreateSession(user, 7*24*time.Hour)
+		if err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+		backdateSession(t, svc, session.ID, tc.createdAgo, tc.lastActivityAgo)
+
+		_, err = svc.GetValidSession(session.ID)
+		if valid := err == nil; valid != tc.wantValid {
+			t.Errorf("%s: valid = %v (%v), want %v", tc.name, valid, err, tc.wantValid)
+		}
+
+		// Idle sessions are deleted, not just rejected
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM sessions WHERE id = ?", session.ID).Scan(&count); err != nil {
+			t.Fatalf("count sessions: %v", err)
+		}
+		if (count == 1) != tc.wantValid {
+			t.Errorf("%s: session row present = %v, want %v", tc.name, count == 1, tc.wantValid)
+		}
+	}
+}
+
+func TestTouchSessionKeepsSessionAlive(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAuthService(db)
+	svc.SetIdleTimeout(30 * time.Minute)
+	user := seedUser(t, db, "alice", "user", "", false)
+
+	session, err := svc.CreateSession(user, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	twentyMinutes := 20 * time.Minute
+	backdateSession(t, svc, session.ID, time.Hour, &twentyMinutes)
+
+	loaded, err := svc.GetValidSession(session.ID)
+	if err != nil {
+		t.Fatalf("get session: %v", err)
+	}
+	if err := svc.TouchSession(loaded); err != nil {
+		t.Fatalf("touch: %v", err)
+	}
+	if loaded.LastActivityAt == nil || time.Since(*loaded.LastActivityAt) > time.Minute {
+		t.Fatalf("last activity not updated: %v", loaded.LastActivityAt)
+	}
+
+	// Another request 15 minutes later still finds the session alive
+	fifteenMinutes := 15 * time.Minute
+	backdateSession(t, svc, session.ID, time.Hour, &fifteenMinutes)
+	if _, err := svc.GetValidSession(session.ID); err != nil {
+		t.Errorf("session after touch: %v", err)
+	}
+}
+
+func TestTouchSessionThrottlesWrites(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAuthService(db)
+	user := seedUser(t, db, "alice", "user", "", false)
+
+	session, err := svc.CreateSession(user, time.Hour)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	// A session used within the last minute is not written again
+	if err := svc.TouchSession(session); err != nil {
+		t.Fatalf("touch: %v", err)
+	}
+	var lastActivity *time.Time
+	if err := db.QueryRow("SELECT last_activity_at FROM sessions WHERE id = ?", session.ID).Scan(&lastActivity); err != nil {
+		t.Fatalf("read last activity: %v", err)
+	}
+	if lastActivity != nil {
+		t.Errorf("last_activity_at written within the throttle interval: %v", lastActivity)
+	}
+}
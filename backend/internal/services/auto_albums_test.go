@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCreateAutoAlbumsPerSubdirectory(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+
+	owner := seedUser(t, db, "owner", "user", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	grantFolder(t, db, owner, folder, "read")
+	seedFile(t, db, folder, "2023/a.jpg", "image")
+	seedFile(t, db, folder, "2023/summer/b.jpg", "image")
+	seedFile(t, db, folder, "2024/c.jpg", "image")
+	seedFile(t, db, folder, "loose.jpg", "image")
+
+	albums, err := svc.CreateAutoAlbums(folder, owner, false, 0)
+	if err != nil {
+		t.Fatalf("CreateAutoAlbums: %v", err)
+	}
+	if len(albums) != 2 {
+		t.Fatalf("created %d albums, want 2", len(albums))
+	}
+	for i, name := range []string{"2023", "2024"} {
+		album := albums[i]
+		if album.Name != name || album.OwnerID != owner {
+			t.Errorf("album %d = %q owned by %d, want %q owned by %d", i, album.Name, album.OwnerID, name, owner)
+		}
+		folders, err := svc.ListAlbumFolders(album.ID)
+		if err != nil {
+			t.Fatalf("list folders: %v", err)
+		}
+		if len(folders) != 1 || folders[0].FolderID != folder || folders[0].PathPrefix != name+"/" {
+			t.Errorf("album %q configs = %+v, want folder %d prefix %q", name, folders, folder, name+"/")
+		}
+	}
+
+	// A second run finds albums for every subdirectory and creates nothing
+	albums, err = svc.CreateAutoAlbums(folder, owner, false, 0)
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if len(albums) != 0 {
+		t.Errorf("second run created %d albums, want 0", len(albums))
+	}
+
+	// A new subdirectory only gets its own album
+	seedFile(t, db, folder, "2025/d.jpg", "image")
+	albums, err = svc.CreateAutoAlbums(folder, owner, false, 0)
+	if err != nil {
+		t.Fatalf("third run: %v", err)
+	}
+	if len(albums) != 1 || albums[0].Name != "2025" {
+		t.Errorf("third run = %+v, want only 2025", albums)
+	}
+}
+
+func TestCreateAutoAlbumsAccess(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+
+	owner := seedUser(t, db, "owner", "user", "", false)
+	other := seedUser(t, db, "other", "user", "", false)
+	admin := seedUser(t, db, "admin", "admin", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	seedFile(t, db, folder, "2023/a.jpg", "image")
+
+	if _, err := svc.CreateAutoAlbums(folder+100, admin, true, 0); !errors.Is(err, ErrFolderNotFound) {
+		t.Errorf("missing folder: err = %v, want ErrFolderNotFound", err)
+	}
+	if _, err := svc.CreateAutoAlbums(folder, other, false, 0); !errors.Is(err, ErrFolderAccessDenied) {
+		t.Errorf("no access: err = %v, want ErrFolderAccessDenied", err)
+	}
+
+	// Albums are per owner, so another owner's album doesn't block the admin's
+	grantFolder(t, db, owner, folder, "read")
+	if _, err := svc.CreateAutoAlbums(folder, owner, false, 0); err != nil {
+		t.Fatalf("owner: %v", err)
+	}
+	albums, err := svc.CreateAutoAlbums(folder, admin, true, 0)
+	if err != nil {
+		t.Fatalf("admin: %v", err)
+	}
+	if len(albums) != 1 || albums[0].OwnerID != admin {
+		t.Errorf("admin albums = %+v, want one owned by the admin", albums)
+	}
+}
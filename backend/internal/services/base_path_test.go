@@ -0,0 +1,28 @@
+package services
+
+import (
+	"testing"
+
+	"awesome-sharing/internal/database"
+	"awesome-sharing/internal/models"
+)
+
+func TestBasePathInURLs(t *testing.T) {
+	SetBasePath("/photos")
+	t.Cleanup(func() { SetBasePath("") })
+
+	if got := BasePath(); got != "/photos" {
+		t.Errorf("BasePath() = %q, want /photos", got)
+	}
+	got := BuildBaseURL(&models.DomainConfig{Protocol: "https", Domain: "example.com", Port: "8443"})
+	if want := "https://example.com:8443/photos"; got != want {
+		t.Errorf("BuildBaseURL = %q, want %q", got, want)
+	}
+
+	db := newTestDB(t)
+	mustExec(t, db, "INSERT INTO domain_config (protocol, domain, port) VALUES ('https', 'example.com', '443')")
+	url, err := NewDomainConfigService(&database.DB{DB: db}).GetFullURL()
+	if err != nil || url != "https://example.com/photos" {
+		t.Errorf("GetFullURL() = %q, %v", url, err)
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"strings"
+)
+
+// albumCandidateBatch bounds the number of file IDs bound in one query
+const albumCandidateBatch = 500
+
+// CleanupAlbum is an album left without files by a cleanup run
+type CleanupAlbum struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Action string `json:"action"` // 'kept', 'flagged' or 'removed'
+}
+
+// CleanupResult summarizes a full validation and cleanup run
+type CleanupResult struct {
+	Deleted     int            `json:"deleted"`
+	EmptyAlbums []CleanupAlbum `json:"empty_albums"`
+	Partial     bool           `json:"partial"` // The run hit its time limit; the next run continues where it stopped
+}
+
+// albumsContaining returns the albums whose folder configurations match any of the files.
+// Must run before the files are deleted, since their mappings cascade away.
+func (s *FileValidatorService) albumsContaining(fileIDs []int64) (map[int64]bool, error) {
+	albums := map[int64]bool{}
+	for start := 0; start < len(fileIDs); start += albumCandidateBatch {
+		end := start + albumCandidateBatch
+		if end > len(fileIDs) {
+			end = len(fileIDs)
+		}
+		batch := fileIDs[start:end]
+
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		rows, err := s.db.Query(`
+			SELECT DISTINCT af.album_id
+			FROM album_folders af
+			JOIN file_folder_mappings ffm ON ffm.folder_id = af.folder_id
+			WHERE ffm.file_id IN (?`+strings.Repeat(",?", len(batch)-1)+`) AND `+PathPrefixMatchSQL,
+			args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var albumID int64
+			if err := rows.Scan(&albumID); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			albums[albumID] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return albums, nil
+}
+
+// albumHasFiles reports whether any folder configuration of an album still matches a file
+func (s *FileValidatorService) albumHasFiles(albumID int64) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM album_folders af
+			JOIN file_folder_mappings ffm ON ffm.folder_id = af.folder_id
+			WHERE af.album_id = ? AND `+PathPrefixMatchSQL+`
+		)
+	`, albumID).Scan(&exists)
+	return exists, err
+}
+
+// handleEmptiedAlbums applies the cleanup_empty_albums policy to the candidate albums
+// that no longer match any file, and clears the flag of albums that have files again
+func (s *FileValidatorService) handleEmptiedAlbums(candidates map[int64]bool) ([]CleanupAlbum, error) {
+	policy, err := s.settings.GetCleanupEmptyAlbums()
+	if err != nil {
+		return nil, err
+	}
+
+	emptied := []CleanupAlbum{}
+	for albumID := range candidates {
+		hasFiles, err := s.albumHasFiles(albumID)
+		if err != nil {
+			return nil, err
+		}
+		if hasFiles {
+			continue
+		}
+
+		album := CleanupAlbum{ID: albumID, Action: "kept"}
+		if err := s.db.QueryRow("SELECT name FROM albums_v2 WHERE id = ?", albumID).Scan(&album.Name); err != nil {
+			continue // Removed meanwhile
+		}
+
+		switch policy {
+		case EmptyAlbumsFlag:
+			if _, err := s.db.Exec("UPDATE albums_v2 SET emptied_at = CURRENT_TIMESTAMP WHERE id = ?", albumID); err != nil {
+				return nil, err
+			}
+			album.Action = "flagged"
+		case EmptyAlbumsRemove:
+			if _, err := s.db.Exec("DELETE FROM albums_v2 WHERE id = ?", albumID); err != nil {
+				return nil, err
+			}
+			album.Action = "removed"
+		}
+		emptied = append(emptied, album)
+	}
+
+	// A rescan may have brought files back into flagged albums
+	if _, err := s.db.Exec(`
+		UPDATE albums_v2 SET emptied_at = NULL
+		WHERE emptied_at IS NOT NULL AND EXISTS(
+			SELECT 1 FROM album_folders af
+			JOIN file_folder_mappings ffm ON ffm.folder_id = af.folder_id
+			WHERE af.album_id = albums_v2.id AND ` + PathPrefixMatchSQL + `
+		)
+	`); err != nil {
+		return nil, err
+	}
+
+	return emptied, nil
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCleanupEmptiedAlbums(t *testing.T) {
+	cases := []struct {
+		policy     string
+		wantAction string
+	}{
+		{"", "kept"},
+		{EmptyAlbumsKeep, "kept"},
+		{EmptyAlbumsFlag, "flagged"},
+		{EmptyAlbumsRemove, "removed"},
+	}
+	for _, tc := range cases {
+		db := newTestDB(t)
+		settings := NewSettingsService(db)
+		albums := NewAlbumService(db)
+		validator := NewFileValidatorService(db, NewFolderService(db), settings, nil)
+		if tc.policy != "" {
+			if err := settings.SetSetting("cleanup_empty_albums", tc.policy); err != nil {
+				t.Fatalf("%q: set policy: %v", tc.policy, err)
+			}
+		}
+
+		owner := seedUser(t, db, "owner", "user", "", false)
+		goneRoot, keptRoot := t.TempDir(), t.TempDir()
+		gone := seedFolder(t, db, goneRoot, owner)
+		kept := seedFolder(t, db, keptRoot, owner)
+		seedFile(t, db, gone, "deleted.jpg", "image")
+		seedFile(t, db, kept, "deleted.jpg", "image")
+		writeDiskFile(t, keptRoot, "present.jpg", []byte("x"))
+		seedFile(t, db, kept, "present.jpg", "image")
+
+		emptied, _ := albums.CreateAlbum("Emptied", "", owner)
+		survivor, _ := albums.CreateAlbum("Survivor", "", owner)
+		albums.AddFolders(emptied.ID, []FolderConfig{{FolderID: gone}}, owner, true)
+		albums.AddFolders(survivor.ID, []FolderConfig{{FolderID: kept}}, owner, true)
+
+		result, err := validator.CleanupAllInvalidFiles(context.Background())
+		if err != nil {
+			t.Fatalf("%q: cleanup: %v", tc.policy, err)
+		}
+		if result.Deleted != 2 {
+			t.Errorf("%q: deleted %d files, want 2", tc.policy, result.Deleted)
+		}
+		want := CleanupAlbum{ID: emptied.ID, Name: "Emptied", Action: tc.wantAction}
+		if len(result.EmptyAlbums) != 1 || result.EmptyAlbums[0] != want {
+			t.Errorf("%q: empty albums %+v, want [%+v]", tc.policy, result.EmptyAlbums, want)
+		}
+
+		album, err := albums.GetAlbum(emptied.ID)
+		switch tc.wantAction {
+		case "removed":
+			if err != ErrAlbumNotFound {
+				t.Errorf("%q: emptied album still exists (%v)", tc.policy, err)
+			}
+		case "flagged":
+			if err != nil || album.EmptiedAt == nil {
+				t.Errorf("%q: emptied album not flagged: %+v, %v", tc.policy, album, err)
+			}
+		default:
+			if err != nil || album.EmptiedAt != nil {
+				t.Errorf("%q: emptied album changed: %+v, %v", tc.policy, album, err)
+			}
+		}
+		if album, err := albums.GetAlbum(survivor.ID); err != nil || album.EmptiedAt != nil {
+			t.Errorf("%q: album with files left: %+v, %v", tc.policy, album, err)
+		}
+	}
+}
+
+func TestCleanupClearsFlagOfRefilledAlbum(t *testing.T) {
+	db := newTestDB(t)
+	settings := NewSettingsService(db)
+	albums := NewAlbumService(db)
+	validator := NewFileValidatorService(db, NewFolderService(db), settings, nil)
+	settings.SetSetting("cleanup_empty_albums", EmptyAlbumsFlag)
+
+	owner := seedUser(t, db, "owner", "user", "", false)
+	root := t.TempDir()
+	folder := seedFolder(t, db, root, owner)
+	seedFile(t, db, folder, "deleted.jpg", "image")
+	album, _ := albums.CreateAlbum("Trip", "", owner)
+	albums.AddFolders(album.ID, []FolderConfig{{FolderID: folder}}, owner, true)
+
+	if _, err := validator.CleanupAllInvalidFiles(context.Background()); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if got, _ := albums.GetAlbum(album.ID); got.EmptiedAt == nil {
+		t.Fatal("album not flagged")
+	}
+
+	// A rescan brings a file back; the next cleanup clears the flag
+	writeDiskFile(t, root, "restored.jpg", []byte("x"))
+	seedFile(t, db, folder, "restored.jpg", "image")
+	seedFile(t, db, folder, "deleted-again.jpg", "image")
+	result, err := validator.CleanupAllInvalidFiles(context.Background())
+	if err != nil {
+		t.Fatalf("second cleanup: %v", err)
+	}
+	if len(result.EmptyAlbums) != 0 {
+		t.Errorf("empty albums %+v, want none", result.EmptyAlbums)
+	}
+	if got, _ := albums.GetAlbum(album.ID); got.EmptiedAt != nil {
+		t.Error("flag of an album with files again was not cleared")
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"os"
+	"time"
+)
+
+// ContentHashService stores SHA-256 content hashes of files, computed on first use
+type ContentHashService struct {
+	db *sql.DB
+}
+
+func NewContentHashService(db *sql.DB) *ContentHashService {
+	return &ContentHashService{db: db}
+}
+
+// ComputeContentHash returns the hex SHA-256 of a file's content
+func ComputeContentHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// GetOrCompute returns the stored content hash of a file, hashing path and storing
+// the result when the file has none yet
+func (s *ContentHashService) GetOrCompute(fileID int64, path string) (string, error) {
+	return s.getOrCompute(fileID, path, time.Time{})
+}
+
+// GetOrComputeCurrent is like GetOrCompute, but rehashes the file when modTime differs
+// from the source mtime recorded with the stored hash, so edits in place get a new hash.
+// Hashes stored before mtimes were recorded are kept and adopt modTime.
+func (s *ContentHashService) GetOrComputeCurrent(fileID int64, path string, modTime time.Time) (string, error) {
+	return s.getOrCompute(fileID, path, modTime)
+}
+
+// Stored returns the stored content hash of a file without reading the file. With a
+// non-zero modTime, only a hash recorded for that source mtime is returned.
+func (s *ContentHashService) Stored(fileID int64, modTime time.Time) (string, bool) {
+	var stored sql.NullString
+	var storedMtime sql.NullInt64
+	err := s.db.QueryRow("SELECT content_hash, content_hash_mtime FROM files WHERE id = ?", fileID).Scan(&stored, &storedMtime)
+	if err != nil || !stored.Valid || stored.String == "" {
+		return "", false
+	}
+	if !modTime.IsZero() && (!storedMtime.Valid || storedMtime.Int64 != modTime.UnixNano()) {
+		return "", false
+	}
+	return stored.String, true
+}
+
+func (s *ContentHashService) getOrCompute(fileID int64, path string, modTime time.Time) (string, error) {
+	var stored sql.NullString
+	var storedMtime sql.NullInt64
+	err := s.db.QueryRow("SELECT content_hash, content_hash_mtime FROM files WHERE id = ?", fileID).Scan(&stored, &storedMtime)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	if stored.Valid && stored.String != "" {
+		if modTime.IsZero() || storedMtime.Int64 == modTime.UnixNano() {
+			return stored.String, nil
+		}
+		if !storedMtime.Valid {
+			_, err := s.db.Exec("UPDATE files SET content_hash_mtime = ? WHERE id = ?", modTime.UnixNano(), fileID)
+			return stored.String, err
+		}
+	}
+
+	if modTime.IsZero() {
+		if info, err := os.Stat(path); err == nil {
+			modTime = info.ModTime()
+		}
+	}
+	hash, err := ComputeContentHash(path)
+	if err != nil {
+		return "", err
+	}
+	var mtime sql.NullInt64
+	if !modTime.IsZero() {
+		mtime = sql.NullInt64{Int64: modTime.UnixNano(), Valid: true}
+	}
+	if _, err := s.db.Exec("UPDATE files SET content_hash = ?, content_hash_mtime = ? WHERE id = ?", hash, mtime, fileID); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContentHashGetOrCompute(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewContentHashService(db)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	file := seedFile(t, db, folder, "a.txt", "other")
+
+	path := filepath.Join(t.TempDir(), "a.txt")
+	os.WriteFile(path, []byte("hello"), 0644)
+
+	hash, err := svc.GetOrCompute(file, path)
+	if err != nil {
+		t.Fatalf("GetOrCompute: %v", err)
+	}
+	const want = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" // sha256("hello")
+	if hash != want {
+		t.Errorf("hash = %s, want %s", hash, want)
+	}
+	var stored string
+	db.QueryRow("SELECT content_hash FROM files WHERE id = ?", file).Scan(&stored)
+	if stored != want {
+		t.Errorf("stored hash = %q", stored)
+	}
+
+	// The stored hash is returned without reading the file again
+	os.Remove(path)
+	if hash, err := svc.GetOrCompute(file, path); err != nil || hash != want {
+		t.Errorf("stored lookup = %q, %v", hash, err)
+	}
+}
+
+func TestThumbnailsSharedByContent(t *testing.T) {
+	db := newTestDB(t)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	first := seedFile(t, db, folder, "a.png", "image")
+	second := seedFile(t, db, folder, "copy/a.png", "image")
+
+	root := t.TempDir()
+	firstPath := filepath.Join(root, "a.png")
+	secondPath := filepath.Join(root, "copy", "a.png")
+	writeTestImage(t, firstPath, 64, 48)
+	os.MkdirAll(filepath.Dir(secondPath), 0755)
+	data, _ := os.ReadFile(firstPath)
+	os.WriteFile(secondPath, data, 0644)
+
+	thumbsDir := t.TempDir()
+	ts := NewThumbnailService(thumbsDir)
+	ts.SetContentHashes(NewContentHashService(db))
+
+	thumbFirst, err := ts.GetThumbnail(firstPath, first, "small", "")
+	if err != nil {
+		t.Fatalf("first thumbnail: %v", err)
+	}
+	thumbSecond, err := ts.GetThumbnail(secondPath, second, "small", "")
+	if err != nil {
+		t.Fatalf("second thumbnail: %v", err)
+	}
+	if thumbFirst != thumbSecond {
+		t.Errorf("identical files got different thumbnails: %s, %s", thumbFirst, thumbSecond)
+	}
+	if !strings.HasPrefix(filepath.Base(thumbFirst), "c_") {
+		t.Errorf("thumbnail %s is not keyed by content", thumbFirst)
+	}
+	if n := len(thumbnailSizesIn(t, thumbsDir)); n != 1 {
+		t.Errorf("%d cached thumbnails, want 1", n)
+	}
+
+	// Moving the file keeps its thumbnail
+	moved := filepath.Join(root, "moved.png")
+	if err := os.Rename(firstPath, moved); err != nil {
+		t.Fatal(err)
+	}
+	thumbMoved, err := ts.GetThumbnail(moved, first, "small", "")
+	if err != nil {
+		t.Fatalf("moved thumbnail: %v", err)
+	}
+	if thumbMoved != thumbFirst || len(thumbnailSizesIn(t, thumbsDir)) != 1 {
+		t.Errorf("moved file regenerated its thumbnail: %s", thumbMoved)
+	}
+}
+
+func TestThumbnailsKeyedByPathWithoutContentHashes(t *testing.T) {
+	root := t.TempDir()
+	firstPath := filepath.Join(root, "a.png")
+	secondPath := filepath.Join(root, "b.png")
+	writeTestImage(t, firstPath, 32, 32)
+	writeTestImage(t, secondPath, 32, 32)
+
+	thumbsDir := t.TempDir()
+	ts := NewThumbnailService(thumbsDir)
+	thumbFirst, err := ts.GetThumbnail(firstPath, 1, "small", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumbSecond, err := ts.GetThumbnail(secondPath, 2, "small", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumbFirst == thumbSecond || strings.HasPrefix(filepath.Base(thumbFirst), "c_") {
+		t.Errorf("path-keyed thumbnails: %s, %s", thumbFirst, thumbSecond)
+	}
+}
+
+func TestThumbnailHashingWaitsForSlot(t *testing.T) {
+	db := newTestDB(t)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	cachedID := seedFile(t, db, folder, "cached.png", "image")
+	pendingID := seedFile(t, db, folder, "pending.png", "image")
+	videoID := seedFile(t, db, folder, "clip.mp4", "video")
+
+	dir := t.TempDir()
+	cached := filepath.Join(dir, "cached.png")
+	pending := filepath.Join(dir, "pending.png")
+	video := filepath.Join(dir, "clip.mp4")
+	writeTestImage(t, cached, 40, 30)
+	writeTestImage(t, pending, 40, 30)
+	os.WriteFile(video, []byte("not really a video"), 0644)
+
+	ts := NewThumbnailService(t.TempDir())
+	ts.SetContentHashes(NewContentHashService(db))
+	ts.SetMaxConcurrentGenerations(1)
+	if _, err := ts.GetThumbnail(cached, cachedID, "small", ""); err != nil {
+		t.Fatalf("generate cached thumbnail: %v", err)
+	}
+
+	// Occupy the only slot, as a long running generation would
+	ts.generateSlots <- struct{}{}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := ts.GetThumbnail(pending, pendingID, "small", "")
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	var hash sql.NullString
+	db.QueryRow("SELECT content_hash FROM files WHERE id = ?", pendingID).Scan(&hash)
+	if hash.Valid {
+		t.Errorf("file hashed before a generation slot was free")
+	}
+
+	// A stored hash is enough to serve a cached thumbnail without a slot
+	if _, err := ts.GetThumbnail(cached, cachedID, "small", ""); err != nil {
+		t.Fatalf("cached thumbnail while slots are busy: %v", err)
+	}
+
+	<-ts.generateSlots
+	if err := <-done; err != nil {
+		t.Fatalf("queued generation: %v", err)
+	}
+	db.QueryRow("SELECT content_hash FROM files WHERE id = ?", pendingID).Scan(&hash)
+	if !hash.Valid {
+		t.Errorf("file not hashed once its thumbnail was generated")
+	}
+
+	// Videos get a placeholder, so they are never hashed
+	ts.GetThumbnail(video, videoID, "small", "")
+	db.QueryRow("SELECT content_hash FROM files WHERE id = ?", videoID).Scan(&hash)
+	if hash.Valid {
+		t.Errorf("video was hashed: %s", hash.String)
+	}
+}
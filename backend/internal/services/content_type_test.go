@@ -0,0 +1,39 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestContentTypeForFile(t *testing.T) {
+	dir := t.TempDir()
+	jpg := filepath.Join(dir, "photo.jpg")
+	writeTestImage(t, filepath.Join(dir, "real.png"), 4, 4)
+	content, err := os.ReadFile(filepath.Join(dir, "real.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A PNG saved under a video extension is sniffed rather than trusted
+	mislabelled := filepath.Join(dir, "clip.mp4")
+	if err := os.WriteFile(mislabelled, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		path     string
+		fileType string
+		want     string
+	}{
+		{jpg, "image", "image/jpeg"},
+		{filepath.Join(dir, "photo.HEIC"), "image", "image/heic"},
+		{filepath.Join(dir, "clip.mov"), "video", "video/quicktime"},
+		{mislabelled, "image", "image/png"},
+		{filepath.Join(dir, "missing.unknownext"), "image", "application/octet-stream"},
+	}
+	for _, tc := range cases {
+		if got := ContentTypeForFile(tc.path, tc.fileType); got != tc.want {
+			t.Errorf("ContentTypeForFile(%s, %s) = %q, want %q", filepath.Base(tc.path), tc.fileType, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package services
+
+import "strings"
+
+// csvFormulaPrefixes are the leading characters spreadsheets treat as the start of a formula
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvCell makes an untrusted value safe for a CSV export: spreadsheets evaluate cells
+// starting with a formula character (=HYPERLINK(...) and the like), so those get a
+// leading apostrophe, which displays the value as text.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// csvCells applies csvCell to every value of a record
+func csvCells(record []string) []string {
+	for i, value := range record {
+		record[i] = csvCell(value)
+	}
+	return record
+}
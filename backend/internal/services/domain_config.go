@@ -1,14 +1,18 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"net/http"
 	"time"
 
 	"awesome-sharing/internal/database"
 	"awesome-sharing/internal/models"
 )
 
+var ErrDomainNotConfigured = errors.New("domain not configured")
+
 type DomainConfigService struct {
 	db *database.DB
 }
@@ -91,7 +95,30 @@ func (s *DomainConfigService) GetFullURL() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if config.Domain == "" {
+		return "", ErrDomainNotConfigured
+	}
+
+	return BuildBaseURL(config), nil
+}
+
+// urlBasePath is the path prefix the server is reachable under behind a reverse proxy
+// (e.g. "/photos"), or "" when served from the root
+var urlBasePath = ""
 
+// SetBasePath configures the path prefix added to generated URLs. It must be empty or
+// start with a slash and have no trailing slash. Must be called before serving requests.
+func SetBasePath(basePath string) {
+	urlBasePath = basePath
+}
+
+// BasePath returns the path prefix of generated URLs ("" when served from the root)
+func BasePath() string {
+	return urlBasePath
+}
+
+// BuildBaseURL composes protocol, domain, port and base path into a base URL
+func BuildBaseURL(config *models.DomainConfig) string {
 	url := config.Protocol + "://" + config.Domain
 
 	// Only add port if it's not the default port for the protocol
@@ -100,5 +127,44 @@ func (s *DomainConfigService) GetFullURL() (string, error) {
 		url += ":" + config.Port
 	}
 
-	return url, nil
+	return url + urlBasePath
+}
+
+// DomainReachability is the result of a HEAD request to the configured base URL
+type DomainReachability struct {
+	Reachable  bool   `json:"reachable"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	LatencyMs  int64  `json:"latency_ms"`
+}
+
+// CheckReachability sends a HEAD request to baseURL. Any HTTP response counts as
+// reachable; the status code is reported so admins can spot proxy errors.
+func (s *DomainConfigService) CheckReachability(ctx context.Context, baseURL string) *DomainReachability {
+	result := &DomainReachability{}
+	start := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL+"/api/health", nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// Don't follow redirects, an http -> https redirect is itself worth reporting
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp.Body.Close()
+
+	result.Reachable = true
+	result.StatusCode = resp.StatusCode
+	return result
 }
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"awesome-sharing/internal/database"
+	"awesome-sharing/internal/models"
+)
+
+func TestBuildBaseURL(t *testing.T) {
+	cases := []struct {
+		protocol, domain, port string
+		want                   string
+	}{
+		{"http", "example.com", "80", "http://example.com"},
+		{"https", "example.com", "443", "https://example.com"},
+		{"http", "example.com", "8080", "http://example.com:8080"},
+		{"https", "example.com", "8443", "https://example.com:8443"},
+		{"http", "192.168.1.10", "443", "http://192.168.1.10:443"},
+		{"https", "192.168.1.10", "80", "https://192.168.1.10:80"},
+	}
+	for _, tc := range cases {
+		got := BuildBaseURL(&models.DomainConfig{Protocol: tc.protocol, Domain: tc.domain, Port: tc.port})
+		if got != tc.want {
+			t.Errorf("BuildBaseURL(%s, %s, %s) = %q, want %q", tc.protocol, tc.domain, tc.port, got, tc.want)
+		}
+	}
+}
+
+func TestCheckReachability(t *testing.T) {
+	var gotMethod, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		if r.URL.Path == "/api/health" {
+			http.Redirect(w, r, "https://elsewhere.example.com/", http.StatusMovedPermanently)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	s := &DomainConfigService{}
+	result := s.CheckReachability(context.Background(), srv.URL)
+	if !result.Reachable || result.Error != "" {
+		t.Fatalf("expected reachable, got %+v", result)
+	}
+	if gotMethod != http.MethodHead || gotPath != "/api/health" {
+		t.Errorf("request %s %s, want HEAD /api/health", gotMethod, gotPath)
+	}
+	if result.StatusCode != http.StatusMovedPermanently {
+		t.Errorf("status = %d, want the redirect itself (%d)", result.StatusCode, http.StatusMovedPermanently)
+	}
+
+	srv.Close()
+	result = s.CheckReachability(context.Background(), srv.URL)
+	if result.Reachable || result.Error == "" || result.StatusCode != 0 {
+		t.Errorf("closed server reported %+v", result)
+	}
+}
+
+func TestGetFullURLRequiresDomain(t *testing.T) {
+	db := newTestDB(t)
+	s := NewDomainConfigService(&database.DB{DB: db})
+
+	mustExec(t, db, "INSERT INTO domain_config (protocol, domain, port) VALUES ('https', '', '443')")
+	if _, err := s.GetFullURL(); err != ErrDomainNotConfigured {
+		t.Errorf("empty domain: err = %v, want ErrDomainNotConfigured", err)
+	}
+
+	mustExec(t, db, "INSERT INTO domain_config (protocol, domain, port) VALUES ('https', 'photos.example.com', '8443')")
+	url, err := s.GetFullURL()
+	if err != nil || url != "https://photos.example.com:8443" {
+		t.Errorf("GetFullURL() = %q, %v", url, err)
+	}
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"awesome-sharing/internal/models"
+)
+
+// Event types emitted by the server
+const (
+	EventFileIndexed   = "file.indexed"
+	EventFileDeleted   = "file.deleted"
+	EventShareCreated  = "share.created"
+	EventShareAccessed = "share.accessed"
+	EventScanStarted   = "scan.started"
+	EventScanProgress  = "scan.progress"
+	EventScanCompleted = "scan.completed"
+	EventJobUpdated    = "job.updated"
+)
+
+// EventTypes lists all event types that can be subscribed to ('*' matches all)
+var EventTypes = []string{EventFileIndexed, EventFileDeleted, EventShareCreated, EventShareAccessed,
+	EventScanStarted, EventScanProgress, EventScanCompleted, EventJobUpdated}
+
+var (
+	ErrSubscriptionNotFound = errors.New("event subscription not found")
+	ErrInvalidEventType     = errors.New("invalid event type")
+	ErrInvalidWebhookURL    = errors.New("webhook URL must be an absolute http(s) URL")
+)
+
+// eventQueueSize bounds the number of pending events; events are dropped when full
+const eventQueueSize = 256
+
+// listenerBufferSize bounds the events buffered per in-process listener
+const listenerBufferSize = 64
+
+// Event is the payload delivered to webhook subscribers
+type Event struct {
+	Type      string      `json:"event"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// EventDispatcher delivers events to subscribed webhooks from a background goroutine,
+// and to in-process listeners (e.g. websocket clients)
+type EventDispatcher struct {
+	db     *sql.DB
+	queue  chan Event
+	client *http.Client
+
+	listenersMu sync.Mutex
+	listeners   map[chan Event]struct{}
+}
+
+func NewEventDispatcher(db *sql.DB) *EventDispatcher {
+	return &EventDispatcher{
+		db:     db,
+		queue:  make(chan Event, eventQueueSize),
+		client: &http.Client{Timeout: 10 * time.Second},
+
+		listeners: make(map[chan Event]struct{}),
+	}
+}
+
+// Listen registers an in-process listener that receives every emitted event.
+// Slow listeners miss events rather than blocking emitters. Call the returned
+// function to unregister.
+func (d *EventDispatcher) Listen() (<-chan Event, func()) {
+	ch := make(chan Event, listenerBufferSize)
+
+	d.listenersMu.Lock()
+	d.listeners[ch] = struct{}{}
+	d.listenersMu.Unlock()
+
+	return ch, func() {
+		d.listenersMu.Lock()
+		if _, ok := d.listeners[ch]; ok {
+			delete(d.listeners, ch)
+			close(ch)
+		}
+		d.listenersMu.Unlock()
+	}
+}
+
+// notifyListeners hands an event to every in-process listener without blocking
+func (d *EventDispatcher) notifyListeners(event Event) {
+	d.listenersMu.Lock()
+	defer d.listenersMu.Unlock()
+	for ch := range d.listeners {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Start launches the delivery goroutine
+func (d *EventDispatcher) Start() {
+	go func() {
+		for event := range d.queue {
+			d.deliver(event)
+		}
+	}()
+}
+
+// Emit queues an event for delivery without blocking the caller.
+// Safe to call on a nil dispatcher.
+func (d *EventDispatcher) Emit(eventType string, data interface{}) {
+	if d == nil {
+		return
+	}
+
+	event := Event{Type: eventType, Timestamp: time.Now(), Data: data}
+	d.notifyListeners(event)
+
+	select {
+	case d.queue <- event:
+	default:
+		log.Printf("Event queue full, dropping %s event", eventType)
+	}
+}
+
+// deliver sends an event to every enabled subscription matching its type
+func (d *EventDispatcher) deliver(event Event) {
+	subs, err := d.subscriptionsFor(event.Type)
+	if err != nil {
+		log.Printf("Error loading event subscriptions: %v", err)
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error encoding %s event: %v", event.Type, err)
+		return
+	}
+
+	for _, sub := range subs {
+		if err := d.post(sub, event.Type, body); err != nil {
+			log.Printf("Webhook delivery of %s to %s failed: %v", event.Type, sub.URL, err)
+		}
+	}
+}
+
+// post sends a signed webhook request
+func (d *EventDispatcher) post(sub models.EventSubscription, eventType string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", eventType)
+	if sub.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(sub.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// subscriptionsFor returns enabled subscriptions for an event type
+func (d *EventDispatcher) subscriptionsFor(eventType string) ([]models.EventSubscription, error) {
+	return d.querySubscriptions(`
+		SELECT id, event_type, url, secret, enabled, created_by, created_at
+		FROM event_subscriptions
+		WHERE enabled = 1 AND (event_type = ? OR event_type = '*')
+	`, eventType)
+}
+
+// ListSubscriptions returns all event subscriptions
+func (d *EventDispatcher) ListSubscriptions() ([]models.EventSubscription, error) {
+	return d.querySubscriptions(`
+		SELECT id, event_type, url, secret, enabled, created_by, created_at
+		FROM event_subscriptions
+		ORDER BY created_at DESC
+	`)
+}
+
+// GetSubscription retrieves an event subscription by ID
+func (d *EventDispatcher) GetSubscription(id int64) (*models.EventSubscription, error) {
+	subs, err := d.querySubscriptions(`
+		SELECT id, event_type, url, secret, enabled, created_by, created_at
+		FROM event_subscriptions WHERE id = ?
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) == 0 {
+		return nil, ErrSubscriptionNotFound
+	}
+	return &subs[0], nil
+}
+
+// CreateSubscription registers a webhook for an event type
+func (d *EventDispatcher) CreateSubscription(eventType, webhookURL, secret string, createdBy int64) (*models.EventSubscription, error) {
+	if err := validateSubscription(eventType, webhookURL); err != nil {
+		return nil, err
+	}
+
+	result, err := d.db.Exec(`
+		INSERT INTO event_subscriptions (event_type, url, secret, enabled, created_by)
+		VALUES (?, ?, ?, 1, ?)
+	`, eventType, webhookURL, secret, createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.GetSubscription(id)
+}
+
+// UpdateSubscription updates an event subscription
+func (d *EventDispatcher) UpdateSubscription(id int64, eventType, webhookURL string, secret *string, enabled bool) error {
+	if err := validateSubscription(eventType, webhookURL); err != nil {
+		return err
+	}
+
+	var result sql.Result
+	var err error
+	if secret != nil {
+		result, err = d.db.Exec(`
+			UPDATE event_subscriptions SET event_type = ?, url = ?, secret = ?, enabled = ?
+			WHERE id = ?
+		`, eventType, webhookURL, *secret, enabled, id)
+	} else {
+		result, err = d.db.Exec(`
+			UPDATE event_subscriptions SET event_type = ?, url = ?, enabled = ?
+			WHERE id = ?
+		`, eventType, webhookURL, enabled, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// DeleteSubscription removes an event subscription
+func (d *EventDispatcher) DeleteSubscription(id int64) error {
+	result, err := d.db.Exec("DELETE FROM event_subscriptions WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+func (d *EventDispatcher) querySubscriptions(query string, args ...interface{}) ([]models.EventSubscription, error) {
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []models.EventSubscription
+	for rows.Next() {
+		var sub models.EventSubscription
+		var secret sql.NullString
+		if err := rows.Scan(&sub.ID, &sub.EventType, &sub.URL, &secret, &sub.Enabled,
+			&sub.CreatedBy, &sub.CreatedAt); err != nil {
+			return nil, err
+		}
+		if secret.Valid && secret.String != "" {
+			sub.Secret = secret.String
+			sub.HasSecret = true
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, rows.Err()
+}
+
+// validateSubscription checks the event type and webhook URL
+func validateSubscription(eventType, webhookURL string) error {
+	validType := eventType == "*"
+	for _, t := range EventTypes {
+		if eventType == t {
+			validType = true
+			break
+		}
+	}
+	if !validType {
+		return ErrInvalidEventType
+	}
+
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// webhookRequest is a request received by a test webhook endpoint
+type webhookRequest struct {
+	eventType string
+	signature string
+	body      []byte
+}
+
+// newWebhookServer starts an HTTP server recording every webhook it receives
+func newWebhookServer(t *testing.T) (*httptest.Server, chan webhookRequest) {
+	t.Helper()
+	received := make(chan webhookRequest, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- webhookRequest{eventType: r.Header.Get("X-Event-Type"), signature: r.Header.Get("X-Signature-256"), body: body}
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func TestEventSubscriptionValidation(t *testing.T) {
+	db := newTestDB(t)
+	d := NewEventDispatcher(db)
+	admin := seedUser(t, db, "admin", "admin", "", false)
+
+	cases := []struct {
+		eventType, url string
+		want           error
+	}{
+		{EventFileIndexed, "https://example.com/hook", nil},
+		{"*", "http://example.com/hook", nil},
+		{"file.renamed", "https://example.com/hook", ErrInvalidEventType},
+		{EventFileIndexed, "ftp://example.com/hook", ErrInvalidWebhookURL},
+		{EventFileIndexed, "/relative", ErrInvalidWebhookURL},
+	}
+	for _, tc := range cases {
+		if _, err := d.CreateSubscription(tc.eventType, tc.url, "", admin); !errors.Is(err, tc.want) {
+			t.Errorf("%s %s: got %v, want %v", tc.eventType, tc.url, err, tc.want)
+		}
+	}
+
+	if err := d.UpdateSubscription(9999, EventFileIndexed, "https://example.com", nil, true); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("update missing: got %v", err)
+	}
+	if err := d.DeleteSubscription(9999); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("delete missing: got %v", err)
+	}
+}
+
+func TestEventDelivery(t *testing.T) {
+	db := newTestDB(t)
+	d := NewEventDispatcher(db)
+	admin := seedUser(t, db, "admin", "admin", "", false)
+	server, received := newWebhookServer(t)
+
+	signed, err := d.CreateSubscription(EventShareCreated, server.URL+"/signed", "s3cret", admin)
+	if err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	if !signed.HasSecret {
+		t.Errorf("subscription should report its secret")
+	}
+	if _, err := d.CreateSubscription("*", server.URL+"/all", "", admin); err != nil {
+		t.Fatalf("create wildcard subscription: %v", err)
+	}
+	disabled, err := d.CreateSubscription(EventShareCreated, server.URL+"/disabled", "", admin)
+	if err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	if err := d.UpdateSubscription(disabled.ID, EventShareCreated, disabled.URL, nil, false); err != nil {
+		t.Fatalf("disable subscription: %v", err)
+	}
+
+	d.deliver(Event{Type: EventShareCreated, Timestamp: time.Now(), Data: map[string]string{"share_id": "abc"}})
+	close(received)
+
+	var signedCount, unsignedCount int
+	for req := range received {
+		if req.eventType != EventShareCreated {
+			t.Errorf("X-Event-Type = %q", req.eventType)
+		}
+		var event Event
+		if err := json.Unmarshal(req.body, &event); err != nil || event.Type != EventShareCreated {
+			t.Errorf("body %s: %v", req.body, err)
+		}
+		if req.signature == "" {
+			unsignedCount++
+			continue
+		}
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write(req.body)
+		if req.signature != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			t.Errorf("signature %q doesn't match the body", req.signature)
+		}
+		signedCount++
+	}
+	if signedCount != 1 || unsignedCount != 1 {
+		t.Errorf("got %d signed and %d unsigned deliveries, want 1 each (disabled subscriptions skipped)", signedCount, unsignedCount)
+	}
+}
+
+func TestEventListeners(t *testing.T) {
+	d := NewEventDispatcher(newTestDB(t))
+	events, stop := d.Listen()
+
+	d.Emit(EventScanStarted, nil)
+	select {
+	case event := <-events:
+		if event.Type != EventScanStarted {
+			t.Errorf("got %s event", event.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("listener received nothing")
+	}
+
+	// Unregistering closes the channel and is safe to repeat
+	stop()
+	stop()
+	if _, ok := <-events; ok {
+		t.Errorf("listener channel still open")
+	}
+
+	// Emitting on a nil dispatcher is a no-op
+	var none *EventDispatcher
+	none.Emit(EventScanStarted, nil)
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExifBucket is one value of a camera setting and the number of photos taken with it.
+// Value is numeric for sorting and charts (seconds for shutter speeds), Label is for display.
+type ExifBucket struct {
+	Label string  `json:"label"`
+	Value float64 `json:"value"`
+	Count int     `json:"count"`
+}
+
+// ExifStats are the camera setting distributions of a set of photos, each sorted by value
+type ExifStats struct {
+	Photos       int          `json:"photos"` // Photos with any camera setting recorded
+	ISO          []ExifBucket `json:"iso"`
+	Aperture     []ExifBucket `json:"aperture"`
+	FocalLength  []ExifBucket `json:"focal_length"`
+	ShutterSpeed []ExifBucket `json:"shutter_speed"`
+}
+
+// exifStatsColumns groups each setting: apertures to one decimal, focal lengths to whole millimetres
+var exifStatsColumns = []struct {
+	expr  string
+	label func(string) string
+}{
+	{"iso", func(v string) string { return "ISO " + v }},
+	{"ROUND(aperture, 1)", func(v string) string { return "f/" + v }},
+	{"ROUND(focal_length)", func(v string) string { return v + "mm" }},
+	{"shutter_speed", func(v string) string { return v + "s" }},
+}
+
+// AlbumExifStats returns the camera setting distributions of the files an album's
+// folder rules currently match. A non-zero viewerID counts only the files that user can
+// access, as in ListItemsWithFiles.
+func (s *AlbumService) AlbumExifStats(ctx context.Context, albumID, viewerID int64) (*ExifStats, error) {
+	fileIDsSQL := `
+		SELECT ffm.file_id FROM album_folders af
+		INNER JOIN file_folder_mappings ffm ON ffm.folder_id = af.folder_id
+		WHERE af.album_id = ? AND ` + PathPrefixMatchSQL
+	if viewerID == 0 {
+		return queryExifStats(ctx, s.db, fileIDsSQL, albumID)
+	}
+	return queryExifStats(ctx, s.db, fileIDsSQL+" AND ffm.file_id IN ("+AccessibleFileIDsSQL+")", albumID, viewerID)
+}
+
+// FolderExifStats returns the camera setting distributions of the files in a folder
+func (s *FolderService) FolderExifStats(ctx context.Context, folderID int64) (*ExifStats, error) {
+	return queryExifStats(ctx, s.db, "SELECT file_id FROM file_folder_mappings WHERE folder_id = ?", folderID)
+}
+
+// queryExifStats groups photo_metadata of the files selected by fileIDsSQL per setting
+func queryExifStats(ctx context.Context, db *sql.DB, fileIDsSQL string, args ...interface{}) (*ExifStats, error) {
+	scope := "FROM photo_metadata WHERE file_id IN (" + fileIDsSQL + ")"
+
+	stats := &ExifStats{}
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*) `+scope+`
+		AND (COALESCE(iso, 0) != 0 OR COALESCE(aperture, 0) != 0 OR COALESCE(focal_length, 0) != 0
+			OR COALESCE(shutter_speed, '') NOT IN ('', '0'))
+	`, args...).Scan(&stats.Photos)
+	if err != nil {
+		return nil, err
+	}
+
+	distributions := []*[]ExifBucket{&stats.ISO, &stats.Aperture, &stats.FocalLength, &stats.ShutterSpeed}
+	for i, column := range exifStatsColumns {
+		rows, err := db.QueryContext(ctx, `
+			SELECT CAST(`+column.expr+` AS TEXT) AS v, COUNT(*) `+scope+`
+			AND `+column.expr+` IS NOT NULL AND `+column.expr+` NOT IN ('', 0)
+			GROUP BY v
+		`, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		buckets := []ExifBucket{}
+		for rows.Next() {
+			var value string
+			var count int
+			if err := rows.Scan(&value, &count); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			value = strings.TrimSuffix(value, ".0")
+			numeric, err := parseExifValue(value)
+			if err != nil {
+				continue
+			}
+			buckets = append(buckets, ExifBucket{Label: column.label(value), Value: numeric, Count: count})
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Slice(buckets, func(a, b int) bool { return buckets[a].Value < buckets[b].Value })
+		*distributions[i] = mergeExifBuckets(buckets)
+	}
+
+	return stats, nil
+}
+
+// parseExifValue reads a stored setting as a number; shutter speeds may be fractions ("1/250")
+func parseExifValue(value string) (float64, error) {
+	if num, den, ok := strings.Cut(value, "/"); ok {
+		n, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return 0, err
+		}
+		d, err := strconv.ParseFloat(den, 64)
+		if err != nil || d == 0 {
+			return 0, fmt.Errorf("invalid fraction %q", value)
+		}
+		return n / d, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// mergeExifBuckets combines adjacent buckets with the same value, such as "1.0" and "1" seconds
+func mergeExifBuckets(buckets []ExifBucket) []ExifBucket {
+	merged := []ExifBucket{}
+	for _, b := range buckets {
+		if n := len(merged); n > 0 && merged[n-1].Value == b.Value {
+			merged[n-1].Count += b.Count
+			continue
+		}
+		merged = append(merged, b)
+	}
+	return merged
+}
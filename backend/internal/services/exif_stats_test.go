@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestExifStats(t *testing.T) {
+	db := newTestDB(t)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	photos := seedFolder(t, db, "/photos", owner)
+	other := seedFolder(t, db, "/other", owner)
+
+	seeds := []struct {
+		folder   int64
+		path     string
+		iso      interface{}
+		aperture interface{}
+		focal    interface{}
+		shutter  interface{}
+	}{
+		{photos, "2024/a.jpg", 100, 2.8, 35.4, "1/250"},
+		{photos, "2024/b.jpg", 100, 2.84, 35, "1.0"},
+		{photos, "2023/c.jpg", 400, nil, 50, "1"},
+		{photos, "2023/d.jpg", 0, nil, nil, ""},
+		{other, "2024/e.jpg", 3200, 8, 200, "1/1000"},
+	}
+	for _, s := range seeds {
+		id := seedFile(t, db, s.folder, s.path, "image")
+		mustExec(t, db, "INSERT INTO photo_metadata (file_id, iso, aperture, focal_length, shutter_speed) VALUES (?, ?, ?, ?, ?)",
+			id, s.iso, s.aperture, s.focal, s.shutter)
+	}
+
+	folderStats, err := NewFolderService(db).FolderExifStats(context.Background(), photos)
+	if err != nil {
+		t.Fatalf("folder stats: %v", err)
+	}
+	want := &ExifStats{
+		Photos:       3,
+		ISO:          []ExifBucket{{"ISO 100", 100, 2}, {"ISO 400", 400, 1}},
+		Aperture:     []ExifBucket{{"f/2.8", 2.8, 2}},
+		FocalLength:  []ExifBucket{{"35mm", 35, 2}, {"50mm", 50, 1}},
+		ShutterSpeed: []ExifBucket{{"1/250s", 0.004, 1}, {"1s", 1, 2}},
+	}
+	if !reflect.DeepEqual(folderStats, want) {
+		t.Errorf("folder stats = %+v, want %+v", folderStats, want)
+	}
+
+	album := lastID(t, mustExec(t, db, "INSERT INTO albums_v2 (name, owner_id) VALUES ('2024', ?)", owner))
+	mustExec(t, db, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '2024')", album, photos)
+	albumStats, err := NewAlbumService(db).AlbumExifStats(context.Background(), album, 0)
+	if err != nil {
+		t.Fatalf("album stats: %v", err)
+	}
+	if albumStats.Photos != 2 || len(albumStats.ISO) != 1 || albumStats.ISO[0].Count != 2 || len(albumStats.ShutterSpeed) != 2 {
+		t.Errorf("unexpected album stats %+v", albumStats)
+	}
+
+	empty := seedFolder(t, db, "/empty", owner)
+	emptyStats, err := NewFolderService(db).FolderExifStats(context.Background(), empty)
+	if err != nil {
+		t.Fatalf("empty folder stats: %v", err)
+	}
+	if emptyStats.Photos != 0 || emptyStats.ISO == nil || len(emptyStats.ISO) != 0 {
+		t.Errorf("empty folder stats = %+v, want empty distributions", emptyStats)
+	}
+}
+
+func TestParseExifValue(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"1/250", 0.004, false},
+		{"2.8", 2.8, false},
+		{"1/0", 0, true},
+		{"fast", 0, true},
+	}
+	for _, tc := range cases {
+		got, err := parseExifValue(tc.value)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("parseExifValue(%q) = %v, %v", tc.value, got, err)
+		}
+	}
+}
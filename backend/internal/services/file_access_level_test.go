@@ -0,0 +1,58 @@
+package services
+
+import "testing"
+
+func TestGetFileAccessLevel(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewPermissionGroupService(db)
+
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	alice := seedUser(t, db, "alice", "user", "alice@example.com", true)
+	bob := seedUser(t, db, "bob", "user", "bob@example.com", true)
+	carol := seedUser(t, db, "carol", "user", "carol@example.com", true)
+
+	archive := seedFolder(t, db, "/photos", owner)
+	trips := seedFolder(t, db, "/photos/trips", owner)
+
+	// The same file is reachable through both folders
+	fileID := seedFile(t, db, archive, "trips/beach.jpg", "image")
+	mustExec(t, db, "INSERT INTO file_folder_mappings (file_id, folder_id, relative_path) VALUES (?, ?, 'beach.jpg')", fileID, trips)
+
+	grantFolder(t, db, alice, archive, "read")
+	grantFolder(t, db, alice, trips, "write")
+	grantFolder(t, db, bob, archive, "read")
+
+	cases := []struct {
+		name      string
+		userID    int64
+		level     string
+		readable  bool
+		writeable bool
+	}{
+		{"read and write groups", alice, "write", true, true},
+		{"read group only", bob, "read", true, false},
+		{"no group", carol, "", false, false},
+	}
+	for _, tc := range cases {
+		level, err := svc.GetFileAccessLevel(tc.userID, fileID)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if level != tc.level {
+			t.Errorf("%s: level %q, want %q", tc.name, level, tc.level)
+		}
+		if ok, _ := svc.CheckFileAccess(tc.userID, fileID, false); ok != tc.readable {
+			t.Errorf("%s: read access %t, want %t", tc.name, ok, tc.readable)
+		}
+		if ok, _ := svc.CheckFileWriteAccess(tc.userID, fileID, false); ok != tc.writeable {
+			t.Errorf("%s: write access %t, want %t", tc.name, ok, tc.writeable)
+		}
+	}
+
+	if ok, _ := svc.CheckFileWriteAccess(carol, fileID, true); !ok {
+		t.Error("admins should always have write access")
+	}
+	if level, err := svc.GetFileAccessLevel(alice, 9999); err != nil || level != "" {
+		t.Errorf("missing file: got %q, %v", level, err)
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"awesome-sharing/internal/models"
+)
+
+// MaxCommentLength bounds the body of a single file comment
+const MaxCommentLength = 2000
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrInvalidComment  = errors.New("comment must not be empty or longer than 2000 characters")
+)
+
+type FileCommentService struct {
+	db *sql.DB
+}
+
+func NewFileCommentService(db *sql.DB) *FileCommentService {
+	return &FileCommentService{db: db}
+}
+
+// ListComments returns a file's comments, oldest first
+func (s *FileCommentService) ListComments(fileID int64) ([]models.FileComment, error) {
+	rows, err := s.db.Query(`
+		SELECT c.id, c.file_id, c.user_id, u.username, c.body, c.created_at
+		FROM file_comments c
+		INNER JOIN users u ON u.id = c.user_id
+		WHERE c.file_id = ?
+		ORDER BY c.created_at ASC, c.id ASC
+	`, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []models.FileComment{}
+	for rows.Next() {
+		var comment models.FileComment
+		if err := rows.Scan(&comment.ID, &comment.FileID, &comment.UserID, &comment.Username,
+			&comment.Body, &comment.CreatedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, nil
+}
+
+// AddComment adds a comment by userID to a file
+func (s *FileCommentService) AddComment(fileID, userID int64, body string) (*models.FileComment, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || len([]rune(body)) > MaxCommentLength {
+		return nil, ErrInvalidComment
+	}
+
+	result, err := s.db.Exec(`
+		INSERT INTO file_comments (file_id, user_id, body) VALUES (?, ?, ?)
+	`, fileID, userID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetComment(fileID, id)
+}
+
+// GetComment retrieves a comment of a file
+func (s *FileCommentService) GetComment(fileID, commentID int64) (*models.FileComment, error) {
+	var comment models.FileComment
+	err := s.db.QueryRow(`
+		SELECT c.id, c.file_id, c.user_id, u.username, c.body, c.created_at
+		FROM file_comments c
+		INNER JOIN users u ON u.id = c.user_id
+		WHERE c.id = ? AND c.file_id = ?
+	`, commentID, fileID).Scan(&comment.ID, &comment.FileID, &comment.UserID, &comment.Username,
+		&comment.Body, &comment.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+// DeleteComment deletes a comment
+func (s *FileCommentService) DeleteComment(commentID int64) error {
+	_, err := s.db.Exec("DELETE FROM file_comments WHERE id = ?", commentID)
+	return err
+}
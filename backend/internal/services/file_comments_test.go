@@ -0,0 +1,59 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFileComments(t *testing.T) {
+	db := newTestDB(t)
+	s := NewFileCommentService(db)
+	alice := seedUser(t, db, "alice", "user", "", false)
+	bob := seedUser(t, db, "bob", "user", "", false)
+	folder := seedFolder(t, db, "/photos", alice)
+	file := seedFile(t, db, folder, "a.jpg", "image")
+	other := seedFile(t, db, folder, "b.jpg", "image")
+
+	first, err := s.AddComment(file, alice, "  Love the light here  ")
+	if err != nil {
+		t.Fatalf("add comment: %v", err)
+	}
+	if first.Body != "Love the light here" || first.Username != "alice" || first.FileID != file {
+		t.Errorf("comment = %+v", first)
+	}
+	if _, err := s.AddComment(file, bob, "Crop a bit tighter?"); err != nil {
+		t.Fatalf("add second comment: %v", err)
+	}
+	if _, err := s.AddComment(other, bob, "Different file"); err != nil {
+		t.Fatalf("add comment to other file: %v", err)
+	}
+
+	for _, body := range []string{"", "   ", strings.Repeat("é", MaxCommentLength+1)} {
+		if _, err := s.AddComment(file, alice, body); err != ErrInvalidComment {
+			t.Errorf("AddComment(%d chars): err = %v, want ErrInvalidComment", len(body), err)
+		}
+	}
+	if _, err := s.AddComment(file, alice, strings.Repeat("é", MaxCommentLength)); err != nil {
+		t.Errorf("comment at the length limit: %v", err)
+	}
+
+	comments, err := s.ListComments(file)
+	if err != nil {
+		t.Fatalf("list comments: %v", err)
+	}
+	if len(comments) != 3 || comments[0].ID != first.ID || comments[1].Username != "bob" {
+		t.Fatalf("comments = %+v, want alice's, bob's, then the long one", comments)
+	}
+
+	// Comments are looked up within their file
+	if _, err := s.GetComment(other, first.ID); err != ErrCommentNotFound {
+		t.Errorf("comment via another file: err = %v, want ErrCommentNotFound", err)
+	}
+
+	if err := s.DeleteComment(first.ID); err != nil {
+		t.Fatalf("delete comment: %v", err)
+	}
+	if _, err := s.GetComment(file, first.ID); err != ErrCommentNotFound {
+		t.Errorf("after delete: err = %v, want ErrCommentNotFound", err)
+	}
+}
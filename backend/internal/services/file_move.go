@@ -0,0 +1,229 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Conflict policies for MoveFiles when the destination path already exists
+const (
+	MoveConflictSkip   = "skip"   // Leave the file where it is
+	MoveConflictRename = "rename" // Move it under a free "name (n).ext" name
+	MoveConflictFail   = "fail"   // Abort before moving anything
+)
+
+var (
+	ErrInvalidMoveConflict = errors.New("on_conflict must be skip, rename or fail")
+	ErrMoveConflict        = errors.New("destination file already exists")
+)
+
+// FileMove is a file that was moved on disk
+type FileMove struct {
+	FileID  int64  `json:"file_id"`
+	OldPath string `json:"old_path"`
+	NewPath string `json:"new_path"`
+}
+
+// FileMoveFailure is a file that was left in place
+type FileMoveFailure struct {
+	FileID int64  `json:"file_id"`
+	Reason string `json:"reason"`
+}
+
+// FileMoveResult summarizes a bulk move
+type FileMoveResult struct {
+	Moved   []FileMove        `json:"moved"`
+	Skipped []FileMoveFailure `json:"skipped"`
+}
+
+// plannedMove is a file move resolved before anything is renamed
+type plannedMove struct {
+	fileID      int64
+	source      string
+	destination string
+}
+
+// MoveFiles moves files on disk into the target folder and points their mappings at it.
+// With flatten the files land directly in the folder root, otherwise their path relative
+// to the source folder is kept. onConflict decides what happens when the destination
+// exists (skip, rename or fail). Files whose source is missing are skipped.
+func (s *FolderService) MoveFiles(fileIDs []int64, targetFolderID int64, flatten bool, onConflict string) (*FileMoveResult, error) {
+	if onConflict == "" {
+		onConflict = MoveConflictSkip
+	}
+	if onConflict != MoveConflictSkip && onConflict != MoveConflictRename && onConflict != MoveConflictFail {
+		return nil, ErrInvalidMoveConflict
+	}
+
+	target, err := s.GetFolder(targetFolderID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FileMoveResult{
+		Moved:   []FileMove{},
+		Skipped: []FileMoveFailure{},
+	}
+
+	// Resolve every destination first so a "fail" conflict leaves the disk untouched
+	var plan []plannedMove
+	claimed := map[string]bool{}
+	for _, fileID := range fileIDs {
+		source, relativePath, err := s.locateFile(fileID)
+		if err != nil {
+			return nil, err
+		}
+		if source == "" {
+			result.Skipped = append(result.Skipped, FileMoveFailure{FileID: fileID, Reason: "file not found on disk"})
+			continue
+		}
+
+		if flatten {
+			relativePath = filepath.Base(relativePath)
+		}
+		destination := filepath.Join(target.AbsolutePath, relativePath)
+		if destination == source {
+			result.Skipped = append(result.Skipped, FileMoveFailure{FileID: fileID, Reason: "already in place"})
+			continue
+		}
+
+		if pathTaken(destination, claimed) {
+			switch onConflict {
+			case MoveConflictFail:
+				return nil, fmt.Errorf("%w: %s", ErrMoveConflict, destination)
+			case MoveConflictSkip:
+				result.Skipped = append(result.Skipped, FileMoveFailure{FileID: fileID, Reason: "destination exists"})
+				continue
+			case MoveConflictRename:
+				destination = freeMovePath(destination, claimed)
+			}
+		}
+
+		claimed[destination] = true
+		plan = append(plan, plannedMove{fileID: fileID, source: source, destination: destination})
+	}
+
+	var done []plannedMove
+	for _, m := range plan {
+		if err := os.MkdirAll(filepath.Dir(m.destination), 0755); err != nil {
+			result.Skipped = append(result.Skipped, FileMoveFailure{FileID: m.fileID, Reason: err.Error()})
+			continue
+		}
+		if err := os.Rename(m.source, m.destination); err != nil {
+			result.Skipped = append(result.Skipped, FileMoveFailure{FileID: m.fileID, Reason: err.Error()})
+			continue
+		}
+		done = append(done, m)
+	}
+
+	if err := s.remapMovedFiles(done); err != nil {
+		// Put the files back so the index still matches the disk
+		for _, m := range done {
+			os.Rename(m.destination, m.source)
+		}
+		return nil, err
+	}
+
+	for _, m := range done {
+		result.Moved = append(result.Moved, FileMove{FileID: m.fileID, OldPath: m.source, NewPath: m.destination})
+	}
+	return result, nil
+}
+
+// locateFile returns the absolute path and folder relative path of the first mapping
+// of a file that exists on disk, or empty strings if none does
+func (s *FolderService) locateFile(fileID int64) (string, string, error) {
+	rows, err := s.db.Query(`
+		SELECT f.absolute_path, ffm.relative_path
+		FROM file_folder_mappings ffm
+		INNER JOIN folders f ON f.id = ffm.folder_id
+		WHERE ffm.file_id = ?
+		ORDER BY ffm.folder_id
+	`, fileID)
+	if err != nil {
+		return "", "", err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var root, relativePath string
+		if err := rows.Scan(&root, &relativePath); err != nil {
+			return "", "", err
+		}
+		fullPath := filepath.Join(root, relativePath)
+		if info, err := os.Stat(fullPath); err == nil && !info.IsDir() {
+			return fullPath, relativePath, nil
+		}
+	}
+	return "", "", rows.Err()
+}
+
+// remapMovedFiles replaces the mappings of moved files with one per folder containing
+// the new location, in a single transaction
+func (s *FolderService) remapMovedFiles(moves []plannedMove) error {
+	if len(moves) == 0 {
+		return nil
+	}
+
+	folders, err := s.ListFolders(0, true)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, m := range moves {
+		if _, err := tx.Exec("DELETE FROM file_folder_mappings WHERE file_id = ?", m.fileID); err != nil {
+			return err
+		}
+		for _, folder := range folders {
+			if !isPathWithin(folder.AbsolutePath, m.destination) {
+				continue
+			}
+			relativePath, err := filepath.Rel(folder.AbsolutePath, m.destination)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`
+				INSERT OR REPLACE INTO file_folder_mappings (file_id, folder_id, relative_path)
+				VALUES (?, ?, ?)
+			`, m.fileID, folder.ID, relativePath); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec("UPDATE files SET filename = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+			filepath.Base(m.destination), m.fileID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// pathTaken reports whether a destination exists on disk or is claimed by an earlier move
+func pathTaken(path string, claimed map[string]bool) bool {
+	if claimed[path] {
+		return true
+	}
+	_, err := os.Lstat(path)
+	return err == nil || !errors.Is(err, os.ErrNotExist)
+}
+
+// freeMovePath returns the first "name (n).ext" variant of path that is not taken
+func freeMovePath(path string, claimed map[string]bool) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for n := 1; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+		if !pathTaken(candidate, claimed) {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fileMappings returns a file's mappings as "folderID:relative_path", ordered by folder
+func fileMappings(t *testing.T, svc *FolderService, fileID int64) []string {
+	t.Helper()
+	rows, err := svc.db.Query("SELECT folder_id, relative_path FROM file_folder_mappings WHERE file_id = ? ORDER BY folder_id", fileID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var mappings []string
+	for rows.Next() {
+		var folderID int64
+		var relativePath string
+		rows.Scan(&folderID, &relativePath)
+		mappings = append(mappings, fmt.Sprintf("%d:%s", folderID, relativePath))
+	}
+	return mappings
+}
+
+// moveFixture is a source and a target folder on disk
+type moveFixture struct {
+	svc            *FolderService
+	source, target string
+	sourceID       int64
+	targetID       int64
+	a, b           int64
+}
+
+// newMoveFixture creates both folders and indexes 2024/a.jpg and 2024/trip/b.jpg in the source
+func newMoveFixture(t *testing.T) *moveFixture {
+	t.Helper()
+	db := newTestDB(t)
+	f := &moveFixture{svc: NewFolderService(db), source: t.TempDir(), target: t.TempDir()}
+	owner := seedUser(t, db, "owner", "server_owner", "", false)
+	f.sourceID = seedFolder(t, db, f.source, owner)
+	f.targetID = seedFolder(t, db, f.target, owner)
+	writeDiskFile(t, f.source, "2024/a.jpg", []byte("a"))
+	writeDiskFile(t, f.source, "2024/trip/b.jpg", []byte("b"))
+	f.a = seedFile(t, db, f.sourceID, "2024/a.jpg", "image")
+	f.b = seedFile(t, db, f.sourceID, "2024/trip/b.jpg", "image")
+	return f
+}
+
+func TestMoveFilesPreservesRelativePaths(t *testing.T) {
+	f := newMoveFixture(t)
+
+	result, err := f.svc.MoveFiles([]int64{f.a, f.b}, f.targetID, false, "")
+	if err != nil {
+		t.Fatalf("MoveFiles: %v", err)
+	}
+	if len(result.Moved) != 2 || len(result.Skipped) != 0 {
+		t.Fatalf("result = %+v, want 2 moved", result)
+	}
+
+	for id, rel := range map[int64]string{f.a: "2024/a.jpg", f.b: "2024/trip/b.jpg"} {
+		if _, err := os.Stat(filepath.Join(f.target, rel)); err != nil {
+			t.Errorf("%s not at the destination: %v", rel, err)
+		}
+		if _, err := os.Stat(filepath.Join(f.source, rel)); !os.IsNotExist(err) {
+			t.Errorf("%s still at the source (%v)", rel, err)
+		}
+		want := fmt.Sprintf("[%d:%s]", f.targetID, filepath.FromSlash(rel))
+		if got := fmt.Sprint(fileMappings(t, f.svc, id)); got != want {
+			t.Errorf("file %d mappings %s, want %s", id, got, want)
+		}
+	}
+}
+
+func TestMoveFilesFlatten(t *testing.T) {
+	f := newMoveFixture(t)
+
+	result, err := f.svc.MoveFiles([]int64{f.b}, f.targetID, true, "")
+	if err != nil || len(result.Moved) != 1 {
+		t.Fatalf("MoveFiles = %+v, %v", result, err)
+	}
+	if result.Moved[0].NewPath != filepath.Join(f.target, "b.jpg") {
+		t.Errorf("new path %s, want the target root", result.Moved[0].NewPath)
+	}
+	if got, want := fmt.Sprint(fileMappings(t, f.svc, f.b)), fmt.Sprintf("[%d:b.jpg]", f.targetID); got != want {
+		t.Errorf("mappings %s, want %s", got, want)
+	}
+}
+
+func TestMoveFilesConflicts(t *testing.T) {
+	cases := []struct {
+		onConflict string
+		wantErr    error
+		wantMoved  int
+		wantAt     string // Where a.jpg ends up, relative to the target
+	}{
+		{"", nil, 0, ""},
+		{MoveConflictSkip, nil, 0, ""},
+		{MoveConflictRename, nil, 1, "a (1).jpg"},
+		{MoveConflictFail, ErrMoveConflict, 0, ""},
+		{"overwrite", ErrInvalidMoveConflict, 0, ""},
+	}
+	for _, tc := range cases {
+		f := newMoveFixture(t)
+		writeDiskFile(t, f.target, "a.jpg", []byte("existing"))
+
+		result, err := f.svc.MoveFiles([]int64{f.a}, f.targetID, true, tc.onConflict)
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%q: error %v, want %v", tc.onConflict, err, tc.wantErr)
+			continue
+		}
+		if err != nil {
+			if _, statErr := os.Stat(filepath.Join(f.source, "2024", "a.jpg")); statErr != nil {
+				t.Errorf("%q: source moved despite the error", tc.onConflict)
+			}
+			continue
+		}
+		if len(result.Moved) != tc.wantMoved {
+			t.Errorf("%q: result %+v, want %d moved", tc.onConflict, result, tc.wantMoved)
+		}
+		if tc.wantAt != "" {
+			if _, err := os.Stat(filepath.Join(f.target, tc.wantAt)); err != nil {
+				t.Errorf("%q: a.jpg not at %s: %v", tc.onConflict, tc.wantAt, err)
+			}
+		}
+		if content, _ := os.ReadFile(filepath.Join(f.target, "a.jpg")); string(content) != "existing" {
+			t.Errorf("%q: existing destination overwritten", tc.onConflict)
+		}
+	}
+}
+
+func TestMoveFilesSkipsMissingAndUnknownTarget(t *testing.T) {
+	f := newMoveFixture(t)
+	os.Remove(filepath.Join(f.source, "2024", "a.jpg"))
+
+	result, err := f.svc.MoveFiles([]int64{f.a, f.b}, f.targetID, false, "")
+	if err != nil {
+		t.Fatalf("MoveFiles: %v", err)
+	}
+	if len(result.Moved) != 1 || len(result.Skipped) != 1 || result.Skipped[0].FileID != f.a {
+		t.Errorf("result = %+v, want a.jpg skipped and b.jpg moved", result)
+	}
+
+	// Moving into the folder a file already lives in is a no-op
+	result, err = f.svc.MoveFiles([]int64{f.b}, f.targetID, false, "")
+	if err != nil || len(result.Moved) != 0 || len(result.Skipped) != 1 {
+		t.Errorf("move in place = %+v, %v", result, err)
+	}
+
+	if _, err := f.svc.MoveFiles([]int64{f.b}, f.targetID+100, false, ""); err != ErrFolderNotFound {
+		t.Errorf("unknown target: %v, want ErrFolderNotFound", err)
+	}
+}
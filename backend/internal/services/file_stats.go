@@ -0,0 +1,54 @@
+package services
+
+import (
+	"database/sql"
+	"time"
+
+	"awesome-sharing/internal/models"
+)
+
+type FileStatsService struct {
+	db *sql.DB
+}
+
+func NewFileStatsService(db *sql.DB) *FileStatsService {
+	return &FileStatsService{db: db}
+}
+
+// RecordView increments the view counter of a file
+func (s *FileStatsService) RecordView(fileID int64) error {
+	_, err := s.db.Exec(`
+		INSERT INTO file_access_stats (file_id, view_count, download_count, last_accessed)
+		VALUES (?, 1, 0, ?)
+		ON CONFLICT(file_id) DO UPDATE SET view_count = view_count + 1, last_accessed = excluded.last_accessed
+	`, fileID, time.Now())
+	return err
+}
+
+// RecordDownload increments the download counter of a file
+func (s *FileStatsService) RecordDownload(fileID int64) error {
+	_, err := s.db.Exec(`
+		INSERT INTO file_access_stats (file_id, view_count, download_count, last_accessed)
+		VALUES (?, 0, 1, ?)
+		ON CONFLICT(file_id) DO UPDATE SET download_count = download_count + 1, last_accessed = excluded.last_accessed
+	`, fileID, time.Now())
+	return err
+}
+
+// GetStats retrieves the access counters of a file (zero values if never accessed)
+func (s *FileStatsService) GetStats(fileID int64) (*models.FileAccessStats, error) {
+	stats := models.FileAccessStats{FileID: fileID}
+	err := s.db.QueryRow(`
+		SELECT view_count, download_count, last_accessed
+		FROM file_access_stats WHERE file_id = ?
+	`, fileID).Scan(&stats.ViewCount, &stats.DownloadCount, &stats.LastAccessed)
+
+	if err == sql.ErrNoRows {
+		return &stats, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
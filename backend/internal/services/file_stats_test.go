@@ -0,0 +1,50 @@
+package services
+
+import "testing"
+
+func TestFileStatsCounters(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewFileStatsService(db)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	file := seedFile(t, db, folder, "a.jpg", "image")
+
+	// Files never accessed report zero counters
+	stats, err := svc.GetStats(file)
+	if err != nil {
+		t.Fatalf("get stats: %v", err)
+	}
+	if stats.ViewCount != 0 || stats.DownloadCount != 0 || stats.LastAccessed != nil {
+		t.Errorf("untouched file: got %+v", stats)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := svc.RecordView(file); err != nil {
+			t.Fatalf("record view: %v", err)
+		}
+	}
+	if err := svc.RecordDownload(file); err != nil {
+		t.Fatalf("record download: %v", err)
+	}
+
+	stats, err = svc.GetStats(file)
+	if err != nil {
+		t.Fatalf("get stats: %v", err)
+	}
+	if stats.ViewCount != 3 || stats.DownloadCount != 1 {
+		t.Errorf("got %d views and %d downloads, want 3 and 1", stats.ViewCount, stats.DownloadCount)
+	}
+	if stats.LastAccessed == nil {
+		t.Errorf("last_accessed not set")
+	}
+
+	// Deleting the file drops its counters
+	mustExec(t, db, "DELETE FROM files WHERE id = ?", file)
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM file_access_stats WHERE file_id = ?", file).Scan(&count); err != nil {
+		t.Fatalf("count stats: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("stats left for deleted file")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"awesome-sharing/internal/database"
 	"awesome-sharing/internal/models"
 )
 
@@ -16,18 +17,40 @@ import (
 type FileValidatorService struct {
 	db            *sql.DB
 	folderService *FolderService
+	settings      *SettingsService
 	mu            sync.Mutex
 	cleanupCache  map[int64]bool // Cache to avoid repeated cleanup attempts
+	events        *EventDispatcher
+
+	// Full validation runs: parallel existence checks, an optional time budget per run,
+	// and the file ID the next run resumes after when a run ran out of time
+	concurrency int
+	runTimeout  time.Duration
+	resumeAfter int64
 }
 
-func NewFileValidatorService(db *sql.DB, folderService *FolderService) *FileValidatorService {
+func NewFileValidatorService(db *sql.DB, folderService *FolderService, settings *SettingsService, events *EventDispatcher) *FileValidatorService {
 	return &FileValidatorService{
 		db:            db,
 		folderService: folderService,
+		settings:      settings,
 		cleanupCache:  make(map[int64]bool),
+		events:        events,
+		concurrency:   1,
 	}
 }
 
+// SetValidationLimits configures full validation runs: how many files are checked at once
+// (at least 1) and how long a run may take before it stops and the next run resumes where
+// it left off (0 = no limit)
+func (s *FileValidatorService) SetValidationLimits(concurrency int, runTimeout time.Duration) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	s.concurrency = concurrency
+	s.runTimeout = runTimeout
+}
+
 // ValidateFiles checks if files exist and returns only valid ones
 // Also marks invalid files for cleanup
 func (s *FileValidatorService) ValidateFiles(files []models.File) []models.File {
@@ -54,6 +77,9 @@ func (s *FileValidatorService) ValidateFiles(files []models.File) []models.File
 	return validFiles
 }
 
+// statFile stats files for existence checks; tests swap it to simulate slow mounts
+var statFile = os.Stat
+
 // fileExists checks if a file exists on the filesystem with timeout protection
 func (s *FileValidatorService) fileExists(path string) bool {
 	// Use a channel to implement timeout for file check
@@ -63,7 +89,7 @@ func (s *FileValidatorService) fileExists(path string) bool {
 
 	startTime := time.Now()
 	go func() {
-		_, err := os.Stat(path)
+		_, err := statFile(path)
 		result <- (err == nil)
 	}()
 
@@ -97,7 +123,7 @@ func (s *FileValidatorService) cleanupFiles(fileIDs []int64) {
 		}
 
 		// Delete file record (this will cascade delete thumbnails and mappings via foreign key)
-		_, err := s.db.Exec("DELETE FROM files WHERE id = ?", id)
+		_, err := database.ExecWithRetry(s.db, "DELETE FROM files WHERE id = ?", id)
 		if err != nil {
 			log.Printf("Error deleting file record %d: %v", id, err)
 			continue
@@ -109,6 +135,7 @@ func (s *FileValidatorService) cleanupFiles(fileIDs []int64) {
 		// Mark as cleaned up
 		s.cleanupCache[id] = true
 		cleanedCount++
+		s.events.Emit(EventFileDeleted, map[string]interface{}{"file_id": id})
 
 		// Log progress for large cleanups
 		if totalToClean > 10 && (i+1)%10 == 0 {
@@ -118,6 +145,9 @@ func (s *FileValidatorService) cleanupFiles(fileIDs []int64) {
 
 	if cleanedCount > 0 {
 		log.Printf("Successfully cleaned up %d file records", cleanedCount)
+		if _, err := s.handleOrphanedShares(); err != nil {
+			log.Printf("Error handling shares of deleted files: %v", err)
+		}
 	}
 }
 
@@ -143,44 +173,88 @@ func (s *FileValidatorService) deleteFileThumbnails(fileID int64) {
 }
 
 // CleanupAllInvalidFiles scans entire database and removes invalid file records
-func (s *FileValidatorService) CleanupAllInvalidFiles() (int, error) {
+// The context bounds the validation queries; cancelling it aborts the run before anything is deleted.
+// Files are checked by a pool of workers; a run that exceeds its time budget cleans up what it
+// found, is reported as partial, and the next run resumes after the last file it checked.
+// Albums left without files are kept, flagged or removed per the cleanup_empty_albums setting,
+// and shares of deleted files and albums are handled per the orphaned_shares setting.
+func (s *FileValidatorService) CleanupAllInvalidFiles(ctx context.Context) (*CleanupResult, error) {
 	log.Println("Starting full file validation and cleanup...")
 
+	s.mu.Lock()
+	resumeAfter := s.resumeAfter
+	s.mu.Unlock()
+	if resumeAfter > 0 {
+		log.Printf("Resuming validation after file %d", resumeAfter)
+	}
+
 	// First, get count of files to validate
 	var fileCount int
-	err := s.db.QueryRow(`
+	err := s.db.QueryRowContext(ctx, `
 		SELECT COUNT(*)
 		FROM files f
 		JOIN file_folder_mappings ffm ON f.id = ffm.file_id
 		JOIN folders fo ON ffm.folder_id = fo.id
-		WHERE f.is_thumbnail IS NULL OR f.is_thumbnail = 0
-	`).Scan(&fileCount)
+		WHERE (f.is_thumbnail IS NULL OR f.is_thumbnail = 0) AND f.id > ?
+	`, resumeAfter).Scan(&fileCount)
 	if err != nil {
 		log.Printf("Error getting file count: %v", err)
 	} else {
 		log.Printf("Found %d files to validate", fileCount)
 	}
 
-	// Get all file-folder mappings
+	// Get all file-folder mappings, in ID order so an interrupted run can resume
 	log.Println("Querying database for all file-folder mappings...")
-	rows, err := s.db.Query(`
+	rows, err := s.db.QueryContext(ctx, `
 		SELECT f.id, fo.absolute_path, ffm.relative_path
 		FROM files f
 		JOIN file_folder_mappings ffm ON f.id = ffm.file_id
 		JOIN folders fo ON ffm.folder_id = fo.id
-		WHERE f.is_thumbnail IS NULL OR f.is_thumbnail = 0
-	`)
+		WHERE (f.is_thumbnail IS NULL OR f.is_thumbnail = 0) AND f.id > ?
+		ORDER BY f.id
+	`, resumeAfter)
 	if err != nil {
 		log.Printf("Error querying database: %v", err)
-		return 0, err
+		return nil, err
 	}
 	defer rows.Close()
-	log.Println("Database query completed, starting validation...")
+	log.Printf("Database query completed, starting validation with %d workers...", s.concurrency)
 
+	type check struct {
+		id   int64
+		path string
+	}
+	checks := make(chan check)
+	var invalidMu sync.Mutex
 	invalidIDs := make([]int64, 0)
+	var workers sync.WaitGroup
+	for i := 0; i < s.concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for c := range checks {
+				if s.fileExists(c.path) {
+					continue
+				}
+				invalidMu.Lock()
+				invalidIDs = append(invalidIDs, c.id)
+				if len(invalidIDs) <= 5 {
+					log.Printf("File %d marked as invalid: %s", c.id, c.path)
+				}
+				invalidMu.Unlock()
+			}
+		}()
+	}
+
+	var deadline time.Time
+	if s.runTimeout > 0 {
+		deadline = time.Now().Add(s.runTimeout)
+	}
 	total := 0
 	checked := 0
 	progressInterval := 10 // Log progress every 10 files for better debugging
+	partial := false
+	var lastID int64
 
 	for rows.Next() {
 		var id int64
@@ -189,8 +263,16 @@ func (s *FileValidatorService) CleanupAllInvalidFiles() (int, error) {
 			log.Printf("Error scanning row: %v", err)
 			continue
 		}
+
+		// Stop between files, never within a file's mappings, so resuming after lastID skips nothing;
+		// the first file is always checked so every run makes progress
+		if !deadline.IsZero() && lastID != 0 && id != lastID && time.Now().After(deadline) {
+			partial = true
+			break
+		}
 		total++
 		checked++
+		lastID = id
 
 		// Construct absolute path
 		absolutePath := filepath.Join(folderPath, relativePath)
@@ -202,28 +284,70 @@ func (s *FileValidatorService) CleanupAllInvalidFiles() (int, error) {
 
 		// Log progress periodically
 		if checked%progressInterval == 0 {
-			log.Printf("Validation progress: checked %d files, found %d invalid so far...", checked, len(invalidIDs))
+			invalidMu.Lock()
+			log.Printf("Validation progress: checked %d/%d files, found %d invalid so far...", checked, fileCount, len(invalidIDs))
+			invalidMu.Unlock()
 		}
 
-		exists := s.fileExists(absolutePath)
-		if !exists {
-			invalidIDs = append(invalidIDs, id)
-			if len(invalidIDs) <= 5 {
-				log.Printf("File %d marked as invalid: %s", id, absolutePath)
-			}
-		}
+		checks <- check{id: id, path: absolutePath}
+	}
+	close(checks)
+	workers.Wait()
+
+	if err := rows.Err(); err != nil {
+		log.Printf("File validation aborted: %v", err)
+		return nil, err
+	}
+
+	// Checks finished, so every file up to lastID was validated
+	s.mu.Lock()
+	if partial {
+		s.resumeAfter = lastID
+	} else {
+		s.resumeAfter = 0
+	}
+	s.mu.Unlock()
+	if partial {
+		log.Printf("Validation stopped after %s (%d of %d files checked); the next run resumes after file %d",
+			s.runTimeout, checked, fileCount, lastID)
 	}
 
 	log.Printf("Validation scan complete: total %d files checked", total)
 
+	result := &CleanupResult{
+		Deleted:     len(invalidIDs),
+		EmptyAlbums: []CleanupAlbum{},
+		Partial:     partial,
+	}
+
 	// Cleanup invalid files
 	if len(invalidIDs) > 0 {
+		candidates, err := s.albumsContaining(invalidIDs)
+		if err != nil {
+			log.Printf("Error finding albums of invalid files: %v", err)
+		}
+
 		log.Printf("Cleaning up %d invalid files...", len(invalidIDs))
 		s.cleanupFiles(invalidIDs)
+
+		if len(candidates) > 0 {
+			emptied, err := s.handleEmptiedAlbums(candidates)
+			if err != nil {
+				log.Printf("Error handling emptied albums: %v", err)
+			} else {
+				result.EmptyAlbums = emptied
+			}
+		}
+	}
+
+	// Also catches shares of albums deleted since the last run
+	if _, err := s.handleOrphanedShares(); err != nil {
+		log.Printf("Error handling orphaned shares: %v", err)
 	}
 
-	log.Printf("File validation complete: checked %d files, cleaned up %d invalid files", total, len(invalidIDs))
-	return len(invalidIDs), nil
+	log.Printf("File validation complete: checked %d files, cleaned up %d invalid files, %d albums emptied",
+		total, len(invalidIDs), len(result.EmptyAlbums))
+	return result, nil
 }
 
 // CheckFileExists checks if a specific file exists
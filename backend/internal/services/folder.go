@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"awesome-sharing/internal/database"
 	"awesome-sharing/internal/models"
 )
 
@@ -16,6 +17,11 @@ var (
 	ErrFolderNotFound       = errors.New("folder not found")
 	ErrFolderPathConflict   = errors.New("folder path conflicts with existing folder")
 	ErrFolderPathNotAbsolute = errors.New("folder path must be absolute")
+	ErrFolderNotContained   = errors.New("target folder must contain the merged folder's path")
+	ErrFolderHasGroupLinks  = errors.New("folder is linked to permission groups")
+	ErrInvalidThumbnailSize = errors.New("invalid thumbnail size")
+	ErrInvalidIndexHidden   = errors.New("index_hidden must be empty, 'true' or 'false'")
+	ErrInvalidMediaTypes    = errors.New("media_types must be empty, 'image' or 'video'")
 )
 
 type FolderService struct {
@@ -76,33 +82,148 @@ func (s *FolderService) ValidateFolderPath(path string) error {
 			continue
 		}
 
-		// Check if new path is parent of existing path
-		if strings.HasPrefix(existingPath, path+string(filepath.Separator)) {
+		if pathsConflict(path, existingPath) {
 			return ErrFolderPathConflict
 		}
+	}
 
-		// Check if new path is child of existing path
-		if strings.HasPrefix(path, existingPath+string(filepath.Separator)) {
-			return ErrFolderPathConflict
+	return nil
+}
+
+// pathsConflict reports whether two folder paths are identical or one is nested in the other
+func pathsConflict(path, existingPath string) bool {
+	// Check if new path is parent of existing path
+	if strings.HasPrefix(existingPath, path+string(filepath.Separator)) {
+		return true
+	}
+
+	// Check if new path is child of existing path
+	if strings.HasPrefix(path, existingPath+string(filepath.Separator)) {
+		return true
+	}
+
+	// Check if paths are identical
+	return path == existingPath
+}
+
+// FolderSpec describes a folder to create
+type FolderSpec struct {
+	Name         string `json:"name"`
+	AbsolutePath string `json:"absolute_path"`
+}
+
+// BulkFolderResult reports the outcome of creating one folder in a bulk request
+type BulkFolderResult struct {
+	Name         string         `json:"name"`
+	AbsolutePath string         `json:"absolute_path"`
+	Success      bool           `json:"success"`
+	Error        string         `json:"error,omitempty"`
+	Folder       *models.Folder `json:"folder,omitempty"`
+}
+
+// CreateFoldersBulk validates each folder against existing folders and the rest of the batch,
+// then creates all valid ones in a single transaction
+func (s *FolderService) CreateFoldersBulk(specs []FolderSpec, createdBy int64) ([]BulkFolderResult, error) {
+	rows, err := s.db.Query("SELECT absolute_path FROM folders")
+	if err != nil {
+		return nil, err
+	}
+	var taken []string
+	for rows.Next() {
+		var existingPath string
+		if err := rows.Scan(&existingPath); err != nil {
+			continue
+		}
+		taken = append(taken, existingPath)
+	}
+	rows.Close()
+
+	results := make([]BulkFolderResult, len(specs))
+	for i, spec := range specs {
+		results[i] = BulkFolderResult{Name: spec.Name, AbsolutePath: spec.AbsolutePath}
+		switch {
+		case spec.Name == "" || spec.AbsolutePath == "":
+			results[i].Error = "Name and absolute path are required"
+			continue
+		case !filepath.IsAbs(spec.AbsolutePath):
+			results[i].Error = ErrFolderPathNotAbsolute.Error()
+			continue
 		}
 
-		// Check if paths are identical
-		if path == existingPath {
-			return ErrFolderPathConflict
+		path := filepath.Clean(spec.AbsolutePath)
+		conflict := false
+		for _, existingPath := range taken {
+			if pathsConflict(path, existingPath) {
+				conflict = true
+				break
+			}
+		}
+		if conflict {
+			results[i].Error = ErrFolderPathConflict.Error()
+			continue
 		}
+
+		results[i].AbsolutePath = path
+		results[i].Success = true
+		taken = append(taken, path)
 	}
 
-	return nil
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO folders (name, absolute_path, enabled, created_by)
+		VALUES (?, ?, 1, ?)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	ids := make(map[int]int64)
+	for i := range results {
+		if !results[i].Success {
+			continue
+		}
+		result, err := stmt.Exec(results[i].Name, results[i].AbsolutePath, createdBy)
+		if err != nil {
+			return nil, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	for i, id := range ids {
+		folder, err := s.GetFolder(id)
+		if err != nil {
+			return nil, err
+		}
+		results[i].Folder = folder
+	}
+
+	return results, nil
 }
 
 // GetFolder retrieves a folder by ID
 func (s *FolderService) GetFolder(id int64) (*models.Folder, error) {
 	var folder models.Folder
 	err := s.db.QueryRow(`
-		SELECT id, name, absolute_path, enabled, created_by, created_at, updated_at
+		SELECT id, name, absolute_path, enabled, created_by, created_at, updated_at, scan_thumbnail_size, index_hidden,
+		       media_types
 		FROM folders WHERE id = ?
 	`, id).Scan(&folder.ID, &folder.Name, &folder.AbsolutePath, &folder.Enabled,
-		&folder.CreatedBy, &folder.CreatedAt, &folder.UpdatedAt)
+		&folder.CreatedBy, &folder.CreatedAt, &folder.UpdatedAt, &folder.ScanThumbnailSize, &folder.IndexHidden,
+		&folder.MediaTypes)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrFolderNotFound
@@ -212,6 +333,74 @@ func (s *FolderService) UpdateFolder(id int64, name, absolutePath string) error
 	return err
 }
 
+// SetScanThumbnailSize sets the folder's scan-time thumbnail size override.
+// Empty means use the global setting, "none" disables pre-generation for the folder.
+func (s *FolderService) SetScanThumbnailSize(id int64, size string) error {
+	if !IsValidScanThumbnailSize(size) {
+		return ErrInvalidThumbnailSize
+	}
+	_, err := s.db.Exec("UPDATE folders SET scan_thumbnail_size = ?, updated_at = ? WHERE id = ?",
+		size, time.Now(), id)
+	return err
+}
+
+// GetScanThumbnailSize returns the folder's scan-time thumbnail size override
+func (s *FolderService) GetScanThumbnailSize(id int64) (string, error) {
+	var size string
+	err := s.db.QueryRow("SELECT scan_thumbnail_size FROM folders WHERE id = ?", id).Scan(&size)
+	if err == sql.ErrNoRows {
+		return "", ErrFolderNotFound
+	}
+	return size, err
+}
+
+// SetIndexHidden sets whether the scanner indexes dot-prefixed entries in this folder.
+// Empty means use the global index_hidden setting.
+func (s *FolderService) SetIndexHidden(id int64, value string) error {
+	if value != "" && value != "true" && value != "false" {
+		return ErrInvalidIndexHidden
+	}
+	_, err := s.db.Exec("UPDATE folders SET index_hidden = ?, updated_at = ? WHERE id = ?",
+		value, time.Now(), id)
+	return err
+}
+
+// GetIndexHidden returns the folder's index_hidden override
+func (s *FolderService) GetIndexHidden(id int64) (string, error) {
+	var value string
+	err := s.db.QueryRow("SELECT index_hidden FROM folders WHERE id = ?", id).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", ErrFolderNotFound
+	}
+	return value, err
+}
+
+// IsValidMediaTypes reports whether value is a valid folder media_types setting
+func IsValidMediaTypes(value string) bool {
+	return value == "" || value == "image" || value == "video"
+}
+
+// SetMediaTypes restricts the media types the scanner indexes in this folder.
+// Empty means both images and videos.
+func (s *FolderService) SetMediaTypes(id int64, value string) error {
+	if !IsValidMediaTypes(value) {
+		return ErrInvalidMediaTypes
+	}
+	_, err := s.db.Exec("UPDATE folders SET media_types = ?, updated_at = ? WHERE id = ?",
+		value, time.Now(), id)
+	return err
+}
+
+// GetMediaTypes returns the folder's media_types restriction
+func (s *FolderService) GetMediaTypes(id int64) (string, error) {
+	var value string
+	err := s.db.QueryRow("SELECT media_types FROM folders WHERE id = ?", id).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", ErrFolderNotFound
+	}
+	return value, err
+}
+
 // DeleteFolder deletes a folder
 func (s *FolderService) DeleteFolder(id int64) error {
 	_, err := s.db.Exec("DELETE FROM folders WHERE id = ?", id)
@@ -275,7 +464,7 @@ func (s *FolderService) ResolveAbsolutePath(fileID int64) (string, error) {
 
 // AddFileMapping adds a file-folder mapping
 func (s *FolderService) AddFileMapping(fileID, folderID int64, relativePath string) error {
-	_, err := s.db.Exec(`
+	_, err := database.ExecWithRetry(s.db, `
 		INSERT OR REPLACE INTO file_folder_mappings (file_id, folder_id, relative_path)
 		VALUES (?, ?, ?)
 	`, fileID, folderID, relativePath)
@@ -284,7 +473,7 @@ func (s *FolderService) AddFileMapping(fileID, folderID int64, relativePath stri
 
 // RemoveFileMapping removes a specific file-folder mapping
 func (s *FolderService) RemoveFileMapping(fileID, folderID int64) error {
-	_, err := s.db.Exec(`
+	_, err := database.ExecWithRetry(s.db, `
 		DELETE FROM file_folder_mappings
 		WHERE file_id = ? AND folder_id = ?
 	`, fileID, folderID)
@@ -334,6 +523,63 @@ func (s *FolderService) CountFilesInFolder(folderID int64) (int, error) {
 	return count, err
 }
 
+// AttachFolderStats augments folders with file counts and permission groups using one grouped
+// query each, instead of calling CountFilesInFolder/GetPermissionGroupsForFolder per folder
+func (s *FolderService) AttachFolderStats(folders []models.Folder) ([]models.FolderWithStats, error) {
+	result := make([]models.FolderWithStats, len(folders))
+	index := make(map[int64]int, len(folders))
+	for i, folder := range folders {
+		result[i] = models.FolderWithStats{Folder: folder, PermissionGroups: []models.FolderGroupRef{}}
+		index[folder.ID] = i
+	}
+
+	rows, err := s.db.Query(`
+		SELECT ffm.folder_id, COUNT(*)
+		FROM files f
+		INNER JOIN file_folder_mappings ffm ON f.id = ffm.file_id
+		WHERE f.is_thumbnail IS NULL OR f.is_thumbnail = 0
+		GROUP BY ffm.folder_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var folderID int64
+		var count int
+		if err := rows.Scan(&folderID, &count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if i, ok := index[folderID]; ok {
+			result[i].FileCount = count
+		}
+	}
+	rows.Close()
+
+	rows, err = s.db.Query(`
+		SELECT pgf.folder_id, pg.id, pg.name
+		FROM permission_group_folders pgf
+		INNER JOIN permission_groups pg ON pg.id = pgf.permission_group_id
+		ORDER BY pg.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var folderID int64
+		var group models.FolderGroupRef
+		if err := rows.Scan(&folderID, &group.ID, &group.Name); err != nil {
+			return nil, err
+		}
+		if i, ok := index[folderID]; ok {
+			result[i].PermissionGroups = append(result[i].PermissionGroups, group)
+		}
+	}
+
+	return result, rows.Err()
+}
+
 // DirectoryInfo represents a directory in the file system
 type DirectoryInfo struct {
 	Name        string `json:"name"`
@@ -398,3 +644,237 @@ func (s *FolderService) BrowseDirectory(path string) ([]DirectoryInfo, error) {
 
 	return directories, nil
 }
+
+// FolderOverlap describes two folders whose paths overlap (one contains the other)
+type FolderOverlap struct {
+	Parent    models.Folder `json:"parent"`
+	Child     models.Folder `json:"child"`
+	Identical bool          `json:"identical"`
+}
+
+// FolderMergeResult summarizes a folder merge
+type FolderMergeResult struct {
+	MappingsMoved            int `json:"mappings_moved"`
+	DuplicatesRemoved        int `json:"duplicates_removed"`
+	AlbumConfigsMoved        int `json:"album_configs_moved"`
+	PermissionGroupsAffected int `json:"permission_groups_affected"`
+}
+
+// isPathWithin reports whether path equals root or lies inside it
+func isPathWithin(root, path string) bool {
+	root = filepath.Clean(root)
+	path = filepath.Clean(path)
+	return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+}
+
+// FindOverlappingFolders reports folder pairs where one path contains the other.
+// Such overlaps cause files to be indexed twice.
+func (s *FolderService) FindOverlappingFolders() ([]FolderOverlap, error) {
+	folders, err := s.ListFolders(0, true)
+	if err != nil {
+		return nil, err
+	}
+
+	overlaps := []FolderOverlap{}
+	for i := 0; i < len(folders); i++ {
+		for j := i + 1; j < len(folders); j++ {
+			a, b := folders[i], folders[j]
+			switch {
+			case isPathWithin(a.AbsolutePath, b.AbsolutePath):
+				overlaps = append(overlaps, FolderOverlap{Parent: a, Child: b,
+					Identical: filepath.Clean(a.AbsolutePath) == filepath.Clean(b.AbsolutePath)})
+			case isPathWithin(b.AbsolutePath, a.AbsolutePath):
+				overlaps = append(overlaps, FolderOverlap{Parent: b, Child: a})
+			}
+		}
+	}
+
+	return overlaps, nil
+}
+
+// repointFileReferences moves everything that refers to a duplicate file record (shares, tags,
+// comments, album covers, child files, access stats) to the surviving record before the
+// duplicate is deleted
+func repointFileReferences(tx *sql.Tx, fromID, toID int64) error {
+	statements := []string{
+		"UPDATE shares SET resource_id = ? WHERE share_type = 'file' AND resource_id = ?",
+		"UPDATE OR IGNORE file_tags SET file_id = ? WHERE file_id = ?",
+		"UPDATE file_comments SET file_id = ? WHERE file_id = ?",
+		"UPDATE albums_v2 SET cover_file_id = ? WHERE cover_file_id = ?",
+		"UPDATE files SET parent_file_id = ? WHERE parent_file_id = ?",
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt, toID, fromID); err != nil {
+			return err
+		}
+	}
+
+	// Access counters are added up rather than moved
+	_, err := tx.Exec(`
+		INSERT INTO file_access_stats (file_id, view_count, download_count, last_accessed)
+		SELECT ?, view_count, download_count, last_accessed FROM file_access_stats WHERE file_id = ?
+		ON CONFLICT(file_id) DO UPDATE SET
+			view_count = view_count + excluded.view_count,
+			download_count = download_count + excluded.download_count,
+			last_accessed = MAX(COALESCE(last_accessed, excluded.last_accessed), COALESCE(excluded.last_accessed, last_accessed))
+	`, toID, fromID)
+	return err
+}
+
+// MergeFolder consolidates the mappings and album configurations of a redundant folder into
+// a target folder whose path contains it, then deletes the redundant folder.
+// Permission group links of the redundant folder are not transferred (that could widen access).
+// If any exist the merge is refused with ErrFolderHasGroupLinks unless dropGroupLinks is set,
+// in which case they are removed and their count is reported.
+func (s *FolderService) MergeFolder(redundantID, targetID int64, dropGroupLinks bool) (*FolderMergeResult, error) {
+	redundant, err := s.GetFolder(redundantID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.GetFolder(targetID)
+	if err != nil {
+		return nil, err
+	}
+	if redundantID == targetID || !isPathWithin(target.AbsolutePath, redundant.AbsolutePath) {
+		return nil, ErrFolderNotContained
+	}
+
+	// Path of the redundant folder relative to the target ("." if identical)
+	relPrefix, err := filepath.Rel(target.AbsolutePath, redundant.AbsolutePath)
+	if err != nil {
+		return nil, err
+	}
+	rebase := func(relativePath string) string {
+		if relPrefix == "." {
+			return relativePath
+		}
+		return filepath.Join(relPrefix, relativePath)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &FolderMergeResult{}
+
+	if err := tx.QueryRow("SELECT COUNT(*) FROM permission_group_folders WHERE folder_id = ?",
+		redundantID).Scan(&result.PermissionGroupsAffected); err != nil {
+		return nil, err
+	}
+	if result.PermissionGroupsAffected > 0 && !dropGroupLinks {
+		return result, ErrFolderHasGroupLinks
+	}
+
+	// Load mappings of the redundant folder
+	rows, err := tx.Query("SELECT file_id, relative_path FROM file_folder_mappings WHERE folder_id = ?", redundantID)
+	if err != nil {
+		return nil, err
+	}
+	type mapping struct {
+		fileID       int64
+		relativePath string
+	}
+	var mappings []mapping
+	for rows.Next() {
+		var m mapping
+		if err := rows.Scan(&m.fileID, &m.relativePath); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		mappings = append(mappings, m)
+	}
+	rows.Close()
+
+	for _, m := range mappings {
+		newPath := rebase(m.relativePath)
+
+		// The target may already have indexed the same file (possibly as another file record)
+		var existingID int64
+		err := tx.QueryRow(`
+			SELECT file_id FROM file_folder_mappings
+			WHERE folder_id = ? AND relative_path = ?
+		`, targetID, newPath).Scan(&existingID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		if err == nil {
+			if existingID != m.fileID {
+				// Duplicate file record created by the overlap; keep the target's one
+				if err := repointFileReferences(tx, m.fileID, existingID); err != nil {
+					return nil, err
+				}
+				if _, err := tx.Exec("DELETE FROM files WHERE id = ?", m.fileID); err != nil {
+					return nil, err
+				}
+				result.DuplicatesRemoved++
+			}
+		} else {
+			if _, err := tx.Exec(`
+				INSERT OR REPLACE INTO file_folder_mappings (file_id, folder_id, relative_path)
+				VALUES (?, ?, ?)
+			`, m.fileID, targetID, newPath); err != nil {
+				return nil, err
+			}
+			result.MappingsMoved++
+		}
+
+		if _, err := tx.Exec("DELETE FROM file_folder_mappings WHERE file_id = ? AND folder_id = ?",
+			m.fileID, redundantID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Rewrite album folder configurations to point at the target
+	albumRows, err := tx.Query("SELECT album_id, path_prefix FROM album_folders WHERE folder_id = ?", redundantID)
+	if err != nil {
+		return nil, err
+	}
+	type albumConfig struct {
+		albumID    int64
+		pathPrefix string
+	}
+	var configs []albumConfig
+	for albumRows.Next() {
+		var ac albumConfig
+		if err := albumRows.Scan(&ac.albumID, &ac.pathPrefix); err != nil {
+			albumRows.Close()
+			return nil, err
+		}
+		configs = append(configs, ac)
+	}
+	albumRows.Close()
+
+	for _, ac := range configs {
+		newPrefix := ac.pathPrefix
+		if relPrefix != "." {
+			newPrefix = filepath.ToSlash(relPrefix) + "/" + ac.pathPrefix
+		}
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO album_folders (album_id, folder_id, path_prefix)
+			VALUES (?, ?, ?)
+		`, ac.albumID, targetID, newPrefix); err != nil {
+			return nil, err
+		}
+		result.AlbumConfigsMoved++
+	}
+	if _, err := tx.Exec("DELETE FROM album_folders WHERE folder_id = ?", redundantID); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM permission_group_folders WHERE folder_id = ?", redundantID); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM folders WHERE id = ?", redundantID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
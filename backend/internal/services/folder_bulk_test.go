@@ -0,0 +1,64 @@
+package services
+
+import "testing"
+
+func TestCreateFoldersBulk(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewFolderService(db)
+
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	seedFolder(t, db, "/data/existing", owner)
+
+	specs := []FolderSpec{
+		{Name: "Photos", AbsolutePath: "/data/photos/"},
+		{Name: "Nested", AbsolutePath: "/data/photos/2024"},
+		{Name: "Inside existing", AbsolutePath: "/data/existing/sub"},
+		{Name: "Parent of existing", AbsolutePath: "/data"},
+		{Name: "Relative", AbsolutePath: "data/videos"},
+		{Name: "", AbsolutePath: "/data/unnamed"},
+		{Name: "Videos", AbsolutePath: "/data/videos"},
+		{Name: "Sibling prefix", AbsolutePath: "/data/photos-old"},
+	}
+	results, err := svc.CreateFoldersBulk(specs, owner)
+	if err != nil {
+		t.Fatalf("bulk create: %v", err)
+	}
+	if len(results) != len(specs) {
+		t.Fatalf("got %d results, want %d", len(results), len(specs))
+	}
+
+	want := []struct {
+		success bool
+		err     string
+	}{
+		{true, ""},
+		{false, ErrFolderPathConflict.Error()},
+		{false, ErrFolderPathConflict.Error()},
+		{false, ErrFolderPathConflict.Error()},
+		{false, ErrFolderPathNotAbsolute.Error()},
+		{false, "Name and absolute path are required"},
+		{true, ""},
+		{true, ""},
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Success != w.success || r.Error != w.err {
+			t.Errorf("%s: got success %v error %q, want %v %q", specs[i].Name, r.Success, r.Error, w.success, w.err)
+		}
+		if r.Success && (r.Folder == nil || r.Folder.ID == 0) {
+			t.Errorf("%s: created folder missing from result", specs[i].Name)
+		}
+		if !r.Success && r.Folder != nil {
+			t.Errorf("%s: failed item has a folder", specs[i].Name)
+		}
+	}
+	if results[0].AbsolutePath != "/data/photos" || results[0].Folder.AbsolutePath != "/data/photos" {
+		t.Errorf("path not cleaned: %q", results[0].AbsolutePath)
+	}
+
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM folders").Scan(&count)
+	if count != 4 {
+		t.Errorf("got %d folders, want 4", count)
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+// AffectedAlbum is an album with folder rules that a folder deletion removes
+type AffectedAlbum struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Rules int    `json:"rules"`
+}
+
+// FolderDeletePreview is what deleting a folder would remove or affect
+type FolderDeletePreview struct {
+	FolderID         int64           `json:"folder_id"`
+	Mappings         int             `json:"mappings"`          // File mappings removed with the folder
+	OrphanedFiles    int             `json:"orphaned_files"`    // Files mapped to no other folder
+	OrphanedBytes    int64           `json:"orphaned_bytes"`    // Indexed size of the orphaned files
+	FileShares       int             `json:"file_shares"`       // Shares of orphaned files
+	Albums           []AffectedAlbum `json:"albums"`            // Albums losing folder rules
+	EmptiedAlbums    int             `json:"emptied_albums"`    // Albums left without any folder rule
+	PermissionGroups int             `json:"permission_groups"` // Groups granting access to the folder
+}
+
+// HasDependencies reports whether deleting the folder would remove anything besides the folder itself
+func (p *FolderDeletePreview) HasDependencies() bool {
+	return p.Mappings > 0 || len(p.Albums) > 0 || p.PermissionGroups > 0
+}
+
+// PreviewDeleteFolder counts what DeleteFolder would cascade to, without changing anything
+func (s *FolderService) PreviewDeleteFolder(id int64) (*FolderDeletePreview, error) {
+	if _, err := s.GetFolder(id); err != nil {
+		return nil, err
+	}
+
+	preview := &FolderDeletePreview{FolderID: id, Albums: []AffectedAlbum{}}
+
+	err := s.db.QueryRow("SELECT COUNT(*) FROM file_folder_mappings WHERE folder_id = ?", id).Scan(&preview.Mappings)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files only reachable through this folder
+	orphans := `
+		SELECT ffm.file_id FROM file_folder_mappings ffm
+		WHERE ffm.folder_id = ? AND NOT EXISTS (
+			SELECT 1 FROM file_folder_mappings other
+			WHERE other.file_id = ffm.file_id AND other.folder_id != ffm.folder_id
+		)`
+	err = s.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(size), 0) FROM files WHERE id IN (`+orphans+`)`, id).
+		Scan(&preview.OrphanedFiles, &preview.OrphanedBytes)
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.QueryRow(`SELECT COUNT(*) FROM shares WHERE share_type = 'file' AND resource_id IN (`+orphans+`)`, id).
+		Scan(&preview.FileShares)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT a.id, a.name, COUNT(*),
+		       (SELECT COUNT(*) FROM album_folders other WHERE other.album_id = a.id AND other.folder_id != ?)
+		FROM album_folders af
+		INNER JOIN albums_v2 a ON a.id = af.album_id
+		WHERE af.folder_id = ?
+		GROUP BY a.id
+		ORDER BY a.name
+	`, id, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var album AffectedAlbum
+		var otherRules int
+		if err := rows.Scan(&album.ID, &album.Name, &album.Rules, &otherRules); err != nil {
+			return nil, err
+		}
+		if otherRules == 0 {
+			preview.EmptiedAlbums++
+		}
+		preview.Albums = append(preview.Albums, album)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = s.db.QueryRow("SELECT COUNT(*) FROM permission_group_folders WHERE folder_id = ?", id).Scan(&preview.PermissionGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPreviewDeleteFolder(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewFolderService(db)
+
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	target := seedFolder(t, db, "/photos/target", owner)
+	other := seedFolder(t, db, "/photos/other", owner)
+	empty := seedFolder(t, db, "/photos/empty", owner)
+
+	only := seedFile(t, db, target, "a.jpg", "image")
+	large := seedFile(t, db, target, "b.jpg", "image")
+	mustExec(t, db, "UPDATE files SET size = 250 WHERE id = ?", large)
+	both := seedFile(t, db, target, "c.jpg", "image")
+	mustExec(t, db, "INSERT INTO file_folder_mappings (file_id, folder_id, relative_path) VALUES (?, ?, 'c.jpg')", both, other)
+	mustExec(t, db, "INSERT INTO shares (id, share_type, resource_id, owner_id, access_type) VALUES ('orphan', 'file', ?, ?, 'public')", only, owner)
+	mustExec(t, db, "INSERT INTO shares (id, share_type, resource_id, owner_id, access_type) VALUES ('kept', 'file', ?, ?, 'public')", both, owner)
+
+	trip := lastID(t, mustExec(t, db, "INSERT INTO albums_v2 (name, owner_id) VALUES ('trip', ?)", owner))
+	mixed := lastID(t, mustExec(t, db, "INSERT INTO albums_v2 (name, owner_id) VALUES ('mixed', ?)", owner))
+	mustExec(t, db, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '')", trip, target)
+	mustExec(t, db, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '2023'), (?, ?, '2024'), (?, ?, '')",
+		mixed, target, mixed, target, mixed, other)
+
+	grantFolder(t, db, owner, target, "read")
+	grantFolder(t, db, owner, target, "write")
+	grantFolder(t, db, owner, other, "read")
+
+	preview, err := svc.PreviewDeleteFolder(target)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if preview.FolderID != target || preview.Mappings != 3 || preview.OrphanedFiles != 2 || preview.OrphanedBytes != 350 ||
+		preview.FileShares != 1 || preview.EmptiedAlbums != 1 || preview.PermissionGroups != 2 {
+		t.Errorf("unexpected preview %+v", preview)
+	}
+	want := []AffectedAlbum{{ID: mixed, Name: "mixed", Rules: 2}, {ID: trip, Name: "trip", Rules: 1}}
+	if len(preview.Albums) != len(want) {
+		t.Fatalf("albums = %+v, want %+v", preview.Albums, want)
+	}
+	for i := range want {
+		if preview.Albums[i] != want[i] {
+			t.Errorf("album %d = %+v, want %+v", i, preview.Albums[i], want[i])
+		}
+	}
+	if !preview.HasDependencies() {
+		t.Error("target folder should have dependencies")
+	}
+
+	var mappings int
+	if err := db.QueryRow("SELECT COUNT(*) FROM file_folder_mappings WHERE folder_id = ?", target).Scan(&mappings); err != nil {
+		t.Fatalf("count mappings: %v", err)
+	}
+	if mappings != 3 {
+		t.Errorf("preview changed mappings: %d left, want 3", mappings)
+	}
+
+	preview, err = svc.PreviewDeleteFolder(empty)
+	if err != nil {
+		t.Fatalf("preview empty folder: %v", err)
+	}
+	if preview.HasDependencies() || preview.Albums == nil {
+		t.Errorf("unexpected preview for empty folder %+v", preview)
+	}
+
+	if _, err := svc.PreviewDeleteFolder(9999); !errors.Is(err, ErrFolderNotFound) {
+		t.Errorf("unknown folder: err = %v, want ErrFolderNotFound", err)
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxDiskTreeDepth bounds how deep a disk tree walk may go
+	MaxDiskTreeDepth = 5
+	// maxDiskTreeNodes bounds the number of directories returned by one walk
+	maxDiskTreeNodes = 5000
+	// diskTreeCacheTTL keeps repeated picker requests from hitting the disk
+	diskTreeCacheTTL = 30 * time.Second
+)
+
+// DiskTreeNode is a directory below a folder root. PathPrefix is the value to
+// use as an album's path_prefix to include the directory's files.
+type DiskTreeNode struct {
+	Name       string         `json:"name"`
+	PathPrefix string         `json:"path_prefix"`
+	Children   []DiskTreeNode `json:"children"`
+	// HasMore is set when the directory has subdirectories beyond the requested depth
+	HasMore bool `json:"has_more"`
+}
+
+// DiskTree is the subdirectory structure of a folder as found on disk
+type DiskTree struct {
+	FolderID    int64          `json:"folder_id"`
+	Depth       int            `json:"depth"`
+	Directories []DiskTreeNode `json:"directories"`
+	// Truncated is set when the walk stopped at the node limit
+	Truncated   bool      `json:"truncated"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+type diskTreeCacheKey struct {
+	folderID int64
+	depth    int
+}
+
+// diskTreeCache holds recent walks shared by all FolderService instances
+var diskTreeCache = struct {
+	sync.Mutex
+	entries map[diskTreeCacheKey]*DiskTree
+}{entries: map[diskTreeCacheKey]*DiskTree{}}
+
+// GetDiskTree walks a folder on disk up to depth levels and returns its subdirectories.
+// Hidden directories and symlinks are skipped. Results are cached briefly.
+func (s *FolderService) GetDiskTree(folderID, userID int64, isAdmin bool, depth int) (*DiskTree, error) {
+	if depth < 1 {
+		depth = 1
+	}
+	if depth > MaxDiskTreeDepth {
+		depth = MaxDiskTreeDepth
+	}
+
+	folder, err := s.GetFolder(folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAdmin {
+		var hasAccess bool
+		err := s.db.QueryRow(`
+			SELECT EXISTS(
+				SELECT 1 FROM permission_group_permissions pgp
+				INNER JOIN permission_group_folders pgf ON pgp.permission_group_id = pgf.permission_group_id
+				WHERE pgp.user_id = ? AND pgf.folder_id = ?
+			)
+		`, userID, folderID).Scan(&hasAccess)
+		if err != nil {
+			return nil, err
+		}
+		if !hasAccess {
+			return nil, ErrFolderAccessDenied
+		}
+	}
+
+	key := diskTreeCacheKey{folderID: folderID, depth: depth}
+	diskTreeCache.Lock()
+	cached := diskTreeCache.entries[key]
+	diskTreeCache.Unlock()
+	if cached != nil && time.Since(cached.GeneratedAt) < diskTreeCacheTTL {
+		return cached, nil
+	}
+
+	info, err := os.Stat(folder.AbsolutePath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, errors.New("folder path is not a directory")
+	}
+
+	tree := &DiskTree{
+		FolderID:    folderID,
+		Depth:       depth,
+		GeneratedAt: time.Now(),
+	}
+	nodes := 0
+	tree.Directories = walkDiskTree(folder.AbsolutePath, "", depth, &nodes, &tree.Truncated)
+
+	diskTreeCache.Lock()
+	diskTreeCache.entries[key] = tree
+	// Drop stale entries so the cache doesn't grow with every folder ever requested
+	for k, entry := range diskTreeCache.entries {
+		if time.Since(entry.GeneratedAt) >= diskTreeCacheTTL {
+			delete(diskTreeCache.entries, k)
+		}
+	}
+	diskTreeCache.Unlock()
+
+	return tree, nil
+}
+
+// walkDiskTree lists the subdirectories of dir, recursing while depth remains
+func walkDiskTree(dir, relPath string, depth int, nodes *int, truncated *bool) []DiskTreeNode {
+	children := []DiskTreeNode{}
+
+	// os.ReadDir returns entries sorted by name
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// Unreadable directories are shown without children
+		return children
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if *nodes >= maxDiskTreeNodes {
+			*truncated = true
+			break
+		}
+		*nodes++
+
+		childRel := entry.Name()
+		if relPath != "" {
+			childRel = relPath + "/" + entry.Name()
+		}
+		node := DiskTreeNode{
+			Name:       entry.Name(),
+			PathPrefix: childRel + "/",
+			Children:   []DiskTreeNode{},
+		}
+		childPath := filepath.Join(dir, entry.Name())
+		if depth > 1 {
+			node.Children = walkDiskTree(childPath, childRel, depth-1, nodes, truncated)
+		} else {
+			node.HasMore = hasSubdirectory(childPath)
+		}
+		children = append(children, node)
+	}
+
+	return children
+}
+
+// hasSubdirectory reports whether dir contains at least one visible subdirectory
+func hasSubdirectory(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			return true
+		}
+	}
+	return false
+}
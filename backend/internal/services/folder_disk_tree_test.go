@@ -0,0 +1,99 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// resetDiskTreeCache empties the process-wide disk tree cache, since folder IDs
+// repeat across test databases
+func resetDiskTreeCache() {
+	diskTreeCache.Lock()
+	diskTreeCache.entries = map[diskTreeCacheKey]*DiskTree{}
+	diskTreeCache.Unlock()
+}
+
+func TestGetDiskTree(t *testing.T) {
+	resetDiskTreeCache()
+	t.Cleanup(resetDiskTreeCache)
+
+	db := newTestDB(t)
+	svc := NewFolderService(db)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	root := t.TempDir()
+	folderID := seedFolder(t, db, root, owner)
+	grantFolder(t, db, owner, folderID, "read")
+
+	for _, dir := range []string{"2023/summer/beach", "2023/winter", "2024", ".cache/thumbs"} {
+		os.MkdirAll(filepath.Join(root, dir), 0755)
+	}
+	os.WriteFile(filepath.Join(root, "2024", "a.jpg"), []byte("x"), 0644)
+
+	tree, err := svc.GetDiskTree(folderID, owner, false, 2)
+	if err != nil {
+		t.Fatalf("GetDiskTree: %v", err)
+	}
+	if tree.FolderID != folderID || tree.Depth != 2 || tree.Truncated {
+		t.Errorf("tree = %+v", tree)
+	}
+	if len(tree.Directories) != 2 {
+		t.Fatalf("top level = %+v, want 2023 and 2024 (hidden skipped)", tree.Directories)
+	}
+
+	y2023, y2024 := tree.Directories[0], tree.Directories[1]
+	if y2023.Name != "2023" || y2023.PathPrefix != "2023/" || len(y2023.Children) != 2 {
+		t.Fatalf("2023 = %+v", y2023)
+	}
+	summer, winter := y2023.Children[0], y2023.Children[1]
+	if summer.Name != "summer" || summer.PathPrefix != "2023/summer/" || !summer.HasMore || len(summer.Children) != 0 {
+		t.Errorf("summer = %+v, want children beyond depth flagged", summer)
+	}
+	if winter.PathPrefix != "2023/winter/" || winter.HasMore {
+		t.Errorf("winter = %+v", winter)
+	}
+	if y2024.Name != "2024" || y2024.PathPrefix != "2024/" || len(y2024.Children) != 0 || y2024.HasMore {
+		t.Errorf("2024 = %+v", y2024)
+	}
+
+	// Deeper walks reach the nested directory
+	deep, err := svc.GetDiskTree(folderID, owner, false, 3)
+	if err != nil {
+		t.Fatalf("depth 3: %v", err)
+	}
+	beach := deep.Directories[0].Children[0].Children
+	if len(beach) != 1 || beach[0].PathPrefix != "2023/summer/beach/" {
+		t.Errorf("depth 3 summer children = %+v", beach)
+	}
+
+	// Results are cached briefly
+	os.MkdirAll(filepath.Join(root, "2025"), 0755)
+	cached, err := svc.GetDiskTree(folderID, owner, false, 2)
+	if err != nil {
+		t.Fatalf("cached: %v", err)
+	}
+	if len(cached.Directories) != 2 || !cached.GeneratedAt.Equal(tree.GeneratedAt) {
+		t.Errorf("second call was not served from the cache")
+	}
+}
+
+func TestGetDiskTreeAccess(t *testing.T) {
+	resetDiskTreeCache()
+	t.Cleanup(resetDiskTreeCache)
+
+	db := newTestDB(t)
+	svc := NewFolderService(db)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	other := seedUser(t, db, "other", "user", "", false)
+	folderID := seedFolder(t, db, t.TempDir(), owner)
+
+	if _, err := svc.GetDiskTree(folderID+100, owner, true, 1); err != ErrFolderNotFound {
+		t.Errorf("missing folder: err = %v, want ErrFolderNotFound", err)
+	}
+	if _, err := svc.GetDiskTree(folderID, other, false, 1); err != ErrFolderAccessDenied {
+		t.Errorf("no access: err = %v, want ErrFolderAccessDenied", err)
+	}
+	if _, err := svc.GetDiskTree(folderID, other, true, 1); err != nil {
+		t.Errorf("admin: %v", err)
+	}
+}
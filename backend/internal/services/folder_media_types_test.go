@@ -0,0 +1,73 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"awesome-sharing/internal/database"
+)
+
+func TestScanMediaTypes(t *testing.T) {
+	cases := []struct {
+		mediaTypes string
+		want       []string
+	}{
+		{"", []string{"a.png", "clip.mp4"}},
+		{"image", []string{"a.png"}},
+		{"video", []string{"clip.mp4"}},
+	}
+	for _, tc := range cases {
+		db := newTestDB(t)
+		folderService := NewFolderService(db)
+		scanner := NewFileScanner(&database.DB{DB: db}, folderService, t.TempDir(), nil)
+
+		owner := seedUser(t, db, "owner", "server_owner", "", false)
+		root := t.TempDir()
+		folderID := seedFolder(t, db, root, owner)
+		writeTestImage(t, filepath.Join(root, "a.png"), 8, 8)
+		writeDiskFile(t, root, "clip.mp4", []byte("\x00\x00\x00\x18ftypmp42"))
+		if err := folderService.SetMediaTypes(folderID, tc.mediaTypes); err != nil {
+			t.Fatalf("%q: set media types: %v", tc.mediaTypes, err)
+		}
+
+		if err := scanner.ScanFolder(folderID); err != nil {
+			t.Fatalf("%q: scan: %v", tc.mediaTypes, err)
+		}
+		if got := indexedPaths(t, db, folderID); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%q: indexed %v, want %v", tc.mediaTypes, got, tc.want)
+		}
+
+		// Skipped types aren't reported as pending either
+		unindexed, err := scanner.FindUnindexed(folderID)
+		if err != nil || len(unindexed) != 0 {
+			t.Errorf("%q: unindexed %v, %v; want none", tc.mediaTypes, unindexed, err)
+		}
+	}
+}
+
+func TestSetMediaTypes(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewFolderService(db)
+	owner := seedUser(t, db, "owner", "server_owner", "", false)
+	folderID := seedFolder(t, db, "/photos", owner)
+
+	for _, value := range []string{"image", "video", ""} {
+		if err := svc.SetMediaTypes(folderID, value); err != nil {
+			t.Fatalf("set %q: %v", value, err)
+		}
+		folder, err := svc.GetFolder(folderID)
+		if err != nil {
+			t.Fatalf("get folder: %v", err)
+		}
+		if folder.MediaTypes != value {
+			t.Errorf("got %q, want %q", folder.MediaTypes, value)
+		}
+	}
+	if err := svc.SetMediaTypes(folderID, "audio"); err != ErrInvalidMediaTypes {
+		t.Errorf("invalid value: got %v, want ErrInvalidMediaTypes", err)
+	}
+	if _, err := svc.GetMediaTypes(folderID + 100); err != ErrFolderNotFound {
+		t.Errorf("missing folder: got %v, want ErrFolderNotFound", err)
+	}
+}
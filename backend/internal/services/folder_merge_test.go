@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFindOverlappingFolders(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewFolderService(db)
+
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	photos := seedFolder(t, db, "/data/photos", owner)
+	trips := seedFolder(t, db, "/data/photos/trips", owner)
+	seedFolder(t, db, "/data/photos-old", owner)
+	dup := lastID(t, mustExec(t, db,
+		"INSERT INTO folders (name, absolute_path, enabled, created_by) VALUES ('dup', '/data/photos/', 1, ?)", owner))
+
+	overlaps, err := svc.FindOverlappingFolders()
+	if err != nil {
+		t.Fatalf("find overlaps: %v", err)
+	}
+
+	type pair struct{ a, b int64 }
+	found := map[pair]bool{}
+	for _, o := range overlaps {
+		found[pair{o.Parent.ID, o.Child.ID}] = o.Identical
+	}
+
+	if identical, ok := found[pair{photos, trips}]; !ok || identical {
+		t.Errorf("expected non-identical overlap photos/trips, got %v (present %v)", identical, ok)
+	}
+	if identical, ok := found[pair{dup, trips}]; !ok || identical {
+		t.Errorf("expected non-identical overlap dup/trips, got %v (present %v)", identical, ok)
+	}
+	identical, ok := found[pair{photos, dup}]
+	if !ok {
+		identical, ok = found[pair{dup, photos}]
+	}
+	if !ok || !identical {
+		t.Errorf("expected photos/dup to be flagged identical, got %+v", overlaps)
+	}
+	if len(overlaps) != 3 {
+		t.Errorf("expected 3 overlaps (sibling prefix must not match), got %d: %+v", len(overlaps), overlaps)
+	}
+}
+
+func TestMergeFolder(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewFolderService(db)
+
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	target := seedFolder(t, db, "/data/photos", owner)
+	redundant := seedFolder(t, db, "/data/photos/trips", owner)
+
+	// Same file indexed twice, once through each folder
+	kept := seedFile(t, db, target, "trips/beach.jpg", "image")
+	dupe := seedFile(t, db, redundant, "beach.jpg", "image")
+	// File only known through the redundant folder
+	moved := seedFile(t, db, redundant, "2024/hike.jpg", "image")
+
+	mustExec(t, db, "INSERT INTO file_access_stats (file_id, view_count, download_count) VALUES (?, 2, 1)", kept)
+	mustExec(t, db, "INSERT INTO file_access_stats (file_id, view_count, download_count) VALUES (?, 3, 4)", dupe)
+
+	tagID := lastID(t, mustExec(t, db, "INSERT INTO tags (name) VALUES ('sea')"))
+	mustExec(t, db, "INSERT INTO file_tags (file_id, tag_id) VALUES (?, ?)", dupe, tagID)
+
+	albumID := lastID(t, mustExec(t, db,
+		"INSERT INTO albums_v2 (name, owner_id, cover_file_id) VALUES ('summer', ?, ?)", owner, dupe))
+	mustExec(t, db, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '2024')", albumID, redundant)
+
+	shareSvc := NewShareService(db, nil)
+	share, err := shareSvc.CreateShare("file", dupe, owner, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	// Refusing to merge into a folder that does not contain the redundant one
+	if _, err := svc.MergeFolder(target, redundant, false); !errors.Is(err, ErrFolderNotContained) {
+		t.Errorf("reverse merge: got %v, want ErrFolderNotContained", err)
+	}
+	if _, err := svc.MergeFolder(target, target, false); !errors.Is(err, ErrFolderNotContained) {
+		t.Errorf("self merge: got %v, want ErrFolderNotContained", err)
+	}
+
+	result, err := svc.MergeFolder(redundant, target, false)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if result.MappingsMoved != 1 || result.DuplicatesRemoved != 1 || result.AlbumConfigsMoved != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM folders WHERE id = ?", redundant).Scan(&count)
+	if count != 0 {
+		t.Errorf("redundant folder still exists")
+	}
+	db.QueryRow("SELECT COUNT(*) FROM files WHERE id = ?", dupe).Scan(&count)
+	if count != 0 {
+		t.Errorf("duplicate file record still exists")
+	}
+
+	var relPath string
+	if err := db.QueryRow("SELECT relative_path FROM file_folder_mappings WHERE file_id = ? AND folder_id = ?",
+		moved, target).Scan(&relPath); err != nil || relPath != "trips/2024/hike.jpg" {
+		t.Errorf("moved mapping: got %q, %v", relPath, err)
+	}
+
+	var views, downloads int
+	db.QueryRow("SELECT view_count, download_count FROM file_access_stats WHERE file_id = ?", kept).Scan(&views, &downloads)
+	if views != 5 || downloads != 5 {
+		t.Errorf("access stats not summed: views %d, downloads %d", views, downloads)
+	}
+
+	db.QueryRow("SELECT COUNT(*) FROM file_tags WHERE file_id = ? AND tag_id = ?", kept, tagID).Scan(&count)
+	if count != 1 {
+		t.Errorf("tag not repointed to kept file")
+	}
+
+	var cover int64
+	db.QueryRow("SELECT cover_file_id FROM albums_v2 WHERE id = ?", albumID).Scan(&cover)
+	if cover != kept {
+		t.Errorf("album cover: got %d, want %d", cover, kept)
+	}
+
+	var prefix string
+	db.QueryRow("SELECT path_prefix FROM album_folders WHERE album_id = ? AND folder_id = ?", albumID, target).Scan(&prefix)
+	if prefix != "trips/2024" {
+		t.Errorf("album path prefix: got %q", prefix)
+	}
+
+	var resourceID int64
+	db.QueryRow("SELECT resource_id FROM shares WHERE id = ?", share.ID).Scan(&resourceID)
+	if resourceID != kept {
+		t.Errorf("share resource: got %d, want %d", resourceID, kept)
+	}
+}
+
+func TestMergeFolderGroupLinks(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewFolderService(db)
+
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	member := seedUser(t, db, "member", "user", "member@example.com", true)
+	target := seedFolder(t, db, "/data/photos", owner)
+	redundant := seedFolder(t, db, "/data/photos/trips", owner)
+	groupID := grantFolder(t, db, member, redundant, "read")
+
+	result, err := svc.MergeFolder(redundant, target, false)
+	if !errors.Is(err, ErrFolderHasGroupLinks) {
+		t.Fatalf("got %v, want ErrFolderHasGroupLinks", err)
+	}
+	if result == nil || result.PermissionGroupsAffected != 1 {
+		t.Errorf("expected affected group count 1, got %+v", result)
+	}
+
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM folders WHERE id = ?", redundant).Scan(&count)
+	if count != 1 {
+		t.Fatalf("refused merge must leave the folder in place")
+	}
+
+	// Access is never transferred to the target
+	result, err = svc.MergeFolder(redundant, target, true)
+	if err != nil {
+		t.Fatalf("merge with drop_group_links: %v", err)
+	}
+	if result.PermissionGroupsAffected != 1 {
+		t.Errorf("expected affected group count 1, got %d", result.PermissionGroupsAffected)
+	}
+	db.QueryRow("SELECT COUNT(*) FROM permission_group_folders WHERE permission_group_id = ?", groupID).Scan(&count)
+	if count != 0 {
+		t.Errorf("group should not gain access to the target folder")
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"database/sql"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"awesome-sharing/internal/database"
+)
+
+// MappingCorrection is a file whose relative path was updated by a repair
+type MappingCorrection struct {
+	FileID  int64  `json:"file_id"`
+	OldPath string `json:"old_path"`
+	NewPath string `json:"new_path"`
+}
+
+// UnresolvedMapping is a file whose relative path is wrong but couldn't be fixed
+type UnresolvedMapping struct {
+	FileID       int64  `json:"file_id"`
+	RelativePath string `json:"relative_path"`
+	Reason       string `json:"reason"`
+}
+
+// MappingRepairResult summarizes a folder mapping repair
+type MappingRepairResult struct {
+	Checked    int                 `json:"checked"`
+	Corrected  []MappingCorrection `json:"corrected"`
+	Unresolved []UnresolvedMapping `json:"unresolved"`
+}
+
+// staleMapping is a mapping whose relative path no longer exists under the folder
+type staleMapping struct {
+	fileID       int64
+	relativePath string
+	filename     string
+	size         int64
+	contentHash  sql.NullString
+}
+
+// RepairMappings re-walks a folder and fixes mappings whose relative_path no longer
+// points at a file, e.g. after the folder's absolute_path was edited. A file is
+// matched by name and size, using the content hash when several candidates remain.
+// Rows are updated in place so IDs, tags, album items and shares are kept.
+func (s *FolderService) RepairMappings(folderID int64) (*MappingRepairResult, error) {
+	folder, err := s.GetFolder(folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT ffm.file_id, ffm.relative_path, f.filename, f.size, f.content_hash
+		FROM file_folder_mappings ffm
+		INNER JOIN files f ON f.id = ffm.file_id
+		WHERE ffm.folder_id = ?
+	`, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MappingRepairResult{
+		Corrected:  []MappingCorrection{},
+		Unresolved: []UnresolvedMapping{},
+	}
+	mapped := map[string]bool{}
+	var stale []staleMapping
+	for rows.Next() {
+		var m staleMapping
+		if err := rows.Scan(&m.fileID, &m.relativePath, &m.filename, &m.size, &m.contentHash); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		result.Checked++
+		if _, err := os.Stat(filepath.Join(folder.AbsolutePath, m.relativePath)); err == nil {
+			mapped[m.relativePath] = true
+			continue
+		}
+		stale = append(stale, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(stale) == 0 {
+		return result, nil
+	}
+
+	// Index files on disk by name; only files not mapped to another row are candidates
+	candidates := map[string][]string{}
+	err = filepath.WalkDir(folder.AbsolutePath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == folder.AbsolutePath {
+				return err
+			}
+			// Skip unreadable directories instead of aborting the repair
+			if entry != nil && entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), ".") && path != folder.AbsolutePath {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(folder.AbsolutePath, path)
+		if err != nil || mapped[relativePath] {
+			return nil
+		}
+		candidates[entry.Name()] = append(candidates[entry.Name()], relativePath)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range stale {
+		var matches []string
+		for _, relativePath := range candidates[m.filename] {
+			info, err := os.Stat(filepath.Join(folder.AbsolutePath, relativePath))
+			if err == nil && info.Size() == m.size && !mapped[relativePath] {
+				matches = append(matches, relativePath)
+			}
+		}
+
+		// Several files with the same name and size: fall back to the content hash
+		if len(matches) > 1 && m.contentHash.Valid {
+			var byHash []string
+			for _, relativePath := range matches {
+				hash, err := ComputeContentHash(filepath.Join(folder.AbsolutePath, relativePath))
+				if err == nil && hash == m.contentHash.String {
+					byHash = append(byHash, relativePath)
+				}
+			}
+			matches = byHash
+		}
+
+		switch len(matches) {
+		case 0:
+			result.Unresolved = append(result.Unresolved, UnresolvedMapping{
+				FileID: m.fileID, RelativePath: m.relativePath, Reason: "no matching file found on disk",
+			})
+			continue
+		case 1:
+		default:
+			result.Unresolved = append(result.Unresolved, UnresolvedMapping{
+				FileID: m.fileID, RelativePath: m.relativePath, Reason: "several matching files found on disk",
+			})
+			continue
+		}
+
+		newPath := matches[0]
+		if _, err := database.ExecWithRetry(s.db, `
+			UPDATE file_folder_mappings SET relative_path = ? WHERE file_id = ? AND folder_id = ?
+		`, newPath, m.fileID, folderID); err != nil {
+			return nil, err
+		}
+		mapped[newPath] = true
+		result.Corrected = append(result.Corrected, MappingCorrection{
+			FileID: m.fileID, OldPath: m.relativePath, NewPath: newPath,
+		})
+	}
+
+	return result, nil
+}
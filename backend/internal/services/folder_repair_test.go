@@ -0,0 +1,109 @@
+package services
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeDiskFile writes content to root/relativePath, creating parent directories
+func writeDiskFile(t *testing.T, root, relativePath string, content []byte) {
+	t.Helper()
+	path := filepath.Join(root, relativePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("create directory: %v", err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("write %s: %v", relativePath, err)
+	}
+}
+
+func TestRepairMappings(t *testing.T) {
+	db := newTestDB(t)
+	s := NewFolderService(db)
+	owner := seedUser(t, db, "owner", "admin", "", false)
+	root := t.TempDir()
+	folderID := seedFolder(t, db, root, owner)
+
+	// seedFile records a size of 100 bytes
+	sameA := bytes.Repeat([]byte("a"), 100)
+	sameB := bytes.Repeat([]byte("b"), 100)
+	writeDiskFile(t, root, "ok.jpg", sameA)
+	writeDiskFile(t, root, "2024/moved.jpg", sameA)
+	writeDiskFile(t, root, "x/dup.jpg", sameA)
+	writeDiskFile(t, root, "y/dup.jpg", sameA)
+	writeDiskFile(t, root, "x/hashed.jpg", sameA)
+	writeDiskFile(t, root, "y/hashed.jpg", sameB)
+	writeDiskFile(t, root, "resized.jpg", []byte("different size"))
+
+	ok := seedFile(t, db, folderID, "ok.jpg", "image")
+	moved := seedFile(t, db, folderID, "old/moved.jpg", "image")
+	dup := seedFile(t, db, folderID, "old/dup.jpg", "image")
+	hashed := seedFile(t, db, folderID, "old/hashed.jpg", "image")
+	hash, _ := ComputeContentHash(filepath.Join(root, "y/hashed.jpg"))
+	mustExec(t, db, "UPDATE files SET content_hash = ? WHERE id = ?", hash, hashed)
+	gone := seedFile(t, db, folderID, "old/gone.jpg", "image")
+	resized := seedFile(t, db, folderID, "old/resized.jpg", "image")
+
+	result, err := s.RepairMappings(folderID)
+	if err != nil {
+		t.Fatalf("RepairMappings: %v", err)
+	}
+	if result.Checked != 6 {
+		t.Errorf("checked %d mappings, want 6", result.Checked)
+	}
+
+	corrected := map[int64]string{}
+	for _, c := range result.Corrected {
+		corrected[c.FileID] = c.NewPath
+	}
+	wantCorrected := map[int64]string{
+		moved:  filepath.Join("2024", "moved.jpg"),
+		hashed: filepath.Join("y", "hashed.jpg"),
+	}
+	if len(corrected) != len(wantCorrected) {
+		t.Errorf("corrected = %+v", result.Corrected)
+	}
+	for id, want := range wantCorrected {
+		if corrected[id] != want {
+			t.Errorf("file %d corrected to %q, want %q", id, corrected[id], want)
+		}
+		var stored string
+		db.QueryRow("SELECT relative_path FROM file_folder_mappings WHERE file_id = ?", id).Scan(&stored)
+		if stored != want {
+			t.Errorf("file %d stored path %q, want %q", id, stored, want)
+		}
+	}
+
+	unresolved := map[int64]string{}
+	for _, u := range result.Unresolved {
+		unresolved[u.FileID] = u.Reason
+	}
+	wantUnresolved := map[int64]string{
+		dup:     "several matching files found on disk",
+		gone:    "no matching file found on disk",
+		resized: "no matching file found on disk",
+	}
+	if len(unresolved) != len(wantUnresolved) {
+		t.Errorf("unresolved = %+v", result.Unresolved)
+	}
+	for id, want := range wantUnresolved {
+		if unresolved[id] != want {
+			t.Errorf("file %d unresolved reason %q, want %q", id, unresolved[id], want)
+		}
+	}
+	if _, touched := corrected[ok]; touched {
+		t.Error("a valid mapping was changed")
+	}
+
+	// A second run finds nothing left to correct
+	result, err = s.RepairMappings(folderID)
+	if err != nil || len(result.Corrected) != 0 {
+		t.Errorf("second run: corrected %+v, err %v", result.Corrected, err)
+	}
+
+	if _, err := s.RepairMappings(folderID + 100); err != ErrFolderNotFound {
+		t.Errorf("unknown folder: err = %v, want ErrFolderNotFound", err)
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"awesome-sharing/internal/models"
+)
+
+func TestAttachFolderStats(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewFolderService(db)
+	groups := NewPermissionGroupService(db)
+
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	shared := seedFolder(t, db, "/photos/shared", owner)
+	single := seedFolder(t, db, "/photos/single", owner)
+	empty := seedFolder(t, db, "/photos/empty", owner)
+	mustExec(t, db, "UPDATE folders SET enabled = 0 WHERE id = ?", empty)
+
+	seedFile(t, db, shared, "a.jpg", "image")
+	seedFile(t, db, shared, "b.jpg", "image")
+	seedFile(t, db, shared, "c.mp4", "video")
+	thumb := seedFile(t, db, shared, ".thumbs/a.jpg", "image")
+	mustExec(t, db, "UPDATE files SET is_thumbnail = 1 WHERE id = ?", thumb)
+	seedFile(t, db, single, "d.jpg", "image")
+
+	family := lastID(t, mustExec(t, db, "INSERT INTO permission_groups (name, description, created_by) VALUES ('Family', '', ?)", owner))
+	friends := lastID(t, mustExec(t, db, "INSERT INTO permission_groups (name, description, created_by) VALUES ('Friends', '', ?)", owner))
+	for _, link := range [][2]int64{{family, shared}, {friends, shared}, {friends, single}} {
+		mustExec(t, db, "INSERT INTO permission_group_folders (permission_group_id, folder_id) VALUES (?, ?)", link[0], link[1])
+	}
+
+	folders, err := svc.ListFolders(owner, true)
+	if err != nil {
+		t.Fatalf("list folders: %v", err)
+	}
+	withStats, err := svc.AttachFolderStats(folders)
+	if err != nil {
+		t.Fatalf("attach stats: %v", err)
+	}
+	if len(withStats) != 3 {
+		t.Fatalf("got %d folders, want 3", len(withStats))
+	}
+
+	groupNames := func(refs []models.FolderGroupRef) string {
+		names := []string{}
+		for _, ref := range refs {
+			names = append(names, ref.Name)
+		}
+		return fmt.Sprint(names)
+	}
+	for _, f := range withStats {
+		// The stats must agree with the per-folder helpers they replace
+		count, err := svc.CountFilesInFolder(f.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		perFolder, err := groups.GetPermissionGroupsForFolder(f.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.FileCount != count || len(f.PermissionGroups) != len(perFolder) {
+			t.Errorf("folder %d: stats %d files / %d groups, helpers say %d / %d",
+				f.ID, f.FileCount, len(f.PermissionGroups), count, len(perFolder))
+		}
+
+		switch f.ID {
+		case shared:
+			if f.FileCount != 3 || groupNames(f.PermissionGroups) != "[Family Friends]" || !f.Enabled {
+				t.Errorf("shared folder: %+v", f)
+			}
+		case single:
+			if f.FileCount != 1 || groupNames(f.PermissionGroups) != "[Friends]" {
+				t.Errorf("single folder: %+v", f)
+			}
+		case empty:
+			if f.FileCount != 0 || f.PermissionGroups == nil || len(f.PermissionGroups) != 0 || f.Enabled {
+				t.Errorf("empty folder: %+v", f)
+			}
+		}
+	}
+}
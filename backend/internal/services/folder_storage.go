@@ -0,0 +1,57 @@
+package services
+
+// FolderStorage is the indexed size of one folder
+type FolderStorage struct {
+	FolderID     int64  `json:"folder_id"`
+	Name         string `json:"name"`
+	AbsolutePath string `json:"absolute_path"`
+	FileCount    int    `json:"file_count"`
+	TotalBytes   int64  `json:"total_bytes"`
+}
+
+// StorageReport breaks indexed storage down per folder, largest first.
+// Files mapped into several overlapping folders count toward each of them,
+// but only once toward the totals.
+type StorageReport struct {
+	Folders    []FolderStorage `json:"folders"`
+	FileCount  int             `json:"file_count"`
+	TotalBytes int64           `json:"total_bytes"`
+}
+
+// StorageByFolder sums file sizes over each folder's mappings
+func (s *FolderService) StorageByFolder() (*StorageReport, error) {
+	rows, err := s.db.Query(`
+		SELECT fo.id, fo.name, fo.absolute_path, COUNT(f.id), COALESCE(SUM(f.size), 0)
+		FROM folders fo
+		LEFT JOIN file_folder_mappings ffm ON ffm.folder_id = fo.id
+		LEFT JOIN files f ON f.id = ffm.file_id
+		GROUP BY fo.id
+		ORDER BY 5 DESC, fo.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := &StorageReport{Folders: []FolderStorage{}}
+	for rows.Next() {
+		var row FolderStorage
+		if err := rows.Scan(&row.FolderID, &row.Name, &row.AbsolutePath, &row.FileCount, &row.TotalBytes); err != nil {
+			return nil, err
+		}
+		report.Folders = append(report.Folders, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = s.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(size), 0) FROM files
+		WHERE id IN (SELECT file_id FROM file_folder_mappings)
+	`).Scan(&report.FileCount, &report.TotalBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import "testing"
+
+func TestStorageByFolder(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewFolderService(db)
+	owner := seedUser(t, db, "owner", "server_owner", "", false)
+	photos := seedFolder(t, db, "/data/photos", owner)
+	trips := seedFolder(t, db, "/data/photos/trips", owner)
+	videos := seedFolder(t, db, "/data/videos", owner)
+	empty := seedFolder(t, db, "/data/empty", owner)
+
+	sized := func(folderID int64, path string, size int64) int64 {
+		id := seedFile(t, db, folderID, path, "image")
+		mustExec(t, db, "UPDATE files SET size = ? WHERE id = ?", size, id)
+		return id
+	}
+	sized(photos, "a.jpg", 1000)
+	sized(photos, "b.jpg", 2000)
+	// Inside both photos and trips
+	trip := sized(photos, "trips/c.jpg", 500)
+	mustExec(t, db, "INSERT INTO file_folder_mappings (file_id, folder_id, relative_path) VALUES (?, ?, 'c.jpg')", trip, trips)
+	sized(videos, "clip.mp4", 10000)
+	// Not mapped into any folder
+	mustExec(t, db, "INSERT INTO files (filename, file_type, size) VALUES ('orphan.jpg', 'image', 99999)")
+
+	report, err := svc.StorageByFolder()
+	if err != nil {
+		t.Fatalf("StorageByFolder: %v", err)
+	}
+
+	want := []struct {
+		id    int64
+		count int
+		bytes int64
+	}{
+		{videos, 1, 10000},
+		{photos, 3, 3500},
+		{trips, 1, 500},
+		{empty, 0, 0},
+	}
+	if len(report.Folders) != len(want) {
+		t.Fatalf("folders = %+v, want %d", report.Folders, len(want))
+	}
+	for i, w := range want {
+		got := report.Folders[i]
+		if got.FolderID != w.id || got.FileCount != w.count || got.TotalBytes != w.bytes {
+			t.Errorf("folder %d = %+v, want id %d with %d files, %d bytes", i, got, w.id, w.count, w.bytes)
+		}
+	}
+
+	// The overlapping file counts once; the unmapped file not at all
+	if report.FileCount != 4 || report.TotalBytes != 13500 {
+		t.Errorf("totals = %d files, %d bytes; want 4, 13500", report.FileCount, report.TotalBytes)
+	}
+}
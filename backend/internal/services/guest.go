@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+
+	"awesome-sharing/internal/models"
+)
+
+// GuestRole is the read-only role limited to the folders of the guest permission group
+const GuestRole = "guest"
+
+// system_settings keys configuring guest access
+const (
+	guestPermissionGroupIDKey = "guest_permission_group_id"
+	guestAnonymousUserIDKey   = "guest_anonymous_user_id"
+)
+
+// ErrNoAnonymousGuest is returned when anonymous browsing is off or its account is unusable
+var ErrNoAnonymousGuest = errors.New("anonymous guest browsing is not enabled")
+
+// GetGuestPermissionGroupID returns the permission group whose folders guests can read,
+// or 0 when none is configured (guests then see nothing)
+func (s *SettingsService) GetGuestPermissionGroupID() (int64, error) {
+	return s.getIDSetting(guestPermissionGroupIDKey)
+}
+
+// GetGuestAnonymousUserID returns the guest account visitors without a session browse
+// as, or 0 when anonymous browsing is disabled
+func (s *SettingsService) GetGuestAnonymousUserID() (int64, error) {
+	return s.getIDSetting(guestAnonymousUserIDKey)
+}
+
+// getIDSetting reads a setting holding a row ID; missing or invalid values read as 0
+func (s *SettingsService) getIDSetting(key string) (int64, error) {
+	setting, err := s.GetSetting(key)
+	if err != nil || setting == nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(setting.Value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, nil
+	}
+	return id, nil
+}
+
+// GetAnonymousGuest returns the account anonymous visitors browse as. It must be an
+// enabled account with the guest role, so a misconfigured setting never grants more.
+func (s *AuthService) GetAnonymousGuest() (*models.User, error) {
+	settings := &SettingsService{db: s.db}
+	userID, err := settings.GetGuestAnonymousUserID()
+	if err != nil {
+		return nil, err
+	}
+	if userID == 0 {
+		return nil, ErrNoAnonymousGuest
+	}
+
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		if err == ErrUserNotFound {
+			return nil, ErrNoAnonymousGuest
+		}
+		return nil, err
+	}
+	if user.Role != GuestRole || !user.Enabled {
+		return nil, ErrNoAnonymousGuest
+	}
+	return user, nil
+}
+
+// AffectsGuestAccess reports whether a settings update changes the guest permission
+// group, so the grants of guests must be synced again
+func AffectsGuestAccess(settings map[string]string) bool {
+	_, ok := settings[guestPermissionGroupIDKey]
+	return ok
+}
+
+// SyncAllGuestAccess runs SyncGuestAccess for every guest account. Grants are synced when
+// the guest permission group setting changes and at startup, not on every request.
+func (s *PermissionGroupService) SyncAllGuestAccess(groupID int64) error {
+	rows, err := s.db.Query("SELECT id FROM users WHERE role = ?", GuestRole)
+	if err != nil {
+		return err
+	}
+	var guests []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		guests = append(guests, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range guests {
+		if err := s.SyncGuestAccess(id, groupID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncGuestAccess gives a user who just became a guest the grants of the guest permission group
+func (s *AuthService) syncGuestAccess(userID int64) error {
+	groupID, err := (&SettingsService{db: s.db}).GetGuestPermissionGroupID()
+	if err != nil {
+		return err
+	}
+	return (&PermissionGroupService{db: s.db}).SyncGuestAccess(userID, groupID)
+}
+
+// SyncGuestAccess makes a guest's permission grants exactly read access to groupID
+// (none when groupID is 0), so every permission group check sees the guest set.
+// It only writes when the grants differ.
+func (s *PermissionGroupService) SyncGuestAccess(userID, groupID int64) error {
+	var stray int
+	var granted bool
+	err := s.db.QueryRow(`
+		SELECT
+			COUNT(CASE WHEN permission_group_id != ? OR permission != 'read' THEN 1 END),
+			COUNT(CASE WHEN permission_group_id = ? AND permission = 'read' THEN 1 END) > 0
+		FROM permission_group_permissions WHERE user_id = ?
+	`, groupID, groupID, userID).Scan(&stray, &granted)
+	if err != nil {
+		return err
+	}
+	if stray == 0 && granted == (groupID != 0) {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		DELETE FROM permission_group_permissions
+		WHERE user_id = ? AND (permission_group_id != ? OR permission != 'read')
+	`, userID, groupID); err != nil {
+		return err
+	}
+	if groupID != 0 {
+		// The group may have been deleted since it was configured
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO permission_group_permissions (permission_group_id, user_id, permission)
+			SELECT id, ?, 'read' FROM permission_groups WHERE id = ?
+		`, userID, groupID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
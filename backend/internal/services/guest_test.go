@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// guestGrants returns the permission group grants of a user as "groupID:permission"
+func guestGrants(t *testing.T, svc *PermissionGroupService, userID int64) []string {
+	t.Helper()
+	rows, err := svc.db.Query("SELECT permission_group_id, permission FROM permission_group_permissions WHERE user_id = ? ORDER BY permission_group_id", userID)
+	if err != nil {
+		t.Fatalf("query grants: %v", err)
+	}
+	defer rows.Close()
+	var grants []string
+	for rows.Next() {
+		var groupID int64
+		var permission string
+		if err := rows.Scan(&groupID, &permission); err != nil {
+			t.Fatalf("scan grant: %v", err)
+		}
+		grants = append(grants, strconv.FormatInt(groupID, 10)+":"+permission)
+	}
+	return grants
+}
+
+func TestSyncGuestAccess(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewPermissionGroupService(db)
+
+	admin := seedUser(t, db, "admin", "admin", "", false)
+	guest := seedUser(t, db, "guest", GuestRole, "", false)
+	guestGroup := lastID(t, mustExec(t, db, "INSERT INTO permission_groups (name, created_by) VALUES ('guests', ?)", admin))
+	otherGroup := lastID(t, mustExec(t, db, "INSERT INTO permission_groups (name, created_by) VALUES ('private', ?)", admin))
+
+	// Grants added elsewhere, or write access to the guest group, don't stick
+	mustExec(t, db, "INSERT INTO permission_group_permissions (permission_group_id, user_id, permission) VALUES (?, ?, 'write')", guestGroup, guest)
+	mustExec(t, db, "INSERT INTO permission_group_permissions (permission_group_id, user_id, permission) VALUES (?, ?, 'read')", otherGroup, guest)
+
+	if err := svc.SyncGuestAccess(guest, guestGroup); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	want := strconv.FormatInt(guestGroup, 10) + ":read"
+	if got := guestGrants(t, svc, guest); len(got) != 1 || got[0] != want {
+		t.Errorf("grants = %v, want [%s]", got, want)
+	}
+
+	// Syncing again is a no-op
+	if err := svc.SyncGuestAccess(guest, guestGroup); err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if got := guestGrants(t, svc, guest); len(got) != 1 || got[0] != want {
+		t.Errorf("grants after second sync = %v, want [%s]", got, want)
+	}
+
+	// No configured group leaves the guest without access
+	if err := svc.SyncGuestAccess(guest, 0); err != nil {
+		t.Fatalf("sync without group: %v", err)
+	}
+	if got := guestGrants(t, svc, guest); len(got) != 0 {
+		t.Errorf("grants without group = %v, want none", got)
+	}
+
+	// A deleted group is not resurrected
+	if err := svc.SyncGuestAccess(guest, 9999); err != nil {
+		t.Fatalf("sync with missing group: %v", err)
+	}
+	if got := guestGrants(t, svc, guest); len(got) != 0 {
+		t.Errorf("grants with missing group = %v, want none", got)
+	}
+}
+
+func TestGetAnonymousGuest(t *testing.T) {
+	db := newTestDB(t)
+	auth := NewAuthService(db)
+	settings := NewSettingsService(db)
+
+	guest := seedUser(t, db, "guest", GuestRole, "", false)
+	regular := seedUser(t, db, "regular", "user", "", false)
+
+	if _, err := auth.GetAnonymousGuest(); !errors.Is(err, ErrNoAnonymousGuest) {
+		t.Errorf("unset: got %v, want ErrNoAnonymousGuest", err)
+	}
+
+	for name, value := range map[string]string{
+		"not a guest":  strconv.FormatInt(regular, 10),
+		"missing user": "9999",
+		"invalid":      "abc",
+		"negative":     "-1",
+	} {
+		if err := settings.SetSetting(guestAnonymousUserIDKey, value); err != nil {
+			t.Fatalf("set setting: %v", err)
+		}
+		if _, err := auth.GetAnonymousGuest(); !errors.Is(err, ErrNoAnonymousGuest) {
+			t.Errorf("%s: got %v, want ErrNoAnonymousGuest", name, err)
+		}
+	}
+
+	if err := settings.SetSetting(guestAnonymousUserIDKey, strconv.FormatInt(guest, 10)); err != nil {
+		t.Fatalf("set setting: %v", err)
+	}
+	user, err := auth.GetAnonymousGuest()
+	if err != nil || user.ID != guest {
+		t.Fatalf("enabled guest: got %v, %v", user, err)
+	}
+
+	// A disabled guest account turns anonymous browsing off
+	mustExec(t, db, "UPDATE users SET enabled = 0 WHERE id = ?", guest)
+	if _, err := auth.GetAnonymousGuest(); !errors.Is(err, ErrNoAnonymousGuest) {
+		t.Errorf("disabled guest: got %v, want ErrNoAnonymousGuest", err)
+	}
+}
+
+func TestIsRegistrableRole(t *testing.T) {
+	for role, want := range map[string]bool{
+		"user":         true,
+		"admin":        false,
+		GuestRole:      true,
+		"server_owner": false,
+		"":             false,
+	} {
+		if got := IsRegistrableRole(role); got != want {
+			t.Errorf("IsRegistrableRole(%q) = %v, want %v", role, got, want)
+		}
+	}
+}
+
+func TestNewGuestsGetGuestGroup(t *testing.T) {
+	db := newTestDB(t)
+	perms := NewPermissionGroupService(db)
+	settings := NewSettingsService(db)
+	auth := NewAuthService(db)
+	auth.SetRegistrationDefaults(settings)
+
+	admin := seedUser(t, db, "admin", "admin", "", false)
+	guestGroup := lastID(t, mustExec(t, db, "INSERT INTO permission_groups (name, created_by) VALUES ('guests', ?)", admin))
+	defaultGroup := lastID(t, mustExec(t, db, "INSERT INTO permission_groups (name, created_by) VALUES ('members', ?)", admin))
+	settings.SetSetting(guestPermissionGroupIDKey, strconv.FormatInt(guestGroup, 10))
+	settings.SetSetting(defaultPermissionGroupIDKey, strconv.FormatInt(defaultGroup, 10))
+	settings.SetSetting(defaultUserRoleKey, GuestRole)
+	want := strconv.FormatInt(guestGroup, 10) + ":read"
+
+	// Registered as a guest: the guest group, not the default one
+	registered, err := auth.CreateUser("visitor", "secret123", "", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if got := guestGrants(t, perms, registered.ID); len(got) != 1 || got[0] != want {
+		t.Errorf("registered guest grants = %v, want [%s]", got, want)
+	}
+
+	// Turned into a guest by an admin
+	member := seedUser(t, db, "member", "user", "", false)
+	if err := auth.UpdateUser(member, map[string]interface{}{"role": GuestRole}); err != nil {
+		t.Fatalf("update role: %v", err)
+	}
+	if got := guestGrants(t, perms, member); len(got) != 1 || got[0] != want {
+		t.Errorf("demoted user grants = %v, want [%s]", got, want)
+	}
+
+	// A changed setting reaches every guest
+	if err := perms.SyncAllGuestAccess(0); err != nil {
+		t.Fatalf("SyncAllGuestAccess: %v", err)
+	}
+	for _, id := range []int64{registered.ID, member} {
+		if got := guestGrants(t, perms, id); len(got) != 0 {
+			t.Errorf("guest %d grants without a guest group = %v", id, got)
+		}
+	}
+}
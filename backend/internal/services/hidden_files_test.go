@@ -0,0 +1,100 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"awesome-sharing/internal/database"
+)
+
+// indexedPaths returns the relative paths mapped into a folder, sorted
+func indexedPaths(t *testing.T, db *sql.DB, folderID int64) []string {
+	t.Helper()
+	rows, err := db.Query("SELECT relative_path FROM file_folder_mappings WHERE folder_id = ? ORDER BY relative_path", folderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var paths []string
+	for rows.Next() {
+		var path string
+		rows.Scan(&path)
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestScanIndexHidden(t *testing.T) {
+	cases := []struct {
+		name     string
+		global   string
+		override string
+		want     []string
+	}{
+		{"skipped by default", "", "", []string{"a.png"}},
+		{"global setting", "true", "", []string{".hidden.png", ".private/b.png", "a.png"}},
+		{"folder enables", "", "true", []string{".hidden.png", ".private/b.png", "a.png"}},
+		{"folder disables", "true", "false", []string{"a.png"}},
+	}
+	for _, tc := range cases {
+		db := newTestDB(t)
+		folderService := NewFolderService(db)
+		settings := NewSettingsService(db)
+		scanner := NewFileScanner(&database.DB{DB: db}, folderService, t.TempDir(), nil)
+		scanner.SetThumbnailPregeneration(NewThumbnailService(t.TempDir()), settings)
+
+		owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+		root := t.TempDir()
+		folderID := seedFolder(t, db, root, owner)
+		os.MkdirAll(filepath.Join(root, ".private"), 0755)
+		writeTestImage(t, filepath.Join(root, "a.png"), 8, 8)
+		writeTestImage(t, filepath.Join(root, ".hidden.png"), 8, 8)
+		writeTestImage(t, filepath.Join(root, ".private", "b.png"), 8, 8)
+		writeTestImage(t, filepath.Join(root, "._a.png"), 8, 8) // macOS resource fork, never indexed
+
+		if tc.global != "" {
+			if err := settings.SetSetting("index_hidden", tc.global); err != nil {
+				t.Fatalf("%s: set setting: %v", tc.name, err)
+			}
+		}
+		if err := folderService.SetIndexHidden(folderID, tc.override); err != nil {
+			t.Fatalf("%s: set override: %v", tc.name, err)
+		}
+
+		if err := scanner.ScanFolder(folderID); err != nil {
+			t.Fatalf("%s: scan: %v", tc.name, err)
+		}
+		if got := indexedPaths(t, db, folderID); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: indexed %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSetIndexHidden(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewFolderService(db)
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	folderID := seedFolder(t, db, "/photos", owner)
+
+	for _, value := range []string{"true", "false", ""} {
+		if err := svc.SetIndexHidden(folderID, value); err != nil {
+			t.Fatalf("set %q: %v", value, err)
+		}
+		folder, err := svc.GetFolder(folderID)
+		if err != nil {
+			t.Fatalf("get folder: %v", err)
+		}
+		if folder.IndexHidden != value {
+			t.Errorf("got %q, want %q", folder.IndexHidden, value)
+		}
+	}
+	if err := svc.SetIndexHidden(folderID, "yes"); err != ErrInvalidIndexHidden {
+		t.Errorf("invalid value: got %v, want ErrInvalidIndexHidden", err)
+	}
+	if _, err := svc.GetIndexHidden(folderID + 100); err != ErrFolderNotFound {
+		t.Errorf("missing folder: got %v, want ErrFolderNotFound", err)
+	}
+}
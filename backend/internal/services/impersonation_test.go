@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestImpersonationSession(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAuthService(db)
+
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	alice := seedUser(t, db, "alice", "user", "alice@example.com", true)
+
+	for _, readOnly := range []bool{true, false} {
+		created, err := svc.CreateImpersonationSession(alice, owner, 30*time.Minute, readOnly)
+		if err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+
+		session, err := svc.GetValidSession(created.ID)
+		if err != nil {
+			t.Fatalf("get session: %v", err)
+		}
+		if session.UserID != alice {
+			t.Errorf("session acts as user %d, want %d", session.UserID, alice)
+		}
+		if session.ImpersonatedBy == nil || *session.ImpersonatedBy != owner {
+			t.Errorf("impersonated_by: got %v, want %d", session.ImpersonatedBy, owner)
+		}
+		if session.ImpersonationReadOnly != readOnly {
+			t.Errorf("read only: got %t, want %t", session.ImpersonationReadOnly, readOnly)
+		}
+		if until := time.Until(session.ExpiresAt); until <= 0 || until > 30*time.Minute {
+			t.Errorf("session expires in %v, want within 30m", until)
+		}
+	}
+
+	// Regular sessions carry no impersonation marker
+	regular, err := svc.CreateSession(alice, time.Hour)
+	if err != nil {
+		t.Fatalf("create regular session: %v", err)
+	}
+	session, err := svc.GetValidSession(regular.ID)
+	if err != nil {
+		t.Fatalf("get regular session: %v", err)
+	}
+	if session.ImpersonatedBy != nil || session.ImpersonationReadOnly {
+		t.Errorf("regular session marked as impersonation: %+v", session)
+	}
+
+	// Expired impersonation sessions are rejected like any other
+	expired, err := svc.CreateImpersonationSession(alice, owner, -time.Minute, true)
+	if err != nil {
+		t.Fatalf("create expired session: %v", err)
+	}
+	if _, err := svc.GetValidSession(expired.ID); err == nil {
+		t.Error("expired impersonation session accepted")
+	}
+}
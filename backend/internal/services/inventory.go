@@ -0,0 +1,117 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// inventoryExportPageSize is the number of files read per query when exporting the inventory
+const inventoryExportPageSize = 500
+
+// InventoryExportErrorMarker starts the last row of an inventory export that failed part
+// way: the response is already streaming, so the error can only be reported in-band
+const InventoryExportErrorMarker = "#export-error"
+
+// WriteInventoryExportError appends the row reporting a failed export to w
+func WriteInventoryExportError(w io.Writer, exportErr error) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{InventoryExportErrorMarker, "export incomplete: " + exportErr.Error()})
+	writer.Flush()
+	return writer.Error()
+}
+
+// ExportInventory writes every indexed file to w as CSV, one row per folder mapping
+// (files without a mapping get a single row with empty folder columns). Files are
+// read in pages by ID so huge libraries are never held in memory at once.
+func (s *FolderService) ExportInventory(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"file_id", "folder", "relative_path", "filename", "size", "file_type", "taken_at", "tags"}); err != nil {
+		return err
+	}
+
+	type inventoryRow struct {
+		fileID       int64
+		folder       sql.NullString
+		relativePath sql.NullString
+		filename     string
+		size         int64
+		fileType     string
+		takenAt      sql.NullTime
+		tags         sql.NullString
+	}
+
+	var lastID int64
+	for {
+		rows, err := s.db.Query(`
+			SELECT f.id, fo.name, ffm.relative_path, f.filename, f.size, f.file_type, pm.taken_at,
+			       (SELECT GROUP_CONCAT(t.name, ';') FROM file_tags ft
+			        JOIN tags t ON ft.tag_id = t.id
+			        WHERE ft.file_id = f.id)
+			FROM files f
+			LEFT JOIN file_folder_mappings ffm ON f.id = ffm.file_id
+			LEFT JOIN folders fo ON ffm.folder_id = fo.id
+			LEFT JOIN photo_metadata pm ON f.id = pm.file_id
+			WHERE f.id IN (
+				SELECT id FROM files
+				WHERE id > ? AND (is_thumbnail IS NULL OR is_thumbnail = 0)
+				ORDER BY id LIMIT ?
+			)
+			ORDER BY f.id, ffm.folder_id
+		`, lastID, inventoryExportPageSize)
+		if err != nil {
+			return err
+		}
+
+		var page []inventoryRow
+		for rows.Next() {
+			var row inventoryRow
+			if err := rows.Scan(&row.fileID, &row.folder, &row.relativePath, &row.filename,
+				&row.size, &row.fileType, &row.takenAt, &row.tags); err != nil {
+				rows.Close()
+				return err
+			}
+			page = append(page, row)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			break
+		}
+
+		for _, row := range page {
+			takenAt := ""
+			if row.takenAt.Valid {
+				takenAt = row.takenAt.Time.Format(time.RFC3339)
+			}
+			record := []string{
+				strconv.FormatInt(row.fileID, 10),
+				row.folder.String,
+				row.relativePath.String,
+				row.filename,
+				strconv.FormatInt(row.size, 10),
+				row.fileType,
+				takenAt,
+				row.tags.String,
+			}
+			if err := writer.Write(csvCells(record)); err != nil {
+				return err
+			}
+		}
+		lastID = page[len(page)-1].fileID
+
+		// Push each page to the client before reading the next one
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// readInventory exports the inventory and returns the header, the rows keyed by
+// "<file id>:<relative path>" and the number of rows
+func readInventory(t *testing.T, s *FolderService) ([]string, map[string][]string, int) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := s.ExportInventory(&buf); err != nil {
+		t.Fatalf("ExportInventory: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("parse inventory: %v", err)
+	}
+	rows := map[string][]string{}
+	for _, record := range records[1:] {
+		rows[record[0]+":"+record[2]] = record
+	}
+	return records[0], rows, len(records) - 1
+}
+
+func TestExportInventory(t *testing.T) {
+	db := newTestDB(t)
+	s := NewFolderService(db)
+	owner := seedUser(t, db, "owner", "admin", "", false)
+	photos := seedFolder(t, db, "/library/photos", owner)
+	backup := seedFolder(t, db, "/library/backup", owner)
+
+	tagged := seedFile(t, db, photos, "2024/beach.jpg", "image")
+	mustExec(t, db, "INSERT INTO file_folder_mappings (file_id, folder_id, relative_path) VALUES (?, ?, 'beach.jpg')", tagged, backup)
+	mustExec(t, db, "INSERT INTO photo_metadata (file_id, taken_at) VALUES (?, '2024-07-01 10:30:00')", tagged)
+	for _, name := range []string{"holiday", "family"} {
+		tagID := lastID(t, mustExec(t, db, "INSERT INTO tags (name) VALUES (?)", name))
+		mustExec(t, db, "INSERT INTO file_tags (file_id, tag_id) VALUES (?, ?)", tagged, tagID)
+	}
+	formula := seedFile(t, db, photos, "=HYPERLINK(x).mp4", "video")
+	unmapped := lastID(t, mustExec(t, db, "INSERT INTO files (filename, file_type, size) VALUES ('orphan.jpg', 'image', 5)"))
+	thumb := lastID(t, mustExec(t, db, "INSERT INTO files (filename, file_type, size, is_thumbnail) VALUES ('thumb.jpg', 'image', 1, 1)"))
+
+	header, rows, count := readInventory(t, s)
+	if strings.Join(header, ",") != "file_id,folder,relative_path,filename,size,file_type,taken_at,tags" {
+		t.Errorf("header = %v", header)
+	}
+	if count != 4 {
+		t.Errorf("exported %d rows, want 4 (one per mapping plus the unmapped file)", count)
+	}
+
+	id := func(fileID int64, path string) string { return fmt.Sprintf("%d:%s", fileID, path) }
+	beach := rows[id(tagged, "2024/beach.jpg")]
+	if beach == nil || beach[1] != "photos" || beach[3] != "beach.jpg" || beach[4] != "100" || beach[5] != "image" {
+		t.Errorf("beach row = %v", beach)
+	}
+	if beach != nil && (!strings.HasPrefix(beach[6], "2024-07-01T10:30:00") || (beach[7] != "holiday;family" && beach[7] != "family;holiday")) {
+		t.Errorf("beach taken_at %q, tags %q", beach[6], beach[7])
+	}
+	if backupRow := rows[id(tagged, "beach.jpg")]; backupRow == nil || backupRow[1] != "backup" {
+		t.Errorf("second mapping row = %v", backupRow)
+	}
+	if orphan := rows[id(unmapped, "")]; orphan == nil || orphan[1] != "" || orphan[3] != "orphan.jpg" {
+		t.Errorf("unmapped row = %v", orphan)
+	}
+	if escaped := rows[id(formula, "'=HYPERLINK(x).mp4")]; escaped == nil || escaped[3] != "'=HYPERLINK(x).mp4" {
+		t.Errorf("formula cells not escaped: %v", rows)
+	}
+	for key := range rows {
+		if strings.HasPrefix(key, fmt.Sprintf("%d:", thumb)) {
+			t.Errorf("thumbnail file exported: %v", rows[key])
+		}
+	}
+}
+
+func TestExportInventoryPages(t *testing.T) {
+	db := newTestDB(t)
+	s := NewFolderService(db)
+	owner := seedUser(t, db, "owner", "admin", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	for i := 0; i < inventoryExportPageSize+3; i++ {
+		seedFile(t, db, folder, fmt.Sprintf("%04d.jpg", i), "image")
+	}
+
+	_, rows, count := readInventory(t, s)
+	if count != inventoryExportPageSize+3 || len(rows) != count {
+		t.Errorf("exported %d rows (%d distinct), want %d", count, len(rows), inventoryExportPageSize+3)
+	}
+}
+
+func TestWriteInventoryExportError(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteInventoryExportError(&buf, errors.New("disk I/O error")); err != nil {
+		t.Fatalf("WriteInventoryExportError: %v", err)
+	}
+	record, err := csv.NewReader(&buf).Read()
+	if err != nil {
+		t.Fatalf("parse error row: %v", err)
+	}
+	if record[0] != InventoryExportErrorMarker || record[1] != "export incomplete: disk I/O error" {
+		t.Errorf("error row = %v", record)
+	}
+}
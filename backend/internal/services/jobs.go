@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"awesome-sharing/internal/models"
+)
+
+// Job statuses
+const (
+	JobStatusQueued  = "queued"
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	JobStatusFailed  = "failed"
+)
+
+// Job types
+const (
+	JobTypeFolderScan        = "folder_scan"
+	JobTypeIndexUnindexed    = "index_unindexed"
+	JobTypeRepairMappings    = "repair_mappings"
+	JobTypeMissingThumbnails = "missing_thumbnails"
+	JobTypeAlbumExport       = "album_export"
+)
+
+var (
+	ErrJobNotFound  = errors.New("job not found")
+	ErrJobQueueFull = errors.New("job queue is full")
+	ErrJobNoFile    = errors.New("job has no file to download")
+)
+
+// jobFilePrefix names the files jobs produce for download; the temp cleanup removes
+// them once they are older than TEMP_MAX_AGE_HOURS
+const jobFilePrefix = "job-"
+
+// JobFileResult is the result of a job that produced a file, e.g. an export.
+// The file is fetched with GET /api/jobs/:id/download.
+type JobFileResult struct {
+	File     string `json:"file"`     // Name inside the job file directory
+	Filename string `json:"filename"` // Name offered to the client
+}
+
+// jobQueueSize bounds the number of jobs waiting for a worker
+const jobQueueSize = 64
+
+// JobFunc performs the work of a job. It may call progress with a percentage (0-100);
+// the returned result is stored as JSON on the job.
+type JobFunc func(ctx context.Context, progress func(percent int)) (interface{}, error)
+
+type pendingJob struct {
+	id int64
+	fn JobFunc
+}
+
+// JobQueue runs long operations on background workers and records their status in the jobs table
+type JobQueue struct {
+	db      *sql.DB
+	queue   chan pendingJob
+	events  *EventDispatcher
+	fileDir string // Where jobs write files for download (default: the system temp dir)
+}
+
+func NewJobQueue(db *sql.DB, events *EventDispatcher) *JobQueue {
+	return &JobQueue{
+		db:     db,
+		queue:  make(chan pendingJob, jobQueueSize),
+		events: events,
+	}
+}
+
+// SetFileDir sets the directory jobs write their downloadable files to
+func (q *JobQueue) SetFileDir(dir string) {
+	q.fileDir = dir
+}
+
+// CreateFile creates a file for a job to write its downloadable output to
+func (q *JobQueue) CreateFile(ext string) (*os.File, error) {
+	return os.CreateTemp(q.fileDir, jobFilePrefix+"*"+ext)
+}
+
+// FilePath returns the path of a job's downloadable file, or ErrJobNoFile if the job
+// did not produce one
+func (q *JobQueue) FilePath(job *models.Job) (string, *JobFileResult, error) {
+	var result JobFileResult
+	if job.Status != JobStatusDone || len(job.Result) == 0 {
+		return "", nil, ErrJobNoFile
+	}
+	if err := json.Unmarshal(job.Result, &result); err != nil || result.File == "" {
+		return "", nil, ErrJobNoFile
+	}
+	// Only names CreateFile could have produced, never a path
+	if filepath.Base(result.File) != result.File || !strings.HasPrefix(result.File, jobFilePrefix) {
+		return "", nil, ErrJobNoFile
+	}
+
+	dir := q.fileDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, result.File), &result, nil
+}
+
+// emitUpdate publishes the current state of a job as a job.updated event
+func (q *JobQueue) emitUpdate(id int64) {
+	job, err := q.GetJob(id)
+	if err != nil {
+		return
+	}
+	q.events.Emit(EventJobUpdated, map[string]interface{}{
+		"job_id":     job.ID,
+		"type":       job.Type,
+		"status":     job.Status,
+		"progress":   job.Progress,
+		"created_by": job.CreatedBy,
+	})
+}
+
+// Start marks jobs left over from a previous run as failed and launches the workers
+func (q *JobQueue) Start(workers int) {
+	_, err := q.db.Exec(`
+		UPDATE jobs SET status = ?, error = 'interrupted by server restart', finished_at = ?
+		WHERE status IN (?, ?)
+	`, JobStatusFailed, time.Now(), JobStatusQueued, JobStatusRunning)
+	if err != nil {
+		log.Printf("Warning: Failed to mark interrupted jobs: %v", err)
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range q.queue {
+				q.run(job)
+			}
+		}()
+	}
+}
+
+// Enqueue records a new job and hands it to a worker
+func (q *JobQueue) Enqueue(jobType string, createdBy int64, fn JobFunc) (*models.Job, error) {
+	result, err := q.db.Exec(`
+		INSERT INTO jobs (type, status, progress, created_by)
+		VALUES (?, ?, 0, ?)
+	`, jobType, JobStatusQueued, createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case q.queue <- pendingJob{id: id, fn: fn}:
+	default:
+		q.finish(id, nil, ErrJobQueueFull)
+		return nil, ErrJobQueueFull
+	}
+
+	return q.GetJob(id)
+}
+
+// GetJob retrieves a job by ID
+func (q *JobQueue) GetJob(id int64) (*models.Job, error) {
+	var job models.Job
+	var result, jobErr sql.NullString
+	err := q.db.QueryRow(`
+		SELECT id, type, status, progress, result, error, created_by, created_at, started_at, finished_at
+		FROM jobs WHERE id = ?
+	`, id).Scan(&job.ID, &job.Type, &job.Status, &job.Progress, &result, &jobErr,
+		&job.CreatedBy, &job.CreatedAt, &job.StartedAt, &job.FinishedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Valid && result.String != "" {
+		job.Result = json.RawMessage(result.String)
+	}
+	job.Error = jobErr.String
+
+	return &job, nil
+}
+
+// run executes a job and records its outcome
+func (q *JobQueue) run(job pendingJob) {
+	if _, err := q.db.Exec("UPDATE jobs SET status = ?, started_at = ? WHERE id = ?",
+		JobStatusRunning, time.Now(), job.id); err != nil {
+		log.Printf("Failed to start job %d: %v", job.id, err)
+	}
+	q.emitUpdate(job.id)
+
+	lastProgress := 0
+	progress := func(percent int) {
+		if percent < 0 {
+			percent = 0
+		} else if percent > 100 {
+			percent = 100
+		}
+		// Only write when the value changes to keep DB traffic low
+		if percent == lastProgress {
+			return
+		}
+		lastProgress = percent
+		if _, err := q.db.Exec("UPDATE jobs SET progress = ? WHERE id = ?", percent, job.id); err != nil {
+			log.Printf("Failed to update progress of job %d: %v", job.id, err)
+		}
+		q.emitUpdate(job.id)
+	}
+
+	result, err := callJob(job, progress)
+	q.finish(job.id, result, err)
+}
+
+// callJob runs a job function, turning a panic into a job failure so it can neither
+// take the worker down nor leave the job marked running
+func callJob(job pendingJob, progress func(percent int)) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %d panicked: %v\n%s", job.id, r, debug.Stack())
+			result, err = nil, fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return job.fn(context.Background(), progress)
+}
+
+// finish stores the final status, result and error of a job
+func (q *JobQueue) finish(id int64, result interface{}, jobErr error) {
+	status := JobStatusDone
+	var errText, resultText sql.NullString
+	if jobErr != nil {
+		status = JobStatusFailed
+		errText = sql.NullString{String: jobErr.Error(), Valid: true}
+		log.Printf("Job %d failed: %v", id, jobErr)
+	}
+	if result != nil {
+		if encoded, err := json.Marshal(result); err == nil {
+			resultText = sql.NullString{String: string(encoded), Valid: true}
+		}
+	}
+
+	// Finished jobs report full progress; failed ones keep where they stopped
+	_, err := q.db.Exec(`
+		UPDATE jobs
+		SET status = ?, progress = CASE WHEN ? THEN 100 ELSE progress END, result = ?, error = ?, finished_at = ?
+		WHERE id = ?
+	`, status, jobErr == nil, resultText, errText, time.Now(), id)
+	if err != nil {
+		log.Printf("Failed to record outcome of job %d: %v", id, err)
+	}
+	q.emitUpdate(id)
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"awesome-sharing/internal/models"
+)
+
+// waitForJob polls a job until it reaches status or the test times out
+func waitForJob(t *testing.T, q *JobQueue, id int64, status string) *models.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := q.GetJob(id)
+		if err != nil {
+			t.Fatalf("get job %d: %v", id, err)
+		}
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %d is %s (progress %d), want %s", id, job.Status, job.Progress, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestJobQueueStatusTransitions(t *testing.T) {
+	db := newTestDB(t)
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	q := NewJobQueue(db, nil)
+
+	release := make(chan struct{})
+	job, err := q.Enqueue(JobTypeFolderScan, owner, func(ctx context.Context, progress func(int)) (interface{}, error) {
+		progress(50)
+		<-release
+		return map[string]int{"folders_scanned": 3}, nil
+	})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if job.Status != JobStatusQueued || job.Type != JobTypeFolderScan || job.CreatedBy != owner {
+		t.Fatalf("new job: %+v", job)
+	}
+
+	// Nothing runs until the workers start
+	time.Sleep(20 * time.Millisecond)
+	if job, _ := q.GetJob(job.ID); job.Status != JobStatusQueued {
+		t.Fatalf("job ran before Start: %s", job.Status)
+	}
+
+	q.Start(1)
+	running := waitForJob(t, q, job.ID, JobStatusRunning)
+	if running.StartedAt == nil {
+		t.Error("running job has no start time")
+	}
+	for deadline := time.Now().Add(5 * time.Second); running.Progress != 50 && time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		running, _ = q.GetJob(job.ID)
+	}
+	if running.Progress != 50 {
+		t.Errorf("progress %d, want 50", running.Progress)
+	}
+
+	close(release)
+	done := waitForJob(t, q, job.ID, JobStatusDone)
+	if done.FinishedAt == nil || done.Error != "" {
+		t.Errorf("finished job: %+v", done)
+	}
+	var result map[string]int
+	if err := json.Unmarshal(done.Result, &result); err != nil || result["folders_scanned"] != 3 {
+		t.Errorf("result %s: %v", done.Result, err)
+	}
+}
+
+func TestJobQueueFailures(t *testing.T) {
+	db := newTestDB(t)
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	q := NewJobQueue(db, nil)
+	q.Start(1)
+
+	failing, err := q.Enqueue(JobTypeFolderScan, owner, func(ctx context.Context, progress func(int)) (interface{}, error) {
+		return nil, errors.New("disk on fire")
+	})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if job := waitForJob(t, q, failing.ID, JobStatusFailed); job.Error != "disk on fire" {
+		t.Errorf("failed job error %q", job.Error)
+	}
+
+	panicking, err := q.Enqueue(JobTypeFolderScan, owner, func(ctx context.Context, progress func(int)) (interface{}, error) {
+		panic("boom")
+	})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	waitForJob(t, q, panicking.ID, JobStatusFailed)
+
+	// The worker survives the panic
+	next, err := q.Enqueue(JobTypeFolderScan, owner, func(ctx context.Context, progress func(int)) (interface{}, error) {
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	waitForJob(t, q, next.ID, JobStatusDone)
+
+	if _, err := q.GetJob(9999); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("missing job: got %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestJobQueueFullAndRestart(t *testing.T) {
+	db := newTestDB(t)
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	noop := func(ctx context.Context, progress func(int)) (interface{}, error) { return nil, nil }
+
+	// Without workers the queue fills up
+	q := NewJobQueue(db, nil)
+	var first int64
+	for i := 0; i < jobQueueSize; i++ {
+		job, err := q.Enqueue(JobTypeFolderScan, owner, noop)
+		if err != nil {
+			t.Fatalf("enqueue %d: %v", i, err)
+		}
+		if i == 0 {
+			first = job.ID
+		}
+	}
+	if _, err := q.Enqueue(JobTypeFolderScan, owner, noop); !errors.Is(err, ErrJobQueueFull) {
+		t.Fatalf("overflow: got %v, want ErrJobQueueFull", err)
+	}
+
+	// A new queue (server restart) fails whatever the previous one left queued
+	NewJobQueue(db, nil).Start(1)
+	if job := waitForJob(t, NewJobQueue(db, nil), first, JobStatusFailed); job.Error != "interrupted by server restart" {
+		t.Errorf("leftover job error %q", job.Error)
+	}
+}
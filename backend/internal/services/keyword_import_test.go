@@ -0,0 +1,158 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"awesome-sharing/internal/database"
+	"awesome-sharing/pkg/exif"
+)
+
+// jpegSegment encodes a JPEG marker segment
+func jpegSegment(marker byte, payload []byte) []byte {
+	seg := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// writeKeywordJPEG writes a small JPEG carrying xmpKeywords in an XMP packet and
+// iptcKeywords in a Photoshop IPTC block; either may be empty
+func writeKeywordJPEG(t *testing.T, path string, xmpKeywords, iptcKeywords []string) {
+	t.Helper()
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := img.Bytes()
+
+	var segments []byte
+	if len(xmpKeywords) > 0 {
+		var items string
+		for _, k := range xmpKeywords {
+			items += "<rdf:li>" + k + "</rdf:li>"
+		}
+		packet := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+			`<rdf:Description xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:subject><rdf:Bag>` + items +
+			`</rdf:Bag></dc:subject></rdf:Description></rdf:RDF></x:xmpmeta>`
+		segments = append(segments, jpegSegment(0xE1, append([]byte("http://ns.adobe.com/xap/1.0/\x00"), packet...))...)
+	}
+	if len(iptcKeywords) > 0 {
+		var iptc []byte
+		for _, k := range iptcKeywords {
+			iptc = append(iptc, 0x1c, 2, 25, 0, 0)
+			binary.BigEndian.PutUint16(iptc[len(iptc)-2:], uint16(len(k)))
+			iptc = append(iptc, k...)
+		}
+		resource := []byte("8BIM\x04\x04\x00\x00\x00\x00\x00\x00")
+		binary.BigEndian.PutUint32(resource[8:], uint32(len(iptc)))
+		resource = append(resource, iptc...)
+		if len(iptc)%2 != 0 {
+			resource = append(resource, 0)
+		}
+		segments = append(segments, jpegSegment(0xED, append([]byte("Photoshop 3.0\x00"), resource...))...)
+	}
+
+	out := append(append(append([]byte{}, data[:2]...), segments...), data[2:]...)
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractKeywords(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		name string
+		xmp  []string
+		iptc []string
+		want []string
+	}{
+		{"xmp only", []string{"Beach", "Sunset"}, nil, []string{"Beach", "Sunset"}},
+		{"iptc only", nil, []string{"Family", "2024"}, []string{"Family", "2024"}},
+		{"merged without duplicates", []string{"Beach", " beach "}, []string{"BEACH", "Family"}, []string{"Beach", "Family"}},
+		{"no keywords", nil, nil, nil},
+	}
+	for i, tc := range cases {
+		path := filepath.Join(dir, fmt.Sprintf("%d.jpg", i))
+		writeKeywordJPEG(t, path, tc.xmp, tc.iptc)
+		got, err := exif.ExtractKeywords(path)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestScanImportsKeywords(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		db := newTestDB(t)
+		settings := NewSettingsService(db)
+		scanner := NewFileScanner(&database.DB{DB: db}, NewFolderService(db), t.TempDir(), nil)
+		scanner.SetThumbnailPregeneration(nil, settings)
+		if enabled {
+			if err := settings.SetSetting("import_keywords", "true"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+		root := t.TempDir()
+		folderID := seedFolder(t, db, root, owner)
+		// An existing tag is reused whatever its case
+		existing := lastID(t, mustExec(t, db, "INSERT INTO tags (name) VALUES ('sunset')"))
+
+		writeKeywordJPEG(t, filepath.Join(root, "tagged.jpg"), []string{"Beach", "Sunset"}, []string{"Family"})
+		writeKeywordJPEG(t, filepath.Join(root, "plain.jpg"), nil, nil)
+
+		if err := scanner.ScanFolder(folderID); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+
+		tagsOf := func(filename string) []string {
+			rows, err := db.Query(`
+				SELECT t.name FROM tags t
+				JOIN file_tags ft ON ft.tag_id = t.id
+				JOIN files f ON f.id = ft.file_id
+				WHERE f.filename = ?`, filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			names := []string{}
+			for rows.Next() {
+				var name string
+				rows.Scan(&name)
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return names
+		}
+
+		want, wantLinked := "[Beach Family sunset]", 1
+		if !enabled {
+			want, wantLinked = "[]", 0
+		}
+		if got := fmt.Sprint(tagsOf("tagged.jpg")); got != want {
+			t.Errorf("enabled=%t: tagged file has tags %s, want %s", enabled, got, want)
+		}
+		if got := tagsOf("plain.jpg"); len(got) != 0 {
+			t.Errorf("enabled=%t: file without keywords got tags %v", enabled, got)
+		}
+
+		var sunsetTags int
+		db.QueryRow("SELECT COUNT(*) FROM tags WHERE name = 'sunset' COLLATE NOCASE").Scan(&sunsetTags)
+		var linked int
+		db.QueryRow("SELECT COUNT(*) FROM file_tags WHERE tag_id = ?", existing).Scan(&linked)
+		if sunsetTags != 1 || linked != wantLinked {
+			t.Errorf("enabled=%t: %d sunset tags, existing tag linked %d times", enabled, sunsetTags, linked)
+		}
+	}
+}
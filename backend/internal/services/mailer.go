@@ -0,0 +1,53 @@
+package services
+
+import (
+	"fmt"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// Mailer sends plain-text email
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPMailer sends mail through an SMTP server. net/smtp upgrades the connection
+// with STARTTLS when the server offers it.
+type SMTPMailer struct {
+	host     string
+	addr     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		addr:     host + ":" + strconv.Itoa(port),
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// stripHeaderBreaks removes line breaks so values can't inject extra headers
+func stripHeaderBreaks(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+// Send delivers a plain-text message to a single recipient
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	to = stripHeaderBreaks(to)
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		stripHeaderBreaks(m.from), to, stripHeaderBreaks(subject), strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return smtp.SendMail(m.addr, auth, m.from, []string{to}, []byte(msg))
+}
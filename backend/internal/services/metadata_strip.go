@@ -0,0 +1,209 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/disintegration/imaging"
+
+	"awesome-sharing/pkg/exif"
+)
+
+var ErrStripUnsupported = errors.New("cannot strip metadata from this image format")
+
+// MetadataStripper produces copies of images without embedded EXIF/XMP/IPTC metadata.
+// Copies are cached on disk, keyed by file ID, modification time and size, so the
+// stored original is never touched.
+type MetadataStripper struct {
+	cacheDir  string
+	maxPixels int64 // Images above this pixel count are not re-encoded (0 = unlimited)
+}
+
+func NewMetadataStripper(cacheDir string) *MetadataStripper {
+	return &MetadataStripper{cacheDir: cacheDir}
+}
+
+// SetMaxSourcePixels limits the pixel count of images decoded for re-encoding (0 = unlimited).
+// Lossless JPEG and PNG stripping never decodes and is not affected.
+func (m *MetadataStripper) SetMaxSourcePixels(pixels int64) {
+	m.maxPixels = pixels
+}
+
+// StrippedPath returns the path of a metadata-free copy of srcPath, creating it if needed
+func (m *MetadataStripper) StrippedPath(fileID int64, srcPath string) (string, error) {
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return "", err
+	}
+
+	ext := strings.ToLower(filepath.Ext(srcPath))
+	switch ext {
+	case ".gif", ".bmp":
+		// These formats carry no EXIF
+		return srcPath, nil
+	case ".jpg", ".jpeg", ".png", ".tif", ".tiff":
+	default:
+		return "", ErrStripUnsupported
+	}
+
+	cachePath := filepath.Join(m.cacheDir, fmt.Sprintf("%d_%d_%d%s", fileID, info.ModTime().UnixNano(), info.Size(), ext))
+	if _, err := os.Stat(cachePath); err == nil {
+		return cachePath, nil
+	}
+
+	if err := os.MkdirAll(m.cacheDir, 0755); err != nil {
+		return "", err
+	}
+
+	// Write to a temp file first so concurrent requests never see a partial copy
+	tmp, err := os.CreateTemp(m.cacheDir, "strip-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpPath)
+
+	if err := stripImageMetadata(srcPath, tmpPath, ext, m.maxPixels); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpPath, cachePath); err != nil {
+		return "", err
+	}
+
+	return cachePath, nil
+}
+
+// stripImageMetadata writes a copy of src without metadata to dst
+func stripImageMetadata(src, dst, ext string, maxPixels int64) error {
+	switch ext {
+	case ".jpg", ".jpeg":
+		// Rotated photos rely on the EXIF orientation tag, so bake the rotation in
+		// by re-encoding; everything else is stripped losslessly
+		if data, err := exif.ExtractEXIF(src); err == nil && data.Orientation > 1 {
+			return reencodeImage(src, dst, maxPixels)
+		}
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		stripped, err := stripJPEGMetadata(data)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dst, stripped, 0644)
+	case ".png":
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		stripped, err := stripPNGMetadata(data)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dst, stripped, 0644)
+	default:
+		return reencodeImage(src, dst, maxPixels)
+	}
+}
+
+// reencodeImage decodes and re-encodes an image, which drops all metadata
+func reencodeImage(src, dst string, maxPixels int64) error {
+	if err := checkPixelLimit(src, maxPixels); err != nil {
+		return err
+	}
+	img, err := imaging.Open(src, imaging.AutoOrientation(true))
+	if err != nil {
+		return err
+	}
+	return imaging.Save(img, dst, imaging.JPEGQuality(95))
+}
+
+// stripJPEGMetadata drops APPn segments (except JFIF and ICC profiles) and comments
+func stripJPEGMetadata(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errors.New("not a JPEG file")
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:2])
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, errors.New("invalid JPEG segment")
+		}
+		marker := data[pos+1]
+		if marker == 0xDA {
+			// Start of scan: the rest is image data
+			out.Write(data[pos:])
+			return out.Bytes(), nil
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, errors.New("truncated JPEG segment")
+		}
+		segment := data[pos:end]
+		payload := segment[4:]
+
+		keep := true
+		switch {
+		case marker == 0xFE: // COM
+			keep = false
+		case marker == 0xE0: // APP0 (JFIF)
+		case marker == 0xE2 && bytes.HasPrefix(payload, []byte("ICC_PROFILE")):
+		case marker >= 0xE1 && marker <= 0xEF: // EXIF, XMP, IPTC, ...
+			keep = false
+		}
+		if keep {
+			out.Write(segment)
+		}
+		pos = end
+	}
+
+	return nil, errors.New("truncated JPEG file")
+}
+
+// pngMetadataChunks are the chunk types that carry EXIF or free-form text
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// stripPNGMetadata drops EXIF and text chunks from a PNG
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	signature := []byte("\x89PNG\r\n\x1a\n")
+	if !bytes.HasPrefix(data, signature) {
+		return nil, errors.New("not a PNG file")
+	}
+
+	var out bytes.Buffer
+	out.Write(signature)
+	pos := len(signature)
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+		end := pos + 12 + length // length + type + data + CRC
+		if length < 0 || end > len(data) {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		if !pngMetadataChunks[chunkType] {
+			out.Write(data[pos:end])
+		}
+		pos = end
+		if chunkType == "IEND" {
+			return out.Bytes(), nil
+		}
+	}
+
+	return nil, errors.New("truncated PNG file")
+}
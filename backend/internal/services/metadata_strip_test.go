@@ -0,0 +1,191 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+
+	"awesome-sharing/pkg/exif"
+)
+
+// gpsEXIF builds a big-endian TIFF block with a GPS position (52.5N 13.4E) and, if
+// orientation is non-zero, an orientation tag
+func gpsEXIF(orientation uint16) []byte {
+	var b bytes.Buffer
+	be := binary.BigEndian
+	entry := func(tag, typ uint16, count, value uint32) {
+		binary.Write(&b, be, tag)
+		binary.Write(&b, be, typ)
+		binary.Write(&b, be, count)
+		binary.Write(&b, be, value)
+	}
+
+	n := uint32(1)
+	if orientation != 0 {
+		n = 2
+	}
+	gpsOffset := 8 + 2 + 12*n + 4
+	ratOffset := gpsOffset + 2 + 4*12 + 4
+
+	b.WriteString("MM\x00\x2a")
+	binary.Write(&b, be, uint32(8))
+	binary.Write(&b, be, uint16(n))
+	if orientation != 0 {
+		entry(0x0112, 3, 1, uint32(orientation)<<16) // SHORT, left-justified
+	}
+	entry(0x8825, 4, 1, gpsOffset)
+	binary.Write(&b, be, uint32(0))
+
+	binary.Write(&b, be, uint16(4))
+	entry(0x0001, 2, 2, uint32('N')<<24)
+	entry(0x0002, 5, 3, ratOffset)
+	entry(0x0003, 2, 2, uint32('E')<<24)
+	entry(0x0004, 5, 3, ratOffset+24)
+	binary.Write(&b, be, uint32(0))
+
+	for _, v := range []uint32{52, 1, 30, 1, 0, 1, 13, 1, 24, 1, 0, 1} {
+		binary.Write(&b, be, v)
+	}
+	return b.Bytes()
+}
+
+// writeGPSJPEG writes a small JPEG with a GPS EXIF block and a comment
+func writeGPSJPEG(t *testing.T, path string, orientation uint16) {
+	t.Helper()
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 8, 4)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := img.Bytes()
+
+	segments := jpegSegment(0xE1, append([]byte("Exif\x00\x00"), gpsEXIF(orientation)...))
+	segments = append(segments, jpegSegment(0xFE, []byte("taken at home"))...)
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segments...)
+	out = append(out, data[2:]...)
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// pngChunk encodes a PNG chunk with its CRC
+func pngChunk(chunkType string, payload []byte) []byte {
+	chunk := make([]byte, 4, 12+len(payload))
+	binary.BigEndian.PutUint32(chunk, uint32(len(payload)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, payload...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func TestStrippedPathRemovesJPEGMetadata(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "gps.jpg")
+	writeGPSJPEG(t, src, 0)
+	original, _ := os.ReadFile(src)
+
+	if data, err := exif.ExtractEXIF(src); err != nil || data.Latitude == nil {
+		t.Fatalf("fixture has no GPS position (err %v)", err)
+	}
+
+	stripper := NewMetadataStripper(filepath.Join(dir, "stripped"))
+	stripped, err := stripper.StrippedPath(7, src)
+	if err != nil {
+		t.Fatalf("StrippedPath: %v", err)
+	}
+	if stripped == src {
+		t.Fatal("expected a separate stripped copy")
+	}
+
+	data, _ := os.ReadFile(stripped)
+	if bytes.Contains(data, []byte("Exif\x00\x00")) || bytes.Contains(data, []byte("taken at home")) {
+		t.Error("stripped copy still carries EXIF or comment")
+	}
+	if data, err := exif.ExtractEXIF(stripped); err == nil && data.Latitude != nil {
+		t.Error("stripped copy still has a GPS position")
+	}
+	if _, err := imaging.Open(stripped); err != nil {
+		t.Errorf("stripped copy does not decode: %v", err)
+	}
+
+	// Original untouched, and a second call reuses the cached copy
+	if now, _ := os.ReadFile(src); !bytes.Equal(now, original) {
+		t.Error("original was modified")
+	}
+	again, err := stripper.StrippedPath(7, src)
+	if err != nil || again != stripped {
+		t.Errorf("second call = %q, %v, want cached %q", again, err, stripped)
+	}
+}
+
+func TestStrippedPathBakesInOrientation(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "rotated.jpg")
+	writeGPSJPEG(t, src, 6) // Rotate 90 CW
+
+	stripped, err := NewMetadataStripper(filepath.Join(dir, "stripped")).StrippedPath(1, src)
+	if err != nil {
+		t.Fatalf("StrippedPath: %v", err)
+	}
+	if data, err := exif.ExtractEXIF(stripped); err == nil && data.Latitude != nil {
+		t.Error("re-encoded copy still has a GPS position")
+	}
+	img, err := imaging.Open(stripped)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 4 || b.Dy() != 8 {
+		t.Errorf("size %dx%d, want the 8x4 source rotated to 4x8", b.Dx(), b.Dy())
+	}
+}
+
+func TestStrippedPathRemovesPNGText(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "plain.png")
+	writeTestImage(t, plain, 8, 8)
+	data, _ := os.ReadFile(plain)
+
+	// Insert a text chunk right after IHDR (signature 8 bytes + IHDR 25 bytes)
+	src := filepath.Join(dir, "text.png")
+	withText := append([]byte{}, data[:33]...)
+	withText = append(withText, pngChunk("tEXt", []byte("Comment\x00taken at home"))...)
+	withText = append(withText, data[33:]...)
+	if err := os.WriteFile(src, withText, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stripped, err := NewMetadataStripper(filepath.Join(dir, "stripped")).StrippedPath(2, src)
+	if err != nil {
+		t.Fatalf("StrippedPath: %v", err)
+	}
+	out, _ := os.ReadFile(stripped)
+	if bytes.Contains(out, []byte("tEXt")) || bytes.Contains(out, []byte("taken at home")) {
+		t.Error("stripped copy still has the text chunk")
+	}
+	if !bytes.Equal(out, data) {
+		t.Error("stripped copy differs from the image without the text chunk")
+	}
+}
+
+func TestStrippedPathFormats(t *testing.T) {
+	dir := t.TempDir()
+	stripper := NewMetadataStripper(filepath.Join(dir, "stripped"))
+
+	gif := filepath.Join(dir, "a.gif")
+	writeTestImage(t, gif, 4, 4)
+	if got, err := stripper.StrippedPath(1, gif); err != nil || got != gif {
+		t.Errorf("gif = %q, %v, want the original path", got, err)
+	}
+
+	heic := filepath.Join(dir, "a.heic")
+	os.WriteFile(heic, []byte("not really"), 0644)
+	if _, err := stripper.StrippedPath(2, heic); err != ErrStripUnsupported {
+		t.Errorf("heic err = %v, want ErrStripUnsupported", err)
+	}
+}
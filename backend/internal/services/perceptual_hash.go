@@ -0,0 +1,45 @@
+package services
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/disintegration/imaging"
+)
+
+// ComputeDHash computes a 64-bit difference hash of an image.
+// The image is reduced to 9x8 grayscale and each bit records whether a pixel
+// is brighter than its right-hand neighbour, so near-identical shots (bursts,
+// re-encodes, small crops) end up with hashes a few bits apart.
+// Images above maxPixels are rejected before decoding (0 = unlimited).
+func ComputeDHash(imagePath string, maxPixels int64) (uint64, error) {
+	if err := checkPixelLimit(imagePath, maxPixels); err != nil {
+		return 0, err
+	}
+
+	src, err := imaging.Open(imagePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open image: %w", err)
+	}
+
+	small := imaging.Grayscale(imaging.Resize(src, 9, 8, imaging.Box))
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			left := small.Pix[small.PixOffset(x, y)]
+			right := small.Pix[small.PixOffset(x+1, y)]
+			hash <<= 1
+			if left > right {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash, nil
+}
+
+// HammingDistance returns the number of differing bits between two perceptual hashes
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+
+	"awesome-sharing/internal/database"
+)
+
+// writeBlockImage saves a 180x160 image of 20px gray blocks with levels drawn from seed
+func writeBlockImage(t *testing.T, path string, seed int64) {
+	t.Helper()
+	rng := rand.New(rand.NewSource(seed))
+	img := image.NewGray(image.Rect(0, 0, 180, 160))
+	for by := 0; by < 8; by++ {
+		for bx := 0; bx < 9; bx++ {
+			level := uint8(rng.Intn(256))
+			for y := by * 20; y < (by+1)*20; y++ {
+				for x := bx * 20; x < (bx+1)*20; x++ {
+					img.SetGray(x, y, color.Gray{Y: level})
+				}
+			}
+		}
+	}
+	if err := imaging.Save(img, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeSimilarImages writes a photo, a slightly brighter re-encode of it, and an unrelated image
+func writeSimilarImages(t *testing.T, dir string) (original, variant, unrelated string) {
+	t.Helper()
+	original = filepath.Join(dir, "burst1.png")
+	variant = filepath.Join(dir, "burst2.jpg")
+	unrelated = filepath.Join(dir, "other.png")
+
+	writeBlockImage(t, original, 1)
+	src, err := imaging.Open(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := imaging.Save(imaging.AdjustBrightness(src, 8), variant, imaging.JPEGQuality(70)); err != nil {
+		t.Fatal(err)
+	}
+	writeBlockImage(t, unrelated, 2)
+	return original, variant, unrelated
+}
+
+func TestComputeDHash(t *testing.T) {
+	original, variant, unrelated := writeSimilarImages(t, t.TempDir())
+
+	hashes := map[string]uint64{}
+	for _, path := range []string{original, variant, unrelated} {
+		hash, err := ComputeDHash(path, 0)
+		if err != nil {
+			t.Fatalf("hash %s: %v", filepath.Base(path), err)
+		}
+		hashes[path] = hash
+	}
+
+	if d := HammingDistance(hashes[original], hashes[variant]); d > 10 {
+		t.Errorf("variant distance %d, want <= 10", d)
+	}
+	if d := HammingDistance(hashes[original], hashes[unrelated]); d <= 10 {
+		t.Errorf("unrelated distance %d, want > 10", d)
+	}
+
+	if _, err := ComputeDHash(filepath.Join(t.TempDir(), "missing.png"), 0); err == nil {
+		t.Error("expected an error for a missing image")
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	cases := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0xff, 0x0f, 4},
+		{0, ^uint64(0), 64},
+	}
+	for _, tc := range cases {
+		if got := HammingDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("HammingDistance(%x, %x) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestFindSimilar(t *testing.T) {
+	db := newTestDB(t)
+	folderSvc := NewFolderService(db)
+	scanner := NewFileScanner(&database.DB{DB: db}, folderSvc, t.TempDir(), nil)
+	meta := NewPhotoMetadataService(db)
+
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	viewer := seedUser(t, db, "viewer", "user", "viewer@example.com", true)
+	root := t.TempDir()
+	folder := seedFolder(t, db, root, owner)
+	writeSimilarImages(t, root)
+
+	if err := scanner.ScanFolder(folder); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	ids := map[string]int64{}
+	rows, err := db.Query("SELECT id, filename FROM files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var id int64
+		var name string
+		rows.Scan(&id, &name)
+		ids[name] = id
+	}
+	rows.Close()
+
+	similar, err := meta.FindSimilar(ids["burst1.png"], 10, 50, nil)
+	if err != nil {
+		t.Fatalf("find similar: %v", err)
+	}
+	if len(similar) != 1 || similar[0].ID != ids["burst2.jpg"] {
+		t.Fatalf("got %+v, want only burst2.jpg", similar)
+	}
+
+	// Scoped to a user without access, nothing is visible
+	if similar, err := meta.FindSimilar(ids["burst1.png"], 10, 50, &viewer); err != nil || len(similar) != 0 {
+		t.Errorf("viewer without access: %+v, %v", similar, err)
+	}
+	grantFolder(t, db, viewer, folder, "read")
+	if similar, err := meta.FindSimilar(ids["burst1.png"], 10, 50, &viewer); err != nil || len(similar) != 1 {
+		t.Errorf("viewer with access: %+v, %v", similar, err)
+	}
+
+	// A wide threshold includes the unrelated image, closest first
+	all, err := meta.FindSimilar(ids["burst1.png"], 64, 50, nil)
+	if err != nil || len(all) != 2 || all[0].ID != ids["burst2.jpg"] || all[0].Distance > all[1].Distance {
+		t.Errorf("wide threshold: %+v, %v", all, err)
+	}
+	if limited, _ := meta.FindSimilar(ids["burst1.png"], 64, 1, nil); len(limited) != 1 {
+		t.Errorf("limit not applied: %d results", len(limited))
+	}
+}
+
+func TestPerceptualHashingDisabled(t *testing.T) {
+	db := newTestDB(t)
+	folderSvc := NewFolderService(db)
+	scanner := NewFileScanner(&database.DB{DB: db}, folderSvc, t.TempDir(), nil)
+	scanner.SetPerceptualHashing(false)
+
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	root := t.TempDir()
+	folder := seedFolder(t, db, root, owner)
+	writeTestImage(t, filepath.Join(root, "a.png"), 16, 16)
+
+	if err := scanner.ScanFolder(folder); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	var fileID int64
+	if err := db.QueryRow("SELECT file_id FROM photo_metadata WHERE phash IS NULL").Scan(&fileID); err != nil {
+		t.Fatalf("expected metadata without a hash: %v", err)
+	}
+	if _, err := NewPhotoMetadataService(db).FindSimilar(fileID, 10, 50, nil); !errors.Is(err, ErrNoPerceptualHash) {
+		t.Errorf("got %v, want ErrNoPerceptualHash", err)
+	}
+}
@@ -257,6 +257,15 @@ func (s *PermissionGroupService) CheckPermission(groupID, userID int64, required
 	return false, nil
 }
 
+// AccessibleFileIDsSQL selects the IDs of the files a user (bound as the only argument)
+// reaches through permission groups; the set-based form of CheckFileAccess
+const AccessibleFileIDsSQL = `
+	SELECT ffm.file_id
+	FROM permission_group_permissions pgp
+	INNER JOIN permission_group_folders pgf ON pgp.permission_group_id = pgf.permission_group_id
+	INNER JOIN file_folder_mappings ffm ON pgf.folder_id = ffm.folder_id
+	WHERE pgp.user_id = ?`
+
 // CheckFileAccess checks if a user has access to a specific file through permission groups
 func (s *PermissionGroupService) CheckFileAccess(userID, fileID int64, isAdmin bool) (bool, error) {
 	// Admin always has access
@@ -281,6 +290,40 @@ func (s *PermissionGroupService) CheckFileAccess(userID, fileID int64, isAdmin b
 	return count > 0, nil
 }
 
+// GetFileAccessLevel returns the highest permission a user has on a file across every folder
+// the file is mapped into: "write", "read", or "" when the user has no access
+func (s *PermissionGroupService) GetFileAccessLevel(userID, fileID int64) (string, error) {
+	var level sql.NullString
+	err := s.db.QueryRow(`
+		SELECT MAX(CASE pgp.permission WHEN 'write' THEN 'write' ELSE 'read' END)
+		FROM permission_group_permissions pgp
+		INNER JOIN permission_group_folders pgf ON pgp.permission_group_id = pgf.permission_group_id
+		INNER JOIN file_folder_mappings ffm ON pgf.folder_id = ffm.folder_id
+		WHERE pgp.user_id = ? AND ffm.file_id = ?
+	`, userID, fileID).Scan(&level)
+
+	if err != nil {
+		return "", err
+	}
+
+	return level.String, nil
+}
+
+// CheckFileWriteAccess checks if a user has write permission on a file through permission groups
+func (s *PermissionGroupService) CheckFileWriteAccess(userID, fileID int64, isAdmin bool) (bool, error) {
+	// Admin always has access
+	if isAdmin {
+		return true, nil
+	}
+
+	level, err := s.GetFileAccessLevel(userID, fileID)
+	if err != nil {
+		return false, err
+	}
+
+	return level == "write", nil
+}
+
 // CheckFolderAccess checks if a user has access to a specific folder through permission groups
 func (s *PermissionGroupService) CheckFolderAccess(userID, folderID int64, isAdmin bool) (bool, error) {
 	// Admin always has access
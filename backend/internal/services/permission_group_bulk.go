@@ -0,0 +1,124 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// BulkPermissionResult reports the outcome for one user in a bulk grant or revoke
+type BulkPermissionResult struct {
+	UserID  int64  `json:"user_id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkGrantPermission grants permission on a group to several users in one transaction.
+// Unknown users, the server owner and repeated IDs are reported per user and skipped.
+// Each change is recorded in the user activity log.
+func (s *PermissionGroupService) BulkGrantPermission(groupID int64, userIDs []int64, permission string, performedBy int64, ipAddress string) ([]BulkPermissionResult, error) {
+	return s.bulkUpdatePermissions(groupID, userIDs, permission, performedBy, ipAddress)
+}
+
+// BulkRevokePermission removes several users from a group in one transaction.
+// Users without a permission on the group are reported as failures.
+func (s *PermissionGroupService) BulkRevokePermission(groupID int64, userIDs []int64, performedBy int64, ipAddress string) ([]BulkPermissionResult, error) {
+	return s.bulkUpdatePermissions(groupID, userIDs, "", performedBy, ipAddress)
+}
+
+// bulkUpdatePermissions grants permission to each user, or revokes when permission is empty
+func (s *PermissionGroupService) bulkUpdatePermissions(groupID int64, userIDs []int64, permission string, performedBy int64, ipAddress string) ([]BulkPermissionResult, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var groupExists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM permission_groups WHERE id = ?)", groupID).Scan(&groupExists); err != nil {
+		return nil, err
+	}
+	if !groupExists {
+		return nil, ErrPermissionGroupNotFound
+	}
+
+	action := "permission_granted"
+	query := `
+		INSERT OR REPLACE INTO permission_group_permissions (permission_group_id, user_id, permission)
+		VALUES (?, ?, ?)
+	`
+	if permission == "" {
+		action = "permission_revoked"
+		query = `
+			DELETE FROM permission_group_permissions
+			WHERE permission_group_id = ? AND user_id = ?
+		`
+	}
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	logStmt, err := tx.Prepare(`
+		INSERT INTO user_activity_logs (user_id, performed_by, action, details, ip_address)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer logStmt.Close()
+
+	results := make([]BulkPermissionResult, 0, len(userIDs))
+	seen := map[int64]bool{}
+	for _, userID := range userIDs {
+		result := BulkPermissionResult{UserID: userID}
+
+		var role string
+		err := tx.QueryRow("SELECT role FROM users WHERE id = ?", userID).Scan(&role)
+		switch {
+		case seen[userID]:
+			result.Error = "duplicate user ID"
+		case err == sql.ErrNoRows:
+			result.Error = ErrUserNotFound.Error()
+		case err != nil:
+			return nil, err
+		case role == "server_owner":
+			// The server owner always has full access, group permissions don't apply
+			result.Error = "server owner permissions cannot be changed"
+		}
+		if result.Error != "" {
+			results = append(results, result)
+			continue
+		}
+		seen[userID] = true
+
+		var res sql.Result
+		if permission == "" {
+			res, err = stmt.Exec(groupID, userID)
+		} else {
+			res, err = stmt.Exec(groupID, userID, permission)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			result.Error = "user has no permission on this group"
+			results = append(results, result)
+			continue
+		}
+
+		details := fmt.Sprintf(`{"permission_group_id":%d,"permission":%q,"bulk":true}`, groupID, permission)
+		if _, err := logStmt.Exec(userID, performedBy, action, details, ipAddress); err != nil {
+			return nil, err
+		}
+
+		result.Success = true
+		results = append(results, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import "testing"
+
+// groupPermissions returns the permission each user holds on a group
+func groupPermissions(t *testing.T, s *PermissionGroupService, groupID int64) map[int64]string {
+	t.Helper()
+	rows, err := s.db.Query("SELECT user_id, permission FROM permission_group_permissions WHERE permission_group_id = ?", groupID)
+	if err != nil {
+		t.Fatalf("query permissions: %v", err)
+	}
+	defer rows.Close()
+	perms := map[int64]string{}
+	for rows.Next() {
+		var userID int64
+		var permission string
+		if err := rows.Scan(&userID, &permission); err != nil {
+			t.Fatalf("scan permission: %v", err)
+		}
+		perms[userID] = permission
+	}
+	return perms
+}
+
+func TestBulkPermissions(t *testing.T) {
+	db := newTestDB(t)
+	s := NewPermissionGroupService(db)
+	admin := seedUser(t, db, "admin", "admin", "", false)
+	owner := seedUser(t, db, "root", "server_owner", "", false)
+	alice := seedUser(t, db, "alice", "user", "", false)
+	bob := seedUser(t, db, "bob", "user", "", false)
+	groupID := lastID(t, mustExec(t, db, "INSERT INTO permission_groups (name, created_by) VALUES ('family', ?)", admin))
+
+	results, err := s.BulkGrantPermission(groupID, []int64{alice, bob, alice, owner, 9999}, "write", admin, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("bulk grant: %v", err)
+	}
+	wantErrors := []string{"", "", "duplicate user ID", "server owner permissions cannot be changed", ErrUserNotFound.Error()}
+	if len(results) != len(wantErrors) {
+		t.Fatalf("got %d results, want %d", len(results), len(wantErrors))
+	}
+	for i, want := range wantErrors {
+		if results[i].Error != want || results[i].Success != (want == "") {
+			t.Errorf("result %d = %+v, want error %q", i, results[i], want)
+		}
+	}
+	perms := groupPermissions(t, s, groupID)
+	if len(perms) != 2 || perms[alice] != "write" || perms[bob] != "write" {
+		t.Errorf("permissions after grant = %v", perms)
+	}
+
+	var logged int
+	if err := db.QueryRow("SELECT COUNT(*) FROM user_activity_logs WHERE action = 'permission_granted' AND performed_by = ? AND ip_address = '10.0.0.1'", admin).Scan(&logged); err != nil {
+		t.Fatalf("count activity log: %v", err)
+	}
+	if logged != 2 {
+		t.Errorf("logged %d grants, want 2", logged)
+	}
+
+	results, err = s.BulkRevokePermission(groupID, []int64{alice, admin}, admin, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("bulk revoke: %v", err)
+	}
+	if !results[0].Success || results[1].Success || results[1].Error != "user has no permission on this group" {
+		t.Errorf("revoke results = %+v", results)
+	}
+	perms = groupPermissions(t, s, groupID)
+	if len(perms) != 1 || perms[bob] != "write" {
+		t.Errorf("permissions after revoke = %v", perms)
+	}
+
+	if _, err := s.BulkGrantPermission(groupID+100, []int64{alice}, "read", admin, ""); err != ErrPermissionGroupNotFound {
+		t.Errorf("unknown group: err = %v, want ErrPermissionGroupNotFound", err)
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// takenAt reads the stored capture date of a file
+func takenAt(t *testing.T, svc *PhotoMetadataService, fileID int64) *time.Time {
+	t.Helper()
+	var at *time.Time
+	if err := svc.db.QueryRow("SELECT taken_at FROM photo_metadata WHERE file_id = ?", fileID).Scan(&at); err != nil {
+		t.Fatalf("read taken_at of %d: %v", fileID, err)
+	}
+	return at
+}
+
+func TestShiftAndSetTakenAt(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewPhotoMetadataService(db)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+
+	dated := seedFile(t, db, folder, "dated.jpg", "image")
+	undated := seedFile(t, db, folder, "undated.jpg", "image")
+	video := seedFile(t, db, folder, "clip.mp4", "video")
+	original := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	mustExec(t, db, "INSERT INTO photo_metadata (file_id, taken_at) VALUES (?, ?)", dated, original)
+	mustExec(t, db, "INSERT INTO photo_metadata (file_id) VALUES (?)", undated)
+
+	results, err := svc.ShiftTakenAt([]int64{dated, undated, video, 9999}, 5*time.Hour)
+	if err != nil {
+		t.Fatalf("shift: %v", err)
+	}
+	want := map[int64]string{dated: DateChangeUpdated, undated: DateChangeNoDate, video: DateChangeNotImage, 9999: DateChangeNotFound}
+	for _, r := range results {
+		if want[r.FileID] != r.Status {
+			t.Errorf("shift %d: status %s, want %s", r.FileID, r.Status, want[r.FileID])
+		}
+	}
+	if CountDateChanges(results) != 1 {
+		t.Errorf("CountDateChanges = %d, want 1", CountDateChanges(results))
+	}
+	if got := takenAt(t, svc, dated); got == nil || !got.Equal(original.Add(5*time.Hour)) {
+		t.Errorf("shifted taken_at = %v, want %v", got, original.Add(5*time.Hour))
+	}
+
+	// An absolute date also fills in images without a capture date, or without metadata at all
+	noMetadata := seedFile(t, db, folder, "new.jpg", "image")
+	date := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	results, err = svc.SetTakenAt([]int64{undated, noMetadata, video}, date)
+	if err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if CountDateChanges(results) != 2 {
+		t.Errorf("set updated %d files, want 2: %v", CountDateChanges(results), results)
+	}
+	for _, id := range []int64{undated, noMetadata} {
+		if got := takenAt(t, svc, id); got == nil || !got.Equal(date) {
+			t.Errorf("file %d: taken_at = %v, want %v", id, got, date)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"awesome-sharing/internal/models"
+)
+
+// ErrNoPerceptualHash is returned when a file has no perceptual hash to compare against
+var ErrNoPerceptualHash = errors.New("file has no perceptual hash")
+
+type PhotoMetadataService struct {
+	db *sql.DB
+}
+
+func NewPhotoMetadataService(db *sql.DB) *PhotoMetadataService {
+	return &PhotoMetadataService{db: db}
+}
+
+// Outcomes of a capture date change for a single file
+const (
+	DateChangeUpdated  = "updated"
+	DateChangeNotFound = "not_found"
+	DateChangeNotImage = "not_image" // Only images carry a capture date
+	DateChangeNoDate   = "no_date"   // Shift only: the capture date is unknown
+	DateChangeDenied   = "denied"    // Set by callers for files the user may not modify
+)
+
+// DateChangeResult is the outcome of a capture date change for one file
+type DateChangeResult struct {
+	FileID int64  `json:"file_id"`
+	Status string `json:"status"`
+}
+
+// CountDateChanges returns the number of results with DateChangeUpdated
+func CountDateChanges(results []DateChangeResult) int {
+	updated := 0
+	for _, result := range results {
+		if result.Status == DateChangeUpdated {
+			updated++
+		}
+	}
+	return updated
+}
+
+// dateChangeTarget reports why a file can't have its capture date changed, or "" if it can
+func dateChangeTarget(tx *sql.Tx, fileID int64) (string, error) {
+	var fileType string
+	err := tx.QueryRow("SELECT file_type FROM files WHERE id = ?", fileID).Scan(&fileType)
+	if err == sql.ErrNoRows {
+		return DateChangeNotFound, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if fileType != "image" {
+		return DateChangeNotImage, nil
+	}
+	return "", nil
+}
+
+// ShiftTakenAt moves the capture date of the given images by offset, reporting the
+// outcome per file. Missing files, videos and images without a known capture date
+// are skipped.
+func (s *PhotoMetadataService) ShiftTakenAt(fileIDs []int64, offset time.Duration) ([]DateChangeResult, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	selectStmt, err := tx.Prepare("SELECT taken_at FROM photo_metadata WHERE file_id = ? AND taken_at IS NOT NULL")
+	if err != nil {
+		return nil, err
+	}
+	defer selectStmt.Close()
+
+	updateStmt, err := tx.Prepare("UPDATE photo_metadata SET taken_at = ?, updated_at = ? WHERE file_id = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer updateStmt.Close()
+
+	results := make([]DateChangeResult, 0, len(fileIDs))
+	now := time.Now()
+	for _, fileID := range fileIDs {
+		status, err := dateChangeTarget(tx, fileID)
+		if err != nil {
+			return nil, err
+		}
+		if status != "" {
+			results = append(results, DateChangeResult{FileID: fileID, Status: status})
+			continue
+		}
+
+		var takenAt time.Time
+		err = selectStmt.QueryRow(fileID).Scan(&takenAt)
+		if err == sql.ErrNoRows {
+			results = append(results, DateChangeResult{FileID: fileID, Status: DateChangeNoDate})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := updateStmt.Exec(takenAt.Add(offset), now, fileID); err != nil {
+			return nil, err
+		}
+		results = append(results, DateChangeResult{FileID: fileID, Status: DateChangeUpdated})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// SetTakenAt sets an absolute capture date on the given images, reporting the outcome
+// per file. Missing files and videos are skipped.
+func (s *PhotoMetadataService) SetTakenAt(fileIDs []int64, takenAt time.Time) ([]DateChangeResult, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO photo_metadata (file_id, taken_at, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(file_id) DO UPDATE SET taken_at = excluded.taken_at, updated_at = excluded.updated_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	results := make([]DateChangeResult, 0, len(fileIDs))
+	now := time.Now()
+	for _, fileID := range fileIDs {
+		status, err := dateChangeTarget(tx, fileID)
+		if err != nil {
+			return nil, err
+		}
+		if status != "" {
+			results = append(results, DateChangeResult{FileID: fileID, Status: status})
+			continue
+		}
+
+		if _, err := stmt.Exec(fileID, takenAt, now); err != nil {
+			return nil, err
+		}
+		results = append(results, DateChangeResult{FileID: fileID, Status: DateChangeUpdated})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// FindSimilar returns files whose perceptual hash is within maxDistance bits of the given file,
+// closest first. When userID is non-nil only files the user can reach through permission groups are considered.
+func (s *PhotoMetadataService) FindSimilar(fileID int64, maxDistance, limit int, userID *int64) ([]models.SimilarFile, error) {
+	var refHash sql.NullInt64
+	err := s.db.QueryRow("SELECT phash FROM photo_metadata WHERE file_id = ?", fileID).Scan(&refHash)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if !refHash.Valid {
+		return nil, ErrNoPerceptualHash
+	}
+
+	query := `SELECT DISTINCT f.id, f.filename, f.file_type, f.size, f.created_at, f.updated_at,
+	                 pm.width, pm.height, pm.taken_at, pm.phash
+	          FROM files f
+	          JOIN photo_metadata pm ON f.id = pm.file_id`
+	args := []interface{}{}
+	if userID != nil {
+		query += `
+	          JOIN file_folder_mappings ffm ON f.id = ffm.file_id
+	          JOIN permission_group_folders pgf ON ffm.folder_id = pgf.folder_id
+	          JOIN permission_group_permissions pgp ON pgf.permission_group_id = pgp.permission_group_id
+	          WHERE pgp.user_id = ? AND`
+		args = append(args, *userID)
+	} else {
+		query += " WHERE"
+	}
+	query += " pm.phash IS NOT NULL AND f.id != ?"
+	args = append(args, fileID)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	similar := []models.SimilarFile{}
+	for rows.Next() {
+		var sf models.SimilarFile
+		var width, height sql.NullInt32
+		var takenAt sql.NullTime
+		var phash int64
+		if err := rows.Scan(&sf.ID, &sf.Filename, &sf.FileType, &sf.Size, &sf.CreatedAt, &sf.UpdatedAt,
+			&width, &height, &takenAt, &phash); err != nil {
+			return nil, err
+		}
+
+		sf.Distance = HammingDistance(uint64(refHash.Int64), uint64(phash))
+		if sf.Distance > maxDistance {
+			continue
+		}
+		if width.Valid {
+			sf.Width = int(width.Int32)
+		}
+		if height.Valid {
+			sf.Height = int(height.Int32)
+		}
+		if takenAt.Valid {
+			sf.TakenAt = &takenAt.Time
+		}
+		similar = append(similar, sf)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(similar, func(i, j int) bool {
+		return similar[i].Distance < similar[j].Distance
+	})
+	if limit > 0 && len(similar) > limit {
+		similar = similar[:limit]
+	}
+
+	return similar, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSlowQueryCancelledByContext(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Counting to a billion takes far longer than the deadline
+	start := time.Now()
+	var n int64
+	err := db.QueryRowContext(ctx, `
+		WITH RECURSIVE counter(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM counter WHERE x < 1000000000)
+		SELECT COUNT(*) FROM counter
+	`).Scan(&n)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v (count %d), want context.DeadlineExceeded", err, n)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("query ran for %v after the deadline", elapsed)
+	}
+}
+
+func TestQueryPathsHonourContext(t *testing.T) {
+	db := newTestDB(t)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	folderID := seedFolder(t, db, t.TempDir(), owner)
+	missing := seedFile(t, db, folderID, "gone.jpg", "image")
+
+	albums := NewAlbumService(db)
+	album, err := albums.CreateAlbum("Album", "", owner)
+	if err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+	mustExec(t, db, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '')", album.ID, folderID)
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	if _, err := albums.ListItemsWithFiles(expired, album.ID, "", "", 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ListItemsWithFiles: got %v, want context.DeadlineExceeded", err)
+	}
+
+	validator := NewFileValidatorService(db, NewFolderService(db), NewSettingsService(db), nil)
+	if _, err := validator.CleanupAllInvalidFiles(expired); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CleanupAllInvalidFiles: got %v, want context.DeadlineExceeded", err)
+	}
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM files WHERE id = ?", missing).Scan(&count)
+	if count != 1 {
+		t.Error("aborted cleanup deleted a file record")
+	}
+
+	// With time to spare the same calls succeed
+	if _, err := albums.ListItemsWithFiles(context.Background(), album.ID, "", "", 0); err != nil {
+		t.Errorf("ListItemsWithFiles: %v", err)
+	}
+	if _, err := validator.CleanupAllInvalidFiles(context.Background()); err != nil {
+		t.Errorf("CleanupAllInvalidFiles: %v", err)
+	}
+	db.QueryRow("SELECT COUNT(*) FROM files WHERE id = ?", missing).Scan(&count)
+	if count != 0 {
+		t.Error("cleanup kept the record of a missing file")
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import "strconv"
+
+// system_settings keys applied to accounts created through registration
+const (
+	defaultUserRoleKey            = "default_user_role"
+	defaultPermissionGroupIDKey   = "default_permission_group_id"
+	defaultRegistrationPermission = "read"
+)
+
+// IsRegistrableRole reports whether role may be given to self-registered users. Never
+// admin: with open registration every anonymous visitor would become one.
+func IsRegistrableRole(role string) bool {
+	return role == "user" || role == GuestRole
+}
+
+// GetDefaultUserRole returns the role given to self-registered users ("user" unless configured)
+func (s *SettingsService) GetDefaultUserRole() (string, error) {
+	setting, err := s.GetSetting(defaultUserRoleKey)
+	if err != nil {
+		return "user", err
+	}
+	if setting == nil || !IsRegistrableRole(setting.Value) {
+		return "user", nil
+	}
+	return setting.Value, nil
+}
+
+// GetDefaultPermissionGroupID returns the permission group new users join on
+// registration, or 0 when none is configured
+func (s *SettingsService) GetDefaultPermissionGroupID() (int64, error) {
+	setting, err := s.GetSetting(defaultPermissionGroupIDKey)
+	if err != nil {
+		return 0, err
+	}
+	if setting == nil {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(setting.Value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, nil
+	}
+	return id, nil
+}
+
+// JoinDefaultPermissionGroup grants a newly registered user read access to the
+// configured default permission group. Returns the group ID, or 0 if none is set.
+func (s *SettingsService) JoinDefaultPermissionGroup(userID int64) (int64, error) {
+	groupID, err := s.GetDefaultPermissionGroupID()
+	if err != nil || groupID == 0 {
+		return 0, err
+	}
+	var exists bool
+	if err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM permission_groups WHERE id = ?)", groupID).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, ErrPermissionGroupNotFound
+	}
+	_, err = s.db.Exec(`
+		INSERT OR IGNORE INTO permission_group_permissions (permission_group_id, user_id, permission)
+		VALUES (?, ?, ?)
+	`, groupID, userID, defaultRegistrationPermission)
+	if err != nil {
+		return 0, err
+	}
+	return groupID, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"strconv"
+	"testing"
+)
+
+// groupPermission returns a user's permission in a permission group, or "" without one
+func groupPermission(t *testing.T, svc *AuthService, groupID, userID int64) string {
+	t.Helper()
+	var permission string
+	svc.db.QueryRow("SELECT permission FROM permission_group_permissions WHERE permission_group_id = ? AND user_id = ?",
+		groupID, userID).Scan(&permission)
+	return permission
+}
+
+func TestGetDefaultUserRole(t *testing.T) {
+	db := newTestDB(t)
+	settings := NewSettingsService(db)
+	for value, want := range map[string]string{
+		"":             "user",
+		"user":         "user",
+		GuestRole:      GuestRole,
+		"admin":        "user", // Never handed out to anonymous visitors
+		"server_owner": "user",
+		"bogus":        "user",
+	} {
+		if value != "" {
+			settings.SetSetting("default_user_role", value)
+		}
+		if got, err := settings.GetDefaultUserRole(); err != nil || got != want {
+			t.Errorf("default_user_role %q: got %q, %v, want %q", value, got, err, want)
+		}
+	}
+}
+
+func TestCreateUserRegistrationDefaults(t *testing.T) {
+	db := newTestDB(t)
+	settings := NewSettingsService(db)
+	auth := NewAuthService(db)
+	auth.SetRegistrationDefaults(settings)
+
+	admin := seedUser(t, db, "admin", "admin", "", false)
+	group := lastID(t, mustExec(t, db, "INSERT INTO permission_groups (name, created_by) VALUES ('public', ?)", admin))
+	settings.SetSetting("default_user_role", GuestRole)
+	settings.SetSetting("default_permission_group_id", strconv.FormatInt(group, 10))
+	// Guests only read the guest permission group, here the same one
+	settings.SetSetting("guest_permission_group_id", strconv.FormatInt(group, 10))
+
+	newcomer, err := auth.CreateUser("newcomer", "secret123", "", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if newcomer.Role != GuestRole {
+		t.Errorf("role %q, want the default %q", newcomer.Role, GuestRole)
+	}
+	if got := groupPermission(t, auth, group, newcomer.ID); got != "read" {
+		t.Errorf("default group permission %q, want read", got)
+	}
+
+	// An explicit role skips the defaults
+	member, err := auth.CreateUser("member", "secret123", "", "user")
+	if err != nil {
+		t.Fatalf("create user with role: %v", err)
+	}
+	if member.Role != "user" || groupPermission(t, auth, group, member.ID) != "" {
+		t.Errorf("explicit role: role %q, group permission %q", member.Role, groupPermission(t, auth, group, member.ID))
+	}
+
+	// A deleted default group doesn't block registration
+	mustExec(t, db, "DELETE FROM permission_groups WHERE id = ?", group)
+	if _, err := auth.CreateUser("late", "secret123", "", ""); err != nil {
+		t.Errorf("create user with a missing default group: %v", err)
+	}
+	if _, err := settings.JoinDefaultPermissionGroup(member.ID); err != ErrPermissionGroupNotFound {
+		t.Errorf("join missing group: %v, want ErrPermissionGroupNotFound", err)
+	}
+}
+
+func TestCreateUserWithoutDefaults(t *testing.T) {
+	db := newTestDB(t)
+	auth := NewAuthService(db)
+	user, err := auth.CreateUser("plain", "secret123", "", "")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if user.Role != "user" {
+		t.Errorf("role %q, want user", user.Role)
+	}
+	if id, err := NewSettingsService(db).JoinDefaultPermissionGroup(user.ID); id != 0 || err != nil {
+		t.Errorf("join without a configured group = %d, %v", id, err)
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"awesome-sharing/internal/database"
+)
+
+// collectEvents listens on d until the returned function is called, which returns
+// everything received; events are read as they come so the listener never overflows
+func collectEvents(d *EventDispatcher) func() []Event {
+	events, stop := d.Listen()
+	done := make(chan []Event)
+	go func() {
+		var got []Event
+		for event := range events {
+			got = append(got, event)
+		}
+		done <- got
+	}()
+	return func() []Event {
+		stop()
+		return <-done
+	}
+}
+
+func TestScanEmitsProgressEvents(t *testing.T) {
+	db := newTestDB(t)
+	d := NewEventDispatcher(db)
+	folderService := NewFolderService(db)
+	scanner := NewFileScanner(&database.DB{DB: db}, folderService, t.TempDir(), d)
+
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	root := t.TempDir()
+	folderID := seedFolder(t, db, root, owner)
+	for i := 0; i < scanProgressInterval+1; i++ {
+		writeTestImage(t, filepath.Join(root, fmt.Sprintf("%03d.png", i)), 2, 2)
+	}
+
+	// Index everything first; a rescan still counts already indexed files but
+	// emits no file.indexed events, so the listener only sees the scan events
+	if err := scanner.ScanFolder(folderID); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	collect := collectEvents(d)
+	if err := scanner.ScanFolder(folderID); err != nil {
+		t.Fatalf("rescan: %v", err)
+	}
+
+	var types []string
+	var progress, completed map[string]interface{}
+	for _, event := range collect() {
+		types = append(types, event.Type)
+		data := event.Data.(map[string]interface{})
+		if data["folder_id"] != folderID {
+			t.Errorf("%s event for folder %v, want %d", event.Type, data["folder_id"], folderID)
+		}
+		switch event.Type {
+		case EventScanProgress:
+			progress = data
+		case EventScanCompleted:
+			completed = data
+		}
+	}
+
+	want := []string{EventScanStarted, EventScanProgress, EventScanCompleted}
+	if fmt.Sprint(types) != fmt.Sprint(want) {
+		t.Fatalf("events %v, want %v", types, want)
+	}
+	if progress["files_processed"] != scanProgressInterval {
+		t.Errorf("progress files_processed = %v, want %d", progress["files_processed"], scanProgressInterval)
+	}
+	if completed["files_processed"] != scanProgressInterval+1 || completed["error"] != nil {
+		t.Errorf("completed = %v", completed)
+	}
+}
+
+func TestScanCompletedReportsError(t *testing.T) {
+	db := newTestDB(t)
+	d := NewEventDispatcher(db)
+	folderService := NewFolderService(db)
+	scanner := NewFileScanner(&database.DB{DB: db}, folderService, t.TempDir(), d)
+
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	folderID := seedFolder(t, db, filepath.Join(t.TempDir(), "missing"), owner)
+
+	collect := collectEvents(d)
+	if err := scanner.ScanFolder(folderID); err == nil {
+		t.Fatal("expected scanning a missing directory to fail")
+	}
+
+	got := collect()
+	if len(got) != 2 || got[0].Type != EventScanStarted || got[1].Type != EventScanCompleted {
+		t.Fatalf("events = %+v, want scan.started and scan.completed", got)
+	}
+	if got[1].Data.(map[string]interface{})["error"] == nil {
+		t.Error("scan.completed carries no error")
+	}
+}
+
+func TestJobQueueEmitsUpdates(t *testing.T) {
+	db := newTestDB(t)
+	d := NewEventDispatcher(db)
+	q := NewJobQueue(db, d)
+	q.Start(1)
+	owner := seedUser(t, db, "owner", "admin", "", false)
+
+	events, stop := d.Listen()
+	defer stop()
+
+	job, err := q.Enqueue("test", owner, func(ctx context.Context, progress func(int)) (interface{}, error) {
+		progress(50)
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	var statuses []string
+	for len(statuses) == 0 || !strings.HasPrefix(statuses[len(statuses)-1], JobStatusDone) {
+		select {
+		case event := <-events:
+			if event.Type != EventJobUpdated {
+				continue
+			}
+			data := event.Data.(map[string]interface{})
+			if data["job_id"] != job.ID || data["created_by"] != owner || data["type"] != "test" {
+				t.Errorf("job event data = %v", data)
+			}
+			statuses = append(statuses, fmt.Sprintf("%v:%v", data["status"], data["progress"]))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("job updates %v, never finished", statuses)
+		}
+	}
+	want := []string{JobStatusRunning + ":0", JobStatusRunning + ":50", JobStatusDone + ":100"}
+	if fmt.Sprint(statuses) != fmt.Sprint(want) {
+		t.Errorf("job updates %v, want %v", statuses, want)
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"awesome-sharing/internal/database"
+)
+
+// thumbnailSizesIn returns the sizes of all thumbnails generated under dir
+func thumbnailSizesIn(t *testing.T, dir string) []string {
+	t.Helper()
+	var sizes []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name := strings.TrimSuffix(info.Name(), filepath.Ext(info.Name()))
+		sizes = append(sizes, name[strings.LastIndex(name, "_")+1:])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(sizes)
+	return sizes
+}
+
+func TestScanThumbnailPregeneration(t *testing.T) {
+	cases := []struct {
+		name     string
+		global   string
+		override string
+		want     []string
+	}{
+		{"disabled by default", "", "", nil},
+		{"global size", "medium", "", []string{"medium"}},
+		{"folder override", "small", "large", []string{"large"}},
+		{"folder opts out", "medium", ScanThumbnailSizeNone, nil},
+		{"invalid global is ignored", "huge", "", nil},
+	}
+	for _, tc := range cases {
+		db := newTestDB(t)
+		folderService := NewFolderService(db)
+		settings := NewSettingsService(db)
+		thumbsDir := t.TempDir()
+		scanner := NewFileScanner(&database.DB{DB: db}, folderService, thumbsDir, nil)
+		scanner.SetThumbnailPregeneration(NewThumbnailService(thumbsDir), settings)
+
+		owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+		root := t.TempDir()
+		folderID := seedFolder(t, db, root, owner)
+		writeTestImage(t, filepath.Join(root, "a.png"), 400, 300)
+
+		if tc.global != "" {
+			if err := settings.SetSetting("scan_thumbnail_size", tc.global); err != nil {
+				t.Fatalf("%s: set setting: %v", tc.name, err)
+			}
+		}
+		if err := folderService.SetScanThumbnailSize(folderID, tc.override); err != nil {
+			t.Fatalf("%s: set override: %v", tc.name, err)
+		}
+
+		if err := scanner.ScanFolder(folderID); err != nil {
+			t.Fatalf("%s: scan: %v", tc.name, err)
+		}
+		if got := thumbnailSizesIn(t, thumbsDir); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: generated %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSetScanThumbnailSize(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewFolderService(db)
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	folderID := seedFolder(t, db, "/photos", owner)
+
+	for _, size := range []string{"medium", ScanThumbnailSizeNone, ""} {
+		if err := svc.SetScanThumbnailSize(folderID, size); err != nil {
+			t.Fatalf("set %q: %v", size, err)
+		}
+		folder, err := svc.GetFolder(folderID)
+		if err != nil {
+			t.Fatalf("get folder: %v", err)
+		}
+		if folder.ScanThumbnailSize != size {
+			t.Errorf("got %q, want %q", folder.ScanThumbnailSize, size)
+		}
+	}
+	if err := svc.SetScanThumbnailSize(folderID, "huge"); err != ErrInvalidThumbnailSize {
+		t.Errorf("invalid size: got %v, want ErrInvalidThumbnailSize", err)
+	}
+}
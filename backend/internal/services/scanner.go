@@ -3,6 +3,8 @@ package services
 import (
 	"awesome-sharing/internal/database"
 	"awesome-sharing/pkg/exif"
+	"context"
+	"errors"
 	"log"
 	"os"
 	"path/filepath"
@@ -14,16 +16,44 @@ type FileScanner struct {
 	db            *database.DB
 	folderService *FolderService
 	thumbsDir     string
+	events        *EventDispatcher
+
+	perceptualHash  bool  // Compute a perceptual hash per image for similar-photo grouping
+	maxDecodePixels int64 // Images above this pixel count are not decoded for hashing (0 = unlimited)
+
+	// Optional scan-time thumbnail pre-generation; settings also gate keyword import and hidden files
+	thumbService *ThumbnailService
+	settings     *SettingsService
 }
 
-func NewFileScanner(db *database.DB, folderService *FolderService, thumbsDir string) *FileScanner {
+func NewFileScanner(db *database.DB, folderService *FolderService, thumbsDir string, events *EventDispatcher) *FileScanner {
 	return &FileScanner{
 		db:            db,
 		folderService: folderService,
 		thumbsDir:     thumbsDir,
+		events:        events,
+
+		perceptualHash: true,
 	}
 }
 
+// SetPerceptualHashing enables or disables perceptual hash computation for newly scanned images
+func (fs *FileScanner) SetPerceptualHashing(enabled bool) {
+	fs.perceptualHash = enabled
+}
+
+// SetMaxDecodePixels limits the pixel count of images decoded for perceptual hashing (0 = unlimited)
+func (fs *FileScanner) SetMaxDecodePixels(pixels int64) {
+	fs.maxDecodePixels = pixels
+}
+
+// SetThumbnailPregeneration lets the scanner pre-generate thumbnails for newly indexed images.
+// The size comes from the folder's override or the scan_thumbnail_size setting.
+func (fs *FileScanner) SetThumbnailPregeneration(thumbService *ThumbnailService, settings *SettingsService) {
+	fs.thumbService = thumbService
+	fs.settings = settings
+}
+
 // ScanFolder scans a specific folder
 func (fs *FileScanner) ScanFolder(folderID int64) error {
 	// Get folder information
@@ -34,7 +64,7 @@ func (fs *FileScanner) ScanFolder(folderID int64) error {
 
 	log.Printf("Starting scan of folder: %s (%s)", folder.Name, folder.AbsolutePath)
 
-	if err := fs.scanDirectory(folder.ID, folder.AbsolutePath, folder.AbsolutePath); err != nil {
+	if err := fs.scanRoot(folder.ID, folder.AbsolutePath); err != nil {
 		return err
 	}
 
@@ -42,6 +72,69 @@ func (fs *FileScanner) ScanFolder(folderID int64) error {
 	return nil
 }
 
+// scanProgressInterval is the number of media files between scan.progress events
+const scanProgressInterval = 100
+
+// scanState tracks a single folder scan across the directory recursion
+type scanState struct {
+	folderID    int64
+	rootPath    string
+	indexHidden bool
+	mediaTypes  string // '' = images and videos, 'image' or 'video'
+	processed   int    // Media files seen so far
+}
+
+// scanRoot scans a folder's root directory, emitting scan start, progress and completion events
+func (fs *FileScanner) scanRoot(folderID int64, rootPath string) error {
+	state := &scanState{
+		folderID:    folderID,
+		rootPath:    rootPath,
+		indexHidden: fs.indexHidden(folderID),
+		mediaTypes:  fs.mediaTypes(folderID),
+	}
+
+	fs.events.Emit(EventScanStarted, map[string]interface{}{"folder_id": folderID})
+	err := fs.scanDirectory(state, rootPath)
+
+	completed := map[string]interface{}{
+		"folder_id":       folderID,
+		"files_processed": state.processed,
+	}
+	if err != nil {
+		completed["error"] = err.Error()
+	}
+	fs.events.Emit(EventScanCompleted, completed)
+	return err
+}
+
+// RunScheduledScans scans all folders after initialDelay (unless scanOnStartup is false),
+// then every interval until ctx is cancelled
+func (fs *FileScanner) RunScheduledScans(ctx context.Context, scanOnStartup bool, initialDelay, interval time.Duration) {
+	if scanOnStartup {
+		// Wait before first scan to avoid conflicts
+		select {
+		case <-time.After(initialDelay):
+		case <-ctx.Done():
+			return
+		}
+		log.Println("Starting initial folder scan...")
+		fs.ScanAllFolders()
+		log.Println("✓ Initial scan complete")
+	}
+
+	// Now start periodic scanning
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			fs.ScanAllFolders()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // ScanAllFolders scans all enabled folders
 func (fs *FileScanner) ScanAllFolders() {
 	log.Println("Starting scan of all folders...")
@@ -64,7 +157,7 @@ func (fs *FileScanner) ScanAllFolders() {
 		}
 
 		log.Printf("Scanning folder: %s (%s)", name, absolutePath)
-		if err := fs.scanDirectory(folderID, absolutePath, absolutePath); err != nil {
+		if err := fs.scanRoot(folderID, absolutePath); err != nil {
 			log.Printf("Error scanning folder %s: %v", name, err)
 		}
 		foldersScanned++
@@ -73,8 +166,36 @@ func (fs *FileScanner) ScanAllFolders() {
 	log.Printf("Scan completed. %d folders scanned.", foldersScanned)
 }
 
+// indexHidden resolves whether dot-prefixed entries are indexed for a folder
+// (the folder's override, else the index_hidden setting; off by default)
+func (fs *FileScanner) indexHidden(folderID int64) bool {
+	value, err := fs.folderService.GetIndexHidden(folderID)
+	if err != nil {
+		log.Printf("Warning: Failed to read index_hidden for folder %d: %v", folderID, err)
+		return false
+	}
+	if value != "" {
+		return value == "true"
+	}
+	if fs.settings == nil {
+		return false
+	}
+	enabled, err := fs.settings.IsHiddenIndexingEnabled()
+	return err == nil && enabled
+}
+
+// mediaTypes returns the folder's media_types restriction (empty = images and videos)
+func (fs *FileScanner) mediaTypes(folderID int64) string {
+	value, err := fs.folderService.GetMediaTypes(folderID)
+	if err != nil {
+		log.Printf("Warning: Failed to read media_types for folder %d: %v", folderID, err)
+		return ""
+	}
+	return value
+}
+
 // scanDirectory recursively scans a directory
-func (fs *FileScanner) scanDirectory(folderID int64, rootPath, currentPath string) error {
+func (fs *FileScanner) scanDirectory(state *scanState, currentPath string) error {
 	entries, err := os.ReadDir(currentPath)
 	if err != nil {
 		return err
@@ -82,57 +203,86 @@ func (fs *FileScanner) scanDirectory(folderID int64, rootPath, currentPath strin
 
 	for _, entry := range entries {
 		fullPath := filepath.Join(currentPath, entry.Name())
-
-		// Skip hidden files and directories
-		if strings.HasPrefix(entry.Name(), ".") {
+		if fs.skipEntry(entry.Name(), fullPath, state.indexHidden) {
 			continue
 		}
 
-		// Skip thumbnails directory
-		if fs.thumbsDir != "" {
-			absThumbsDir, _ := filepath.Abs(fs.thumbsDir)
-			absFullPath, _ := filepath.Abs(fullPath)
-			if strings.HasPrefix(absFullPath, absThumbsDir) {
-				continue
-			}
-		}
-
 		if entry.IsDir() {
 			// Recursively scan subdirectories
-			if err := fs.scanDirectory(folderID, rootPath, fullPath); err != nil {
+			if err := fs.scanDirectory(state, fullPath); err != nil {
 				log.Printf("Error scanning directory %s: %v", fullPath, err)
 			}
 			continue
 		}
 
 		// Process file
-		if fs.isMediaFile(entry.Name()) {
-			if err := fs.indexFile(folderID, rootPath, fullPath); err != nil {
+		if fs.isMediaFileOf(entry.Name(), state.mediaTypes) {
+			if err := fs.indexFile(state.folderID, state.rootPath, fullPath); err != nil {
 				log.Printf("Error indexing file %s: %v", fullPath, err)
 			}
+
+			state.processed++
+			if state.processed%scanProgressInterval == 0 {
+				fs.events.Emit(EventScanProgress, map[string]interface{}{
+					"folder_id":       state.folderID,
+					"files_processed": state.processed,
+				})
+			}
 		}
 	}
 
 	return nil
 }
 
+// skipEntry reports whether a directory entry is left out of scans
+func (fs *FileScanner) skipEntry(name, fullPath string, indexHidden bool) bool {
+	// Skip hidden files and directories unless enabled for the folder;
+	// macOS "._" resource forks are never media
+	if strings.HasPrefix(name, ".") && (!indexHidden || strings.HasPrefix(name, "._")) {
+		return true
+	}
+
+	// Skip thumbnails directory
+	if fs.thumbsDir != "" {
+		absThumbsDir, _ := filepath.Abs(fs.thumbsDir)
+		absFullPath, _ := filepath.Abs(fullPath)
+		if strings.HasPrefix(absFullPath, absThumbsDir) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // isMediaFile checks if the file is an image or video
 func (fs *FileScanner) isMediaFile(filename string) bool {
+	return mediaFileType(filename) != ""
+}
+
+// isMediaFileOf checks if the file is media of the given folder media_types
+// (empty accepts images and videos)
+func (fs *FileScanner) isMediaFileOf(filename, mediaTypes string) bool {
+	fileType := mediaFileType(filename)
+	return fileType != "" && (mediaTypes == "" || fileType == mediaTypes)
+}
+
+// mediaFileType returns "image" or "video" by extension, or "" for other files
+func mediaFileType(filename string) string {
 	ext := strings.ToLower(filepath.Ext(filename))
 	imageExts := []string{".jpg", ".jpeg", ".png", ".gif", ".bmp", ".webp", ".heic", ".heif", ".tif", ".tiff"}
 	videoExts := []string{".mp4", ".mov", ".avi", ".mkv", ".webm", ".m4v"}
 
 	for _, e := range imageExts {
 		if ext == e {
-			return true
+			return "image"
 		}
 	}
 	for _, e := range videoExts {
 		if ext == e {
-			return true
+			return "video"
 		}
 	}
-	return false
+	return ""
 }
 
 // indexFile adds or updates a file in the database
@@ -171,7 +321,7 @@ func (fs *FileScanner) indexFile(folderID int64, rootPath, filePath string) erro
 	}
 
 	// Insert file into database WITHOUT photo-specific fields
-	result, err := fs.db.Exec(`
+	result, err := database.ExecWithRetry(fs.db, `
 		INSERT INTO files (filename, file_type, size, is_thumbnail, parent_file_id)
 		VALUES (?, ?, ?, 0, NULL)`,
 		filepath.Base(filePath), fileType, info.Size())
@@ -191,6 +341,8 @@ func (fs *FileScanner) indexFile(folderID int64, rootPath, filePath string) erro
 			log.Printf("Warning: Failed to save photo metadata for file %d: %v", fileID, err)
 			// Don't fail indexing if EXIF extraction fails
 		}
+		fs.importKeywords(fileID, filePath)
+		fs.pregenerateThumbnail(folderID, fileID, filePath)
 	}
 
 	// Create file-folder mapping
@@ -200,9 +352,92 @@ func (fs *FileScanner) indexFile(folderID int64, rootPath, filePath string) erro
 	}
 
 	log.Printf("Indexed: %s (folder ID: %d)", filePath, folderID)
+	fs.events.Emit(EventFileIndexed, map[string]interface{}{
+		"file_id":       fileID,
+		"folder_id":     folderID,
+		"filename":      filepath.Base(filePath),
+		"relative_path": relativePath,
+		"file_type":     fileType,
+		"size":          info.Size(),
+	})
 	return nil
 }
 
+// scanThumbnailSize resolves the thumbnail size to pre-generate for a folder ("" = none)
+func (fs *FileScanner) scanThumbnailSize(folderID int64) (string, error) {
+	size, err := fs.folderService.GetScanThumbnailSize(folderID)
+	if err != nil {
+		return "", err
+	}
+	if size == ScanThumbnailSizeNone {
+		return "", nil
+	}
+	if size != "" {
+		return size, nil
+	}
+	return fs.settings.GetScanThumbnailSize()
+}
+
+// pregenerateThumbnail generates the scan-time thumbnail for a newly indexed image, if enabled
+func (fs *FileScanner) pregenerateThumbnail(folderID, fileID int64, filePath string) {
+	if fs.thumbService == nil || fs.settings == nil {
+		return
+	}
+
+	size, err := fs.scanThumbnailSize(folderID)
+	if err != nil {
+		log.Printf("Warning: Failed to resolve scan thumbnail size for folder %d: %v", folderID, err)
+		return
+	}
+	if size == "" {
+		return
+	}
+
+	mode, err := fs.settings.GetThumbnailMode()
+	if err != nil {
+		mode = ThumbnailModeFit
+	}
+	if _, err := fs.thumbService.GetThumbnail(filePath, fileID, size, mode); err != nil && !errors.Is(err, ErrHEICUnsupported) {
+		log.Printf("Warning: Failed to pre-generate %s thumbnail for file %d: %v", size, fileID, err)
+	}
+}
+
+// importKeywords attaches the image's embedded keywords as tags when the import_keywords setting is on
+func (fs *FileScanner) importKeywords(fileID int64, filePath string) {
+	if fs.settings == nil {
+		return
+	}
+	enabled, err := fs.settings.IsKeywordImportEnabled()
+	if err != nil || !enabled {
+		return
+	}
+
+	keywords, err := exif.ExtractKeywords(filePath)
+	if err != nil {
+		log.Printf("Warning: Failed to read keywords for file %d: %v", fileID, err)
+		return
+	}
+
+	// Keywords reuse existing tags regardless of case
+	for _, keyword := range keywords {
+		_, err := database.ExecWithRetry(fs.db, `
+			INSERT INTO tags (name)
+			SELECT ? WHERE NOT EXISTS (SELECT 1 FROM tags WHERE name = ? COLLATE NOCASE)
+		`, keyword, keyword)
+		if err != nil {
+			log.Printf("Warning: Failed to create tag %q: %v", keyword, err)
+			continue
+		}
+		_, err = database.ExecWithRetry(fs.db, `
+			INSERT OR IGNORE INTO file_tags (file_id, tag_id)
+			SELECT ?, id FROM tags WHERE name = ? COLLATE NOCASE LIMIT 1
+		`, fileID, keyword)
+		if err != nil {
+			log.Printf("Warning: Failed to tag file %d with %q: %v", fileID, keyword, err)
+		}
+	}
+}
+
 // fixMissingDimensions checks if a file has missing width/height and attempts to fix it
 func (fs *FileScanner) fixMissingDimensions(fileID int64, filePath string) error {
 	// Check if this is an image file
@@ -255,7 +490,7 @@ func (fs *FileScanner) fixMissingDimensions(fileID int64, filePath string) error
 
 	// Update the database
 	if newWidth > 0 && newHeight > 0 {
-		_, err = fs.db.Exec(`
+		_, err = database.ExecWithRetry(fs.db, `
 			UPDATE photo_metadata SET width = ?, height = ? WHERE file_id = ?
 		`, newWidth, newHeight, fileID)
 		if err != nil {
@@ -273,6 +508,17 @@ func (fs *FileScanner) savePhotoMetadata(fileID int64, filePath string, modTime
 	takenAt := modTime
 	width, height := 0, 0
 
+	// Perceptual hash is optional, a failure just leaves the file out of similar-photo results
+	var phash *int64
+	if fs.perceptualHash {
+		if hash, err := ComputeDHash(filePath, fs.maxDecodePixels); err == nil {
+			value := int64(hash)
+			phash = &value
+		} else {
+			log.Printf("Perceptual hash failed for %s: %v", filepath.Base(filePath), err)
+		}
+	}
+
 	// Try to extract EXIF
 	exifData, err := exif.ExtractEXIF(filePath)
 	if err == nil {
@@ -295,18 +541,23 @@ func (fs *FileScanner) savePhotoMetadata(fileID int64, filePath string, modTime
 			log.Printf("EXIF dimensions found: %dx%d for %s", width, height, filepath.Base(filePath))
 		}
 
+		screenshot := isScreenshot(filePath, exifData.Make, exifData.Model,
+			exifData.Latitude, exifData.Longitude, width, height)
+
 		// Insert with all EXIF fields
-		_, err = fs.db.Exec(`
+		_, err = database.ExecWithRetry(fs.db, `
 			INSERT INTO photo_metadata (
 				file_id, width, height, taken_at,
 				make, model, latitude, longitude, altitude,
-				iso, aperture, shutter_speed, focal_length, orientation
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				iso, aperture, shutter_speed, focal_length, orientation,
+				is_screenshot, phash
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			fileID, width, height, takenAt,
 			exifData.Make, exifData.Model,
 			exifData.Latitude, exifData.Longitude, exifData.Altitude,
 			exifData.ISO, exifData.Aperture, exifData.ShutterSpeed,
-			exifData.FocalLength, exifData.Orientation)
+			exifData.FocalLength, exifData.Orientation,
+			screenshot, phash)
 
 		return err
 	}
@@ -320,11 +571,12 @@ func (fs *FileScanner) savePhotoMetadata(fileID int64, filePath string, modTime
 		log.Printf("GetDimensions failed for %s: %v", filepath.Base(filePath), err)
 	}
 
-	// Insert minimal metadata
-	_, err = fs.db.Exec(`
-		INSERT INTO photo_metadata (file_id, width, height, taken_at)
-		VALUES (?, ?, ?, ?)`,
-		fileID, width, height, takenAt)
+	// Insert minimal metadata (no EXIF means no camera info or GPS)
+	screenshot := isScreenshot(filePath, "", "", nil, nil, width, height)
+	_, err = database.ExecWithRetry(fs.db, `
+		INSERT INTO photo_metadata (file_id, width, height, taken_at, is_screenshot, phash)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		fileID, width, height, takenAt, screenshot, phash)
 
 	return err
 }
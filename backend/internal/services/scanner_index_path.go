@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	ErrPathNotAbsolute = errors.New("path must be absolute")
+	ErrNoFolderForPath = errors.New("no folder covers this path")
+	ErrNotMediaFile    = errors.New("not a media file indexed by its folder")
+)
+
+// IndexedPath is the result of indexing a single path
+type IndexedPath struct {
+	FileID       int64  `json:"file_id"`
+	FolderID     int64  `json:"folder_id"`
+	RelativePath string `json:"relative_path"`
+	Created      bool   `json:"created"` // false when the file was already indexed
+}
+
+// IndexPath indexes one file by absolute path under the folder that most closely
+// contains it, applying that folder's media type and hidden file rules. Files that are
+// already indexed only get their missing dimensions filled in, as during a scan.
+func (fs *FileScanner) IndexPath(absolutePath string) (*IndexedPath, error) {
+	if !filepath.IsAbs(absolutePath) {
+		return nil, ErrPathNotAbsolute
+	}
+	absolutePath = filepath.Clean(absolutePath)
+
+	info, err := os.Stat(absolutePath)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%w: %s is a directory", ErrNotMediaFile, absolutePath)
+	}
+
+	// The longest containing path wins when folders overlap
+	folders, err := fs.folderService.ListFolders(0, true)
+	if err != nil {
+		return nil, err
+	}
+	var folderID int64
+	var root string
+	for _, folder := range folders {
+		if isPathWithin(folder.AbsolutePath, absolutePath) && len(filepath.Clean(folder.AbsolutePath)) > len(root) {
+			folderID, root = folder.ID, filepath.Clean(folder.AbsolutePath)
+		}
+	}
+	if root == "" {
+		return nil, ErrNoFolderForPath
+	}
+
+	relativePath, err := filepath.Rel(root, absolutePath)
+	if err != nil {
+		return nil, err
+	}
+	if fs.skipEntry(filepath.Base(absolutePath), absolutePath, fs.indexHidden(folderID)) ||
+		!fs.isMediaFileOf(filepath.Base(absolutePath), fs.mediaTypes(folderID)) {
+		return nil, ErrNotMediaFile
+	}
+
+	result := &IndexedPath{FolderID: folderID, RelativePath: relativePath}
+	if fs.mappedFileID(folderID, relativePath) == 0 {
+		result.Created = true
+	}
+	if err := fs.indexFile(folderID, root, absolutePath); err != nil {
+		return nil, err
+	}
+
+	result.FileID = fs.mappedFileID(folderID, relativePath)
+	if result.FileID == 0 {
+		return nil, fmt.Errorf("file was not indexed: %s", absolutePath)
+	}
+	return result, nil
+}
+
+// mappedFileID returns the ID of the file mapped at a folder relative path, or 0
+func (fs *FileScanner) mappedFileID(folderID int64, relativePath string) int64 {
+	var fileID int64
+	fs.db.QueryRow(`
+		SELECT file_id FROM file_folder_mappings WHERE folder_id = ? AND relative_path = ?
+	`, folderID, relativePath).Scan(&fileID)
+	return fileID
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"awesome-sharing/internal/database"
+)
+
+func TestIndexPath(t *testing.T) {
+	db := newTestDB(t)
+	folderService := NewFolderService(db)
+	scanner := NewFileScanner(&database.DB{DB: db}, folderService, t.TempDir(), nil)
+
+	owner := seedUser(t, db, "owner", "server_owner", "", false)
+	base := t.TempDir()
+	root := filepath.Join(base, "photos")
+	nested := filepath.Join(root, "trips")
+	sibling := filepath.Join(base, "photos2")
+	for _, dir := range []string{filepath.Join(root, "2024"), nested, sibling} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rootID := seedFolder(t, db, root, owner)
+	nestedID := seedFolder(t, db, nested, owner)
+
+	writeTestImage(t, filepath.Join(root, "2024", "a.png"), 4, 4)
+	writeTestImage(t, filepath.Join(nested, "b.png"), 4, 4)
+	writeTestImage(t, filepath.Join(root, ".hidden.png"), 4, 4)
+	writeTestImage(t, filepath.Join(sibling, "c.png"), 4, 4)
+	writeDiskFile(t, root, "notes.txt", []byte("not media"))
+
+	got, err := scanner.IndexPath(filepath.Join(root, "2024", "a.png"))
+	if err != nil {
+		t.Fatalf("index a.png: %v", err)
+	}
+	if got.FolderID != rootID || got.RelativePath != filepath.Join("2024", "a.png") || !got.Created || got.FileID == 0 {
+		t.Errorf("a.png indexed as %+v", got)
+	}
+	again, err := scanner.IndexPath(filepath.Join(root, "2024", "..", "2024", "a.png"))
+	if err != nil {
+		t.Fatalf("reindex a.png: %v", err)
+	}
+	if again.Created || again.FileID != got.FileID {
+		t.Errorf("reindexing created a new file: %+v, first %+v", again, got)
+	}
+
+	got, err = scanner.IndexPath(filepath.Join(nested, "b.png"))
+	if err != nil {
+		t.Fatalf("index b.png: %v", err)
+	}
+	if got.FolderID != nestedID || got.RelativePath != "b.png" {
+		t.Errorf("b.png indexed under folder %d as %q, want the nested folder %d", got.FolderID, got.RelativePath, nestedID)
+	}
+
+	cases := []struct {
+		name string
+		path string
+		want error
+	}{
+		{"relative path", "photos/2024/a.png", ErrPathNotAbsolute},
+		{"folder with a shared name prefix", filepath.Join(sibling, "c.png"), ErrNoFolderForPath},
+		{"not media", filepath.Join(root, "notes.txt"), ErrNotMediaFile},
+		{"hidden file", filepath.Join(root, ".hidden.png"), ErrNotMediaFile},
+		{"directory", filepath.Join(root, "2024"), ErrNotMediaFile},
+		{"missing file", filepath.Join(root, "gone.png"), os.ErrNotExist},
+	}
+	for _, tc := range cases {
+		if _, err := scanner.IndexPath(tc.path); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FindUnindexed walks a folder and returns the relative paths of media files that
+// have no file_folder_mappings row yet (dropped in since the last scan), sorted.
+// It applies the same skip rules as a scan.
+func (fs *FileScanner) FindUnindexed(folderID int64) ([]string, error) {
+	folder, err := fs.folderService.GetFolder(folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := fs.db.Query("SELECT relative_path FROM file_folder_mappings WHERE folder_id = ?", folderID)
+	if err != nil {
+		return nil, err
+	}
+	indexed := make(map[string]bool)
+	for rows.Next() {
+		var relativePath string
+		if err := rows.Scan(&relativePath); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		indexed[relativePath] = true
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	indexHidden := fs.indexHidden(folderID)
+	mediaTypes := fs.mediaTypes(folderID)
+	unindexed := []string{}
+	err = filepath.WalkDir(folder.AbsolutePath, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			if path == folder.AbsolutePath {
+				return err
+			}
+			log.Printf("Error reading %s: %v", path, err)
+			return nil
+		}
+		if path == folder.AbsolutePath {
+			return nil
+		}
+
+		if fs.skipEntry(entry.Name(), path, indexHidden) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !fs.isMediaFileOf(entry.Name(), mediaTypes) {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(folder.AbsolutePath, path)
+		if err != nil {
+			return err
+		}
+		if !indexed[relativePath] {
+			unindexed = append(unindexed, relativePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(unindexed)
+	return unindexed, nil
+}
+
+// IndexUnindexed indexes only the files FindUnindexed reports, without rescanning
+// the rest of the folder. Returns the number indexed and the errors per relative path.
+func (fs *FileScanner) IndexUnindexed(folderID int64, progress func(percent int)) (int, map[string]string, error) {
+	unindexed, err := fs.FindUnindexed(folderID)
+	if err != nil {
+		return 0, nil, err
+	}
+	folder, err := fs.folderService.GetFolder(folderID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	indexed := 0
+	failed := map[string]string{}
+	for i, relativePath := range unindexed {
+		if err := fs.indexFile(folderID, folder.AbsolutePath, filepath.Join(folder.AbsolutePath, relativePath)); err != nil {
+			log.Printf("Error indexing file %s: %v", relativePath, err)
+			failed[relativePath] = err.Error()
+		} else {
+			indexed++
+		}
+		if progress != nil {
+			progress((i + 1) * 100 / len(unindexed))
+		}
+	}
+
+	return indexed, failed, nil
+}
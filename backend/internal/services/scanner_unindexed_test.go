@@ -0,0 +1,61 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"awesome-sharing/internal/database"
+)
+
+func TestFindAndIndexUnindexed(t *testing.T) {
+	db := newTestDB(t)
+	folderService := NewFolderService(db)
+	scanner := NewFileScanner(&database.DB{DB: db}, folderService, t.TempDir(), nil)
+
+	owner := seedUser(t, db, "owner", "server_owner", "", false)
+	root := t.TempDir()
+	folderID := seedFolder(t, db, root, owner)
+	writeTestImage(t, filepath.Join(root, "a.png"), 4, 4)
+	if err := scanner.ScanFolder(folderID); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	// Dropped in after the scan
+	writeTestImage(t, filepath.Join(root, "b.png"), 4, 4)
+	os.Mkdir(filepath.Join(root, "sub"), 0755)
+	writeTestImage(t, filepath.Join(root, "sub", "c.png"), 4, 4)
+	writeTestImage(t, filepath.Join(root, ".hidden.png"), 4, 4)
+	writeDiskFile(t, root, "notes.txt", []byte("not media"))
+
+	unindexed, err := scanner.FindUnindexed(folderID)
+	if err != nil {
+		t.Fatalf("FindUnindexed: %v", err)
+	}
+	if got, want := fmt.Sprint(unindexed), fmt.Sprint([]string{"b.png", filepath.Join("sub", "c.png")}); got != want {
+		t.Fatalf("unindexed = %s, want %s", got, want)
+	}
+
+	var lastProgress int
+	indexed, failed, err := scanner.IndexUnindexed(folderID, func(percent int) { lastProgress = percent })
+	if err != nil || indexed != 2 || len(failed) != 0 {
+		t.Fatalf("IndexUnindexed = %d, %v, %v; want 2 indexed", indexed, failed, err)
+	}
+	if lastProgress != 100 {
+		t.Errorf("final progress %d, want 100", lastProgress)
+	}
+
+	var mappings int
+	db.QueryRow("SELECT COUNT(*) FROM file_folder_mappings WHERE folder_id = ?", folderID).Scan(&mappings)
+	if mappings != 3 {
+		t.Errorf("%d mappings after indexing, want 3", mappings)
+	}
+	if unindexed, err := scanner.FindUnindexed(folderID); err != nil || len(unindexed) != 0 {
+		t.Errorf("after indexing: %v, %v; want none", unindexed, err)
+	}
+
+	if _, err := scanner.FindUnindexed(folderID + 100); err != ErrFolderNotFound {
+		t.Errorf("unknown folder: %v, want ErrFolderNotFound", err)
+	}
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"awesome-sharing/internal/database"
+)
+
+func TestRunScheduledScansStartupFlag(t *testing.T) {
+	for _, scanOnStartup := range []bool{true, false} {
+		db := newTestDB(t)
+		scanner := NewFileScanner(&database.DB{DB: db}, NewFolderService(db), t.TempDir(), nil)
+
+		owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+		root := t.TempDir()
+		seedFolder(t, db, root, owner)
+		writeTestImage(t, filepath.Join(root, "a.png"), 8, 8)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			// The periodic interval is far beyond the test, so only the startup scan can run
+			scanner.RunScheduledScans(ctx, scanOnStartup, 10*time.Millisecond, time.Hour)
+			close(done)
+		}()
+
+		// Poll until the file is indexed; without a startup scan, give it many times the delay
+		wait := 2 * time.Second
+		if !scanOnStartup {
+			wait = 200 * time.Millisecond
+		}
+		var count int
+		for deadline := time.Now().Add(wait); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if db.QueryRow("SELECT COUNT(*) FROM files").Scan(&count); count > 0 {
+				break
+			}
+		}
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("RunScheduledScans did not stop after cancel")
+		}
+
+		if scanOnStartup && count != 1 {
+			t.Errorf("startup scan enabled: %d files indexed, want 1", count)
+		}
+		if !scanOnStartup && count != 0 {
+			t.Errorf("startup scan disabled: %d files indexed, want 0", count)
+		}
+	}
+}
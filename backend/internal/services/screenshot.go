@@ -0,0 +1,55 @@
+package services
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// screenResolutions lists common display resolutions (landscape) used to recognise screenshots
+var screenResolutions = map[[2]int]bool{
+	// Desktop / laptop
+	{1280, 720}: true, {1280, 800}: true, {1366, 768}: true, {1440, 900}: true,
+	{1536, 864}: true, {1600, 900}: true, {1680, 1050}: true, {1920, 1080}: true,
+	{1920, 1200}: true, {2560, 1080}: true, {2560, 1440}: true, {2560, 1600}: true,
+	{2880, 1800}: true, {3024, 1964}: true, {3440, 1440}: true, {3456, 2234}: true,
+	{3840, 2160}: true, {5120, 2880}: true,
+	// Phones
+	{1334, 750}: true, {1792, 828}: true, {2208, 1242}: true, {2340, 1080}: true,
+	{2400, 1080}: true, {2436, 1125}: true, {2532, 1170}: true, {2556, 1179}: true,
+	{2622, 1206}: true, {2688, 1242}: true, {2778, 1284}: true, {2796, 1290}: true,
+	{2868, 1320}: true, {3120, 1440}: true, {3200, 1440}: true,
+	// Tablets
+	{2048, 1536}: true, {2160, 1620}: true, {2360, 1640}: true, {2388, 1668}: true,
+	{2732, 2048}: true,
+}
+
+// screenshotPrefixes are filename prefixes used by common OS screenshot tools
+var screenshotPrefixes = []string{"screenshot", "screen shot", "screen_shot", "截屏", "屏幕截图"}
+
+// isScreenshot reports whether a photo looks like a screenshot rather than a camera photo.
+// A filename matching a screenshot tool's naming is enough; otherwise the photo must have
+// no camera make/model, no GPS position, and dimensions matching a common screen resolution.
+func isScreenshot(filename, make, model string, latitude, longitude *float64, width, height int) bool {
+	name := strings.ToLower(filepath.Base(filename))
+	for _, prefix := range screenshotPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	if strings.TrimSpace(make) != "" || strings.TrimSpace(model) != "" {
+		return false
+	}
+	if latitude != nil || longitude != nil {
+		return false
+	}
+	if width <= 0 || height <= 0 {
+		return false
+	}
+
+	// Normalise to landscape so portrait screenshots match too
+	if height > width {
+		width, height = height, width
+	}
+	return screenResolutions[[2]int{width, height}]
+}
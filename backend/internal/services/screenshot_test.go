@@ -0,0 +1,64 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"awesome-sharing/internal/database"
+)
+
+func TestIsScreenshot(t *testing.T) {
+	lat, lon := 48.85, 2.35
+	cases := []struct {
+		name          string
+		filename      string
+		make, model   string
+		lat, lon      *float64
+		width, height int
+		want          bool
+	}{
+		{"screen resolution without camera info", "IMG_0001.PNG", "", "", nil, nil, 1920, 1080, true},
+		{"portrait phone screenshot", "IMG_0002.PNG", "", "", nil, nil, 1170, 2532, true},
+		{"screenshot filename", "Screenshot 2024-05-01 at 10.00.00.png", "", "", nil, nil, 1000, 700, true},
+		{"macOS screen shot filename", "Screen Shot 2020-01-01.png", "", "", nil, nil, 0, 0, true},
+		{"camera photo", "IMG_0003.JPG", "Canon", "EOS R5", nil, nil, 1920, 1080, false},
+		{"geotagged photo", "IMG_0004.JPG", "", "", &lat, &lon, 1920, 1080, false},
+		{"odd dimensions", "IMG_0005.JPG", "", "", nil, nil, 4032, 3024, false},
+		{"unknown dimensions", "IMG_0006.JPG", "", "", nil, nil, 0, 0, false},
+	}
+	for _, tc := range cases {
+		if got := isScreenshot(tc.filename, tc.make, tc.model, tc.lat, tc.lon, tc.width, tc.height); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestScanFlagsScreenshots(t *testing.T) {
+	db := newTestDB(t)
+	folderSvc := NewFolderService(db)
+	scanner := NewFileScanner(&database.DB{DB: db}, folderSvc, t.TempDir(), nil)
+
+	owner := seedUser(t, db, "owner", "server_owner", "owner@example.com", true)
+	root := t.TempDir()
+	folder := seedFolder(t, db, root, owner)
+	writeTestImage(t, filepath.Join(root, "capture.png"), 1920, 1080)
+	writeTestImage(t, filepath.Join(root, "photo.png"), 1000, 750)
+
+	if err := scanner.ScanFolder(folder); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	for name, want := range map[string]bool{"capture.png": true, "photo.png": false} {
+		var got bool
+		err := db.QueryRow(`
+			SELECT pm.is_screenshot FROM photo_metadata pm
+			JOIN files f ON f.id = pm.file_id WHERE f.filename = ?
+		`, name).Scan(&got)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got != want {
+			t.Errorf("%s: is_screenshot = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -138,3 +138,113 @@ func (s *SettingsService) IsRegistrationAllowed() (bool, error) {
 	}
 	return setting.Value == "true", nil
 }
+
+// GetThumbnailMode retrieves the default thumbnail mode ("fit" or "cover")
+func (s *SettingsService) GetThumbnailMode() (string, error) {
+	setting, err := s.GetSetting("thumbnail_mode")
+	if err != nil {
+		return "", err
+	}
+	if setting == nil || !IsValidThumbnailMode(setting.Value) {
+		return ThumbnailModeFit, nil
+	}
+	return setting.Value, nil
+}
+
+// GetScanThumbnailSize returns the thumbnail size pre-generated while scanning,
+// or an empty string when pre-generation is disabled (the default)
+func (s *SettingsService) GetScanThumbnailSize() (string, error) {
+	setting, err := s.GetSetting("scan_thumbnail_size")
+	if err != nil {
+		return "", err
+	}
+	if setting == nil {
+		return "", nil
+	}
+	if _, ok := ThumbnailSizes[setting.Value]; !ok {
+		return "", nil
+	}
+	return setting.Value, nil
+}
+
+// IsKeywordImportEnabled checks if embedded XMP/IPTC keywords are imported as tags during scans
+func (s *SettingsService) IsKeywordImportEnabled() (bool, error) {
+	setting, err := s.GetSetting("import_keywords")
+	if err != nil {
+		return false, err
+	}
+	if setting == nil {
+		return false, nil
+	}
+	return setting.Value == "true", nil
+}
+
+// IsHiddenIndexingEnabled checks if the scanner indexes dot-prefixed files and directories
+func (s *SettingsService) IsHiddenIndexingEnabled() (bool, error) {
+	setting, err := s.GetSetting("index_hidden")
+	if err != nil {
+		return false, err
+	}
+	if setting == nil {
+		return false, nil
+	}
+	return setting.Value == "true", nil
+}
+
+// IsReadOnly checks if the server is in read-only (maintenance) mode
+func (s *SettingsService) IsReadOnly() (bool, error) {
+	setting, err := s.GetSetting("read_only")
+	if err != nil {
+		return false, err
+	}
+	if setting == nil {
+		return false, nil
+	}
+	return setting.Value == "true", nil
+}
+
+// What file cleanup does with albums it leaves without files
+const (
+	EmptyAlbumsKeep   = "keep"
+	EmptyAlbumsFlag   = "flag"
+	EmptyAlbumsRemove = "remove"
+)
+
+// GetCleanupEmptyAlbums returns the cleanup_empty_albums policy: keep (default), flag or remove
+func (s *SettingsService) GetCleanupEmptyAlbums() (string, error) {
+	setting, err := s.GetSetting("cleanup_empty_albums")
+	if err != nil {
+		return EmptyAlbumsKeep, err
+	}
+	if setting == nil {
+		return EmptyAlbumsKeep, nil
+	}
+	switch setting.Value {
+	case EmptyAlbumsFlag, EmptyAlbumsRemove:
+		return setting.Value, nil
+	}
+	return EmptyAlbumsKeep, nil
+}
+
+// What happens to shares whose file or album was deleted
+const (
+	OrphanedSharesKeep    = "keep"
+	OrphanedSharesDisable = "disable"
+	OrphanedSharesDelete  = "delete"
+)
+
+// GetOrphanedShares returns the orphaned_shares policy: disable (default), delete or keep
+func (s *SettingsService) GetOrphanedShares() (string, error) {
+	setting, err := s.GetSetting("orphaned_shares")
+	if err != nil {
+		return OrphanedSharesDisable, err
+	}
+	if setting == nil {
+		return OrphanedSharesDisable, nil
+	}
+	switch setting.Value {
+	case OrphanedSharesKeep, OrphanedSharesDelete:
+		return setting.Value, nil
+	}
+	return OrphanedSharesDisable, nil
+}
@@ -1,13 +1,21 @@
 package services
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
 	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"golang.org/x/crypto/bcrypt"
 
@@ -15,24 +23,47 @@ import (
 )
 
 var (
-	ErrShareNotFound   = errors.New("share not found")
-	ErrShareExpired    = errors.New("share has expired")
-	ErrShareDisabled   = errors.New("share is disabled")
-	ErrMaxViewsReached = errors.New("maximum views reached")
-	ErrInvalidPassword = errors.New("invalid password")
-	ErrAccessDenied    = errors.New("access denied")
+	ErrShareNotFound    = errors.New("share not found")
+	ErrShareExpired     = errors.New("share has expired")
+	ErrShareDisabled    = errors.New("share is disabled")
+	ErrMaxViewsReached  = errors.New("maximum views reached")
+	ErrInvalidPassword  = errors.New("invalid password")
+	ErrAccessDenied     = errors.New("access denied")
+	ErrInvalidToken     = errors.New("invalid access token")
+	ErrSignatureExpired = errors.New("signed URL has expired")
+	ErrTokenRevoked     = errors.New("access token has been revoked")
+	ErrTokenExpired     = errors.New("access token has expired")
 )
 
+// Length limits for share title and description (in characters)
+const (
+	MaxShareTitleLength       = 200
+	MaxShareDescriptionLength = 2000
+)
+
+// AccessTokenLifetime bounds how long an access token issued for a share visit stays valid
+const AccessTokenLifetime = 24 * time.Hour
+
+// shareTokenSecretKey is the server_secrets key holding the HMAC secret for access tokens
+const shareTokenSecretKey = "share_token_secret"
+
+// tokenSecretSize is the length of the access token secret in bytes
+const tokenSecretSize = 32
+
 type ShareService struct {
-	db *sql.DB
+	db     *sql.DB
+	events *EventDispatcher
+
+	secretMu    sync.RWMutex
+	tokenSecret []byte
 }
 
-func NewShareService(db *sql.DB) *ShareService {
-	return &ShareService{db: db}
+func NewShareService(db *sql.DB, events *EventDispatcher) *ShareService {
+	return &ShareService{db: db, events: events}
 }
 
 // CreateShare creates a new share link
-func (s *ShareService) CreateShare(shareType string, resourceID, ownerID int64, accessType string, password string, requiresAuth bool, expiresAt *time.Time, maxViews *int) (*models.Share, error) {
+func (s *ShareService) CreateShare(shareType string, resourceID, ownerID int64, title, description, accessType string, password string, requiresAuth, stripEXIF bool, expiresAt *time.Time, maxViews *int) (*models.Share, error) {
 	// Generate short share ID
 	shareID := generateShortID(8)
 
@@ -46,27 +77,48 @@ func (s *ShareService) CreateShare(shareType string, resourceID, ownerID int64,
 	}
 
 	_, err := s.db.Exec(`
-		INSERT INTO shares (id, share_type, resource_id, owner_id, access_type, password_hash, requires_auth, expires_at, max_views, enabled)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
-	`, shareID, shareType, resourceID, ownerID, accessType, passwordHash, requiresAuth, expiresAt, maxViews)
+		INSERT INTO shares (id, share_type, resource_id, owner_id, title, description, access_type, password_hash, requires_auth, strip_exif, expires_at, max_views, enabled)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
+	`, shareID, shareType, resourceID, ownerID, SanitizeShareText(title), SanitizeShareText(description), accessType, passwordHash, requiresAuth, stripEXIF, expiresAt, maxViews)
 	if err != nil {
 		return nil, err
 	}
 
+	s.events.Emit(EventShareCreated, map[string]interface{}{
+		"share_id":    shareID,
+		"share_type":  shareType,
+		"resource_id": resourceID,
+		"owner_id":    ownerID,
+		"access_type": accessType,
+	})
+
 	return s.GetShare(shareID)
 }
 
+// SanitizeShareText trims whitespace and strips control characters (except newlines and tabs)
+func SanitizeShareText(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if r != '\n' && r != '\t' && unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+	return strings.TrimSpace(text)
+}
+
 // GetShare retrieves a share by ID
 func (s *ShareService) GetShare(id string) (*models.Share, error) {
 	var share models.Share
 	var passwordHash sql.NullString
+	var allowedEmails string
 
 	err := s.db.QueryRow(`
-		SELECT id, share_type, resource_id, owner_id, access_type, password_hash, requires_auth, expires_at, max_views, view_count, enabled, created_at
+		SELECT id, share_type, resource_id, owner_id, title, description, access_type, password_hash, requires_auth, strip_exif, expires_at, max_views, view_count, enabled, created_at,
+		       allowed_emails
 		FROM shares WHERE id = ?
 	`, id).Scan(&share.ID, &share.ShareType, &share.ResourceID, &share.OwnerID,
-		&share.AccessType, &passwordHash, &share.RequiresAuth, &share.ExpiresAt, &share.MaxViews,
-		&share.ViewCount, &share.Enabled, &share.CreatedAt)
+		&share.Title, &share.Description, &share.AccessType, &passwordHash, &share.RequiresAuth, &share.StripEXIF, &share.ExpiresAt, &share.MaxViews,
+		&share.ViewCount, &share.Enabled, &share.CreatedAt, &allowedEmails)
 
 	if err == sql.ErrNoRows {
 		return nil, ErrShareNotFound
@@ -79,6 +131,7 @@ func (s *ShareService) GetShare(id string) (*models.Share, error) {
 		share.PasswordHash = passwordHash.String
 		share.HasPassword = true
 	}
+	share.AllowedEmails = splitAllowedEmails(allowedEmails)
 
 	return &share, nil
 }
@@ -90,6 +143,15 @@ func (s *ShareService) ValidateShareAccess(shareID, password string, userID *int
 		return nil, err
 	}
 
+	// A deleted file or album is reported as such, even once the share was disabled for it
+	exists, err := s.shareResourceExists(share.ShareType, share.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrShareResourceGone
+	}
+
 	// Check if enabled
 	if !share.Enabled {
 		return nil, ErrShareDisabled
@@ -110,6 +172,22 @@ func (s *ShareService) ValidateShareAccess(shareID, password string, userID *int
 		return nil, ErrAccessDenied
 	}
 
+	// An email allowlist requires a logged-in user with a matching address
+	if len(share.AllowedEmails) > 0 {
+		if userID == nil {
+			return nil, ErrAccessDenied
+		}
+		if *userID != share.OwnerID {
+			allowed, err := s.userEmailAllowed(*userID, share.AllowedEmails)
+			if err != nil {
+				return nil, err
+			}
+			if !allowed {
+				return nil, ErrAccessDenied
+			}
+		}
+	}
+
 	// Check password if set
 	if share.PasswordHash != "" {
 		if password == "" {
@@ -151,13 +229,23 @@ func (s *ShareService) LogAccess(shareID string, userID *int64, ipAddress, userA
 		INSERT INTO share_access_log (share_id, accessed_by, ip_address, user_agent)
 		VALUES (?, ?, ?, ?)
 	`, shareID, userID, ipAddress, userAgent)
-	return err
+	if err != nil {
+		return err
+	}
+
+	s.events.Emit(EventShareAccessed, map[string]interface{}{
+		"share_id":    shareID,
+		"accessed_by": userID,
+		"ip_address":  ipAddress,
+	})
+	return nil
 }
 
 // ListSharesByOwner retrieves all shares created by a user
 func (s *ShareService) ListSharesByOwner(ownerID int64) ([]models.Share, error) {
 	rows, err := s.db.Query(`
-		SELECT id, share_type, resource_id, owner_id, access_type, password_hash, requires_auth, expires_at, max_views, view_count, enabled, created_at
+		SELECT id, share_type, resource_id, owner_id, title, description, access_type, password_hash, requires_auth, strip_exif, expires_at, max_views, view_count, enabled, created_at,
+		       allowed_emails
 		FROM shares WHERE owner_id = ?
 		ORDER BY created_at DESC
 	`, ownerID)
@@ -170,14 +258,16 @@ func (s *ShareService) ListSharesByOwner(ownerID int64) ([]models.Share, error)
 	for rows.Next() {
 		var share models.Share
 		var passwordHash sql.NullString
+		var allowedEmails string
 		if err := rows.Scan(&share.ID, &share.ShareType, &share.ResourceID, &share.OwnerID,
-			&share.AccessType, &passwordHash, &share.RequiresAuth, &share.ExpiresAt, &share.MaxViews, &share.ViewCount,
-			&share.Enabled, &share.CreatedAt); err != nil {
+			&share.Title, &share.Description, &share.AccessType, &passwordHash, &share.RequiresAuth, &share.StripEXIF, &share.ExpiresAt, &share.MaxViews, &share.ViewCount,
+			&share.Enabled, &share.CreatedAt, &allowedEmails); err != nil {
 			return nil, err
 		}
 		if passwordHash.Valid && passwordHash.String != "" {
 			share.HasPassword = true
 		}
+		share.AllowedEmails = splitAllowedEmails(allowedEmails)
 		shares = append(shares, share)
 	}
 
@@ -200,6 +290,20 @@ func (s *ShareService) UpdateShare(id string, updates map[string]interface{}) er
 		}
 	}
 
+	if title, ok := updates["title"]; ok {
+		_, err := s.db.Exec("UPDATE shares SET title = ? WHERE id = ?", SanitizeShareText(title.(string)), id)
+		if err != nil {
+			return err
+		}
+	}
+
+	if description, ok := updates["description"]; ok {
+		_, err := s.db.Exec("UPDATE shares SET description = ? WHERE id = ?", SanitizeShareText(description.(string)), id)
+		if err != nil {
+			return err
+		}
+	}
+
 	if maxViews, ok := updates["max_views"]; ok {
 		_, err := s.db.Exec("UPDATE shares SET max_views = ? WHERE id = ?", maxViews, id)
 		if err != nil {
@@ -214,6 +318,24 @@ func (s *ShareService) UpdateShare(id string, updates map[string]interface{}) er
 		}
 	}
 
+	if stripEXIF, ok := updates["strip_exif"]; ok {
+		_, err := s.db.Exec("UPDATE shares SET strip_exif = ? WHERE id = ?", stripEXIF, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	if allowedEmails, ok := updates["allowed_emails"]; ok {
+		normalized, err := NormalizeAllowedEmails(allowedEmails.([]string))
+		if err != nil {
+			return err
+		}
+		_, err = s.db.Exec("UPDATE shares SET allowed_emails = ? WHERE id = ?", strings.Join(normalized, ","), id)
+		if err != nil {
+			return err
+		}
+	}
+
 	if password, ok := updates["password"]; ok {
 		var passwordHash string
 		if password != nil && password.(string) != "" {
@@ -238,6 +360,60 @@ func (s *ShareService) DeleteShare(id string) error {
 	return err
 }
 
+// RotateShareID moves a share to a new short ID so the old link stops working.
+// Settings, permissions and the access log are carried over; outstanding access
+// tokens for the old ID become invalid. Returns the updated share.
+func (s *ShareService) RotateShareID(id string) (*models.Share, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Copy every column except the ID, so later columns are carried over too
+	rows, err := tx.Query("SELECT name FROM pragma_table_info('shares') WHERE name != 'id'")
+	if err != nil {
+		return nil, err
+	}
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	rows.Close()
+	columnList := strings.Join(columns, ", ")
+
+	newID := generateShortID(8)
+	result, err := tx.Exec("INSERT INTO shares (id, "+columnList+") SELECT ?, "+columnList+" FROM shares WHERE id = ?", newID, id)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrShareNotFound
+	}
+
+	// Re-point children before the old row goes, or the delete would cascade to them
+	if _, err := tx.Exec("UPDATE share_permissions SET share_id = ? WHERE share_id = ?", newID, id); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("UPDATE share_access_log SET share_id = ? WHERE share_id = ?", newID, id); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM shares WHERE id = ?", id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetShare(newID)
+}
+
 // DeleteExpiredShares deletes all expired shares
 func (s *ShareService) DeleteExpiredShares() (int64, error) {
 	result, err := s.db.Exec("DELETE FROM shares WHERE expires_at IS NOT NULL AND expires_at < ?", time.Now())
@@ -341,6 +517,75 @@ func (s *ShareService) GetAccessLog(shareID string, limit int) ([]models.ShareAc
 	return logs, nil
 }
 
+// accessLogExportPageSize is the number of access log rows read per query when exporting
+const accessLogExportPageSize = 500
+
+// ExportAccessLog writes the complete access log of a share to w as CSV, oldest entry first.
+// Rows are read in pages so large logs are never held in memory at once.
+func (s *ShareService) ExportAccessLog(shareID string, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"accessed_at", "ip_address", "user_agent", "accessed_by"}); err != nil {
+		return err
+	}
+
+	type accessLogRow struct {
+		accessedAt time.Time
+		ipAddress  sql.NullString
+		userAgent  sql.NullString
+		username   sql.NullString
+	}
+
+	var lastID int64
+	for {
+		rows, err := s.db.Query(`
+			SELECT sal.id, sal.accessed_at, sal.ip_address, sal.user_agent, u.username
+			FROM share_access_log sal
+			LEFT JOIN users u ON sal.accessed_by = u.id
+			WHERE sal.share_id = ? AND sal.id > ?
+			ORDER BY sal.id ASC
+			LIMIT ?
+		`, shareID, lastID, accessLogExportPageSize)
+		if err != nil {
+			return err
+		}
+
+		var page []accessLogRow
+		for rows.Next() {
+			var row accessLogRow
+			if err := rows.Scan(&lastID, &row.accessedAt, &row.ipAddress, &row.userAgent, &row.username); err != nil {
+				rows.Close()
+				return err
+			}
+			page = append(page, row)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, row := range page {
+			record := []string{
+				row.accessedAt.UTC().Format(time.RFC3339),
+				row.ipAddress.String,
+				row.userAgent.String,
+				row.username.String,
+			}
+			if err := writer.Write(csvCells(record)); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+
+		if len(page) < accessLogExportPageSize {
+			return nil
+		}
+	}
+}
+
 // generateShortID generates a short random ID for shares
 func generateShortID(length int) string {
 	bytes := make([]byte, length)
@@ -354,36 +599,103 @@ func generateShortID(length int) string {
 }
 
 // GenerateAccessToken generates a temporary access token for a share
-// Token format: shareID:timestamp:signature
+// Token format: shareID:resourceID:nonce:signature
 func (s *ShareService) GenerateAccessToken(shareID string) (string, error) {
 	share, err := s.GetShare(shareID)
 	if err != nil {
 		return "", err
 	}
 
-	// Generate a random token
-	tokenBytes := make([]byte, 32)
-	rand.Read(tokenBytes)
-	token := base64.URLEncoding.EncodeToString(tokenBytes)
-	token = strings.TrimRight(token, "=")
+	// Generate a random nonce so every access gets a distinct token
+	nonceBytes := make([]byte, 16)
+	rand.Read(nonceBytes)
+	nonce := strings.TrimRight(base64.URLEncoding.EncodeToString(nonceBytes), "=")
+
+	payload := fmt.Sprintf("%s:%d:%s", shareID, share.ResourceID, nonce)
+	signature, err := s.signToken(payload)
+	if err != nil {
+		return "", err
+	}
+
+	token := payload + ":" + signature
+
+	// Drop this share's expired tokens so the table doesn't grow with every visit
+	if _, err := s.db.Exec("DELETE FROM access_tokens WHERE share_id = ? AND expires_at < ?", shareID, time.Now()); err != nil {
+		return "", err
+	}
+
+	// Persist the token so it can be revoked; only its hash is stored
+	_, err = s.db.Exec(`
+		INSERT INTO access_tokens (token_hash, share_id, resource_id, expires_at)
+		VALUES (?, ?, ?, ?)
+	`, hashAccessToken(token), shareID, share.ResourceID, time.Now().Add(AccessTokenLifetime))
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
 
-	// Token format: shareID:resourceID:token
-	accessToken := fmt.Sprintf("%s:%d:%s", shareID, share.ResourceID, token)
-	return accessToken, nil
+// hashAccessToken returns the hex SHA-256 of a token as stored in access_tokens
+func hashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RevokeAccessTokens revokes every outstanding access token of a share and
+// returns how many were revoked
+func (s *ShareService) RevokeAccessTokens(shareID string) (int64, error) {
+	result, err := s.db.Exec(`
+		UPDATE access_tokens SET revoked = 1
+		WHERE share_id = ? AND revoked = 0 AND expires_at >= ?
+	`, shareID, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 // ValidateAccessToken validates an access token and returns the share and resource ID
 func (s *ShareService) ValidateAccessToken(token string) (string, int64, error) {
-	// Parse token format: shareID:resourceID:token
+	// Parse token format: shareID:resourceID:nonce:signature
 	parts := strings.Split(token, ":")
-	if len(parts) != 3 {
+	if len(parts) != 4 {
 		return "", 0, errors.New("invalid token format")
 	}
 
+	// Verify the signature before touching the database
+	expected, err := s.signToken(strings.Join(parts[:3], ":"))
+	if err != nil {
+		return "", 0, err
+	}
+	if !hmac.Equal([]byte(expected), []byte(parts[3])) {
+		return "", 0, ErrInvalidToken
+	}
+
 	shareID := parts[0]
 	var resourceID int64
 	fmt.Sscanf(parts[1], "%d", &resourceID)
 
+	// Check the issued token record (revocation and lifetime)
+	var revoked bool
+	var expiresAt time.Time
+	err = s.db.QueryRow(`
+		SELECT revoked, expires_at FROM access_tokens
+		WHERE token_hash = ? AND share_id = ?
+	`, hashAccessToken(token), shareID).Scan(&revoked, &expiresAt)
+	if err == sql.ErrNoRows {
+		return "", 0, ErrInvalidToken
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if revoked {
+		return "", 0, ErrTokenRevoked
+	}
+	if time.Now().After(expiresAt) {
+		return "", 0, ErrTokenExpired
+	}
+
 	// Verify the share still exists and is valid
 	share, err := s.GetShare(shareID)
 	if err != nil {
@@ -407,3 +719,140 @@ func (s *ShareService) ValidateAccessToken(token string) (string, int64, error)
 
 	return shareID, resourceID, nil
 }
+
+// thumbnailSignaturePayload is the signed content of a thumbnail URL. The prefix keeps
+// thumbnail signatures from being reused as share access tokens and vice versa.
+func thumbnailSignaturePayload(fileID int64, size string, expiresAt int64) string {
+	return fmt.Sprintf("thumb:%d:%s:%d", fileID, size, expiresAt)
+}
+
+// SignThumbnail returns the signature for a thumbnail URL valid until expiresAt (unix seconds)
+func (s *ShareService) SignThumbnail(fileID int64, size string, expiresAt int64) (string, error) {
+	return s.signToken(thumbnailSignaturePayload(fileID, size, expiresAt))
+}
+
+// VerifyThumbnailSignature checks a signed thumbnail URL's signature and expiry
+func (s *ShareService) VerifyThumbnailSignature(fileID int64, size string, expiresAt int64, signature string) error {
+	expected, err := s.SignThumbnail(fileID, size, expiresAt)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidToken
+	}
+	if time.Now().Unix() > expiresAt {
+		return ErrSignatureExpired
+	}
+	return nil
+}
+
+// RotateTokenSecret replaces the access token secret, invalidating every token issued so far
+func (s *ShareService) RotateTokenSecret() error {
+	s.secretMu.Lock()
+	defer s.secretMu.Unlock()
+	return s.storeNewTokenSecret()
+}
+
+// signToken returns the base64url HMAC-SHA256 signature of a token payload
+func (s *ShareService) signToken(payload string) (string, error) {
+	secret, err := s.getTokenSecret()
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// getTokenSecret loads the token secret from server_secrets, creating one on first use.
+// A stored value that is empty or not a full-length hex secret is replaced, never used.
+func (s *ShareService) getTokenSecret() ([]byte, error) {
+	s.secretMu.RLock()
+	secret := s.tokenSecret
+	s.secretMu.RUnlock()
+	if secret != nil {
+		return secret, nil
+	}
+
+	s.secretMu.Lock()
+	defer s.secretMu.Unlock()
+	if s.tokenSecret != nil {
+		return s.tokenSecret, nil
+	}
+
+	var stored string
+	err := s.db.QueryRow("SELECT value FROM server_secrets WHERE key = ?", shareTokenSecretKey).Scan(&stored)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if err == nil {
+		decoded, decodeErr := hex.DecodeString(stored)
+		if decodeErr == nil && len(decoded) >= tokenSecretSize {
+			s.tokenSecret = decoded
+			return s.tokenSecret, nil
+		}
+		log.Printf("Stored share token secret is invalid, generating a new one (outstanding access tokens stop working)")
+	}
+
+	if err := s.storeNewTokenSecret(); err != nil {
+		return nil, err
+	}
+	return s.tokenSecret, nil
+}
+
+// storeNewTokenSecret generates, stores and caches a new token secret. Callers hold secretMu.
+func (s *ShareService) storeNewTokenSecret() error {
+	secret, err := generateTokenSecret()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO server_secrets (key, value, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`, shareTokenSecretKey, hex.EncodeToString(secret), time.Now())
+	if err != nil {
+		return err
+	}
+
+	s.tokenSecret = secret
+	return nil
+}
+
+// generateTokenSecret creates a new random 32-byte secret
+func generateTokenSecret() ([]byte, error) {
+	secret := make([]byte, tokenSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// ShareIncludesFile reports whether a share currently grants access to a file: the
+// shared file itself, or any file an album share's folder rules match right now, so
+// files added to the album's folders later are included without touching the share
+func (s *ShareService) ShareIncludesFile(shareID string, fileID int64) (bool, error) {
+	share, err := s.GetShare(shareID)
+	if err != nil {
+		return false, err
+	}
+
+	switch share.ShareType {
+	case "file":
+		return share.ResourceID == fileID, nil
+	case "album":
+		var included bool
+		err := s.db.QueryRow(`
+			SELECT EXISTS (
+				SELECT 1 FROM album_folders af
+				INNER JOIN file_folder_mappings ffm ON ffm.folder_id = af.folder_id
+				WHERE af.album_id = ? AND ffm.file_id = ? AND `+PathPrefixMatchSQL+`
+			)
+		`, share.ResourceID, fileID).Scan(&included)
+		return included, err
+	}
+	return false, nil
+}
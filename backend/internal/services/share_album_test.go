@@ -0,0 +1,57 @@
+package services
+
+import "testing"
+
+func TestShareIncludesFile(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewShareService(db, nil)
+
+	owner := seedUser(t, db, "owner", "user", "", false)
+	photos := seedFolder(t, db, "/photos", owner)
+	other := seedFolder(t, db, "/other", owner)
+	shared := seedFile(t, db, photos, "2024/a.jpg", "image")
+	outsidePrefix := seedFile(t, db, photos, "2023/b.jpg", "image")
+	otherFolder := seedFile(t, db, other, "2024/c.jpg", "image")
+
+	album := lastID(t, mustExec(t, db, "INSERT INTO albums_v2 (name, owner_id) VALUES ('2024', ?)", owner))
+	mustExec(t, db, "INSERT INTO album_folders (album_id, folder_id, path_prefix) VALUES (?, ?, '2024')", album, photos)
+
+	fileShare, err := svc.CreateShare("file", shared, owner, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create file share: %v", err)
+	}
+	albumShare, err := svc.CreateShare("album", album, owner, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create album share: %v", err)
+	}
+	// Added after the share was created, so only a live evaluation sees it
+	added := seedFile(t, db, photos, "2024/trip/d.jpg", "image")
+
+	cases := []struct {
+		name    string
+		shareID string
+		fileID  int64
+		want    bool
+	}{
+		{"shared file", fileShare.ID, shared, true},
+		{"other file of a file share", fileShare.ID, outsidePrefix, false},
+		{"album file", albumShare.ID, shared, true},
+		{"album file added after sharing", albumShare.ID, added, true},
+		{"file outside the album prefix", albumShare.ID, outsidePrefix, false},
+		{"matching path in another folder", albumShare.ID, otherFolder, false},
+	}
+	for _, tc := range cases {
+		got, err := svc.ShareIncludesFile(tc.shareID, tc.fileID)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: included = %t, want %t", tc.name, got, tc.want)
+		}
+	}
+
+	if _, err := svc.ShareIncludesFile("missing", shared); err == nil {
+		t.Error("unknown share: expected an error")
+	}
+}
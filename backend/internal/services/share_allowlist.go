@@ -0,0 +1,79 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidAllowedEmail is returned for allowlist entries that are neither an email nor a domain pattern
+var ErrInvalidAllowedEmail = errors.New("allowed_emails entries must be an email address or a domain pattern like @example.com")
+
+// NormalizeAllowedEmails validates a share's email allowlist and returns it lowercased
+// and deduplicated. Entries are full addresses ("alice@example.com") or domain patterns
+// ("@example.com" or "*@example.com", stored as "@example.com").
+func NormalizeAllowedEmails(entries []string) ([]string, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		entry = strings.TrimPrefix(entry, "*")
+
+		at := strings.Index(entry, "@")
+		if at < 0 || at != strings.LastIndex(entry, "@") || strings.ContainsAny(entry, ", \t") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidAllowedEmail, entry)
+		}
+		domain := entry[at+1:]
+		if domain == "" || !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidAllowedEmail, entry)
+		}
+
+		if !seen[entry] {
+			seen[entry] = true
+			normalized = append(normalized, entry)
+		}
+	}
+	return normalized, nil
+}
+
+// emailAllowed reports whether an email matches an address or domain pattern of the allowlist
+func emailAllowed(email string, allowed []string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return false
+	}
+	domain := email[at:]
+	for _, entry := range allowed {
+		if entry == email || entry == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// splitAllowedEmails parses the stored comma separated allowlist
+func splitAllowedEmails(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// userEmailAllowed checks a user's email against a share allowlist. Only verified
+// addresses count: self-registered users choose their email freely.
+func (s *ShareService) userEmailAllowed(userID int64, allowed []string) (bool, error) {
+	var email sql.NullString
+	err := s.db.QueryRow("SELECT email FROM users WHERE id = ? AND email_verified = 1", userID).Scan(&email)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return emailAllowed(email.String, allowed), nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeAllowedEmails(t *testing.T) {
+	got, err := NormalizeAllowedEmails([]string{" Alice@Example.com ", "*@Corp.example.org", "@corp.example.org", "", "alice@example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"alice@example.com", "@corp.example.org"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, entry := range []string{"alice", "a@b@example.com", "alice@", "alice@localhost", "@.example.com", "a@example.com,b@example.com", "a b@example.com"} {
+		if _, err := NormalizeAllowedEmails([]string{entry}); !errors.Is(err, ErrInvalidAllowedEmail) {
+			t.Errorf("%q: expected ErrInvalidAllowedEmail, got %v", entry, err)
+		}
+	}
+}
+
+func TestEmailAllowed(t *testing.T) {
+	allowed := []string{"alice@example.com", "@corp.example.org"}
+	cases := map[string]bool{
+		"alice@example.com":          true,
+		"ALICE@example.com":          true,
+		"bob@example.com":            false,
+		"bob@corp.example.org":       true,
+		"bob@sub.corp.example.org":   false,
+		"bob@evilcorp.example.org":   false,
+		"@corp.example.org":          false,
+		"":                           false,
+		"alice@example.com.evil.net": false,
+	}
+	for email, want := range cases {
+		if got := emailAllowed(email, allowed); got != want {
+			t.Errorf("emailAllowed(%q) = %v, want %v", email, got, want)
+		}
+	}
+}
+
+func TestValidateShareAccessAllowlist(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewShareService(db, nil)
+
+	owner := seedUser(t, db, "owner", "user", "owner@elsewhere.net", true)
+	verified := seedUser(t, db, "verified", "user", "friend@example.com", true)
+	unverified := seedUser(t, db, "unverified", "user", "friend2@example.com", false)
+	outsider := seedUser(t, db, "outsider", "user", "someone@other.net", true)
+
+	folder := seedFolder(t, db, "/photos", owner)
+	file := seedFile(t, db, folder, "a.jpg", "image")
+
+	share, err := svc.CreateShare("file", file, owner, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	if err := svc.UpdateShare(share.ID, map[string]interface{}{"allowed_emails": []string{"@example.com"}}); err != nil {
+		t.Fatalf("update share: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		userID *int64
+		want   error
+	}{
+		{"anonymous", nil, ErrAccessDenied},
+		{"owner", &owner, nil},
+		{"verified match", &verified, nil},
+		{"unverified match", &unverified, ErrAccessDenied},
+		{"no match", &outsider, ErrAccessDenied},
+	}
+	for _, tc := range cases {
+		_, err := svc.ValidateShareAccess(share.ID, "", tc.userID)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// Removing the allowlist opens the share to anyone again
+	if err := svc.UpdateShare(share.ID, map[string]interface{}{"allowed_emails": []string{}}); err != nil {
+		t.Fatalf("clear allowlist: %v", err)
+	}
+	if _, err := svc.ValidateShareAccess(share.ID, "", nil); err != nil {
+		t.Errorf("anonymous after clearing allowlist: %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// shareDigestPreferenceKey is the user preference selecting the share activity digest
+// frequency ("daily" or "weekly"; anything else means no digest)
+const shareDigestPreferenceKey = "share_digest"
+
+// shareDigestPeriods maps digest frequencies to the interval between digests
+var shareDigestPeriods = map[string]time.Duration{
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
+// accessLogTimeFormat matches the CURRENT_TIMESTAMP format of share_access_log.accessed_at
+const accessLogTimeFormat = "2006-01-02 15:04:05"
+
+// ShareDigestEntry summarizes the activity of one share over a digest period
+type ShareDigestEntry struct {
+	ShareID  string
+	Title    string
+	Views    int
+	Visitors int // Distinct IP addresses
+}
+
+// ShareDigestService emails share owners a periodic summary of their shares' activity
+type ShareDigestService struct {
+	db     *sql.DB
+	mailer Mailer
+}
+
+func NewShareDigestService(db *sql.DB, mailer Mailer) *ShareDigestService {
+	return &ShareDigestService{db: db, mailer: mailer}
+}
+
+// SendDueDigests emails every user whose digest period has elapsed since their last
+// digest. Users without activity in the period get no email, but their period still
+// advances. Returns the number of emails sent.
+func (s *ShareDigestService) SendDueDigests(now time.Time) (int, error) {
+	type recipient struct {
+		userID    int64
+		username  string
+		email     string
+		frequency string
+		lastSent  sql.NullTime
+	}
+
+	rows, err := s.db.Query(`
+		SELECT u.id, u.username, u.email, up.value, sd.last_sent_at
+		FROM users u
+		INNER JOIN user_preferences up ON up.user_id = u.id AND up.key = ?
+		LEFT JOIN share_digests sd ON sd.user_id = u.id
+		WHERE u.enabled = 1 AND COALESCE(u.email, '') != ''
+	`, shareDigestPreferenceKey)
+	if err != nil {
+		return 0, err
+	}
+
+	var recipients []recipient
+	for rows.Next() {
+		var r recipient
+		if err := rows.Scan(&r.userID, &r.username, &r.email, &r.frequency, &r.lastSent); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if _, ok := shareDigestPeriods[r.frequency]; ok {
+			recipients = append(recipients, r)
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, r := range recipients {
+		period := shareDigestPeriods[r.frequency]
+		since := now.Add(-period)
+		if r.lastSent.Valid {
+			if now.Sub(r.lastSent.Time) < period {
+				continue
+			}
+			since = r.lastSent.Time
+		}
+
+		entries, err := s.BuildDigest(r.userID, since, now)
+		if err != nil {
+			return sent, err
+		}
+
+		if len(entries) > 0 {
+			subject := fmt.Sprintf("Your %s share activity digest", r.frequency)
+			if err := s.mailer.Send(r.email, subject, FormatShareDigest(r.username, since, now, entries)); err != nil {
+				// Leave last_sent_at alone so the digest is retried on the next run
+				log.Printf("Failed to send share digest to user %d: %v", r.userID, err)
+				continue
+			}
+			sent++
+		}
+
+		_, err = s.db.Exec(`
+			INSERT INTO share_digests (user_id, last_sent_at) VALUES (?, ?)
+			ON CONFLICT(user_id) DO UPDATE SET last_sent_at = excluded.last_sent_at
+		`, r.userID, now)
+		if err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// BuildDigest returns per-share access counts of a user's shares between since and
+// until, busiest share first. Shares without accesses are omitted.
+func (s *ShareDigestService) BuildDigest(ownerID int64, since, until time.Time) ([]ShareDigestEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT s.id, s.title, COUNT(*), COUNT(DISTINCT sal.ip_address)
+		FROM shares s
+		INNER JOIN share_access_log sal ON sal.share_id = s.id
+		WHERE s.owner_id = ? AND sal.accessed_at >= ? AND sal.accessed_at < ?
+		GROUP BY s.id
+		ORDER BY COUNT(*) DESC, s.id
+	`, ownerID, since.UTC().Format(accessLogTimeFormat), until.UTC().Format(accessLogTimeFormat))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []ShareDigestEntry
+	for rows.Next() {
+		var e ShareDigestEntry
+		if err := rows.Scan(&e.ShareID, &e.Title, &e.Views, &e.Visitors); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// FormatShareDigest renders the plain-text digest email body
+func FormatShareDigest(username string, since, until time.Time, entries []ShareDigestEntry) string {
+	var b strings.Builder
+	totalViews := 0
+	for _, e := range entries {
+		totalViews += e.Views
+	}
+
+	fmt.Fprintf(&b, "Hi %s,\n\n", username)
+	fmt.Fprintf(&b, "Your shares were viewed %d times between %s and %s.\n\n",
+		totalViews, since.UTC().Format(time.RFC1123), until.UTC().Format(time.RFC1123))
+	for _, e := range entries {
+		name := e.Title
+		if name == "" {
+			name = e.ShareID
+		}
+		fmt.Fprintf(&b, "- %s: %d views from %d visitors\n", name, e.Views, e.Visitors)
+	}
+	b.WriteString("\nYou can change how often you receive this digest in your preferences.\n")
+
+	return b.String()
+}
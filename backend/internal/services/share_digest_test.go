@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// digestMail is a message captured by recordingMailer
+type digestMail struct {
+	to, subject, body string
+}
+
+// recordingMailer records sent mail instead of delivering it; err fails every send
+type recordingMailer struct {
+	sent []digestMail
+	err  error
+}
+
+func (m *recordingMailer) Send(to, subject, body string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, digestMail{to, subject, body})
+	return nil
+}
+
+// seedAccess logs a share access at the given time
+func seedAccess(t *testing.T, svc *ShareService, shareID, ip string, at time.Time) {
+	t.Helper()
+	mustExec(t, svc.db, "INSERT INTO share_access_log (share_id, ip_address, accessed_at) VALUES (?, ?, ?)",
+		shareID, ip, at.UTC().Format(accessLogTimeFormat))
+}
+
+func TestSendDueDigests(t *testing.T) {
+	db := newTestDB(t)
+	shares := NewShareService(db, nil)
+	prefs := NewUserPreferenceService(db)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	alice := seedUser(t, db, "alice", "user", "alice@example.com", true)
+	bob := seedUser(t, db, "bob", "user", "bob@example.com", true)
+	carol := seedUser(t, db, "carol", "user", "", false)
+	folder := seedFolder(t, db, "/photos", alice)
+	file := seedFile(t, db, folder, "a.jpg", "image")
+	for _, u := range []int64{alice, bob, carol} {
+		if err := prefs.UpdatePreferences(u, map[string]*string{"share_digest": strPtr("daily")}); err != nil {
+			t.Fatalf("set digest preference: %v", err)
+		}
+	}
+
+	beach, _ := shares.CreateShare("file", file, alice, "Beach", "", "public", "", false, false, nil, nil)
+	quiet, _ := shares.CreateShare("file", file, alice, "", "", "public", "", false, false, nil, nil)
+	seedAccess(t, shares, beach.ID, "10.0.0.1", now.Add(-2*time.Hour))
+	seedAccess(t, shares, beach.ID, "10.0.0.1", now.Add(-3*time.Hour))
+	seedAccess(t, shares, beach.ID, "10.0.0.2", now.Add(-4*time.Hour))
+	seedAccess(t, shares, quiet.ID, "10.0.0.3", now.Add(-5*time.Hour))
+	// Before the daily period
+	seedAccess(t, shares, beach.ID, "10.0.0.4", now.Add(-30*time.Hour))
+	carolShare, _ := shares.CreateShare("file", file, carol, "", "", "public", "", false, false, nil, nil)
+	seedAccess(t, shares, carolShare.ID, "10.0.0.5", now.Add(-time.Hour))
+
+	mailer := &recordingMailer{}
+	svc := NewShareDigestService(db, mailer)
+	sent, err := svc.SendDueDigests(now)
+	if err != nil {
+		t.Fatalf("SendDueDigests: %v", err)
+	}
+
+	// bob had no activity and carol has no email address
+	if sent != 1 || len(mailer.sent) != 1 {
+		t.Fatalf("sent %d mails %+v, want only alice's", sent, mailer.sent)
+	}
+	mail := mailer.sent[0]
+	if mail.to != "alice@example.com" || mail.subject != "Your daily share activity digest" {
+		t.Errorf("mail to %q, subject %q", mail.to, mail.subject)
+	}
+	for _, want := range []string{
+		"Hi alice,",
+		"viewed 4 times",
+		"- Beach: 3 views from 2 visitors\n- " + quiet.ID + ": 1 views from 1 visitors\n",
+	} {
+		if !strings.Contains(mail.body, want) {
+			t.Errorf("digest body missing %q:\n%s", want, mail.body)
+		}
+	}
+
+	// Both alice and bob wait a full period before the next digest
+	seedAccess(t, shares, beach.ID, "10.0.0.6", now.Add(time.Hour))
+	if sent, err := svc.SendDueDigests(now.Add(2 * time.Hour)); err != nil || sent != 0 {
+		t.Errorf("second run within the period: sent %d, err %v", sent, err)
+	}
+	var digests int
+	db.QueryRow("SELECT COUNT(*) FROM share_digests WHERE user_id IN (?, ?)", alice, bob).Scan(&digests)
+	if digests != 2 {
+		t.Errorf("%d digest timestamps stored, want 2", digests)
+	}
+
+	// The next digest covers only activity since the last one
+	mailer.sent = nil
+	if sent, err := svc.SendDueDigests(now.Add(25 * time.Hour)); err != nil || sent != 1 {
+		t.Fatalf("next day: sent %d, err %v", sent, err)
+	}
+	if !strings.Contains(mailer.sent[0].body, "- Beach: 1 views from 1 visitors\n") {
+		t.Errorf("next digest body:\n%s", mailer.sent[0].body)
+	}
+}
+
+func TestSendDueDigestsRetriesFailedMail(t *testing.T) {
+	db := newTestDB(t)
+	shares := NewShareService(db, nil)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	shareID := seedFileShare(t, shares)
+	var owner int64
+	db.QueryRow("SELECT owner_id FROM shares WHERE id = ?", shareID).Scan(&owner)
+	mustExec(t, db, "UPDATE users SET email = 'owner@example.com' WHERE id = ?", owner)
+	if err := NewUserPreferenceService(db).UpdatePreferences(owner, map[string]*string{"share_digest": strPtr("weekly")}); err != nil {
+		t.Fatalf("set digest preference: %v", err)
+	}
+	seedAccess(t, shares, shareID, "10.0.0.1", now.Add(-24*time.Hour))
+
+	mailer := &recordingMailer{err: errors.New("connection refused")}
+	svc := NewShareDigestService(db, mailer)
+	if sent, err := svc.SendDueDigests(now); err != nil || sent != 0 {
+		t.Fatalf("failing mailer: sent %d, err %v", sent, err)
+	}
+
+	mailer.err = nil
+	if sent, err := svc.SendDueDigests(now.Add(time.Hour)); err != nil || sent != 1 {
+		t.Errorf("retry: sent %d, err %v, want the digest resent", sent, err)
+	}
+}
+
+func TestShareDigestPreferenceValues(t *testing.T) {
+	for value, want := range map[string]error{
+		"daily":  nil,
+		"weekly": nil,
+		"off":    nil,
+		"hourly": ErrInvalidPreferenceValue,
+		"":       ErrInvalidPreferenceValue,
+	} {
+		if err := ValidatePreferences(map[string]*string{"share_digest": strPtr(value)}); !errors.Is(err, want) {
+			t.Errorf("share_digest %q: got %v, want %v", value, err, want)
+		}
+	}
+	if err := ValidatePreferences(map[string]*string{"share_digest": nil}); err != nil {
+		t.Errorf("removing share_digest: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+
+	"awesome-sharing/internal/database"
+)
+
+// ErrShareResourceGone is returned for shares whose file or album no longer exists
+var ErrShareResourceGone = errors.New("shared item no longer exists")
+
+// orphanedSharesWhere selects shares whose (share_type, resource_id) no longer exists
+const orphanedSharesWhere = `
+	(share_type = 'file' AND NOT EXISTS (SELECT 1 FROM files WHERE id = shares.resource_id))
+	OR (share_type = 'album' AND NOT EXISTS (SELECT 1 FROM albums_v2 WHERE id = shares.resource_id))`
+
+// shareResourceExists reports whether the file or album of a share still exists
+func (s *ShareService) shareResourceExists(shareType string, resourceID int64) (bool, error) {
+	var table string
+	switch shareType {
+	case "file":
+		table = "files"
+	case "album":
+		table = "albums_v2"
+	default:
+		return true, nil
+	}
+	var exists bool
+	err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = ?)", resourceID).Scan(&exists)
+	return exists, err
+}
+
+// handleOrphanedShares applies the orphaned_shares policy to shares whose resource was
+// deleted: disable them (default), delete them, or keep them. Returns the number changed.
+func (s *FileValidatorService) handleOrphanedShares() (int, error) {
+	policy := OrphanedSharesDisable
+	if s.settings != nil {
+		var err error
+		if policy, err = s.settings.GetOrphanedShares(); err != nil {
+			return 0, err
+		}
+	}
+
+	var result sql.Result
+	var err error
+	switch policy {
+	case OrphanedSharesDisable:
+		result, err = database.ExecWithRetry(s.db, "UPDATE shares SET enabled = 0 WHERE enabled = 1 AND ("+orphanedSharesWhere+")")
+	case OrphanedSharesDelete:
+		result, err = database.ExecWithRetry(s.db, "DELETE FROM shares WHERE "+orphanedSharesWhere)
+	default:
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	changed, err := result.RowsAffected()
+	if changed > 0 {
+		log.Printf("Orphaned shares: %d shares of deleted files or albums %sd", changed, policy)
+	}
+	return int(changed), err
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestOrphanedSharesPolicy(t *testing.T) {
+	cases := []struct {
+		policy      string
+		wantExists  bool
+		wantEnabled bool
+	}{
+		{"", true, false},
+		{OrphanedSharesDisable, true, false},
+		{OrphanedSharesDelete, false, false},
+		{OrphanedSharesKeep, true, true},
+	}
+	for _, tc := range cases {
+		db := newTestDB(t)
+		settings := NewSettingsService(db)
+		shares := NewShareService(db, nil)
+		albums := NewAlbumService(db)
+		validator := NewFileValidatorService(db, NewFolderService(db), settings, nil)
+		if tc.policy != "" {
+			if err := settings.SetSetting("orphaned_shares", tc.policy); err != nil {
+				t.Fatalf("%q: set policy: %v", tc.policy, err)
+			}
+		}
+
+		owner := seedUser(t, db, "owner", "user", "", false)
+		root := t.TempDir()
+		folder := seedFolder(t, db, root, owner)
+		deleted := seedFile(t, db, folder, "deleted.jpg", "image")
+		writeDiskFile(t, root, "present.jpg", []byte("x"))
+		present := seedFile(t, db, folder, "present.jpg", "image")
+		album, _ := albums.CreateAlbum("Removed", "", owner)
+
+		orphans := []string{}
+		for _, resource := range []struct {
+			shareType string
+			id        int64
+		}{{"file", deleted}, {"album", album.ID}} {
+			share, err := shares.CreateShare(resource.shareType, resource.id, owner, "", "", "public", "", false, false, nil, nil)
+			if err != nil {
+				t.Fatalf("%q: create share: %v", tc.policy, err)
+			}
+			orphans = append(orphans, share.ID)
+		}
+		live, err := shares.CreateShare("file", present, owner, "", "", "public", "", false, false, nil, nil)
+		if err != nil {
+			t.Fatalf("%q: create share: %v", tc.policy, err)
+		}
+
+		mustExec(t, db, "DELETE FROM albums_v2 WHERE id = ?", album.ID)
+		if _, err := validator.CleanupAllInvalidFiles(context.Background()); err != nil {
+			t.Fatalf("%q: cleanup: %v", tc.policy, err)
+		}
+
+		for _, id := range orphans {
+			share, err := shares.GetShare(id)
+			if !tc.wantExists {
+				if err == nil {
+					t.Errorf("%q: orphaned share %s still exists", tc.policy, id)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%q: get share %s: %v", tc.policy, id, err)
+			}
+			if share.Enabled != tc.wantEnabled {
+				t.Errorf("%q: orphaned share %s enabled = %t, want %t", tc.policy, id, share.Enabled, tc.wantEnabled)
+			}
+			if _, err := shares.ValidateShareAccess(id, "", nil); !errors.Is(err, ErrShareResourceGone) {
+				t.Errorf("%q: access to orphaned share %s: err = %v, want ErrShareResourceGone", tc.policy, id, err)
+			}
+		}
+
+		if share, err := shares.GetShare(live.ID); err != nil || !share.Enabled {
+			t.Errorf("%q: share of an existing file changed (%v)", tc.policy, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRotateShareIDKeepsSettingsAndHistory(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewShareService(db, nil)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	viewer := seedUser(t, db, "viewer", "user", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	file := seedFile(t, db, folder, "a.jpg", "image")
+
+	expires := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	maxViews := 10
+	share, err := svc.CreateShare("file", file, owner, "Leaked", "desc", "private", "pw", true, true, &expires, &maxViews)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	if err := svc.GrantSharePermission(share.ID, viewer); err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := svc.LogAccess(share.ID, &viewer, "198.51.100.7", "test"); err != nil {
+			t.Fatalf("log access: %v", err)
+		}
+	}
+	oldToken, err := svc.GenerateAccessToken(share.ID)
+	if err != nil {
+		t.Fatalf("access token: %v", err)
+	}
+
+	rotated, err := svc.RotateShareID(share.ID)
+	if err != nil {
+		t.Fatalf("RotateShareID: %v", err)
+	}
+	if rotated.ID == share.ID || len(rotated.ID) != len(share.ID) {
+		t.Fatalf("new ID %q, old %q", rotated.ID, share.ID)
+	}
+
+	if _, err := svc.GetShare(share.ID); err != ErrShareNotFound {
+		t.Errorf("old ID: err = %v, want ErrShareNotFound", err)
+	}
+	if rotated.Title != "Leaked" || rotated.AccessType != "private" || !rotated.RequiresAuth || !rotated.StripEXIF ||
+		!rotated.HasPassword || rotated.ViewCount != 3 || rotated.MaxViews == nil || *rotated.MaxViews != 10 ||
+		rotated.ExpiresAt == nil || !rotated.ExpiresAt.Equal(expires) || rotated.OwnerID != owner || rotated.ResourceID != file {
+		t.Errorf("settings not carried over: %+v", rotated)
+	}
+	if _, err := svc.ValidateShareAccess(rotated.ID, "pw", &viewer); err != nil {
+		t.Errorf("password no longer valid: %v", err)
+	}
+
+	if ok, err := svc.CheckSharePermission(rotated.ID, viewer); err != nil || !ok {
+		t.Errorf("permission not carried over: %v, %v", ok, err)
+	}
+	var logged, orphaned int
+	db.QueryRow("SELECT COUNT(*) FROM share_access_log WHERE share_id = ?", rotated.ID).Scan(&logged)
+	db.QueryRow("SELECT COUNT(*) FROM share_access_log WHERE share_id = ?", share.ID).Scan(&orphaned)
+	if logged != 3 || orphaned != 0 {
+		t.Errorf("access log rows: new %d, old %d, want 3 and 0", logged, orphaned)
+	}
+
+	if _, _, err := svc.ValidateAccessToken(oldToken); err == nil {
+		t.Error("access token for the old ID is still valid")
+	}
+}
+
+func TestRotateShareIDMissing(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewShareService(db, nil)
+	if _, err := svc.RotateShareID("nope1234"); err != ErrShareNotFound {
+		t.Errorf("err = %v, want ErrShareNotFound", err)
+	}
+}
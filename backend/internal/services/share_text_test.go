@@ -0,0 +1,50 @@
+package services
+
+import "testing"
+
+func TestSanitizeShareText(t *testing.T) {
+	cases := map[string]string{
+		"  Proofs from the May shoot  ": "Proofs from the May shoot",
+		"line one\nline two\tend":       "line one\nline two\tend",
+		"bell\a and null\x00 removed":   "bell and null removed",
+		"\r\n  ":                        "",
+	}
+	for input, want := range cases {
+		if got := SanitizeShareText(input); got != want {
+			t.Errorf("SanitizeShareText(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestShareTitleDescriptionRoundTrip(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewShareService(db, nil)
+
+	owner := seedUser(t, db, "owner", "user", "owner@example.com", true)
+	folder := seedFolder(t, db, "/photos", owner)
+	file := seedFile(t, db, folder, "a.jpg", "image")
+
+	share, err := svc.CreateShare("file", file, owner, " Proofs ", "Pick your favorites\x07", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	if share.Title != "Proofs" || share.Description != "Pick your favorites" {
+		t.Errorf("created share: title %q, description %q", share.Title, share.Description)
+	}
+
+	if err := svc.UpdateShare(share.ID, map[string]interface{}{"description": "Final selection"}); err != nil {
+		t.Fatalf("update share: %v", err)
+	}
+	updated, err := svc.ValidateShareAccess(share.ID, "", nil)
+	if err != nil {
+		t.Fatalf("validate access: %v", err)
+	}
+	if updated.Title != "Proofs" || updated.Description != "Final selection" {
+		t.Errorf("updated share: title %q, description %q", updated.Title, updated.Description)
+	}
+
+	shares, err := svc.ListSharesByOwner(owner)
+	if err != nil || len(shares) != 1 || shares[0].Description != "Final selection" {
+		t.Errorf("list shares: %+v, %v", shares, err)
+	}
+}
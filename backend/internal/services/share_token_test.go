@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// seedFileShare creates a public share of a new file owned by a new user
+func seedFileShare(t *testing.T, svc *ShareService) string {
+	t.Helper()
+	owner := seedUser(t, svc.db, "owner", "user", "", false)
+	folder := seedFolder(t, svc.db, "/photos", owner)
+	file := seedFile(t, svc.db, folder, "a.jpg", "image")
+	share, err := svc.CreateShare("file", file, owner, "", "", "public", "", false, false, nil, nil)
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	return share.ID
+}
+
+func TestAccessTokenSignature(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewShareService(db, nil)
+	shareID := seedFileShare(t, svc)
+
+	token, err := svc.GenerateAccessToken(shareID)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	if got, _, err := svc.ValidateAccessToken(token); err != nil || got != shareID {
+		t.Fatalf("validate token: %q, %v", got, err)
+	}
+
+	// Changing any part of the payload breaks the signature
+	parts := strings.Split(token, ":")
+	parts[1] = "9999"
+	if _, _, err := svc.ValidateAccessToken(strings.Join(parts, ":")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("tampered token: got %v, want ErrInvalidToken", err)
+	}
+	if _, _, err := svc.ValidateAccessToken(token + "x"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("tampered signature: got %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestRotateTokenSecretInvalidatesTokens(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewShareService(db, nil)
+	shareID := seedFileShare(t, svc)
+
+	token, err := svc.GenerateAccessToken(shareID)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	if err := svc.RotateTokenSecret(); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if _, _, err := svc.ValidateAccessToken(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token after rotation: got %v, want ErrInvalidToken", err)
+	}
+
+	// A second service instance (e.g. after a restart) uses the rotated secret
+	fresh, err := svc.GenerateAccessToken(shareID)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	if _, _, err := NewShareService(db, nil).ValidateAccessToken(fresh); err != nil {
+		t.Errorf("token on restarted service: %v", err)
+	}
+}
+
+func TestTokenSecretStorage(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewShareService(db, nil)
+	if err := svc.RotateTokenSecret(); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+
+	// The secret lives in server_secrets, out of reach of the settings API
+	settings, err := NewSettingsService(db).GetAllSettings()
+	if err != nil {
+		t.Fatalf("get settings: %v", err)
+	}
+	if _, ok := settings[shareTokenSecretKey]; ok {
+		t.Errorf("token secret exposed through settings")
+	}
+	var stored string
+	if err := db.QueryRow("SELECT value FROM server_secrets WHERE key = ?", shareTokenSecretKey).Scan(&stored); err != nil {
+		t.Fatalf("read stored secret: %v", err)
+	}
+	if len(stored) != 2*tokenSecretSize {
+		t.Errorf("stored secret has %d hex characters, want %d", len(stored), 2*tokenSecretSize)
+	}
+
+	// A stored secret that is empty or too short is replaced, never used
+	for _, bad := range []string{"", "abcd", "not hex"} {
+		mustExec(t, db, "UPDATE server_secrets SET value = ? WHERE key = ?", bad, shareTokenSecretKey)
+		secret, err := NewShareService(db, nil).getTokenSecret()
+		if err != nil {
+			t.Fatalf("%q: get secret: %v", bad, err)
+		}
+		if len(secret) != tokenSecretSize {
+			t.Errorf("%q: secret has %d bytes, want %d", bad, len(secret), tokenSecretSize)
+		}
+	}
+}
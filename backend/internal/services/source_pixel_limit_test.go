@@ -0,0 +1,95 @@
+package services
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// writeOversizedPNG writes a tiny PNG whose header claims width x height pixels.
+// Only the header is valid, so anything that tries to decode it in full fails or
+// allocates a width x height bitmap.
+func writeOversizedPNG(t *testing.T, path string, width, height uint32) {
+	t.Helper()
+	writeTestImage(t, path, 4, 4)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Signature (8) + chunk length (4) + "IHDR" (4), then width, height, ... and the CRC at 29
+	binary.BigEndian.PutUint32(data[16:], width)
+	binary.BigEndian.PutUint32(data[20:], height)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestThumbnailSourcePixelLimit(t *testing.T) {
+	dir := t.TempDir()
+	huge := filepath.Join(dir, "panorama.png")
+	// 50000 x 40000 = 2 gigapixels, 8 GB once decoded
+	writeOversizedPNG(t, huge, 50000, 40000)
+	normal := filepath.Join(dir, "normal.png")
+	writeTestImage(t, normal, 400, 300)
+
+	ts := NewThumbnailService(t.TempDir())
+	ts.SetMaxSourcePixels(100 * 1000 * 1000)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := ts.GetThumbnail(huge, 1, "small", ThumbnailModeFit)
+	runtime.ReadMemStats(&after)
+	if !errors.Is(err, ErrSourceTooLarge) {
+		t.Fatalf("oversized source: got %v, want ErrSourceTooLarge", err)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16<<20 {
+		t.Errorf("rejecting the source allocated %d bytes", allocated)
+	}
+
+	if _, err := ts.GetThumbnail(normal, 2, "small", ThumbnailModeFit); err != nil {
+		t.Errorf("source below the cap: %v", err)
+	}
+
+	if _, err := ComputeDHash(huge, 100*1000*1000); !errors.Is(err, ErrSourceTooLarge) {
+		t.Errorf("dHash of oversized source: got %v, want ErrSourceTooLarge", err)
+	}
+	if _, err := ComputeDHash(normal, 100*1000*1000); err != nil {
+		t.Errorf("dHash below the cap: %v", err)
+	}
+}
+
+func TestMetadataStripperPixelLimit(t *testing.T) {
+	dir := t.TempDir()
+	tiff := filepath.Join(dir, "scan.tif")
+	writeTestImage(t, tiff, 20, 20)
+	png := filepath.Join(dir, "panorama.png")
+	writeOversizedPNG(t, png, 50000, 40000)
+
+	cases := []struct {
+		name      string
+		path      string
+		maxPixels int64
+		wantErr   error
+	}{
+		{"re-encoded format above the cap", tiff, 100, ErrSourceTooLarge},
+		{"re-encoded format below the cap", tiff, 400, nil},
+		{"no cap", tiff, 0, nil},
+		{"lossless stripping never decodes", png, 100, nil},
+	}
+	for i, tc := range cases {
+		m := NewMetadataStripper(t.TempDir())
+		m.SetMaxSourcePixels(tc.maxPixels)
+		_, err := m.StrippedPath(int64(i+1), tc.path)
+		if tc.wantErr == nil && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
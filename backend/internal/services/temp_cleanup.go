@@ -0,0 +1,56 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tempFilePrefixes are the name prefixes of the temp files the server creates: HEIC
+// converter output, multipart upload parts spooled to disk by mime/multipart and job
+// downloads. TEMP_DIR may be shared with other programs, so nothing else is ever touched.
+var tempFilePrefixes = []string{"heic-", "multipart-", jobFilePrefix}
+
+// isAppTempFile reports whether a temp directory entry was created by the server
+func isAppTempFile(entry os.DirEntry) bool {
+	if !entry.Type().IsRegular() {
+		return false
+	}
+	for _, prefix := range tempFilePrefixes {
+		if strings.HasPrefix(entry.Name(), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanupTempDir removes the server's temp files last modified more than maxAge ago:
+// upload parts spooled to disk and converter output left behind by requests that were
+// interrupted. It returns the number of files removed.
+func CleanupTempDir(dir string, maxAge time.Duration) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, entry := range entries {
+		if !isAppTempFile(entry) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCleanupTempDir(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+
+	cases := []struct {
+		name     string
+		stale    bool
+		dir      bool
+		wantGone bool
+	}{
+		{"multipart-123", true, false, true},
+		{"heic-456.jpg", true, false, true},
+		{"multipart-789", false, false, false},
+		{"other-app.tmp", true, false, false},
+		{"heic-dir", true, true, false},
+	}
+	for _, tc := range cases {
+		path := filepath.Join(dir, tc.name)
+		if tc.dir {
+			if err := os.Mkdir(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+		} else if err := os.WriteFile(path, []byte("part"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if tc.stale {
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	removed, err := CleanupTempDir(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("removed %d files, want 2", removed)
+	}
+	for _, tc := range cases {
+		_, err := os.Stat(filepath.Join(dir, tc.name))
+		if gone := os.IsNotExist(err); gone != tc.wantGone {
+			t.Errorf("%s: removed = %t, want %t", tc.name, gone, tc.wantGone)
+		}
+	}
+
+	if removed, err := CleanupTempDir(filepath.Join(dir, "missing"), time.Hour); err != nil || removed != 0 {
+		t.Errorf("missing dir: removed %d, err %v", removed, err)
+	}
+}
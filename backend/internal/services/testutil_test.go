@@ -0,0 +1,95 @@
+package services
+
+import (
+	"database/sql"
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+
+	"awesome-sharing/internal/database"
+)
+
+// newTestDB opens a fresh, fully migrated database in a temporary directory
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := database.Initialize(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("initialize database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db.DB
+}
+
+// mustExec runs a statement and fails the test on error
+func mustExec(t *testing.T, db *sql.DB, query string, args ...interface{}) sql.Result {
+	t.Helper()
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		t.Fatalf("exec %q: %v", query, err)
+	}
+	return res
+}
+
+// lastID returns the row ID of an insert
+func lastID(t *testing.T, res sql.Result) int64 {
+	t.Helper()
+	id, err := res.LastInsertId()
+	if err != nil {
+		t.Fatalf("last insert id: %v", err)
+	}
+	return id
+}
+
+// seedUser inserts an enabled user with the given role and email
+func seedUser(t *testing.T, db *sql.DB, username, role, email string, emailVerified bool) int64 {
+	t.Helper()
+	return lastID(t, mustExec(t, db,
+		"INSERT INTO users (username, password_hash, email, role, enabled, email_verified) VALUES (?, 'x', ?, ?, 1, ?)",
+		username, email, role, emailVerified))
+}
+
+// seedFolder inserts an enabled folder
+func seedFolder(t *testing.T, db *sql.DB, path string, createdBy int64) int64 {
+	t.Helper()
+	return lastID(t, mustExec(t, db,
+		"INSERT INTO folders (name, absolute_path, enabled, created_by) VALUES (?, ?, 1, ?)",
+		filepath.Base(path), path, createdBy))
+}
+
+// seedFile inserts a file and maps it into a folder
+func seedFile(t *testing.T, db *sql.DB, folderID int64, relativePath, fileType string) int64 {
+	t.Helper()
+	id := lastID(t, mustExec(t, db,
+		"INSERT INTO files (filename, file_type, size) VALUES (?, ?, 100)",
+		filepath.Base(relativePath), fileType))
+	mustExec(t, db,
+		"INSERT INTO file_folder_mappings (file_id, folder_id, relative_path) VALUES (?, ?, ?)",
+		id, folderID, relativePath)
+	return id
+}
+
+// grantFolder gives a user access to a folder through a new permission group
+func grantFolder(t *testing.T, db *sql.DB, userID, folderID int64, permission string) int64 {
+	t.Helper()
+	groupID := lastID(t, mustExec(t, db, "INSERT INTO permission_groups (name, created_by) VALUES ('group', ?)", userID))
+	mustExec(t, db, "INSERT INTO permission_group_folders (permission_group_id, folder_id) VALUES (?, ?)", groupID, folderID)
+	mustExec(t, db, "INSERT INTO permission_group_permissions (permission_group_id, user_id, permission) VALUES (?, ?, ?)", groupID, userID, permission)
+	return groupID
+}
+
+// writeTestImage saves a width x height gradient image; the format follows the extension
+func writeTestImage(t *testing.T, path string, width, height int) {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xff})
+		}
+	}
+	if err := imaging.Save(img, path); err != nil {
+		t.Fatalf("save test image: %v", err)
+	}
+}
@@ -2,13 +2,22 @@ package services
 
 import (
 	"crypto/md5"
+	"errors"
 	"fmt"
 	"image"
+	"image/color"
+	"io"
+	"log"
 	_ "image/jpeg"
 	_ "image/png"
 	_ "image/gif"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/disintegration/imaging"
 	_ "golang.org/x/image/tiff" // TIFF format support
@@ -32,19 +41,165 @@ var (
 	}
 )
 
+// ErrSourceTooLarge is returned when an image has more pixels than the configured decode limit
+var ErrSourceTooLarge = errors.New("image too large to thumbnail")
+
+// Thumbnail modes: fit keeps the whole image (letterboxed in the grid), cover crops to fill the box
+const (
+	ThumbnailModeFit   = "fit"
+	ThumbnailModeCover = "cover"
+)
+
+// IsValidThumbnailMode checks if mode is a supported thumbnail mode
+func IsValidThumbnailMode(mode string) bool {
+	return mode == ThumbnailModeFit || mode == ThumbnailModeCover
+}
+
 type ThumbnailService struct {
 	thumbsDir string
+	sizeDirs  map[string]string // Optional per-size base directories
+
+	maxSourcePixels int64 // Images above this pixel count are not decoded (0 = unlimited)
+
+	contentHashes *ContentHashService // Optional: key thumbnails by content instead of path
+
+	placeholderDir string // Optional directory with custom <kind>.png/.jpg placeholders
+
+	generateSlots chan struct{} // Bounds concurrent thumbnail generations
+
+	regenerateOnChange bool // Regenerate cached thumbnails older than their source file
+
+	heicConverter []string // Optional external HEIC/HEIF to JPEG command, with {input} and {output}
+
+	background color.NRGBA // Fills transparent areas, since JPEG thumbnails have no alpha
 }
 
 func NewThumbnailService(thumbsDir string) *ThumbnailService {
 	return &ThumbnailService{
-		thumbsDir: thumbsDir,
+		thumbsDir:     thumbsDir,
+		sizeDirs:      make(map[string]string),
+		generateSlots: make(chan struct{}, runtime.NumCPU()),
+		background:    color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+// SetMaxConcurrentGenerations limits how many thumbnails are generated at once
+// (n <= 0 uses the number of CPUs). Further requests wait for a free slot; cached
+// thumbnails are served without waiting. Must be called before serving requests.
+func (ts *ThumbnailService) SetMaxConcurrentGenerations(n int) {
+	if n <= 0 {
+		n = runtime.NumCPU()
 	}
+	ts.generateSlots = make(chan struct{}, n)
+}
+
+// SetSizeDir stores thumbnails of the given size under dir instead of thumbsDir
+func (ts *ThumbnailService) SetSizeDir(sizeType, dir string) {
+	ts.sizeDirs[sizeType] = dir
+}
+
+// SetContentHashes keys cached thumbnails by file content, so moved or renamed files
+// keep their thumbnail and identical files share one
+func (ts *ThumbnailService) SetContentHashes(contentHashes *ContentHashService) {
+	ts.contentHashes = contentHashes
+}
+
+// ErrInvalidBackground is returned for thumbnail backgrounds that aren't #rrggbb colors
+var ErrInvalidBackground = errors.New("thumbnail background must be a #rrggbb color")
+
+// SetBackground sets the #rrggbb color transparent images are flattened onto
+// (white by default). Already cached thumbnails keep their old background.
+func (ts *ThumbnailService) SetBackground(hex string) error {
+	if len(hex) != 7 || hex[0] != '#' {
+		return ErrInvalidBackground
+	}
+	rgb, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return ErrInvalidBackground
+	}
+	ts.background = color.NRGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xff}
+	return nil
+}
+
+// flatten composites an image with transparency onto the background color;
+// opaque images are returned unchanged
+func (ts *ThumbnailService) flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	bounds := img.Bounds()
+	canvas := imaging.New(bounds.Dx(), bounds.Dy(), ts.background)
+	return imaging.Overlay(canvas, img, image.Pt(0, 0), 1.0)
+}
+
+// SetRegenerateOnChange makes GetThumbnail check the source mtime on every request and
+// regenerate cached thumbnails of files edited in place. The mtime is compared with the
+// one stored next to the content hash, so only content-keyed thumbnails follow edits.
+func (ts *ThumbnailService) SetRegenerateOnChange(enabled bool) {
+	ts.regenerateOnChange = enabled
+}
+
+// SetMaxSourcePixels limits the pixel count of images decoded for thumbnails (0 = unlimited).
+// Decoding allocates the full bitmap, so this bounds memory use on huge panoramas.
+func (ts *ThumbnailService) SetMaxSourcePixels(pixels int64) {
+	ts.maxSourcePixels = pixels
+}
+
+// checkSourceSize reads only the image header and rejects images above maxSourcePixels
+func (ts *ThumbnailService) checkSourceSize(srcPath string) error {
+	return checkPixelLimit(srcPath, ts.maxSourcePixels)
+}
+
+// checkPixelLimit reads only the image header and rejects images above maxPixels (0 = unlimited).
+// Every full decode of user files goes through this first.
+func checkPixelLimit(srcPath string, maxPixels int64) error {
+	if maxPixels <= 0 {
+		return nil
+	}
+
+	width, height, err := GetDimensions(srcPath)
+	if err != nil {
+		// Unknown header, let the decoder report the real error
+		return nil
+	}
+
+	if int64(width)*int64(height) > maxPixels {
+		return fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrSourceTooLarge, width, height, maxPixels)
+	}
+	return nil
+}
+
+// baseDir returns the base directory for a thumbnail size
+func (ts *ThumbnailService) baseDir(sizeType string) string {
+	if dir, ok := ts.sizeDirs[sizeType]; ok && dir != "" {
+		return dir
+	}
+	return ts.thumbsDir
+}
+
+// shardedPath returns the thumbnail path sharded by the first two hash byte pairs,
+// e.g. <base>/ab/cd/<fileID>_abcd1234_small.jpg
+func (ts *ThumbnailService) shardedPath(sizeType, hash, thumbFilename string) string {
+	return filepath.Join(ts.baseDir(sizeType), hash[0:2], hash[2:4], thumbFilename)
+}
+
+// ScanThumbnailSizeNone disables scan-time thumbnail pre-generation
+const ScanThumbnailSizeNone = "none"
+
+// IsValidScanThumbnailSize reports whether size is a valid scan-time thumbnail size
+// (a thumbnail size name, "none", or empty for "inherit")
+func IsValidScanThumbnailSize(size string) bool {
+	if size == "" || size == ScanThumbnailSizeNone {
+		return true
+	}
+	_, ok := ThumbnailSizes[size]
+	return ok
 }
 
 // GetThumbnail returns the path to a thumbnail, generating it if necessary
 // sizeType can be "small", "medium", or "large". Defaults to "small" if empty.
-func (ts *ThumbnailService) GetThumbnail(originalPath string, fileID int64, sizeType string) (string, error) {
+// mode can be "fit" or "cover". Defaults to "fit" if empty.
+func (ts *ThumbnailService) GetThumbnail(originalPath string, fileID int64, sizeType, mode string) (string, error) {
 	// Default to small size if not specified
 	if sizeType == "" {
 		sizeType = "small"
@@ -56,37 +211,341 @@ func (ts *ThumbnailService) GetThumbnail(originalPath string, fileID int64, size
 		size = ThumbnailSizes["small"]
 	}
 
-	// Generate thumbnail filename based on file ID, hash, and size
-	hash := fmt.Sprintf("%x", md5.Sum([]byte(originalPath)))
-	thumbFilename := fmt.Sprintf("%d_%s_%s.jpg", fileID, hash[:8], sizeType)
-	thumbPath := filepath.Join(ts.thumbsDir, thumbFilename)
+	if !IsValidThumbnailMode(mode) {
+		mode = ThumbnailModeFit
+	}
+
+	// The content hash is stored with the source mtime it was computed at, so any change
+	// of the mtime (newer or older) rehashes the file and moves it to a new thumbnail
+	var srcModTime time.Time
+	if ts.regenerateOnChange && ts.keyedByContent(originalPath) {
+		if info, err := os.Stat(originalPath); err == nil {
+			srcModTime = info.ModTime()
+		}
+	}
+
+	// Hashing reads the whole file, so the lookup before taking a generation slot only
+	// uses a stored hash; files without a current one are hashed once a slot is free
+	thumbPath, hashed := ts.cachedThumbnailPath(originalPath, fileID, sizeType, mode, srcModTime)
 
 	// Check if thumbnail already exists
-	if _, err := os.Stat(thumbPath); err == nil {
+	if hashed && thumbnailExists(thumbPath) {
+		return thumbPath, nil
+	}
+
+	// Wait for a generation slot, so a burst of requests can't saturate every CPU
+	ts.generateSlots <- struct{}{}
+	defer func() { <-ts.generateSlots }()
+
+	if !hashed {
+		thumbPath = ts.thumbnailPath(originalPath, fileID, sizeType, mode, srcModTime)
+	}
+
+	// Another request may have generated it while this one was waiting, or another
+	// file with the same content already has one
+	if thumbnailExists(thumbPath) {
 		return thumbPath, nil
 	}
 
+	if err := os.MkdirAll(filepath.Dir(thumbPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create thumbnail directory: %w", err)
+	}
+
 	// Generate thumbnail
-	if err := ts.generateThumbnail(originalPath, thumbPath, size.Width, size.Height); err != nil {
+	if err := ts.generateThumbnail(originalPath, thumbPath, size.Width, size.Height, mode); err != nil {
 		return "", err
 	}
 
 	return thumbPath, nil
 }
 
-// generateThumbnail creates a thumbnail from an image
-func (ts *ThumbnailService) generateThumbnail(srcPath, dstPath string, width, height int) error {
-	// Open source image
-	src, err := imaging.Open(srcPath)
+// thumbnailExists reports whether a cached thumbnail exists
+func thumbnailExists(thumbPath string) bool {
+	_, err := os.Stat(thumbPath)
+	return err == nil
+}
+
+// keyedByContent reports whether a file's thumbnail is keyed by its content hash. Only
+// files the thumbnailer can decode are hashed: videos, RAW and HEIC without a converter
+// get a placeholder anyway, and hashing a large video would be wasted work.
+func (ts *ThumbnailService) keyedByContent(originalPath string) bool {
+	if ts.contentHashes == nil || mediaFileType(originalPath) != "image" {
+		return false
+	}
+	return !isHEIC(originalPath) || len(ts.heicConverter) > 0
+}
+
+// cachedThumbnailPath is thumbnailPath without hashing the file. hashed is false when a
+// content-keyed file has no current stored hash yet, and the path is then unknown.
+func (ts *ThumbnailService) cachedThumbnailPath(originalPath string, fileID int64, sizeType, mode string, srcModTime time.Time) (thumbPath string, hashed bool) {
+	if !ts.keyedByContent(originalPath) {
+		return ts.pathThumbnailPath(originalPath, fileID, sizeType, mode), true
+	}
+	hash, ok := ts.contentHashes.Stored(fileID, srcModTime)
+	if !ok {
+		return "", false
+	}
+	return ts.contentThumbnailPath(hash, sizeType, mode), true
+}
+
+// thumbnailPath returns the cache path of a thumbnail. With content hashes enabled the
+// name of decodable images is derived from the file content (c_<sha256>_<size>.jpg);
+// otherwise, or when the content can't be hashed, from the file ID and path. A non-zero
+// srcModTime rehashes files modified since their content hash was stored.
+func (ts *ThumbnailService) thumbnailPath(originalPath string, fileID int64, sizeType, mode string, srcModTime time.Time) string {
+	if ts.keyedByContent(originalPath) {
+		hash, err := ts.contentHashes.GetOrComputeCurrent(fileID, originalPath, srcModTime)
+		if err == nil {
+			return ts.contentThumbnailPath(hash, sizeType, mode)
+		}
+		log.Printf("Content hash failed for file %d, using path-based thumbnail key: %v", fileID, err)
+	}
+
+	return ts.pathThumbnailPath(originalPath, fileID, sizeType, mode)
+}
+
+// contentThumbnailPath returns the cache path of a thumbnail keyed by content hash
+func (ts *ThumbnailService) contentThumbnailPath(hash, sizeType, mode string) string {
+	thumbFilename := fmt.Sprintf("c_%s_%s.jpg", hash, sizeType)
+	if mode == ThumbnailModeCover {
+		thumbFilename = fmt.Sprintf("c_%s_%s_%s.jpg", hash, sizeType, mode)
+	}
+	return ts.shardedPath(sizeType, hash, thumbFilename)
+}
+
+// pathThumbnailPath returns the cache path of a thumbnail keyed by file ID and path
+func (ts *ThumbnailService) pathThumbnailPath(originalPath string, fileID int64, sizeType, mode string) string {
+	// Generate thumbnail filename based on file ID, hash, size, and mode (fit keeps the legacy name)
+	hash := fmt.Sprintf("%x", md5.Sum([]byte(originalPath)))
+	thumbFilename := fmt.Sprintf("%d_%s_%s.jpg", fileID, hash[:8], sizeType)
+	if mode == ThumbnailModeCover {
+		thumbFilename = fmt.Sprintf("%d_%s_%s_%s.jpg", fileID, hash[:8], sizeType, mode)
+	}
+	return ts.shardedPath(sizeType, hash, thumbFilename)
+}
+
+// HasThumbnail reports whether a thumbnail is cached, without generating it or hashing
+// the file. contentHash is the file's stored content hash, if any.
+func (ts *ThumbnailService) HasThumbnail(originalPath string, fileID int64, contentHash, sizeType, mode string) bool {
+	if !IsValidThumbnailMode(mode) {
+		mode = ThumbnailModeFit
+	}
+
+	thumbPath := ts.pathThumbnailPath(originalPath, fileID, sizeType, mode)
+	if contentHash != "" && ts.keyedByContent(originalPath) {
+		thumbPath = ts.contentThumbnailPath(contentHash, sizeType, mode)
+	}
+
+	_, err := os.Stat(thumbPath)
+	return err == nil
+}
+
+// InvalidateThumbnail deletes a file's cached thumbnails of a size (every size when sizeType
+// is empty) in both modes, so the next request regenerates them. contentHash is the file's
+// stored content hash, if any; a content-keyed thumbnail is shared by files with the same
+// content. Returns the number of thumbnails removed.
+func (ts *ThumbnailService) InvalidateThumbnail(originalPath string, fileID int64, contentHash, sizeType string) (int, error) {
+	sizes := []string{sizeType}
+	if sizeType == "" {
+		sizes = sizes[:0]
+		for name := range ThumbnailSizes {
+			sizes = append(sizes, name)
+		}
+	}
+
+	removed := 0
+	for _, size := range sizes {
+		for _, mode := range []string{ThumbnailModeFit, ThumbnailModeCover} {
+			paths := []string{ts.pathThumbnailPath(originalPath, fileID, size, mode)}
+			if contentHash != "" {
+				paths = append(paths, ts.contentThumbnailPath(contentHash, size, mode))
+			}
+			for _, path := range paths {
+				err := os.Remove(path)
+				if err == nil {
+					removed++
+				} else if !os.IsNotExist(err) {
+					return removed, fmt.Errorf("failed to remove thumbnail: %w", err)
+				}
+			}
+		}
+	}
+	return removed, nil
+}
+
+// prefetchWorkers bounds the number of thumbnails generated concurrently by Prefetch
+const prefetchWorkers = 4
+
+// Prefetch generates thumbnails for the given files (file ID -> original path) using a
+// bounded worker pool. Returns the IDs whose thumbnail is ready and the errors of the others.
+func (ts *ThumbnailService) Prefetch(paths map[int64]string, sizeType, mode string) ([]int64, map[int64]error) {
+	type result struct {
+		fileID int64
+		err    error
+	}
+
+	jobs := make(chan int64)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < prefetchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fileID := range jobs {
+				_, err := ts.GetThumbnail(paths[fileID], fileID, sizeType, mode)
+				results <- result{fileID: fileID, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for fileID := range paths {
+			jobs <- fileID
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	ready := []int64{}
+	failed := make(map[int64]error)
+	for r := range results {
+		if r.err != nil {
+			failed[r.fileID] = r.err
+			continue
+		}
+		ready = append(ready, r.fileID)
+	}
+
+	return ready, failed
+}
+
+// MigrateFlatThumbnails moves thumbnails from the legacy flat layout in thumbsDir
+// into their sharded subdirectories. Returns the number of thumbnails moved.
+func (ts *ThumbnailService) MigrateFlatThumbnails() (int, error) {
+	entries, err := os.ReadDir(ts.thumbsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	moved := 0
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".jpg" {
+			continue
+		}
+
+		// Legacy filenames look like <fileID>_<hash8>_<size>.jpg
+		parts := strings.Split(strings.TrimSuffix(entry.Name(), ".jpg"), "_")
+		if len(parts) != 3 || len(parts[1]) != 8 {
+			continue
+		}
+		if _, ok := ThumbnailSizes[parts[2]]; !ok {
+			continue
+		}
+
+		oldPath := filepath.Join(ts.thumbsDir, entry.Name())
+		newPath := ts.shardedPath(parts[2], parts[1], entry.Name())
+		if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+			log.Printf("Failed to create thumbnail directory for %s: %v", entry.Name(), err)
+			continue
+		}
+		if err := moveFile(oldPath, newPath); err != nil {
+			log.Printf("Failed to migrate thumbnail %s: %v", entry.Name(), err)
+			continue
+		}
+		moved++
+	}
+
+	return moved, nil
+}
+
+// moveFile renames a file, falling back to copy+remove across filesystems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
 	if err != nil {
-		return fmt.Errorf("failed to open image: %w", err)
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	return os.Remove(src)
+}
+
+// generateThumbnail creates a thumbnail from an image
+func (ts *ThumbnailService) generateThumbnail(srcPath, dstPath string, width, height int, mode string) error {
+	// Refuse to fully decode images above the pixel cap
+	if err := ts.checkSourceSize(srcPath); err != nil {
+		return err
+	}
+
+	// Open source image, converting HEIC through the external converter
+	var src image.Image
+	var err error
+	if isHEIC(srcPath) {
+		src, err = ts.decodeHEIC(srcPath)
+		if err != nil {
+			return err
+		}
+	} else {
+		src, err = imaging.Open(srcPath)
+		if err != nil {
+			return fmt.Errorf("failed to open image: %w", err)
+		}
 	}
 
 	// Resize image to thumbnail size while maintaining aspect ratio
-	thumb := imaging.Fit(src, width, height, imaging.Lanczos)
+	var thumb image.Image
+	if mode == ThumbnailModeCover {
+		// Crop to fill the whole box for uniform grid tiles
+		thumb = imaging.Fill(src, width, height, imaging.Center, imaging.Lanczos)
+	} else {
+		thumb = imaging.Fit(src, width, height, imaging.Lanczos)
+	}
 
-	// Save thumbnail
-	if err := imaging.Save(thumb, dstPath, imaging.JPEGQuality(85)); err != nil {
+	// Files with identical content share one thumbnail, so another request
+	// may be reading or writing dstPath concurrently
+	return saveThumbnailJPEG(ts.flatten(thumb), dstPath)
+}
+
+// saveThumbnailJPEG writes img to a temp file next to dstPath and renames it into
+// place, so readers never see a partially written thumbnail
+func saveThumbnailJPEG(img image.Image, dstPath string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(dstPath), "thumb-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save thumbnail: %w", err)
+	}
+	tmpPath := tmp.Name()
+	err = imaging.Encode(tmp, img, imaging.JPEG, imaging.JPEGQuality(85))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save thumbnail: %w", err)
+	}
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		os.Remove(tmpPath)
 		return fmt.Errorf("failed to save thumbnail: %w", err)
 	}
 
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// writeFilledPNG saves a 64x64 PNG filled with c
+func writeFilledPNG(t *testing.T, path string, c color.NRGBA) {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// nearChannel reports whether two color channels match within JPEG compression error
+func nearChannel(a, b uint8) bool {
+	d := int(a) - int(b)
+	return d > -8 && d < 8
+}
+
+func TestThumbnailBackground(t *testing.T) {
+	dir := t.TempDir()
+	transparent := filepath.Join(dir, "transparent.png")
+	writeFilledPNG(t, transparent, color.NRGBA{})
+	opaque := filepath.Join(dir, "opaque.png")
+	writeFilledPNG(t, opaque, color.NRGBA{R: 0x20, G: 0x40, B: 0xc0, A: 0xff})
+
+	cases := []struct {
+		name       string
+		background string
+		src        string
+		want       color.NRGBA
+	}{
+		{"default background", "", transparent, color.NRGBA{R: 0xff, G: 0xff, B: 0xff}},
+		{"configured background", "#ff8000", transparent, color.NRGBA{R: 0xff, G: 0x80, B: 0x00}},
+		{"opaque image", "#ff8000", opaque, color.NRGBA{R: 0x20, G: 0x40, B: 0xc0}},
+	}
+	for i, tc := range cases {
+		ts := NewThumbnailService(t.TempDir())
+		if tc.background != "" {
+			if err := ts.SetBackground(tc.background); err != nil {
+				t.Fatalf("%s: set background: %v", tc.name, err)
+			}
+		}
+		thumbPath, err := ts.GetThumbnail(tc.src, int64(i+1), "small", ThumbnailModeFit)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		thumb, err := imaging.Open(thumbPath)
+		if err != nil {
+			t.Fatalf("%s: open thumbnail: %v", tc.name, err)
+		}
+		got := color.NRGBAModel.Convert(thumb.At(thumb.Bounds().Dx()/2, thumb.Bounds().Dy()/2)).(color.NRGBA)
+		if !nearChannel(got.R, tc.want.R) || !nearChannel(got.G, tc.want.G) || !nearChannel(got.B, tc.want.B) {
+			t.Errorf("%s: center pixel %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSetBackgroundRejectsInvalidColors(t *testing.T) {
+	ts := NewThumbnailService(t.TempDir())
+	for _, hex := range []string{"", "ffffff", "#fff", "#gggggg", "#ffffff00"} {
+		if err := ts.SetBackground(hex); !errors.Is(err, ErrInvalidBackground) {
+			t.Errorf("SetBackground(%q) = %v, want ErrInvalidBackground", hex, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetMaxConcurrentGenerations(t *testing.T) {
+	ts := NewThumbnailService(t.TempDir())
+	if got := cap(ts.generateSlots); got != runtime.NumCPU() {
+		t.Errorf("default limit %d, want NumCPU (%d)", got, runtime.NumCPU())
+	}
+	for n, want := range map[int]int{3: 3, 0: runtime.NumCPU(), -1: runtime.NumCPU()} {
+		ts.SetMaxConcurrentGenerations(n)
+		if got := cap(ts.generateSlots); got != want {
+			t.Errorf("SetMaxConcurrentGenerations(%d): limit %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestThumbnailGenerationWaitsForSlot(t *testing.T) {
+	ts := NewThumbnailService(t.TempDir())
+	ts.SetMaxConcurrentGenerations(1)
+	dir := t.TempDir()
+	cached := filepath.Join(dir, "cached.png")
+	pending := filepath.Join(dir, "pending.png")
+	writeTestImage(t, cached, 40, 30)
+	writeTestImage(t, pending, 40, 30)
+	if _, err := ts.GetThumbnail(cached, 1, "small", ThumbnailModeFit); err != nil {
+		t.Fatalf("generate cached thumbnail: %v", err)
+	}
+
+	// Occupy the only slot, as a long running generation would
+	ts.generateSlots <- struct{}{}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := ts.GetThumbnail(pending, 2, "small", ThumbnailModeFit)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("generation ran without a free slot (err %v)", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Cached thumbnails are served without waiting
+	if _, err := ts.GetThumbnail(cached, 1, "small", ThumbnailModeFit); err != nil {
+		t.Fatalf("cached thumbnail while slots are busy: %v", err)
+	}
+
+	<-ts.generateSlots
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("queued generation: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued generation never ran after the slot was freed")
+	}
+}
+
+func TestThumbnailGenerationBurst(t *testing.T) {
+	const limit, requests = 2, 12
+	ts := NewThumbnailService(t.TempDir())
+	ts.SetMaxConcurrentGenerations(limit)
+	dir := t.TempDir()
+	for i := 0; i < requests; i++ {
+		writeTestImage(t, filepath.Join(dir, fmt.Sprintf("%02d.png", i)), 400, 300)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			thumbPath, err := ts.GetThumbnail(filepath.Join(dir, fmt.Sprintf("%02d.png", i)), int64(i+1), "medium", ThumbnailModeFit)
+			if err == nil {
+				_, err = os.Stat(thumbPath)
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("burst request failed: %v", err)
+		}
+	}
+	if len(ts.generateSlots) != 0 {
+		t.Errorf("%d slots still held after the burst", len(ts.generateSlots))
+	}
+}
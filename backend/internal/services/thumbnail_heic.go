@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/disintegration/imaging"
+)
+
+// ErrHEICUnsupported is returned for HEIC/HEIF images when no converter is configured
+var ErrHEICUnsupported = errors.New("HEIC decoding not configured")
+
+// heicConvertTimeout bounds a single external HEIC conversion
+const heicConvertTimeout = 60 * time.Second
+
+// heicExtensions are the HEIF container formats the Go image decoders can't read
+var heicExtensions = map[string]bool{".heic": true, ".heif": true}
+
+// isHEIC reports whether path is a HEIC/HEIF image by extension
+func isHEIC(path string) bool {
+	return heicExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// SetHEICConverter decodes HEIC/HEIF images by running an external command that
+// converts {input} into a JPEG or PNG at {output}, e.g. "heif-convert -q 95 {input} {output}".
+// An empty command disables HEIC thumbnails; those files get a placeholder.
+func (ts *ThumbnailService) SetHEICConverter(command string) {
+	ts.heicConverter = strings.Fields(command)
+}
+
+// decodeHEIC converts a HEIC image with the configured command and decodes the result
+func (ts *ThumbnailService) decodeHEIC(srcPath string) (image.Image, error) {
+	if len(ts.heicConverter) == 0 {
+		return nil, ErrHEICUnsupported
+	}
+
+	tmp, err := os.CreateTemp("", "heic-*.jpg")
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert HEIC: %w", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpPath)
+
+	args := make([]string, len(ts.heicConverter))
+	for i, arg := range ts.heicConverter {
+		arg = strings.ReplaceAll(arg, "{input}", srcPath)
+		args[i] = strings.ReplaceAll(arg, "{output}", tmpPath)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), heicConvertTimeout)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to convert HEIC: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	// The HEIC header itself is not parsed by checkSourceSize, so apply the cap to the converted image
+	if err := ts.checkSourceSize(tmpPath); err != nil {
+		return nil, err
+	}
+
+	img, err := imaging.Open(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open converted HEIC: %w", err)
+	}
+	return img, nil
+}
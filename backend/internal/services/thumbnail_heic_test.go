@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestHEICThumbnail stands in "cp" for a real converter: the fixture is a PNG with a
+// .heic name, so copying it to {output} is a faithful conversion.
+func TestHEICThumbnail(t *testing.T) {
+	if _, err := exec.LookPath("cp"); err != nil {
+		t.Skip("cp not available to stand in for a HEIC converter")
+	}
+	dir := t.TempDir()
+	png := filepath.Join(dir, "IMG_0001.png")
+	writeTestImage(t, png, 80, 40)
+	src := filepath.Join(dir, "IMG_0001.HEIC")
+	if err := os.Rename(png, src); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name      string
+		command   string
+		maxPixels int64
+		wantErr   error
+		wantThumb bool
+	}{
+		{"no converter", "", 0, ErrHEICUnsupported, false},
+		{"converter", "cp {input} {output}", 0, nil, true},
+		{"failing converter", "false {input} {output}", 0, nil, false},
+		{"converted image over the pixel cap", "cp {input} {output}", 1000, ErrSourceTooLarge, false},
+	}
+	for i, tc := range cases {
+		ts := NewThumbnailService(t.TempDir())
+		ts.SetHEICConverter(tc.command)
+		if tc.maxPixels > 0 {
+			ts.SetMaxSourcePixels(tc.maxPixels)
+		}
+
+		thumbPath, err := ts.GetThumbnail(src, int64(i+1), "small", ThumbnailModeFit)
+		if tc.wantThumb {
+			if err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+			} else if !thumbnailLandscape(t, thumbPath) {
+				t.Errorf("%s: thumbnail lost the source orientation", tc.name)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", tc.name)
+		} else if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.wantErr)
+		} else if tc.wantErr == nil && errors.Is(err, ErrHEICUnsupported) {
+			t.Errorf("%s: conversion failure reported as unsupported", tc.name)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInvalidateThumbnail(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "a.png")
+	writeTestImage(t, src, 80, 60)
+	ts := NewThumbnailService(t.TempDir())
+
+	small, err := ts.GetThumbnail(src, 1, "small", ThumbnailModeFit)
+	if err != nil {
+		t.Fatalf("small thumbnail: %v", err)
+	}
+	medium, err := ts.GetThumbnail(src, 1, "medium", ThumbnailModeCover)
+	if err != nil {
+		t.Fatalf("medium thumbnail: %v", err)
+	}
+	// Interrupted write: the cached file is served as is until invalidated
+	if err := os.WriteFile(small, []byte("truncated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	shared := ts.contentThumbnailPath("abc123", "small", ThumbnailModeFit)
+	os.MkdirAll(filepath.Dir(shared), 0755)
+	if err := os.WriteFile(shared, []byte("truncated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := ts.InvalidateThumbnail(src, 1, "abc123", "small")
+	if err != nil || removed != 2 {
+		t.Fatalf("invalidate small: removed %d, %v; want the path- and content-keyed thumbnails", removed, err)
+	}
+	for _, path := range []string{small, shared} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s still cached (%v)", path, err)
+		}
+	}
+	if _, err := os.Stat(medium); err != nil {
+		t.Errorf("other size was invalidated too: %v", err)
+	}
+
+	regenerated, err := ts.GetThumbnail(src, 1, "small", ThumbnailModeFit)
+	if err != nil {
+		t.Fatalf("regenerate: %v", err)
+	}
+	if _, _, err := GetDimensions(regenerated); err != nil {
+		t.Errorf("regenerated thumbnail is not an image: %v", err)
+	}
+
+	removed, err = ts.InvalidateThumbnail(src, 1, "", "")
+	if err != nil || removed != 2 {
+		t.Errorf("invalidate every size: removed %d, %v; want 2", removed, err)
+	}
+	if removed, err := ts.InvalidateThumbnail(src, 1, "", ""); err != nil || removed != 0 {
+		t.Errorf("nothing cached: removed %d, %v", removed, err)
+	}
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestThumbnailShardedLayout(t *testing.T) {
+	thumbsDir := t.TempDir()
+	largeDir := t.TempDir()
+	ts := NewThumbnailService(thumbsDir)
+	ts.SetSizeDir("large", largeDir)
+
+	src := filepath.Join(t.TempDir(), "photo.jpg")
+	writeTestImage(t, src, 64, 48)
+
+	for size, base := range map[string]string{"small": thumbsDir, "large": largeDir} {
+		thumbPath, err := ts.GetThumbnail(src, 7, size, "")
+		if err != nil {
+			t.Fatalf("%s: generate: %v", size, err)
+		}
+		rel, err := filepath.Rel(base, thumbPath)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			t.Fatalf("%s: thumbnail %s is outside %s", size, thumbPath, base)
+		}
+
+		// <base>/ab/cd/<fileID>_abcd1234_<size>.jpg
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 3 || len(parts[0]) != 2 || len(parts[1]) != 2 {
+			t.Fatalf("%s: unexpected layout %s", size, rel)
+		}
+		if !strings.HasPrefix(parts[2], "7_"+parts[0]+parts[1]) || !strings.HasSuffix(parts[2], "_"+size+".jpg") {
+			t.Errorf("%s: thumbnail name %s doesn't match its shard", size, parts[2])
+		}
+	}
+}
+
+func TestMigrateFlatThumbnails(t *testing.T) {
+	thumbsDir := t.TempDir()
+	ts := NewThumbnailService(thumbsDir)
+
+	legacy := []string{"1_abcd1234_small.jpg", "2_0123abcd_large.jpg"}
+	ignored := []string{"3_abcd1234_huge.jpg", "4_abc_small.jpg", "notes.txt", "1_abcd1234_small_cover_x.jpg"}
+	for _, name := range append(legacy, ignored...) {
+		if err := os.WriteFile(filepath.Join(thumbsDir, name), []byte("jpg"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	moved, err := ts.MigrateFlatThumbnails()
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if moved != len(legacy) {
+		t.Errorf("moved %d thumbnails, want %d", moved, len(legacy))
+	}
+
+	for _, name := range legacy {
+		hash := strings.Split(name, "_")[1]
+		if _, err := os.Stat(filepath.Join(thumbsDir, hash[0:2], hash[2:4], name)); err != nil {
+			t.Errorf("%s not in its shard: %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(thumbsDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s still in the flat directory", name)
+		}
+	}
+	for _, name := range ignored {
+		if _, err := os.Stat(filepath.Join(thumbsDir, name)); err != nil {
+			t.Errorf("%s should have been left alone: %v", name, err)
+		}
+	}
+
+	// A second run finds nothing left to move
+	if moved, err := ts.MigrateFlatThumbnails(); err != nil || moved != 0 {
+		t.Errorf("second run: moved %d, err %v", moved, err)
+	}
+
+	// A missing thumbnail directory is not an error
+	if moved, err := NewThumbnailService(filepath.Join(thumbsDir, "missing")).MigrateFlatThumbnails(); err != nil || moved != 0 {
+		t.Errorf("missing directory: moved %d, err %v", moved, err)
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestThumbnailModes(t *testing.T) {
+	ts := NewThumbnailService(t.TempDir())
+
+	src := filepath.Join(t.TempDir(), "wide.jpg")
+	writeTestImage(t, src, 600, 300)
+
+	cases := []struct {
+		mode          string
+		width, height int
+	}{
+		{ThumbnailModeFit, 300, 150},
+		{ThumbnailModeCover, 300, 300},
+		{"", 300, 150},
+		{"bogus", 300, 150},
+	}
+	paths := map[string]string{}
+	for _, tc := range cases {
+		thumbPath, err := ts.GetThumbnail(src, 1, "small", tc.mode)
+		if err != nil {
+			t.Fatalf("%q: generate: %v", tc.mode, err)
+		}
+		img, err := imaging.Open(thumbPath)
+		if err != nil {
+			t.Fatalf("%q: open thumbnail: %v", tc.mode, err)
+		}
+		if b := img.Bounds(); b.Dx() != tc.width || b.Dy() != tc.height {
+			t.Errorf("%q: got %dx%d, want %dx%d", tc.mode, b.Dx(), b.Dy(), tc.width, tc.height)
+		}
+		paths[tc.mode] = thumbPath
+	}
+
+	// Each mode has its own cache entry; unknown or empty modes share the fit one
+	if paths[ThumbnailModeFit] == paths[ThumbnailModeCover] {
+		t.Error("fit and cover thumbnails share a cache path")
+	}
+	if paths[""] != paths[ThumbnailModeFit] || paths["bogus"] != paths[ThumbnailModeFit] {
+		t.Error("default mode should reuse the fit thumbnail")
+	}
+}
+
+func TestGetThumbnailMode(t *testing.T) {
+	db := newTestDB(t)
+	settings := NewSettingsService(db)
+
+	for value, want := range map[string]string{"": ThumbnailModeFit, "cover": ThumbnailModeCover, "fit": ThumbnailModeFit, "stretch": ThumbnailModeFit} {
+		if value != "" {
+			if err := settings.SetSetting("thumbnail_mode", value); err != nil {
+				t.Fatalf("set mode: %v", err)
+			}
+		}
+		got, err := settings.GetThumbnailMode()
+		if err != nil || got != want {
+			t.Errorf("setting %q: got %q (%v), want %q", value, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// Placeholder kinds served when a file has no thumbnail
+const (
+	PlaceholderPhoto   = "photo"
+	PlaceholderVideo   = "video"
+	PlaceholderRaw     = "raw"
+	PlaceholderUnknown = "unknown"
+)
+
+// rawExtensions are camera RAW formats the image decoders can't read
+var rawExtensions = map[string]bool{
+	".cr2": true, ".cr3": true, ".nef": true, ".arw": true, ".dng": true,
+	".raf": true, ".orf": true, ".rw2": true, ".pef": true, ".srw": true,
+}
+
+// placeholderColors give each built-in placeholder kind a distinct tint
+var placeholderColors = map[string]color.NRGBA{
+	PlaceholderPhoto:   {R: 0x6b, G: 0x8e, B: 0xb5, A: 0xff},
+	PlaceholderVideo:   {R: 0x8e, G: 0x6b, B: 0xb5, A: 0xff},
+	PlaceholderRaw:     {R: 0xb5, G: 0x92, B: 0x6b, A: 0xff},
+	PlaceholderUnknown: {R: 0x90, G: 0x90, B: 0x90, A: 0xff},
+}
+
+// PlaceholderKind picks the placeholder for a file from its file_type and extension
+func PlaceholderKind(fileType, path string) string {
+	if rawExtensions[strings.ToLower(filepath.Ext(path))] {
+		return PlaceholderRaw
+	}
+	switch fileType {
+	case "image":
+		return PlaceholderPhoto
+	case "video":
+		return PlaceholderVideo
+	}
+	return PlaceholderUnknown
+}
+
+// SetPlaceholderDir uses <dir>/<kind>.png (or .jpg) as placeholder images instead of
+// the built-in ones. Missing kinds fall back to the built-in placeholder.
+func (ts *ThumbnailService) SetPlaceholderDir(dir string) {
+	ts.placeholderDir = dir
+}
+
+// GetPlaceholder returns the path to a placeholder thumbnail for a kind and size,
+// rendering and caching it on first use
+func (ts *ThumbnailService) GetPlaceholder(kind, sizeType string) (string, error) {
+	if _, ok := placeholderColors[kind]; !ok {
+		kind = PlaceholderUnknown
+	}
+	size, ok := ThumbnailSizes[sizeType]
+	if !ok {
+		sizeType = "small"
+		size = ThumbnailSizes["small"]
+	}
+
+	dstPath := filepath.Join(ts.thumbsDir, "placeholders", fmt.Sprintf("%s_%s.jpg", kind, sizeType))
+
+	custom := ts.customPlaceholder(kind)
+	if info, err := os.Stat(dstPath); err == nil {
+		// Re-render when the configured image changed
+		if custom == "" {
+			return dstPath, nil
+		}
+		if customInfo, err := os.Stat(custom); err != nil || !customInfo.ModTime().After(info.ModTime()) {
+			return dstPath, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create placeholder directory: %w", err)
+	}
+
+	var img image.Image
+	if custom != "" {
+		src, err := imaging.Open(custom)
+		if err != nil {
+			return "", fmt.Errorf("failed to open placeholder %s: %w", custom, err)
+		}
+		img = imaging.Fit(src, size.Width, size.Height, imaging.Lanczos)
+	} else {
+		img = builtinPlaceholder(kind, size.Width, size.Height)
+	}
+
+	if err := saveThumbnailJPEG(img, dstPath); err != nil {
+		return "", err
+	}
+	return dstPath, nil
+}
+
+// customPlaceholder returns the configured placeholder image for kind, if any
+func (ts *ThumbnailService) customPlaceholder(kind string) string {
+	if ts.placeholderDir == "" {
+		return ""
+	}
+	for _, ext := range []string{".png", ".jpg", ".jpeg"} {
+		path := filepath.Join(ts.placeholderDir, kind+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// builtinPlaceholder draws a light tile with a tinted square in the middle
+func builtinPlaceholder(kind string, width, height int) image.Image {
+	tile := imaging.New(width, height, color.NRGBA{R: 0xe5, G: 0xe7, B: 0xeb, A: 0xff})
+	side := width
+	if height < side {
+		side = height
+	}
+	side /= 3
+	mark := imaging.New(side, side, placeholderColors[kind])
+	return imaging.PasteCenter(tile, mark)
+}
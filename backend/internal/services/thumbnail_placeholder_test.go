@@ -0,0 +1,104 @@
+package services
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestPlaceholderKind(t *testing.T) {
+	cases := []struct {
+		fileType, path, want string
+	}{
+		{"image", "a.jpg", PlaceholderPhoto},
+		{"image", "IMG_0001.CR2", PlaceholderRaw},
+		{"", "shot.nef", PlaceholderRaw},
+		{"video", "clip.mp4", PlaceholderVideo},
+		{"", "notes.txt", PlaceholderUnknown},
+	}
+	for _, tc := range cases {
+		if got := PlaceholderKind(tc.fileType, tc.path); got != tc.want {
+			t.Errorf("PlaceholderKind(%q, %q) = %q, want %q", tc.fileType, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestGetPlaceholder(t *testing.T) {
+	ts := NewThumbnailService(t.TempDir())
+
+	path, err := ts.GetPlaceholder(PlaceholderVideo, "medium")
+	if err != nil {
+		t.Fatalf("GetPlaceholder: %v", err)
+	}
+	img, err := imaging.Open(path)
+	if err != nil {
+		t.Fatalf("open placeholder: %v", err)
+	}
+	size := ThumbnailSizes["medium"]
+	if b := img.Bounds(); b.Dx() != size.Width || b.Dy() != size.Height {
+		t.Errorf("placeholder is %dx%d, want %dx%d", b.Dx(), b.Dy(), size.Width, size.Height)
+	}
+
+	// Cached on disk and reused
+	again, _ := ts.GetPlaceholder(PlaceholderVideo, "medium")
+	if again != path {
+		t.Errorf("second call returned %s, want cached %s", again, path)
+	}
+
+	// Unknown kinds and sizes fall back to the generic small placeholder
+	fallback, err := ts.GetPlaceholder("hologram", "huge")
+	if err != nil {
+		t.Fatalf("GetPlaceholder fallback: %v", err)
+	}
+	if filepath.Base(fallback) != "unknown_small.jpg" {
+		t.Errorf("fallback placeholder = %s", fallback)
+	}
+}
+
+func TestCustomPlaceholder(t *testing.T) {
+	ts := NewThumbnailService(t.TempDir())
+	custom := t.TempDir()
+	ts.SetPlaceholderDir(custom)
+
+	red := imaging.New(40, 40, color.NRGBA{R: 0xff, A: 0xff})
+	if err := imaging.Save(red, filepath.Join(custom, "raw.png")); err != nil {
+		t.Fatalf("save custom placeholder: %v", err)
+	}
+
+	centerColor := func(kind string) color.NRGBA {
+		t.Helper()
+		path, err := ts.GetPlaceholder(kind, "small")
+		if err != nil {
+			t.Fatalf("GetPlaceholder(%s): %v", kind, err)
+		}
+		img, err := imaging.Open(path)
+		if err != nil {
+			t.Fatalf("open placeholder: %v", err)
+		}
+		b := img.Bounds()
+		return color.NRGBAModel.Convert(img.At(b.Dx()/2, b.Dy()/2)).(color.NRGBA)
+	}
+
+	if c := centerColor(PlaceholderRaw); c.R < 0xe0 || c.G > 0x20 {
+		t.Errorf("custom raw placeholder center = %v, want red", c)
+	}
+	// Kinds without a custom image keep the built-in one
+	if c := centerColor(PlaceholderPhoto); c.R > 0x80 || c.B < 0x90 {
+		t.Errorf("photo placeholder center = %v, want the built-in tint", c)
+	}
+
+	// Replacing the custom image re-renders the cached placeholder
+	blue := imaging.New(40, 40, color.NRGBA{B: 0xff, A: 0xff})
+	if err := imaging.Save(blue, filepath.Join(custom, "raw.png")); err != nil {
+		t.Fatalf("replace custom placeholder: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(custom, "raw.png"), later, later)
+	if c := centerColor(PlaceholderRaw); c.B < 0xe0 || c.R > 0x20 {
+		t.Errorf("replaced raw placeholder center = %v, want blue", c)
+	}
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestPrefetch(t *testing.T) {
+	dir := t.TempDir()
+	ts := NewThumbnailService(t.TempDir())
+
+	paths := map[int64]string{}
+	for id := int64(1); id <= 6; id++ {
+		path := filepath.Join(dir, fmt.Sprintf("img%d.png", id))
+		writeTestImage(t, path, 40, 30)
+		paths[id] = path
+	}
+	paths[7] = filepath.Join(dir, "missing.jpg")
+	broken := filepath.Join(dir, "broken.jpg")
+	if err := os.WriteFile(broken, []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	paths[8] = broken
+
+	ready, failed := ts.Prefetch(paths, "small", ThumbnailModeFit)
+	sort.Slice(ready, func(i, j int) bool { return ready[i] < ready[j] })
+	if len(ready) != 6 || ready[0] != 1 || ready[5] != 6 {
+		t.Errorf("ready = %v, want 1..6", ready)
+	}
+	if len(failed) != 2 || failed[7] == nil || failed[8] == nil {
+		t.Errorf("failed = %v, want errors for 7 and 8", failed)
+	}
+
+	for id := int64(1); id <= 6; id++ {
+		if !ts.HasThumbnail(paths[id], id, "", "small", ThumbnailModeFit) {
+			t.Errorf("no cached thumbnail for file %d", id)
+		}
+	}
+}
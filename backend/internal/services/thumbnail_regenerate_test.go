@@ -0,0 +1,147 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// editInPlace rewrites path as a width x height image with the given mtime
+func editInPlace(t *testing.T, path string, width, height int, modTime time.Time) {
+	t.Helper()
+	writeTestImage(t, path, width, height)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("set mtime: %v", err)
+	}
+}
+
+// thumbnailLandscape reports whether the thumbnail at path is wider than tall
+func thumbnailLandscape(t *testing.T, path string) bool {
+	t.Helper()
+	width, height, err := GetDimensions(path)
+	if err != nil {
+		t.Fatalf("read thumbnail: %v", err)
+	}
+	return width > height
+}
+
+func TestThumbnailRegeneratesWhenSourceChanges(t *testing.T) {
+	db := newTestDB(t)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	file := seedFile(t, db, folder, "a.png", "image")
+
+	src := filepath.Join(t.TempDir(), "a.png")
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	editInPlace(t, src, 80, 40, base)
+
+	ts := NewThumbnailService(t.TempDir())
+	ts.SetContentHashes(NewContentHashService(db))
+	ts.SetRegenerateOnChange(true)
+	thumb, err := ts.GetThumbnail(src, file, "small", ThumbnailModeFit)
+	if err != nil {
+		t.Fatalf("GetThumbnail: %v", err)
+	}
+	var storedMtime int64
+	db.QueryRow("SELECT content_hash_mtime FROM files WHERE id = ?", file).Scan(&storedMtime)
+	if storedMtime != base.UnixNano() {
+		t.Errorf("stored source mtime %d, want %d", storedMtime, base.UnixNano())
+	}
+
+	// Edited in place, newer and then restored from a backup with an older mtime
+	for _, tc := range []struct {
+		name      string
+		w, h      int
+		modTime   time.Time
+		landscape bool
+	}{
+		{"newer", 40, 80, base.Add(time.Hour), false},
+		{"older", 80, 40, base.Add(-24 * time.Hour), true},
+	} {
+		editInPlace(t, src, tc.w, tc.h, tc.modTime)
+		got, err := ts.GetThumbnail(src, file, "small", ThumbnailModeFit)
+		if err != nil {
+			t.Fatalf("%s: GetThumbnail: %v", tc.name, err)
+		}
+		if thumbnailLandscape(t, got) != tc.landscape {
+			t.Errorf("%s: stale thumbnail served", tc.name)
+		}
+	}
+
+	// The original content is back, and so is its thumbnail
+	if got, _ := ts.GetThumbnail(src, file, "small", ThumbnailModeFit); got != thumb {
+		t.Errorf("restored file got %s, want its original thumbnail %s", got, thumb)
+	}
+}
+
+func TestThumbnailKeptWithoutRegenerateOnChange(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "a.png")
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	editInPlace(t, src, 80, 40, base)
+
+	ts := NewThumbnailService(t.TempDir())
+	if _, err := ts.GetThumbnail(src, 1, "small", ThumbnailModeFit); err != nil {
+		t.Fatalf("GetThumbnail: %v", err)
+	}
+	editInPlace(t, src, 40, 80, base.Add(time.Hour))
+	thumb, err := ts.GetThumbnail(src, 1, "small", ThumbnailModeFit)
+	if err != nil {
+		t.Fatalf("GetThumbnail: %v", err)
+	}
+	if !thumbnailLandscape(t, thumb) {
+		t.Error("thumbnail regenerated although regeneration on change is off")
+	}
+}
+
+func TestGetOrComputeCurrent(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewContentHashService(db)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	file := seedFile(t, db, folder, "a.txt", "other")
+
+	path := filepath.Join(t.TempDir(), "a.txt")
+	os.WriteFile(path, []byte("hello"), 0644)
+	const hello = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" // sha256("hello")
+	first := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	storedMtime := func() int64 {
+		var mtime int64
+		db.QueryRow("SELECT COALESCE(content_hash_mtime, 0) FROM files WHERE id = ?", file).Scan(&mtime)
+		return mtime
+	}
+
+	// A hash stored before mtimes were recorded is kept and adopts the mtime
+	mustExec(t, db, "UPDATE files SET content_hash = ? WHERE id = ?", hello, file)
+	os.WriteFile(path, []byte("changed"), 0644)
+	if hash, err := svc.GetOrComputeCurrent(file, path, first); err != nil || hash != hello {
+		t.Errorf("legacy hash = %q, %v, want it kept", hash, err)
+	}
+	if storedMtime() != first.UnixNano() {
+		t.Errorf("stored mtime %d, want %d", storedMtime(), first.UnixNano())
+	}
+
+	// The same mtime returns the stored hash without reading the file
+	if hash, _ := svc.GetOrComputeCurrent(file, path, first); hash != hello {
+		t.Errorf("unchanged mtime rehashed: %q", hash)
+	}
+
+	// Any other mtime rehashes
+	later := first.Add(time.Minute)
+	hash, err := svc.GetOrComputeCurrent(file, path, later)
+	if err != nil {
+		t.Fatalf("GetOrComputeCurrent: %v", err)
+	}
+	if hash == hello {
+		t.Error("changed mtime kept the old hash")
+	}
+	if storedMtime() != later.UnixNano() {
+		t.Errorf("stored mtime %d, want %d", storedMtime(), later.UnixNano())
+	}
+
+	// GetOrCompute ignores mtimes
+	if got, _ := svc.GetOrCompute(file, path); got != hash {
+		t.Errorf("GetOrCompute = %q, want the stored %q", got, hash)
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestHasThumbnail(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.png")
+	writeTestImage(t, path, 64, 48)
+
+	ts := NewThumbnailService(t.TempDir())
+	if ts.HasThumbnail(path, 1, "", "small", ThumbnailModeFit) {
+		t.Fatal("thumbnail reported before it was generated")
+	}
+	if _, err := ts.GetThumbnail(path, 1, "small", ThumbnailModeFit); err != nil {
+		t.Fatalf("GetThumbnail: %v", err)
+	}
+	if !ts.HasThumbnail(path, 1, "", "small", ThumbnailModeFit) {
+		t.Error("generated thumbnail not reported")
+	}
+	if !ts.HasThumbnail(path, 1, "", "small", "bogus") {
+		t.Error("an unknown mode should fall back to fit")
+	}
+	if ts.HasThumbnail(path, 1, "", "medium", ThumbnailModeFit) {
+		t.Error("medium thumbnail reported, only small was generated")
+	}
+	if ts.HasThumbnail(path, 1, "", "small", ThumbnailModeCover) {
+		t.Error("cover thumbnail reported, only fit was generated")
+	}
+}
+
+func TestHasThumbnailByContentHash(t *testing.T) {
+	db := newTestDB(t)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	file := seedFile(t, db, folder, "a.png", "image")
+
+	path := filepath.Join(t.TempDir(), "a.png")
+	writeTestImage(t, path, 64, 48)
+	ts := NewThumbnailService(t.TempDir())
+	hashes := NewContentHashService(db)
+	ts.SetContentHashes(hashes)
+
+	if _, err := ts.GetThumbnail(path, file, "small", ThumbnailModeFit); err != nil {
+		t.Fatalf("GetThumbnail: %v", err)
+	}
+	hash, err := hashes.GetOrCompute(file, path)
+	if err != nil {
+		t.Fatalf("GetOrCompute: %v", err)
+	}
+
+	if !ts.HasThumbnail(path, file, hash, "small", ThumbnailModeFit) {
+		t.Error("content-keyed thumbnail not reported")
+	}
+	// Without a stored hash the path-keyed name is checked, which was never written
+	if ts.HasThumbnail(path, file, "", "small", ThumbnailModeFit) {
+		t.Error("path-keyed thumbnail reported for a content-keyed cache")
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestThumbnailSignature(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewShareService(db, nil)
+
+	exp := time.Now().Add(time.Hour).Unix()
+	sig, err := svc.SignThumbnail(42, "small", exp)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if err := svc.VerifyThumbnailSignature(42, "small", exp, sig); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+
+	past := time.Now().Add(-time.Minute).Unix()
+	expiredSig, err := svc.SignThumbnail(42, "small", past)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		fileID  int64
+		size    string
+		exp     int64
+		sig     string
+		wantErr error
+	}{
+		{"other file", 43, "small", exp, sig, ErrInvalidToken},
+		{"other size", 42, "large", exp, sig, ErrInvalidToken},
+		{"extended expiry", 42, "small", exp + 3600, sig, ErrInvalidToken},
+		{"altered signature", 42, "small", exp, sig + "x", ErrInvalidToken},
+		{"expired", 42, "small", past, expiredSig, ErrSignatureExpired},
+	}
+	for _, tc := range cases {
+		if err := svc.VerifyThumbnailSignature(tc.fileID, tc.size, tc.exp, tc.sig); !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.wantErr)
+		}
+	}
+
+	// Thumbnail signatures and share access tokens are not interchangeable
+	shareID := seedFileShare(t, svc)
+	token, err := svc.GenerateAccessToken(shareID)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	if _, _, err := svc.ValidateAccessToken(sig); err == nil {
+		t.Error("thumbnail signature accepted as an access token")
+	}
+	if err := svc.VerifyThumbnailSignature(42, "small", exp, token); err == nil {
+		t.Error("access token accepted as a thumbnail signature")
+	}
+
+	// Rotating the share token secret invalidates signed thumbnail URLs as well
+	if err := svc.RotateTokenSecret(); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if err := svc.VerifyThumbnailSignature(42, "small", exp, sig); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("signature after rotation: got %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// uploadPolicyKey is the system_settings key holding the upload MIME policy as JSON,
+// mapping MIME types or wildcards (e.g. "image/*") to a maximum size in bytes
+const uploadPolicyKey = "upload_mime_policy"
+
+// DefaultUploadPolicy applies when no policy is configured: images up to 50MB, videos up to 2GB
+var DefaultUploadPolicy = map[string]int64{
+	"image/*": 50 << 20,
+	"video/*": 2 << 30,
+}
+
+// extensionMIMETypes covers media types that neither content sniffing nor the system MIME table know reliably
+var extensionMIMETypes = map[string]string{
+	".heic": "image/heic",
+	".heif": "image/heif",
+	".mov":  "video/quicktime",
+	".m4v":  "video/x-m4v",
+	".mkv":  "video/x-matroska",
+	".avi":  "video/x-msvideo",
+	".tif":  "image/tiff",
+	".tiff": "image/tiff",
+	".webp": "image/webp",
+}
+
+// GetUploadPolicy returns the configured upload MIME policy, or the default policy
+func (s *SettingsService) GetUploadPolicy() (map[string]int64, error) {
+	setting, err := s.GetSetting(uploadPolicyKey)
+	if err != nil {
+		return nil, err
+	}
+	if setting == nil || setting.Value == "" {
+		return DefaultUploadPolicy, nil
+	}
+
+	var policy map[string]int64
+	if err := json.Unmarshal([]byte(setting.Value), &policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// DetectMIMEType determines a file's MIME type from its first bytes, falling back to the
+// extension when sniffing only yields a generic type
+func DetectMIMEType(head []byte, filename string) string {
+	sniffed := http.DetectContentType(head)
+	if i := strings.Index(sniffed, ";"); i >= 0 {
+		sniffed = sniffed[:i]
+	}
+	if sniffed != "application/octet-stream" && sniffed != "text/plain" {
+		return sniffed
+	}
+
+	if mimeType := MIMETypeByExtension(filename); mimeType != "" {
+		return mimeType
+	}
+	return sniffed
+}
+
+// MIMETypeByExtension returns the MIME type for a filename's extension, or "" if unknown
+func MIMETypeByExtension(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if mimeType, ok := extensionMIMETypes[ext]; ok {
+		return mimeType
+	}
+	mimeType := mime.TypeByExtension(ext)
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return mimeType
+}
+
+// ContentTypeForFile returns the Content-Type to serve a media file with. The extension
+// decides when it agrees with the stored file type ("image" or "video"); extension-less
+// or mislabeled files are identified by sniffing their first bytes.
+func ContentTypeForFile(path, fileType string) string {
+	byExtension := MIMETypeByExtension(path)
+	if byExtension != "" && (fileType == "" || strings.HasPrefix(byExtension, fileType+"/")) {
+		return byExtension
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if byExtension != "" {
+			return byExtension
+		}
+		return "application/octet-stream"
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	return DetectMIMEType(head[:n], path)
+}
+
+// MatchUploadPolicy returns the maximum allowed size for a MIME type.
+// Exact entries take precedence over wildcards like "image/*".
+func MatchUploadPolicy(policy map[string]int64, mimeType string) (int64, bool) {
+	if maxSize, ok := policy[mimeType]; ok {
+		return maxSize, true
+	}
+	if i := strings.Index(mimeType, "/"); i >= 0 {
+		if maxSize, ok := policy[mimeType[:i]+"/*"]; ok {
+			return maxSize, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,79 @@
+package services
+
+import "testing"
+
+func TestDetectMIMEType(t *testing.T) {
+	mp4 := append([]byte{0, 0, 0, 0x18}, []byte("ftypmp42\x00\x00\x00\x00mp42isom")...)
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+
+	cases := []struct {
+		name     string
+		head     []byte
+		filename string
+		want     string
+	}{
+		{"sniffed video", mp4, "clip.bin", "video/mp4"},
+		{"sniffed image", png, "photo.jpg", "image/png"},
+		{"extension fallback", []byte{0x01, 0x02, 0x03}, "clip.MOV", "video/quicktime"},
+		{"heic fallback", []byte{0x01, 0x02, 0x03}, "photo.heic", "image/heic"},
+		{"unknown", []byte{0x01, 0x02, 0x03}, "data.unknownext", "application/octet-stream"},
+	}
+	for _, tc := range cases {
+		if got := DetectMIMEType(tc.head, tc.filename); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMatchUploadPolicy(t *testing.T) {
+	policy := map[string]int64{
+		"image/*":   50,
+		"image/gif": 10,
+		"video/mp4": 100,
+	}
+	cases := []struct {
+		mimeType string
+		max      int64
+		allowed  bool
+	}{
+		{"image/jpeg", 50, true},
+		{"image/gif", 10, true},
+		{"video/mp4", 100, true},
+		{"video/quicktime", 0, false},
+		{"application/pdf", 0, false},
+	}
+	for _, tc := range cases {
+		max, allowed := MatchUploadPolicy(policy, tc.mimeType)
+		if max != tc.max || allowed != tc.allowed {
+			t.Errorf("%s: got (%d, %v), want (%d, %v)", tc.mimeType, max, allowed, tc.max, tc.allowed)
+		}
+	}
+}
+
+func TestGetUploadPolicy(t *testing.T) {
+	db := newTestDB(t)
+	settings := NewSettingsService(db)
+
+	policy, err := settings.GetUploadPolicy()
+	if err != nil {
+		t.Fatalf("default policy: %v", err)
+	}
+	if policy["image/*"] != 50<<20 || policy["video/*"] != 2<<30 {
+		t.Errorf("unexpected default policy: %v", policy)
+	}
+
+	if err := settings.SetSetting(uploadPolicyKey, `{"video/mp4": 1024}`); err != nil {
+		t.Fatalf("set policy: %v", err)
+	}
+	policy, err = settings.GetUploadPolicy()
+	if err != nil || len(policy) != 1 || policy["video/mp4"] != 1024 {
+		t.Errorf("configured policy: %v, %v", policy, err)
+	}
+
+	if err := settings.SetSetting(uploadPolicyKey, `not json`); err != nil {
+		t.Fatalf("set policy: %v", err)
+	}
+	if _, err := settings.GetUploadPolicy(); err == nil {
+		t.Error("expected an error for an invalid policy")
+	}
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// maxUserImportRows bounds a single CSV import
+const maxUserImportRows = 1000
+
+var ErrTooManyImportRows = errors.New("too many rows in import")
+
+// UserImportResult is the outcome of one CSV row
+type UserImportResult struct {
+	Line     int
+	Username string
+	Email    string
+	Role     string
+	Password string // Only set when generated by the import
+	Created  bool
+	Error    string
+}
+
+// ImportUsers creates users from a CSV of username,email,role,password rows.
+// A blank password is replaced by a generated one, a blank role defaults to 'user'.
+// Rows that fail validation are reported and skipped; the valid rows are created
+// in a single transaction. allowAdmin controls whether 'admin' rows are accepted.
+func (s *AuthService) ImportUsers(r io.Reader, allowAdmin bool) ([]UserImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	// Optional header row; line numbers in the results refer to the file
+	firstLine := 1
+	if len(records) > 0 && len(records[0]) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "username") {
+		records = records[1:]
+		firstLine = 2
+	}
+	if len(records) > maxUserImportRows {
+		return nil, ErrTooManyImportRows
+	}
+
+	// Validate rows and hash passwords before opening the write transaction;
+	// bcrypt is slow and would otherwise hold the database lock for the whole file
+	results := make([]UserImportResult, 0, len(records))
+	hashes := make([]string, len(records))
+	seen := map[string]bool{}
+	for i, record := range records {
+		field := func(n int) string {
+			if n < len(record) {
+				return strings.TrimSpace(record[n])
+			}
+			return ""
+		}
+
+		result := UserImportResult{
+			Line:     firstLine + i,
+			Username: field(0),
+			Email:    field(1),
+			Role:     field(2),
+		}
+		password := field(3)
+		if result.Role == "" {
+			result.Role = "user"
+		}
+
+		switch {
+		case result.Username == "":
+			result.Error = "username is required"
+		case result.Role != "user" && result.Role != "admin" && result.Role != GuestRole:
+			result.Error = "invalid role: " + result.Role
+		case result.Role == "admin" && !allowAdmin:
+			result.Error = "admin users cannot create other admin accounts"
+		case seen[strings.ToLower(result.Username)]:
+			result.Error = "duplicate username in file"
+		}
+		if result.Error != "" {
+			results = append(results, result)
+			continue
+		}
+		seen[strings.ToLower(result.Username)] = true
+
+		if password == "" {
+			if password, err = generateRandomID(16); err != nil {
+				return nil, err
+			}
+			result.Password = password
+		}
+		if hashes[i], err = s.HashPassword(password); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO users (username, password_hash, email, role, enabled, email_verified)
+		VALUES (?, ?, ?, ?, 1, 1)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var guests []int64
+	for i := range results {
+		result := &results[i]
+		if result.Error != "" {
+			continue
+		}
+
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE username = ?)", result.Username).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if exists {
+			result.Error = ErrUserExists.Error()
+			result.Password = ""
+			continue
+		}
+
+		res, err := stmt.Exec(result.Username, hashes[i], result.Email, result.Role)
+		if err != nil {
+			return nil, err
+		}
+		result.Created = true
+		if result.Role == GuestRole {
+			id, err := res.LastInsertId()
+			if err != nil {
+				return nil, err
+			}
+			guests = append(guests, id)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// Imported guests get the grants of the guest permission group
+	for _, id := range guests {
+		if err := s.syncGuestAccess(id); err != nil {
+			log.Printf("Failed to grant guest %d access to the guest permission group: %v", id, err)
+		}
+	}
+
+	return results, nil
+}
+
+// UserImportResultsCSV renders import results, including generated passwords, as CSV.
+// Cells are escaped since usernames and emails come straight from the uploaded file.
+func UserImportResultsCSV(results []UserImportResult) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"line", "username", "email", "role", "generated_password", "status", "error"})
+	for _, result := range results {
+		status := "created"
+		if !result.Created {
+			status = "failed"
+		}
+		w.Write(csvCells([]string{
+			strconv.Itoa(result.Line),
+			result.Username,
+			result.Email,
+			result.Role,
+			result.Password,
+			status,
+			result.Error,
+		}))
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportUsersPerRowOutcomes(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAuthService(db)
+	seedUser(t, db, "existing", "user", "existing@example.com", true)
+
+	csvData := strings.Join([]string{
+		"username,email,role,password",
+		"alice,alice@example.com,user,s3cret-pass",
+		"bob,bob@example.com,,",
+		"alice,alice2@example.com,user,",
+		"carol,carol@example.com,superuser,",
+		"dave,dave@example.com,admin,",
+		"existing,other@example.com,user,",
+		",nobody@example.com,user,",
+	}, "\n")
+
+	results, err := svc.ImportUsers(strings.NewReader(csvData), false)
+	if err != nil {
+		t.Fatalf("ImportUsers: %v", err)
+	}
+
+	want := []struct {
+		line     int
+		username string
+		created  bool
+		errPart  string
+	}{
+		{2, "alice", true, ""},
+		{3, "bob", true, ""},
+		{4, "alice", false, "duplicate username"},
+		{5, "carol", false, "invalid role"},
+		{6, "dave", false, "admin"},
+		{7, "existing", false, ErrUserExists.Error()},
+		{8, "", false, "username is required"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Line != w.line || r.Username != w.username || r.Created != w.created || !strings.Contains(r.Error, w.errPart) {
+			t.Errorf("row %d = %+v, want line %d %q created=%v error containing %q", i, r, w.line, w.username, w.created, w.errPart)
+		}
+		if !r.Created && r.Password != "" {
+			t.Errorf("row %d: failed row reports a password", i)
+		}
+	}
+
+	// Supplied passwords are not echoed back, blank ones are generated
+	if results[0].Password != "" {
+		t.Errorf("alice: supplied password echoed in results")
+	}
+	if results[1].Password == "" || results[1].Role != "user" {
+		t.Errorf("bob: password %q role %q, want generated password and default role", results[1].Password, results[1].Role)
+	}
+
+	for _, check := range []struct{ username, password string }{
+		{"alice", "s3cret-pass"},
+		{"bob", results[1].Password},
+	} {
+		var hash string
+		if err := db.QueryRow("SELECT password_hash FROM users WHERE username = ?", check.username).Scan(&hash); err != nil {
+			t.Fatalf("load %s: %v", check.username, err)
+		}
+		if err := svc.CheckPassword(check.password, hash); err != nil {
+			t.Errorf("%s: password does not match stored hash", check.username)
+		}
+	}
+
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM users WHERE username IN ('carol', 'dave')").Scan(&count)
+	if count != 0 {
+		t.Errorf("rejected rows created %d users", count)
+	}
+}
+
+func TestImportUsersAllowAdmin(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAuthService(db)
+
+	results, err := svc.ImportUsers(strings.NewReader("erin,erin@example.com,admin,\n"), true)
+	if err != nil {
+		t.Fatalf("ImportUsers: %v", err)
+	}
+	if len(results) != 1 || !results[0].Created || results[0].Line != 1 {
+		t.Fatalf("results = %+v, want one created admin on line 1", results)
+	}
+	var role string
+	db.QueryRow("SELECT role FROM users WHERE username = 'erin'").Scan(&role)
+	if role != "admin" {
+		t.Errorf("role = %q, want admin", role)
+	}
+}
+
+func TestImportUsersTooManyRows(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAuthService(db)
+
+	var b strings.Builder
+	for i := 0; i <= maxUserImportRows; i++ {
+		b.WriteString("user,,user,\n")
+	}
+	if _, err := svc.ImportUsers(strings.NewReader(b.String()), false); err != ErrTooManyImportRows {
+		t.Errorf("err = %v, want ErrTooManyImportRows", err)
+	}
+}
+
+func TestUserImportResultsCSVEscapesCells(t *testing.T) {
+	data, err := UserImportResultsCSV([]UserImportResult{
+		{Line: 2, Username: "=HYPERLINK(\"x\")", Email: "a@example.com", Role: "user", Password: "pw", Created: true},
+		{Line: 3, Username: "bob", Role: "user", Error: "duplicate username in file"},
+	})
+	if err != nil {
+		t.Fatalf("UserImportResultsCSV: %v", err)
+	}
+	out := string(data)
+	if !strings.HasPrefix(out, "line,username,email,role,generated_password,status,error\n") {
+		t.Errorf("missing header: %q", out)
+	}
+	if !strings.Contains(out, "'=HYPERLINK") {
+		t.Errorf("formula cell not escaped: %q", out)
+	}
+	if !strings.Contains(out, "3,bob,,user,,failed,duplicate username in file") {
+		t.Errorf("failed row missing: %q", out)
+	}
+}
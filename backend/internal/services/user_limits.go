@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+)
+
+// system_settings keys capping how many albums and shares a non-admin user may own.
+// A missing, empty or non-positive value means unlimited.
+const (
+	maxAlbumsPerUserKey = "max_albums_per_user"
+	maxSharesPerUserKey = "max_shares_per_user"
+)
+
+var (
+	ErrAlbumLimitReached = errors.New("album limit reached")
+	ErrShareLimitReached = errors.New("share limit reached")
+)
+
+// LimitUsage is a user's current count against a limit (0 = unlimited)
+type LimitUsage struct {
+	Count int `json:"count"`
+	Limit int `json:"limit"`
+}
+
+// Reached reports whether the user may not create another resource
+func (u LimitUsage) Reached() bool {
+	return u.Limit > 0 && u.Count >= u.Limit
+}
+
+// UserLimits holds a user's resource counts against the configured limits
+type UserLimits struct {
+	Albums LimitUsage `json:"albums"`
+	Shares LimitUsage `json:"shares"`
+}
+
+// getLimitSetting reads a per-user limit setting, treating invalid values as unlimited
+func (s *SettingsService) getLimitSetting(key string) (int, error) {
+	setting, err := s.GetSetting(key)
+	if err != nil {
+		return 0, err
+	}
+	if setting == nil {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(setting.Value)
+	if err != nil || limit < 0 {
+		return 0, nil
+	}
+	return limit, nil
+}
+
+// GetAlbumUsage returns how many albums the user owns and the configured limit
+func (s *SettingsService) GetAlbumUsage(userID int64) (LimitUsage, error) {
+	var usage LimitUsage
+	limit, err := s.getLimitSetting(maxAlbumsPerUserKey)
+	if err != nil {
+		return usage, err
+	}
+	usage.Limit = limit
+	err = s.db.QueryRow("SELECT COUNT(*) FROM albums_v2 WHERE owner_id = ?", userID).Scan(&usage.Count)
+	return usage, err
+}
+
+// GetShareUsage returns how many shares the user owns and the configured limit
+func (s *SettingsService) GetShareUsage(userID int64) (LimitUsage, error) {
+	var usage LimitUsage
+	limit, err := s.getLimitSetting(maxSharesPerUserKey)
+	if err != nil {
+		return usage, err
+	}
+	usage.Limit = limit
+	err = s.db.QueryRow("SELECT COUNT(*) FROM shares WHERE owner_id = ?", userID).Scan(&usage.Count)
+	return usage, err
+}
+
+// GetUserLimits returns the user's album and share counts against the configured limits
+func (s *SettingsService) GetUserLimits(userID int64) (*UserLimits, error) {
+	albums, err := s.GetAlbumUsage(userID)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := s.GetShareUsage(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &UserLimits{Albums: albums, Shares: shares}, nil
+}
+
+// CheckAlbumLimit returns ErrAlbumLimitReached if the user may not create another album
+func (s *SettingsService) CheckAlbumLimit(userID int64) error {
+	usage, err := s.GetAlbumUsage(userID)
+	if err != nil {
+		return err
+	}
+	if usage.Reached() {
+		return ErrAlbumLimitReached
+	}
+	return nil
+}
+
+// CheckShareLimit returns ErrShareLimitReached if the user may not create another share
+func (s *SettingsService) CheckShareLimit(userID int64) error {
+	usage, err := s.GetShareUsage(userID)
+	if err != nil {
+		return err
+	}
+	if usage.Reached() {
+		return ErrShareLimitReached
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLimitUsageReached(t *testing.T) {
+	cases := []struct {
+		usage LimitUsage
+		want  bool
+	}{
+		{LimitUsage{Count: 100, Limit: 0}, false},
+		{LimitUsage{Count: 2, Limit: 3}, false},
+		{LimitUsage{Count: 3, Limit: 3}, true},
+		{LimitUsage{Count: 4, Limit: 3}, true},
+	}
+	for _, tc := range cases {
+		if got := tc.usage.Reached(); got != tc.want {
+			t.Errorf("%+v.Reached() = %v, want %v", tc.usage, got, tc.want)
+		}
+	}
+}
+
+func TestUserLimits(t *testing.T) {
+	db := newTestDB(t)
+	s := NewSettingsService(db)
+	user := seedUser(t, db, "user", "user", "", false)
+	other := seedUser(t, db, "other", "user", "", false)
+	for _, owner := range []int64{user, user, other} {
+		mustExec(t, db, "INSERT INTO albums_v2 (name, description, owner_id) VALUES ('a', '', ?)", owner)
+	}
+	mustExec(t, db, "INSERT INTO shares (id, share_type, resource_id, owner_id, access_type) VALUES ('s1', 'file', 1, ?, 'public')", user)
+
+	// Unset limits are unlimited
+	limits, err := s.GetUserLimits(user)
+	if err != nil {
+		t.Fatalf("GetUserLimits: %v", err)
+	}
+	if *limits != (UserLimits{Albums: LimitUsage{Count: 2}, Shares: LimitUsage{Count: 1}}) {
+		t.Errorf("limits = %+v", limits)
+	}
+	if err := s.CheckAlbumLimit(user); err != nil {
+		t.Errorf("CheckAlbumLimit without a limit: %v", err)
+	}
+
+	// Invalid values are treated as unlimited too
+	for _, value := range []string{"", "lots", "-1"} {
+		s.SetSetting(maxAlbumsPerUserKey, value)
+		if usage, err := s.GetAlbumUsage(user); err != nil || usage.Limit != 0 {
+			t.Errorf("limit %q: usage %+v, err %v, want unlimited", value, usage, err)
+		}
+	}
+
+	s.SetSetting(maxAlbumsPerUserKey, "2")
+	s.SetSetting(maxSharesPerUserKey, "2")
+	if err := s.CheckAlbumLimit(user); err != ErrAlbumLimitReached {
+		t.Errorf("CheckAlbumLimit at the cap: err = %v, want ErrAlbumLimitReached", err)
+	}
+	if err := s.CheckAlbumLimit(other); err != nil {
+		t.Errorf("CheckAlbumLimit below the cap: %v", err)
+	}
+	if err := s.CheckShareLimit(user); err != nil {
+		t.Errorf("CheckShareLimit below the cap: %v", err)
+	}
+	s.SetSetting(maxSharesPerUserKey, "1")
+	if err := s.CheckShareLimit(user); err != ErrShareLimitReached {
+		t.Errorf("CheckShareLimit at the cap: err = %v, want ErrShareLimitReached", err)
+	}
+}
+
+func TestCreateAutoAlbumsLimit(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewAlbumService(db)
+	owner := seedUser(t, db, "owner", "user", "", false)
+	folder := seedFolder(t, db, "/photos", owner)
+	grantFolder(t, db, owner, folder, "read")
+	for _, path := range []string{"2022/a.jpg", "2023/b.jpg", "2024/c.jpg"} {
+		seedFile(t, db, folder, path, "image")
+	}
+	if _, err := svc.CreateAlbum("Existing", "", owner); err != nil {
+		t.Fatalf("create album: %v", err)
+	}
+
+	// Three more albums don't fit in a limit of three, so none are created
+	if _, err := svc.CreateAutoAlbums(folder, owner, false, 3); !errors.Is(err, ErrAlbumLimitReached) {
+		t.Fatalf("over the limit: err = %v, want ErrAlbumLimitReached", err)
+	}
+	albums, _ := svc.ListAlbums(owner)
+	if len(albums) != 1 {
+		t.Fatalf("owner has %d albums after a rejected batch, want 1", len(albums))
+	}
+
+	created, err := svc.CreateAutoAlbums(folder, owner, false, 4)
+	if err != nil || len(created) != 3 {
+		t.Fatalf("exactly at the limit: created %d, err %v", len(created), err)
+	}
+
+	// Nothing left to create never fails, even at the limit
+	if created, err := svc.CreateAutoAlbums(folder, owner, false, 4); err != nil || len(created) != 0 {
+		t.Errorf("rerun at the limit: created %d, err %v", len(created), err)
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxPreferenceValueLength is the maximum size in bytes of a single preference value
+const MaxPreferenceValueLength = 1024
+
+var (
+	ErrUnknownPreference      = errors.New("unknown preference key")
+	ErrPreferenceTooLarge     = errors.New("preference value too large")
+	ErrInvalidPreferenceValue = errors.New("invalid preference value")
+)
+
+// allowedPreferenceKeys lists the UI preferences users may store
+var allowedPreferenceKeys = map[string]bool{
+	"theme":            true,
+	"language":         true,
+	"grid_size":        true,
+	"sort_order":       true,
+	"sort_field":       true,
+	"view_mode":        true,
+	"thumbnail_mode":   true,
+	"timeline_group":   true,
+	"show_filenames":   true,
+	"hide_screenshots": true,
+	"share_digest":     true,
+}
+
+type UserPreferenceService struct {
+	db *sql.DB
+}
+
+func NewUserPreferenceService(db *sql.DB) *UserPreferenceService {
+	return &UserPreferenceService{db: db}
+}
+
+// GetPreferences returns all stored preferences for a user
+func (s *UserPreferenceService) GetPreferences(userID int64) (map[string]string, error) {
+	rows, err := s.db.Query("SELECT key, value FROM user_preferences WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		prefs[key] = value
+	}
+
+	return prefs, rows.Err()
+}
+
+// ValidatePreferences checks keys against the allow-list and enforces value size limits
+func ValidatePreferences(updates map[string]*string) error {
+	for key, value := range updates {
+		if !allowedPreferenceKeys[key] {
+			return fmt.Errorf("%w: %s", ErrUnknownPreference, key)
+		}
+		if value != nil && len(*value) > MaxPreferenceValueLength {
+			return fmt.Errorf("%w: %s", ErrPreferenceTooLarge, key)
+		}
+		if key == shareDigestPreferenceKey && value != nil && *value != "off" {
+			if _, ok := shareDigestPeriods[*value]; !ok {
+				return fmt.Errorf("%w: %s must be 'off', 'daily' or 'weekly'", ErrInvalidPreferenceValue, key)
+			}
+		}
+	}
+	return nil
+}
+
+// UpdatePreferences merges updates into a user's preferences.
+// Keys absent from updates are left untouched; a nil value removes the key.
+func (s *UserPreferenceService) UpdatePreferences(userID int64, updates map[string]*string) error {
+	if err := ValidatePreferences(updates); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	upsertStmt, err := tx.Prepare(`
+		INSERT INTO user_preferences (user_id, key, value, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`)
+	if err != nil {
+		return err
+	}
+	defer upsertStmt.Close()
+
+	deleteStmt, err := tx.Prepare("DELETE FROM user_preferences WHERE user_id = ? AND key = ?")
+	if err != nil {
+		return err
+	}
+	defer deleteStmt.Close()
+
+	now := time.Now()
+	for key, value := range updates {
+		if value == nil {
+			_, err = deleteStmt.Exec(userID, key)
+		} else {
+			_, err = upsertStmt.Exec(userID, key, *value, now)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestUserPreferencesRoundTrip(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewUserPreferenceService(db)
+
+	alice := seedUser(t, db, "alice", "user", "alice@example.com", true)
+	bob := seedUser(t, db, "bob", "user", "bob@example.com", true)
+
+	if err := svc.UpdatePreferences(alice, map[string]*string{"theme": strPtr("dark"), "grid_size": strPtr("large")}); err != nil {
+		t.Fatalf("update alice: %v", err)
+	}
+	if err := svc.UpdatePreferences(bob, map[string]*string{"theme": strPtr("light")}); err != nil {
+		t.Fatalf("update bob: %v", err)
+	}
+
+	// Merge: untouched keys stay, a nil value removes the key
+	if err := svc.UpdatePreferences(alice, map[string]*string{"sort_order": strPtr("desc"), "grid_size": nil}); err != nil {
+		t.Fatalf("merge alice: %v", err)
+	}
+
+	got, err := svc.GetPreferences(alice)
+	if err != nil {
+		t.Fatalf("get alice: %v", err)
+	}
+	if want := map[string]string{"theme": "dark", "sort_order": "desc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("alice: got %v, want %v", got, want)
+	}
+
+	got, err = svc.GetPreferences(bob)
+	if err != nil {
+		t.Fatalf("get bob: %v", err)
+	}
+	if want := map[string]string{"theme": "light"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bob: got %v, want %v", got, want)
+	}
+}
+
+func TestUserPreferencesValidation(t *testing.T) {
+	db := newTestDB(t)
+	svc := NewUserPreferenceService(db)
+	alice := seedUser(t, db, "alice", "user", "alice@example.com", true)
+
+	cases := []struct {
+		name    string
+		updates map[string]*string
+		want    error
+	}{
+		{"unknown key", map[string]*string{"theme": strPtr("dark"), "is_admin": strPtr("true")}, ErrUnknownPreference},
+		{"value too large", map[string]*string{"theme": strPtr(strings.Repeat("x", MaxPreferenceValueLength+1))}, ErrPreferenceTooLarge},
+		{"value at limit", map[string]*string{"theme": strPtr(strings.Repeat("x", MaxPreferenceValueLength))}, nil},
+		{"delete unknown key", map[string]*string{"bogus": nil}, ErrUnknownPreference},
+	}
+	for _, tc := range cases {
+		if err := svc.UpdatePreferences(alice, tc.updates); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// A rejected update stores nothing, not even its valid keys
+	got, _ := svc.GetPreferences(alice)
+	if _, ok := got["is_admin"]; ok || len(got) != 1 {
+		t.Errorf("unexpected preferences after rejected updates: %v", got)
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowStat makes every existence check take delay and records the most checks in flight
+func slowStat(t *testing.T, delay time.Duration) func() int {
+	t.Helper()
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	statFile = func(path string) (os.FileInfo, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > peak {
+			peak = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(delay)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return os.Stat(path)
+	}
+	t.Cleanup(func() { statFile = os.Stat })
+	return func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return peak
+	}
+}
+
+// seedValidationFiles indexes count files in a new folder, leaving the ones in missing off
+// disk, and returns the folder root
+func seedValidationFiles(t *testing.T, s *FileValidatorService, count int, missing map[int]bool) string {
+	t.Helper()
+	owner := seedUser(t, s.db, "owner", "user", "", false)
+	root := t.TempDir()
+	folder := seedFolder(t, s.db, root, owner)
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("%02d.jpg", i)
+		if !missing[i] {
+			writeDiskFile(t, root, name, []byte("x"))
+		}
+		seedFile(t, s.db, folder, name, "image")
+	}
+	return root
+}
+
+func TestValidationChecksFilesConcurrently(t *testing.T) {
+	db := newTestDB(t)
+	validator := NewFileValidatorService(db, NewFolderService(db), nil, nil)
+	validator.SetValidationLimits(4, 0)
+	seedValidationFiles(t, validator, 8, map[int]bool{1: true, 4: true, 6: true})
+	peak := slowStat(t, 20*time.Millisecond)
+
+	result, err := validator.CleanupAllInvalidFiles(context.Background())
+	if err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if result.Deleted != 3 || result.Partial {
+		t.Errorf("deleted %d (partial %t), want 3 in one full run", result.Deleted, result.Partial)
+	}
+	if p := peak(); p < 2 || p > 4 {
+		t.Errorf("%d checks ran at once, want between 2 and the 4 workers", p)
+	}
+}
+
+func TestValidationRunTimeLimit(t *testing.T) {
+	db := newTestDB(t)
+	validator := NewFileValidatorService(db, NewFolderService(db), nil, nil)
+	validator.SetValidationLimits(1, 50*time.Millisecond)
+	root := seedValidationFiles(t, validator, 10, map[int]bool{2: true, 9: true})
+	slowStat(t, 20*time.Millisecond)
+
+	runs, deleted := 0, 0
+	for {
+		runs++
+		if runs > 10 {
+			t.Fatal("validation never completed a full pass")
+		}
+		start := time.Now()
+		result, err := validator.CleanupAllInvalidFiles(context.Background())
+		if err != nil {
+			t.Fatalf("run %d: %v", runs, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("run %d took %s despite the 50ms limit", runs, elapsed)
+		}
+		deleted += result.Deleted
+		if !result.Partial {
+			break
+		}
+	}
+	if runs < 2 {
+		t.Errorf("finished in %d run, want the limit to split the pass", runs)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted %d files across %d runs, want 2", deleted, runs)
+	}
+
+	// A completed pass starts over from the first file
+	if err := os.Remove(filepath.Join(root, "00.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	validator.SetValidationLimits(1, 0)
+	result, err := validator.CleanupAllInvalidFiles(context.Background())
+	if err != nil || result.Partial || result.Deleted != 1 {
+		t.Errorf("run after a full pass: %+v, %v; want the first file removed", result, err)
+	}
+}
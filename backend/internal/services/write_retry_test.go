@@ -0,0 +1,92 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+
+	"awesome-sharing/internal/database"
+)
+
+// flakyExecer fails the first failures calls with err, then runs the statement on db
+type flakyExecer struct {
+	db       *sql.DB
+	err      error
+	failures int
+	calls    int
+}
+
+func (e *flakyExecer) Exec(query string, args ...interface{}) (sql.Result, error) {
+	e.calls++
+	if e.calls <= e.failures {
+		return nil, e.err
+	}
+	return e.db.Exec(query, args...)
+}
+
+func TestExecWithRetry(t *testing.T) {
+	busy := sqlite3.Error{Code: sqlite3.ErrBusy}
+	locked := sqlite3.Error{Code: sqlite3.ErrLocked}
+	other := errors.New("constraint failed")
+
+	cases := []struct {
+		name      string
+		err       error
+		failures  int
+		wantCalls int
+		wantErr   error
+	}{
+		{"no contention", busy, 0, 1, nil},
+		{"busy then succeeds", busy, 2, 3, nil},
+		{"locked then succeeds", locked, 1, 2, nil},
+		{"wrapped busy error", fmt.Errorf("insert: %w", busy), 1, 2, nil},
+		{"other errors are not retried", other, 1, 1, other},
+		{"gives up after five attempts", busy, 10, 5, busy},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestDB(t)
+			execer := &flakyExecer{db: db, err: tc.err, failures: tc.failures}
+
+			_, err := database.ExecWithRetry(execer, "INSERT INTO tags (name) VALUES (?)", "retried")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+			if execer.calls != tc.wantCalls {
+				t.Errorf("%d calls, want %d", execer.calls, tc.wantCalls)
+			}
+
+			var count int
+			db.QueryRow("SELECT COUNT(*) FROM tags WHERE name = 'retried'").Scan(&count)
+			want := 0
+			if tc.wantErr == nil {
+				want = 1
+			}
+			if count != want {
+				t.Errorf("%d rows written, want %d", count, want)
+			}
+		})
+	}
+}
+
+func TestIsBusyError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{sqlite3.Error{Code: sqlite3.ErrBusy}, true},
+		{sqlite3.Error{Code: sqlite3.ErrLocked}, true},
+		{fmt.Errorf("scan: %w", sqlite3.Error{Code: sqlite3.ErrBusy}), true},
+		{sqlite3.Error{Code: sqlite3.ErrConstraint}, false},
+		{sql.ErrNoRows, false},
+		{nil, false},
+	}
+	for _, tc := range cases {
+		if got := database.IsBusyError(tc.err); got != tc.want {
+			t.Errorf("IsBusyError(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
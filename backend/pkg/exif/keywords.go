@@ -0,0 +1,162 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"os"
+	"strings"
+)
+
+// keywordScanLimit bounds how much of a file is searched for XMP/IPTC blocks.
+// Both are stored near the start of JPEG and TIFF files.
+const keywordScanLimit = 4 << 20
+
+// maxKeywordLength drops oversized values that are unlikely to be real keywords
+const maxKeywordLength = 100
+
+// ExtractKeywords returns the embedded keywords of an image, read from the
+// XMP dc:subject list and the IPTC Keywords dataset. Duplicates are removed
+// case-insensitively. A file without keywords returns an empty slice.
+func ExtractKeywords(filePath string) ([]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, keywordScanLimit))
+	if err != nil {
+		return nil, err
+	}
+
+	var keywords []string
+	seen := map[string]bool{}
+	add := func(keyword string) {
+		keyword = strings.TrimSpace(keyword)
+		if keyword == "" || len(keyword) > maxKeywordLength {
+			return
+		}
+		key := strings.ToLower(keyword)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		keywords = append(keywords, keyword)
+	}
+
+	for _, keyword := range xmpKeywords(data) {
+		add(keyword)
+	}
+	for _, keyword := range iptcKeywords(data) {
+		add(keyword)
+	}
+
+	return keywords, nil
+}
+
+// xmpKeywords reads the rdf:li entries of dc:subject from the first XMP packet
+func xmpKeywords(data []byte) []string {
+	start := bytes.Index(data, []byte("<x:xmpmeta"))
+	if start < 0 {
+		return nil
+	}
+	end := bytes.Index(data[start:], []byte("</x:xmpmeta>"))
+	if end < 0 {
+		return nil
+	}
+	packet := data[start : start+end+len("</x:xmpmeta>")]
+
+	var keywords []string
+	decoder := xml.NewDecoder(bytes.NewReader(packet))
+	inSubject, inItem := false, false
+	var item strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			// Malformed XMP just yields whatever was read so far
+			return keywords
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "subject" && t.Name.Space == "http://purl.org/dc/elements/1.1/" {
+				inSubject = true
+			} else if inSubject && t.Name.Local == "li" {
+				inItem = true
+				item.Reset()
+			}
+		case xml.CharData:
+			if inItem {
+				item.Write(t)
+			}
+		case xml.EndElement:
+			if inItem && t.Name.Local == "li" {
+				keywords = append(keywords, item.String())
+				inItem = false
+			} else if inSubject && t.Name.Local == "subject" {
+				inSubject = false
+			}
+		}
+	}
+}
+
+// iptcKeywords reads the Keywords dataset (2:25) from the IPTC block inside
+// a Photoshop image resource (APP13 "Photoshop 3.0", resource 0x0404)
+func iptcKeywords(data []byte) []string {
+	start := bytes.Index(data, []byte("Photoshop 3.0\x00"))
+	if start < 0 {
+		return nil
+	}
+	resources := data[start+len("Photoshop 3.0\x00"):]
+
+	for len(resources) >= 12 && string(resources[:4]) == "8BIM" {
+		id := binary.BigEndian.Uint16(resources[4:6])
+
+		// Pascal string name, padded to an even length including the length byte
+		nameLen := int(resources[6]) + 1
+		if nameLen%2 != 0 {
+			nameLen++
+		}
+		offset := 6 + nameLen
+		if len(resources) < offset+4 {
+			return nil
+		}
+		size := int(binary.BigEndian.Uint32(resources[offset : offset+4]))
+		offset += 4
+		if size < 0 || len(resources) < offset+size {
+			return nil
+		}
+
+		if id == 0x0404 {
+			return iptcDatasets(resources[offset : offset+size])
+		}
+
+		if size%2 != 0 {
+			size++
+		}
+		if len(resources) < offset+size {
+			return nil
+		}
+		resources = resources[offset+size:]
+	}
+	return nil
+}
+
+// iptcDatasets collects the values of all 2:25 datasets in an IPTC-IIM block
+func iptcDatasets(block []byte) []string {
+	var keywords []string
+	for len(block) >= 5 && block[0] == 0x1c {
+		record, dataset := block[1], block[2]
+		size := int(binary.BigEndian.Uint16(block[3:5]))
+		// Extended datasets (high bit set) are not used for keywords
+		if size&0x8000 != 0 || len(block) < 5+size {
+			break
+		}
+		if record == 2 && dataset == 25 {
+			keywords = append(keywords, string(block[5:5+size]))
+		}
+		block = block[5+size:]
+	}
+	return keywords
+}
@@ -1,3 +1,4 @@
+import './services/csrf'
 import React from 'react'
 import ReactDOM from 'react-dom/client'
 import App from './App'
@@ -15,7 +15,8 @@ export default function AlbumDetail() {
   const [album, setAlbum] = useState<Album | null>(null)
   const [files, setFiles] = useState<File[]>([])
   const [loading, setLoading] = useState(true)
-  const [sortOrder, setSortOrder] = useState<string>('taken_at DESC')
+  // Empty until the user picks an order, so the album's default sort applies
+  const [sortOrder, setSortOrder] = useState<string>('')
   const [showEditDialog, setShowEditDialog] = useState(false)
   const [selectedFileIds, setSelectedFileIds] = useState<number[]>([])
 
@@ -128,6 +129,7 @@ export default function AlbumDetail() {
           onChange={(e) => handleSortChange(e.target.value)}
           className="px-3 py-1.5 border rounded-lg dark:bg-gray-800 dark:border-gray-700"
         >
+          <option value="">{t('album.sortByDefault')}</option>
           <option value="taken_at DESC">{t('album.sortByDateDesc')}</option>
           <option value="taken_at ASC">{t('album.sortByDateAsc')}</option>
           <option value="filename ASC">{t('album.sortByNameAsc')}</option>
@@ -74,7 +74,19 @@ export default function FolderManagement() {
     if (!confirm(t('folderManagement.confirmDelete'))) return
 
     try {
-      await folderService.deleteFolder(folderId)
+      try {
+        await folderService.deleteFolder(folderId)
+      } catch (err: any) {
+        // The folder still has mappings, album rules or group links: confirm the cascade
+        const preview = err.response?.status === 409 ? err.response.data?.preview : null
+        if (!preview) throw err
+        if (!confirm(t('folderManagement.confirmForceDelete', {
+          mappings: preview.mappings,
+          albums: preview.albums?.length ?? 0,
+          groups: preview.permission_groups,
+        }))) return
+        await folderService.deleteFolder(folderId, true)
+      }
       if (selectedFolder?.id === folderId) {
         setSelectedFolder(null)
       }
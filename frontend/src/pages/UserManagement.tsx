@@ -382,6 +382,7 @@ export default function UserManagement() {
               <option value="server_owner">Server Owner</option>
               <option value="admin">Administrator</option>
               <option value="user">User</option>
+              <option value="guest">Guest</option>
             </select>
           </div>
 
@@ -491,10 +492,12 @@ export default function UserManagement() {
                         <span className={`px-2 py-1 text-xs rounded-full ${
                           user.role === 'server_owner' ? 'bg-purple-100 text-purple-700' :
                           user.role === 'admin' ? 'bg-blue-100 text-blue-700' :
+                          user.role === 'guest' ? 'bg-green-100 text-green-700' :
                           'bg-gray-100 text-gray-700'
                         }`}>
                           {user.role === 'server_owner' ? 'Server Owner' :
-                           user.role === 'admin' ? 'Admin' : 'User'}
+                           user.role === 'admin' ? 'Admin' :
+                           user.role === 'guest' ? 'Guest' : 'User'}
                         </span>
                       </td>
                       <td className="px-4 py-3">
@@ -635,6 +638,7 @@ export default function UserManagement() {
             >
               <option value="admin">Administrator</option>
               <option value="user">User</option>
+              <option value="guest">Guest</option>
             </select>
             <p className="text-xs text-gray-500 mt-1">
               Note: Server owner accounts can only be created during system initialization
@@ -708,6 +712,7 @@ export default function UserManagement() {
             >
               <option value="admin">Administrator</option>
               <option value="user">User</option>
+              <option value="guest">Guest</option>
             </select>
             <p className="text-xs text-gray-500 mt-1">
               Note: Server owner role cannot be assigned or modified
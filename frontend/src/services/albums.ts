@@ -65,10 +65,10 @@ export const albumService = {
   deleteAlbum: (id: number) =>
     api.delete<{ message: string }>(`/albums-v2/${id}`),
 
-  // List album items (files) - now returns files directly with optional sort parameter
-  listAlbumItems: (id: number, sort: string = 'taken_at DESC') =>
+  // List album items (files) - now returns files directly; without sort the album's default order applies
+  listAlbumItems: (id: number, sort?: string) =>
     api.get<{ files: any[], total: number }>(`/albums-v2/${id}/items`, {
-      params: { sort }
+      params: sort ? { sort } : undefined
     }),
 
   // List folder configurations
@@ -10,7 +10,7 @@ export interface User {
   id: number
   username: string
   email: string
-  role: 'server_owner' | 'admin' | 'user'
+  role: 'server_owner' | 'admin' | 'user' | 'guest'
   created_at: string
 }
 
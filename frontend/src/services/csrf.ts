@@ -0,0 +1,6 @@
+import axios from 'axios'
+
+// Echo the CSRF cookie on mutating requests (double-submit protection).
+// Imported before any axios instance is created so they all inherit it.
+axios.defaults.xsrfCookieName = 'csrf_token'
+axios.defaults.xsrfHeaderName = 'X-CSRF-Token'
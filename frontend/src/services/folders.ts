@@ -51,9 +51,9 @@ export const folderService = {
     return response.data;
   },
 
-  // Delete a folder
-  deleteFolder: async (id: number): Promise<{ message: string }> => {
-    const response = await api.delete(`/folders/${id}`);
+  // Delete a folder; without force the server refuses (409) folders that still have dependencies
+  deleteFolder: async (id: number, force = false): Promise<{ message: string }> => {
+    const response = await api.delete(`/folders/${id}`, { params: force ? { force: true } : undefined });
     return response.data;
   },
 
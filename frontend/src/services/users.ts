@@ -4,7 +4,7 @@ export interface User {
   id: number
   username: string
   email: string
-  role: 'server_owner' | 'admin' | 'user'
+  role: 'server_owner' | 'admin' | 'user' | 'guest'
   enabled: boolean
   created_at: string
   updated_at: string
@@ -8,7 +8,6 @@ export interface File {
   taken_at: string
   created_at: string
   updated_at: string
-  absolute_path?: string  // Computed from folder + relative_path
   thumbnail_url?: string
 }
 